@@ -230,16 +230,17 @@ func (x *BalanceResponse) GetAvailableCents() int64 {
 }
 
 type GrantRequest struct {
-	state            protoimpl.MessageState `protogen:"open.v1"`
-	UserId           string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
-	AmountCents      int64                  `protobuf:"varint,2,opt,name=amount_cents,json=amountCents,proto3" json:"amount_cents,omitempty"`
-	IdempotencyKey   string                 `protobuf:"bytes,3,opt,name=idempotency_key,json=idempotencyKey,proto3" json:"idempotency_key,omitempty"`
-	ExpiresAtUnixUtc int64                  `protobuf:"varint,4,opt,name=expires_at_unix_utc,json=expiresAtUnixUtc,proto3" json:"expires_at_unix_utc,omitempty"`
-	MetadataJson     string                 `protobuf:"bytes,5,opt,name=metadata_json,json=metadataJson,proto3" json:"metadata_json,omitempty"`
-	LedgerId         string                 `protobuf:"bytes,6,opt,name=ledger_id,json=ledgerId,proto3" json:"ledger_id,omitempty"`
-	TenantId         string                 `protobuf:"bytes,7,opt,name=tenant_id,json=tenantId,proto3" json:"tenant_id,omitempty"`
-	unknownFields    protoimpl.UnknownFields
-	sizeCache        protoimpl.SizeCache
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	UserId             string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	AmountCents        int64                  `protobuf:"varint,2,opt,name=amount_cents,json=amountCents,proto3" json:"amount_cents,omitempty"`
+	IdempotencyKey     string                 `protobuf:"bytes,3,opt,name=idempotency_key,json=idempotencyKey,proto3" json:"idempotency_key,omitempty"`
+	ExpiresAtUnixUtc   int64                  `protobuf:"varint,4,opt,name=expires_at_unix_utc,json=expiresAtUnixUtc,proto3" json:"expires_at_unix_utc,omitempty"`
+	MetadataJson       string                 `protobuf:"bytes,5,opt,name=metadata_json,json=metadataJson,proto3" json:"metadata_json,omitempty"`
+	LedgerId           string                 `protobuf:"bytes,6,opt,name=ledger_id,json=ledgerId,proto3" json:"ledger_id,omitempty"`
+	TenantId           string                 `protobuf:"bytes,7,opt,name=tenant_id,json=tenantId,proto3" json:"tenant_id,omitempty"`
+	EffectiveAtUnixUtc int64                  `protobuf:"varint,8,opt,name=effective_at_unix_utc,json=effectiveAtUnixUtc,proto3" json:"effective_at_unix_utc,omitempty"`
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
 }
 
 func (x *GrantRequest) Reset() {
@@ -321,6 +322,127 @@ func (x *GrantRequest) GetTenantId() string {
 	return ""
 }
 
+func (x *GrantRequest) GetEffectiveAtUnixUtc() int64 {
+	if x != nil {
+		return x.EffectiveAtUnixUtc
+	}
+	return 0
+}
+
+type CancelGrantRequest struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	UserId   string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	LedgerId string                 `protobuf:"bytes,2,opt,name=ledger_id,json=ledgerId,proto3" json:"ledger_id,omitempty"`
+	TenantId string                 `protobuf:"bytes,3,opt,name=tenant_id,json=tenantId,proto3" json:"tenant_id,omitempty"`
+	// Types that are valid to be assigned to Grant:
+	//
+	//	*CancelGrantRequest_GrantEntryId
+	//	*CancelGrantRequest_GrantIdempotencyKey
+	Grant         isCancelGrantRequest_Grant `protobuf_oneof:"grant"`
+	MetadataJson  string                     `protobuf:"bytes,6,opt,name=metadata_json,json=metadataJson,proto3" json:"metadata_json,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CancelGrantRequest) Reset() {
+	*x = CancelGrantRequest{}
+	mi := &file_api_credit_v1_credit_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CancelGrantRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CancelGrantRequest) ProtoMessage() {}
+
+func (x *CancelGrantRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_credit_v1_credit_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CancelGrantRequest.ProtoReflect.Descriptor instead.
+func (*CancelGrantRequest) Descriptor() ([]byte, []int) {
+	return file_api_credit_v1_credit_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *CancelGrantRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *CancelGrantRequest) GetLedgerId() string {
+	if x != nil {
+		return x.LedgerId
+	}
+	return ""
+}
+
+func (x *CancelGrantRequest) GetTenantId() string {
+	if x != nil {
+		return x.TenantId
+	}
+	return ""
+}
+
+func (x *CancelGrantRequest) GetGrant() isCancelGrantRequest_Grant {
+	if x != nil {
+		return x.Grant
+	}
+	return nil
+}
+
+func (x *CancelGrantRequest) GetGrantEntryId() string {
+	if x != nil {
+		if x, ok := x.Grant.(*CancelGrantRequest_GrantEntryId); ok {
+			return x.GrantEntryId
+		}
+	}
+	return ""
+}
+
+func (x *CancelGrantRequest) GetGrantIdempotencyKey() string {
+	if x != nil {
+		if x, ok := x.Grant.(*CancelGrantRequest_GrantIdempotencyKey); ok {
+			return x.GrantIdempotencyKey
+		}
+	}
+	return ""
+}
+
+func (x *CancelGrantRequest) GetMetadataJson() string {
+	if x != nil {
+		return x.MetadataJson
+	}
+	return ""
+}
+
+type isCancelGrantRequest_Grant interface {
+	isCancelGrantRequest_Grant()
+}
+
+type CancelGrantRequest_GrantEntryId struct {
+	GrantEntryId string `protobuf:"bytes,4,opt,name=grant_entry_id,json=grantEntryId,proto3,oneof"`
+}
+
+type CancelGrantRequest_GrantIdempotencyKey struct {
+	GrantIdempotencyKey string `protobuf:"bytes,5,opt,name=grant_idempotency_key,json=grantIdempotencyKey,proto3,oneof"`
+}
+
+func (*CancelGrantRequest_GrantEntryId) isCancelGrantRequest_Grant() {}
+
+func (*CancelGrantRequest_GrantIdempotencyKey) isCancelGrantRequest_Grant() {}
+
 type ReserveRequest struct {
 	state            protoimpl.MessageState `protogen:"open.v1"`
 	UserId           string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
@@ -337,7 +459,7 @@ type ReserveRequest struct {
 
 func (x *ReserveRequest) Reset() {
 	*x = ReserveRequest{}
-	mi := &file_api_credit_v1_credit_proto_msgTypes[5]
+	mi := &file_api_credit_v1_credit_proto_msgTypes[6]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -349,7 +471,7 @@ func (x *ReserveRequest) String() string {
 func (*ReserveRequest) ProtoMessage() {}
 
 func (x *ReserveRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_api_credit_v1_credit_proto_msgTypes[5]
+	mi := &file_api_credit_v1_credit_proto_msgTypes[6]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -362,7 +484,7 @@ func (x *ReserveRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ReserveRequest.ProtoReflect.Descriptor instead.
 func (*ReserveRequest) Descriptor() ([]byte, []int) {
-	return file_api_credit_v1_credit_proto_rawDescGZIP(), []int{5}
+	return file_api_credit_v1_credit_proto_rawDescGZIP(), []int{6}
 }
 
 func (x *ReserveRequest) GetUserId() string {
@@ -436,7 +558,7 @@ type CaptureRequest struct {
 
 func (x *CaptureRequest) Reset() {
 	*x = CaptureRequest{}
-	mi := &file_api_credit_v1_credit_proto_msgTypes[6]
+	mi := &file_api_credit_v1_credit_proto_msgTypes[7]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -448,7 +570,7 @@ func (x *CaptureRequest) String() string {
 func (*CaptureRequest) ProtoMessage() {}
 
 func (x *CaptureRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_api_credit_v1_credit_proto_msgTypes[6]
+	mi := &file_api_credit_v1_credit_proto_msgTypes[7]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -461,7 +583,7 @@ func (x *CaptureRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use CaptureRequest.ProtoReflect.Descriptor instead.
 func (*CaptureRequest) Descriptor() ([]byte, []int) {
-	return file_api_credit_v1_credit_proto_rawDescGZIP(), []int{6}
+	return file_api_credit_v1_credit_proto_rawDescGZIP(), []int{7}
 }
 
 func (x *CaptureRequest) GetUserId() string {
@@ -527,7 +649,7 @@ type ReleaseRequest struct {
 
 func (x *ReleaseRequest) Reset() {
 	*x = ReleaseRequest{}
-	mi := &file_api_credit_v1_credit_proto_msgTypes[7]
+	mi := &file_api_credit_v1_credit_proto_msgTypes[8]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -539,7 +661,7 @@ func (x *ReleaseRequest) String() string {
 func (*ReleaseRequest) ProtoMessage() {}
 
 func (x *ReleaseRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_api_credit_v1_credit_proto_msgTypes[7]
+	mi := &file_api_credit_v1_credit_proto_msgTypes[8]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -552,7 +674,7 @@ func (x *ReleaseRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ReleaseRequest.ProtoReflect.Descriptor instead.
 func (*ReleaseRequest) Descriptor() ([]byte, []int) {
-	return file_api_credit_v1_credit_proto_rawDescGZIP(), []int{7}
+	return file_api_credit_v1_credit_proto_rawDescGZIP(), []int{8}
 }
 
 func (x *ReleaseRequest) GetUserId() string {
@@ -611,7 +733,7 @@ type SpendRequest struct {
 
 func (x *SpendRequest) Reset() {
 	*x = SpendRequest{}
-	mi := &file_api_credit_v1_credit_proto_msgTypes[8]
+	mi := &file_api_credit_v1_credit_proto_msgTypes[9]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -623,7 +745,7 @@ func (x *SpendRequest) String() string {
 func (*SpendRequest) ProtoMessage() {}
 
 func (x *SpendRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_api_credit_v1_credit_proto_msgTypes[8]
+	mi := &file_api_credit_v1_credit_proto_msgTypes[9]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -636,7 +758,7 @@ func (x *SpendRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SpendRequest.ProtoReflect.Descriptor instead.
 func (*SpendRequest) Descriptor() ([]byte, []int) {
-	return file_api_credit_v1_credit_proto_rawDescGZIP(), []int{8}
+	return file_api_credit_v1_credit_proto_rawDescGZIP(), []int{9}
 }
 
 func (x *SpendRequest) GetUserId() string {
@@ -700,7 +822,7 @@ type RefundRequest struct {
 
 func (x *RefundRequest) Reset() {
 	*x = RefundRequest{}
-	mi := &file_api_credit_v1_credit_proto_msgTypes[9]
+	mi := &file_api_credit_v1_credit_proto_msgTypes[10]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -712,7 +834,7 @@ func (x *RefundRequest) String() string {
 func (*RefundRequest) ProtoMessage() {}
 
 func (x *RefundRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_api_credit_v1_credit_proto_msgTypes[9]
+	mi := &file_api_credit_v1_credit_proto_msgTypes[10]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -725,7 +847,7 @@ func (x *RefundRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use RefundRequest.ProtoReflect.Descriptor instead.
 func (*RefundRequest) Descriptor() ([]byte, []int) {
-	return file_api_credit_v1_credit_proto_rawDescGZIP(), []int{9}
+	return file_api_credit_v1_credit_proto_rawDescGZIP(), []int{10}
 }
 
 func (x *RefundRequest) GetUserId() string {
@@ -811,29 +933,33 @@ func (*RefundRequest_OriginalEntryId) isRefundRequest_Original() {}
 
 func (*RefundRequest_OriginalIdempotencyKey) isRefundRequest_Original() {}
 
-type RefundResponse struct {
+type SpendAcrossLedgersRequest struct {
 	state          protoimpl.MessageState `protogen:"open.v1"`
-	EntryId        string                 `protobuf:"bytes,1,opt,name=entry_id,json=entryId,proto3" json:"entry_id,omitempty"`
-	CreatedUnixUtc int64                  `protobuf:"varint,2,opt,name=created_unix_utc,json=createdUnixUtc,proto3" json:"created_unix_utc,omitempty"`
+	UserId         string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	LedgerIds      []string               `protobuf:"bytes,2,rep,name=ledger_ids,json=ledgerIds,proto3" json:"ledger_ids,omitempty"`
+	TenantId       string                 `protobuf:"bytes,3,opt,name=tenant_id,json=tenantId,proto3" json:"tenant_id,omitempty"`
+	AmountCents    int64                  `protobuf:"varint,4,opt,name=amount_cents,json=amountCents,proto3" json:"amount_cents,omitempty"`
+	IdempotencyKey string                 `protobuf:"bytes,5,opt,name=idempotency_key,json=idempotencyKey,proto3" json:"idempotency_key,omitempty"`
+	MetadataJson   string                 `protobuf:"bytes,6,opt,name=metadata_json,json=metadataJson,proto3" json:"metadata_json,omitempty"`
 	unknownFields  protoimpl.UnknownFields
 	sizeCache      protoimpl.SizeCache
 }
 
-func (x *RefundResponse) Reset() {
-	*x = RefundResponse{}
-	mi := &file_api_credit_v1_credit_proto_msgTypes[10]
+func (x *SpendAcrossLedgersRequest) Reset() {
+	*x = SpendAcrossLedgersRequest{}
+	mi := &file_api_credit_v1_credit_proto_msgTypes[11]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *RefundResponse) String() string {
+func (x *SpendAcrossLedgersRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*RefundResponse) ProtoMessage() {}
+func (*SpendAcrossLedgersRequest) ProtoMessage() {}
 
-func (x *RefundResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_api_credit_v1_credit_proto_msgTypes[10]
+func (x *SpendAcrossLedgersRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_credit_v1_credit_proto_msgTypes[11]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -844,56 +970,78 @@ func (x *RefundResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use RefundResponse.ProtoReflect.Descriptor instead.
-func (*RefundResponse) Descriptor() ([]byte, []int) {
-	return file_api_credit_v1_credit_proto_rawDescGZIP(), []int{10}
+// Deprecated: Use SpendAcrossLedgersRequest.ProtoReflect.Descriptor instead.
+func (*SpendAcrossLedgersRequest) Descriptor() ([]byte, []int) {
+	return file_api_credit_v1_credit_proto_rawDescGZIP(), []int{11}
 }
 
-func (x *RefundResponse) GetEntryId() string {
+func (x *SpendAcrossLedgersRequest) GetUserId() string {
 	if x != nil {
-		return x.EntryId
+		return x.UserId
 	}
 	return ""
 }
 
-func (x *RefundResponse) GetCreatedUnixUtc() int64 {
+func (x *SpendAcrossLedgersRequest) GetLedgerIds() []string {
 	if x != nil {
-		return x.CreatedUnixUtc
+		return x.LedgerIds
+	}
+	return nil
+}
+
+func (x *SpendAcrossLedgersRequest) GetTenantId() string {
+	if x != nil {
+		return x.TenantId
+	}
+	return ""
+}
+
+func (x *SpendAcrossLedgersRequest) GetAmountCents() int64 {
+	if x != nil {
+		return x.AmountCents
 	}
 	return 0
 }
 
-type Entry struct {
-	state            protoimpl.MessageState `protogen:"open.v1"`
-	EntryId          string                 `protobuf:"bytes,1,opt,name=entry_id,json=entryId,proto3" json:"entry_id,omitempty"`
-	AccountId        string                 `protobuf:"bytes,2,opt,name=account_id,json=accountId,proto3" json:"account_id,omitempty"`
-	Type             string                 `protobuf:"bytes,3,opt,name=type,proto3" json:"type,omitempty"`
-	AmountCents      int64                  `protobuf:"varint,4,opt,name=amount_cents,json=amountCents,proto3" json:"amount_cents,omitempty"`
-	ReservationId    string                 `protobuf:"bytes,5,opt,name=reservation_id,json=reservationId,proto3" json:"reservation_id,omitempty"`
-	IdempotencyKey   string                 `protobuf:"bytes,6,opt,name=idempotency_key,json=idempotencyKey,proto3" json:"idempotency_key,omitempty"`
-	ExpiresAtUnixUtc int64                  `protobuf:"varint,7,opt,name=expires_at_unix_utc,json=expiresAtUnixUtc,proto3" json:"expires_at_unix_utc,omitempty"`
-	MetadataJson     string                 `protobuf:"bytes,8,opt,name=metadata_json,json=metadataJson,proto3" json:"metadata_json,omitempty"`
-	CreatedUnixUtc   int64                  `protobuf:"varint,9,opt,name=created_unix_utc,json=createdUnixUtc,proto3" json:"created_unix_utc,omitempty"`
-	RefundOfEntryId  string                 `protobuf:"bytes,10,opt,name=refund_of_entry_id,json=refundOfEntryId,proto3" json:"refund_of_entry_id,omitempty"`
-	unknownFields    protoimpl.UnknownFields
-	sizeCache        protoimpl.SizeCache
+func (x *SpendAcrossLedgersRequest) GetIdempotencyKey() string {
+	if x != nil {
+		return x.IdempotencyKey
+	}
+	return ""
 }
 
-func (x *Entry) Reset() {
-	*x = Entry{}
-	mi := &file_api_credit_v1_credit_proto_msgTypes[11]
+func (x *SpendAcrossLedgersRequest) GetMetadataJson() string {
+	if x != nil {
+		return x.MetadataJson
+	}
+	return ""
+}
+
+type LedgerDebit struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	LedgerId       string                 `protobuf:"bytes,1,opt,name=ledger_id,json=ledgerId,proto3" json:"ledger_id,omitempty"`
+	EntryId        string                 `protobuf:"bytes,2,opt,name=entry_id,json=entryId,proto3" json:"entry_id,omitempty"`
+	AmountCents    int64                  `protobuf:"varint,3,opt,name=amount_cents,json=amountCents,proto3" json:"amount_cents,omitempty"`
+	CreatedUnixUtc int64                  `protobuf:"varint,4,opt,name=created_unix_utc,json=createdUnixUtc,proto3" json:"created_unix_utc,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *LedgerDebit) Reset() {
+	*x = LedgerDebit{}
+	mi := &file_api_credit_v1_credit_proto_msgTypes[12]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *Entry) String() string {
+func (x *LedgerDebit) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Entry) ProtoMessage() {}
+func (*LedgerDebit) ProtoMessage() {}
 
-func (x *Entry) ProtoReflect() protoreflect.Message {
-	mi := &file_api_credit_v1_credit_proto_msgTypes[11]
+func (x *LedgerDebit) ProtoReflect() protoreflect.Message {
+	mi := &file_api_credit_v1_credit_proto_msgTypes[12]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -904,110 +1052,2232 @@ func (x *Entry) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use Entry.ProtoReflect.Descriptor instead.
-func (*Entry) Descriptor() ([]byte, []int) {
-	return file_api_credit_v1_credit_proto_rawDescGZIP(), []int{11}
+// Deprecated: Use LedgerDebit.ProtoReflect.Descriptor instead.
+func (*LedgerDebit) Descriptor() ([]byte, []int) {
+	return file_api_credit_v1_credit_proto_rawDescGZIP(), []int{12}
 }
 
-func (x *Entry) GetEntryId() string {
+func (x *LedgerDebit) GetLedgerId() string {
 	if x != nil {
-		return x.EntryId
+		return x.LedgerId
 	}
 	return ""
 }
 
-func (x *Entry) GetAccountId() string {
+func (x *LedgerDebit) GetEntryId() string {
 	if x != nil {
-		return x.AccountId
+		return x.EntryId
 	}
 	return ""
 }
 
-func (x *Entry) GetType() string {
+func (x *LedgerDebit) GetAmountCents() int64 {
 	if x != nil {
-		return x.Type
+		return x.AmountCents
 	}
-	return ""
+	return 0
 }
 
-func (x *Entry) GetAmountCents() int64 {
+func (x *LedgerDebit) GetCreatedUnixUtc() int64 {
 	if x != nil {
-		return x.AmountCents
+		return x.CreatedUnixUtc
 	}
 	return 0
 }
 
-func (x *Entry) GetReservationId() string {
-	if x != nil {
-		return x.ReservationId
-	}
-	return ""
+type SpendAcrossLedgersResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Debits        []*LedgerDebit         `protobuf:"bytes,1,rep,name=debits,proto3" json:"debits,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
-func (x *Entry) GetIdempotencyKey() string {
-	if x != nil {
-		return x.IdempotencyKey
-	}
-	return ""
+func (x *SpendAcrossLedgersResponse) Reset() {
+	*x = SpendAcrossLedgersResponse{}
+	mi := &file_api_credit_v1_credit_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
 }
 
-func (x *Entry) GetExpiresAtUnixUtc() int64 {
-	if x != nil {
-		return x.ExpiresAtUnixUtc
-	}
-	return 0
+func (x *SpendAcrossLedgersResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
 }
 
-func (x *Entry) GetMetadataJson() string {
+func (*SpendAcrossLedgersResponse) ProtoMessage() {}
+
+func (x *SpendAcrossLedgersResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_credit_v1_credit_proto_msgTypes[13]
 	if x != nil {
-		return x.MetadataJson
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
-	return ""
+	return mi.MessageOf(x)
 }
 
-func (x *Entry) GetCreatedUnixUtc() int64 {
-	if x != nil {
-		return x.CreatedUnixUtc
-	}
-	return 0
+// Deprecated: Use SpendAcrossLedgersResponse.ProtoReflect.Descriptor instead.
+func (*SpendAcrossLedgersResponse) Descriptor() ([]byte, []int) {
+	return file_api_credit_v1_credit_proto_rawDescGZIP(), []int{13}
 }
 
-func (x *Entry) GetRefundOfEntryId() string {
+func (x *SpendAcrossLedgersResponse) GetDebits() []*LedgerDebit {
 	if x != nil {
-		return x.RefundOfEntryId
+		return x.Debits
 	}
-	return ""
+	return nil
 }
 
-type ListEntriesRequest struct {
-	state                protoimpl.MessageState `protogen:"open.v1"`
-	UserId               string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
-	BeforeUnixUtc        int64                  `protobuf:"varint,2,opt,name=before_unix_utc,json=beforeUnixUtc,proto3" json:"before_unix_utc,omitempty"`
-	Limit                int32                  `protobuf:"varint,3,opt,name=limit,proto3" json:"limit,omitempty"`
+type RefundAcrossLedgersRequest struct {
+	state                  protoimpl.MessageState `protogen:"open.v1"`
+	UserId                 string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	LedgerIds              []string               `protobuf:"bytes,2,rep,name=ledger_ids,json=ledgerIds,proto3" json:"ledger_ids,omitempty"`
+	TenantId               string                 `protobuf:"bytes,3,opt,name=tenant_id,json=tenantId,proto3" json:"tenant_id,omitempty"`
+	OriginalIdempotencyKey string                 `protobuf:"bytes,4,opt,name=original_idempotency_key,json=originalIdempotencyKey,proto3" json:"original_idempotency_key,omitempty"`
+	AmountCents            int64                  `protobuf:"varint,5,opt,name=amount_cents,json=amountCents,proto3" json:"amount_cents,omitempty"`
+	IdempotencyKey         string                 `protobuf:"bytes,6,opt,name=idempotency_key,json=idempotencyKey,proto3" json:"idempotency_key,omitempty"`
+	MetadataJson           string                 `protobuf:"bytes,7,opt,name=metadata_json,json=metadataJson,proto3" json:"metadata_json,omitempty"`
+	unknownFields          protoimpl.UnknownFields
+	sizeCache              protoimpl.SizeCache
+}
+
+func (x *RefundAcrossLedgersRequest) Reset() {
+	*x = RefundAcrossLedgersRequest{}
+	mi := &file_api_credit_v1_credit_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RefundAcrossLedgersRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RefundAcrossLedgersRequest) ProtoMessage() {}
+
+func (x *RefundAcrossLedgersRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_credit_v1_credit_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RefundAcrossLedgersRequest.ProtoReflect.Descriptor instead.
+func (*RefundAcrossLedgersRequest) Descriptor() ([]byte, []int) {
+	return file_api_credit_v1_credit_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *RefundAcrossLedgersRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *RefundAcrossLedgersRequest) GetLedgerIds() []string {
+	if x != nil {
+		return x.LedgerIds
+	}
+	return nil
+}
+
+func (x *RefundAcrossLedgersRequest) GetTenantId() string {
+	if x != nil {
+		return x.TenantId
+	}
+	return ""
+}
+
+func (x *RefundAcrossLedgersRequest) GetOriginalIdempotencyKey() string {
+	if x != nil {
+		return x.OriginalIdempotencyKey
+	}
+	return ""
+}
+
+func (x *RefundAcrossLedgersRequest) GetAmountCents() int64 {
+	if x != nil {
+		return x.AmountCents
+	}
+	return 0
+}
+
+func (x *RefundAcrossLedgersRequest) GetIdempotencyKey() string {
+	if x != nil {
+		return x.IdempotencyKey
+	}
+	return ""
+}
+
+func (x *RefundAcrossLedgersRequest) GetMetadataJson() string {
+	if x != nil {
+		return x.MetadataJson
+	}
+	return ""
+}
+
+type LedgerRefund struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	LedgerId        string                 `protobuf:"bytes,1,opt,name=ledger_id,json=ledgerId,proto3" json:"ledger_id,omitempty"`
+	EntryId         string                 `protobuf:"bytes,2,opt,name=entry_id,json=entryId,proto3" json:"entry_id,omitempty"`
+	AmountCents     int64                  `protobuf:"varint,3,opt,name=amount_cents,json=amountCents,proto3" json:"amount_cents,omitempty"`
+	CreatedUnixUtc  int64                  `protobuf:"varint,4,opt,name=created_unix_utc,json=createdUnixUtc,proto3" json:"created_unix_utc,omitempty"`
+	RefundOfEntryId string                 `protobuf:"bytes,5,opt,name=refund_of_entry_id,json=refundOfEntryId,proto3" json:"refund_of_entry_id,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *LedgerRefund) Reset() {
+	*x = LedgerRefund{}
+	mi := &file_api_credit_v1_credit_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LedgerRefund) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LedgerRefund) ProtoMessage() {}
+
+func (x *LedgerRefund) ProtoReflect() protoreflect.Message {
+	mi := &file_api_credit_v1_credit_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LedgerRefund.ProtoReflect.Descriptor instead.
+func (*LedgerRefund) Descriptor() ([]byte, []int) {
+	return file_api_credit_v1_credit_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *LedgerRefund) GetLedgerId() string {
+	if x != nil {
+		return x.LedgerId
+	}
+	return ""
+}
+
+func (x *LedgerRefund) GetEntryId() string {
+	if x != nil {
+		return x.EntryId
+	}
+	return ""
+}
+
+func (x *LedgerRefund) GetAmountCents() int64 {
+	if x != nil {
+		return x.AmountCents
+	}
+	return 0
+}
+
+func (x *LedgerRefund) GetCreatedUnixUtc() int64 {
+	if x != nil {
+		return x.CreatedUnixUtc
+	}
+	return 0
+}
+
+func (x *LedgerRefund) GetRefundOfEntryId() string {
+	if x != nil {
+		return x.RefundOfEntryId
+	}
+	return ""
+}
+
+type RefundAcrossLedgersResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Refunds       []*LedgerRefund        `protobuf:"bytes,1,rep,name=refunds,proto3" json:"refunds,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RefundAcrossLedgersResponse) Reset() {
+	*x = RefundAcrossLedgersResponse{}
+	mi := &file_api_credit_v1_credit_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RefundAcrossLedgersResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RefundAcrossLedgersResponse) ProtoMessage() {}
+
+func (x *RefundAcrossLedgersResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_credit_v1_credit_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RefundAcrossLedgersResponse.ProtoReflect.Descriptor instead.
+func (*RefundAcrossLedgersResponse) Descriptor() ([]byte, []int) {
+	return file_api_credit_v1_credit_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *RefundAcrossLedgersResponse) GetRefunds() []*LedgerRefund {
+	if x != nil {
+		return x.Refunds
+	}
+	return nil
+}
+
+type RefundResponse struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	EntryId        string                 `protobuf:"bytes,1,opt,name=entry_id,json=entryId,proto3" json:"entry_id,omitempty"`
+	CreatedUnixUtc int64                  `protobuf:"varint,2,opt,name=created_unix_utc,json=createdUnixUtc,proto3" json:"created_unix_utc,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *RefundResponse) Reset() {
+	*x = RefundResponse{}
+	mi := &file_api_credit_v1_credit_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RefundResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RefundResponse) ProtoMessage() {}
+
+func (x *RefundResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_credit_v1_credit_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RefundResponse.ProtoReflect.Descriptor instead.
+func (*RefundResponse) Descriptor() ([]byte, []int) {
+	return file_api_credit_v1_credit_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *RefundResponse) GetEntryId() string {
+	if x != nil {
+		return x.EntryId
+	}
+	return ""
+}
+
+func (x *RefundResponse) GetCreatedUnixUtc() int64 {
+	if x != nil {
+		return x.CreatedUnixUtc
+	}
+	return 0
+}
+
+type Entry struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	EntryId            string                 `protobuf:"bytes,1,opt,name=entry_id,json=entryId,proto3" json:"entry_id,omitempty"`
+	AccountId          string                 `protobuf:"bytes,2,opt,name=account_id,json=accountId,proto3" json:"account_id,omitempty"`
+	Type               string                 `protobuf:"bytes,3,opt,name=type,proto3" json:"type,omitempty"`
+	AmountCents        int64                  `protobuf:"varint,4,opt,name=amount_cents,json=amountCents,proto3" json:"amount_cents,omitempty"`
+	ReservationId      string                 `protobuf:"bytes,5,opt,name=reservation_id,json=reservationId,proto3" json:"reservation_id,omitempty"`
+	IdempotencyKey     string                 `protobuf:"bytes,6,opt,name=idempotency_key,json=idempotencyKey,proto3" json:"idempotency_key,omitempty"`
+	ExpiresAtUnixUtc   int64                  `protobuf:"varint,7,opt,name=expires_at_unix_utc,json=expiresAtUnixUtc,proto3" json:"expires_at_unix_utc,omitempty"`
+	MetadataJson       string                 `protobuf:"bytes,8,opt,name=metadata_json,json=metadataJson,proto3" json:"metadata_json,omitempty"`
+	CreatedUnixUtc     int64                  `protobuf:"varint,9,opt,name=created_unix_utc,json=createdUnixUtc,proto3" json:"created_unix_utc,omitempty"`
+	RefundOfEntryId    string                 `protobuf:"bytes,10,opt,name=refund_of_entry_id,json=refundOfEntryId,proto3" json:"refund_of_entry_id,omitempty"`
+	EffectiveAtUnixUtc int64                  `protobuf:"varint,11,opt,name=effective_at_unix_utc,json=effectiveAtUnixUtc,proto3" json:"effective_at_unix_utc,omitempty"`
+	Pending            bool                   `protobuf:"varint,12,opt,name=pending,proto3" json:"pending,omitempty"`
+	Actor              *Actor                 `protobuf:"bytes,13,opt,name=actor,proto3" json:"actor,omitempty"`
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *Entry) Reset() {
+	*x = Entry{}
+	mi := &file_api_credit_v1_credit_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Entry) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Entry) ProtoMessage() {}
+
+func (x *Entry) ProtoReflect() protoreflect.Message {
+	mi := &file_api_credit_v1_credit_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Entry.ProtoReflect.Descriptor instead.
+func (*Entry) Descriptor() ([]byte, []int) {
+	return file_api_credit_v1_credit_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *Entry) GetEntryId() string {
+	if x != nil {
+		return x.EntryId
+	}
+	return ""
+}
+
+func (x *Entry) GetAccountId() string {
+	if x != nil {
+		return x.AccountId
+	}
+	return ""
+}
+
+func (x *Entry) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *Entry) GetAmountCents() int64 {
+	if x != nil {
+		return x.AmountCents
+	}
+	return 0
+}
+
+func (x *Entry) GetReservationId() string {
+	if x != nil {
+		return x.ReservationId
+	}
+	return ""
+}
+
+func (x *Entry) GetIdempotencyKey() string {
+	if x != nil {
+		return x.IdempotencyKey
+	}
+	return ""
+}
+
+func (x *Entry) GetExpiresAtUnixUtc() int64 {
+	if x != nil {
+		return x.ExpiresAtUnixUtc
+	}
+	return 0
+}
+
+func (x *Entry) GetMetadataJson() string {
+	if x != nil {
+		return x.MetadataJson
+	}
+	return ""
+}
+
+func (x *Entry) GetCreatedUnixUtc() int64 {
+	if x != nil {
+		return x.CreatedUnixUtc
+	}
+	return 0
+}
+
+func (x *Entry) GetRefundOfEntryId() string {
+	if x != nil {
+		return x.RefundOfEntryId
+	}
+	return ""
+}
+
+func (x *Entry) GetEffectiveAtUnixUtc() int64 {
+	if x != nil {
+		return x.EffectiveAtUnixUtc
+	}
+	return 0
+}
+
+func (x *Entry) GetPending() bool {
+	if x != nil {
+		return x.Pending
+	}
+	return false
+}
+
+func (x *Entry) GetActor() *Actor {
+	if x != nil {
+		return x.Actor
+	}
+	return nil
+}
+
+// Actor records who wrote an entry. api_key_id identifies the tenant key, never the secret.
+type Actor struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ApiKeyId      string                 `protobuf:"bytes,1,opt,name=api_key_id,json=apiKeyId,proto3" json:"api_key_id,omitempty"`
+	Service       string                 `protobuf:"bytes,2,opt,name=service,proto3" json:"service,omitempty"`
+	RequestId     string                 `protobuf:"bytes,3,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	ClientAddress string                 `protobuf:"bytes,4,opt,name=client_address,json=clientAddress,proto3" json:"client_address,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Actor) Reset() {
+	*x = Actor{}
+	mi := &file_api_credit_v1_credit_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Actor) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Actor) ProtoMessage() {}
+
+func (x *Actor) ProtoReflect() protoreflect.Message {
+	mi := &file_api_credit_v1_credit_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Actor.ProtoReflect.Descriptor instead.
+func (*Actor) Descriptor() ([]byte, []int) {
+	return file_api_credit_v1_credit_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *Actor) GetApiKeyId() string {
+	if x != nil {
+		return x.ApiKeyId
+	}
+	return ""
+}
+
+func (x *Actor) GetService() string {
+	if x != nil {
+		return x.Service
+	}
+	return ""
+}
+
+func (x *Actor) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+func (x *Actor) GetClientAddress() string {
+	if x != nil {
+		return x.ClientAddress
+	}
+	return ""
+}
+
+type ListEntriesRequest struct {
+	state                protoimpl.MessageState `protogen:"open.v1"`
+	UserId               string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	BeforeUnixUtc        int64                  `protobuf:"varint,2,opt,name=before_unix_utc,json=beforeUnixUtc,proto3" json:"before_unix_utc,omitempty"`
+	Limit                int32                  `protobuf:"varint,3,opt,name=limit,proto3" json:"limit,omitempty"`
 	LedgerId             string                 `protobuf:"bytes,4,opt,name=ledger_id,json=ledgerId,proto3" json:"ledger_id,omitempty"`
 	TenantId             string                 `protobuf:"bytes,5,opt,name=tenant_id,json=tenantId,proto3" json:"tenant_id,omitempty"`
 	Types                []string               `protobuf:"bytes,6,rep,name=types,proto3" json:"types,omitempty"`
 	ReservationId        string                 `protobuf:"bytes,7,opt,name=reservation_id,json=reservationId,proto3" json:"reservation_id,omitempty"`
 	IdempotencyKeyPrefix string                 `protobuf:"bytes,8,opt,name=idempotency_key_prefix,json=idempotencyKeyPrefix,proto3" json:"idempotency_key_prefix,omitempty"`
+	// actor keeps entries whose actor matches every non-empty field.
+	Actor         *Actor `protobuf:"bytes,9,opt,name=actor,proto3" json:"actor,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListEntriesRequest) Reset() {
+	*x = ListEntriesRequest{}
+	mi := &file_api_credit_v1_credit_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListEntriesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListEntriesRequest) ProtoMessage() {}
+
+func (x *ListEntriesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_credit_v1_credit_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListEntriesRequest.ProtoReflect.Descriptor instead.
+func (*ListEntriesRequest) Descriptor() ([]byte, []int) {
+	return file_api_credit_v1_credit_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *ListEntriesRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *ListEntriesRequest) GetBeforeUnixUtc() int64 {
+	if x != nil {
+		return x.BeforeUnixUtc
+	}
+	return 0
+}
+
+func (x *ListEntriesRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *ListEntriesRequest) GetLedgerId() string {
+	if x != nil {
+		return x.LedgerId
+	}
+	return ""
+}
+
+func (x *ListEntriesRequest) GetTenantId() string {
+	if x != nil {
+		return x.TenantId
+	}
+	return ""
+}
+
+func (x *ListEntriesRequest) GetTypes() []string {
+	if x != nil {
+		return x.Types
+	}
+	return nil
+}
+
+func (x *ListEntriesRequest) GetReservationId() string {
+	if x != nil {
+		return x.ReservationId
+	}
+	return ""
+}
+
+func (x *ListEntriesRequest) GetIdempotencyKeyPrefix() string {
+	if x != nil {
+		return x.IdempotencyKeyPrefix
+	}
+	return ""
+}
+
+func (x *ListEntriesRequest) GetActor() *Actor {
+	if x != nil {
+		return x.Actor
+	}
+	return nil
+}
+
+type ListEntriesResponse struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Entries []*Entry               `protobuf:"bytes,1,rep,name=entries,proto3" json:"entries,omitempty"`
+	// archived_before_unix_utc is set when entries created before it were compacted into an opening_balance entry.
+	ArchivedBeforeUnixUtc int64 `protobuf:"varint,2,opt,name=archived_before_unix_utc,json=archivedBeforeUnixUtc,proto3" json:"archived_before_unix_utc,omitempty"`
+	unknownFields         protoimpl.UnknownFields
+	sizeCache             protoimpl.SizeCache
+}
+
+func (x *ListEntriesResponse) Reset() {
+	*x = ListEntriesResponse{}
+	mi := &file_api_credit_v1_credit_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListEntriesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListEntriesResponse) ProtoMessage() {}
+
+func (x *ListEntriesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_credit_v1_credit_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListEntriesResponse.ProtoReflect.Descriptor instead.
+func (*ListEntriesResponse) Descriptor() ([]byte, []int) {
+	return file_api_credit_v1_credit_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *ListEntriesResponse) GetEntries() []*Entry {
+	if x != nil {
+		return x.Entries
+	}
+	return nil
+}
+
+func (x *ListEntriesResponse) GetArchivedBeforeUnixUtc() int64 {
+	if x != nil {
+		return x.ArchivedBeforeUnixUtc
+	}
+	return 0
+}
+
+type WatchEntriesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TenantId      string                 `protobuf:"bytes,1,opt,name=tenant_id,json=tenantId,proto3" json:"tenant_id,omitempty"`
+	LedgerId      string                 `protobuf:"bytes,2,opt,name=ledger_id,json=ledgerId,proto3" json:"ledger_id,omitempty"`
+	UserId        string                 `protobuf:"bytes,3,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Types         []string               `protobuf:"bytes,4,rep,name=types,proto3" json:"types,omitempty"`
+	AfterCursor   int64                  `protobuf:"varint,5,opt,name=after_cursor,json=afterCursor,proto3" json:"after_cursor,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchEntriesRequest) Reset() {
+	*x = WatchEntriesRequest{}
+	mi := &file_api_credit_v1_credit_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchEntriesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchEntriesRequest) ProtoMessage() {}
+
+func (x *WatchEntriesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_credit_v1_credit_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchEntriesRequest.ProtoReflect.Descriptor instead.
+func (*WatchEntriesRequest) Descriptor() ([]byte, []int) {
+	return file_api_credit_v1_credit_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *WatchEntriesRequest) GetTenantId() string {
+	if x != nil {
+		return x.TenantId
+	}
+	return ""
+}
+
+func (x *WatchEntriesRequest) GetLedgerId() string {
+	if x != nil {
+		return x.LedgerId
+	}
+	return ""
+}
+
+func (x *WatchEntriesRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *WatchEntriesRequest) GetTypes() []string {
+	if x != nil {
+		return x.Types
+	}
+	return nil
+}
+
+func (x *WatchEntriesRequest) GetAfterCursor() int64 {
+	if x != nil {
+		return x.AfterCursor
+	}
+	return 0
+}
+
+type EntryChange struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Cursor        int64                  `protobuf:"varint,1,opt,name=cursor,proto3" json:"cursor,omitempty"`
+	UserId        string                 `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	LedgerId      string                 `protobuf:"bytes,3,opt,name=ledger_id,json=ledgerId,proto3" json:"ledger_id,omitempty"`
+	Entry         *Entry                 `protobuf:"bytes,4,opt,name=entry,proto3" json:"entry,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EntryChange) Reset() {
+	*x = EntryChange{}
+	mi := &file_api_credit_v1_credit_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EntryChange) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EntryChange) ProtoMessage() {}
+
+func (x *EntryChange) ProtoReflect() protoreflect.Message {
+	mi := &file_api_credit_v1_credit_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EntryChange.ProtoReflect.Descriptor instead.
+func (*EntryChange) Descriptor() ([]byte, []int) {
+	return file_api_credit_v1_credit_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *EntryChange) GetCursor() int64 {
+	if x != nil {
+		return x.Cursor
+	}
+	return 0
+}
+
+func (x *EntryChange) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *EntryChange) GetLedgerId() string {
+	if x != nil {
+		return x.LedgerId
+	}
+	return ""
+}
+
+func (x *EntryChange) GetEntry() *Entry {
+	if x != nil {
+		return x.Entry
+	}
+	return nil
+}
+
+type Reservation struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	ReservationId    string                 `protobuf:"bytes,1,opt,name=reservation_id,json=reservationId,proto3" json:"reservation_id,omitempty"`
+	AmountCents      int64                  `protobuf:"varint,2,opt,name=amount_cents,json=amountCents,proto3" json:"amount_cents,omitempty"`
+	Status           string                 `protobuf:"bytes,3,opt,name=status,proto3" json:"status,omitempty"`
+	ExpiresAtUnixUtc int64                  `protobuf:"varint,4,opt,name=expires_at_unix_utc,json=expiresAtUnixUtc,proto3" json:"expires_at_unix_utc,omitempty"`
+	CreatedUnixUtc   int64                  `protobuf:"varint,5,opt,name=created_unix_utc,json=createdUnixUtc,proto3" json:"created_unix_utc,omitempty"`
+	UpdatedUnixUtc   int64                  `protobuf:"varint,6,opt,name=updated_unix_utc,json=updatedUnixUtc,proto3" json:"updated_unix_utc,omitempty"`
+	Expired          bool                   `protobuf:"varint,7,opt,name=expired,proto3" json:"expired,omitempty"`
+	HeldCents        int64                  `protobuf:"varint,8,opt,name=held_cents,json=heldCents,proto3" json:"held_cents,omitempty"`
+	CapturedCents    int64                  `protobuf:"varint,9,opt,name=captured_cents,json=capturedCents,proto3" json:"captured_cents,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *Reservation) Reset() {
+	*x = Reservation{}
+	mi := &file_api_credit_v1_credit_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Reservation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Reservation) ProtoMessage() {}
+
+func (x *Reservation) ProtoReflect() protoreflect.Message {
+	mi := &file_api_credit_v1_credit_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Reservation.ProtoReflect.Descriptor instead.
+func (*Reservation) Descriptor() ([]byte, []int) {
+	return file_api_credit_v1_credit_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *Reservation) GetReservationId() string {
+	if x != nil {
+		return x.ReservationId
+	}
+	return ""
+}
+
+func (x *Reservation) GetAmountCents() int64 {
+	if x != nil {
+		return x.AmountCents
+	}
+	return 0
+}
+
+func (x *Reservation) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *Reservation) GetExpiresAtUnixUtc() int64 {
+	if x != nil {
+		return x.ExpiresAtUnixUtc
+	}
+	return 0
+}
+
+func (x *Reservation) GetCreatedUnixUtc() int64 {
+	if x != nil {
+		return x.CreatedUnixUtc
+	}
+	return 0
+}
+
+func (x *Reservation) GetUpdatedUnixUtc() int64 {
+	if x != nil {
+		return x.UpdatedUnixUtc
+	}
+	return 0
+}
+
+func (x *Reservation) GetExpired() bool {
+	if x != nil {
+		return x.Expired
+	}
+	return false
+}
+
+func (x *Reservation) GetHeldCents() int64 {
+	if x != nil {
+		return x.HeldCents
+	}
+	return 0
+}
+
+func (x *Reservation) GetCapturedCents() int64 {
+	if x != nil {
+		return x.CapturedCents
+	}
+	return 0
+}
+
+type GetReservationRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	LedgerId      string                 `protobuf:"bytes,2,opt,name=ledger_id,json=ledgerId,proto3" json:"ledger_id,omitempty"`
+	TenantId      string                 `protobuf:"bytes,3,opt,name=tenant_id,json=tenantId,proto3" json:"tenant_id,omitempty"`
+	ReservationId string                 `protobuf:"bytes,4,opt,name=reservation_id,json=reservationId,proto3" json:"reservation_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetReservationRequest) Reset() {
+	*x = GetReservationRequest{}
+	mi := &file_api_credit_v1_credit_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetReservationRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetReservationRequest) ProtoMessage() {}
+
+func (x *GetReservationRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_credit_v1_credit_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetReservationRequest.ProtoReflect.Descriptor instead.
+func (*GetReservationRequest) Descriptor() ([]byte, []int) {
+	return file_api_credit_v1_credit_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *GetReservationRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *GetReservationRequest) GetLedgerId() string {
+	if x != nil {
+		return x.LedgerId
+	}
+	return ""
+}
+
+func (x *GetReservationRequest) GetTenantId() string {
+	if x != nil {
+		return x.TenantId
+	}
+	return ""
+}
+
+func (x *GetReservationRequest) GetReservationId() string {
+	if x != nil {
+		return x.ReservationId
+	}
+	return ""
+}
+
+type GetReservationResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Reservation   *Reservation           `protobuf:"bytes,1,opt,name=reservation,proto3" json:"reservation,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetReservationResponse) Reset() {
+	*x = GetReservationResponse{}
+	mi := &file_api_credit_v1_credit_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetReservationResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetReservationResponse) ProtoMessage() {}
+
+func (x *GetReservationResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_credit_v1_credit_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetReservationResponse.ProtoReflect.Descriptor instead.
+func (*GetReservationResponse) Descriptor() ([]byte, []int) {
+	return file_api_credit_v1_credit_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *GetReservationResponse) GetReservation() *Reservation {
+	if x != nil {
+		return x.Reservation
+	}
+	return nil
+}
+
+type ListReservationsRequest struct {
+	state                protoimpl.MessageState `protogen:"open.v1"`
+	UserId               string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	LedgerId             string                 `protobuf:"bytes,2,opt,name=ledger_id,json=ledgerId,proto3" json:"ledger_id,omitempty"`
+	TenantId             string                 `protobuf:"bytes,3,opt,name=tenant_id,json=tenantId,proto3" json:"tenant_id,omitempty"`
+	BeforeCreatedUnixUtc int64                  `protobuf:"varint,4,opt,name=before_created_unix_utc,json=beforeCreatedUnixUtc,proto3" json:"before_created_unix_utc,omitempty"`
+	Limit                int32                  `protobuf:"varint,5,opt,name=limit,proto3" json:"limit,omitempty"`
+	Statuses             []string               `protobuf:"bytes,6,rep,name=statuses,proto3" json:"statuses,omitempty"`
+	unknownFields        protoimpl.UnknownFields
+	sizeCache            protoimpl.SizeCache
+}
+
+func (x *ListReservationsRequest) Reset() {
+	*x = ListReservationsRequest{}
+	mi := &file_api_credit_v1_credit_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListReservationsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListReservationsRequest) ProtoMessage() {}
+
+func (x *ListReservationsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_credit_v1_credit_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListReservationsRequest.ProtoReflect.Descriptor instead.
+func (*ListReservationsRequest) Descriptor() ([]byte, []int) {
+	return file_api_credit_v1_credit_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *ListReservationsRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *ListReservationsRequest) GetLedgerId() string {
+	if x != nil {
+		return x.LedgerId
+	}
+	return ""
+}
+
+func (x *ListReservationsRequest) GetTenantId() string {
+	if x != nil {
+		return x.TenantId
+	}
+	return ""
+}
+
+func (x *ListReservationsRequest) GetBeforeCreatedUnixUtc() int64 {
+	if x != nil {
+		return x.BeforeCreatedUnixUtc
+	}
+	return 0
+}
+
+func (x *ListReservationsRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *ListReservationsRequest) GetStatuses() []string {
+	if x != nil {
+		return x.Statuses
+	}
+	return nil
+}
+
+type ListReservationsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Reservations  []*Reservation         `protobuf:"bytes,1,rep,name=reservations,proto3" json:"reservations,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListReservationsResponse) Reset() {
+	*x = ListReservationsResponse{}
+	mi := &file_api_credit_v1_credit_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListReservationsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListReservationsResponse) ProtoMessage() {}
+
+func (x *ListReservationsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_credit_v1_credit_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListReservationsResponse.ProtoReflect.Descriptor instead.
+func (*ListReservationsResponse) Descriptor() ([]byte, []int) {
+	return file_api_credit_v1_credit_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *ListReservationsResponse) GetReservations() []*Reservation {
+	if x != nil {
+		return x.Reservations
+	}
+	return nil
+}
+
+type GrantSchedule struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	ScheduleId      string                 `protobuf:"bytes,1,opt,name=schedule_id,json=scheduleId,proto3" json:"schedule_id,omitempty"`
+	UserId          string                 `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	LedgerId        string                 `protobuf:"bytes,3,opt,name=ledger_id,json=ledgerId,proto3" json:"ledger_id,omitempty"`
+	AmountCents     int64                  `protobuf:"varint,4,opt,name=amount_cents,json=amountCents,proto3" json:"amount_cents,omitempty"`
+	Interval        string                 `protobuf:"bytes,5,opt,name=interval,proto3" json:"interval,omitempty"`
+	StartUnixUtc    int64                  `protobuf:"varint,6,opt,name=start_unix_utc,json=startUnixUtc,proto3" json:"start_unix_utc,omitempty"`
+	EndUnixUtc      int64                  `protobuf:"varint,7,opt,name=end_unix_utc,json=endUnixUtc,proto3" json:"end_unix_utc,omitempty"`
+	GrantTtlSeconds int64                  `protobuf:"varint,8,opt,name=grant_ttl_seconds,json=grantTtlSeconds,proto3" json:"grant_ttl_seconds,omitempty"`
+	MetadataJson    string                 `protobuf:"bytes,9,opt,name=metadata_json,json=metadataJson,proto3" json:"metadata_json,omitempty"`
+	Status          string                 `protobuf:"bytes,10,opt,name=status,proto3" json:"status,omitempty"`
+	NextRunUnixUtc  int64                  `protobuf:"varint,11,opt,name=next_run_unix_utc,json=nextRunUnixUtc,proto3" json:"next_run_unix_utc,omitempty"`
+	CreatedUnixUtc  int64                  `protobuf:"varint,12,opt,name=created_unix_utc,json=createdUnixUtc,proto3" json:"created_unix_utc,omitempty"`
+	UpdatedUnixUtc  int64                  `protobuf:"varint,13,opt,name=updated_unix_utc,json=updatedUnixUtc,proto3" json:"updated_unix_utc,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *GrantSchedule) Reset() {
+	*x = GrantSchedule{}
+	mi := &file_api_credit_v1_credit_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GrantSchedule) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GrantSchedule) ProtoMessage() {}
+
+func (x *GrantSchedule) ProtoReflect() protoreflect.Message {
+	mi := &file_api_credit_v1_credit_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GrantSchedule.ProtoReflect.Descriptor instead.
+func (*GrantSchedule) Descriptor() ([]byte, []int) {
+	return file_api_credit_v1_credit_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *GrantSchedule) GetScheduleId() string {
+	if x != nil {
+		return x.ScheduleId
+	}
+	return ""
+}
+
+func (x *GrantSchedule) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *GrantSchedule) GetLedgerId() string {
+	if x != nil {
+		return x.LedgerId
+	}
+	return ""
+}
+
+func (x *GrantSchedule) GetAmountCents() int64 {
+	if x != nil {
+		return x.AmountCents
+	}
+	return 0
+}
+
+func (x *GrantSchedule) GetInterval() string {
+	if x != nil {
+		return x.Interval
+	}
+	return ""
+}
+
+func (x *GrantSchedule) GetStartUnixUtc() int64 {
+	if x != nil {
+		return x.StartUnixUtc
+	}
+	return 0
+}
+
+func (x *GrantSchedule) GetEndUnixUtc() int64 {
+	if x != nil {
+		return x.EndUnixUtc
+	}
+	return 0
+}
+
+func (x *GrantSchedule) GetGrantTtlSeconds() int64 {
+	if x != nil {
+		return x.GrantTtlSeconds
+	}
+	return 0
+}
+
+func (x *GrantSchedule) GetMetadataJson() string {
+	if x != nil {
+		return x.MetadataJson
+	}
+	return ""
+}
+
+func (x *GrantSchedule) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *GrantSchedule) GetNextRunUnixUtc() int64 {
+	if x != nil {
+		return x.NextRunUnixUtc
+	}
+	return 0
+}
+
+func (x *GrantSchedule) GetCreatedUnixUtc() int64 {
+	if x != nil {
+		return x.CreatedUnixUtc
+	}
+	return 0
+}
+
+func (x *GrantSchedule) GetUpdatedUnixUtc() int64 {
+	if x != nil {
+		return x.UpdatedUnixUtc
+	}
+	return 0
+}
+
+type CreateGrantScheduleRequest struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	UserId          string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	LedgerId        string                 `protobuf:"bytes,2,opt,name=ledger_id,json=ledgerId,proto3" json:"ledger_id,omitempty"`
+	TenantId        string                 `protobuf:"bytes,3,opt,name=tenant_id,json=tenantId,proto3" json:"tenant_id,omitempty"`
+	ScheduleId      string                 `protobuf:"bytes,4,opt,name=schedule_id,json=scheduleId,proto3" json:"schedule_id,omitempty"`
+	AmountCents     int64                  `protobuf:"varint,5,opt,name=amount_cents,json=amountCents,proto3" json:"amount_cents,omitempty"`
+	Interval        string                 `protobuf:"bytes,6,opt,name=interval,proto3" json:"interval,omitempty"`
+	StartUnixUtc    int64                  `protobuf:"varint,7,opt,name=start_unix_utc,json=startUnixUtc,proto3" json:"start_unix_utc,omitempty"`
+	EndUnixUtc      int64                  `protobuf:"varint,8,opt,name=end_unix_utc,json=endUnixUtc,proto3" json:"end_unix_utc,omitempty"`
+	GrantTtlSeconds int64                  `protobuf:"varint,9,opt,name=grant_ttl_seconds,json=grantTtlSeconds,proto3" json:"grant_ttl_seconds,omitempty"`
+	MetadataJson    string                 `protobuf:"bytes,10,opt,name=metadata_json,json=metadataJson,proto3" json:"metadata_json,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *CreateGrantScheduleRequest) Reset() {
+	*x = CreateGrantScheduleRequest{}
+	mi := &file_api_credit_v1_credit_proto_msgTypes[30]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateGrantScheduleRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateGrantScheduleRequest) ProtoMessage() {}
+
+func (x *CreateGrantScheduleRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_credit_v1_credit_proto_msgTypes[30]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateGrantScheduleRequest.ProtoReflect.Descriptor instead.
+func (*CreateGrantScheduleRequest) Descriptor() ([]byte, []int) {
+	return file_api_credit_v1_credit_proto_rawDescGZIP(), []int{30}
+}
+
+func (x *CreateGrantScheduleRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *CreateGrantScheduleRequest) GetLedgerId() string {
+	if x != nil {
+		return x.LedgerId
+	}
+	return ""
+}
+
+func (x *CreateGrantScheduleRequest) GetTenantId() string {
+	if x != nil {
+		return x.TenantId
+	}
+	return ""
+}
+
+func (x *CreateGrantScheduleRequest) GetScheduleId() string {
+	if x != nil {
+		return x.ScheduleId
+	}
+	return ""
+}
+
+func (x *CreateGrantScheduleRequest) GetAmountCents() int64 {
+	if x != nil {
+		return x.AmountCents
+	}
+	return 0
+}
+
+func (x *CreateGrantScheduleRequest) GetInterval() string {
+	if x != nil {
+		return x.Interval
+	}
+	return ""
+}
+
+func (x *CreateGrantScheduleRequest) GetStartUnixUtc() int64 {
+	if x != nil {
+		return x.StartUnixUtc
+	}
+	return 0
+}
+
+func (x *CreateGrantScheduleRequest) GetEndUnixUtc() int64 {
+	if x != nil {
+		return x.EndUnixUtc
+	}
+	return 0
+}
+
+func (x *CreateGrantScheduleRequest) GetGrantTtlSeconds() int64 {
+	if x != nil {
+		return x.GrantTtlSeconds
+	}
+	return 0
+}
+
+func (x *CreateGrantScheduleRequest) GetMetadataJson() string {
+	if x != nil {
+		return x.MetadataJson
+	}
+	return ""
+}
+
+type CreateGrantScheduleResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Schedule      *GrantSchedule         `protobuf:"bytes,1,opt,name=schedule,proto3" json:"schedule,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateGrantScheduleResponse) Reset() {
+	*x = CreateGrantScheduleResponse{}
+	mi := &file_api_credit_v1_credit_proto_msgTypes[31]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateGrantScheduleResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateGrantScheduleResponse) ProtoMessage() {}
+
+func (x *CreateGrantScheduleResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_credit_v1_credit_proto_msgTypes[31]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateGrantScheduleResponse.ProtoReflect.Descriptor instead.
+func (*CreateGrantScheduleResponse) Descriptor() ([]byte, []int) {
+	return file_api_credit_v1_credit_proto_rawDescGZIP(), []int{31}
+}
+
+func (x *CreateGrantScheduleResponse) GetSchedule() *GrantSchedule {
+	if x != nil {
+		return x.Schedule
+	}
+	return nil
+}
+
+type ListGrantSchedulesRequest struct {
+	state                protoimpl.MessageState `protogen:"open.v1"`
+	TenantId             string                 `protobuf:"bytes,1,opt,name=tenant_id,json=tenantId,proto3" json:"tenant_id,omitempty"`
+	UserId               string                 `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	LedgerId             string                 `protobuf:"bytes,3,opt,name=ledger_id,json=ledgerId,proto3" json:"ledger_id,omitempty"`
+	BeforeCreatedUnixUtc int64                  `protobuf:"varint,4,opt,name=before_created_unix_utc,json=beforeCreatedUnixUtc,proto3" json:"before_created_unix_utc,omitempty"`
+	Limit                int32                  `protobuf:"varint,5,opt,name=limit,proto3" json:"limit,omitempty"`
+	Statuses             []string               `protobuf:"bytes,6,rep,name=statuses,proto3" json:"statuses,omitempty"`
+	unknownFields        protoimpl.UnknownFields
+	sizeCache            protoimpl.SizeCache
+}
+
+func (x *ListGrantSchedulesRequest) Reset() {
+	*x = ListGrantSchedulesRequest{}
+	mi := &file_api_credit_v1_credit_proto_msgTypes[32]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListGrantSchedulesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListGrantSchedulesRequest) ProtoMessage() {}
+
+func (x *ListGrantSchedulesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_credit_v1_credit_proto_msgTypes[32]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListGrantSchedulesRequest.ProtoReflect.Descriptor instead.
+func (*ListGrantSchedulesRequest) Descriptor() ([]byte, []int) {
+	return file_api_credit_v1_credit_proto_rawDescGZIP(), []int{32}
+}
+
+func (x *ListGrantSchedulesRequest) GetTenantId() string {
+	if x != nil {
+		return x.TenantId
+	}
+	return ""
+}
+
+func (x *ListGrantSchedulesRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *ListGrantSchedulesRequest) GetLedgerId() string {
+	if x != nil {
+		return x.LedgerId
+	}
+	return ""
+}
+
+func (x *ListGrantSchedulesRequest) GetBeforeCreatedUnixUtc() int64 {
+	if x != nil {
+		return x.BeforeCreatedUnixUtc
+	}
+	return 0
+}
+
+func (x *ListGrantSchedulesRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *ListGrantSchedulesRequest) GetStatuses() []string {
+	if x != nil {
+		return x.Statuses
+	}
+	return nil
+}
+
+type ListGrantSchedulesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Schedules     []*GrantSchedule       `protobuf:"bytes,1,rep,name=schedules,proto3" json:"schedules,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListGrantSchedulesResponse) Reset() {
+	*x = ListGrantSchedulesResponse{}
+	mi := &file_api_credit_v1_credit_proto_msgTypes[33]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListGrantSchedulesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListGrantSchedulesResponse) ProtoMessage() {}
+
+func (x *ListGrantSchedulesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_credit_v1_credit_proto_msgTypes[33]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListGrantSchedulesResponse.ProtoReflect.Descriptor instead.
+func (*ListGrantSchedulesResponse) Descriptor() ([]byte, []int) {
+	return file_api_credit_v1_credit_proto_rawDescGZIP(), []int{33}
+}
+
+func (x *ListGrantSchedulesResponse) GetSchedules() []*GrantSchedule {
+	if x != nil {
+		return x.Schedules
+	}
+	return nil
+}
+
+type CancelGrantScheduleRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TenantId      string                 `protobuf:"bytes,1,opt,name=tenant_id,json=tenantId,proto3" json:"tenant_id,omitempty"`
+	ScheduleId    string                 `protobuf:"bytes,2,opt,name=schedule_id,json=scheduleId,proto3" json:"schedule_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CancelGrantScheduleRequest) Reset() {
+	*x = CancelGrantScheduleRequest{}
+	mi := &file_api_credit_v1_credit_proto_msgTypes[34]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CancelGrantScheduleRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CancelGrantScheduleRequest) ProtoMessage() {}
+
+func (x *CancelGrantScheduleRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_credit_v1_credit_proto_msgTypes[34]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CancelGrantScheduleRequest.ProtoReflect.Descriptor instead.
+func (*CancelGrantScheduleRequest) Descriptor() ([]byte, []int) {
+	return file_api_credit_v1_credit_proto_rawDescGZIP(), []int{34}
+}
+
+func (x *CancelGrantScheduleRequest) GetTenantId() string {
+	if x != nil {
+		return x.TenantId
+	}
+	return ""
+}
+
+func (x *CancelGrantScheduleRequest) GetScheduleId() string {
+	if x != nil {
+		return x.ScheduleId
+	}
+	return ""
+}
+
+type CancelGrantScheduleResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Schedule      *GrantSchedule         `protobuf:"bytes,1,opt,name=schedule,proto3" json:"schedule,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CancelGrantScheduleResponse) Reset() {
+	*x = CancelGrantScheduleResponse{}
+	mi := &file_api_credit_v1_credit_proto_msgTypes[35]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CancelGrantScheduleResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CancelGrantScheduleResponse) ProtoMessage() {}
+
+func (x *CancelGrantScheduleResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_credit_v1_credit_proto_msgTypes[35]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CancelGrantScheduleResponse.ProtoReflect.Descriptor instead.
+func (*CancelGrantScheduleResponse) Descriptor() ([]byte, []int) {
+	return file_api_credit_v1_credit_proto_rawDescGZIP(), []int{35}
+}
+
+func (x *CancelGrantScheduleResponse) GetSchedule() *GrantSchedule {
+	if x != nil {
+		return x.Schedule
+	}
+	return nil
+}
+
+type WebhookEvent struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	EventId            string                 `protobuf:"bytes,1,opt,name=event_id,json=eventId,proto3" json:"event_id,omitempty"`
+	EventType          string                 `protobuf:"bytes,2,opt,name=event_type,json=eventType,proto3" json:"event_type,omitempty"`
+	Status             string                 `protobuf:"bytes,3,opt,name=status,proto3" json:"status,omitempty"`
+	Attempts           int32                  `protobuf:"varint,4,opt,name=attempts,proto3" json:"attempts,omitempty"`
+	NextAttemptUnixUtc int64                  `protobuf:"varint,5,opt,name=next_attempt_unix_utc,json=nextAttemptUnixUtc,proto3" json:"next_attempt_unix_utc,omitempty"`
+	LastError          string                 `protobuf:"bytes,6,opt,name=last_error,json=lastError,proto3" json:"last_error,omitempty"`
+	CreatedUnixUtc     int64                  `protobuf:"varint,7,opt,name=created_unix_utc,json=createdUnixUtc,proto3" json:"created_unix_utc,omitempty"`
+	DeliveredUnixUtc   int64                  `protobuf:"varint,8,opt,name=delivered_unix_utc,json=deliveredUnixUtc,proto3" json:"delivered_unix_utc,omitempty"`
+	PayloadJson        string                 `protobuf:"bytes,9,opt,name=payload_json,json=payloadJson,proto3" json:"payload_json,omitempty"`
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *WebhookEvent) Reset() {
+	*x = WebhookEvent{}
+	mi := &file_api_credit_v1_credit_proto_msgTypes[36]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WebhookEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WebhookEvent) ProtoMessage() {}
+
+func (x *WebhookEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_api_credit_v1_credit_proto_msgTypes[36]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WebhookEvent.ProtoReflect.Descriptor instead.
+func (*WebhookEvent) Descriptor() ([]byte, []int) {
+	return file_api_credit_v1_credit_proto_rawDescGZIP(), []int{36}
+}
+
+func (x *WebhookEvent) GetEventId() string {
+	if x != nil {
+		return x.EventId
+	}
+	return ""
+}
+
+func (x *WebhookEvent) GetEventType() string {
+	if x != nil {
+		return x.EventType
+	}
+	return ""
+}
+
+func (x *WebhookEvent) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *WebhookEvent) GetAttempts() int32 {
+	if x != nil {
+		return x.Attempts
+	}
+	return 0
+}
+
+func (x *WebhookEvent) GetNextAttemptUnixUtc() int64 {
+	if x != nil {
+		return x.NextAttemptUnixUtc
+	}
+	return 0
+}
+
+func (x *WebhookEvent) GetLastError() string {
+	if x != nil {
+		return x.LastError
+	}
+	return ""
+}
+
+func (x *WebhookEvent) GetCreatedUnixUtc() int64 {
+	if x != nil {
+		return x.CreatedUnixUtc
+	}
+	return 0
+}
+
+func (x *WebhookEvent) GetDeliveredUnixUtc() int64 {
+	if x != nil {
+		return x.DeliveredUnixUtc
+	}
+	return 0
+}
+
+func (x *WebhookEvent) GetPayloadJson() string {
+	if x != nil {
+		return x.PayloadJson
+	}
+	return ""
+}
+
+type ListWebhookEventsRequest struct {
+	state                protoimpl.MessageState `protogen:"open.v1"`
+	TenantId             string                 `protobuf:"bytes,1,opt,name=tenant_id,json=tenantId,proto3" json:"tenant_id,omitempty"`
+	BeforeCreatedUnixUtc int64                  `protobuf:"varint,2,opt,name=before_created_unix_utc,json=beforeCreatedUnixUtc,proto3" json:"before_created_unix_utc,omitempty"`
+	Limit                int32                  `protobuf:"varint,3,opt,name=limit,proto3" json:"limit,omitempty"`
+	Statuses             []string               `protobuf:"bytes,4,rep,name=statuses,proto3" json:"statuses,omitempty"`
+	unknownFields        protoimpl.UnknownFields
+	sizeCache            protoimpl.SizeCache
+}
+
+func (x *ListWebhookEventsRequest) Reset() {
+	*x = ListWebhookEventsRequest{}
+	mi := &file_api_credit_v1_credit_proto_msgTypes[37]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListWebhookEventsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListWebhookEventsRequest) ProtoMessage() {}
+
+func (x *ListWebhookEventsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_credit_v1_credit_proto_msgTypes[37]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListWebhookEventsRequest.ProtoReflect.Descriptor instead.
+func (*ListWebhookEventsRequest) Descriptor() ([]byte, []int) {
+	return file_api_credit_v1_credit_proto_rawDescGZIP(), []int{37}
+}
+
+func (x *ListWebhookEventsRequest) GetTenantId() string {
+	if x != nil {
+		return x.TenantId
+	}
+	return ""
+}
+
+func (x *ListWebhookEventsRequest) GetBeforeCreatedUnixUtc() int64 {
+	if x != nil {
+		return x.BeforeCreatedUnixUtc
+	}
+	return 0
+}
+
+func (x *ListWebhookEventsRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *ListWebhookEventsRequest) GetStatuses() []string {
+	if x != nil {
+		return x.Statuses
+	}
+	return nil
+}
+
+type ListWebhookEventsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Events        []*WebhookEvent        `protobuf:"bytes,1,rep,name=events,proto3" json:"events,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListWebhookEventsResponse) Reset() {
+	*x = ListWebhookEventsResponse{}
+	mi := &file_api_credit_v1_credit_proto_msgTypes[38]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListWebhookEventsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListWebhookEventsResponse) ProtoMessage() {}
+
+func (x *ListWebhookEventsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_credit_v1_credit_proto_msgTypes[38]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListWebhookEventsResponse.ProtoReflect.Descriptor instead.
+func (*ListWebhookEventsResponse) Descriptor() ([]byte, []int) {
+	return file_api_credit_v1_credit_proto_rawDescGZIP(), []int{38}
+}
+
+func (x *ListWebhookEventsResponse) GetEvents() []*WebhookEvent {
+	if x != nil {
+		return x.Events
+	}
+	return nil
+}
+
+type ReplayWebhookEventRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TenantId      string                 `protobuf:"bytes,1,opt,name=tenant_id,json=tenantId,proto3" json:"tenant_id,omitempty"`
+	EventId       string                 `protobuf:"bytes,2,opt,name=event_id,json=eventId,proto3" json:"event_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReplayWebhookEventRequest) Reset() {
+	*x = ReplayWebhookEventRequest{}
+	mi := &file_api_credit_v1_credit_proto_msgTypes[39]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReplayWebhookEventRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReplayWebhookEventRequest) ProtoMessage() {}
+
+func (x *ReplayWebhookEventRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_credit_v1_credit_proto_msgTypes[39]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReplayWebhookEventRequest.ProtoReflect.Descriptor instead.
+func (*ReplayWebhookEventRequest) Descriptor() ([]byte, []int) {
+	return file_api_credit_v1_credit_proto_rawDescGZIP(), []int{39}
+}
+
+func (x *ReplayWebhookEventRequest) GetTenantId() string {
+	if x != nil {
+		return x.TenantId
+	}
+	return ""
+}
+
+func (x *ReplayWebhookEventRequest) GetEventId() string {
+	if x != nil {
+		return x.EventId
+	}
+	return ""
+}
+
+type ReplayWebhookEventResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Event         *WebhookEvent          `protobuf:"bytes,1,opt,name=event,proto3" json:"event,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReplayWebhookEventResponse) Reset() {
+	*x = ReplayWebhookEventResponse{}
+	mi := &file_api_credit_v1_credit_proto_msgTypes[40]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReplayWebhookEventResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReplayWebhookEventResponse) ProtoMessage() {}
+
+func (x *ReplayWebhookEventResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_credit_v1_credit_proto_msgTypes[40]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReplayWebhookEventResponse.ProtoReflect.Descriptor instead.
+func (*ReplayWebhookEventResponse) Descriptor() ([]byte, []int) {
+	return file_api_credit_v1_credit_proto_rawDescGZIP(), []int{40}
+}
+
+func (x *ReplayWebhookEventResponse) GetEvent() *WebhookEvent {
+	if x != nil {
+		return x.Event
+	}
+	return nil
+}
+
+type Account struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	AccountId      string                 `protobuf:"bytes,1,opt,name=account_id,json=accountId,proto3" json:"account_id,omitempty"`
+	UserId         string                 `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	LedgerId       string                 `protobuf:"bytes,3,opt,name=ledger_id,json=ledgerId,proto3" json:"ledger_id,omitempty"`
+	CreatedUnixUtc int64                  `protobuf:"varint,4,opt,name=created_unix_utc,json=createdUnixUtc,proto3" json:"created_unix_utc,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *Account) Reset() {
+	*x = Account{}
+	mi := &file_api_credit_v1_credit_proto_msgTypes[41]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Account) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Account) ProtoMessage() {}
+
+func (x *Account) ProtoReflect() protoreflect.Message {
+	mi := &file_api_credit_v1_credit_proto_msgTypes[41]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Account.ProtoReflect.Descriptor instead.
+func (*Account) Descriptor() ([]byte, []int) {
+	return file_api_credit_v1_credit_proto_rawDescGZIP(), []int{41}
+}
+
+func (x *Account) GetAccountId() string {
+	if x != nil {
+		return x.AccountId
+	}
+	return ""
+}
+
+func (x *Account) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *Account) GetLedgerId() string {
+	if x != nil {
+		return x.LedgerId
+	}
+	return ""
+}
+
+func (x *Account) GetCreatedUnixUtc() int64 {
+	if x != nil {
+		return x.CreatedUnixUtc
+	}
+	return 0
+}
+
+type ListAccountsRequest struct {
+	state                protoimpl.MessageState `protogen:"open.v1"`
+	TenantId             string                 `protobuf:"bytes,1,opt,name=tenant_id,json=tenantId,proto3" json:"tenant_id,omitempty"`
+	LedgerId             string                 `protobuf:"bytes,2,opt,name=ledger_id,json=ledgerId,proto3" json:"ledger_id,omitempty"`
+	UserIdPrefix         string                 `protobuf:"bytes,3,opt,name=user_id_prefix,json=userIdPrefix,proto3" json:"user_id_prefix,omitempty"`
+	CreatedAfterUnixUtc  int64                  `protobuf:"varint,4,opt,name=created_after_unix_utc,json=createdAfterUnixUtc,proto3" json:"created_after_unix_utc,omitempty"`
+	BeforeCreatedUnixUtc int64                  `protobuf:"varint,5,opt,name=before_created_unix_utc,json=beforeCreatedUnixUtc,proto3" json:"before_created_unix_utc,omitempty"`
+	Limit                int32                  `protobuf:"varint,6,opt,name=limit,proto3" json:"limit,omitempty"`
+	NonZeroBalance       bool                   `protobuf:"varint,7,opt,name=non_zero_balance,json=nonZeroBalance,proto3" json:"non_zero_balance,omitempty"`
 	unknownFields        protoimpl.UnknownFields
 	sizeCache            protoimpl.SizeCache
 }
 
-func (x *ListEntriesRequest) Reset() {
-	*x = ListEntriesRequest{}
-	mi := &file_api_credit_v1_credit_proto_msgTypes[12]
+func (x *ListAccountsRequest) Reset() {
+	*x = ListAccountsRequest{}
+	mi := &file_api_credit_v1_credit_proto_msgTypes[42]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListAccountsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListAccountsRequest) ProtoMessage() {}
+
+func (x *ListAccountsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_credit_v1_credit_proto_msgTypes[42]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListAccountsRequest.ProtoReflect.Descriptor instead.
+func (*ListAccountsRequest) Descriptor() ([]byte, []int) {
+	return file_api_credit_v1_credit_proto_rawDescGZIP(), []int{42}
+}
+
+func (x *ListAccountsRequest) GetTenantId() string {
+	if x != nil {
+		return x.TenantId
+	}
+	return ""
+}
+
+func (x *ListAccountsRequest) GetLedgerId() string {
+	if x != nil {
+		return x.LedgerId
+	}
+	return ""
+}
+
+func (x *ListAccountsRequest) GetUserIdPrefix() string {
+	if x != nil {
+		return x.UserIdPrefix
+	}
+	return ""
+}
+
+func (x *ListAccountsRequest) GetCreatedAfterUnixUtc() int64 {
+	if x != nil {
+		return x.CreatedAfterUnixUtc
+	}
+	return 0
+}
+
+func (x *ListAccountsRequest) GetBeforeCreatedUnixUtc() int64 {
+	if x != nil {
+		return x.BeforeCreatedUnixUtc
+	}
+	return 0
+}
+
+func (x *ListAccountsRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *ListAccountsRequest) GetNonZeroBalance() bool {
+	if x != nil {
+		return x.NonZeroBalance
+	}
+	return false
+}
+
+type ListAccountsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Accounts      []*Account             `protobuf:"bytes,1,rep,name=accounts,proto3" json:"accounts,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListAccountsResponse) Reset() {
+	*x = ListAccountsResponse{}
+	mi := &file_api_credit_v1_credit_proto_msgTypes[43]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ListEntriesRequest) String() string {
+func (x *ListAccountsResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ListEntriesRequest) ProtoMessage() {}
+func (*ListAccountsResponse) ProtoMessage() {}
 
-func (x *ListEntriesRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_api_credit_v1_credit_proto_msgTypes[12]
+func (x *ListAccountsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_credit_v1_credit_proto_msgTypes[43]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1018,89 +3288,326 @@ func (x *ListEntriesRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ListEntriesRequest.ProtoReflect.Descriptor instead.
-func (*ListEntriesRequest) Descriptor() ([]byte, []int) {
-	return file_api_credit_v1_credit_proto_rawDescGZIP(), []int{12}
+// Deprecated: Use ListAccountsResponse.ProtoReflect.Descriptor instead.
+func (*ListAccountsResponse) Descriptor() ([]byte, []int) {
+	return file_api_credit_v1_credit_proto_rawDescGZIP(), []int{43}
 }
 
-func (x *ListEntriesRequest) GetUserId() string {
+func (x *ListAccountsResponse) GetAccounts() []*Account {
 	if x != nil {
-		return x.UserId
+		return x.Accounts
+	}
+	return nil
+}
+
+type GetTrialBalanceRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TenantId      string                 `protobuf:"bytes,1,opt,name=tenant_id,json=tenantId,proto3" json:"tenant_id,omitempty"`
+	AsOfUnixUtc   int64                  `protobuf:"varint,2,opt,name=as_of_unix_utc,json=asOfUnixUtc,proto3" json:"as_of_unix_utc,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetTrialBalanceRequest) Reset() {
+	*x = GetTrialBalanceRequest{}
+	mi := &file_api_credit_v1_credit_proto_msgTypes[44]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetTrialBalanceRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetTrialBalanceRequest) ProtoMessage() {}
+
+func (x *GetTrialBalanceRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_credit_v1_credit_proto_msgTypes[44]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetTrialBalanceRequest.ProtoReflect.Descriptor instead.
+func (*GetTrialBalanceRequest) Descriptor() ([]byte, []int) {
+	return file_api_credit_v1_credit_proto_rawDescGZIP(), []int{44}
+}
+
+func (x *GetTrialBalanceRequest) GetTenantId() string {
+	if x != nil {
+		return x.TenantId
 	}
 	return ""
 }
 
-func (x *ListEntriesRequest) GetBeforeUnixUtc() int64 {
+func (x *GetTrialBalanceRequest) GetAsOfUnixUtc() int64 {
 	if x != nil {
-		return x.BeforeUnixUtc
+		return x.AsOfUnixUtc
 	}
 	return 0
 }
 
-func (x *ListEntriesRequest) GetLimit() int32 {
+type TrialBalanceLine struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	LedgerId      string                 `protobuf:"bytes,1,opt,name=ledger_id,json=ledgerId,proto3" json:"ledger_id,omitempty"`
+	Account       string                 `protobuf:"bytes,2,opt,name=account,proto3" json:"account,omitempty"`
+	BalanceCents  int64                  `protobuf:"varint,3,opt,name=balance_cents,json=balanceCents,proto3" json:"balance_cents,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TrialBalanceLine) Reset() {
+	*x = TrialBalanceLine{}
+	mi := &file_api_credit_v1_credit_proto_msgTypes[45]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TrialBalanceLine) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TrialBalanceLine) ProtoMessage() {}
+
+func (x *TrialBalanceLine) ProtoReflect() protoreflect.Message {
+	mi := &file_api_credit_v1_credit_proto_msgTypes[45]
 	if x != nil {
-		return x.Limit
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
-	return 0
+	return mi.MessageOf(x)
 }
 
-func (x *ListEntriesRequest) GetLedgerId() string {
+// Deprecated: Use TrialBalanceLine.ProtoReflect.Descriptor instead.
+func (*TrialBalanceLine) Descriptor() ([]byte, []int) {
+	return file_api_credit_v1_credit_proto_rawDescGZIP(), []int{45}
+}
+
+func (x *TrialBalanceLine) GetLedgerId() string {
 	if x != nil {
 		return x.LedgerId
 	}
 	return ""
 }
 
-func (x *ListEntriesRequest) GetTenantId() string {
+func (x *TrialBalanceLine) GetAccount() string {
 	if x != nil {
-		return x.TenantId
+		return x.Account
 	}
 	return ""
 }
 
-func (x *ListEntriesRequest) GetTypes() []string {
+func (x *TrialBalanceLine) GetBalanceCents() int64 {
 	if x != nil {
-		return x.Types
+		return x.BalanceCents
+	}
+	return 0
+}
+
+type GetTrialBalanceResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	AsOfUnixUtc   int64                  `protobuf:"varint,1,opt,name=as_of_unix_utc,json=asOfUnixUtc,proto3" json:"as_of_unix_utc,omitempty"`
+	Lines         []*TrialBalanceLine    `protobuf:"bytes,2,rep,name=lines,proto3" json:"lines,omitempty"`
+	TotalCents    int64                  `protobuf:"varint,3,opt,name=total_cents,json=totalCents,proto3" json:"total_cents,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetTrialBalanceResponse) Reset() {
+	*x = GetTrialBalanceResponse{}
+	mi := &file_api_credit_v1_credit_proto_msgTypes[46]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetTrialBalanceResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetTrialBalanceResponse) ProtoMessage() {}
+
+func (x *GetTrialBalanceResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_credit_v1_credit_proto_msgTypes[46]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetTrialBalanceResponse.ProtoReflect.Descriptor instead.
+func (*GetTrialBalanceResponse) Descriptor() ([]byte, []int) {
+	return file_api_credit_v1_credit_proto_rawDescGZIP(), []int{46}
+}
+
+func (x *GetTrialBalanceResponse) GetAsOfUnixUtc() int64 {
+	if x != nil {
+		return x.AsOfUnixUtc
+	}
+	return 0
+}
+
+func (x *GetTrialBalanceResponse) GetLines() []*TrialBalanceLine {
+	if x != nil {
+		return x.Lines
 	}
 	return nil
 }
 
-func (x *ListEntriesRequest) GetReservationId() string {
+func (x *GetTrialBalanceResponse) GetTotalCents() int64 {
 	if x != nil {
-		return x.ReservationId
+		return x.TotalCents
+	}
+	return 0
+}
+
+type GetLiabilityReportRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TenantId      string                 `protobuf:"bytes,1,opt,name=tenant_id,json=tenantId,proto3" json:"tenant_id,omitempty"`
+	AsOfUnixUtc   int64                  `protobuf:"varint,2,opt,name=as_of_unix_utc,json=asOfUnixUtc,proto3" json:"as_of_unix_utc,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetLiabilityReportRequest) Reset() {
+	*x = GetLiabilityReportRequest{}
+	mi := &file_api_credit_v1_credit_proto_msgTypes[47]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetLiabilityReportRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetLiabilityReportRequest) ProtoMessage() {}
+
+func (x *GetLiabilityReportRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_credit_v1_credit_proto_msgTypes[47]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetLiabilityReportRequest.ProtoReflect.Descriptor instead.
+func (*GetLiabilityReportRequest) Descriptor() ([]byte, []int) {
+	return file_api_credit_v1_credit_proto_rawDescGZIP(), []int{47}
+}
+
+func (x *GetLiabilityReportRequest) GetTenantId() string {
+	if x != nil {
+		return x.TenantId
 	}
 	return ""
 }
 
-func (x *ListEntriesRequest) GetIdempotencyKeyPrefix() string {
+func (x *GetLiabilityReportRequest) GetAsOfUnixUtc() int64 {
 	if x != nil {
-		return x.IdempotencyKeyPrefix
+		return x.AsOfUnixUtc
+	}
+	return 0
+}
+
+type LiabilityBucket struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	LedgerId      string                 `protobuf:"bytes,1,opt,name=ledger_id,json=ledgerId,proto3" json:"ledger_id,omitempty"`
+	ExpiryMonth   string                 `protobuf:"bytes,2,opt,name=expiry_month,json=expiryMonth,proto3" json:"expiry_month,omitempty"`
+	AmountCents   int64                  `protobuf:"varint,3,opt,name=amount_cents,json=amountCents,proto3" json:"amount_cents,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LiabilityBucket) Reset() {
+	*x = LiabilityBucket{}
+	mi := &file_api_credit_v1_credit_proto_msgTypes[48]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LiabilityBucket) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LiabilityBucket) ProtoMessage() {}
+
+func (x *LiabilityBucket) ProtoReflect() protoreflect.Message {
+	mi := &file_api_credit_v1_credit_proto_msgTypes[48]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LiabilityBucket.ProtoReflect.Descriptor instead.
+func (*LiabilityBucket) Descriptor() ([]byte, []int) {
+	return file_api_credit_v1_credit_proto_rawDescGZIP(), []int{48}
+}
+
+func (x *LiabilityBucket) GetLedgerId() string {
+	if x != nil {
+		return x.LedgerId
 	}
 	return ""
 }
 
-type ListEntriesResponse struct {
+func (x *LiabilityBucket) GetExpiryMonth() string {
+	if x != nil {
+		return x.ExpiryMonth
+	}
+	return ""
+}
+
+func (x *LiabilityBucket) GetAmountCents() int64 {
+	if x != nil {
+		return x.AmountCents
+	}
+	return 0
+}
+
+type GetLiabilityReportResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Entries       []*Entry               `protobuf:"bytes,1,rep,name=entries,proto3" json:"entries,omitempty"`
+	AsOfUnixUtc   int64                  `protobuf:"varint,1,opt,name=as_of_unix_utc,json=asOfUnixUtc,proto3" json:"as_of_unix_utc,omitempty"`
+	Outstanding   []*LiabilityBucket     `protobuf:"bytes,2,rep,name=outstanding,proto3" json:"outstanding,omitempty"`
+	Breakage      []*LiabilityBucket     `protobuf:"bytes,3,rep,name=breakage,proto3" json:"breakage,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ListEntriesResponse) Reset() {
-	*x = ListEntriesResponse{}
-	mi := &file_api_credit_v1_credit_proto_msgTypes[13]
+func (x *GetLiabilityReportResponse) Reset() {
+	*x = GetLiabilityReportResponse{}
+	mi := &file_api_credit_v1_credit_proto_msgTypes[49]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ListEntriesResponse) String() string {
+func (x *GetLiabilityReportResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ListEntriesResponse) ProtoMessage() {}
+func (*GetLiabilityReportResponse) ProtoMessage() {}
 
-func (x *ListEntriesResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_api_credit_v1_credit_proto_msgTypes[13]
+func (x *GetLiabilityReportResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_credit_v1_credit_proto_msgTypes[49]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1111,48 +3618,56 @@ func (x *ListEntriesResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ListEntriesResponse.ProtoReflect.Descriptor instead.
-func (*ListEntriesResponse) Descriptor() ([]byte, []int) {
-	return file_api_credit_v1_credit_proto_rawDescGZIP(), []int{13}
+// Deprecated: Use GetLiabilityReportResponse.ProtoReflect.Descriptor instead.
+func (*GetLiabilityReportResponse) Descriptor() ([]byte, []int) {
+	return file_api_credit_v1_credit_proto_rawDescGZIP(), []int{49}
 }
 
-func (x *ListEntriesResponse) GetEntries() []*Entry {
+func (x *GetLiabilityReportResponse) GetAsOfUnixUtc() int64 {
 	if x != nil {
-		return x.Entries
+		return x.AsOfUnixUtc
+	}
+	return 0
+}
+
+func (x *GetLiabilityReportResponse) GetOutstanding() []*LiabilityBucket {
+	if x != nil {
+		return x.Outstanding
 	}
 	return nil
 }
 
-type Reservation struct {
-	state            protoimpl.MessageState `protogen:"open.v1"`
-	ReservationId    string                 `protobuf:"bytes,1,opt,name=reservation_id,json=reservationId,proto3" json:"reservation_id,omitempty"`
-	AmountCents      int64                  `protobuf:"varint,2,opt,name=amount_cents,json=amountCents,proto3" json:"amount_cents,omitempty"`
-	Status           string                 `protobuf:"bytes,3,opt,name=status,proto3" json:"status,omitempty"`
-	ExpiresAtUnixUtc int64                  `protobuf:"varint,4,opt,name=expires_at_unix_utc,json=expiresAtUnixUtc,proto3" json:"expires_at_unix_utc,omitempty"`
-	CreatedUnixUtc   int64                  `protobuf:"varint,5,opt,name=created_unix_utc,json=createdUnixUtc,proto3" json:"created_unix_utc,omitempty"`
-	UpdatedUnixUtc   int64                  `protobuf:"varint,6,opt,name=updated_unix_utc,json=updatedUnixUtc,proto3" json:"updated_unix_utc,omitempty"`
-	Expired          bool                   `protobuf:"varint,7,opt,name=expired,proto3" json:"expired,omitempty"`
-	HeldCents        int64                  `protobuf:"varint,8,opt,name=held_cents,json=heldCents,proto3" json:"held_cents,omitempty"`
-	CapturedCents    int64                  `protobuf:"varint,9,opt,name=captured_cents,json=capturedCents,proto3" json:"captured_cents,omitempty"`
-	unknownFields    protoimpl.UnknownFields
-	sizeCache        protoimpl.SizeCache
+func (x *GetLiabilityReportResponse) GetBreakage() []*LiabilityBucket {
+	if x != nil {
+		return x.Breakage
+	}
+	return nil
 }
 
-func (x *Reservation) Reset() {
-	*x = Reservation{}
-	mi := &file_api_credit_v1_credit_proto_msgTypes[14]
+type VerifyChainRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TenantId      string                 `protobuf:"bytes,1,opt,name=tenant_id,json=tenantId,proto3" json:"tenant_id,omitempty"`
+	UserId        string                 `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	LedgerId      string                 `protobuf:"bytes,3,opt,name=ledger_id,json=ledgerId,proto3" json:"ledger_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *VerifyChainRequest) Reset() {
+	*x = VerifyChainRequest{}
+	mi := &file_api_credit_v1_credit_proto_msgTypes[50]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *Reservation) String() string {
+func (x *VerifyChainRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Reservation) ProtoMessage() {}
+func (*VerifyChainRequest) ProtoMessage() {}
 
-func (x *Reservation) ProtoReflect() protoreflect.Message {
-	mi := &file_api_credit_v1_credit_proto_msgTypes[14]
+func (x *VerifyChainRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_credit_v1_credit_proto_msgTypes[50]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1163,99 +3678,120 @@ func (x *Reservation) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use Reservation.ProtoReflect.Descriptor instead.
-func (*Reservation) Descriptor() ([]byte, []int) {
-	return file_api_credit_v1_credit_proto_rawDescGZIP(), []int{14}
+// Deprecated: Use VerifyChainRequest.ProtoReflect.Descriptor instead.
+func (*VerifyChainRequest) Descriptor() ([]byte, []int) {
+	return file_api_credit_v1_credit_proto_rawDescGZIP(), []int{50}
 }
 
-func (x *Reservation) GetReservationId() string {
+func (x *VerifyChainRequest) GetTenantId() string {
 	if x != nil {
-		return x.ReservationId
+		return x.TenantId
 	}
 	return ""
 }
 
-func (x *Reservation) GetAmountCents() int64 {
+func (x *VerifyChainRequest) GetUserId() string {
 	if x != nil {
-		return x.AmountCents
+		return x.UserId
 	}
-	return 0
+	return ""
 }
 
-func (x *Reservation) GetStatus() string {
+func (x *VerifyChainRequest) GetLedgerId() string {
 	if x != nil {
-		return x.Status
+		return x.LedgerId
 	}
 	return ""
 }
 
-func (x *Reservation) GetExpiresAtUnixUtc() int64 {
-	if x != nil {
-		return x.ExpiresAtUnixUtc
-	}
-	return 0
+// ChainBreak is the first link that failed verification; entry_id is empty for missing_entry.
+type ChainBreak struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Sequence      int64                  `protobuf:"varint,1,opt,name=sequence,proto3" json:"sequence,omitempty"`
+	EntryId       string                 `protobuf:"bytes,2,opt,name=entry_id,json=entryId,proto3" json:"entry_id,omitempty"`
+	Reason        string                 `protobuf:"bytes,3,opt,name=reason,proto3" json:"reason,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
-func (x *Reservation) GetCreatedUnixUtc() int64 {
-	if x != nil {
-		return x.CreatedUnixUtc
-	}
-	return 0
+func (x *ChainBreak) Reset() {
+	*x = ChainBreak{}
+	mi := &file_api_credit_v1_credit_proto_msgTypes[51]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
 }
 
-func (x *Reservation) GetUpdatedUnixUtc() int64 {
-	if x != nil {
-		return x.UpdatedUnixUtc
-	}
-	return 0
+func (x *ChainBreak) String() string {
+	return protoimpl.X.MessageStringOf(x)
 }
 
-func (x *Reservation) GetExpired() bool {
+func (*ChainBreak) ProtoMessage() {}
+
+func (x *ChainBreak) ProtoReflect() protoreflect.Message {
+	mi := &file_api_credit_v1_credit_proto_msgTypes[51]
 	if x != nil {
-		return x.Expired
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
-	return false
+	return mi.MessageOf(x)
 }
 
-func (x *Reservation) GetHeldCents() int64 {
+// Deprecated: Use ChainBreak.ProtoReflect.Descriptor instead.
+func (*ChainBreak) Descriptor() ([]byte, []int) {
+	return file_api_credit_v1_credit_proto_rawDescGZIP(), []int{51}
+}
+
+func (x *ChainBreak) GetSequence() int64 {
 	if x != nil {
-		return x.HeldCents
+		return x.Sequence
 	}
 	return 0
 }
 
-func (x *Reservation) GetCapturedCents() int64 {
+func (x *ChainBreak) GetEntryId() string {
 	if x != nil {
-		return x.CapturedCents
+		return x.EntryId
 	}
-	return 0
+	return ""
 }
 
-type GetReservationRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
-	LedgerId      string                 `protobuf:"bytes,2,opt,name=ledger_id,json=ledgerId,proto3" json:"ledger_id,omitempty"`
-	TenantId      string                 `protobuf:"bytes,3,opt,name=tenant_id,json=tenantId,proto3" json:"tenant_id,omitempty"`
-	ReservationId string                 `protobuf:"bytes,4,opt,name=reservation_id,json=reservationId,proto3" json:"reservation_id,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+func (x *ChainBreak) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
 }
 
-func (x *GetReservationRequest) Reset() {
-	*x = GetReservationRequest{}
-	mi := &file_api_credit_v1_credit_proto_msgTypes[15]
+type VerifyChainResponse struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Valid          bool                   `protobuf:"varint,1,opt,name=valid,proto3" json:"valid,omitempty"`
+	HeadSequence   int64                  `protobuf:"varint,2,opt,name=head_sequence,json=headSequence,proto3" json:"head_sequence,omitempty"`
+	CheckedEntries int64                  `protobuf:"varint,3,opt,name=checked_entries,json=checkedEntries,proto3" json:"checked_entries,omitempty"`
+	Break          *ChainBreak            `protobuf:"bytes,4,opt,name=break,proto3" json:"break,omitempty"`
+	// archived_through_sequence is the last link removed by compaction; verification starts after it.
+	ArchivedThroughSequence int64 `protobuf:"varint,5,opt,name=archived_through_sequence,json=archivedThroughSequence,proto3" json:"archived_through_sequence,omitempty"`
+	unknownFields           protoimpl.UnknownFields
+	sizeCache               protoimpl.SizeCache
+}
+
+func (x *VerifyChainResponse) Reset() {
+	*x = VerifyChainResponse{}
+	mi := &file_api_credit_v1_credit_proto_msgTypes[52]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *GetReservationRequest) String() string {
+func (x *VerifyChainResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*GetReservationRequest) ProtoMessage() {}
+func (*VerifyChainResponse) ProtoMessage() {}
 
-func (x *GetReservationRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_api_credit_v1_credit_proto_msgTypes[15]
+func (x *VerifyChainResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_credit_v1_credit_proto_msgTypes[52]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1266,61 +3802,70 @@ func (x *GetReservationRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use GetReservationRequest.ProtoReflect.Descriptor instead.
-func (*GetReservationRequest) Descriptor() ([]byte, []int) {
-	return file_api_credit_v1_credit_proto_rawDescGZIP(), []int{15}
+// Deprecated: Use VerifyChainResponse.ProtoReflect.Descriptor instead.
+func (*VerifyChainResponse) Descriptor() ([]byte, []int) {
+	return file_api_credit_v1_credit_proto_rawDescGZIP(), []int{52}
 }
 
-func (x *GetReservationRequest) GetUserId() string {
+func (x *VerifyChainResponse) GetValid() bool {
 	if x != nil {
-		return x.UserId
+		return x.Valid
 	}
-	return ""
+	return false
 }
 
-func (x *GetReservationRequest) GetLedgerId() string {
+func (x *VerifyChainResponse) GetHeadSequence() int64 {
 	if x != nil {
-		return x.LedgerId
+		return x.HeadSequence
 	}
-	return ""
+	return 0
 }
 
-func (x *GetReservationRequest) GetTenantId() string {
+func (x *VerifyChainResponse) GetCheckedEntries() int64 {
 	if x != nil {
-		return x.TenantId
+		return x.CheckedEntries
 	}
-	return ""
+	return 0
 }
 
-func (x *GetReservationRequest) GetReservationId() string {
+func (x *VerifyChainResponse) GetBreak() *ChainBreak {
 	if x != nil {
-		return x.ReservationId
+		return x.Break
 	}
-	return ""
+	return nil
 }
 
-type GetReservationResponse struct {
+func (x *VerifyChainResponse) GetArchivedThroughSequence() int64 {
+	if x != nil {
+		return x.ArchivedThroughSequence
+	}
+	return 0
+}
+
+type VerifyAccountRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Reservation   *Reservation           `protobuf:"bytes,1,opt,name=reservation,proto3" json:"reservation,omitempty"`
+	TenantId      string                 `protobuf:"bytes,1,opt,name=tenant_id,json=tenantId,proto3" json:"tenant_id,omitempty"`
+	UserId        string                 `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	LedgerId      string                 `protobuf:"bytes,3,opt,name=ledger_id,json=ledgerId,proto3" json:"ledger_id,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *GetReservationResponse) Reset() {
-	*x = GetReservationResponse{}
-	mi := &file_api_credit_v1_credit_proto_msgTypes[16]
+func (x *VerifyAccountRequest) Reset() {
+	*x = VerifyAccountRequest{}
+	mi := &file_api_credit_v1_credit_proto_msgTypes[53]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *GetReservationResponse) String() string {
+func (x *VerifyAccountRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*GetReservationResponse) ProtoMessage() {}
+func (*VerifyAccountRequest) ProtoMessage() {}
 
-func (x *GetReservationResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_api_credit_v1_credit_proto_msgTypes[16]
+func (x *VerifyAccountRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_credit_v1_credit_proto_msgTypes[53]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1331,45 +3876,60 @@ func (x *GetReservationResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use GetReservationResponse.ProtoReflect.Descriptor instead.
-func (*GetReservationResponse) Descriptor() ([]byte, []int) {
-	return file_api_credit_v1_credit_proto_rawDescGZIP(), []int{16}
+// Deprecated: Use VerifyAccountRequest.ProtoReflect.Descriptor instead.
+func (*VerifyAccountRequest) Descriptor() ([]byte, []int) {
+	return file_api_credit_v1_credit_proto_rawDescGZIP(), []int{53}
 }
 
-func (x *GetReservationResponse) GetReservation() *Reservation {
+func (x *VerifyAccountRequest) GetTenantId() string {
 	if x != nil {
-		return x.Reservation
+		return x.TenantId
 	}
-	return nil
+	return ""
 }
 
-type ListReservationsRequest struct {
-	state                protoimpl.MessageState `protogen:"open.v1"`
-	UserId               string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
-	LedgerId             string                 `protobuf:"bytes,2,opt,name=ledger_id,json=ledgerId,proto3" json:"ledger_id,omitempty"`
-	TenantId             string                 `protobuf:"bytes,3,opt,name=tenant_id,json=tenantId,proto3" json:"tenant_id,omitempty"`
-	BeforeCreatedUnixUtc int64                  `protobuf:"varint,4,opt,name=before_created_unix_utc,json=beforeCreatedUnixUtc,proto3" json:"before_created_unix_utc,omitempty"`
-	Limit                int32                  `protobuf:"varint,5,opt,name=limit,proto3" json:"limit,omitempty"`
-	Statuses             []string               `protobuf:"bytes,6,rep,name=statuses,proto3" json:"statuses,omitempty"`
-	unknownFields        protoimpl.UnknownFields
-	sizeCache            protoimpl.SizeCache
+func (x *VerifyAccountRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
 }
 
-func (x *ListReservationsRequest) Reset() {
-	*x = ListReservationsRequest{}
-	mi := &file_api_credit_v1_credit_proto_msgTypes[17]
+func (x *VerifyAccountRequest) GetLedgerId() string {
+	if x != nil {
+		return x.LedgerId
+	}
+	return ""
+}
+
+// InvariantViolation is one broken consistency rule; entry_id, reservation_id, and the amounts are set when relevant.
+type InvariantViolation struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Check         string                 `protobuf:"bytes,1,opt,name=check,proto3" json:"check,omitempty"`
+	EntryId       string                 `protobuf:"bytes,2,opt,name=entry_id,json=entryId,proto3" json:"entry_id,omitempty"`
+	ReservationId string                 `protobuf:"bytes,3,opt,name=reservation_id,json=reservationId,proto3" json:"reservation_id,omitempty"`
+	ExpectedCents int64                  `protobuf:"varint,4,opt,name=expected_cents,json=expectedCents,proto3" json:"expected_cents,omitempty"`
+	ActualCents   int64                  `protobuf:"varint,5,opt,name=actual_cents,json=actualCents,proto3" json:"actual_cents,omitempty"`
+	Message       string                 `protobuf:"bytes,6,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *InvariantViolation) Reset() {
+	*x = InvariantViolation{}
+	mi := &file_api_credit_v1_credit_proto_msgTypes[54]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ListReservationsRequest) String() string {
+func (x *InvariantViolation) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ListReservationsRequest) ProtoMessage() {}
+func (*InvariantViolation) ProtoMessage() {}
 
-func (x *ListReservationsRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_api_credit_v1_credit_proto_msgTypes[17]
+func (x *InvariantViolation) ProtoReflect() protoreflect.Message {
+	mi := &file_api_credit_v1_credit_proto_msgTypes[54]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1380,75 +3940,79 @@ func (x *ListReservationsRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ListReservationsRequest.ProtoReflect.Descriptor instead.
-func (*ListReservationsRequest) Descriptor() ([]byte, []int) {
-	return file_api_credit_v1_credit_proto_rawDescGZIP(), []int{17}
+// Deprecated: Use InvariantViolation.ProtoReflect.Descriptor instead.
+func (*InvariantViolation) Descriptor() ([]byte, []int) {
+	return file_api_credit_v1_credit_proto_rawDescGZIP(), []int{54}
 }
 
-func (x *ListReservationsRequest) GetUserId() string {
+func (x *InvariantViolation) GetCheck() string {
 	if x != nil {
-		return x.UserId
+		return x.Check
 	}
 	return ""
 }
 
-func (x *ListReservationsRequest) GetLedgerId() string {
+func (x *InvariantViolation) GetEntryId() string {
 	if x != nil {
-		return x.LedgerId
+		return x.EntryId
 	}
 	return ""
 }
 
-func (x *ListReservationsRequest) GetTenantId() string {
+func (x *InvariantViolation) GetReservationId() string {
 	if x != nil {
-		return x.TenantId
+		return x.ReservationId
 	}
 	return ""
 }
 
-func (x *ListReservationsRequest) GetBeforeCreatedUnixUtc() int64 {
+func (x *InvariantViolation) GetExpectedCents() int64 {
 	if x != nil {
-		return x.BeforeCreatedUnixUtc
+		return x.ExpectedCents
 	}
 	return 0
 }
 
-func (x *ListReservationsRequest) GetLimit() int32 {
+func (x *InvariantViolation) GetActualCents() int64 {
 	if x != nil {
-		return x.Limit
+		return x.ActualCents
 	}
 	return 0
 }
 
-func (x *ListReservationsRequest) GetStatuses() []string {
+func (x *InvariantViolation) GetMessage() string {
 	if x != nil {
-		return x.Statuses
+		return x.Message
 	}
-	return nil
+	return ""
 }
 
-type ListReservationsResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Reservations  []*Reservation         `protobuf:"bytes,1,rep,name=reservations,proto3" json:"reservations,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+type VerifyAccountResponse struct {
+	state               protoimpl.MessageState `protogen:"open.v1"`
+	Valid               bool                   `protobuf:"varint,1,opt,name=valid,proto3" json:"valid,omitempty"`
+	CheckedUnixUtc      int64                  `protobuf:"varint,2,opt,name=checked_unix_utc,json=checkedUnixUtc,proto3" json:"checked_unix_utc,omitempty"`
+	CheckedEntries      int32                  `protobuf:"varint,3,opt,name=checked_entries,json=checkedEntries,proto3" json:"checked_entries,omitempty"`
+	CheckedReservations int32                  `protobuf:"varint,4,opt,name=checked_reservations,json=checkedReservations,proto3" json:"checked_reservations,omitempty"`
+	Violations          []*InvariantViolation  `protobuf:"bytes,5,rep,name=violations,proto3" json:"violations,omitempty"`
+	unknownFields       protoimpl.UnknownFields
+	sizeCache           protoimpl.SizeCache
 }
 
-func (x *ListReservationsResponse) Reset() {
-	*x = ListReservationsResponse{}
-	mi := &file_api_credit_v1_credit_proto_msgTypes[18]
+func (x *VerifyAccountResponse) Reset() {
+	*x = VerifyAccountResponse{}
+	mi := &file_api_credit_v1_credit_proto_msgTypes[55]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ListReservationsResponse) String() string {
+func (x *VerifyAccountResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ListReservationsResponse) ProtoMessage() {}
+func (*VerifyAccountResponse) ProtoMessage() {}
 
-func (x *ListReservationsResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_api_credit_v1_credit_proto_msgTypes[18]
+func (x *VerifyAccountResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_credit_v1_credit_proto_msgTypes[55]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1459,14 +4023,42 @@ func (x *ListReservationsResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ListReservationsResponse.ProtoReflect.Descriptor instead.
-func (*ListReservationsResponse) Descriptor() ([]byte, []int) {
-	return file_api_credit_v1_credit_proto_rawDescGZIP(), []int{18}
+// Deprecated: Use VerifyAccountResponse.ProtoReflect.Descriptor instead.
+func (*VerifyAccountResponse) Descriptor() ([]byte, []int) {
+	return file_api_credit_v1_credit_proto_rawDescGZIP(), []int{55}
 }
 
-func (x *ListReservationsResponse) GetReservations() []*Reservation {
+func (x *VerifyAccountResponse) GetValid() bool {
 	if x != nil {
-		return x.Reservations
+		return x.Valid
+	}
+	return false
+}
+
+func (x *VerifyAccountResponse) GetCheckedUnixUtc() int64 {
+	if x != nil {
+		return x.CheckedUnixUtc
+	}
+	return 0
+}
+
+func (x *VerifyAccountResponse) GetCheckedEntries() int32 {
+	if x != nil {
+		return x.CheckedEntries
+	}
+	return 0
+}
+
+func (x *VerifyAccountResponse) GetCheckedReservations() int32 {
+	if x != nil {
+		return x.CheckedReservations
+	}
+	return 0
+}
+
+func (x *VerifyAccountResponse) GetViolations() []*InvariantViolation {
+	if x != nil {
+		return x.Violations
 	}
 	return nil
 }
@@ -1482,7 +4074,7 @@ type AccountContext struct {
 
 func (x *AccountContext) Reset() {
 	*x = AccountContext{}
-	mi := &file_api_credit_v1_credit_proto_msgTypes[19]
+	mi := &file_api_credit_v1_credit_proto_msgTypes[56]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1494,7 +4086,7 @@ func (x *AccountContext) String() string {
 func (*AccountContext) ProtoMessage() {}
 
 func (x *AccountContext) ProtoReflect() protoreflect.Message {
-	mi := &file_api_credit_v1_credit_proto_msgTypes[19]
+	mi := &file_api_credit_v1_credit_proto_msgTypes[56]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1507,7 +4099,7 @@ func (x *AccountContext) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use AccountContext.ProtoReflect.Descriptor instead.
 func (*AccountContext) Descriptor() ([]byte, []int) {
-	return file_api_credit_v1_credit_proto_rawDescGZIP(), []int{19}
+	return file_api_credit_v1_credit_proto_rawDescGZIP(), []int{56}
 }
 
 func (x *AccountContext) GetUserId() string {
@@ -1532,18 +4124,19 @@ func (x *AccountContext) GetTenantId() string {
 }
 
 type BatchGrantOp struct {
-	state            protoimpl.MessageState `protogen:"open.v1"`
-	AmountCents      int64                  `protobuf:"varint,1,opt,name=amount_cents,json=amountCents,proto3" json:"amount_cents,omitempty"`
-	IdempotencyKey   string                 `protobuf:"bytes,2,opt,name=idempotency_key,json=idempotencyKey,proto3" json:"idempotency_key,omitempty"`
-	ExpiresAtUnixUtc int64                  `protobuf:"varint,3,opt,name=expires_at_unix_utc,json=expiresAtUnixUtc,proto3" json:"expires_at_unix_utc,omitempty"`
-	MetadataJson     string                 `protobuf:"bytes,4,opt,name=metadata_json,json=metadataJson,proto3" json:"metadata_json,omitempty"`
-	unknownFields    protoimpl.UnknownFields
-	sizeCache        protoimpl.SizeCache
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	AmountCents        int64                  `protobuf:"varint,1,opt,name=amount_cents,json=amountCents,proto3" json:"amount_cents,omitempty"`
+	IdempotencyKey     string                 `protobuf:"bytes,2,opt,name=idempotency_key,json=idempotencyKey,proto3" json:"idempotency_key,omitempty"`
+	ExpiresAtUnixUtc   int64                  `protobuf:"varint,3,opt,name=expires_at_unix_utc,json=expiresAtUnixUtc,proto3" json:"expires_at_unix_utc,omitempty"`
+	MetadataJson       string                 `protobuf:"bytes,4,opt,name=metadata_json,json=metadataJson,proto3" json:"metadata_json,omitempty"`
+	EffectiveAtUnixUtc int64                  `protobuf:"varint,5,opt,name=effective_at_unix_utc,json=effectiveAtUnixUtc,proto3" json:"effective_at_unix_utc,omitempty"`
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
 }
 
 func (x *BatchGrantOp) Reset() {
 	*x = BatchGrantOp{}
-	mi := &file_api_credit_v1_credit_proto_msgTypes[20]
+	mi := &file_api_credit_v1_credit_proto_msgTypes[57]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1555,7 +4148,7 @@ func (x *BatchGrantOp) String() string {
 func (*BatchGrantOp) ProtoMessage() {}
 
 func (x *BatchGrantOp) ProtoReflect() protoreflect.Message {
-	mi := &file_api_credit_v1_credit_proto_msgTypes[20]
+	mi := &file_api_credit_v1_credit_proto_msgTypes[57]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1568,7 +4161,7 @@ func (x *BatchGrantOp) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use BatchGrantOp.ProtoReflect.Descriptor instead.
 func (*BatchGrantOp) Descriptor() ([]byte, []int) {
-	return file_api_credit_v1_credit_proto_rawDescGZIP(), []int{20}
+	return file_api_credit_v1_credit_proto_rawDescGZIP(), []int{57}
 }
 
 func (x *BatchGrantOp) GetAmountCents() int64 {
@@ -1599,6 +4192,13 @@ func (x *BatchGrantOp) GetMetadataJson() string {
 	return ""
 }
 
+func (x *BatchGrantOp) GetEffectiveAtUnixUtc() int64 {
+	if x != nil {
+		return x.EffectiveAtUnixUtc
+	}
+	return 0
+}
+
 type BatchReserveOp struct {
 	state            protoimpl.MessageState `protogen:"open.v1"`
 	AmountCents      int64                  `protobuf:"varint,1,opt,name=amount_cents,json=amountCents,proto3" json:"amount_cents,omitempty"`
@@ -1612,7 +4212,7 @@ type BatchReserveOp struct {
 
 func (x *BatchReserveOp) Reset() {
 	*x = BatchReserveOp{}
-	mi := &file_api_credit_v1_credit_proto_msgTypes[21]
+	mi := &file_api_credit_v1_credit_proto_msgTypes[58]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1624,7 +4224,7 @@ func (x *BatchReserveOp) String() string {
 func (*BatchReserveOp) ProtoMessage() {}
 
 func (x *BatchReserveOp) ProtoReflect() protoreflect.Message {
-	mi := &file_api_credit_v1_credit_proto_msgTypes[21]
+	mi := &file_api_credit_v1_credit_proto_msgTypes[58]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1637,7 +4237,7 @@ func (x *BatchReserveOp) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use BatchReserveOp.ProtoReflect.Descriptor instead.
 func (*BatchReserveOp) Descriptor() ([]byte, []int) {
-	return file_api_credit_v1_credit_proto_rawDescGZIP(), []int{21}
+	return file_api_credit_v1_credit_proto_rawDescGZIP(), []int{58}
 }
 
 func (x *BatchReserveOp) GetAmountCents() int64 {
@@ -1687,7 +4287,7 @@ type BatchCaptureOp struct {
 
 func (x *BatchCaptureOp) Reset() {
 	*x = BatchCaptureOp{}
-	mi := &file_api_credit_v1_credit_proto_msgTypes[22]
+	mi := &file_api_credit_v1_credit_proto_msgTypes[59]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1699,7 +4299,7 @@ func (x *BatchCaptureOp) String() string {
 func (*BatchCaptureOp) ProtoMessage() {}
 
 func (x *BatchCaptureOp) ProtoReflect() protoreflect.Message {
-	mi := &file_api_credit_v1_credit_proto_msgTypes[22]
+	mi := &file_api_credit_v1_credit_proto_msgTypes[59]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1712,7 +4312,7 @@ func (x *BatchCaptureOp) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use BatchCaptureOp.ProtoReflect.Descriptor instead.
 func (*BatchCaptureOp) Descriptor() ([]byte, []int) {
-	return file_api_credit_v1_credit_proto_rawDescGZIP(), []int{22}
+	return file_api_credit_v1_credit_proto_rawDescGZIP(), []int{59}
 }
 
 func (x *BatchCaptureOp) GetReservationId() string {
@@ -1754,7 +4354,7 @@ type BatchReleaseOp struct {
 
 func (x *BatchReleaseOp) Reset() {
 	*x = BatchReleaseOp{}
-	mi := &file_api_credit_v1_credit_proto_msgTypes[23]
+	mi := &file_api_credit_v1_credit_proto_msgTypes[60]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1766,7 +4366,7 @@ func (x *BatchReleaseOp) String() string {
 func (*BatchReleaseOp) ProtoMessage() {}
 
 func (x *BatchReleaseOp) ProtoReflect() protoreflect.Message {
-	mi := &file_api_credit_v1_credit_proto_msgTypes[23]
+	mi := &file_api_credit_v1_credit_proto_msgTypes[60]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1779,7 +4379,7 @@ func (x *BatchReleaseOp) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use BatchReleaseOp.ProtoReflect.Descriptor instead.
 func (*BatchReleaseOp) Descriptor() ([]byte, []int) {
-	return file_api_credit_v1_credit_proto_rawDescGZIP(), []int{23}
+	return file_api_credit_v1_credit_proto_rawDescGZIP(), []int{60}
 }
 
 func (x *BatchReleaseOp) GetReservationId() string {
@@ -1814,7 +4414,7 @@ type BatchSpendOp struct {
 
 func (x *BatchSpendOp) Reset() {
 	*x = BatchSpendOp{}
-	mi := &file_api_credit_v1_credit_proto_msgTypes[24]
+	mi := &file_api_credit_v1_credit_proto_msgTypes[61]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1826,7 +4426,7 @@ func (x *BatchSpendOp) String() string {
 func (*BatchSpendOp) ProtoMessage() {}
 
 func (x *BatchSpendOp) ProtoReflect() protoreflect.Message {
-	mi := &file_api_credit_v1_credit_proto_msgTypes[24]
+	mi := &file_api_credit_v1_credit_proto_msgTypes[61]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1839,7 +4439,7 @@ func (x *BatchSpendOp) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use BatchSpendOp.ProtoReflect.Descriptor instead.
 func (*BatchSpendOp) Descriptor() ([]byte, []int) {
-	return file_api_credit_v1_credit_proto_rawDescGZIP(), []int{24}
+	return file_api_credit_v1_credit_proto_rawDescGZIP(), []int{61}
 }
 
 func (x *BatchSpendOp) GetAmountCents() int64 {
@@ -1879,7 +4479,7 @@ type BatchRefundOp struct {
 
 func (x *BatchRefundOp) Reset() {
 	*x = BatchRefundOp{}
-	mi := &file_api_credit_v1_credit_proto_msgTypes[25]
+	mi := &file_api_credit_v1_credit_proto_msgTypes[62]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1891,7 +4491,7 @@ func (x *BatchRefundOp) String() string {
 func (*BatchRefundOp) ProtoMessage() {}
 
 func (x *BatchRefundOp) ProtoReflect() protoreflect.Message {
-	mi := &file_api_credit_v1_credit_proto_msgTypes[25]
+	mi := &file_api_credit_v1_credit_proto_msgTypes[62]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1904,7 +4504,7 @@ func (x *BatchRefundOp) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use BatchRefundOp.ProtoReflect.Descriptor instead.
 func (*BatchRefundOp) Descriptor() ([]byte, []int) {
-	return file_api_credit_v1_credit_proto_rawDescGZIP(), []int{25}
+	return file_api_credit_v1_credit_proto_rawDescGZIP(), []int{62}
 }
 
 func (x *BatchRefundOp) GetOriginal() isBatchRefundOp_Original {
@@ -1987,7 +4587,7 @@ type BatchOperation struct {
 
 func (x *BatchOperation) Reset() {
 	*x = BatchOperation{}
-	mi := &file_api_credit_v1_credit_proto_msgTypes[26]
+	mi := &file_api_credit_v1_credit_proto_msgTypes[63]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1999,7 +4599,7 @@ func (x *BatchOperation) String() string {
 func (*BatchOperation) ProtoMessage() {}
 
 func (x *BatchOperation) ProtoReflect() protoreflect.Message {
-	mi := &file_api_credit_v1_credit_proto_msgTypes[26]
+	mi := &file_api_credit_v1_credit_proto_msgTypes[63]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2012,7 +4612,7 @@ func (x *BatchOperation) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use BatchOperation.ProtoReflect.Descriptor instead.
 func (*BatchOperation) Descriptor() ([]byte, []int) {
-	return file_api_credit_v1_credit_proto_rawDescGZIP(), []int{26}
+	return file_api_credit_v1_credit_proto_rawDescGZIP(), []int{63}
 }
 
 func (x *BatchOperation) GetOperationId() string {
@@ -2134,7 +4734,7 @@ type BatchRequest struct {
 
 func (x *BatchRequest) Reset() {
 	*x = BatchRequest{}
-	mi := &file_api_credit_v1_credit_proto_msgTypes[27]
+	mi := &file_api_credit_v1_credit_proto_msgTypes[64]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2146,7 +4746,7 @@ func (x *BatchRequest) String() string {
 func (*BatchRequest) ProtoMessage() {}
 
 func (x *BatchRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_api_credit_v1_credit_proto_msgTypes[27]
+	mi := &file_api_credit_v1_credit_proto_msgTypes[64]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2159,7 +4759,7 @@ func (x *BatchRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use BatchRequest.ProtoReflect.Descriptor instead.
 func (*BatchRequest) Descriptor() ([]byte, []int) {
-	return file_api_credit_v1_credit_proto_rawDescGZIP(), []int{27}
+	return file_api_credit_v1_credit_proto_rawDescGZIP(), []int{64}
 }
 
 func (x *BatchRequest) GetAccount() *AccountContext {
@@ -2198,7 +4798,7 @@ type BatchOperationResult struct {
 
 func (x *BatchOperationResult) Reset() {
 	*x = BatchOperationResult{}
-	mi := &file_api_credit_v1_credit_proto_msgTypes[28]
+	mi := &file_api_credit_v1_credit_proto_msgTypes[65]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2210,7 +4810,7 @@ func (x *BatchOperationResult) String() string {
 func (*BatchOperationResult) ProtoMessage() {}
 
 func (x *BatchOperationResult) ProtoReflect() protoreflect.Message {
-	mi := &file_api_credit_v1_credit_proto_msgTypes[28]
+	mi := &file_api_credit_v1_credit_proto_msgTypes[65]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2223,7 +4823,7 @@ func (x *BatchOperationResult) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use BatchOperationResult.ProtoReflect.Descriptor instead.
 func (*BatchOperationResult) Descriptor() ([]byte, []int) {
-	return file_api_credit_v1_credit_proto_rawDescGZIP(), []int{28}
+	return file_api_credit_v1_credit_proto_rawDescGZIP(), []int{65}
 }
 
 func (x *BatchOperationResult) GetOperationId() string {
@@ -2284,7 +4884,7 @@ type BatchResponse struct {
 
 func (x *BatchResponse) Reset() {
 	*x = BatchResponse{}
-	mi := &file_api_credit_v1_credit_proto_msgTypes[29]
+	mi := &file_api_credit_v1_credit_proto_msgTypes[66]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2296,7 +4896,7 @@ func (x *BatchResponse) String() string {
 func (*BatchResponse) ProtoMessage() {}
 
 func (x *BatchResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_api_credit_v1_credit_proto_msgTypes[29]
+	mi := &file_api_credit_v1_credit_proto_msgTypes[66]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2309,7 +4909,7 @@ func (x *BatchResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use BatchResponse.ProtoReflect.Descriptor instead.
 func (*BatchResponse) Descriptor() ([]byte, []int) {
-	return file_api_credit_v1_credit_proto_rawDescGZIP(), []int{29}
+	return file_api_credit_v1_credit_proto_rawDescGZIP(), []int{66}
 }
 
 func (x *BatchResponse) GetResults() []*BatchOperationResult {
@@ -2336,7 +4936,7 @@ const file_api_credit_v1_credit_proto_rawDesc = "" +
 	"\x0fBalanceResponse\x12\x1f\n" +
 	"\vtotal_cents\x18\x01 \x01(\x03R\n" +
 	"totalCents\x12'\n" +
-	"\x0favailable_cents\x18\x02 \x01(\x03R\x0eavailableCents\"\x81\x02\n" +
+	"\x0favailable_cents\x18\x02 \x01(\x03R\x0eavailableCents\"\xb4\x02\n" +
 	"\fGrantRequest\x12\x17\n" +
 	"\auser_id\x18\x01 \x01(\tR\x06userId\x12!\n" +
 	"\famount_cents\x18\x02 \x01(\x03R\vamountCents\x12'\n" +
@@ -2344,7 +4944,16 @@ const file_api_credit_v1_credit_proto_rawDesc = "" +
 	"\x13expires_at_unix_utc\x18\x04 \x01(\x03R\x10expiresAtUnixUtc\x12#\n" +
 	"\rmetadata_json\x18\x05 \x01(\tR\fmetadataJson\x12\x1b\n" +
 	"\tledger_id\x18\x06 \x01(\tR\bledgerId\x12\x1b\n" +
-	"\ttenant_id\x18\a \x01(\tR\btenantId\"\xaa\x02\n" +
+	"\ttenant_id\x18\a \x01(\tR\btenantId\x121\n" +
+	"\x15effective_at_unix_utc\x18\b \x01(\x03R\x12effectiveAtUnixUtc\"\xf3\x01\n" +
+	"\x12CancelGrantRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x1b\n" +
+	"\tledger_id\x18\x02 \x01(\tR\bledgerId\x12\x1b\n" +
+	"\ttenant_id\x18\x03 \x01(\tR\btenantId\x12&\n" +
+	"\x0egrant_entry_id\x18\x04 \x01(\tH\x00R\fgrantEntryId\x124\n" +
+	"\x15grant_idempotency_key\x18\x05 \x01(\tH\x00R\x13grantIdempotencyKey\x12#\n" +
+	"\rmetadata_json\x18\x06 \x01(\tR\fmetadataJsonB\a\n" +
+	"\x05grant\"\xaa\x02\n" +
 	"\x0eReserveRequest\x12\x17\n" +
 	"\auser_id\x18\x01 \x01(\tR\x06userId\x12!\n" +
 	"\famount_cents\x18\x02 \x01(\x03R\vamountCents\x12%\n" +
@@ -2386,10 +4995,42 @@ const file_api_credit_v1_credit_proto_rawDesc = "" +
 	"\x0fidempotency_key\x18\a \x01(\tR\x0eidempotencyKey\x12#\n" +
 	"\rmetadata_json\x18\b \x01(\tR\fmetadataJsonB\n" +
 	"\n" +
-	"\boriginal\"U\n" +
+	"\boriginal\"\xe1\x01\n" +
+	"\x19SpendAcrossLedgersRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x1d\n" +
+	"\n" +
+	"ledger_ids\x18\x02 \x03(\tR\tledgerIds\x12\x1b\n" +
+	"\ttenant_id\x18\x03 \x01(\tR\btenantId\x12!\n" +
+	"\famount_cents\x18\x04 \x01(\x03R\vamountCents\x12'\n" +
+	"\x0fidempotency_key\x18\x05 \x01(\tR\x0eidempotencyKey\x12#\n" +
+	"\rmetadata_json\x18\x06 \x01(\tR\fmetadataJson\"\x92\x01\n" +
+	"\vLedgerDebit\x12\x1b\n" +
+	"\tledger_id\x18\x01 \x01(\tR\bledgerId\x12\x19\n" +
+	"\bentry_id\x18\x02 \x01(\tR\aentryId\x12!\n" +
+	"\famount_cents\x18\x03 \x01(\x03R\vamountCents\x12(\n" +
+	"\x10created_unix_utc\x18\x04 \x01(\x03R\x0ecreatedUnixUtc\"L\n" +
+	"\x1aSpendAcrossLedgersResponse\x12.\n" +
+	"\x06debits\x18\x01 \x03(\v2\x16.credit.v1.LedgerDebitR\x06debits\"\x9c\x02\n" +
+	"\x1aRefundAcrossLedgersRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x1d\n" +
+	"\n" +
+	"ledger_ids\x18\x02 \x03(\tR\tledgerIds\x12\x1b\n" +
+	"\ttenant_id\x18\x03 \x01(\tR\btenantId\x128\n" +
+	"\x18original_idempotency_key\x18\x04 \x01(\tR\x16originalIdempotencyKey\x12!\n" +
+	"\famount_cents\x18\x05 \x01(\x03R\vamountCents\x12'\n" +
+	"\x0fidempotency_key\x18\x06 \x01(\tR\x0eidempotencyKey\x12#\n" +
+	"\rmetadata_json\x18\a \x01(\tR\fmetadataJson\"\xc0\x01\n" +
+	"\fLedgerRefund\x12\x1b\n" +
+	"\tledger_id\x18\x01 \x01(\tR\bledgerId\x12\x19\n" +
+	"\bentry_id\x18\x02 \x01(\tR\aentryId\x12!\n" +
+	"\famount_cents\x18\x03 \x01(\x03R\vamountCents\x12(\n" +
+	"\x10created_unix_utc\x18\x04 \x01(\x03R\x0ecreatedUnixUtc\x12+\n" +
+	"\x12refund_of_entry_id\x18\x05 \x01(\tR\x0frefundOfEntryId\"P\n" +
+	"\x1bRefundAcrossLedgersResponse\x121\n" +
+	"\arefunds\x18\x01 \x03(\v2\x17.credit.v1.LedgerRefundR\arefunds\"U\n" +
 	"\x0eRefundResponse\x12\x19\n" +
 	"\bentry_id\x18\x01 \x01(\tR\aentryId\x12(\n" +
-	"\x10created_unix_utc\x18\x02 \x01(\x03R\x0ecreatedUnixUtc\"\xf3\x02\n" +
+	"\x10created_unix_utc\x18\x02 \x01(\x03R\x0ecreatedUnixUtc\"\xe8\x03\n" +
 	"\x05Entry\x12\x19\n" +
 	"\bentry_id\x18\x01 \x01(\tR\aentryId\x12\x1d\n" +
 	"\n" +
@@ -2402,7 +5043,17 @@ const file_api_credit_v1_credit_proto_rawDesc = "" +
 	"\rmetadata_json\x18\b \x01(\tR\fmetadataJson\x12(\n" +
 	"\x10created_unix_utc\x18\t \x01(\x03R\x0ecreatedUnixUtc\x12+\n" +
 	"\x12refund_of_entry_id\x18\n" +
-	" \x01(\tR\x0frefundOfEntryId\"\x98\x02\n" +
+	" \x01(\tR\x0frefundOfEntryId\x121\n" +
+	"\x15effective_at_unix_utc\x18\v \x01(\x03R\x12effectiveAtUnixUtc\x12\x18\n" +
+	"\apending\x18\f \x01(\bR\apending\x12&\n" +
+	"\x05actor\x18\r \x01(\v2\x10.credit.v1.ActorR\x05actor\"\x85\x01\n" +
+	"\x05Actor\x12\x1c\n" +
+	"\n" +
+	"api_key_id\x18\x01 \x01(\tR\bapiKeyId\x12\x18\n" +
+	"\aservice\x18\x02 \x01(\tR\aservice\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\x03 \x01(\tR\trequestId\x12%\n" +
+	"\x0eclient_address\x18\x04 \x01(\tR\rclientAddress\"\xc0\x02\n" +
 	"\x12ListEntriesRequest\x12\x17\n" +
 	"\auser_id\x18\x01 \x01(\tR\x06userId\x12&\n" +
 	"\x0fbefore_unix_utc\x18\x02 \x01(\x03R\rbeforeUnixUtc\x12\x14\n" +
@@ -2411,9 +5062,22 @@ const file_api_credit_v1_credit_proto_rawDesc = "" +
 	"\ttenant_id\x18\x05 \x01(\tR\btenantId\x12\x14\n" +
 	"\x05types\x18\x06 \x03(\tR\x05types\x12%\n" +
 	"\x0ereservation_id\x18\a \x01(\tR\rreservationId\x124\n" +
-	"\x16idempotency_key_prefix\x18\b \x01(\tR\x14idempotencyKeyPrefix\"A\n" +
+	"\x16idempotency_key_prefix\x18\b \x01(\tR\x14idempotencyKeyPrefix\x12&\n" +
+	"\x05actor\x18\t \x01(\v2\x10.credit.v1.ActorR\x05actor\"z\n" +
 	"\x13ListEntriesResponse\x12*\n" +
-	"\aentries\x18\x01 \x03(\v2\x10.credit.v1.EntryR\aentries\"\xd2\x02\n" +
+	"\aentries\x18\x01 \x03(\v2\x10.credit.v1.EntryR\aentries\x127\n" +
+	"\x18archived_before_unix_utc\x18\x02 \x01(\x03R\x15archivedBeforeUnixUtc\"\xa1\x01\n" +
+	"\x13WatchEntriesRequest\x12\x1b\n" +
+	"\ttenant_id\x18\x01 \x01(\tR\btenantId\x12\x1b\n" +
+	"\tledger_id\x18\x02 \x01(\tR\bledgerId\x12\x17\n" +
+	"\auser_id\x18\x03 \x01(\tR\x06userId\x12\x14\n" +
+	"\x05types\x18\x04 \x03(\tR\x05types\x12!\n" +
+	"\fafter_cursor\x18\x05 \x01(\x03R\vafterCursor\"\x83\x01\n" +
+	"\vEntryChange\x12\x16\n" +
+	"\x06cursor\x18\x01 \x01(\x03R\x06cursor\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\tR\x06userId\x12\x1b\n" +
+	"\tledger_id\x18\x03 \x01(\tR\bledgerId\x12&\n" +
+	"\x05entry\x18\x04 \x01(\v2\x10.credit.v1.EntryR\x05entry\"\xd2\x02\n" +
 	"\vReservation\x12%\n" +
 	"\x0ereservation_id\x18\x01 \x01(\tR\rreservationId\x12!\n" +
 	"\famount_cents\x18\x02 \x01(\x03R\vamountCents\x12\x16\n" +
@@ -2440,16 +5104,162 @@ const file_api_credit_v1_credit_proto_rawDesc = "" +
 	"\x05limit\x18\x05 \x01(\x05R\x05limit\x12\x1a\n" +
 	"\bstatuses\x18\x06 \x03(\tR\bstatuses\"V\n" +
 	"\x18ListReservationsResponse\x12:\n" +
-	"\freservations\x18\x01 \x03(\v2\x16.credit.v1.ReservationR\freservations\"c\n" +
+	"\freservations\x18\x01 \x03(\v2\x16.credit.v1.ReservationR\freservations\"\xd5\x03\n" +
+	"\rGrantSchedule\x12\x1f\n" +
+	"\vschedule_id\x18\x01 \x01(\tR\n" +
+	"scheduleId\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\tR\x06userId\x12\x1b\n" +
+	"\tledger_id\x18\x03 \x01(\tR\bledgerId\x12!\n" +
+	"\famount_cents\x18\x04 \x01(\x03R\vamountCents\x12\x1a\n" +
+	"\binterval\x18\x05 \x01(\tR\binterval\x12$\n" +
+	"\x0estart_unix_utc\x18\x06 \x01(\x03R\fstartUnixUtc\x12 \n" +
+	"\fend_unix_utc\x18\a \x01(\x03R\n" +
+	"endUnixUtc\x12*\n" +
+	"\x11grant_ttl_seconds\x18\b \x01(\x03R\x0fgrantTtlSeconds\x12#\n" +
+	"\rmetadata_json\x18\t \x01(\tR\fmetadataJson\x12\x16\n" +
+	"\x06status\x18\n" +
+	" \x01(\tR\x06status\x12)\n" +
+	"\x11next_run_unix_utc\x18\v \x01(\x03R\x0enextRunUnixUtc\x12(\n" +
+	"\x10created_unix_utc\x18\f \x01(\x03R\x0ecreatedUnixUtc\x12(\n" +
+	"\x10updated_unix_utc\x18\r \x01(\x03R\x0eupdatedUnixUtc\"\xe8\x02\n" +
+	"\x1aCreateGrantScheduleRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x1b\n" +
+	"\tledger_id\x18\x02 \x01(\tR\bledgerId\x12\x1b\n" +
+	"\ttenant_id\x18\x03 \x01(\tR\btenantId\x12\x1f\n" +
+	"\vschedule_id\x18\x04 \x01(\tR\n" +
+	"scheduleId\x12!\n" +
+	"\famount_cents\x18\x05 \x01(\x03R\vamountCents\x12\x1a\n" +
+	"\binterval\x18\x06 \x01(\tR\binterval\x12$\n" +
+	"\x0estart_unix_utc\x18\a \x01(\x03R\fstartUnixUtc\x12 \n" +
+	"\fend_unix_utc\x18\b \x01(\x03R\n" +
+	"endUnixUtc\x12*\n" +
+	"\x11grant_ttl_seconds\x18\t \x01(\x03R\x0fgrantTtlSeconds\x12#\n" +
+	"\rmetadata_json\x18\n" +
+	" \x01(\tR\fmetadataJson\"S\n" +
+	"\x1bCreateGrantScheduleResponse\x124\n" +
+	"\bschedule\x18\x01 \x01(\v2\x18.credit.v1.GrantScheduleR\bschedule\"\xd7\x01\n" +
+	"\x19ListGrantSchedulesRequest\x12\x1b\n" +
+	"\ttenant_id\x18\x01 \x01(\tR\btenantId\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\tR\x06userId\x12\x1b\n" +
+	"\tledger_id\x18\x03 \x01(\tR\bledgerId\x125\n" +
+	"\x17before_created_unix_utc\x18\x04 \x01(\x03R\x14beforeCreatedUnixUtc\x12\x14\n" +
+	"\x05limit\x18\x05 \x01(\x05R\x05limit\x12\x1a\n" +
+	"\bstatuses\x18\x06 \x03(\tR\bstatuses\"T\n" +
+	"\x1aListGrantSchedulesResponse\x126\n" +
+	"\tschedules\x18\x01 \x03(\v2\x18.credit.v1.GrantScheduleR\tschedules\"Z\n" +
+	"\x1aCancelGrantScheduleRequest\x12\x1b\n" +
+	"\ttenant_id\x18\x01 \x01(\tR\btenantId\x12\x1f\n" +
+	"\vschedule_id\x18\x02 \x01(\tR\n" +
+	"scheduleId\"S\n" +
+	"\x1bCancelGrantScheduleResponse\x124\n" +
+	"\bschedule\x18\x01 \x01(\v2\x18.credit.v1.GrantScheduleR\bschedule\"\xc9\x02\n" +
+	"\fWebhookEvent\x12\x19\n" +
+	"\bevent_id\x18\x01 \x01(\tR\aeventId\x12\x1d\n" +
+	"\n" +
+	"event_type\x18\x02 \x01(\tR\teventType\x12\x16\n" +
+	"\x06status\x18\x03 \x01(\tR\x06status\x12\x1a\n" +
+	"\battempts\x18\x04 \x01(\x05R\battempts\x121\n" +
+	"\x15next_attempt_unix_utc\x18\x05 \x01(\x03R\x12nextAttemptUnixUtc\x12\x1d\n" +
+	"\n" +
+	"last_error\x18\x06 \x01(\tR\tlastError\x12(\n" +
+	"\x10created_unix_utc\x18\a \x01(\x03R\x0ecreatedUnixUtc\x12,\n" +
+	"\x12delivered_unix_utc\x18\b \x01(\x03R\x10deliveredUnixUtc\x12!\n" +
+	"\fpayload_json\x18\t \x01(\tR\vpayloadJson\"\xa0\x01\n" +
+	"\x18ListWebhookEventsRequest\x12\x1b\n" +
+	"\ttenant_id\x18\x01 \x01(\tR\btenantId\x125\n" +
+	"\x17before_created_unix_utc\x18\x02 \x01(\x03R\x14beforeCreatedUnixUtc\x12\x14\n" +
+	"\x05limit\x18\x03 \x01(\x05R\x05limit\x12\x1a\n" +
+	"\bstatuses\x18\x04 \x03(\tR\bstatuses\"L\n" +
+	"\x19ListWebhookEventsResponse\x12/\n" +
+	"\x06events\x18\x01 \x03(\v2\x17.credit.v1.WebhookEventR\x06events\"S\n" +
+	"\x19ReplayWebhookEventRequest\x12\x1b\n" +
+	"\ttenant_id\x18\x01 \x01(\tR\btenantId\x12\x19\n" +
+	"\bevent_id\x18\x02 \x01(\tR\aeventId\"K\n" +
+	"\x1aReplayWebhookEventResponse\x12-\n" +
+	"\x05event\x18\x01 \x01(\v2\x17.credit.v1.WebhookEventR\x05event\"\x88\x01\n" +
+	"\aAccount\x12\x1d\n" +
+	"\n" +
+	"account_id\x18\x01 \x01(\tR\taccountId\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\tR\x06userId\x12\x1b\n" +
+	"\tledger_id\x18\x03 \x01(\tR\bledgerId\x12(\n" +
+	"\x10created_unix_utc\x18\x04 \x01(\x03R\x0ecreatedUnixUtc\"\xa1\x02\n" +
+	"\x13ListAccountsRequest\x12\x1b\n" +
+	"\ttenant_id\x18\x01 \x01(\tR\btenantId\x12\x1b\n" +
+	"\tledger_id\x18\x02 \x01(\tR\bledgerId\x12$\n" +
+	"\x0euser_id_prefix\x18\x03 \x01(\tR\fuserIdPrefix\x123\n" +
+	"\x16created_after_unix_utc\x18\x04 \x01(\x03R\x13createdAfterUnixUtc\x125\n" +
+	"\x17before_created_unix_utc\x18\x05 \x01(\x03R\x14beforeCreatedUnixUtc\x12\x14\n" +
+	"\x05limit\x18\x06 \x01(\x05R\x05limit\x12(\n" +
+	"\x10non_zero_balance\x18\a \x01(\bR\x0enonZeroBalance\"F\n" +
+	"\x14ListAccountsResponse\x12.\n" +
+	"\baccounts\x18\x01 \x03(\v2\x12.credit.v1.AccountR\baccounts\"Z\n" +
+	"\x16GetTrialBalanceRequest\x12\x1b\n" +
+	"\ttenant_id\x18\x01 \x01(\tR\btenantId\x12#\n" +
+	"\x0eas_of_unix_utc\x18\x02 \x01(\x03R\vasOfUnixUtc\"n\n" +
+	"\x10TrialBalanceLine\x12\x1b\n" +
+	"\tledger_id\x18\x01 \x01(\tR\bledgerId\x12\x18\n" +
+	"\aaccount\x18\x02 \x01(\tR\aaccount\x12#\n" +
+	"\rbalance_cents\x18\x03 \x01(\x03R\fbalanceCents\"\x92\x01\n" +
+	"\x17GetTrialBalanceResponse\x12#\n" +
+	"\x0eas_of_unix_utc\x18\x01 \x01(\x03R\vasOfUnixUtc\x121\n" +
+	"\x05lines\x18\x02 \x03(\v2\x1b.credit.v1.TrialBalanceLineR\x05lines\x12\x1f\n" +
+	"\vtotal_cents\x18\x03 \x01(\x03R\n" +
+	"totalCents\"]\n" +
+	"\x19GetLiabilityReportRequest\x12\x1b\n" +
+	"\ttenant_id\x18\x01 \x01(\tR\btenantId\x12#\n" +
+	"\x0eas_of_unix_utc\x18\x02 \x01(\x03R\vasOfUnixUtc\"t\n" +
+	"\x0fLiabilityBucket\x12\x1b\n" +
+	"\tledger_id\x18\x01 \x01(\tR\bledgerId\x12!\n" +
+	"\fexpiry_month\x18\x02 \x01(\tR\vexpiryMonth\x12!\n" +
+	"\famount_cents\x18\x03 \x01(\x03R\vamountCents\"\xb7\x01\n" +
+	"\x1aGetLiabilityReportResponse\x12#\n" +
+	"\x0eas_of_unix_utc\x18\x01 \x01(\x03R\vasOfUnixUtc\x12<\n" +
+	"\voutstanding\x18\x02 \x03(\v2\x1a.credit.v1.LiabilityBucketR\voutstanding\x126\n" +
+	"\bbreakage\x18\x03 \x03(\v2\x1a.credit.v1.LiabilityBucketR\bbreakage\"g\n" +
+	"\x12VerifyChainRequest\x12\x1b\n" +
+	"\ttenant_id\x18\x01 \x01(\tR\btenantId\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\tR\x06userId\x12\x1b\n" +
+	"\tledger_id\x18\x03 \x01(\tR\bledgerId\"[\n" +
+	"\n" +
+	"ChainBreak\x12\x1a\n" +
+	"\bsequence\x18\x01 \x01(\x03R\bsequence\x12\x19\n" +
+	"\bentry_id\x18\x02 \x01(\tR\aentryId\x12\x16\n" +
+	"\x06reason\x18\x03 \x01(\tR\x06reason\"\xe2\x01\n" +
+	"\x13VerifyChainResponse\x12\x14\n" +
+	"\x05valid\x18\x01 \x01(\bR\x05valid\x12#\n" +
+	"\rhead_sequence\x18\x02 \x01(\x03R\fheadSequence\x12'\n" +
+	"\x0fchecked_entries\x18\x03 \x01(\x03R\x0echeckedEntries\x12+\n" +
+	"\x05break\x18\x04 \x01(\v2\x15.credit.v1.ChainBreakR\x05break\x12:\n" +
+	"\x19archived_through_sequence\x18\x05 \x01(\x03R\x17archivedThroughSequence\"i\n" +
+	"\x14VerifyAccountRequest\x12\x1b\n" +
+	"\ttenant_id\x18\x01 \x01(\tR\btenantId\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\tR\x06userId\x12\x1b\n" +
+	"\tledger_id\x18\x03 \x01(\tR\bledgerId\"\xd0\x01\n" +
+	"\x12InvariantViolation\x12\x14\n" +
+	"\x05check\x18\x01 \x01(\tR\x05check\x12\x19\n" +
+	"\bentry_id\x18\x02 \x01(\tR\aentryId\x12%\n" +
+	"\x0ereservation_id\x18\x03 \x01(\tR\rreservationId\x12%\n" +
+	"\x0eexpected_cents\x18\x04 \x01(\x03R\rexpectedCents\x12!\n" +
+	"\factual_cents\x18\x05 \x01(\x03R\vactualCents\x12\x18\n" +
+	"\amessage\x18\x06 \x01(\tR\amessage\"\xf2\x01\n" +
+	"\x15VerifyAccountResponse\x12\x14\n" +
+	"\x05valid\x18\x01 \x01(\bR\x05valid\x12(\n" +
+	"\x10checked_unix_utc\x18\x02 \x01(\x03R\x0echeckedUnixUtc\x12'\n" +
+	"\x0fchecked_entries\x18\x03 \x01(\x05R\x0echeckedEntries\x121\n" +
+	"\x14checked_reservations\x18\x04 \x01(\x05R\x13checkedReservations\x12=\n" +
+	"\n" +
+	"violations\x18\x05 \x03(\v2\x1d.credit.v1.InvariantViolationR\n" +
+	"violations\"c\n" +
 	"\x0eAccountContext\x12\x17\n" +
 	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x1b\n" +
 	"\tledger_id\x18\x02 \x01(\tR\bledgerId\x12\x1b\n" +
-	"\ttenant_id\x18\x03 \x01(\tR\btenantId\"\xae\x01\n" +
+	"\ttenant_id\x18\x03 \x01(\tR\btenantId\"\xe1\x01\n" +
 	"\fBatchGrantOp\x12!\n" +
 	"\famount_cents\x18\x01 \x01(\x03R\vamountCents\x12'\n" +
 	"\x0fidempotency_key\x18\x02 \x01(\tR\x0eidempotencyKey\x12-\n" +
 	"\x13expires_at_unix_utc\x18\x03 \x01(\x03R\x10expiresAtUnixUtc\x12#\n" +
-	"\rmetadata_json\x18\x04 \x01(\tR\fmetadataJson\"\xd7\x01\n" +
+	"\rmetadata_json\x18\x04 \x01(\tR\fmetadataJson\x121\n" +
+	"\x15effective_at_unix_utc\x18\x05 \x01(\x03R\x12effectiveAtUnixUtc\"\xd7\x01\n" +
 	"\x0eBatchReserveOp\x12!\n" +
 	"\famount_cents\x18\x01 \x01(\x03R\vamountCents\x12%\n" +
 	"\x0ereservation_id\x18\x02 \x01(\tR\rreservationId\x12'\n" +
@@ -2502,20 +5312,34 @@ const file_api_credit_v1_credit_proto_rawDesc = "" +
 	"\x10created_unix_utc\x18\x06 \x01(\x03R\x0ecreatedUnixUtc\x12\x1c\n" +
 	"\tduplicate\x18\a \x01(\bR\tduplicate\"J\n" +
 	"\rBatchResponse\x129\n" +
-	"\aresults\x18\x01 \x03(\v2\x1f.credit.v1.BatchOperationResultR\aresults2\xe1\x05\n" +
+	"\aresults\x18\x01 \x03(\v2\x1f.credit.v1.BatchOperationResultR\aresults2\xd6\x0f\n" +
 	"\rCreditService\x12C\n" +
 	"\n" +
 	"GetBalance\x12\x19.credit.v1.BalanceRequest\x1a\x1a.credit.v1.BalanceResponse\x122\n" +
-	"\x05Grant\x12\x17.credit.v1.GrantRequest\x1a\x10.credit.v1.Empty\x126\n" +
+	"\x05Grant\x12\x17.credit.v1.GrantRequest\x1a\x10.credit.v1.Empty\x12>\n" +
+	"\vCancelGrant\x12\x1d.credit.v1.CancelGrantRequest\x1a\x10.credit.v1.Empty\x126\n" +
 	"\aReserve\x12\x19.credit.v1.ReserveRequest\x1a\x10.credit.v1.Empty\x126\n" +
 	"\aCapture\x12\x19.credit.v1.CaptureRequest\x1a\x10.credit.v1.Empty\x126\n" +
 	"\aRelease\x12\x19.credit.v1.ReleaseRequest\x1a\x10.credit.v1.Empty\x122\n" +
 	"\x05Spend\x12\x17.credit.v1.SpendRequest\x1a\x10.credit.v1.Empty\x12=\n" +
-	"\x06Refund\x12\x18.credit.v1.RefundRequest\x1a\x19.credit.v1.RefundResponse\x12:\n" +
+	"\x06Refund\x12\x18.credit.v1.RefundRequest\x1a\x19.credit.v1.RefundResponse\x12a\n" +
+	"\x12SpendAcrossLedgers\x12$.credit.v1.SpendAcrossLedgersRequest\x1a%.credit.v1.SpendAcrossLedgersResponse\x12d\n" +
+	"\x13RefundAcrossLedgers\x12%.credit.v1.RefundAcrossLedgersRequest\x1a&.credit.v1.RefundAcrossLedgersResponse\x12:\n" +
 	"\x05Batch\x12\x17.credit.v1.BatchRequest\x1a\x18.credit.v1.BatchResponse\x12L\n" +
-	"\vListEntries\x12\x1d.credit.v1.ListEntriesRequest\x1a\x1e.credit.v1.ListEntriesResponse\x12U\n" +
+	"\vListEntries\x12\x1d.credit.v1.ListEntriesRequest\x1a\x1e.credit.v1.ListEntriesResponse\x12H\n" +
+	"\fWatchEntries\x12\x1e.credit.v1.WatchEntriesRequest\x1a\x16.credit.v1.EntryChange0\x01\x12U\n" +
 	"\x0eGetReservation\x12 .credit.v1.GetReservationRequest\x1a!.credit.v1.GetReservationResponse\x12[\n" +
-	"\x10ListReservations\x12\".credit.v1.ListReservationsRequest\x1a#.credit.v1.ListReservationsResponseB?Z=github.com/MarkoPoloResearchLab/ledger/api/credit/v1;creditv1b\x06proto3"
+	"\x10ListReservations\x12\".credit.v1.ListReservationsRequest\x1a#.credit.v1.ListReservationsResponse\x12d\n" +
+	"\x13CreateGrantSchedule\x12%.credit.v1.CreateGrantScheduleRequest\x1a&.credit.v1.CreateGrantScheduleResponse\x12a\n" +
+	"\x12ListGrantSchedules\x12$.credit.v1.ListGrantSchedulesRequest\x1a%.credit.v1.ListGrantSchedulesResponse\x12d\n" +
+	"\x13CancelGrantSchedule\x12%.credit.v1.CancelGrantScheduleRequest\x1a&.credit.v1.CancelGrantScheduleResponse\x12O\n" +
+	"\fListAccounts\x12\x1e.credit.v1.ListAccountsRequest\x1a\x1f.credit.v1.ListAccountsResponse\x12X\n" +
+	"\x0fGetTrialBalance\x12!.credit.v1.GetTrialBalanceRequest\x1a\".credit.v1.GetTrialBalanceResponse\x12a\n" +
+	"\x12GetLiabilityReport\x12$.credit.v1.GetLiabilityReportRequest\x1a%.credit.v1.GetLiabilityReportResponse\x12^\n" +
+	"\x11ListWebhookEvents\x12#.credit.v1.ListWebhookEventsRequest\x1a$.credit.v1.ListWebhookEventsResponse\x12a\n" +
+	"\x12ReplayWebhookEvent\x12$.credit.v1.ReplayWebhookEventRequest\x1a%.credit.v1.ReplayWebhookEventResponse\x12L\n" +
+	"\vVerifyChain\x12\x1d.credit.v1.VerifyChainRequest\x1a\x1e.credit.v1.VerifyChainResponse\x12R\n" +
+	"\rVerifyAccount\x12\x1f.credit.v1.VerifyAccountRequest\x1a .credit.v1.VerifyAccountResponseB?Z=github.com/MarkoPoloResearchLab/ledger/api/credit/v1;creditv1b\x06proto3"
 
 var (
 	file_api_credit_v1_credit_proto_rawDescOnce sync.Once
@@ -2529,79 +5353,160 @@ func file_api_credit_v1_credit_proto_rawDescGZIP() []byte {
 	return file_api_credit_v1_credit_proto_rawDescData
 }
 
-var file_api_credit_v1_credit_proto_msgTypes = make([]protoimpl.MessageInfo, 30)
+var file_api_credit_v1_credit_proto_msgTypes = make([]protoimpl.MessageInfo, 67)
 var file_api_credit_v1_credit_proto_goTypes = []any{
-	(*Empty)(nil),                    // 0: credit.v1.Empty
-	(*Amount)(nil),                   // 1: credit.v1.Amount
-	(*BalanceRequest)(nil),           // 2: credit.v1.BalanceRequest
-	(*BalanceResponse)(nil),          // 3: credit.v1.BalanceResponse
-	(*GrantRequest)(nil),             // 4: credit.v1.GrantRequest
-	(*ReserveRequest)(nil),           // 5: credit.v1.ReserveRequest
-	(*CaptureRequest)(nil),           // 6: credit.v1.CaptureRequest
-	(*ReleaseRequest)(nil),           // 7: credit.v1.ReleaseRequest
-	(*SpendRequest)(nil),             // 8: credit.v1.SpendRequest
-	(*RefundRequest)(nil),            // 9: credit.v1.RefundRequest
-	(*RefundResponse)(nil),           // 10: credit.v1.RefundResponse
-	(*Entry)(nil),                    // 11: credit.v1.Entry
-	(*ListEntriesRequest)(nil),       // 12: credit.v1.ListEntriesRequest
-	(*ListEntriesResponse)(nil),      // 13: credit.v1.ListEntriesResponse
-	(*Reservation)(nil),              // 14: credit.v1.Reservation
-	(*GetReservationRequest)(nil),    // 15: credit.v1.GetReservationRequest
-	(*GetReservationResponse)(nil),   // 16: credit.v1.GetReservationResponse
-	(*ListReservationsRequest)(nil),  // 17: credit.v1.ListReservationsRequest
-	(*ListReservationsResponse)(nil), // 18: credit.v1.ListReservationsResponse
-	(*AccountContext)(nil),           // 19: credit.v1.AccountContext
-	(*BatchGrantOp)(nil),             // 20: credit.v1.BatchGrantOp
-	(*BatchReserveOp)(nil),           // 21: credit.v1.BatchReserveOp
-	(*BatchCaptureOp)(nil),           // 22: credit.v1.BatchCaptureOp
-	(*BatchReleaseOp)(nil),           // 23: credit.v1.BatchReleaseOp
-	(*BatchSpendOp)(nil),             // 24: credit.v1.BatchSpendOp
-	(*BatchRefundOp)(nil),            // 25: credit.v1.BatchRefundOp
-	(*BatchOperation)(nil),           // 26: credit.v1.BatchOperation
-	(*BatchRequest)(nil),             // 27: credit.v1.BatchRequest
-	(*BatchOperationResult)(nil),     // 28: credit.v1.BatchOperationResult
-	(*BatchResponse)(nil),            // 29: credit.v1.BatchResponse
+	(*Empty)(nil),                       // 0: credit.v1.Empty
+	(*Amount)(nil),                      // 1: credit.v1.Amount
+	(*BalanceRequest)(nil),              // 2: credit.v1.BalanceRequest
+	(*BalanceResponse)(nil),             // 3: credit.v1.BalanceResponse
+	(*GrantRequest)(nil),                // 4: credit.v1.GrantRequest
+	(*CancelGrantRequest)(nil),          // 5: credit.v1.CancelGrantRequest
+	(*ReserveRequest)(nil),              // 6: credit.v1.ReserveRequest
+	(*CaptureRequest)(nil),              // 7: credit.v1.CaptureRequest
+	(*ReleaseRequest)(nil),              // 8: credit.v1.ReleaseRequest
+	(*SpendRequest)(nil),                // 9: credit.v1.SpendRequest
+	(*RefundRequest)(nil),               // 10: credit.v1.RefundRequest
+	(*SpendAcrossLedgersRequest)(nil),   // 11: credit.v1.SpendAcrossLedgersRequest
+	(*LedgerDebit)(nil),                 // 12: credit.v1.LedgerDebit
+	(*SpendAcrossLedgersResponse)(nil),  // 13: credit.v1.SpendAcrossLedgersResponse
+	(*RefundAcrossLedgersRequest)(nil),  // 14: credit.v1.RefundAcrossLedgersRequest
+	(*LedgerRefund)(nil),                // 15: credit.v1.LedgerRefund
+	(*RefundAcrossLedgersResponse)(nil), // 16: credit.v1.RefundAcrossLedgersResponse
+	(*RefundResponse)(nil),              // 17: credit.v1.RefundResponse
+	(*Entry)(nil),                       // 18: credit.v1.Entry
+	(*Actor)(nil),                       // 19: credit.v1.Actor
+	(*ListEntriesRequest)(nil),          // 20: credit.v1.ListEntriesRequest
+	(*ListEntriesResponse)(nil),         // 21: credit.v1.ListEntriesResponse
+	(*WatchEntriesRequest)(nil),         // 22: credit.v1.WatchEntriesRequest
+	(*EntryChange)(nil),                 // 23: credit.v1.EntryChange
+	(*Reservation)(nil),                 // 24: credit.v1.Reservation
+	(*GetReservationRequest)(nil),       // 25: credit.v1.GetReservationRequest
+	(*GetReservationResponse)(nil),      // 26: credit.v1.GetReservationResponse
+	(*ListReservationsRequest)(nil),     // 27: credit.v1.ListReservationsRequest
+	(*ListReservationsResponse)(nil),    // 28: credit.v1.ListReservationsResponse
+	(*GrantSchedule)(nil),               // 29: credit.v1.GrantSchedule
+	(*CreateGrantScheduleRequest)(nil),  // 30: credit.v1.CreateGrantScheduleRequest
+	(*CreateGrantScheduleResponse)(nil), // 31: credit.v1.CreateGrantScheduleResponse
+	(*ListGrantSchedulesRequest)(nil),   // 32: credit.v1.ListGrantSchedulesRequest
+	(*ListGrantSchedulesResponse)(nil),  // 33: credit.v1.ListGrantSchedulesResponse
+	(*CancelGrantScheduleRequest)(nil),  // 34: credit.v1.CancelGrantScheduleRequest
+	(*CancelGrantScheduleResponse)(nil), // 35: credit.v1.CancelGrantScheduleResponse
+	(*WebhookEvent)(nil),                // 36: credit.v1.WebhookEvent
+	(*ListWebhookEventsRequest)(nil),    // 37: credit.v1.ListWebhookEventsRequest
+	(*ListWebhookEventsResponse)(nil),   // 38: credit.v1.ListWebhookEventsResponse
+	(*ReplayWebhookEventRequest)(nil),   // 39: credit.v1.ReplayWebhookEventRequest
+	(*ReplayWebhookEventResponse)(nil),  // 40: credit.v1.ReplayWebhookEventResponse
+	(*Account)(nil),                     // 41: credit.v1.Account
+	(*ListAccountsRequest)(nil),         // 42: credit.v1.ListAccountsRequest
+	(*ListAccountsResponse)(nil),        // 43: credit.v1.ListAccountsResponse
+	(*GetTrialBalanceRequest)(nil),      // 44: credit.v1.GetTrialBalanceRequest
+	(*TrialBalanceLine)(nil),            // 45: credit.v1.TrialBalanceLine
+	(*GetTrialBalanceResponse)(nil),     // 46: credit.v1.GetTrialBalanceResponse
+	(*GetLiabilityReportRequest)(nil),   // 47: credit.v1.GetLiabilityReportRequest
+	(*LiabilityBucket)(nil),             // 48: credit.v1.LiabilityBucket
+	(*GetLiabilityReportResponse)(nil),  // 49: credit.v1.GetLiabilityReportResponse
+	(*VerifyChainRequest)(nil),          // 50: credit.v1.VerifyChainRequest
+	(*ChainBreak)(nil),                  // 51: credit.v1.ChainBreak
+	(*VerifyChainResponse)(nil),         // 52: credit.v1.VerifyChainResponse
+	(*VerifyAccountRequest)(nil),        // 53: credit.v1.VerifyAccountRequest
+	(*InvariantViolation)(nil),          // 54: credit.v1.InvariantViolation
+	(*VerifyAccountResponse)(nil),       // 55: credit.v1.VerifyAccountResponse
+	(*AccountContext)(nil),              // 56: credit.v1.AccountContext
+	(*BatchGrantOp)(nil),                // 57: credit.v1.BatchGrantOp
+	(*BatchReserveOp)(nil),              // 58: credit.v1.BatchReserveOp
+	(*BatchCaptureOp)(nil),              // 59: credit.v1.BatchCaptureOp
+	(*BatchReleaseOp)(nil),              // 60: credit.v1.BatchReleaseOp
+	(*BatchSpendOp)(nil),                // 61: credit.v1.BatchSpendOp
+	(*BatchRefundOp)(nil),               // 62: credit.v1.BatchRefundOp
+	(*BatchOperation)(nil),              // 63: credit.v1.BatchOperation
+	(*BatchRequest)(nil),                // 64: credit.v1.BatchRequest
+	(*BatchOperationResult)(nil),        // 65: credit.v1.BatchOperationResult
+	(*BatchResponse)(nil),               // 66: credit.v1.BatchResponse
 }
 var file_api_credit_v1_credit_proto_depIdxs = []int32{
-	11, // 0: credit.v1.ListEntriesResponse.entries:type_name -> credit.v1.Entry
-	14, // 1: credit.v1.GetReservationResponse.reservation:type_name -> credit.v1.Reservation
-	14, // 2: credit.v1.ListReservationsResponse.reservations:type_name -> credit.v1.Reservation
-	20, // 3: credit.v1.BatchOperation.grant:type_name -> credit.v1.BatchGrantOp
-	24, // 4: credit.v1.BatchOperation.spend:type_name -> credit.v1.BatchSpendOp
-	21, // 5: credit.v1.BatchOperation.reserve:type_name -> credit.v1.BatchReserveOp
-	22, // 6: credit.v1.BatchOperation.capture:type_name -> credit.v1.BatchCaptureOp
-	23, // 7: credit.v1.BatchOperation.release:type_name -> credit.v1.BatchReleaseOp
-	25, // 8: credit.v1.BatchOperation.refund:type_name -> credit.v1.BatchRefundOp
-	19, // 9: credit.v1.BatchRequest.account:type_name -> credit.v1.AccountContext
-	26, // 10: credit.v1.BatchRequest.operations:type_name -> credit.v1.BatchOperation
-	28, // 11: credit.v1.BatchResponse.results:type_name -> credit.v1.BatchOperationResult
-	2,  // 12: credit.v1.CreditService.GetBalance:input_type -> credit.v1.BalanceRequest
-	4,  // 13: credit.v1.CreditService.Grant:input_type -> credit.v1.GrantRequest
-	5,  // 14: credit.v1.CreditService.Reserve:input_type -> credit.v1.ReserveRequest
-	6,  // 15: credit.v1.CreditService.Capture:input_type -> credit.v1.CaptureRequest
-	7,  // 16: credit.v1.CreditService.Release:input_type -> credit.v1.ReleaseRequest
-	8,  // 17: credit.v1.CreditService.Spend:input_type -> credit.v1.SpendRequest
-	9,  // 18: credit.v1.CreditService.Refund:input_type -> credit.v1.RefundRequest
-	27, // 19: credit.v1.CreditService.Batch:input_type -> credit.v1.BatchRequest
-	12, // 20: credit.v1.CreditService.ListEntries:input_type -> credit.v1.ListEntriesRequest
-	15, // 21: credit.v1.CreditService.GetReservation:input_type -> credit.v1.GetReservationRequest
-	17, // 22: credit.v1.CreditService.ListReservations:input_type -> credit.v1.ListReservationsRequest
-	3,  // 23: credit.v1.CreditService.GetBalance:output_type -> credit.v1.BalanceResponse
-	0,  // 24: credit.v1.CreditService.Grant:output_type -> credit.v1.Empty
-	0,  // 25: credit.v1.CreditService.Reserve:output_type -> credit.v1.Empty
-	0,  // 26: credit.v1.CreditService.Capture:output_type -> credit.v1.Empty
-	0,  // 27: credit.v1.CreditService.Release:output_type -> credit.v1.Empty
-	0,  // 28: credit.v1.CreditService.Spend:output_type -> credit.v1.Empty
-	10, // 29: credit.v1.CreditService.Refund:output_type -> credit.v1.RefundResponse
-	29, // 30: credit.v1.CreditService.Batch:output_type -> credit.v1.BatchResponse
-	13, // 31: credit.v1.CreditService.ListEntries:output_type -> credit.v1.ListEntriesResponse
-	16, // 32: credit.v1.CreditService.GetReservation:output_type -> credit.v1.GetReservationResponse
-	18, // 33: credit.v1.CreditService.ListReservations:output_type -> credit.v1.ListReservationsResponse
-	23, // [23:34] is the sub-list for method output_type
-	12, // [12:23] is the sub-list for method input_type
-	12, // [12:12] is the sub-list for extension type_name
-	12, // [12:12] is the sub-list for extension extendee
-	0,  // [0:12] is the sub-list for field type_name
+	12, // 0: credit.v1.SpendAcrossLedgersResponse.debits:type_name -> credit.v1.LedgerDebit
+	15, // 1: credit.v1.RefundAcrossLedgersResponse.refunds:type_name -> credit.v1.LedgerRefund
+	19, // 2: credit.v1.Entry.actor:type_name -> credit.v1.Actor
+	19, // 3: credit.v1.ListEntriesRequest.actor:type_name -> credit.v1.Actor
+	18, // 4: credit.v1.ListEntriesResponse.entries:type_name -> credit.v1.Entry
+	18, // 5: credit.v1.EntryChange.entry:type_name -> credit.v1.Entry
+	24, // 6: credit.v1.GetReservationResponse.reservation:type_name -> credit.v1.Reservation
+	24, // 7: credit.v1.ListReservationsResponse.reservations:type_name -> credit.v1.Reservation
+	29, // 8: credit.v1.CreateGrantScheduleResponse.schedule:type_name -> credit.v1.GrantSchedule
+	29, // 9: credit.v1.ListGrantSchedulesResponse.schedules:type_name -> credit.v1.GrantSchedule
+	29, // 10: credit.v1.CancelGrantScheduleResponse.schedule:type_name -> credit.v1.GrantSchedule
+	36, // 11: credit.v1.ListWebhookEventsResponse.events:type_name -> credit.v1.WebhookEvent
+	36, // 12: credit.v1.ReplayWebhookEventResponse.event:type_name -> credit.v1.WebhookEvent
+	41, // 13: credit.v1.ListAccountsResponse.accounts:type_name -> credit.v1.Account
+	45, // 14: credit.v1.GetTrialBalanceResponse.lines:type_name -> credit.v1.TrialBalanceLine
+	48, // 15: credit.v1.GetLiabilityReportResponse.outstanding:type_name -> credit.v1.LiabilityBucket
+	48, // 16: credit.v1.GetLiabilityReportResponse.breakage:type_name -> credit.v1.LiabilityBucket
+	51, // 17: credit.v1.VerifyChainResponse.break:type_name -> credit.v1.ChainBreak
+	54, // 18: credit.v1.VerifyAccountResponse.violations:type_name -> credit.v1.InvariantViolation
+	57, // 19: credit.v1.BatchOperation.grant:type_name -> credit.v1.BatchGrantOp
+	61, // 20: credit.v1.BatchOperation.spend:type_name -> credit.v1.BatchSpendOp
+	58, // 21: credit.v1.BatchOperation.reserve:type_name -> credit.v1.BatchReserveOp
+	59, // 22: credit.v1.BatchOperation.capture:type_name -> credit.v1.BatchCaptureOp
+	60, // 23: credit.v1.BatchOperation.release:type_name -> credit.v1.BatchReleaseOp
+	62, // 24: credit.v1.BatchOperation.refund:type_name -> credit.v1.BatchRefundOp
+	56, // 25: credit.v1.BatchRequest.account:type_name -> credit.v1.AccountContext
+	63, // 26: credit.v1.BatchRequest.operations:type_name -> credit.v1.BatchOperation
+	65, // 27: credit.v1.BatchResponse.results:type_name -> credit.v1.BatchOperationResult
+	2,  // 28: credit.v1.CreditService.GetBalance:input_type -> credit.v1.BalanceRequest
+	4,  // 29: credit.v1.CreditService.Grant:input_type -> credit.v1.GrantRequest
+	5,  // 30: credit.v1.CreditService.CancelGrant:input_type -> credit.v1.CancelGrantRequest
+	6,  // 31: credit.v1.CreditService.Reserve:input_type -> credit.v1.ReserveRequest
+	7,  // 32: credit.v1.CreditService.Capture:input_type -> credit.v1.CaptureRequest
+	8,  // 33: credit.v1.CreditService.Release:input_type -> credit.v1.ReleaseRequest
+	9,  // 34: credit.v1.CreditService.Spend:input_type -> credit.v1.SpendRequest
+	10, // 35: credit.v1.CreditService.Refund:input_type -> credit.v1.RefundRequest
+	11, // 36: credit.v1.CreditService.SpendAcrossLedgers:input_type -> credit.v1.SpendAcrossLedgersRequest
+	14, // 37: credit.v1.CreditService.RefundAcrossLedgers:input_type -> credit.v1.RefundAcrossLedgersRequest
+	64, // 38: credit.v1.CreditService.Batch:input_type -> credit.v1.BatchRequest
+	20, // 39: credit.v1.CreditService.ListEntries:input_type -> credit.v1.ListEntriesRequest
+	22, // 40: credit.v1.CreditService.WatchEntries:input_type -> credit.v1.WatchEntriesRequest
+	25, // 41: credit.v1.CreditService.GetReservation:input_type -> credit.v1.GetReservationRequest
+	27, // 42: credit.v1.CreditService.ListReservations:input_type -> credit.v1.ListReservationsRequest
+	30, // 43: credit.v1.CreditService.CreateGrantSchedule:input_type -> credit.v1.CreateGrantScheduleRequest
+	32, // 44: credit.v1.CreditService.ListGrantSchedules:input_type -> credit.v1.ListGrantSchedulesRequest
+	34, // 45: credit.v1.CreditService.CancelGrantSchedule:input_type -> credit.v1.CancelGrantScheduleRequest
+	42, // 46: credit.v1.CreditService.ListAccounts:input_type -> credit.v1.ListAccountsRequest
+	44, // 47: credit.v1.CreditService.GetTrialBalance:input_type -> credit.v1.GetTrialBalanceRequest
+	47, // 48: credit.v1.CreditService.GetLiabilityReport:input_type -> credit.v1.GetLiabilityReportRequest
+	37, // 49: credit.v1.CreditService.ListWebhookEvents:input_type -> credit.v1.ListWebhookEventsRequest
+	39, // 50: credit.v1.CreditService.ReplayWebhookEvent:input_type -> credit.v1.ReplayWebhookEventRequest
+	50, // 51: credit.v1.CreditService.VerifyChain:input_type -> credit.v1.VerifyChainRequest
+	53, // 52: credit.v1.CreditService.VerifyAccount:input_type -> credit.v1.VerifyAccountRequest
+	3,  // 53: credit.v1.CreditService.GetBalance:output_type -> credit.v1.BalanceResponse
+	0,  // 54: credit.v1.CreditService.Grant:output_type -> credit.v1.Empty
+	0,  // 55: credit.v1.CreditService.CancelGrant:output_type -> credit.v1.Empty
+	0,  // 56: credit.v1.CreditService.Reserve:output_type -> credit.v1.Empty
+	0,  // 57: credit.v1.CreditService.Capture:output_type -> credit.v1.Empty
+	0,  // 58: credit.v1.CreditService.Release:output_type -> credit.v1.Empty
+	0,  // 59: credit.v1.CreditService.Spend:output_type -> credit.v1.Empty
+	17, // 60: credit.v1.CreditService.Refund:output_type -> credit.v1.RefundResponse
+	13, // 61: credit.v1.CreditService.SpendAcrossLedgers:output_type -> credit.v1.SpendAcrossLedgersResponse
+	16, // 62: credit.v1.CreditService.RefundAcrossLedgers:output_type -> credit.v1.RefundAcrossLedgersResponse
+	66, // 63: credit.v1.CreditService.Batch:output_type -> credit.v1.BatchResponse
+	21, // 64: credit.v1.CreditService.ListEntries:output_type -> credit.v1.ListEntriesResponse
+	23, // 65: credit.v1.CreditService.WatchEntries:output_type -> credit.v1.EntryChange
+	26, // 66: credit.v1.CreditService.GetReservation:output_type -> credit.v1.GetReservationResponse
+	28, // 67: credit.v1.CreditService.ListReservations:output_type -> credit.v1.ListReservationsResponse
+	31, // 68: credit.v1.CreditService.CreateGrantSchedule:output_type -> credit.v1.CreateGrantScheduleResponse
+	33, // 69: credit.v1.CreditService.ListGrantSchedules:output_type -> credit.v1.ListGrantSchedulesResponse
+	35, // 70: credit.v1.CreditService.CancelGrantSchedule:output_type -> credit.v1.CancelGrantScheduleResponse
+	43, // 71: credit.v1.CreditService.ListAccounts:output_type -> credit.v1.ListAccountsResponse
+	46, // 72: credit.v1.CreditService.GetTrialBalance:output_type -> credit.v1.GetTrialBalanceResponse
+	49, // 73: credit.v1.CreditService.GetLiabilityReport:output_type -> credit.v1.GetLiabilityReportResponse
+	38, // 74: credit.v1.CreditService.ListWebhookEvents:output_type -> credit.v1.ListWebhookEventsResponse
+	40, // 75: credit.v1.CreditService.ReplayWebhookEvent:output_type -> credit.v1.ReplayWebhookEventResponse
+	52, // 76: credit.v1.CreditService.VerifyChain:output_type -> credit.v1.VerifyChainResponse
+	55, // 77: credit.v1.CreditService.VerifyAccount:output_type -> credit.v1.VerifyAccountResponse
+	53, // [53:78] is the sub-list for method output_type
+	28, // [28:53] is the sub-list for method input_type
+	28, // [28:28] is the sub-list for extension type_name
+	28, // [28:28] is the sub-list for extension extendee
+	0,  // [0:28] is the sub-list for field type_name
 }
 
 func init() { file_api_credit_v1_credit_proto_init() }
@@ -2609,15 +5514,19 @@ func file_api_credit_v1_credit_proto_init() {
 	if File_api_credit_v1_credit_proto != nil {
 		return
 	}
-	file_api_credit_v1_credit_proto_msgTypes[9].OneofWrappers = []any{
+	file_api_credit_v1_credit_proto_msgTypes[5].OneofWrappers = []any{
+		(*CancelGrantRequest_GrantEntryId)(nil),
+		(*CancelGrantRequest_GrantIdempotencyKey)(nil),
+	}
+	file_api_credit_v1_credit_proto_msgTypes[10].OneofWrappers = []any{
 		(*RefundRequest_OriginalEntryId)(nil),
 		(*RefundRequest_OriginalIdempotencyKey)(nil),
 	}
-	file_api_credit_v1_credit_proto_msgTypes[25].OneofWrappers = []any{
+	file_api_credit_v1_credit_proto_msgTypes[62].OneofWrappers = []any{
 		(*BatchRefundOp_OriginalEntryId)(nil),
 		(*BatchRefundOp_OriginalIdempotencyKey)(nil),
 	}
-	file_api_credit_v1_credit_proto_msgTypes[26].OneofWrappers = []any{
+	file_api_credit_v1_credit_proto_msgTypes[63].OneofWrappers = []any{
 		(*BatchOperation_Grant)(nil),
 		(*BatchOperation_Spend)(nil),
 		(*BatchOperation_Reserve)(nil),
@@ -2631,7 +5540,7 @@ func file_api_credit_v1_credit_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_api_credit_v1_credit_proto_rawDesc), len(file_api_credit_v1_credit_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   30,
+			NumMessages:   67,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -19,17 +19,31 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	CreditService_GetBalance_FullMethodName       = "/credit.v1.CreditService/GetBalance"
-	CreditService_Grant_FullMethodName            = "/credit.v1.CreditService/Grant"
-	CreditService_Reserve_FullMethodName          = "/credit.v1.CreditService/Reserve"
-	CreditService_Capture_FullMethodName          = "/credit.v1.CreditService/Capture"
-	CreditService_Release_FullMethodName          = "/credit.v1.CreditService/Release"
-	CreditService_Spend_FullMethodName            = "/credit.v1.CreditService/Spend"
-	CreditService_Refund_FullMethodName           = "/credit.v1.CreditService/Refund"
-	CreditService_Batch_FullMethodName            = "/credit.v1.CreditService/Batch"
-	CreditService_ListEntries_FullMethodName      = "/credit.v1.CreditService/ListEntries"
-	CreditService_GetReservation_FullMethodName   = "/credit.v1.CreditService/GetReservation"
-	CreditService_ListReservations_FullMethodName = "/credit.v1.CreditService/ListReservations"
+	CreditService_GetBalance_FullMethodName          = "/credit.v1.CreditService/GetBalance"
+	CreditService_Grant_FullMethodName               = "/credit.v1.CreditService/Grant"
+	CreditService_CancelGrant_FullMethodName         = "/credit.v1.CreditService/CancelGrant"
+	CreditService_Reserve_FullMethodName             = "/credit.v1.CreditService/Reserve"
+	CreditService_Capture_FullMethodName             = "/credit.v1.CreditService/Capture"
+	CreditService_Release_FullMethodName             = "/credit.v1.CreditService/Release"
+	CreditService_Spend_FullMethodName               = "/credit.v1.CreditService/Spend"
+	CreditService_Refund_FullMethodName              = "/credit.v1.CreditService/Refund"
+	CreditService_SpendAcrossLedgers_FullMethodName  = "/credit.v1.CreditService/SpendAcrossLedgers"
+	CreditService_RefundAcrossLedgers_FullMethodName = "/credit.v1.CreditService/RefundAcrossLedgers"
+	CreditService_Batch_FullMethodName               = "/credit.v1.CreditService/Batch"
+	CreditService_ListEntries_FullMethodName         = "/credit.v1.CreditService/ListEntries"
+	CreditService_WatchEntries_FullMethodName        = "/credit.v1.CreditService/WatchEntries"
+	CreditService_GetReservation_FullMethodName      = "/credit.v1.CreditService/GetReservation"
+	CreditService_ListReservations_FullMethodName    = "/credit.v1.CreditService/ListReservations"
+	CreditService_CreateGrantSchedule_FullMethodName = "/credit.v1.CreditService/CreateGrantSchedule"
+	CreditService_ListGrantSchedules_FullMethodName  = "/credit.v1.CreditService/ListGrantSchedules"
+	CreditService_CancelGrantSchedule_FullMethodName = "/credit.v1.CreditService/CancelGrantSchedule"
+	CreditService_ListAccounts_FullMethodName        = "/credit.v1.CreditService/ListAccounts"
+	CreditService_GetTrialBalance_FullMethodName     = "/credit.v1.CreditService/GetTrialBalance"
+	CreditService_GetLiabilityReport_FullMethodName  = "/credit.v1.CreditService/GetLiabilityReport"
+	CreditService_ListWebhookEvents_FullMethodName   = "/credit.v1.CreditService/ListWebhookEvents"
+	CreditService_ReplayWebhookEvent_FullMethodName  = "/credit.v1.CreditService/ReplayWebhookEvent"
+	CreditService_VerifyChain_FullMethodName         = "/credit.v1.CreditService/VerifyChain"
+	CreditService_VerifyAccount_FullMethodName       = "/credit.v1.CreditService/VerifyAccount"
 )
 
 // CreditServiceClient is the client API for CreditService service.
@@ -38,15 +52,29 @@ const (
 type CreditServiceClient interface {
 	GetBalance(ctx context.Context, in *BalanceRequest, opts ...grpc.CallOption) (*BalanceResponse, error)
 	Grant(ctx context.Context, in *GrantRequest, opts ...grpc.CallOption) (*Empty, error)
+	CancelGrant(ctx context.Context, in *CancelGrantRequest, opts ...grpc.CallOption) (*Empty, error)
 	Reserve(ctx context.Context, in *ReserveRequest, opts ...grpc.CallOption) (*Empty, error)
 	Capture(ctx context.Context, in *CaptureRequest, opts ...grpc.CallOption) (*Empty, error)
 	Release(ctx context.Context, in *ReleaseRequest, opts ...grpc.CallOption) (*Empty, error)
 	Spend(ctx context.Context, in *SpendRequest, opts ...grpc.CallOption) (*Empty, error)
 	Refund(ctx context.Context, in *RefundRequest, opts ...grpc.CallOption) (*RefundResponse, error)
+	SpendAcrossLedgers(ctx context.Context, in *SpendAcrossLedgersRequest, opts ...grpc.CallOption) (*SpendAcrossLedgersResponse, error)
+	RefundAcrossLedgers(ctx context.Context, in *RefundAcrossLedgersRequest, opts ...grpc.CallOption) (*RefundAcrossLedgersResponse, error)
 	Batch(ctx context.Context, in *BatchRequest, opts ...grpc.CallOption) (*BatchResponse, error)
 	ListEntries(ctx context.Context, in *ListEntriesRequest, opts ...grpc.CallOption) (*ListEntriesResponse, error)
+	WatchEntries(ctx context.Context, in *WatchEntriesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[EntryChange], error)
 	GetReservation(ctx context.Context, in *GetReservationRequest, opts ...grpc.CallOption) (*GetReservationResponse, error)
 	ListReservations(ctx context.Context, in *ListReservationsRequest, opts ...grpc.CallOption) (*ListReservationsResponse, error)
+	CreateGrantSchedule(ctx context.Context, in *CreateGrantScheduleRequest, opts ...grpc.CallOption) (*CreateGrantScheduleResponse, error)
+	ListGrantSchedules(ctx context.Context, in *ListGrantSchedulesRequest, opts ...grpc.CallOption) (*ListGrantSchedulesResponse, error)
+	CancelGrantSchedule(ctx context.Context, in *CancelGrantScheduleRequest, opts ...grpc.CallOption) (*CancelGrantScheduleResponse, error)
+	ListAccounts(ctx context.Context, in *ListAccountsRequest, opts ...grpc.CallOption) (*ListAccountsResponse, error)
+	GetTrialBalance(ctx context.Context, in *GetTrialBalanceRequest, opts ...grpc.CallOption) (*GetTrialBalanceResponse, error)
+	GetLiabilityReport(ctx context.Context, in *GetLiabilityReportRequest, opts ...grpc.CallOption) (*GetLiabilityReportResponse, error)
+	ListWebhookEvents(ctx context.Context, in *ListWebhookEventsRequest, opts ...grpc.CallOption) (*ListWebhookEventsResponse, error)
+	ReplayWebhookEvent(ctx context.Context, in *ReplayWebhookEventRequest, opts ...grpc.CallOption) (*ReplayWebhookEventResponse, error)
+	VerifyChain(ctx context.Context, in *VerifyChainRequest, opts ...grpc.CallOption) (*VerifyChainResponse, error)
+	VerifyAccount(ctx context.Context, in *VerifyAccountRequest, opts ...grpc.CallOption) (*VerifyAccountResponse, error)
 }
 
 type creditServiceClient struct {
@@ -77,6 +105,16 @@ func (c *creditServiceClient) Grant(ctx context.Context, in *GrantRequest, opts
 	return out, nil
 }
 
+func (c *creditServiceClient) CancelGrant(ctx context.Context, in *CancelGrantRequest, opts ...grpc.CallOption) (*Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, CreditService_CancelGrant_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *creditServiceClient) Reserve(ctx context.Context, in *ReserveRequest, opts ...grpc.CallOption) (*Empty, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(Empty)
@@ -127,6 +165,26 @@ func (c *creditServiceClient) Refund(ctx context.Context, in *RefundRequest, opt
 	return out, nil
 }
 
+func (c *creditServiceClient) SpendAcrossLedgers(ctx context.Context, in *SpendAcrossLedgersRequest, opts ...grpc.CallOption) (*SpendAcrossLedgersResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SpendAcrossLedgersResponse)
+	err := c.cc.Invoke(ctx, CreditService_SpendAcrossLedgers_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *creditServiceClient) RefundAcrossLedgers(ctx context.Context, in *RefundAcrossLedgersRequest, opts ...grpc.CallOption) (*RefundAcrossLedgersResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RefundAcrossLedgersResponse)
+	err := c.cc.Invoke(ctx, CreditService_RefundAcrossLedgers_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *creditServiceClient) Batch(ctx context.Context, in *BatchRequest, opts ...grpc.CallOption) (*BatchResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(BatchResponse)
@@ -147,6 +205,25 @@ func (c *creditServiceClient) ListEntries(ctx context.Context, in *ListEntriesRe
 	return out, nil
 }
 
+func (c *creditServiceClient) WatchEntries(ctx context.Context, in *WatchEntriesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[EntryChange], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &CreditService_ServiceDesc.Streams[0], CreditService_WatchEntries_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchEntriesRequest, EntryChange]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type CreditService_WatchEntriesClient = grpc.ServerStreamingClient[EntryChange]
+
 func (c *creditServiceClient) GetReservation(ctx context.Context, in *GetReservationRequest, opts ...grpc.CallOption) (*GetReservationResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(GetReservationResponse)
@@ -167,21 +244,135 @@ func (c *creditServiceClient) ListReservations(ctx context.Context, in *ListRese
 	return out, nil
 }
 
+func (c *creditServiceClient) CreateGrantSchedule(ctx context.Context, in *CreateGrantScheduleRequest, opts ...grpc.CallOption) (*CreateGrantScheduleResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CreateGrantScheduleResponse)
+	err := c.cc.Invoke(ctx, CreditService_CreateGrantSchedule_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *creditServiceClient) ListGrantSchedules(ctx context.Context, in *ListGrantSchedulesRequest, opts ...grpc.CallOption) (*ListGrantSchedulesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListGrantSchedulesResponse)
+	err := c.cc.Invoke(ctx, CreditService_ListGrantSchedules_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *creditServiceClient) CancelGrantSchedule(ctx context.Context, in *CancelGrantScheduleRequest, opts ...grpc.CallOption) (*CancelGrantScheduleResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CancelGrantScheduleResponse)
+	err := c.cc.Invoke(ctx, CreditService_CancelGrantSchedule_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *creditServiceClient) ListAccounts(ctx context.Context, in *ListAccountsRequest, opts ...grpc.CallOption) (*ListAccountsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListAccountsResponse)
+	err := c.cc.Invoke(ctx, CreditService_ListAccounts_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *creditServiceClient) GetTrialBalance(ctx context.Context, in *GetTrialBalanceRequest, opts ...grpc.CallOption) (*GetTrialBalanceResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetTrialBalanceResponse)
+	err := c.cc.Invoke(ctx, CreditService_GetTrialBalance_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *creditServiceClient) GetLiabilityReport(ctx context.Context, in *GetLiabilityReportRequest, opts ...grpc.CallOption) (*GetLiabilityReportResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetLiabilityReportResponse)
+	err := c.cc.Invoke(ctx, CreditService_GetLiabilityReport_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *creditServiceClient) ListWebhookEvents(ctx context.Context, in *ListWebhookEventsRequest, opts ...grpc.CallOption) (*ListWebhookEventsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListWebhookEventsResponse)
+	err := c.cc.Invoke(ctx, CreditService_ListWebhookEvents_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *creditServiceClient) ReplayWebhookEvent(ctx context.Context, in *ReplayWebhookEventRequest, opts ...grpc.CallOption) (*ReplayWebhookEventResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ReplayWebhookEventResponse)
+	err := c.cc.Invoke(ctx, CreditService_ReplayWebhookEvent_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *creditServiceClient) VerifyChain(ctx context.Context, in *VerifyChainRequest, opts ...grpc.CallOption) (*VerifyChainResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(VerifyChainResponse)
+	err := c.cc.Invoke(ctx, CreditService_VerifyChain_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *creditServiceClient) VerifyAccount(ctx context.Context, in *VerifyAccountRequest, opts ...grpc.CallOption) (*VerifyAccountResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(VerifyAccountResponse)
+	err := c.cc.Invoke(ctx, CreditService_VerifyAccount_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // CreditServiceServer is the server API for CreditService service.
 // All implementations must embed UnimplementedCreditServiceServer
 // for forward compatibility.
 type CreditServiceServer interface {
 	GetBalance(context.Context, *BalanceRequest) (*BalanceResponse, error)
 	Grant(context.Context, *GrantRequest) (*Empty, error)
+	CancelGrant(context.Context, *CancelGrantRequest) (*Empty, error)
 	Reserve(context.Context, *ReserveRequest) (*Empty, error)
 	Capture(context.Context, *CaptureRequest) (*Empty, error)
 	Release(context.Context, *ReleaseRequest) (*Empty, error)
 	Spend(context.Context, *SpendRequest) (*Empty, error)
 	Refund(context.Context, *RefundRequest) (*RefundResponse, error)
+	SpendAcrossLedgers(context.Context, *SpendAcrossLedgersRequest) (*SpendAcrossLedgersResponse, error)
+	RefundAcrossLedgers(context.Context, *RefundAcrossLedgersRequest) (*RefundAcrossLedgersResponse, error)
 	Batch(context.Context, *BatchRequest) (*BatchResponse, error)
 	ListEntries(context.Context, *ListEntriesRequest) (*ListEntriesResponse, error)
+	WatchEntries(*WatchEntriesRequest, grpc.ServerStreamingServer[EntryChange]) error
 	GetReservation(context.Context, *GetReservationRequest) (*GetReservationResponse, error)
 	ListReservations(context.Context, *ListReservationsRequest) (*ListReservationsResponse, error)
+	CreateGrantSchedule(context.Context, *CreateGrantScheduleRequest) (*CreateGrantScheduleResponse, error)
+	ListGrantSchedules(context.Context, *ListGrantSchedulesRequest) (*ListGrantSchedulesResponse, error)
+	CancelGrantSchedule(context.Context, *CancelGrantScheduleRequest) (*CancelGrantScheduleResponse, error)
+	ListAccounts(context.Context, *ListAccountsRequest) (*ListAccountsResponse, error)
+	GetTrialBalance(context.Context, *GetTrialBalanceRequest) (*GetTrialBalanceResponse, error)
+	GetLiabilityReport(context.Context, *GetLiabilityReportRequest) (*GetLiabilityReportResponse, error)
+	ListWebhookEvents(context.Context, *ListWebhookEventsRequest) (*ListWebhookEventsResponse, error)
+	ReplayWebhookEvent(context.Context, *ReplayWebhookEventRequest) (*ReplayWebhookEventResponse, error)
+	VerifyChain(context.Context, *VerifyChainRequest) (*VerifyChainResponse, error)
+	VerifyAccount(context.Context, *VerifyAccountRequest) (*VerifyAccountResponse, error)
 	mustEmbedUnimplementedCreditServiceServer()
 }
 
@@ -198,6 +389,9 @@ func (UnimplementedCreditServiceServer) GetBalance(context.Context, *BalanceRequ
 func (UnimplementedCreditServiceServer) Grant(context.Context, *GrantRequest) (*Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Grant not implemented")
 }
+func (UnimplementedCreditServiceServer) CancelGrant(context.Context, *CancelGrantRequest) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CancelGrant not implemented")
+}
 func (UnimplementedCreditServiceServer) Reserve(context.Context, *ReserveRequest) (*Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Reserve not implemented")
 }
@@ -213,18 +407,57 @@ func (UnimplementedCreditServiceServer) Spend(context.Context, *SpendRequest) (*
 func (UnimplementedCreditServiceServer) Refund(context.Context, *RefundRequest) (*RefundResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Refund not implemented")
 }
+func (UnimplementedCreditServiceServer) SpendAcrossLedgers(context.Context, *SpendAcrossLedgersRequest) (*SpendAcrossLedgersResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SpendAcrossLedgers not implemented")
+}
+func (UnimplementedCreditServiceServer) RefundAcrossLedgers(context.Context, *RefundAcrossLedgersRequest) (*RefundAcrossLedgersResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RefundAcrossLedgers not implemented")
+}
 func (UnimplementedCreditServiceServer) Batch(context.Context, *BatchRequest) (*BatchResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Batch not implemented")
 }
 func (UnimplementedCreditServiceServer) ListEntries(context.Context, *ListEntriesRequest) (*ListEntriesResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ListEntries not implemented")
 }
+func (UnimplementedCreditServiceServer) WatchEntries(*WatchEntriesRequest, grpc.ServerStreamingServer[EntryChange]) error {
+	return status.Errorf(codes.Unimplemented, "method WatchEntries not implemented")
+}
 func (UnimplementedCreditServiceServer) GetReservation(context.Context, *GetReservationRequest) (*GetReservationResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetReservation not implemented")
 }
 func (UnimplementedCreditServiceServer) ListReservations(context.Context, *ListReservationsRequest) (*ListReservationsResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ListReservations not implemented")
 }
+func (UnimplementedCreditServiceServer) CreateGrantSchedule(context.Context, *CreateGrantScheduleRequest) (*CreateGrantScheduleResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateGrantSchedule not implemented")
+}
+func (UnimplementedCreditServiceServer) ListGrantSchedules(context.Context, *ListGrantSchedulesRequest) (*ListGrantSchedulesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListGrantSchedules not implemented")
+}
+func (UnimplementedCreditServiceServer) CancelGrantSchedule(context.Context, *CancelGrantScheduleRequest) (*CancelGrantScheduleResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CancelGrantSchedule not implemented")
+}
+func (UnimplementedCreditServiceServer) ListAccounts(context.Context, *ListAccountsRequest) (*ListAccountsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListAccounts not implemented")
+}
+func (UnimplementedCreditServiceServer) GetTrialBalance(context.Context, *GetTrialBalanceRequest) (*GetTrialBalanceResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetTrialBalance not implemented")
+}
+func (UnimplementedCreditServiceServer) GetLiabilityReport(context.Context, *GetLiabilityReportRequest) (*GetLiabilityReportResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetLiabilityReport not implemented")
+}
+func (UnimplementedCreditServiceServer) ListWebhookEvents(context.Context, *ListWebhookEventsRequest) (*ListWebhookEventsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListWebhookEvents not implemented")
+}
+func (UnimplementedCreditServiceServer) ReplayWebhookEvent(context.Context, *ReplayWebhookEventRequest) (*ReplayWebhookEventResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReplayWebhookEvent not implemented")
+}
+func (UnimplementedCreditServiceServer) VerifyChain(context.Context, *VerifyChainRequest) (*VerifyChainResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method VerifyChain not implemented")
+}
+func (UnimplementedCreditServiceServer) VerifyAccount(context.Context, *VerifyAccountRequest) (*VerifyAccountResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method VerifyAccount not implemented")
+}
 func (UnimplementedCreditServiceServer) mustEmbedUnimplementedCreditServiceServer() {}
 func (UnimplementedCreditServiceServer) testEmbeddedByValue()                       {}
 
@@ -282,6 +515,24 @@ func _CreditService_Grant_Handler(srv interface{}, ctx context.Context, dec func
 	return interceptor(ctx, in, info, handler)
 }
 
+func _CreditService_CancelGrant_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CancelGrantRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CreditServiceServer).CancelGrant(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CreditService_CancelGrant_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CreditServiceServer).CancelGrant(ctx, req.(*CancelGrantRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _CreditService_Reserve_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(ReserveRequest)
 	if err := dec(in); err != nil {
@@ -372,6 +623,42 @@ func _CreditService_Refund_Handler(srv interface{}, ctx context.Context, dec fun
 	return interceptor(ctx, in, info, handler)
 }
 
+func _CreditService_SpendAcrossLedgers_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SpendAcrossLedgersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CreditServiceServer).SpendAcrossLedgers(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CreditService_SpendAcrossLedgers_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CreditServiceServer).SpendAcrossLedgers(ctx, req.(*SpendAcrossLedgersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CreditService_RefundAcrossLedgers_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RefundAcrossLedgersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CreditServiceServer).RefundAcrossLedgers(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CreditService_RefundAcrossLedgers_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CreditServiceServer).RefundAcrossLedgers(ctx, req.(*RefundAcrossLedgersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _CreditService_Batch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(BatchRequest)
 	if err := dec(in); err != nil {
@@ -408,6 +695,17 @@ func _CreditService_ListEntries_Handler(srv interface{}, ctx context.Context, de
 	return interceptor(ctx, in, info, handler)
 }
 
+func _CreditService_WatchEntries_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchEntriesRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(CreditServiceServer).WatchEntries(m, &grpc.GenericServerStream[WatchEntriesRequest, EntryChange]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type CreditService_WatchEntriesServer = grpc.ServerStreamingServer[EntryChange]
+
 func _CreditService_GetReservation_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(GetReservationRequest)
 	if err := dec(in); err != nil {
@@ -444,6 +742,186 @@ func _CreditService_ListReservations_Handler(srv interface{}, ctx context.Contex
 	return interceptor(ctx, in, info, handler)
 }
 
+func _CreditService_CreateGrantSchedule_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateGrantScheduleRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CreditServiceServer).CreateGrantSchedule(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CreditService_CreateGrantSchedule_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CreditServiceServer).CreateGrantSchedule(ctx, req.(*CreateGrantScheduleRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CreditService_ListGrantSchedules_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListGrantSchedulesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CreditServiceServer).ListGrantSchedules(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CreditService_ListGrantSchedules_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CreditServiceServer).ListGrantSchedules(ctx, req.(*ListGrantSchedulesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CreditService_CancelGrantSchedule_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CancelGrantScheduleRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CreditServiceServer).CancelGrantSchedule(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CreditService_CancelGrantSchedule_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CreditServiceServer).CancelGrantSchedule(ctx, req.(*CancelGrantScheduleRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CreditService_ListAccounts_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListAccountsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CreditServiceServer).ListAccounts(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CreditService_ListAccounts_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CreditServiceServer).ListAccounts(ctx, req.(*ListAccountsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CreditService_GetTrialBalance_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetTrialBalanceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CreditServiceServer).GetTrialBalance(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CreditService_GetTrialBalance_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CreditServiceServer).GetTrialBalance(ctx, req.(*GetTrialBalanceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CreditService_GetLiabilityReport_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetLiabilityReportRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CreditServiceServer).GetLiabilityReport(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CreditService_GetLiabilityReport_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CreditServiceServer).GetLiabilityReport(ctx, req.(*GetLiabilityReportRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CreditService_ListWebhookEvents_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListWebhookEventsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CreditServiceServer).ListWebhookEvents(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CreditService_ListWebhookEvents_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CreditServiceServer).ListWebhookEvents(ctx, req.(*ListWebhookEventsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CreditService_ReplayWebhookEvent_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReplayWebhookEventRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CreditServiceServer).ReplayWebhookEvent(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CreditService_ReplayWebhookEvent_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CreditServiceServer).ReplayWebhookEvent(ctx, req.(*ReplayWebhookEventRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CreditService_VerifyChain_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(VerifyChainRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CreditServiceServer).VerifyChain(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CreditService_VerifyChain_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CreditServiceServer).VerifyChain(ctx, req.(*VerifyChainRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CreditService_VerifyAccount_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(VerifyAccountRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CreditServiceServer).VerifyAccount(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CreditService_VerifyAccount_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CreditServiceServer).VerifyAccount(ctx, req.(*VerifyAccountRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // CreditService_ServiceDesc is the grpc.ServiceDesc for CreditService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -459,6 +937,10 @@ var CreditService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "Grant",
 			Handler:    _CreditService_Grant_Handler,
 		},
+		{
+			MethodName: "CancelGrant",
+			Handler:    _CreditService_CancelGrant_Handler,
+		},
 		{
 			MethodName: "Reserve",
 			Handler:    _CreditService_Reserve_Handler,
@@ -479,6 +961,14 @@ var CreditService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "Refund",
 			Handler:    _CreditService_Refund_Handler,
 		},
+		{
+			MethodName: "SpendAcrossLedgers",
+			Handler:    _CreditService_SpendAcrossLedgers_Handler,
+		},
+		{
+			MethodName: "RefundAcrossLedgers",
+			Handler:    _CreditService_RefundAcrossLedgers_Handler,
+		},
 		{
 			MethodName: "Batch",
 			Handler:    _CreditService_Batch_Handler,
@@ -495,7 +985,53 @@ var CreditService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "ListReservations",
 			Handler:    _CreditService_ListReservations_Handler,
 		},
+		{
+			MethodName: "CreateGrantSchedule",
+			Handler:    _CreditService_CreateGrantSchedule_Handler,
+		},
+		{
+			MethodName: "ListGrantSchedules",
+			Handler:    _CreditService_ListGrantSchedules_Handler,
+		},
+		{
+			MethodName: "CancelGrantSchedule",
+			Handler:    _CreditService_CancelGrantSchedule_Handler,
+		},
+		{
+			MethodName: "ListAccounts",
+			Handler:    _CreditService_ListAccounts_Handler,
+		},
+		{
+			MethodName: "GetTrialBalance",
+			Handler:    _CreditService_GetTrialBalance_Handler,
+		},
+		{
+			MethodName: "GetLiabilityReport",
+			Handler:    _CreditService_GetLiabilityReport_Handler,
+		},
+		{
+			MethodName: "ListWebhookEvents",
+			Handler:    _CreditService_ListWebhookEvents_Handler,
+		},
+		{
+			MethodName: "ReplayWebhookEvent",
+			Handler:    _CreditService_ReplayWebhookEvent_Handler,
+		},
+		{
+			MethodName: "VerifyChain",
+			Handler:    _CreditService_VerifyChain_Handler,
+		},
+		{
+			MethodName: "VerifyAccount",
+			Handler:    _CreditService_VerifyAccount_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchEntries",
+			Handler:       _CreditService_WatchEntries_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "api/credit/v1/credit.proto",
 }
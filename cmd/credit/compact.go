@@ -0,0 +1,224 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/MarkoPoloResearchLab/ledger/pkg/ledger"
+	"github.com/spf13/cobra"
+)
+
+const (
+	flagCompactTenant     = "tenant"
+	flagCompactUser       = "user"
+	flagCompactLedger     = "ledger"
+	flagCompactBefore     = "before"
+	flagCompactArchiveDir = "archive-dir"
+	flagCompactFormat     = "format"
+	archiveDirMode        = 0o750
+)
+
+type accountCompactionOutput struct {
+	AccountID            string `json:"account_id"`
+	UserID               string `json:"user_id"`
+	LedgerID             string `json:"ledger_id"`
+	ArchivedEntries      int    `json:"archived_entries"`
+	ArchivedReservations int    `json:"archived_reservations"`
+	RetainedBeforeCutoff int    `json:"retained_before_cutoff"`
+	OpeningBalanceCents  int64  `json:"opening_balance_cents"`
+}
+
+// compactionReportOutput lists only the accounts compaction changed or had to leave entries in; ArchivePath is
+// empty when nothing was archived.
+type compactionReportOutput struct {
+	TenantID          string                    `json:"tenant_id"`
+	CutoffUnixUTC     int64                     `json:"cutoff_unix_utc"`
+	ArchivePath       string                    `json:"archive_path"`
+	AccountsChecked   int                       `json:"accounts_checked"`
+	AccountsCompacted int                       `json:"accounts_compacted"`
+	EntriesArchived   int                       `json:"entries_archived"`
+	Accounts          []accountCompactionOutput `json:"accounts"`
+}
+
+func newCompactCommand(cfg *runtimeConfig) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "compact",
+		Short: "Archive entries older than a cutoff and carry their balance forward as opening balances",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			tenant, _ := cmd.Flags().GetString(flagCompactTenant)
+			user, _ := cmd.Flags().GetString(flagCompactUser)
+			ledgerName, _ := cmd.Flags().GetString(flagCompactLedger)
+			before, _ := cmd.Flags().GetString(flagCompactBefore)
+			archiveDir, _ := cmd.Flags().GetString(flagCompactArchiveDir)
+			format, _ := cmd.Flags().GetString(flagCompactFormat)
+			return runCompact(cmd.Context(), cfg, cmd.OutOrStdout(), tenant, user, ledgerName, before, archiveDir, format)
+		},
+	}
+	cmd.Flags().String(flagCompactTenant, "", "Tenant to compact")
+	cmd.Flags().String(flagCompactUser, "", "Compact only this user's account (requires --ledger)")
+	cmd.Flags().String(flagCompactLedger, "", "Compact only accounts of this ledger")
+	cmd.Flags().String(flagCompactBefore, "", "Archive entries created before this RFC 3339 time")
+	cmd.Flags().String(flagCompactArchiveDir, "", "Directory that receives the gzipped JSONL archive")
+	cmd.Flags().String(flagCompactFormat, reportFormatText, "Output format: text or json")
+	_ = cmd.MarkFlagRequired(flagCompactTenant)
+	_ = cmd.MarkFlagRequired(flagCompactBefore)
+	_ = cmd.MarkFlagRequired(flagCompactArchiveDir)
+	return cmd
+}
+
+// runCompact writes every archived account of the run to one <tenant>-<cutoff>-<run>.jsonl.gz file in
+// archiveDir. Accounts are compacted one transaction at a time, so a failed run keeps what it already archived.
+func runCompact(ctx context.Context, cfg *runtimeConfig, out io.Writer, rawTenantID string, rawUserID string, rawLedgerID string, rawBefore string, archiveDir string, format string) error {
+	if format != reportFormatText && format != reportFormatJSON {
+		return fmt.Errorf("unsupported output format %q", format)
+	}
+	tenantID, err := ledger.NewTenantID(rawTenantID)
+	if err != nil {
+		return err
+	}
+	before, err := time.Parse(time.RFC3339, rawBefore)
+	if err != nil {
+		return fmt.Errorf("parse --%s: %w", flagCompactBefore, err)
+	}
+	cutoffUnixUTC := before.UTC().Unix()
+	var filter ledger.ListAccountsFilter
+	var ledgerID ledger.LedgerID
+	if strings.TrimSpace(rawLedgerID) != "" {
+		ledgerID, err = ledger.NewLedgerID(rawLedgerID)
+		if err != nil {
+			return err
+		}
+		filter.LedgerID = &ledgerID
+	}
+	var userID *ledger.UserID
+	if strings.TrimSpace(rawUserID) != "" {
+		if filter.LedgerID == nil {
+			return fmt.Errorf("--%s requires --%s", flagCompactUser, flagCompactLedger)
+		}
+		parsedUserID, err := ledger.NewUserID(rawUserID)
+		if err != nil {
+			return err
+		}
+		userID = &parsedUserID
+	}
+	if strings.TrimSpace(archiveDir) == "" {
+		return fmt.Errorf("--%s is required", flagCompactArchiveDir)
+	}
+
+	gormDB, cleanup, driver, err := openDatabaseFunc(ctx, cfg.Service.DatabaseURL)
+	if err != nil {
+		return fmt.Errorf("database open: %w", err)
+	}
+	defer func() { _ = cleanup() }()
+	if err := requireMigratedSchemaFunc(gormDB, driver); err != nil {
+		return err
+	}
+	store, closeStore, err := newLedgerStoreFunc(ctx, gormDB, driver, cfg.Service.DatabaseURL)
+	if err != nil {
+		return fmt.Errorf("ledger store init: %w", err)
+	}
+	defer closeStore()
+	creditService, err := newServiceFunc(store, func() int64 { return time.Now().UTC().Unix() })
+	if err != nil {
+		return fmt.Errorf("ledger service init: %w", err)
+	}
+	if err := os.MkdirAll(archiveDir, archiveDirMode); err != nil {
+		return fmt.Errorf("create archive directory: %w", err)
+	}
+	archivePath := filepath.Join(archiveDir, fmt.Sprintf("%s-%d-%d.jsonl.gz", tenantID.String(), cutoffUnixUTC, time.Now().UTC().Unix()))
+	_, statErr := os.Stat(archivePath)
+	createdArchive := errors.Is(statErr, os.ErrNotExist)
+	writer, err := ledger.NewGzipJSONLArchiveWriter(archivePath)
+	if err != nil {
+		return err
+	}
+	var results []ledger.CompactionResult
+	if userID != nil {
+		var result ledger.CompactionResult
+		result, err = creditService.CompactAccount(ctx, tenantID, *userID, ledgerID, cutoffUnixUTC, writer)
+		if err == nil {
+			results = append(results, result)
+		}
+	} else {
+		results, err = creditService.CompactAccounts(ctx, tenantID, filter, cutoffUnixUTC, writer)
+	}
+	if closeErr := writer.Close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("close archive: %w", closeErr)
+	}
+	output := newCompactionReportOutput(tenantID, cutoffUnixUTC, archivePath, results)
+	if output.EntriesArchived == 0 {
+		if createdArchive {
+			_ = os.Remove(archivePath)
+		}
+		output.ArchivePath = ""
+	}
+	if err != nil {
+		return fmt.Errorf("compact: %w", err)
+	}
+
+	if format == reportFormatJSON {
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(output)
+	}
+	return writeCompactionReportText(out, output)
+}
+
+func newCompactionReportOutput(tenantID ledger.TenantID, cutoffUnixUTC int64, archivePath string, results []ledger.CompactionResult) compactionReportOutput {
+	output := compactionReportOutput{
+		TenantID:        tenantID.String(),
+		CutoffUnixUTC:   cutoffUnixUTC,
+		ArchivePath:     archivePath,
+		AccountsChecked: len(results),
+		Accounts:        []accountCompactionOutput{},
+	}
+	for _, result := range results {
+		if result.ArchivedEntries == 0 && result.RetainedBeforeCutoff == 0 {
+			continue
+		}
+		if result.ArchivedEntries > 0 {
+			output.AccountsCompacted++
+			output.EntriesArchived += result.ArchivedEntries
+		}
+		output.Accounts = append(output.Accounts, accountCompactionOutput{
+			AccountID:            result.AccountID.String(),
+			UserID:               result.UserID.String(),
+			LedgerID:             result.LedgerID.String(),
+			ArchivedEntries:      result.ArchivedEntries,
+			ArchivedReservations: result.ArchivedReservations,
+			RetainedBeforeCutoff: result.RetainedBeforeCutoff,
+			OpeningBalanceCents:  result.OpeningBalanceCents.Int64(),
+		})
+	}
+	return output
+}
+
+func writeCompactionReportText(out io.Writer, output compactionReportOutput) error {
+	var table strings.Builder
+	fmt.Fprintf(&table, "tenant\t%s\n", output.TenantID)
+	fmt.Fprintf(&table, "cutoff\t%s\n", time.Unix(output.CutoffUnixUTC, 0).UTC().Format(time.RFC3339))
+	fmt.Fprintf(&table, "archive\t%s\n", dashIfEmpty(output.ArchivePath))
+	fmt.Fprintf(&table, "accounts_checked\t%d\n", output.AccountsChecked)
+	fmt.Fprintf(&table, "accounts_compacted\t%d\n", output.AccountsCompacted)
+	fmt.Fprintf(&table, "entries_archived\t%d\n", output.EntriesArchived)
+	if len(output.Accounts) > 0 {
+		table.WriteString("\nUSER\tLEDGER\tARCHIVED\tRESERVATIONS\tRETAINED\tOPENING_BALANCE\n")
+		for _, account := range output.Accounts {
+			fmt.Fprintf(&table, "%s\t%s\t%d\t%d\t%d\t%d\n", account.UserID, account.LedgerID, account.ArchivedEntries, account.ArchivedReservations, account.RetainedBeforeCutoff, account.OpeningBalanceCents)
+		}
+	}
+	writer := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	if _, err := io.WriteString(writer, table.String()); err != nil {
+		return err
+	}
+	return writer.Flush()
+}
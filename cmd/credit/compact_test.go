@@ -0,0 +1,215 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/MarkoPoloResearchLab/ledger/pkg/ledger"
+	"gorm.io/gorm"
+)
+
+func TestCompactCommandArchivesAndCarriesBalanceForward(test *testing.T) {
+	tempDir := test.TempDir()
+	sqlitePath := filepath.Join(tempDir, "ledger.db")
+	archiveDir := filepath.Join(tempDir, "archives")
+	seedLiabilityReportDatabase(test, "sqlite://"+sqlitePath)
+
+	configFile := filepath.Join(tempDir, "config.yml")
+	content := fmt.Sprintf(`
+service:
+  database_url: "sqlite://%s"
+  listen_addr: "127.0.0.1:0"
+tenants:
+  - id: "default"
+    secret_key: "default-secret"
+`, sqlitePath)
+	if err := os.WriteFile(configFile, []byte(content), 0o644); err != nil {
+		test.Fatalf("write config file: %v", err)
+	}
+	compactArgs := []string{"--" + flagConfigFile, configFile, "compact", "--tenant", "default", "--before", "2023-11-15T00:00:00Z", "--archive-dir", archiveDir}
+
+	var textOutput bytes.Buffer
+	cmd := newRootCommand()
+	cmd.SetOut(&textOutput)
+	cmd.SetArgs(compactArgs)
+	if err := cmd.Execute(); err != nil {
+		test.Fatalf("compact: %v", err)
+	}
+	for _, expected := range []string{"accounts_compacted  1", "entries_archived    4", "user-1"} {
+		if !strings.Contains(textOutput.String(), expected) {
+			test.Fatalf("expected %q in output:\n%s", expected, textOutput.String())
+		}
+	}
+	archives, err := filepath.Glob(filepath.Join(archiveDir, "default-1700006400-*.jsonl.gz"))
+	if err != nil || len(archives) != 1 {
+		test.Fatalf("expected one archive file, got %v (%v)", archives, err)
+	}
+
+	var jsonOutput bytes.Buffer
+	cmd = newRootCommand()
+	cmd.SetOut(&jsonOutput)
+	cmd.SetArgs(append(compactArgs, "--format", "json"))
+	if err := cmd.Execute(); err != nil {
+		test.Fatalf("compact again: %v", err)
+	}
+	var output compactionReportOutput
+	if err := json.Unmarshal(jsonOutput.Bytes(), &output); err != nil {
+		test.Fatalf("decode output: %v\n%s", err, jsonOutput.String())
+	}
+	if output.AccountsChecked != 1 || output.EntriesArchived != 0 || output.ArchivePath != "" {
+		test.Fatalf("expected nothing left to archive, got %+v", output)
+	}
+	entries, err := os.ReadDir(archiveDir)
+	if err != nil || len(entries) != 1 {
+		test.Fatalf("expected the empty archive to be removed, got %v (%v)", entries, err)
+	}
+
+	var verifyOutput bytes.Buffer
+	cmd = newRootCommand()
+	cmd.SetOut(&verifyOutput)
+	cmd.SetArgs([]string{"--" + flagConfigFile, configFile, "verify-chain", "--tenant", "default", "--user", "user-1", "--ledger", "default"})
+	if err := cmd.Execute(); err != nil {
+		test.Fatalf("verify chain: %v", err)
+	}
+	if !strings.Contains(verifyOutput.String(), "chain intact: 1 of 5 entries verified after 4 archived") {
+		test.Fatalf("unexpected verify output:\n%s", verifyOutput.String())
+	}
+}
+
+func TestCompactCommandValidatesFlags(test *testing.T) {
+	test.Parallel()
+	cfg := &runtimeConfig{}
+	testCases := []struct {
+		name       string
+		tenantID   string
+		userID     string
+		ledgerID   string
+		before     string
+		archiveDir string
+		format     string
+		expected   string
+	}{
+		{name: "format", tenantID: "default", before: "2023-11-15T00:00:00Z", archiveDir: "archives", format: "csv", expected: "unsupported output format"},
+		{name: "tenant", tenantID: " ", before: "2023-11-15T00:00:00Z", archiveDir: "archives", format: reportFormatText, expected: "tenant"},
+		{name: "before", tenantID: "default", before: "yesterday", archiveDir: "archives", format: reportFormatText, expected: "--before"},
+		{name: "user without ledger", tenantID: "default", userID: "user-1", before: "2023-11-15T00:00:00Z", archiveDir: "archives", format: reportFormatText, expected: "requires --ledger"},
+		{name: "archive dir", tenantID: "default", before: "2023-11-15T00:00:00Z", archiveDir: " ", format: reportFormatText, expected: "--archive-dir"},
+	}
+	for _, testCase := range testCases {
+		err := runCompact(context.Background(), cfg, &bytes.Buffer{}, testCase.tenantID, testCase.userID, testCase.ledgerID, testCase.before, testCase.archiveDir, testCase.format)
+		if err == nil || !strings.Contains(err.Error(), testCase.expected) {
+			test.Fatalf("%s: expected error containing %q, got %v", testCase.name, testCase.expected, err)
+		}
+	}
+}
+
+func TestCompactCommandRefusesPendingMigrations(test *testing.T) {
+	test.Parallel()
+	tempDir := test.TempDir()
+	cfg := &runtimeConfig{}
+	cfg.Service.DatabaseURL = "sqlite://" + filepath.Join(tempDir, "ledger.db")
+	archiveDir := filepath.Join(tempDir, "archives")
+
+	err := runCompact(context.Background(), cfg, &bytes.Buffer{}, "default", "", "", "2023-11-15T00:00:00Z", archiveDir, reportFormatText)
+	if !errors.Is(err, errPendingMigrations) {
+		test.Fatalf("expected pending migrations error, got %v", err)
+	}
+	if _, statErr := os.Stat(archiveDir); !errors.Is(statErr, os.ErrNotExist) {
+		test.Fatalf("expected no archive directory before the schema is migrated, got %v", statErr)
+	}
+}
+
+func TestCompactCommandCompactsSingleAccount(test *testing.T) {
+	test.Parallel()
+	ctx := context.Background()
+	tempDir := test.TempDir()
+	sqlitePath := filepath.Join(tempDir, "ledger.db")
+	seedLiabilityReportDatabase(test, "sqlite://"+sqlitePath)
+	cfg := &runtimeConfig{}
+	cfg.Service.DatabaseURL = "sqlite://" + sqlitePath
+	archiveDir := filepath.Join(tempDir, "archives")
+
+	var earlyOutput bytes.Buffer
+	if err := runCompact(ctx, cfg, &earlyOutput, "default", "user-1", "default", "2023-01-01T00:00:00Z", archiveDir, reportFormatJSON); err != nil {
+		test.Fatalf("compact before any entries: %v", err)
+	}
+	var output compactionReportOutput
+	if err := json.Unmarshal(earlyOutput.Bytes(), &output); err != nil {
+		test.Fatalf("decode output: %v\n%s", err, earlyOutput.String())
+	}
+	if output.AccountsChecked != 1 || output.EntriesArchived != 0 || output.ArchivePath != "" {
+		test.Fatalf("expected nothing to archive, got %+v", output)
+	}
+	if entries, err := os.ReadDir(archiveDir); err != nil || len(entries) != 0 {
+		test.Fatalf("expected the empty archive to be removed, got %v (%v)", entries, err)
+	}
+
+	var textOutput bytes.Buffer
+	if err := runCompact(ctx, cfg, &textOutput, "default", "user-1", "default", "2023-11-15T00:00:00Z", archiveDir, reportFormatText); err != nil {
+		test.Fatalf("compact account: %v", err)
+	}
+	for _, expected := range []string{"accounts_checked    1", "entries_archived    4", "user-1"} {
+		if !strings.Contains(textOutput.String(), expected) {
+			test.Fatalf("expected %q in output:\n%s", expected, textOutput.String())
+		}
+	}
+}
+
+func TestRunCompactReportsFailures(test *testing.T) {
+	originalNewLedgerStore := newLedgerStoreFunc
+	originalNewService := newServiceFunc
+	test.Cleanup(func() {
+		newLedgerStoreFunc = originalNewLedgerStore
+		newServiceFunc = originalNewService
+	})
+	ctx := context.Background()
+	tempDir := test.TempDir()
+	sqlitePath := filepath.Join(tempDir, "ledger.db")
+	seedLiabilityReportDatabase(test, "sqlite://"+sqlitePath)
+	cfg := &runtimeConfig{}
+	cfg.Service.DatabaseURL = "sqlite://" + sqlitePath
+	archiveDir := filepath.Join(tempDir, "archives")
+	regularFile := filepath.Join(tempDir, "file")
+	if err := os.WriteFile(regularFile, nil, 0o600); err != nil {
+		test.Fatalf("write file: %v", err)
+	}
+	compact := func(before string, directory string) error {
+		return runCompact(ctx, cfg, &bytes.Buffer{}, "default", "", "", before, directory, reportFormatText)
+	}
+
+	if err := compact("2023-11-15T00:00:00Z", filepath.Join(regularFile, "archives")); err == nil || !strings.Contains(err.Error(), "create archive directory") {
+		test.Fatalf("expected archive directory error, got %v", err)
+	}
+	if err := compact("2023-11-15T00:00:00Z", "/proc/self"); err == nil || !strings.Contains(err.Error(), "open archive file") {
+		test.Fatalf("expected archive file error, got %v", err)
+	}
+	if err := compact("2999-01-01T00:00:00Z", archiveDir); !errors.Is(err, ledger.ErrInvalidArchiveCutoff) || !strings.Contains(err.Error(), "compact") {
+		test.Fatalf("expected invalid cutoff error, got %v", err)
+	}
+
+	serviceErr := errors.New("service failed")
+	newServiceFunc = func(ledger.Store, func() int64, ...ledger.ServiceOption) (*ledger.Service, error) {
+		return nil, serviceErr
+	}
+	if err := compact("2023-11-15T00:00:00Z", archiveDir); !errors.Is(err, serviceErr) {
+		test.Fatalf("expected service init error, got %v", err)
+	}
+	storeErr := errors.New("store failed")
+	newLedgerStoreFunc = func(context.Context, *gorm.DB, string, string) (ledger.Store, func(), error) {
+		return nil, nil, storeErr
+	}
+	if err := compact("2023-11-15T00:00:00Z", archiveDir); !errors.Is(err, storeErr) || !strings.Contains(err.Error(), "ledger store init") {
+		test.Fatalf("expected ledger store init error, got %v", err)
+	}
+	cfg.Service.DatabaseURL = "mysql://ledger"
+	if err := compact("2023-11-15T00:00:00Z", archiveDir); err == nil || !strings.Contains(err.Error(), "database open") {
+		test.Fatalf("expected database open error, got %v", err)
+	}
+}
@@ -2,6 +2,9 @@ package main
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"net"
@@ -11,13 +14,18 @@ import (
 	"path/filepath"
 	"regexp"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
 	"github.com/MarkoPoloResearchLab/ledger/api/credit/v1"
+	"github.com/MarkoPoloResearchLab/ledger/internal/gormadapters"
 	"github.com/MarkoPoloResearchLab/ledger/internal/grpcserver"
-	"github.com/MarkoPoloResearchLab/ledger/internal/store/gormstore"
+	"github.com/MarkoPoloResearchLab/ledger/internal/schedules"
+	"github.com/MarkoPoloResearchLab/ledger/internal/webhooks"
 	"github.com/MarkoPoloResearchLab/ledger/pkg/ledger"
+	"github.com/MarkoPoloResearchLab/ledger/pkg/ledger/gormstore"
+	"github.com/MarkoPoloResearchLab/ledger/pkg/ledger/pgxstore"
 	"github.com/glebarez/sqlite"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -25,6 +33,7 @@ import (
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
 	"google.golang.org/grpc/status"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
@@ -32,33 +41,93 @@ import (
 )
 
 const (
-	flagConfigFile    = "config"
-	defaultConfigFile = "config.yml"
+	flagConfigFile                   = "config"
+	flagRequireMigrated              = "require-migrated"
+	defaultConfigFile                = "config.yml"
+	defaultGrantSchedulePollInterval = time.Minute
+	defaultWebhookPollInterval       = 5 * time.Second
+	defaultPartitionCheckInterval    = time.Hour
+
+	metadataRequestID         = "x-request-id"
+	metadataClientService     = "x-client-service"
+	grantScheduleActorService = "ledgerd/grant-schedules"
+	// pgxScheme selects the native pgx ledger store; the rest of the URL is a regular Postgres URL.
+	pgxScheme = "pgx://"
 )
 
 type tenantConfig struct {
-	ID        string `mapstructure:"id"`
-	Name      string `mapstructure:"name"`
-	SecretKey string `mapstructure:"secret_key"`
+	ID                   string                   `mapstructure:"id"`
+	Name                 string                   `mapstructure:"name"`
+	SecretKey            string                   `mapstructure:"secret_key"`
+	VelocityLimits       []velocityLimitConfig    `mapstructure:"velocity_limits"`
+	BalanceLimits        []balanceLimitConfig     `mapstructure:"balance_limits"`
+	BalanceThresholds    []balanceThresholdConfig `mapstructure:"balance_thresholds"`
+	RejectUnknownLedgers bool                     `mapstructure:"reject_unknown_ledgers"`
+	LedgerPolicies       []ledgerPolicyConfig     `mapstructure:"ledger_policies"`
+	Webhook              *webhookConfig           `mapstructure:"webhook"`
+}
+
+type webhookConfig struct {
+	URL           string `mapstructure:"url"`
+	SigningSecret string `mapstructure:"signing_secret"`
+}
+
+type velocityLimitConfig struct {
+	LedgerID       string        `mapstructure:"ledger_id"`
+	Window         time.Duration `mapstructure:"window"`
+	MaxAmountCents int64         `mapstructure:"max_amount_cents"`
+	MaxOperations  int64         `mapstructure:"max_operations"`
+}
+
+type balanceLimitConfig struct {
+	LedgerID        string `mapstructure:"ledger_id"`
+	MaxBalanceCents int64  `mapstructure:"max_balance_cents"`
+}
+
+type balanceThresholdConfig struct {
+	LedgerID       string `mapstructure:"ledger_id"`
+	ThresholdCents int64  `mapstructure:"threshold_cents"`
+}
+
+type ledgerPolicyConfig struct {
+	LedgerID              string        `mapstructure:"ledger_id"`
+	DefaultReservationTTL time.Duration `mapstructure:"default_reservation_ttl"`
+	MaxReservationTTL     time.Duration `mapstructure:"max_reservation_ttl"`
+	MaxGrantAmountCents   int64         `mapstructure:"max_grant_amount_cents"`
+	MaxSpendAmountCents   int64         `mapstructure:"max_spend_amount_cents"`
+	AllowExpiringGrants   *bool         `mapstructure:"allow_expiring_grants"`
 }
 
 type runtimeConfig struct {
 	Service struct {
-		DatabaseURL string `mapstructure:"database_url"`
-		ListenAddr  string `mapstructure:"listen_addr"`
+		DatabaseURL               string        `mapstructure:"database_url"`
+		ListenAddr                string        `mapstructure:"listen_addr"`
+		GrantSchedulePollInterval time.Duration `mapstructure:"grant_schedule_poll_interval"`
+		PartitionCheckInterval    time.Duration `mapstructure:"partition_check_interval"`
+		StrictAccountLookup       bool          `mapstructure:"strict_account_lookup"`
+		WatchPollInterval         time.Duration `mapstructure:"watch_poll_interval"`
+		WebhookPollInterval       time.Duration `mapstructure:"webhook_poll_interval"`
+		WebhookMaxAttempts        int           `mapstructure:"webhook_max_attempts"`
+		WebhookRetryBackoff       time.Duration `mapstructure:"webhook_retry_backoff"`
 	} `mapstructure:"service"`
 	Tenants []tenantConfig `mapstructure:"tenants"`
+
+	requireMigrated bool
 }
 
 var (
-	exitFunc                                                       = os.Exit
-	stderrWriter          io.Writer                                = os.Stderr
-	newLogger             func(...zap.Option) (*zap.Logger, error) = zap.NewProduction
-	openDatabaseFunc                                               = openDatabase
-	prepareSchemaFunc                                              = prepareSchema
-	newServiceFunc                                                 = ledger.NewService
-	gormOpenFunc                                                   = gorm.Open
-	configVariablePattern                                          = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(:-([^}]*))?\}`)
+	exitFunc                                                            = os.Exit
+	stderrWriter               io.Writer                                = os.Stderr
+	newLogger                  func(...zap.Option) (*zap.Logger, error) = zap.NewProduction
+	openDatabaseFunc                                                    = openDatabase
+	prepareSchemaFunc                                                   = prepareSchema
+	requireMigratedSchemaFunc                                           = requireMigratedSchema
+	newServiceFunc                                                      = ledger.NewService
+	newLedgerStoreFunc                                                  = newLedgerStore
+	ensureEntryPartitionsFunc                                           = gormstore.EnsureEntryPartitions
+	partitionLedgerEntriesFunc                                          = gormstore.PartitionLedgerEntries
+	gormOpenFunc                                                        = gorm.Open
+	configVariablePattern                                               = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(:-([^}]*))?\}`)
 )
 
 func main() {
@@ -92,6 +161,12 @@ func newRootCommand() *cobra.Command {
 	}
 
 	cmd.PersistentFlags().String(flagConfigFile, defaultConfigFile, "Path to mandatory configuration file")
+	cmd.Flags().BoolVar(&cfg.requireMigrated, flagRequireMigrated, false, "Refuse to start when schema migrations are pending instead of applying them")
+	cmd.AddCommand(newMigrateCommand(cfg))
+	cmd.AddCommand(newReportCommand(cfg))
+	cmd.AddCommand(newVerifyCommand(cfg))
+	cmd.AddCommand(newVerifyChainCommand(cfg))
+	cmd.AddCommand(newCompactCommand(cfg))
 
 	return cmd
 }
@@ -131,6 +206,24 @@ func loadConfig(cmd *cobra.Command, cfg *runtimeConfig) error {
 	if strings.TrimSpace(cfg.Service.ListenAddr) == "" {
 		return fmt.Errorf("service.listen_addr is required in %q", configFile)
 	}
+	if cfg.Service.GrantSchedulePollInterval < 0 {
+		return fmt.Errorf("service.grant_schedule_poll_interval must not be negative in %q", configFile)
+	}
+	if cfg.Service.PartitionCheckInterval < 0 {
+		return fmt.Errorf("service.partition_check_interval must not be negative in %q", configFile)
+	}
+	if cfg.Service.WatchPollInterval < 0 {
+		return fmt.Errorf("service.watch_poll_interval must not be negative in %q", configFile)
+	}
+	if cfg.Service.WebhookPollInterval < 0 {
+		return fmt.Errorf("service.webhook_poll_interval must not be negative in %q", configFile)
+	}
+	if cfg.Service.WebhookMaxAttempts < 0 {
+		return fmt.Errorf("service.webhook_max_attempts must not be negative in %q", configFile)
+	}
+	if cfg.Service.WebhookRetryBackoff < 0 {
+		return fmt.Errorf("service.webhook_retry_backoff must not be negative in %q", configFile)
+	}
 
 	for _, tenant := range cfg.Tenants {
 		if strings.TrimSpace(tenant.ID) == "" {
@@ -140,6 +233,21 @@ func loadConfig(cmd *cobra.Command, cfg *runtimeConfig) error {
 			return fmt.Errorf("tenant %q secret_key is required in %q", tenant.ID, configFile)
 		}
 	}
+	if _, err := buildVelocityRules(cfg.Tenants); err != nil {
+		return fmt.Errorf("%w in %q", err, configFile)
+	}
+	if _, err := buildBalanceLimits(cfg.Tenants); err != nil {
+		return fmt.Errorf("%w in %q", err, configFile)
+	}
+	if _, err := buildBalanceThresholds(cfg.Tenants); err != nil {
+		return fmt.Errorf("%w in %q", err, configFile)
+	}
+	if _, err := buildTenantPolicies(cfg.Tenants); err != nil {
+		return fmt.Errorf("%w in %q", err, configFile)
+	}
+	if _, err := buildWebhookEndpoints(cfg.Tenants); err != nil {
+		return fmt.Errorf("%w in %q", err, configFile)
+	}
 
 	return nil
 }
@@ -161,6 +269,128 @@ func expandConfigVariables(content string) string {
 	})
 }
 
+func buildVelocityRules(tenants []tenantConfig) ([]ledger.VelocityRule, error) {
+	var rules []ledger.VelocityRule
+	for _, tenant := range tenants {
+		for index, limit := range tenant.VelocityLimits {
+			tenantID, err := ledger.NewTenantID(tenant.ID)
+			if err != nil {
+				return nil, fmt.Errorf("tenant %q velocity_limits[%d]: %w", tenant.ID, index, err)
+			}
+			ledgerID, err := ledger.NewLedgerID(limit.LedgerID)
+			if err != nil {
+				return nil, fmt.Errorf("tenant %q velocity_limits[%d]: %w", tenant.ID, index, err)
+			}
+			rule, err := ledger.NewVelocityRule(tenantID, ledgerID, int64(limit.Window/time.Second), limit.MaxAmountCents, limit.MaxOperations)
+			if err != nil {
+				return nil, fmt.Errorf("tenant %q velocity_limits[%d]: %w", tenant.ID, index, err)
+			}
+			rules = append(rules, rule)
+		}
+	}
+	return rules, nil
+}
+
+func buildBalanceLimits(tenants []tenantConfig) ([]ledger.BalanceLimit, error) {
+	var limits []ledger.BalanceLimit
+	for _, tenant := range tenants {
+		for index, limitConfig := range tenant.BalanceLimits {
+			tenantID, err := ledger.NewTenantID(tenant.ID)
+			if err != nil {
+				return nil, fmt.Errorf("tenant %q balance_limits[%d]: %w", tenant.ID, index, err)
+			}
+			ledgerID, err := ledger.NewLedgerID(limitConfig.LedgerID)
+			if err != nil {
+				return nil, fmt.Errorf("tenant %q balance_limits[%d]: %w", tenant.ID, index, err)
+			}
+			limit, err := ledger.NewBalanceLimit(tenantID, ledgerID, limitConfig.MaxBalanceCents)
+			if err != nil {
+				return nil, fmt.Errorf("tenant %q balance_limits[%d]: %w", tenant.ID, index, err)
+			}
+			limits = append(limits, limit)
+		}
+	}
+	return limits, nil
+}
+
+func buildBalanceThresholds(tenants []tenantConfig) ([]ledger.BalanceThreshold, error) {
+	var thresholds []ledger.BalanceThreshold
+	for _, tenant := range tenants {
+		for index, thresholdConfig := range tenant.BalanceThresholds {
+			tenantID, err := ledger.NewTenantID(tenant.ID)
+			if err != nil {
+				return nil, fmt.Errorf("tenant %q balance_thresholds[%d]: %w", tenant.ID, index, err)
+			}
+			ledgerID, err := ledger.NewLedgerID(thresholdConfig.LedgerID)
+			if err != nil {
+				return nil, fmt.Errorf("tenant %q balance_thresholds[%d]: %w", tenant.ID, index, err)
+			}
+			threshold, err := ledger.NewBalanceThreshold(tenantID, ledgerID, thresholdConfig.ThresholdCents)
+			if err != nil {
+				return nil, fmt.Errorf("tenant %q balance_thresholds[%d]: %w", tenant.ID, index, err)
+			}
+			thresholds = append(thresholds, threshold)
+		}
+	}
+	return thresholds, nil
+}
+
+func buildWebhookEndpoints(tenants []tenantConfig) (map[ledger.TenantID]webhooks.Endpoint, error) {
+	endpoints := make(map[ledger.TenantID]webhooks.Endpoint)
+	for _, tenant := range tenants {
+		if tenant.Webhook == nil {
+			continue
+		}
+		tenantID, err := ledger.NewTenantID(tenant.ID)
+		if err != nil {
+			return nil, fmt.Errorf("tenant %q webhook: %w", tenant.ID, err)
+		}
+		endpoint, err := webhooks.NewEndpoint(tenant.Webhook.URL, tenant.Webhook.SigningSecret)
+		if err != nil {
+			return nil, fmt.Errorf("tenant %q webhook: %w", tenant.ID, err)
+		}
+		endpoints[tenantID] = endpoint
+	}
+	return endpoints, nil
+}
+
+func buildTenantPolicies(tenants []tenantConfig) ([]ledger.TenantPolicy, error) {
+	var policies []ledger.TenantPolicy
+	for _, tenant := range tenants {
+		if !tenant.RejectUnknownLedgers && len(tenant.LedgerPolicies) == 0 {
+			continue
+		}
+		tenantID, err := ledger.NewTenantID(tenant.ID)
+		if err != nil {
+			return nil, fmt.Errorf("tenant %q ledger_policies: %w", tenant.ID, err)
+		}
+		ledgerPolicies := make([]ledger.LedgerPolicy, 0, len(tenant.LedgerPolicies))
+		for index, policyConfig := range tenant.LedgerPolicies {
+			ledgerID, err := ledger.NewLedgerID(policyConfig.LedgerID)
+			if err != nil {
+				return nil, fmt.Errorf("tenant %q ledger_policies[%d]: %w", tenant.ID, index, err)
+			}
+			ledgerPolicy, err := ledger.NewLedgerPolicy(ledgerID, ledger.LedgerPolicySettings{
+				DefaultReservationTTLSeconds: int64(policyConfig.DefaultReservationTTL / time.Second),
+				MaxReservationTTLSeconds:     int64(policyConfig.MaxReservationTTL / time.Second),
+				MaxGrantAmountCents:          policyConfig.MaxGrantAmountCents,
+				MaxSpendAmountCents:          policyConfig.MaxSpendAmountCents,
+				DenyExpiringGrants:           policyConfig.AllowExpiringGrants != nil && !*policyConfig.AllowExpiringGrants,
+			})
+			if err != nil {
+				return nil, fmt.Errorf("tenant %q ledger_policies[%d]: %w", tenant.ID, index, err)
+			}
+			ledgerPolicies = append(ledgerPolicies, ledgerPolicy)
+		}
+		policy, err := ledger.NewTenantPolicy(tenantID, tenant.RejectUnknownLedgers, ledgerPolicies...)
+		if err != nil {
+			return nil, fmt.Errorf("tenant %q ledger_policies: %w", tenant.ID, err)
+		}
+		policies = append(policies, policy)
+	}
+	return policies, nil
+}
+
 type listenFunc func(network, address string) (net.Listener, error)
 
 func runServer(ctx context.Context, cfg *runtimeConfig) error {
@@ -184,22 +414,86 @@ func runServerWithListen(ctx context.Context, cfg *runtimeConfig, logger *zap.Lo
 		}
 	}()
 
-	if err := prepareSchemaFunc(gormDB, driver); err != nil {
+	prepare := prepareSchemaFunc
+	if cfg.requireMigrated {
+		prepare = requireMigratedSchemaFunc
+	}
+	if err := prepare(gormDB, driver); err != nil {
+		return err
+	}
+
+	velocityRules, err := buildVelocityRules(cfg.Tenants)
+	if err != nil {
+		return err
+	}
+	balanceLimits, err := buildBalanceLimits(cfg.Tenants)
+	if err != nil {
+		return err
+	}
+	balanceThresholds, err := buildBalanceThresholds(cfg.Tenants)
+	if err != nil {
+		return err
+	}
+	tenantPolicies, err := buildTenantPolicies(cfg.Tenants)
+	if err != nil {
 		return err
 	}
+	webhookEndpoints, err := buildWebhookEndpoints(cfg.Tenants)
+	if err != nil {
+		return err
+	}
+	outboxTenants := make([]ledger.TenantID, 0, len(webhookEndpoints))
+	for tenantID := range webhookEndpoints {
+		outboxTenants = append(outboxTenants, tenantID)
+	}
 
-	store := gormstore.New(gormDB)
+	store, closeStore, err := newLedgerStoreFunc(ctx, gormDB, driver, cfg.Service.DatabaseURL)
+	if err != nil {
+		return fmt.Errorf("ledger store init: %w", err)
+	}
+	defer closeStore()
 	clock := func() int64 { return time.Now().UTC().Unix() }
 	opLogger := &zapOperationLogger{logger: logger}
 	creditService, err := newServiceFunc(
 		store,
 		clock,
 		ledger.WithOperationLogger(opLogger),
+		ledger.WithVelocityRules(velocityRules...),
+		ledger.WithBalanceLimits(balanceLimits...),
+		ledger.WithBalanceThresholds(balanceThresholds...),
+		ledger.WithTenantPolicies(tenantPolicies...),
+		ledger.WithStrictAccountLookup(cfg.Service.StrictAccountLookup),
+		ledger.WithOutbox(outboxTenants...),
 	)
 	if err != nil {
 		return fmt.Errorf("ledger service init: %w", err)
 	}
 
+	scheduleStore := gormadapters.NewScheduleStore(gormDB)
+	scheduleService, err := schedules.NewService(scheduleStore, clock)
+	if err != nil {
+		return fmt.Errorf("schedule service init: %w", err)
+	}
+	scheduleWorker, err := schedules.NewWorker(scheduleStore, creditService, clock)
+	if err != nil {
+		return fmt.Errorf("schedule worker init: %w", err)
+	}
+	webhookStore := gormadapters.NewWebhookStore(gormDB)
+	webhookService, err := webhooks.NewService(webhookStore, clock)
+	if err != nil {
+		return fmt.Errorf("webhook service init: %w", err)
+	}
+	webhookWorker, err := webhooks.NewWorker(
+		webhookStore,
+		webhookEndpoints,
+		clock,
+		webhooks.WithMaxAttempts(cfg.Service.WebhookMaxAttempts),
+		webhooks.WithRetryBackoff(cfg.Service.WebhookRetryBackoff, 0),
+	)
+	if err != nil {
+		return fmt.Errorf("webhook worker init: %w", err)
+	}
+
 	lis, err := listen("tcp", cfg.Service.ListenAddr)
 	if err != nil {
 		return fmt.Errorf("listen: %w", err)
@@ -216,10 +510,59 @@ func runServerWithListen(ctx context.Context, cfg *runtimeConfig, logger *zap.Lo
 		grpc.ChainUnaryInterceptor(
 			newLoggingInterceptor(logger),
 			newAuthInterceptor(tenantSecrets),
+			newActorInterceptor(),
+		),
+		grpc.ChainStreamInterceptor(
+			newStreamLoggingInterceptor(logger),
+			newStreamAuthInterceptor(tenantSecrets),
 		),
 	)
 
-	creditv1.RegisterCreditServiceServer(grpcServer, grpcserver.NewCreditServiceServer(creditService, tenantIDs))
+	creditv1.RegisterCreditServiceServer(grpcServer, grpcserver.NewCreditServiceServer(
+		creditService,
+		tenantIDs,
+		grpcserver.WithGrantSchedules(scheduleService),
+		grpcserver.WithWebhooks(webhookService),
+		grpcserver.WithWatchPollInterval(cfg.Service.WatchPollInterval),
+	))
+
+	pollInterval := cfg.Service.GrantSchedulePollInterval
+	if pollInterval <= 0 {
+		pollInterval = defaultGrantSchedulePollInterval
+	}
+	webhookPollInterval := cfg.Service.WebhookPollInterval
+	if webhookPollInterval <= 0 {
+		webhookPollInterval = defaultWebhookPollInterval
+	}
+	scheduleActor, err := ledger.NewActor("", grantScheduleActorService, "", "")
+	if err != nil {
+		return err
+	}
+	workerCtx, stopWorker := context.WithCancel(ctx)
+	var workers sync.WaitGroup
+	workers.Go(func() {
+		scheduleWorker.Run(ledger.ContextWithActor(workerCtx, scheduleActor), pollInterval, func(runErr error) {
+			logger.Error("grant schedule run failed", zap.Error(runErr))
+		})
+	})
+	workers.Go(func() {
+		webhookWorker.Run(workerCtx, webhookPollInterval, func(runErr error) {
+			logger.Error("webhook delivery run failed", zap.Error(runErr))
+		})
+	})
+	if driver != "sqlite" {
+		partitionCheckInterval := cfg.Service.PartitionCheckInterval
+		if partitionCheckInterval <= 0 {
+			partitionCheckInterval = defaultPartitionCheckInterval
+		}
+		workers.Go(func() {
+			runEntryPartitionMaintenance(workerCtx, gormDB, partitionCheckInterval, logger)
+		})
+	}
+	defer func() {
+		stopWorker()
+		workers.Wait()
+	}()
 
 	errCh := make(chan error, 1)
 	go func() {
@@ -230,6 +573,27 @@ func runServerWithListen(ctx context.Context, cfg *runtimeConfig, logger *zap.Lo
 	return awaitServer(ctx, grpcServer, errCh, logger)
 }
 
+// runEntryPartitionMaintenance creates upcoming ledger_entries month partitions now and then every interval
+// until ctx ends. It does nothing on databases that are not partitioned by month.
+func runEntryPartitionMaintenance(ctx context.Context, db *gorm.DB, interval time.Duration, logger *zap.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		created, err := ensureEntryPartitionsFunc(ctx, db, time.Now().UTC(), 0)
+		if err != nil && ctx.Err() == nil {
+			logger.Error("ledger entry partition maintenance failed", zap.Error(err))
+		}
+		for _, partition := range created {
+			logger.Info("ledger entry partition created", zap.String("partition", partition))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
 type userIDGetter interface {
 	GetUserId() string
 }
@@ -288,39 +652,112 @@ func extractTenantID(request interface{}) string {
 
 func newAuthInterceptor(tenantSecrets map[string]string) grpc.UnaryServerInterceptor {
 	return func(ctx context.Context, request interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
-		tenantID := extractTenantID(request)
-		if tenantID == "" {
-			return nil, status.Error(codes.Unauthenticated, "missing tenant_id")
+		if err := authorizeRequest(ctx, tenantSecrets, request); err != nil {
+			return nil, err
 		}
+		return handler(ctx, request)
+	}
+}
 
-		expectedSecret, ok := tenantSecrets[tenantID]
-		if !ok {
-			return nil, status.Errorf(codes.PermissionDenied, "tenant %q is not authorized", tenantID)
-		}
+// newStreamAuthInterceptor applies the unary tenant check to the request message of server-streaming RPCs.
+func newStreamAuthInterceptor(tenantSecrets map[string]string) grpc.StreamServerInterceptor {
+	return func(server interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(server, &authorizedServerStream{ServerStream: stream, tenantSecrets: tenantSecrets})
+	}
+}
 
-		md, ok := metadata.FromIncomingContext(ctx)
-		if !ok {
-			return nil, status.Error(codes.Unauthenticated, "missing metadata")
-		}
+type authorizedServerStream struct {
+	grpc.ServerStream
+	tenantSecrets map[string]string
+}
 
-		authHeader := md.Get("authorization")
-		if len(authHeader) == 0 {
-			return nil, status.Error(codes.Unauthenticated, "missing authorization header")
-		}
+func (stream *authorizedServerStream) RecvMsg(message interface{}) error {
+	if err := stream.ServerStream.RecvMsg(message); err != nil {
+		return err
+	}
+	return authorizeRequest(stream.Context(), stream.tenantSecrets, message)
+}
 
-		const bearerPrefix = "Bearer "
-		token := authHeader[0]
-		if !strings.HasPrefix(token, bearerPrefix) {
-			return nil, status.Error(codes.Unauthenticated, "invalid authorization header format")
-		}
+func authorizeRequest(ctx context.Context, tenantSecrets map[string]string, request interface{}) error {
+	tenantID := extractTenantID(request)
+	if tenantID == "" {
+		return status.Error(codes.Unauthenticated, "missing tenant_id")
+	}
 
-		providedSecret := strings.TrimPrefix(token, bearerPrefix)
-		if providedSecret != expectedSecret {
-			return nil, status.Error(codes.Unauthenticated, "invalid secret key")
+	expectedSecret, ok := tenantSecrets[tenantID]
+	if !ok {
+		return status.Errorf(codes.PermissionDenied, "tenant %q is not authorized", tenantID)
+	}
+
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return status.Error(codes.Unauthenticated, "missing metadata")
+	}
+
+	authHeader := md.Get("authorization")
+	if len(authHeader) == 0 {
+		return status.Error(codes.Unauthenticated, "missing authorization header")
+	}
+
+	const bearerPrefix = "Bearer "
+	token := authHeader[0]
+	if !strings.HasPrefix(token, bearerPrefix) {
+		return status.Error(codes.Unauthenticated, "invalid authorization header format")
+	}
+
+	providedSecret := strings.TrimPrefix(token, bearerPrefix)
+	if providedSecret != expectedSecret {
+		return status.Error(codes.Unauthenticated, "invalid secret key")
+	}
+	return nil
+}
+
+// newActorInterceptor attributes the writes of an authenticated request to its API key, calling service
+// (x-client-service), request ID (x-request-id, generated and echoed when missing), and peer address.
+func newActorInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, request interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		actor, err := requestActor(ctx)
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
 		}
+		return handler(ledger.ContextWithActor(ctx, actor), request)
+	}
+}
 
-		return handler(ctx, request)
+func requestActor(ctx context.Context) (ledger.Actor, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	firstValue := func(key string) string {
+		if values := md.Get(key); len(values) > 0 {
+			return values[0]
+		}
+		return ""
+	}
+	apiKeyID := ""
+	if token := strings.TrimPrefix(firstValue("authorization"), "Bearer "); token != "" {
+		apiKeyID = apiKeyFingerprint(token)
+	}
+	requestID := strings.TrimSpace(firstValue(metadataRequestID))
+	if requestID == "" {
+		requestID = newRequestID()
+		_ = grpc.SetHeader(ctx, metadata.Pairs(metadataRequestID, requestID))
 	}
+	clientAddress := ""
+	if remote, ok := peer.FromContext(ctx); ok && remote.Addr != nil {
+		clientAddress = remote.Addr.String()
+	}
+	return ledger.NewActor(apiKeyID, firstValue(metadataClientService), requestID, clientAddress)
+}
+
+// apiKeyFingerprint identifies a tenant secret without storing it: the first 16 hex digits of its SHA-256.
+func apiKeyFingerprint(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return "sha256:" + hex.EncodeToString(sum[:])[:16]
+}
+
+func newRequestID() string {
+	var raw [16]byte
+	_, _ = rand.Read(raw[:])
+	return hex.EncodeToString(raw[:])
 }
 
 func newLoggingInterceptor(logger *zap.Logger) grpc.UnaryServerInterceptor {
@@ -352,6 +789,24 @@ func newLoggingInterceptor(logger *zap.Logger) grpc.UnaryServerInterceptor {
 	}
 }
 
+func newStreamLoggingInterceptor(logger *zap.Logger) grpc.StreamServerInterceptor {
+	return func(server interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		err := handler(server, stream)
+		fields := []zap.Field{
+			zap.String("method", info.FullMethod),
+			zap.Duration("duration", time.Since(start)),
+			zap.String("code", status.Code(err).String()),
+		}
+		if err != nil && status.Code(err) != codes.Canceled {
+			logger.Error("grpc stream failed", append(fields, zap.Error(err))...)
+		} else {
+			logger.Info("grpc stream closed", fields...)
+		}
+		return err
+	}
+}
+
 type zapOperationLogger struct {
 	logger *zap.Logger
 }
@@ -396,6 +851,30 @@ func (logger *zapOperationLogger) LogOperation(_ context.Context, entry ledger.O
 	if metadata := entry.Metadata.String(); metadata != "" && metadata != "{}" {
 		fields = append(fields, zap.String("metadata", metadata))
 	}
+	if crossing := entry.ThresholdCrossing; crossing != nil {
+		fields = append(fields,
+			zap.Int64("threshold_cents", crossing.ThresholdCents),
+			zap.String("direction", crossing.Direction.String()),
+			zap.Int64("previous_available_cents", crossing.PreviousAvailableCents),
+			zap.Int64("available_cents", crossing.AvailableCents),
+		)
+	}
+	if entry.BatchID != "" {
+		fields = append(fields, zap.String("batch_id", entry.BatchID))
+	}
+	if entry.OperationID != "" {
+		fields = append(fields, zap.String("operation_id", entry.OperationID))
+	}
+	if summary := entry.Batch; summary != nil {
+		fields = append(fields,
+			zap.Bool("atomic", summary.Atomic),
+			zap.Int("operations", summary.Operations),
+			zap.Int("succeeded", summary.Succeeded),
+			zap.Int("duplicates", summary.Duplicates),
+			zap.Int("failed", summary.Failed),
+			zap.Int("rolled_back", summary.RolledBack),
+		)
+	}
 	if entry.Error != nil {
 		fields = append(fields, zap.Error(entry.Error))
 		logger.logger.Error(logEventLedgerOperation, fields...)
@@ -417,6 +896,8 @@ func openDatabase(ctx context.Context, dsn string) (*gorm.DB, func() error, stri
 	switch driver {
 	case "postgres":
 		db, err = gormOpenFunc(postgres.Open(dsn), cfg)
+	case "pgx":
+		db, err = gormOpenFunc(postgres.Open(pgxPostgresURL(dsn)), cfg)
 	case "sqlite":
 		db, err = gormOpenFunc(sqlite.Open(sqlitePath), cfg)
 	default:
@@ -437,6 +918,9 @@ func resolveDriver(dsn string) (string, string, error) {
 	if strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://") {
 		return "postgres", "", nil
 	}
+	if strings.HasPrefix(dsn, pgxScheme) {
+		return "pgx", "", nil
+	}
 	if strings.HasPrefix(dsn, "file:") {
 		sqliteDSN, err := normalizeSQLiteFileDSN(dsn)
 		if err != nil {
@@ -472,6 +956,24 @@ func resolveDriver(dsn string) (string, string, error) {
 	return "sqlite", sqlitePath, err
 }
 
+// pgxPostgresURL turns a pgx:// DATABASE_URL into the postgres:// URL both pgx and GORM understand.
+func pgxPostgresURL(dsn string) string {
+	return "postgres://" + strings.TrimPrefix(dsn, pgxScheme)
+}
+
+// newLedgerStore returns the ledger.Store the server runs on: the native pgx store for the pgx driver and the
+// GORM store on the already-open connection otherwise. The returned function releases what the store opened.
+func newLedgerStore(ctx context.Context, gormDB *gorm.DB, driver string, dsn string) (ledger.Store, func(), error) {
+	if driver != "pgx" {
+		return gormstore.New(gormDB), func() {}, nil
+	}
+	store, pool, err := pgxstore.Open(ctx, pgxPostgresURL(dsn))
+	if err != nil {
+		return nil, nil, err
+	}
+	return store, pool.Close, nil
+}
+
 func normalizeSQLiteFileDSN(dsn string) (string, error) {
 	parsed, err := url.Parse(dsn)
 	if err != nil {
@@ -510,26 +1012,45 @@ func normalizeSQLitePath(path string) (string, error) {
 }
 
 func prepareSchema(db *gorm.DB, driver string) error {
-	if driver == "sqlite" {
-		sqlDB, err := db.DB()
-		if err != nil {
-			return fmt.Errorf("sql database: %w", err)
-		}
-		sqlDB.SetMaxOpenConns(1)
-		sqlDB.SetMaxIdleConns(1)
+	if err := configureDatabase(db, driver); err != nil {
+		return err
+	}
+	if _, err := gormstore.MigrateUp(schemaContext(db), db); err != nil {
+		return fmt.Errorf("migrate schema: %w", err)
+	}
+	return nil
+}
 
-		if err := db.Exec("PRAGMA journal_mode=WAL;").Error; err != nil {
-			return fmt.Errorf("pragma journal_mode: %w", err)
-		}
-		if err := db.Exec("PRAGMA busy_timeout=5000;").Error; err != nil {
-			return fmt.Errorf("pragma busy_timeout: %w", err)
-		}
-		if err := db.Exec("PRAGMA foreign_keys=ON;").Error; err != nil {
-			return fmt.Errorf("pragma foreign_keys: %w", err)
-		}
+// requireMigratedSchema configures the connection like prepareSchema but refuses to continue when the
+// database is missing shipped migrations instead of applying them.
+func requireMigratedSchema(db *gorm.DB, driver string) error {
+	if err := configureDatabase(db, driver); err != nil {
+		return err
+	}
+	pending, err := gormstore.PendingMigrations(schemaContext(db), db)
+	if err != nil {
+		return fmt.Errorf("check migrations: %w", err)
 	}
-	if err := db.AutoMigrate(&gormstore.Account{}, &gormstore.LedgerEntry{}, &gormstore.Reservation{}); err != nil {
-		return fmt.Errorf("auto migrate: %w", err)
+	if len(pending) > 0 {
+		versions := make([]string, 0, len(pending))
+		for _, migration := range pending {
+			versions = append(versions, migrationLabel(migration.Version, migration.Name))
+		}
+		return fmt.Errorf("%w: %s; run `ledgerd migrate up`", errPendingMigrations, strings.Join(versions, ", "))
 	}
 	return nil
 }
+
+func configureDatabase(db *gorm.DB, driver string) error {
+	if driver != "sqlite" {
+		return nil
+	}
+	return gormstore.ConfigureSQLite(db)
+}
+
+func schemaContext(db *gorm.DB) context.Context {
+	if db.Statement != nil && db.Statement.Context != nil {
+		return db.Statement.Context
+	}
+	return context.Background()
+}
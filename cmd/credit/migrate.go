@@ -0,0 +1,202 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/MarkoPoloResearchLab/ledger/pkg/ledger/gormstore"
+	"github.com/spf13/cobra"
+	"gorm.io/gorm"
+)
+
+const (
+	flagMigrateSteps    = "steps"
+	flagPartitionBy     = "by"
+	flagHashPartitions  = "hash-partitions"
+	flagMonthsAhead     = "months-ahead"
+	flagPartitionDryRun = "dry-run"
+	migrationApplied    = "applied"
+	migrationPending    = "pending"
+	migrationUnknown    = "unknown"
+	defaultMigrateSteps = 1
+)
+
+var errPendingMigrations = errors.New("schema migrations pending")
+
+func newMigrateCommand(cfg *runtimeConfig) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "Apply, revert, or inspect versioned schema migrations",
+		Args:  cobra.NoArgs,
+	}
+
+	up := &cobra.Command{
+		Use:   "up",
+		Short: "Apply every pending migration",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runMigrateUp(cmd.Context(), cfg, cmd.OutOrStdout())
+		},
+	}
+	down := &cobra.Command{
+		Use:   "down",
+		Short: "Revert the most recently applied migrations",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			steps, _ := cmd.Flags().GetInt(flagMigrateSteps)
+			return runMigrateDown(cmd.Context(), cfg, cmd.OutOrStdout(), steps)
+		},
+	}
+	down.Flags().Int(flagMigrateSteps, defaultMigrateSteps, "Number of migrations to revert")
+	status := &cobra.Command{
+		Use:   "status",
+		Short: "List shipped and applied migrations",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runMigrateStatus(cmd.Context(), cfg, cmd.OutOrStdout())
+		},
+	}
+
+	partition := &cobra.Command{
+		Use:   "partition",
+		Short: "Partition ledger_entries on PostgreSQL by month or by account hash",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			strategy, _ := cmd.Flags().GetString(flagPartitionBy)
+			hashPartitions, _ := cmd.Flags().GetInt(flagHashPartitions)
+			monthsAhead, _ := cmd.Flags().GetInt(flagMonthsAhead)
+			dryRun, _ := cmd.Flags().GetBool(flagPartitionDryRun)
+			options := gormstore.PartitionOptions{
+				Strategy:       gormstore.PartitionStrategy(strategy),
+				HashPartitions: hashPartitions,
+				MonthsAhead:    monthsAhead,
+				DryRun:         dryRun,
+			}
+			return runMigratePartition(cmd.Context(), cfg, cmd.OutOrStdout(), options)
+		},
+	}
+	partition.Flags().String(flagPartitionBy, "", "Partition strategy: month (created_at) or account_hash (account_id)")
+	partition.Flags().Int(flagHashPartitions, 0, "Number of account_hash partitions (default 16)")
+	partition.Flags().Int(flagMonthsAhead, 0, "Months after the current one to create month partitions for (default 3)")
+	partition.Flags().Bool(flagPartitionDryRun, false, "Run the conversion and roll it back, listing the partitions it would create")
+
+	cmd.AddCommand(up, down, status, partition)
+	return cmd
+}
+
+func runMigrateUp(ctx context.Context, cfg *runtimeConfig, out io.Writer) error {
+	return withMigrationDatabase(ctx, cfg, func(db *gorm.DB) error {
+		applied, err := gormstore.MigrateUp(ctx, db)
+		for _, migration := range applied {
+			if _, writeErr := fmt.Fprintf(out, "applied %s\n", migrationLabel(migration.Version, migration.Name)); writeErr != nil {
+				return writeErr
+			}
+		}
+		if err != nil {
+			return err
+		}
+		if len(applied) == 0 {
+			_, err = fmt.Fprintln(out, "schema is up to date")
+		}
+		return err
+	})
+}
+
+func runMigrateDown(ctx context.Context, cfg *runtimeConfig, out io.Writer, steps int) error {
+	if steps <= 0 {
+		return fmt.Errorf("--%s must be positive", flagMigrateSteps)
+	}
+	return withMigrationDatabase(ctx, cfg, func(db *gorm.DB) error {
+		reverted, err := gormstore.MigrateDown(ctx, db, steps)
+		for _, migration := range reverted {
+			if _, writeErr := fmt.Fprintf(out, "reverted %s\n", migrationLabel(migration.Version, migration.Name)); writeErr != nil {
+				return writeErr
+			}
+		}
+		if err != nil {
+			return err
+		}
+		if len(reverted) == 0 {
+			_, err = fmt.Fprintln(out, "no applied migrations to revert")
+		}
+		return err
+	})
+}
+
+func runMigrateStatus(ctx context.Context, cfg *runtimeConfig, out io.Writer) error {
+	return withMigrationDatabase(ctx, cfg, func(db *gorm.DB) error {
+		states, err := gormstore.MigrationStatus(ctx, db)
+		if err != nil {
+			return err
+		}
+		writer := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		if _, err := fmt.Fprintln(writer, "VERSION\tNAME\tSTATUS\tAPPLIED_AT"); err != nil {
+			return err
+		}
+		for _, state := range states {
+			status := migrationPending
+			appliedAt := "-"
+			if state.AppliedAt != nil {
+				status = migrationApplied
+				appliedAt = state.AppliedAt.UTC().Format(time.RFC3339)
+			}
+			if !state.Known {
+				status = migrationUnknown
+			}
+			if _, err := fmt.Fprintf(writer, "%04d\t%s\t%s\t%s\n", state.Version, dashIfEmpty(state.Name), status, appliedAt); err != nil {
+				return err
+			}
+		}
+		return writer.Flush()
+	})
+}
+
+func runMigratePartition(ctx context.Context, cfg *runtimeConfig, out io.Writer, options gormstore.PartitionOptions) error {
+	if options.Strategy == gormstore.PartitionNone {
+		return fmt.Errorf("--%s is required (%s or %s)", flagPartitionBy, gormstore.PartitionByMonth, gormstore.PartitionByAccountHash)
+	}
+	return withMigrationDatabase(ctx, cfg, func(db *gorm.DB) error {
+		created, err := partitionLedgerEntriesFunc(ctx, db, options, time.Now().UTC())
+		if err != nil {
+			return err
+		}
+		if options.DryRun {
+			for _, partition := range created {
+				if _, err := fmt.Fprintf(out, "would create %s\n", partition); err != nil {
+					return err
+				}
+			}
+			_, err = fmt.Fprintf(out, "dry run: ledger_entries would be partitioned by %s; rolled back\n", options.Strategy)
+			return err
+		}
+		for _, partition := range created {
+			if _, err := fmt.Fprintf(out, "created %s\n", partition); err != nil {
+				return err
+			}
+		}
+		_, err = fmt.Fprintf(out, "ledger_entries is partitioned by %s\n", options.Strategy)
+		return err
+	})
+}
+
+// withMigrationDatabase opens the configured database with the same connection settings as the server,
+// without applying migrations.
+func withMigrationDatabase(ctx context.Context, cfg *runtimeConfig, fn func(db *gorm.DB) error) error {
+	gormDB, cleanup, driver, err := openDatabaseFunc(ctx, cfg.Service.DatabaseURL)
+	if err != nil {
+		return fmt.Errorf("database open: %w", err)
+	}
+	defer func() { _ = cleanup() }()
+	if err := configureDatabase(gormDB, driver); err != nil {
+		return err
+	}
+	return fn(gormDB)
+}
+
+func migrationLabel(version int64, name string) string {
+	return fmt.Sprintf("%04d_%s", version, name)
+}
@@ -0,0 +1,268 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MarkoPoloResearchLab/ledger/pkg/ledger/gormstore"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+func writeMigrateConfig(test *testing.T, sqlitePath string) string {
+	test.Helper()
+	configFile := filepath.Join(filepath.Dir(sqlitePath), "config.yml")
+	content := fmt.Sprintf(`
+service:
+  database_url: "sqlite://%s"
+  listen_addr: "127.0.0.1:0"
+`, sqlitePath)
+	if err := os.WriteFile(configFile, []byte(content), 0o644); err != nil {
+		test.Fatalf("write config file: %v", err)
+	}
+	return configFile
+}
+
+func runMigrateCommand(test *testing.T, configFile string, args ...string) string {
+	test.Helper()
+	var output bytes.Buffer
+	cmd := newRootCommand()
+	cmd.SetOut(&output)
+	cmd.SetArgs(append([]string{"--" + flagConfigFile, configFile, "migrate"}, args...))
+	if err := cmd.Execute(); err != nil {
+		test.Fatalf("migrate %s: %v", strings.Join(args, " "), err)
+	}
+	return output.String()
+}
+
+func migrationStatuses(output string) map[string]string {
+	statuses := map[string]string{}
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n")[1:] {
+		fields := strings.Fields(line)
+		statuses[fields[0]] = fields[2]
+	}
+	return statuses
+}
+
+func TestMigrateCommandAppliesRevertsAndReportsStatus(test *testing.T) {
+	test.Parallel()
+	sqlitePath := filepath.Join(test.TempDir(), "ledger.db")
+	configFile := writeMigrateConfig(test, sqlitePath)
+
+	statuses := migrationStatuses(runMigrateCommand(test, configFile, "status"))
+	if len(statuses) != 10 || statuses["0001"] != migrationPending || statuses["0010"] != migrationPending {
+		test.Fatalf("expected every migration pending: %v", statuses)
+	}
+
+	applied := runMigrateCommand(test, configFile, "up")
+	if !strings.Contains(applied, "applied 0001_baseline") || !strings.Contains(applied, "applied 0010_archived_entry_keys") {
+		test.Fatalf("unexpected up output:\n%s", applied)
+	}
+	if output := runMigrateCommand(test, configFile, "up"); !strings.Contains(output, "schema is up to date") {
+		test.Fatalf("unexpected second up output:\n%s", output)
+	}
+
+	reverted := runMigrateCommand(test, configFile, "down", "--steps", "2")
+	if reverted != "reverted 0010_archived_entry_keys\nreverted 0009_grant_consumption\n" {
+		test.Fatalf("unexpected down output:\n%s", reverted)
+	}
+	statuses = migrationStatuses(runMigrateCommand(test, configFile, "status"))
+	for version, status := range statuses {
+		expected := migrationApplied
+		if version == "0009" || version == "0010" {
+			expected = migrationPending
+		}
+		if status != expected {
+			test.Fatalf("expected %s to be %s, got %v", version, expected, statuses)
+		}
+	}
+
+	cmd := newRootCommand()
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetArgs([]string{"--" + flagConfigFile, configFile, "migrate", "down", "--steps", "0"})
+	if err := cmd.Execute(); err == nil || !strings.Contains(err.Error(), "must be positive") {
+		test.Fatalf("expected steps validation error, got %v", err)
+	}
+}
+
+func TestMigrateCommandsReportFailures(test *testing.T) {
+	originalOpenDB := openDatabaseFunc
+	test.Cleanup(func() { openDatabaseFunc = originalOpenDB })
+	ctx := context.Background()
+	openSQLite := func(sqlitePath string) *gorm.DB {
+		test.Helper()
+		db, cleanup, _, err := openDatabase(ctx, "sqlite://"+sqlitePath)
+		if err != nil {
+			test.Fatalf("open database: %v", err)
+		}
+		test.Cleanup(func() { _ = cleanup() })
+		return db
+	}
+
+	cfg := &runtimeConfig{}
+	sqlitePath := filepath.Join(test.TempDir(), "ledger.db")
+	cfg.Service.DatabaseURL = "sqlite://" + sqlitePath
+	var output bytes.Buffer
+	if err := runMigrateDown(ctx, cfg, &output, 1); err != nil || output.String() != "no applied migrations to revert\n" {
+		test.Fatalf("expected nothing to revert, got %q (%v)", output.String(), err)
+	}
+	if err := runMigrateUp(ctx, cfg, alwaysErrorWriter{}); err == nil || err.Error() != "write failed" {
+		test.Fatalf("expected up write failure, got %v", err)
+	}
+	if err := runMigrateDown(ctx, cfg, alwaysErrorWriter{}, 1); err == nil || err.Error() != "write failed" {
+		test.Fatalf("expected down write failure, got %v", err)
+	}
+	if err := openSQLite(sqlitePath).Exec("INSERT INTO schema_migrations (version, name, applied_at) VALUES (9999, 'future', CURRENT_TIMESTAMP)").Error; err != nil {
+		test.Fatalf("record future version: %v", err)
+	}
+	output.Reset()
+	if err := runMigrateStatus(ctx, cfg, &output); err != nil || migrationStatuses(output.String())["9999"] != migrationUnknown {
+		test.Fatalf("expected the future version to be unknown, got %q (%v)", output.String(), err)
+	}
+	if err := runMigrateDown(ctx, cfg, &bytes.Buffer{}, 1); err == nil || !strings.Contains(err.Error(), "not shipped") {
+		test.Fatalf("expected an unshipped version error, got %v", err)
+	}
+
+	brokenPath := filepath.Join(test.TempDir(), "broken.db")
+	broken := openSQLite(brokenPath)
+	if err := broken.Exec("CREATE TABLE schema_migrations (id integer)").Error; err != nil {
+		test.Fatalf("create broken table: %v", err)
+	}
+	cfg.Service.DatabaseURL = "sqlite://" + brokenPath
+	if err := runMigrateUp(ctx, cfg, &bytes.Buffer{}); err == nil || !strings.Contains(err.Error(), "read schema_migrations") {
+		test.Fatalf("expected up read failure, got %v", err)
+	}
+	if err := runMigrateStatus(ctx, cfg, &bytes.Buffer{}); err == nil || !strings.Contains(err.Error(), "read schema_migrations") {
+		test.Fatalf("expected status read failure, got %v", err)
+	}
+	if err := requireMigratedSchema(broken, "sqlite"); err == nil || !strings.Contains(err.Error(), "check migrations") {
+		test.Fatalf("expected check migrations failure, got %v", err)
+	}
+
+	closed := openSQLite(filepath.Join(test.TempDir(), "closed.db"))
+	sqlDB, err := closed.DB()
+	if err != nil {
+		test.Fatalf("sql db: %v", err)
+	}
+	if err := sqlDB.Close(); err != nil {
+		test.Fatalf("close db: %v", err)
+	}
+	openDatabaseFunc = func(context.Context, string) (*gorm.DB, func() error, string, error) {
+		return closed, func() error { return nil }, "sqlite", nil
+	}
+	if err := runMigrateUp(ctx, cfg, &bytes.Buffer{}); err == nil {
+		test.Fatalf("expected a configure failure on a closed database")
+	}
+	if err := requireMigratedSchema(closed, "sqlite"); err == nil {
+		test.Fatalf("expected a configure failure on a closed database")
+	}
+	if schemaContext(&gorm.DB{}) != context.Background() {
+		test.Fatalf("expected the background context without a statement")
+	}
+}
+
+func TestRunServerRequireMigratedRefusesPendingMigrations(test *testing.T) {
+	test.Parallel()
+	sqlitePath := filepath.Join(test.TempDir(), "ledger.db")
+	cfg := &runtimeConfig{requireMigrated: true}
+	cfg.Service.DatabaseURL = "sqlite://" + sqlitePath
+	cfg.Service.ListenAddr = "127.0.0.1:0"
+	listen := func(network, address string) (net.Listener, error) {
+		test.Fatalf("server must not listen with pending migrations")
+		return nil, nil
+	}
+
+	err := runServerWithListen(context.Background(), cfg, zap.NewNop(), listen)
+	if !errors.Is(err, errPendingMigrations) || !strings.Contains(err.Error(), "0001_baseline") || !strings.Contains(err.Error(), "ledgerd migrate up") {
+		test.Fatalf("expected pending migrations error, got %v", err)
+	}
+}
+
+func TestMigratePartitionRequiresStrategyAndPostgres(test *testing.T) {
+	test.Parallel()
+	configFile := writeMigrateConfig(test, filepath.Join(test.TempDir(), "ledger.db"))
+	testCases := []struct {
+		name    string
+		args    []string
+		wantErr string
+	}{
+		{name: "missing strategy", args: []string{"migrate", "partition"}, wantErr: "--by is required"},
+		{name: "sqlite", args: []string{"migrate", "partition", "--by", "month"}, wantErr: "PostgreSQL is required"},
+		{name: "unknown strategy", args: []string{"migrate", "partition", "--by", "weekly"}, wantErr: "unknown strategy"},
+	}
+	for _, testCase := range testCases {
+		test.Run(testCase.name, func(test *testing.T) {
+			test.Parallel()
+			cmd := newRootCommand()
+			cmd.SetOut(&bytes.Buffer{})
+			cmd.SetArgs(append([]string{"--" + flagConfigFile, configFile}, testCase.args...))
+			if err := cmd.Execute(); err == nil || !strings.Contains(err.Error(), testCase.wantErr) {
+				test.Fatalf("expected %q error, got %v", testCase.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestMigratePartitionReportsCreatedPartitionsDryRunsAndFailures(test *testing.T) {
+	originalPartition := partitionLedgerEntriesFunc
+	test.Cleanup(func() { partitionLedgerEntriesFunc = originalPartition })
+	var received gormstore.PartitionOptions
+	partitionErr := errors.New("lock timeout")
+	partitionLedgerEntriesFunc = func(_ context.Context, _ *gorm.DB, options gormstore.PartitionOptions, _ time.Time) ([]string, error) {
+		received = options
+		if options.HashPartitions == 3 {
+			return nil, partitionErr
+		}
+		return []string{"ledger_entries_h000", "ledger_entries_h001"}, nil
+	}
+	sqlitePath := filepath.Join(test.TempDir(), "ledger.db")
+	configFile := writeMigrateConfig(test, sqlitePath)
+
+	output := runMigrateCommand(test, configFile, "partition", "--by", "account_hash", "--hash-partitions", "2", "--months-ahead", "5")
+	if output != "created ledger_entries_h000\ncreated ledger_entries_h001\nledger_entries is partitioned by account_hash\n" {
+		test.Fatalf("unexpected partition output %q", output)
+	}
+	wantOptions := gormstore.PartitionOptions{Strategy: gormstore.PartitionByAccountHash, HashPartitions: 2, MonthsAhead: 5}
+	if received != wantOptions {
+		test.Fatalf("expected options %+v, got %+v", wantOptions, received)
+	}
+
+	output = runMigrateCommand(test, configFile, "partition", "--by", "account_hash", "--dry-run")
+	if output != "would create ledger_entries_h000\nwould create ledger_entries_h001\ndry run: ledger_entries would be partitioned by account_hash; rolled back\n" {
+		test.Fatalf("unexpected dry run output %q", output)
+	}
+	if !received.DryRun {
+		test.Fatalf("expected the dry run flag to reach the partitioner, got %+v", received)
+	}
+
+	cmd := newRootCommand()
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetArgs([]string{"--" + flagConfigFile, configFile, "migrate", "partition", "--by", "account_hash", "--hash-partitions", "3"})
+	if err := cmd.Execute(); !errors.Is(err, partitionErr) {
+		test.Fatalf("expected the partition error, got %v", err)
+	}
+
+	cfg := &runtimeConfig{}
+	cfg.Service.DatabaseURL = "sqlite://" + sqlitePath
+	for _, options := range []gormstore.PartitionOptions{
+		{Strategy: gormstore.PartitionByMonth},
+		{Strategy: gormstore.PartitionByMonth, DryRun: true},
+	} {
+		if err := runMigratePartition(context.Background(), cfg, alwaysErrorWriter{}, options); err == nil || err.Error() != "write failed" {
+			test.Fatalf("expected the write error with %+v, got %v", options, err)
+		}
+	}
+
+	cfg.Service.DatabaseURL = "mysql://ledger"
+	if err := runMigratePartition(context.Background(), cfg, &bytes.Buffer{}, gormstore.PartitionOptions{Strategy: gormstore.PartitionByMonth}); err == nil || !strings.Contains(err.Error(), "database open") {
+		test.Fatalf("expected a database open error, got %v", err)
+	}
+}
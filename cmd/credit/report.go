@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/MarkoPoloResearchLab/ledger/pkg/ledger"
+	"github.com/MarkoPoloResearchLab/ledger/pkg/ledger/gormstore"
+	"github.com/spf13/cobra"
+)
+
+const (
+	flagReportTenant         = "tenant"
+	flagReportAsOf           = "as-of"
+	flagReportFormat         = "format"
+	reportFormatText         = "text"
+	reportFormatJSON         = "json"
+	reportNoExpiryLabel      = "never"
+	reportSectionOutstanding = "outstanding"
+	reportSectionBreakage    = "breakage"
+)
+
+type liabilityBucketOutput struct {
+	LedgerID    string `json:"ledger_id"`
+	ExpiryMonth string `json:"expiry_month"`
+	AmountCents int64  `json:"amount_cents"`
+}
+
+type liabilityReportOutput struct {
+	TenantID    string                  `json:"tenant_id"`
+	AsOfUnixUTC int64                   `json:"as_of_unix_utc"`
+	Outstanding []liabilityBucketOutput `json:"outstanding"`
+	Breakage    []liabilityBucketOutput `json:"breakage"`
+}
+
+func newReportCommand(cfg *runtimeConfig) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "report",
+		Short: "Accounting reports read directly from the database",
+		Args:  cobra.NoArgs,
+	}
+	cmd.AddCommand(newLiabilityReportCommand(cfg))
+	return cmd
+}
+
+func newLiabilityReportCommand(cfg *runtimeConfig) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "liability",
+		Short: "Outstanding credit by expiry month and breakage for a tenant",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			tenant, _ := cmd.Flags().GetString(flagReportTenant)
+			asOf, _ := cmd.Flags().GetString(flagReportAsOf)
+			format, _ := cmd.Flags().GetString(flagReportFormat)
+			return runLiabilityReport(cmd.Context(), cfg, cmd.OutOrStdout(), tenant, asOf, format)
+		},
+	}
+	cmd.Flags().String(flagReportTenant, "", "Tenant to report on")
+	cmd.Flags().String(flagReportAsOf, "", "Report time in RFC 3339 (defaults to now)")
+	cmd.Flags().String(flagReportFormat, reportFormatText, "Output format: text or json")
+	_ = cmd.MarkFlagRequired(flagReportTenant)
+	return cmd
+}
+
+func runLiabilityReport(ctx context.Context, cfg *runtimeConfig, out io.Writer, rawTenantID string, rawAsOf string, format string) error {
+	if format != reportFormatText && format != reportFormatJSON {
+		return fmt.Errorf("unsupported report format %q", format)
+	}
+	tenantID, err := ledger.NewTenantID(rawTenantID)
+	if err != nil {
+		return err
+	}
+	var asOfUnixUTC int64
+	if strings.TrimSpace(rawAsOf) != "" {
+		asOf, err := time.Parse(time.RFC3339, rawAsOf)
+		if err != nil {
+			return fmt.Errorf("parse --%s: %w", flagReportAsOf, err)
+		}
+		asOfUnixUTC = asOf.UTC().Unix()
+	}
+
+	gormDB, cleanup, driver, err := openDatabaseFunc(ctx, cfg.Service.DatabaseURL)
+	if err != nil {
+		return fmt.Errorf("database open: %w", err)
+	}
+	defer func() { _ = cleanup() }()
+	if err := prepareSchemaFunc(gormDB, driver); err != nil {
+		return err
+	}
+	creditService, err := newServiceFunc(gormstore.New(gormDB), func() int64 { return time.Now().UTC().Unix() })
+	if err != nil {
+		return fmt.Errorf("ledger service init: %w", err)
+	}
+	report, err := creditService.LiabilityReport(ctx, tenantID, asOfUnixUTC)
+	if err != nil {
+		return fmt.Errorf("liability report: %w", err)
+	}
+
+	output := liabilityReportOutput{
+		TenantID:    tenantID.String(),
+		AsOfUnixUTC: report.AsOfUnixUTC,
+		Outstanding: mapLiabilityBucketOutputs(report.Outstanding),
+		Breakage:    mapLiabilityBucketOutputs(report.Breakage),
+	}
+	if format == reportFormatJSON {
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(output)
+	}
+	return writeLiabilityReportText(out, output)
+}
+
+func mapLiabilityBucketOutputs(buckets []ledger.LiabilityBucket) []liabilityBucketOutput {
+	outputs := make([]liabilityBucketOutput, 0, len(buckets))
+	for _, bucket := range buckets {
+		outputs = append(outputs, liabilityBucketOutput{
+			LedgerID:    bucket.LedgerID.String(),
+			ExpiryMonth: bucket.ExpiryMonth,
+			AmountCents: bucket.AmountCents.Int64(),
+		})
+	}
+	return outputs
+}
+
+func writeLiabilityReportText(out io.Writer, output liabilityReportOutput) error {
+	var table strings.Builder
+	fmt.Fprintf(&table, "tenant\t%s\n", output.TenantID)
+	fmt.Fprintf(&table, "as_of\t%s\n\n", time.Unix(output.AsOfUnixUTC, 0).UTC().Format(time.RFC3339))
+	table.WriteString("SECTION\tLEDGER\tEXPIRY_MONTH\tAMOUNT_CENTS\n")
+	for _, section := range []struct {
+		name    string
+		buckets []liabilityBucketOutput
+	}{
+		{name: reportSectionOutstanding, buckets: output.Outstanding},
+		{name: reportSectionBreakage, buckets: output.Breakage},
+	} {
+		for _, bucket := range section.buckets {
+			expiryMonth := bucket.ExpiryMonth
+			if expiryMonth == "" {
+				expiryMonth = reportNoExpiryLabel
+			}
+			fmt.Fprintf(&table, "%s\t%s\t%s\t%d\n", section.name, bucket.LedgerID, expiryMonth, bucket.AmountCents)
+		}
+	}
+	writer := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	if _, err := io.WriteString(writer, table.String()); err != nil {
+		return err
+	}
+	return writer.Flush()
+}
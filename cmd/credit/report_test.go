@@ -0,0 +1,183 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/MarkoPoloResearchLab/ledger/pkg/ledger"
+	"github.com/MarkoPoloResearchLab/ledger/pkg/ledger/gormstore"
+	"gorm.io/gorm"
+)
+
+func TestReportLiabilityCommandPrintsBuckets(test *testing.T) {
+	tempDir := test.TempDir()
+	sqlitePath := filepath.Join(tempDir, "ledger.db")
+	seedLiabilityReportDatabase(test, "sqlite://"+sqlitePath)
+
+	configFile := filepath.Join(tempDir, "config.yml")
+	content := fmt.Sprintf(`
+service:
+  database_url: "sqlite://%s"
+  listen_addr: "127.0.0.1:0"
+tenants:
+  - id: "default"
+    secret_key: "default-secret"
+`, sqlitePath)
+	if err := os.WriteFile(configFile, []byte(content), 0o644); err != nil {
+		test.Fatalf("write config file: %v", err)
+	}
+
+	var jsonOutput bytes.Buffer
+	cmd := newRootCommand()
+	cmd.SetOut(&jsonOutput)
+	cmd.SetArgs([]string{"--" + flagConfigFile, configFile, "report", "liability", "--tenant", "default", "--as-of", "2023-11-14T22:13:20Z", "--format", "json"})
+	if err := cmd.Execute(); err != nil {
+		test.Fatalf("report liability: %v", err)
+	}
+	var report liabilityReportOutput
+	if err := json.Unmarshal(jsonOutput.Bytes(), &report); err != nil {
+		test.Fatalf("decode report: %v\n%s", err, jsonOutput.String())
+	}
+	expectedOutstanding := []liabilityBucketOutput{
+		{LedgerID: "default", ExpiryMonth: "2023-12", AmountCents: 200},
+		{LedgerID: "default", ExpiryMonth: "", AmountCents: 1000},
+	}
+	if report.TenantID != "default" || report.AsOfUnixUTC != 1700000000 || len(report.Breakage) != 1 || len(report.Outstanding) != len(expectedOutstanding) {
+		test.Fatalf("unexpected report: %+v", report)
+	}
+	for index, bucket := range expectedOutstanding {
+		if report.Outstanding[index] != bucket {
+			test.Fatalf("outstanding %d: expected %+v, got %+v", index, bucket, report.Outstanding[index])
+		}
+	}
+	if report.Breakage[0] != (liabilityBucketOutput{LedgerID: "default", ExpiryMonth: "2023-11", AmountCents: 50}) {
+		test.Fatalf("unexpected breakage: %+v", report.Breakage)
+	}
+
+	var textOutput bytes.Buffer
+	cmd = newRootCommand()
+	cmd.SetOut(&textOutput)
+	cmd.SetArgs([]string{"--" + flagConfigFile, configFile, "report", "liability", "--tenant", "default", "--as-of", "2023-11-14T22:13:20Z"})
+	if err := cmd.Execute(); err != nil {
+		test.Fatalf("report liability text: %v", err)
+	}
+	for _, expected := range []string{"SECTION", "outstanding  default  2023-12", "outstanding  default  never", "breakage     default  2023-11"} {
+		if !strings.Contains(textOutput.String(), expected) {
+			test.Fatalf("expected text output to contain %q, got:\n%s", expected, textOutput.String())
+		}
+	}
+}
+
+func TestReportLiabilityCommandValidatesFlags(test *testing.T) {
+	test.Parallel()
+	cfg := &runtimeConfig{}
+	testCases := []struct {
+		name     string
+		tenantID string
+		asOf     string
+		format   string
+		expected string
+	}{
+		{name: "format", tenantID: "default", format: "csv", expected: "unsupported report format"},
+		{name: "tenant", tenantID: " ", format: reportFormatText, expected: ledger.ErrInvalidTenantID.Error()},
+		{name: "as of", tenantID: "default", asOf: "yesterday", format: reportFormatText, expected: "parse --as-of"},
+	}
+	for _, testCase := range testCases {
+		err := runLiabilityReport(context.Background(), cfg, &bytes.Buffer{}, testCase.tenantID, testCase.asOf, testCase.format)
+		if err == nil || !strings.Contains(err.Error(), testCase.expected) {
+			test.Fatalf("%s: expected error containing %q, got %v", testCase.name, testCase.expected, err)
+		}
+	}
+}
+
+func TestRunLiabilityReportReportsFailures(test *testing.T) {
+	originalPrepareSchema := prepareSchemaFunc
+	originalNewService := newServiceFunc
+	test.Cleanup(func() {
+		prepareSchemaFunc = originalPrepareSchema
+		newServiceFunc = originalNewService
+	})
+	ctx := context.Background()
+	sqlitePath := filepath.Join(test.TempDir(), "ledger.db")
+	seedLiabilityReportDatabase(test, "sqlite://"+sqlitePath)
+	cfg := &runtimeConfig{}
+	cfg.Service.DatabaseURL = "sqlite://" + sqlitePath
+
+	var output bytes.Buffer
+	if err := runLiabilityReport(ctx, cfg, &output, "default", "", reportFormatText); err != nil {
+		test.Fatalf("report as of now: %v", err)
+	}
+	if !strings.Contains(output.String(), "breakage") {
+		test.Fatalf("expected the expired grant to be breakage as of now, got:\n%s", output.String())
+	}
+	if err := runLiabilityReport(ctx, cfg, alwaysErrorWriter{}, "default", "", reportFormatText); err == nil || err.Error() != "write failed" {
+		test.Fatalf("expected write failure, got %v", err)
+	}
+
+	schemaErr := errors.New("schema failed")
+	prepareSchemaFunc = func(*gorm.DB, string) error { return schemaErr }
+	if err := runLiabilityReport(ctx, cfg, &bytes.Buffer{}, "default", "", reportFormatText); !errors.Is(err, schemaErr) {
+		test.Fatalf("expected schema error, got %v", err)
+	}
+	prepareSchemaFunc = func(*gorm.DB, string) error { return nil }
+	serviceErr := errors.New("service failed")
+	newServiceFunc = func(ledger.Store, func() int64, ...ledger.ServiceOption) (*ledger.Service, error) {
+		return nil, serviceErr
+	}
+	if err := runLiabilityReport(ctx, cfg, &bytes.Buffer{}, "default", "", reportFormatText); !errors.Is(err, serviceErr) {
+		test.Fatalf("expected service init error, got %v", err)
+	}
+	newServiceFunc = originalNewService
+	cfg.Service.DatabaseURL = "sqlite://" + filepath.Join(test.TempDir(), "empty.db")
+	if err := runLiabilityReport(ctx, cfg, &bytes.Buffer{}, "default", "", reportFormatText); err == nil || !strings.Contains(err.Error(), "liability report") {
+		test.Fatalf("expected liability report error without a schema, got %v", err)
+	}
+	cfg.Service.DatabaseURL = "mysql://ledger"
+	if err := runLiabilityReport(ctx, cfg, &bytes.Buffer{}, "default", "", reportFormatText); err == nil || !strings.Contains(err.Error(), "database open") {
+		test.Fatalf("expected database open error, got %v", err)
+	}
+}
+
+func seedLiabilityReportDatabase(test *testing.T, databaseURL string) {
+	test.Helper()
+	ctx := context.Background()
+	gormDB, cleanup, driver, err := openDatabase(ctx, databaseURL)
+	if err != nil {
+		test.Fatalf("open database: %v", err)
+	}
+	defer func() { _ = cleanup() }()
+	if err := prepareSchema(gormDB, driver); err != nil {
+		test.Fatalf("prepare schema: %v", err)
+	}
+	service, err := ledger.NewService(gormstore.New(gormDB), func() int64 { return 1699990000 })
+	if err != nil {
+		test.Fatalf("new service: %v", err)
+	}
+	tenantID, _ := ledger.NewTenantID("default")
+	userID, _ := ledger.NewUserID("user-1")
+	ledgerID, _ := ledger.NewLedgerID("default")
+	metadata, _ := ledger.NewMetadataJSON("{}")
+	grant := func(rawKey string, amountCents int64, expiresAtUnixUTC int64) {
+		test.Helper()
+		amount, _ := ledger.NewPositiveAmountCents(amountCents)
+		idempotencyKey, _ := ledger.NewIdempotencyKey(rawKey)
+		if err := service.Grant(ctx, tenantID, userID, ledgerID, amount, idempotencyKey, expiresAtUnixUTC, metadata); err != nil {
+			test.Fatalf("grant %s: %v", rawKey, err)
+		}
+	}
+	grant("grant-1", 1000, 0)
+	grant("grant-expiring", 300, 1701388800)
+	amount, _ := ledger.NewPositiveAmountCents(100)
+	idempotencyKey, _ := ledger.NewIdempotencyKey("spend-1")
+	if err := service.Spend(ctx, tenantID, userID, ledgerID, amount, idempotencyKey, metadata); err != nil {
+		test.Fatalf("spend: %v", err)
+	}
+	grant("grant-expired", 50, 1699999000)
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/MarkoPoloResearchLab/ledger/pkg/ledger"
+	"github.com/MarkoPoloResearchLab/ledger/pkg/ledger/gormstore"
+	"github.com/spf13/cobra"
+)
+
+var errInvariantsViolated = errors.New("ledger invariants violated")
+
+type invariantViolationOutput struct {
+	Check         string `json:"check"`
+	EntryID       string `json:"entry_id,omitempty"`
+	ReservationID string `json:"reservation_id,omitempty"`
+	ExpectedCents int64  `json:"expected_cents"`
+	ActualCents   int64  `json:"actual_cents"`
+	Message       string `json:"message"`
+}
+
+type accountVerificationOutput struct {
+	AccountID           string                     `json:"account_id"`
+	UserID              string                     `json:"user_id"`
+	LedgerID            string                     `json:"ledger_id"`
+	CheckedEntries      int                        `json:"checked_entries"`
+	CheckedReservations int                        `json:"checked_reservations"`
+	Violations          []invariantViolationOutput `json:"violations"`
+}
+
+// verificationReportOutput lists only the accounts with violations; the counts cover every checked account.
+type verificationReportOutput struct {
+	TenantID        string                      `json:"tenant_id"`
+	CheckedUnixUTC  int64                       `json:"checked_unix_utc"`
+	Valid           bool                        `json:"valid"`
+	AccountsChecked int                         `json:"accounts_checked"`
+	AccountsInvalid int                         `json:"accounts_invalid"`
+	Accounts        []accountVerificationOutput `json:"accounts"`
+}
+
+func newVerifyCommand(cfg *runtimeConfig) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "verify",
+		Short: "Check ledger invariants for one account or every account of a tenant",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			tenant, _ := cmd.Flags().GetString(flagVerifyTenant)
+			user, _ := cmd.Flags().GetString(flagVerifyUser)
+			ledgerName, _ := cmd.Flags().GetString(flagVerifyLedger)
+			format, _ := cmd.Flags().GetString(flagVerifyFormat)
+			return runVerify(cmd.Context(), cfg, cmd.OutOrStdout(), tenant, user, ledgerName, format)
+		},
+	}
+	cmd.Flags().String(flagVerifyTenant, "", "Tenant to verify")
+	cmd.Flags().String(flagVerifyUser, "", "Verify only this user's account (requires --ledger)")
+	cmd.Flags().String(flagVerifyLedger, "", "Verify only accounts of this ledger")
+	cmd.Flags().String(flagVerifyFormat, reportFormatJSON, "Output format: json or text")
+	_ = cmd.MarkFlagRequired(flagVerifyTenant)
+	return cmd
+}
+
+// runVerify prints the invariant report and returns errInvariantsViolated when any account fails a check,
+// so scheduled runs exit non-zero.
+func runVerify(ctx context.Context, cfg *runtimeConfig, out io.Writer, rawTenantID string, rawUserID string, rawLedgerID string, format string) error {
+	if format != reportFormatText && format != reportFormatJSON {
+		return fmt.Errorf("unsupported output format %q", format)
+	}
+	tenantID, err := ledger.NewTenantID(rawTenantID)
+	if err != nil {
+		return err
+	}
+	var filter ledger.ListAccountsFilter
+	var ledgerID ledger.LedgerID
+	if strings.TrimSpace(rawLedgerID) != "" {
+		ledgerID, err = ledger.NewLedgerID(rawLedgerID)
+		if err != nil {
+			return err
+		}
+		filter.LedgerID = &ledgerID
+	}
+	var userID *ledger.UserID
+	if strings.TrimSpace(rawUserID) != "" {
+		if filter.LedgerID == nil {
+			return fmt.Errorf("--%s requires --%s", flagVerifyUser, flagVerifyLedger)
+		}
+		parsedUserID, err := ledger.NewUserID(rawUserID)
+		if err != nil {
+			return err
+		}
+		userID = &parsedUserID
+	}
+
+	gormDB, cleanup, driver, err := openDatabaseFunc(ctx, cfg.Service.DatabaseURL)
+	if err != nil {
+		return fmt.Errorf("database open: %w", err)
+	}
+	defer func() { _ = cleanup() }()
+	if err := prepareSchemaFunc(gormDB, driver); err != nil {
+		return err
+	}
+	creditService, err := newServiceFunc(gormstore.New(gormDB), func() int64 { return time.Now().UTC().Unix() })
+	if err != nil {
+		return fmt.Errorf("ledger service init: %w", err)
+	}
+	var verifications []ledger.AccountVerification
+	if userID != nil {
+		verification, err := creditService.VerifyAccount(ctx, tenantID, *userID, ledgerID)
+		if err != nil {
+			return fmt.Errorf("verify account: %w", err)
+		}
+		verifications = append(verifications, verification)
+	} else {
+		verifications, err = creditService.VerifyAccounts(ctx, tenantID, filter)
+		if err != nil {
+			return fmt.Errorf("verify accounts: %w", err)
+		}
+	}
+
+	output := newVerificationReportOutput(tenantID, verifications)
+	if format == reportFormatJSON {
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(output); err != nil {
+			return err
+		}
+	} else if err := writeVerificationReportText(out, output); err != nil {
+		return err
+	}
+	if !output.Valid {
+		return fmt.Errorf("%w in %d of %d accounts", errInvariantsViolated, output.AccountsInvalid, output.AccountsChecked)
+	}
+	return nil
+}
+
+func newVerificationReportOutput(tenantID ledger.TenantID, verifications []ledger.AccountVerification) verificationReportOutput {
+	output := verificationReportOutput{
+		TenantID:        tenantID.String(),
+		Valid:           true,
+		AccountsChecked: len(verifications),
+		Accounts:        []accountVerificationOutput{},
+	}
+	for _, verification := range verifications {
+		output.CheckedUnixUTC = max(output.CheckedUnixUTC, verification.CheckedUnixUTC)
+		if verification.Valid() {
+			continue
+		}
+		output.Valid = false
+		output.AccountsInvalid++
+		account := accountVerificationOutput{
+			AccountID:           verification.AccountID.String(),
+			UserID:              verification.UserID.String(),
+			LedgerID:            verification.LedgerID.String(),
+			CheckedEntries:      verification.CheckedEntries,
+			CheckedReservations: verification.CheckedReservations,
+			Violations:          make([]invariantViolationOutput, 0, len(verification.Violations)),
+		}
+		for _, violation := range verification.Violations {
+			account.Violations = append(account.Violations, invariantViolationOutput{
+				Check:         violation.Check.String(),
+				EntryID:       violation.EntryID.String(),
+				ReservationID: violation.ReservationID.String(),
+				ExpectedCents: violation.ExpectedCents,
+				ActualCents:   violation.ActualCents,
+				Message:       violation.Message,
+			})
+		}
+		output.Accounts = append(output.Accounts, account)
+	}
+	return output
+}
+
+func writeVerificationReportText(out io.Writer, output verificationReportOutput) error {
+	var table strings.Builder
+	fmt.Fprintf(&table, "tenant\t%s\n", output.TenantID)
+	fmt.Fprintf(&table, "accounts_checked\t%d\n", output.AccountsChecked)
+	fmt.Fprintf(&table, "accounts_invalid\t%d\n", output.AccountsInvalid)
+	if len(output.Accounts) > 0 {
+		table.WriteString("\nUSER\tLEDGER\tCHECK\tENTRY\tRESERVATION\tMESSAGE\n")
+		for _, account := range output.Accounts {
+			for _, violation := range account.Violations {
+				fmt.Fprintf(&table, "%s\t%s\t%s\t%s\t%s\t%s\n", account.UserID, account.LedgerID, violation.Check, dashIfEmpty(violation.EntryID), dashIfEmpty(violation.ReservationID), violation.Message)
+			}
+		}
+	}
+	writer := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	if _, err := io.WriteString(writer, table.String()); err != nil {
+		return err
+	}
+	return writer.Flush()
+}
+
+func dashIfEmpty(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
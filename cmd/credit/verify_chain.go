@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/MarkoPoloResearchLab/ledger/pkg/ledger"
+	"github.com/MarkoPoloResearchLab/ledger/pkg/ledger/gormstore"
+	"github.com/spf13/cobra"
+)
+
+const (
+	flagVerifyTenant = "tenant"
+	flagVerifyUser   = "user"
+	flagVerifyLedger = "ledger"
+	flagVerifyFormat = "format"
+)
+
+var errChainBroken = errors.New("hash chain broken")
+
+type chainBreakOutput struct {
+	Sequence int64  `json:"sequence"`
+	EntryID  string `json:"entry_id,omitempty"`
+	Reason   string `json:"reason"`
+}
+
+type chainVerificationOutput struct {
+	TenantID                string            `json:"tenant_id"`
+	UserID                  string            `json:"user_id"`
+	LedgerID                string            `json:"ledger_id"`
+	Valid                   bool              `json:"valid"`
+	HeadSequence            int64             `json:"head_sequence"`
+	ArchivedThroughSequence int64             `json:"archived_through_sequence"`
+	CheckedEntries          int64             `json:"checked_entries"`
+	Break                   *chainBreakOutput `json:"break,omitempty"`
+}
+
+func newVerifyChainCommand(cfg *runtimeConfig) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "verify-chain",
+		Short: "Verify an account's entry hash chain and report the first broken link",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			tenant, _ := cmd.Flags().GetString(flagVerifyTenant)
+			user, _ := cmd.Flags().GetString(flagVerifyUser)
+			ledgerName, _ := cmd.Flags().GetString(flagVerifyLedger)
+			format, _ := cmd.Flags().GetString(flagVerifyFormat)
+			return runVerifyChain(cmd.Context(), cfg, cmd.OutOrStdout(), tenant, user, ledgerName, format)
+		},
+	}
+	cmd.Flags().String(flagVerifyTenant, "", "Tenant of the account")
+	cmd.Flags().String(flagVerifyUser, "", "User of the account")
+	cmd.Flags().String(flagVerifyLedger, "", "Ledger of the account")
+	cmd.Flags().String(flagVerifyFormat, reportFormatText, "Output format: text or json")
+	_ = cmd.MarkFlagRequired(flagVerifyTenant)
+	_ = cmd.MarkFlagRequired(flagVerifyUser)
+	_ = cmd.MarkFlagRequired(flagVerifyLedger)
+	return cmd
+}
+
+// runVerifyChain prints the verification result and returns errChainBroken when a link fails, so the
+// command exits non-zero.
+func runVerifyChain(ctx context.Context, cfg *runtimeConfig, out io.Writer, rawTenantID string, rawUserID string, rawLedgerID string, format string) error {
+	if format != reportFormatText && format != reportFormatJSON {
+		return fmt.Errorf("unsupported output format %q", format)
+	}
+	tenantID, err := ledger.NewTenantID(rawTenantID)
+	if err != nil {
+		return err
+	}
+	userID, err := ledger.NewUserID(rawUserID)
+	if err != nil {
+		return err
+	}
+	ledgerID, err := ledger.NewLedgerID(rawLedgerID)
+	if err != nil {
+		return err
+	}
+
+	gormDB, cleanup, driver, err := openDatabaseFunc(ctx, cfg.Service.DatabaseURL)
+	if err != nil {
+		return fmt.Errorf("database open: %w", err)
+	}
+	defer func() { _ = cleanup() }()
+	if err := prepareSchemaFunc(gormDB, driver); err != nil {
+		return err
+	}
+	creditService, err := newServiceFunc(gormstore.New(gormDB), func() int64 { return time.Now().UTC().Unix() })
+	if err != nil {
+		return fmt.Errorf("ledger service init: %w", err)
+	}
+	verification, err := creditService.VerifyChain(ctx, tenantID, userID, ledgerID)
+	if err != nil {
+		return fmt.Errorf("verify chain: %w", err)
+	}
+
+	output := chainVerificationOutput{
+		TenantID:                tenantID.String(),
+		UserID:                  userID.String(),
+		LedgerID:                ledgerID.String(),
+		Valid:                   verification.Valid(),
+		HeadSequence:            verification.HeadSequence,
+		ArchivedThroughSequence: verification.ArchivedThroughSequence,
+		CheckedEntries:          verification.CheckedEntries,
+	}
+	if verification.Break != nil {
+		output.Break = &chainBreakOutput{
+			Sequence: verification.Break.Sequence,
+			EntryID:  verification.Break.EntryID.String(),
+			Reason:   verification.Break.Reason.String(),
+		}
+	}
+	if format == reportFormatJSON {
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(output); err != nil {
+			return err
+		}
+	} else if err := writeChainVerificationText(out, output); err != nil {
+		return err
+	}
+	if output.Break != nil {
+		return fmt.Errorf("%w at sequence %d: %s", errChainBroken, output.Break.Sequence, output.Break.Reason)
+	}
+	return nil
+}
+
+func writeChainVerificationText(out io.Writer, output chainVerificationOutput) error {
+	if output.Break == nil && output.ArchivedThroughSequence > 0 {
+		_, err := fmt.Fprintf(out, "chain intact: %d of %d entries verified after %d archived\n", output.CheckedEntries, output.HeadSequence, output.ArchivedThroughSequence)
+		return err
+	}
+	if output.Break == nil {
+		_, err := fmt.Fprintf(out, "chain intact: %d of %d entries verified\n", output.CheckedEntries, output.HeadSequence)
+		return err
+	}
+	_, err := fmt.Fprintf(out, "chain broken at sequence %d (entry %s): %s; %d entries verified before the break\n", output.Break.Sequence, dashIfEmpty(output.Break.EntryID), output.Break.Reason, output.CheckedEntries)
+	return err
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/MarkoPoloResearchLab/ledger/pkg/ledger"
+	"gorm.io/gorm"
+)
+
+func TestVerifyChainCommandReportsFirstBrokenLink(test *testing.T) {
+	tempDir := test.TempDir()
+	sqlitePath := filepath.Join(tempDir, "ledger.db")
+	seedLiabilityReportDatabase(test, "sqlite://"+sqlitePath)
+
+	configFile := filepath.Join(tempDir, "config.yml")
+	content := fmt.Sprintf(`
+service:
+  database_url: "sqlite://%s"
+  listen_addr: "127.0.0.1:0"
+tenants:
+  - id: "default"
+    secret_key: "default-secret"
+`, sqlitePath)
+	if err := os.WriteFile(configFile, []byte(content), 0o644); err != nil {
+		test.Fatalf("write config file: %v", err)
+	}
+	verifyArgs := []string{"--" + flagConfigFile, configFile, "verify-chain", "--tenant", "default", "--user", "user-1", "--ledger", "default"}
+
+	var textOutput bytes.Buffer
+	cmd := newRootCommand()
+	cmd.SetOut(&textOutput)
+	cmd.SetArgs(verifyArgs)
+	if err := cmd.Execute(); err != nil {
+		test.Fatalf("verify chain: %v", err)
+	}
+	if !strings.Contains(textOutput.String(), "chain intact: 4 of 4 entries verified") {
+		test.Fatalf("unexpected output:\n%s", textOutput.String())
+	}
+
+	gormDB, cleanup, _, err := openDatabase(context.Background(), "sqlite://"+sqlitePath)
+	if err != nil {
+		test.Fatalf("open database: %v", err)
+	}
+	if err := gormDB.Exec("UPDATE ledger_entries SET amount_cents = 1 WHERE idempotency_key = ?", "grant-expiring").Error; err != nil {
+		test.Fatalf("tamper: %v", err)
+	}
+	_ = cleanup()
+
+	var jsonOutput bytes.Buffer
+	cmd = newRootCommand()
+	cmd.SetOut(&jsonOutput)
+	cmd.SetArgs(append(verifyArgs, "--format", "json"))
+	if err := cmd.Execute(); !errors.Is(err, errChainBroken) {
+		test.Fatalf("expected broken chain error, got %v", err)
+	}
+	var output chainVerificationOutput
+	if err := json.Unmarshal(jsonOutput.Bytes(), &output); err != nil {
+		test.Fatalf("decode output: %v\n%s", err, jsonOutput.String())
+	}
+	if output.Valid || output.CheckedEntries != 1 || output.Break == nil || output.Break.Sequence != 2 || output.Break.Reason != "hash_mismatch" || output.Break.EntryID == "" {
+		test.Fatalf("unexpected output: %+v", output)
+	}
+
+	textOutput.Reset()
+	cmd = newRootCommand()
+	cmd.SetOut(&textOutput)
+	cmd.SetArgs(verifyArgs)
+	if err := cmd.Execute(); !errors.Is(err, errChainBroken) {
+		test.Fatalf("expected broken chain error, got %v", err)
+	}
+	if !strings.Contains(textOutput.String(), "chain broken at sequence 2 (entry ") || !strings.Contains(textOutput.String(), "hash_mismatch; 1 entries verified before the break") {
+		test.Fatalf("unexpected output:\n%s", textOutput.String())
+	}
+}
+
+func TestRunVerifyChainReportsFailures(test *testing.T) {
+	originalPrepareSchema := prepareSchemaFunc
+	originalNewService := newServiceFunc
+	test.Cleanup(func() {
+		prepareSchemaFunc = originalPrepareSchema
+		newServiceFunc = originalNewService
+	})
+	ctx := context.Background()
+	sqlitePath := filepath.Join(test.TempDir(), "ledger.db")
+	seedLiabilityReportDatabase(test, "sqlite://"+sqlitePath)
+	cfg := &runtimeConfig{}
+	cfg.Service.DatabaseURL = "sqlite://" + sqlitePath
+
+	for _, format := range []string{reportFormatText, reportFormatJSON} {
+		if err := runVerifyChain(ctx, cfg, alwaysErrorWriter{}, "default", "user-1", "default", format); err == nil || err.Error() != "write failed" {
+			test.Fatalf("%s: expected write failure, got %v", format, err)
+		}
+	}
+
+	schemaErr := errors.New("schema failed")
+	prepareSchemaFunc = func(*gorm.DB, string) error { return schemaErr }
+	if err := runVerifyChain(ctx, cfg, &bytes.Buffer{}, "default", "user-1", "default", reportFormatText); !errors.Is(err, schemaErr) {
+		test.Fatalf("expected schema error, got %v", err)
+	}
+	prepareSchemaFunc = func(*gorm.DB, string) error { return nil }
+	serviceErr := errors.New("service failed")
+	var clockUnixUTC int64
+	newServiceFunc = func(_ ledger.Store, nowFn func() int64, _ ...ledger.ServiceOption) (*ledger.Service, error) {
+		clockUnixUTC = nowFn()
+		return nil, serviceErr
+	}
+	if err := runVerifyChain(ctx, cfg, &bytes.Buffer{}, "default", "user-1", "default", reportFormatText); !errors.Is(err, serviceErr) {
+		test.Fatalf("expected service init error, got %v", err)
+	}
+	if clockUnixUTC <= 0 {
+		test.Fatalf("expected the service clock to report the current time, got %d", clockUnixUTC)
+	}
+	newServiceFunc = originalNewService
+	cfg.Service.DatabaseURL = "sqlite://" + filepath.Join(test.TempDir(), "empty.db")
+	if err := runVerifyChain(ctx, cfg, &bytes.Buffer{}, "default", "user-1", "default", reportFormatText); err == nil || !strings.Contains(err.Error(), "verify chain") {
+		test.Fatalf("expected verify chain error without a schema, got %v", err)
+	}
+	cfg.Service.DatabaseURL = "mysql://ledger"
+	if err := runVerifyChain(ctx, cfg, &bytes.Buffer{}, "default", "user-1", "default", reportFormatText); err == nil || !strings.Contains(err.Error(), "database open") {
+		test.Fatalf("expected database open error, got %v", err)
+	}
+}
+
+func TestVerifyChainCommandValidatesFlags(test *testing.T) {
+	test.Parallel()
+	cfg := &runtimeConfig{}
+	testCases := []struct {
+		name     string
+		tenantID string
+		userID   string
+		ledgerID string
+		format   string
+		expected string
+	}{
+		{name: "format", tenantID: "default", userID: "user-1", ledgerID: "default", format: "csv", expected: "unsupported output format"},
+		{name: "tenant", tenantID: " ", userID: "user-1", ledgerID: "default", format: reportFormatText, expected: "tenant"},
+		{name: "user", tenantID: "default", userID: " ", ledgerID: "default", format: reportFormatText, expected: "user"},
+		{name: "ledger", tenantID: "default", userID: "user-1", ledgerID: " ", format: reportFormatText, expected: "ledger"},
+	}
+	for _, testCase := range testCases {
+		err := runVerifyChain(context.Background(), cfg, &bytes.Buffer{}, testCase.tenantID, testCase.userID, testCase.ledgerID, testCase.format)
+		if err == nil || !strings.Contains(err.Error(), testCase.expected) {
+			test.Fatalf("%s: expected error containing %q, got %v", testCase.name, testCase.expected, err)
+		}
+	}
+}
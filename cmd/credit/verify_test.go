@@ -0,0 +1,170 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/MarkoPoloResearchLab/ledger/pkg/ledger"
+	"gorm.io/gorm"
+)
+
+func TestVerifyCommandReportsViolations(test *testing.T) {
+	tempDir := test.TempDir()
+	sqlitePath := filepath.Join(tempDir, "ledger.db")
+	seedLiabilityReportDatabase(test, "sqlite://"+sqlitePath)
+
+	configFile := filepath.Join(tempDir, "config.yml")
+	content := fmt.Sprintf(`
+service:
+  database_url: "sqlite://%s"
+  listen_addr: "127.0.0.1:0"
+tenants:
+  - id: "default"
+    secret_key: "default-secret"
+`, sqlitePath)
+	if err := os.WriteFile(configFile, []byte(content), 0o644); err != nil {
+		test.Fatalf("write config file: %v", err)
+	}
+
+	var consistentOutput bytes.Buffer
+	cmd := newRootCommand()
+	cmd.SetOut(&consistentOutput)
+	cmd.SetArgs([]string{"--" + flagConfigFile, configFile, "verify", "--tenant", "default"})
+	if err := cmd.Execute(); err != nil {
+		test.Fatalf("verify: %v\n%s", err, consistentOutput.String())
+	}
+	var report verificationReportOutput
+	if err := json.Unmarshal(consistentOutput.Bytes(), &report); err != nil {
+		test.Fatalf("decode report: %v\n%s", err, consistentOutput.String())
+	}
+	if !report.Valid || report.AccountsChecked != 1 || report.AccountsInvalid != 0 || len(report.Accounts) != 0 {
+		test.Fatalf("expected a consistent tenant, got %+v", report)
+	}
+
+	gormDB, cleanup, _, err := openDatabase(context.Background(), "sqlite://"+sqlitePath)
+	if err != nil {
+		test.Fatalf("open database: %v", err)
+	}
+	if err := gormDB.Exec("UPDATE ledger_entries SET type = 'hold', reservation_id = 'order-ghost' WHERE idempotency_key = ?", "spend-1").Error; err != nil {
+		test.Fatalf("tamper: %v", err)
+	}
+	_ = cleanup()
+
+	var textOutput bytes.Buffer
+	cmd = newRootCommand()
+	cmd.SetOut(&textOutput)
+	cmd.SetArgs([]string{"--" + flagConfigFile, configFile, "verify", "--tenant", "default", "--user", "user-1", "--ledger", "default", "--format", "text"})
+	if err := cmd.Execute(); !errors.Is(err, errInvariantsViolated) {
+		test.Fatalf("expected invariant violation error, got %v", err)
+	}
+	for _, expected := range []string{"accounts_invalid  1", "user-1  default  reservation_hold"} {
+		if !strings.Contains(textOutput.String(), expected) {
+			test.Fatalf("expected text output to contain %q, got:\n%s", expected, textOutput.String())
+		}
+	}
+}
+
+func TestRunVerifyReportsFailures(test *testing.T) {
+	originalPrepareSchema := prepareSchemaFunc
+	originalNewService := newServiceFunc
+	test.Cleanup(func() {
+		prepareSchemaFunc = originalPrepareSchema
+		newServiceFunc = originalNewService
+	})
+	ctx := context.Background()
+	sqlitePath := filepath.Join(test.TempDir(), "ledger.db")
+	seedLiabilityReportDatabase(test, "sqlite://"+sqlitePath)
+	gormDB, cleanup, _, err := openDatabase(ctx, "sqlite://"+sqlitePath)
+	if err != nil {
+		test.Fatalf("open database: %v", err)
+	}
+	if err := gormDB.Exec("UPDATE ledger_entries SET type = 'hold', reservation_id = 'order-ghost' WHERE idempotency_key = ?", "spend-1").Error; err != nil {
+		test.Fatalf("tamper: %v", err)
+	}
+	_ = cleanup()
+	cfg := &runtimeConfig{}
+	cfg.Service.DatabaseURL = "sqlite://" + sqlitePath
+
+	for _, format := range []string{reportFormatText, reportFormatJSON} {
+		if err := runVerify(ctx, cfg, alwaysErrorWriter{}, "default", "", "", format); err == nil || err.Error() != "write failed" {
+			test.Fatalf("%s: expected write failure, got %v", format, err)
+		}
+	}
+
+	schemaErr := errors.New("schema failed")
+	prepareSchemaFunc = func(*gorm.DB, string) error { return schemaErr }
+	if err := runVerify(ctx, cfg, &bytes.Buffer{}, "default", "", "", reportFormatJSON); !errors.Is(err, schemaErr) {
+		test.Fatalf("expected schema error, got %v", err)
+	}
+	prepareSchemaFunc = func(*gorm.DB, string) error { return nil }
+	serviceErr := errors.New("service failed")
+	newServiceFunc = func(ledger.Store, func() int64, ...ledger.ServiceOption) (*ledger.Service, error) {
+		return nil, serviceErr
+	}
+	if err := runVerify(ctx, cfg, &bytes.Buffer{}, "default", "", "", reportFormatJSON); !errors.Is(err, serviceErr) {
+		test.Fatalf("expected service init error, got %v", err)
+	}
+	newServiceFunc = originalNewService
+	cfg.Service.DatabaseURL = "sqlite://" + filepath.Join(test.TempDir(), "empty.db")
+	if err := runVerify(ctx, cfg, &bytes.Buffer{}, "default", "user-1", "default", reportFormatJSON); err == nil || !strings.Contains(err.Error(), "verify account") {
+		test.Fatalf("expected verify account error without a schema, got %v", err)
+	}
+	if err := runVerify(ctx, cfg, &bytes.Buffer{}, "default", "", "", reportFormatJSON); err == nil || !strings.Contains(err.Error(), "verify accounts") {
+		test.Fatalf("expected verify accounts error without a schema, got %v", err)
+	}
+	cfg.Service.DatabaseURL = "mysql://ledger"
+	if err := runVerify(ctx, cfg, &bytes.Buffer{}, "default", "", "", reportFormatJSON); err == nil || !strings.Contains(err.Error(), "database open") {
+		test.Fatalf("expected database open error, got %v", err)
+	}
+}
+
+func TestWriteVerificationReportTextDashesMissingIdentifiers(test *testing.T) {
+	test.Parallel()
+	output := verificationReportOutput{
+		TenantID:        "default",
+		AccountsChecked: 1,
+		AccountsInvalid: 1,
+		Accounts: []accountVerificationOutput{{
+			UserID:     "user-1",
+			LedgerID:   "default",
+			Violations: []invariantViolationOutput{{Check: "balance_total", Message: "total balance does not match the entry sum"}},
+		}},
+	}
+	var text bytes.Buffer
+	if err := writeVerificationReportText(&text, output); err != nil {
+		test.Fatalf("write report: %v", err)
+	}
+	if !strings.Contains(text.String(), "user-1  default  balance_total  -      -            total balance") {
+		test.Fatalf("expected dashes for the missing entry and reservation, got:\n%s", text.String())
+	}
+}
+
+func TestVerifyCommandValidatesFlags(test *testing.T) {
+	test.Parallel()
+	cfg := &runtimeConfig{}
+	testCases := []struct {
+		name     string
+		tenantID string
+		userID   string
+		ledgerID string
+		format   string
+		expected string
+	}{
+		{name: "format", tenantID: "default", format: "csv", expected: "unsupported output format"},
+		{name: "tenant", tenantID: " ", format: reportFormatJSON, expected: "tenant"},
+		{name: "user without ledger", tenantID: "default", userID: "user-1", format: reportFormatJSON, expected: "--user requires --ledger"},
+	}
+	for _, testCase := range testCases {
+		err := runVerify(context.Background(), cfg, &bytes.Buffer{}, testCase.tenantID, testCase.userID, testCase.ledgerID, testCase.format)
+		if err == nil || !strings.Contains(err.Error(), testCase.expected) {
+			test.Fatalf("%s: expected error containing %q, got %v", testCase.name, testCase.expected, err)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+// Package gormadapters implements the server's schedules.Store and webhooks.Store on the tables that
+// gormstore migrations create. It stays internal because both interfaces use internal types.
+package gormadapters
+
+import (
+	"time"
+
+	"github.com/MarkoPoloResearchLab/ledger/pkg/ledger"
+	"gorm.io/datatypes"
+)
+
+const (
+	defaultMetadataJSON   = "{}"
+	pgUniqueViolationCode = "23505"
+	sqliteConstraintCode  = 19
+	errorOperationStore   = "store"
+	errorSubjectOutbox    = "outbox"
+	errorCodeCreate       = "create"
+	errorCodeDuplicate    = "duplicate"
+	errorCodeGet          = "get"
+	errorCodeInvalid      = "invalid"
+	errorCodeList         = "list"
+	errorCodeUpdateStatus = "update_status"
+)
+
+func wrapStoreError(subject string, code string, err error) error {
+	return ledger.WrapError(errorOperationStore, subject, code, err)
+}
+
+func timeOrZero(value *time.Time) int64 {
+	if value == nil {
+		return 0
+	}
+	return value.Unix()
+}
+
+func unixToTimePointer(unixUTC int64) *time.Time {
+	if unixUTC == 0 {
+		return nil
+	}
+	value := time.Unix(unixUTC, 0).UTC()
+	return &value
+}
+
+func datatypesJSON(raw string) datatypes.JSON {
+	if raw == "" {
+		return datatypes.JSON([]byte(defaultMetadataJSON))
+	}
+	return datatypes.JSON([]byte(raw))
+}
@@ -0,0 +1,68 @@
+package gormadapters
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/MarkoPoloResearchLab/ledger/pkg/ledger"
+	"github.com/MarkoPoloResearchLab/ledger/pkg/ledger/gormstore"
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+func newSQLiteDB(test *testing.T) *gorm.DB {
+	test.Helper()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(test.TempDir(), "ledger.db")), &gorm.Config{})
+	if err != nil {
+		test.Fatalf("open sqlite: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		test.Fatalf("sql db: %v", err)
+	}
+	test.Cleanup(func() { _ = sqlDB.Close() })
+	if _, err := gormstore.MigrateUp(context.Background(), db); err != nil {
+		test.Fatalf("migrate up: %v", err)
+	}
+	return db
+}
+
+func mustTenantID(test *testing.T) ledger.TenantID {
+	test.Helper()
+	tenantID, err := ledger.NewTenantID("default")
+	if err != nil {
+		test.Fatalf("tenant id: %v", err)
+	}
+	return tenantID
+}
+
+func mustUserID(test *testing.T) ledger.UserID {
+	test.Helper()
+	userID, err := ledger.NewUserID("user-123")
+	if err != nil {
+		test.Fatalf("user id: %v", err)
+	}
+	return userID
+}
+
+func mustLedgerID(test *testing.T) ledger.LedgerID {
+	test.Helper()
+	ledgerID, err := ledger.NewLedgerID("default")
+	if err != nil {
+		test.Fatalf("ledger id: %v", err)
+	}
+	return ledgerID
+}
+
+// closeDB closes db so every later query fails.
+func closeDB(test *testing.T, db *gorm.DB) {
+	test.Helper()
+	sqlDB, err := db.DB()
+	if err != nil {
+		test.Fatalf("sql db: %v", err)
+	}
+	if err := sqlDB.Close(); err != nil {
+		test.Fatalf("close db: %v", err)
+	}
+}
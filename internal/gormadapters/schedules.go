@@ -0,0 +1,232 @@
+package gormadapters
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/MarkoPoloResearchLab/ledger/internal/schedules"
+	"github.com/MarkoPoloResearchLab/ledger/pkg/ledger"
+	"github.com/MarkoPoloResearchLab/ledger/pkg/ledger/gormstore"
+	gosqlite "github.com/glebarez/go-sqlite"
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+)
+
+const (
+	constraintGrantSchedulePrimary = "grant_schedules_pkey"
+	errorSubjectSchedule           = "schedule"
+	errorCodeAdvance               = "advance"
+)
+
+// ScheduleStore implements schedules.Store using GORM.
+type ScheduleStore struct {
+	db *gorm.DB
+}
+
+// NewScheduleStore returns a ScheduleStore backed by gorm.DB.
+func NewScheduleStore(db *gorm.DB) *ScheduleStore {
+	return &ScheduleStore{db: db}
+}
+
+func (store *ScheduleStore) CreateSchedule(ctx context.Context, schedule schedules.Schedule) (schedules.Schedule, error) {
+	model := gormstore.GrantSchedule{
+		TenantID:        schedule.TenantID.String(),
+		ScheduleID:      schedule.ScheduleID.String(),
+		UserID:          schedule.UserID.String(),
+		LedgerID:        schedule.LedgerID.String(),
+		AmountCents:     schedule.Amount.Int64(),
+		Interval:        schedule.Interval.String(),
+		StartAt:         time.Unix(schedule.StartUnixUTC, 0).UTC(),
+		EndAt:           unixToTimePointer(schedule.EndUnixUTC),
+		GrantTTLSeconds: schedule.GrantTTLSeconds,
+		Metadata:        datatypesJSON(schedule.Metadata.String()),
+		Status:          schedule.Status.String(),
+		NextPeriod:      schedule.NextPeriod,
+		NextRunAt:       time.Unix(schedule.NextRunUnixUTC, 0).UTC(),
+		CreatedAt:       time.Unix(schedule.CreatedUnixUTC, 0).UTC(),
+		UpdatedAt:       time.Unix(schedule.UpdatedUnixUTC, 0).UTC(),
+	}
+	err := store.db.WithContext(ctx).Create(&model).Error
+	if isScheduleConflict(err) {
+		return schedules.Schedule{}, wrapStoreError(errorSubjectSchedule, errorCodeDuplicate, schedules.ErrScheduleExists)
+	}
+	if err != nil {
+		return schedules.Schedule{}, wrapStoreError(errorSubjectSchedule, errorCodeCreate, err)
+	}
+	return mapGrantSchedule(model)
+}
+
+func (store *ScheduleStore) GetSchedule(ctx context.Context, tenantID ledger.TenantID, scheduleID schedules.ScheduleID) (schedules.Schedule, error) {
+	var model gormstore.GrantSchedule
+	err := store.db.WithContext(ctx).
+		Where("tenant_id = ? AND schedule_id = ?", tenantID.String(), scheduleID.String()).
+		Take(&model).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return schedules.Schedule{}, wrapStoreError(errorSubjectSchedule, errorCodeGet, schedules.ErrUnknownSchedule)
+		}
+		return schedules.Schedule{}, wrapStoreError(errorSubjectSchedule, errorCodeGet, err)
+	}
+	return mapGrantSchedule(model)
+}
+
+func (store *ScheduleStore) ListSchedules(ctx context.Context, tenantID ledger.TenantID, beforeCreatedUnixUTC int64, limit int, filter schedules.ListFilter) ([]schedules.Schedule, error) {
+	before := time.Unix(beforeCreatedUnixUTC, 0).UTC()
+	if beforeCreatedUnixUTC == 0 {
+		before = time.Now().UTC().Add(time.Second)
+	}
+	query := store.db.WithContext(ctx).
+		Where("tenant_id = ? AND created_at < ?", tenantID.String(), before).
+		Order("created_at DESC").
+		Limit(limit)
+	if filter.UserID != nil {
+		query = query.Where("user_id = ?", filter.UserID.String())
+	}
+	if filter.LedgerID != nil {
+		query = query.Where("ledger_id = ?", filter.LedgerID.String())
+	}
+	if len(filter.Statuses) > 0 {
+		statusValues := make([]string, 0, len(filter.Statuses))
+		for _, status := range filter.Statuses {
+			statusValues = append(statusValues, status.String())
+		}
+		query = query.Where("status in ?", statusValues)
+	}
+	var rows []gormstore.GrantSchedule
+	if err := query.Find(&rows).Error; err != nil {
+		return nil, wrapStoreError(errorSubjectSchedule, errorCodeList, err)
+	}
+	return mapGrantSchedules(rows)
+}
+
+func (store *ScheduleStore) ListDueSchedules(ctx context.Context, atUnixUTC int64, limit int) ([]schedules.Schedule, error) {
+	var rows []gormstore.GrantSchedule
+	err := store.db.WithContext(ctx).
+		Where("status = ? AND next_run_at <= ?", schedules.StatusActive.String(), time.Unix(atUnixUTC, 0).UTC()).
+		Order("next_run_at ASC").
+		Limit(limit).
+		Find(&rows).Error
+	if err != nil {
+		return nil, wrapStoreError(errorSubjectSchedule, errorCodeList, err)
+	}
+	return mapGrantSchedules(rows)
+}
+
+func (store *ScheduleStore) UpdateScheduleStatus(ctx context.Context, tenantID ledger.TenantID, scheduleID schedules.ScheduleID, from, to schedules.Status, updatedUnixUTC int64) error {
+	result := store.db.WithContext(ctx).
+		Model(&gormstore.GrantSchedule{}).
+		Where("tenant_id = ? AND schedule_id = ? AND status = ?", tenantID.String(), scheduleID.String(), from.String()).
+		Updates(map[string]interface{}{
+			"status":     to.String(),
+			"updated_at": time.Unix(updatedUnixUTC, 0).UTC(),
+		})
+	if result.Error != nil {
+		return wrapStoreError(errorSubjectSchedule, errorCodeUpdateStatus, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		if _, err := store.GetSchedule(ctx, tenantID, scheduleID); err != nil {
+			return err
+		}
+		return wrapStoreError(errorSubjectSchedule, errorCodeUpdateStatus, schedules.ErrScheduleClosed)
+	}
+	return nil
+}
+
+func (store *ScheduleStore) AdvanceSchedule(ctx context.Context, tenantID ledger.TenantID, scheduleID schedules.ScheduleID, fromPeriod int64, nextRunUnixUTC int64, status schedules.Status, updatedUnixUTC int64) (bool, error) {
+	result := store.db.WithContext(ctx).
+		Model(&gormstore.GrantSchedule{}).
+		Where("tenant_id = ? AND schedule_id = ? AND status = ? AND next_period = ?", tenantID.String(), scheduleID.String(), schedules.StatusActive.String(), fromPeriod).
+		Updates(map[string]interface{}{
+			"next_period": fromPeriod + 1,
+			"next_run_at": time.Unix(nextRunUnixUTC, 0).UTC(),
+			"status":      status.String(),
+			"updated_at":  time.Unix(updatedUnixUTC, 0).UTC(),
+		})
+	if result.Error != nil {
+		return false, wrapStoreError(errorSubjectSchedule, errorCodeAdvance, result.Error)
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func mapGrantSchedules(rows []gormstore.GrantSchedule) ([]schedules.Schedule, error) {
+	result := make([]schedules.Schedule, 0, len(rows))
+	for _, row := range rows {
+		schedule, err := mapGrantSchedule(row)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, schedule)
+	}
+	return result, nil
+}
+
+func mapGrantSchedule(row gormstore.GrantSchedule) (schedules.Schedule, error) {
+	tenantID, err := ledger.NewTenantID(row.TenantID)
+	if err != nil {
+		return schedules.Schedule{}, wrapStoreError(errorSubjectSchedule, errorCodeInvalid, err)
+	}
+	scheduleID, err := schedules.NewScheduleID(row.ScheduleID)
+	if err != nil {
+		return schedules.Schedule{}, wrapStoreError(errorSubjectSchedule, errorCodeInvalid, err)
+	}
+	userID, err := ledger.NewUserID(row.UserID)
+	if err != nil {
+		return schedules.Schedule{}, wrapStoreError(errorSubjectSchedule, errorCodeInvalid, err)
+	}
+	ledgerID, err := ledger.NewLedgerID(row.LedgerID)
+	if err != nil {
+		return schedules.Schedule{}, wrapStoreError(errorSubjectSchedule, errorCodeInvalid, err)
+	}
+	amount, err := ledger.NewPositiveAmountCents(row.AmountCents)
+	if err != nil {
+		return schedules.Schedule{}, wrapStoreError(errorSubjectSchedule, errorCodeInvalid, err)
+	}
+	interval, err := schedules.ParseInterval(row.Interval)
+	if err != nil {
+		return schedules.Schedule{}, wrapStoreError(errorSubjectSchedule, errorCodeInvalid, err)
+	}
+	status, err := schedules.ParseStatus(row.Status)
+	if err != nil {
+		return schedules.Schedule{}, wrapStoreError(errorSubjectSchedule, errorCodeInvalid, err)
+	}
+	metadata, err := ledger.NewMetadataJSON(string(row.Metadata))
+	if err != nil {
+		return schedules.Schedule{}, wrapStoreError(errorSubjectSchedule, errorCodeInvalid, err)
+	}
+	return schedules.Schedule{
+		ScheduleID:      scheduleID,
+		TenantID:        tenantID,
+		UserID:          userID,
+		LedgerID:        ledgerID,
+		Amount:          amount,
+		Interval:        interval,
+		StartUnixUTC:    row.StartAt.UTC().Unix(),
+		EndUnixUTC:      timeOrZero(row.EndAt),
+		GrantTTLSeconds: row.GrantTTLSeconds,
+		Metadata:        metadata,
+		Status:          status,
+		NextPeriod:      row.NextPeriod,
+		NextRunUnixUTC:  row.NextRunAt.UTC().Unix(),
+		CreatedUnixUTC:  row.CreatedAt.UTC().Unix(),
+		UpdatedUnixUTC:  row.UpdatedAt.UTC().Unix(),
+	}, nil
+}
+
+func isScheduleConflict(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return true
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code == pgUniqueViolationCode && pgErr.ConstraintName == constraintGrantSchedulePrimary
+	}
+	var sqliteErr *gosqlite.Error
+	if errors.As(err, &sqliteErr) {
+		return sqliteErr.Code()&0xFF == sqliteConstraintCode
+	}
+	return false
+}
@@ -0,0 +1,278 @@
+package gormadapters
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/MarkoPoloResearchLab/ledger/internal/schedules"
+	"github.com/MarkoPoloResearchLab/ledger/pkg/ledger"
+	"github.com/MarkoPoloResearchLab/ledger/pkg/ledger/gormstore"
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+)
+
+func TestScheduleStoreLifecycle(test *testing.T) {
+	test.Parallel()
+	store := NewScheduleStore(newSQLiteDB(test))
+	ctx := context.Background()
+	tenantID := mustTenantID(test)
+
+	created, err := store.CreateSchedule(ctx, newTestSchedule(test, "monthly-credits", 1000, 2000))
+	if err != nil {
+		test.Fatalf("create schedule: %v", err)
+	}
+	if created.Status != schedules.StatusActive || created.NextRunUnixUTC != 1000 || created.EndUnixUTC != 2000 {
+		test.Fatalf("unexpected created schedule: %+v", created)
+	}
+	if _, err := store.CreateSchedule(ctx, newTestSchedule(test, "monthly-credits", 1000, 0)); !errors.Is(err, schedules.ErrScheduleExists) {
+		test.Fatalf("expected schedule exists, got %v", err)
+	}
+
+	due, err := store.ListDueSchedules(ctx, 999, 10)
+	if err != nil {
+		test.Fatalf("list due early: %v", err)
+	}
+	if len(due) != 0 {
+		test.Fatalf("expected no due schedules, got %d", len(due))
+	}
+	due, err = store.ListDueSchedules(ctx, 1000, 10)
+	if err != nil {
+		test.Fatalf("list due: %v", err)
+	}
+	if len(due) != 1 || due[0].ScheduleID.String() != "monthly-credits" {
+		test.Fatalf("expected one due schedule, got %+v", due)
+	}
+
+	advanced, err := store.AdvanceSchedule(ctx, tenantID, created.ScheduleID, 0, 1500, schedules.StatusActive, 1001)
+	if err != nil || !advanced {
+		test.Fatalf("advance: advanced=%v err=%v", advanced, err)
+	}
+	advanced, err = store.AdvanceSchedule(ctx, tenantID, created.ScheduleID, 0, 1500, schedules.StatusActive, 1002)
+	if err != nil || advanced {
+		test.Fatalf("expected stale advance to be skipped, advanced=%v err=%v", advanced, err)
+	}
+	fetched, err := store.GetSchedule(ctx, tenantID, created.ScheduleID)
+	if err != nil {
+		test.Fatalf("get schedule: %v", err)
+	}
+	if fetched.NextPeriod != 1 || fetched.NextRunUnixUTC != 1500 || fetched.UpdatedUnixUTC != 1001 {
+		test.Fatalf("unexpected advanced schedule: %+v", fetched)
+	}
+
+	if err := store.UpdateScheduleStatus(ctx, tenantID, created.ScheduleID, schedules.StatusActive, schedules.StatusCancelled, 1100); err != nil {
+		test.Fatalf("cancel: %v", err)
+	}
+	if err := store.UpdateScheduleStatus(ctx, tenantID, created.ScheduleID, schedules.StatusActive, schedules.StatusCancelled, 1100); !errors.Is(err, schedules.ErrScheduleClosed) {
+		test.Fatalf("expected schedule closed, got %v", err)
+	}
+	unknownID, err := schedules.NewScheduleID("missing")
+	if err != nil {
+		test.Fatalf("schedule id: %v", err)
+	}
+	if err := store.UpdateScheduleStatus(ctx, tenantID, unknownID, schedules.StatusActive, schedules.StatusCancelled, 1100); !errors.Is(err, schedules.ErrUnknownSchedule) {
+		test.Fatalf("expected unknown schedule, got %v", err)
+	}
+	if _, err := store.GetSchedule(ctx, tenantID, unknownID); !errors.Is(err, schedules.ErrUnknownSchedule) {
+		test.Fatalf("expected unknown schedule, got %v", err)
+	}
+	due, err = store.ListDueSchedules(ctx, 5000, 10)
+	if err != nil {
+		test.Fatalf("list due after cancel: %v", err)
+	}
+	if len(due) != 0 {
+		test.Fatalf("expected cancelled schedule to be skipped, got %d", len(due))
+	}
+}
+
+func TestScheduleStoreListSchedulesAppliesFilters(test *testing.T) {
+	test.Parallel()
+	store := NewScheduleStore(newSQLiteDB(test))
+	ctx := context.Background()
+	tenantID := mustTenantID(test)
+
+	first := newTestSchedule(test, "first", 1000, 0)
+	first.CreatedUnixUTC = 100
+	second := newTestSchedule(test, "second", 1000, 0)
+	second.CreatedUnixUTC = 200
+	otherLedgerID, err := ledger.NewLedgerID("other")
+	if err != nil {
+		test.Fatalf("ledger id: %v", err)
+	}
+	second.LedgerID = otherLedgerID
+	for _, schedule := range []schedules.Schedule{first, second} {
+		if _, err := store.CreateSchedule(ctx, schedule); err != nil {
+			test.Fatalf("create schedule: %v", err)
+		}
+	}
+	if err := store.UpdateScheduleStatus(ctx, tenantID, first.ScheduleID, schedules.StatusActive, schedules.StatusCancelled, 300); err != nil {
+		test.Fatalf("cancel: %v", err)
+	}
+
+	all, err := store.ListSchedules(ctx, tenantID, 0, 10, schedules.ListFilter{})
+	if err != nil {
+		test.Fatalf("list: %v", err)
+	}
+	if len(all) != 2 || all[0].ScheduleID.String() != "second" {
+		test.Fatalf("expected newest first, got %+v", all)
+	}
+	before, err := store.ListSchedules(ctx, tenantID, 200, 10, schedules.ListFilter{})
+	if err != nil {
+		test.Fatalf("list before: %v", err)
+	}
+	if len(before) != 1 || before[0].ScheduleID.String() != "first" {
+		test.Fatalf("expected cursor to skip newer schedules, got %+v", before)
+	}
+	userID := mustUserID(test)
+	byLedger, err := store.ListSchedules(ctx, tenantID, 0, 10, schedules.ListFilter{UserID: &userID, LedgerID: &otherLedgerID})
+	if err != nil {
+		test.Fatalf("list by ledger: %v", err)
+	}
+	if len(byLedger) != 1 || byLedger[0].ScheduleID.String() != "second" {
+		test.Fatalf("expected ledger filter, got %+v", byLedger)
+	}
+	cancelled, err := store.ListSchedules(ctx, tenantID, 0, 10, schedules.ListFilter{Statuses: []schedules.Status{schedules.StatusCancelled}})
+	if err != nil {
+		test.Fatalf("list cancelled: %v", err)
+	}
+	if len(cancelled) != 1 || cancelled[0].ScheduleID.String() != "first" {
+		test.Fatalf("expected status filter, got %+v", cancelled)
+	}
+}
+
+func TestScheduleStoreRejectsCorruptRows(test *testing.T) {
+	test.Parallel()
+	db := newSQLiteDB(test)
+	store := NewScheduleStore(db)
+	ctx := context.Background()
+	schedule := newTestSchedule(test, "corrupt", 1000, 0)
+	if _, err := store.CreateSchedule(ctx, schedule); err != nil {
+		test.Fatalf("create schedule: %v", err)
+	}
+	if err := db.Model(&gormstore.GrantSchedule{}).Where("schedule_id = ?", "corrupt").Update("interval", "hourly").Error; err != nil {
+		test.Fatalf("corrupt row: %v", err)
+	}
+	if _, err := store.GetSchedule(ctx, schedule.TenantID, schedule.ScheduleID); !errors.Is(err, schedules.ErrInvalidInterval) {
+		test.Fatalf("expected invalid interval, got %v", err)
+	}
+	if _, err := store.ListDueSchedules(ctx, 1000, 10); !errors.Is(err, schedules.ErrInvalidInterval) {
+		test.Fatalf("expected invalid interval, got %v", err)
+	}
+}
+
+func newTestSchedule(test *testing.T, rawScheduleID string, startUnixUTC int64, endUnixUTC int64) schedules.Schedule {
+	test.Helper()
+	scheduleID, err := schedules.NewScheduleID(rawScheduleID)
+	if err != nil {
+		test.Fatalf("schedule id: %v", err)
+	}
+	amount, err := ledger.NewPositiveAmountCents(500)
+	if err != nil {
+		test.Fatalf("amount: %v", err)
+	}
+	metadata, err := ledger.NewMetadataJSON(`{"plan":"pro"}`)
+	if err != nil {
+		test.Fatalf("metadata: %v", err)
+	}
+	return schedules.Schedule{
+		ScheduleID:      scheduleID,
+		TenantID:        mustTenantID(test),
+		UserID:          mustUserID(test),
+		LedgerID:        mustLedgerID(test),
+		Amount:          amount,
+		Interval:        schedules.IntervalMonthly,
+		StartUnixUTC:    startUnixUTC,
+		EndUnixUTC:      endUnixUTC,
+		GrantTTLSeconds: 30 * 24 * 60 * 60,
+		Metadata:        metadata,
+		Status:          schedules.StatusActive,
+		NextRunUnixUTC:  startUnixUTC,
+		CreatedUnixUTC:  startUnixUTC,
+		UpdatedUnixUTC:  startUnixUTC,
+	}
+}
+
+func TestMapGrantScheduleRejectsInvalidColumns(test *testing.T) {
+	test.Parallel()
+	db := newSQLiteDB(test)
+	store := NewScheduleStore(db)
+	ctx := context.Background()
+	if _, err := store.CreateSchedule(ctx, newTestSchedule(test, "valid", 1000, 0)); err != nil {
+		test.Fatalf("create schedule: %v", err)
+	}
+	var valid gormstore.GrantSchedule
+	if err := db.Where("schedule_id = ?", "valid").Take(&valid).Error; err != nil {
+		test.Fatalf("load schedule: %v", err)
+	}
+	for _, testCase := range []struct {
+		name   string
+		mutate func(row *gormstore.GrantSchedule)
+	}{
+		{name: "tenant", mutate: func(row *gormstore.GrantSchedule) { row.TenantID = " " }},
+		{name: "schedule", mutate: func(row *gormstore.GrantSchedule) { row.ScheduleID = " " }},
+		{name: "user", mutate: func(row *gormstore.GrantSchedule) { row.UserID = " " }},
+		{name: "ledger", mutate: func(row *gormstore.GrantSchedule) { row.LedgerID = " " }},
+		{name: "amount", mutate: func(row *gormstore.GrantSchedule) { row.AmountCents = 0 }},
+		{name: "status", mutate: func(row *gormstore.GrantSchedule) { row.Status = "paused-forever" }},
+		{name: "metadata", mutate: func(row *gormstore.GrantSchedule) { row.Metadata = []byte("not json") }},
+	} {
+		row := valid
+		testCase.mutate(&row)
+		if _, err := mapGrantSchedule(row); err == nil {
+			test.Fatalf("%s: expected a corrupt row to fail", testCase.name)
+		}
+	}
+	if _, err := mapGrantSchedules([]gormstore.GrantSchedule{valid, {}}); err == nil {
+		test.Fatalf("expected a corrupt row in a list to fail")
+	}
+	if string(datatypesJSON("")) != defaultMetadataJSON {
+		test.Fatalf("expected empty metadata to default to %s", defaultMetadataJSON)
+	}
+}
+
+func TestScheduleStoreWrapsDatabaseErrors(test *testing.T) {
+	test.Parallel()
+	db := newSQLiteDB(test)
+	closeDB(test, db)
+	store := NewScheduleStore(db)
+	ctx := context.Background()
+	schedule := newTestSchedule(test, "closed", 1000, 0)
+	if _, err := store.CreateSchedule(ctx, schedule); err == nil || errors.Is(err, schedules.ErrScheduleExists) {
+		test.Fatalf("expected create to fail with a database error, got %v", err)
+	}
+	if _, err := store.GetSchedule(ctx, schedule.TenantID, schedule.ScheduleID); err == nil || errors.Is(err, schedules.ErrUnknownSchedule) {
+		test.Fatalf("expected get to fail with a database error, got %v", err)
+	}
+	if _, err := store.ListSchedules(ctx, schedule.TenantID, 0, 10, schedules.ListFilter{}); err == nil {
+		test.Fatalf("expected list to fail")
+	}
+	if _, err := store.ListDueSchedules(ctx, 1000, 10); err == nil {
+		test.Fatalf("expected list due to fail")
+	}
+	if err := store.UpdateScheduleStatus(ctx, schedule.TenantID, schedule.ScheduleID, schedules.StatusActive, schedules.StatusCancelled, 1100); err == nil {
+		test.Fatalf("expected update status to fail")
+	}
+	if _, err := store.AdvanceSchedule(ctx, schedule.TenantID, schedule.ScheduleID, 0, 1500, schedules.StatusActive, 1001); err == nil {
+		test.Fatalf("expected advance to fail")
+	}
+}
+
+func TestIsScheduleConflict(test *testing.T) {
+	test.Parallel()
+	testCases := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{name: "nil", err: nil, expected: false},
+		{name: "gorm duplicate", err: gorm.ErrDuplicatedKey, expected: true},
+		{name: "postgres primary key", err: &pgconn.PgError{Code: pgUniqueViolationCode, ConstraintName: constraintGrantSchedulePrimary}, expected: true},
+		{name: "postgres other constraint", err: &pgconn.PgError{Code: pgUniqueViolationCode, ConstraintName: "other"}, expected: false},
+		{name: "other error", err: errors.New("boom"), expected: false},
+	}
+	for _, testCase := range testCases {
+		if isScheduleConflict(testCase.err) != testCase.expected {
+			test.Fatalf("%s: expected %v", testCase.name, testCase.expected)
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package gormadapters
+
+import (
+	"context"
+	"time"
+
+	"github.com/MarkoPoloResearchLab/ledger/internal/webhooks"
+	"github.com/MarkoPoloResearchLab/ledger/pkg/ledger"
+	"github.com/MarkoPoloResearchLab/ledger/pkg/ledger/gormstore"
+	"gorm.io/gorm"
+)
+
+const (
+	outboxStatusPending = string(webhooks.StatusPending)
+	errorCodeClaim      = "claim"
+	errorCodeReset      = "reset"
+	errorCodeUpdate     = "update"
+)
+
+// WebhookStore implements webhooks.Store using GORM.
+type WebhookStore struct {
+	db *gorm.DB
+}
+
+// NewWebhookStore returns a WebhookStore backed by gorm.DB.
+func NewWebhookStore(db *gorm.DB) *WebhookStore {
+	return &WebhookStore{db: db}
+}
+
+func (store *WebhookStore) ListEvents(ctx context.Context, tenantID ledger.TenantID, beforeCreatedUnixUTC int64, limit int, filter webhooks.ListFilter) ([]webhooks.Event, error) {
+	before := time.Unix(beforeCreatedUnixUTC, 0).UTC()
+	if beforeCreatedUnixUTC == 0 {
+		before = time.Now().UTC().Add(time.Second)
+	}
+	query := store.db.WithContext(ctx).
+		Where("tenant_id = ? AND created_at < ?", tenantID.String(), before).
+		Order("created_at DESC").
+		Limit(limit)
+	if len(filter.Statuses) > 0 {
+		statusValues := make([]string, 0, len(filter.Statuses))
+		for _, status := range filter.Statuses {
+			statusValues = append(statusValues, status.String())
+		}
+		query = query.Where("status in ?", statusValues)
+	}
+	var rows []gormstore.OutboxEvent
+	if err := query.Find(&rows).Error; err != nil {
+		return nil, wrapStoreError(errorSubjectOutbox, errorCodeList, err)
+	}
+	return mapOutboxEvents(rows)
+}
+
+func (store *WebhookStore) ListDueEvents(ctx context.Context, tenantIDs []ledger.TenantID, atUnixUTC int64, limit int) ([]webhooks.Event, error) {
+	if len(tenantIDs) == 0 {
+		return nil, nil
+	}
+	tenantValues := make([]string, 0, len(tenantIDs))
+	for _, tenantID := range tenantIDs {
+		tenantValues = append(tenantValues, tenantID.String())
+	}
+	var rows []gormstore.OutboxEvent
+	err := store.db.WithContext(ctx).
+		Where("status = ? AND next_attempt_at <= ? AND tenant_id in ?", outboxStatusPending, time.Unix(atUnixUTC, 0).UTC(), tenantValues).
+		Order("next_attempt_at ASC").
+		Order("created_at ASC").
+		Limit(limit).
+		Find(&rows).Error
+	if err != nil {
+		return nil, wrapStoreError(errorSubjectOutbox, errorCodeList, err)
+	}
+	return mapOutboxEvents(rows)
+}
+
+func (store *WebhookStore) ClaimEvent(ctx context.Context, eventID webhooks.EventID, atUnixUTC int64, leaseUntilUnixUTC int64) (bool, error) {
+	result := store.db.WithContext(ctx).
+		Model(&gormstore.OutboxEvent{}).
+		Where("event_id = ? AND status = ? AND next_attempt_at <= ?", eventID.String(), outboxStatusPending, time.Unix(atUnixUTC, 0).UTC()).
+		Update("next_attempt_at", time.Unix(leaseUntilUnixUTC, 0).UTC())
+	if result.Error != nil {
+		return false, wrapStoreError(errorSubjectOutbox, errorCodeClaim, result.Error)
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (store *WebhookStore) UpdateDelivery(ctx context.Context, eventID webhooks.EventID, update webhooks.DeliveryUpdate) error {
+	result := store.db.WithContext(ctx).
+		Model(&gormstore.OutboxEvent{}).
+		Where("event_id = ?", eventID.String()).
+		Updates(map[string]interface{}{
+			"status":          update.Status.String(),
+			"attempts":        update.Attempts,
+			"next_attempt_at": time.Unix(update.NextAttemptUnixUTC, 0).UTC(),
+			"last_error":      update.LastError,
+			"delivered_at":    unixToTimePointer(update.DeliveredUnixUTC),
+		})
+	if result.Error != nil {
+		return wrapStoreError(errorSubjectOutbox, errorCodeUpdate, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return wrapStoreError(errorSubjectOutbox, errorCodeUpdate, webhooks.ErrUnknownEvent)
+	}
+	return nil
+}
+
+func (store *WebhookStore) ResetEvent(ctx context.Context, tenantID ledger.TenantID, eventID webhooks.EventID, nextAttemptUnixUTC int64) (webhooks.Event, error) {
+	var model gormstore.OutboxEvent
+	err := store.db.WithContext(ctx).Transaction(func(transaction *gorm.DB) error {
+		result := transaction.
+			Model(&gormstore.OutboxEvent{}).
+			Where("tenant_id = ? AND event_id = ?", tenantID.String(), eventID.String()).
+			Updates(map[string]interface{}{
+				"status":          outboxStatusPending,
+				"attempts":        0,
+				"next_attempt_at": time.Unix(nextAttemptUnixUTC, 0).UTC(),
+				"last_error":      "",
+				"delivered_at":    nil,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return webhooks.ErrUnknownEvent
+		}
+		return transaction.Where("event_id = ?", eventID.String()).Take(&model).Error
+	})
+	if err != nil {
+		return webhooks.Event{}, wrapStoreError(errorSubjectOutbox, errorCodeReset, err)
+	}
+	return mapOutboxEvent(model)
+}
+
+func mapOutboxEvents(rows []gormstore.OutboxEvent) ([]webhooks.Event, error) {
+	result := make([]webhooks.Event, 0, len(rows))
+	for _, row := range rows {
+		event, err := mapOutboxEvent(row)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, event)
+	}
+	return result, nil
+}
+
+func mapOutboxEvent(row gormstore.OutboxEvent) (webhooks.Event, error) {
+	eventID, err := webhooks.NewEventID(row.EventID)
+	if err != nil {
+		return webhooks.Event{}, wrapStoreError(errorSubjectOutbox, errorCodeInvalid, err)
+	}
+	tenantID, err := ledger.NewTenantID(row.TenantID)
+	if err != nil {
+		return webhooks.Event{}, wrapStoreError(errorSubjectOutbox, errorCodeInvalid, err)
+	}
+	status, err := webhooks.ParseStatus(row.Status)
+	if err != nil {
+		return webhooks.Event{}, wrapStoreError(errorSubjectOutbox, errorCodeInvalid, err)
+	}
+	return webhooks.Event{
+		EventID:            eventID,
+		TenantID:           tenantID,
+		EventType:          row.EventType,
+		PayloadJSON:        string(row.Payload),
+		Status:             status,
+		Attempts:           row.Attempts,
+		NextAttemptUnixUTC: row.NextAttemptAt.UTC().Unix(),
+		LastError:          row.LastError,
+		CreatedUnixUTC:     row.CreatedAt.UTC().Unix(),
+		DeliveredUnixUTC:   timeOrZero(row.DeliveredAt),
+	}, nil
+}
@@ -0,0 +1,205 @@
+package gormadapters
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/MarkoPoloResearchLab/ledger/internal/webhooks"
+	"github.com/MarkoPoloResearchLab/ledger/pkg/ledger"
+	"github.com/MarkoPoloResearchLab/ledger/pkg/ledger/gormstore"
+)
+
+func TestOutboxEventsCommitWithTheirTransaction(test *testing.T) {
+	test.Parallel()
+	db := newSQLiteDB(test)
+	store := gormstore.New(db)
+	ctx := context.Background()
+	tenantID := mustTenantID(test)
+
+	sentinelError := errors.New("rollback requested")
+	err := store.WithTx(ctx, func(ctx context.Context, txStore ledger.Store) error {
+		if err := txStore.(*gormstore.Store).InsertOutboxEvent(ctx, ledger.OutboxEvent{TenantID: tenantID, EventType: "entry.grant", PayloadJSON: `{}`, CreatedUnixUTC: 1000}); err != nil {
+			return err
+		}
+		return sentinelError
+	})
+	if !errors.Is(err, sentinelError) {
+		test.Fatalf("expected sentinel error, got %v", err)
+	}
+
+	service, err := ledger.NewService(store, func() int64 { return 1000 }, ledger.WithOutbox(tenantID))
+	if err != nil {
+		test.Fatalf("new service: %v", err)
+	}
+	amount, _ := ledger.NewPositiveAmountCents(500)
+	idempotencyKey, _ := ledger.NewIdempotencyKey("grant-1")
+	metadata, _ := ledger.NewMetadataJSON(`{"source":"test"}`)
+	if err := service.Grant(ctx, tenantID, mustUserID(test), mustLedgerID(test), amount, idempotencyKey, 0, metadata); err != nil {
+		test.Fatalf("grant: %v", err)
+	}
+	overdraft, _ := ledger.NewPositiveAmountCents(900)
+	spendKey, _ := ledger.NewIdempotencyKey("spend-1")
+	if err := service.Spend(ctx, tenantID, mustUserID(test), mustLedgerID(test), overdraft, spendKey, metadata); !errors.Is(err, ledger.ErrInsufficientFunds) {
+		test.Fatalf("expected insufficient funds, got %v", err)
+	}
+
+	events, err := NewWebhookStore(db).ListEvents(ctx, tenantID, 0, 10, webhooks.ListFilter{})
+	if err != nil {
+		test.Fatalf("list events: %v", err)
+	}
+	if len(events) != 1 || events[0].EventType != "entry.grant" || events[0].Status != webhooks.StatusPending || events[0].NextAttemptUnixUTC != 1000 {
+		test.Fatalf("expected only the committed grant event, got %+v", events)
+	}
+	var payload map[string]any
+	if err := json.Unmarshal([]byte(events[0].PayloadJSON), &payload); err != nil {
+		test.Fatalf("decode payload: %v", err)
+	}
+	if payload["user_id"] != mustUserID(test).String() || payload["amount_cents"] != float64(500) {
+		test.Fatalf("unexpected payload: %v", payload)
+	}
+}
+
+func TestWebhookStoreDeliveryLifecycle(test *testing.T) {
+	test.Parallel()
+	db := newSQLiteDB(test)
+	store := NewWebhookStore(db)
+	ctx := context.Background()
+	tenantID := mustTenantID(test)
+	otherTenantID, _ := ledger.NewTenantID("other")
+	for _, event := range []ledger.OutboxEvent{
+		{TenantID: tenantID, EventType: "entry.grant", PayloadJSON: `{"n":1}`, CreatedUnixUTC: 1000},
+		{TenantID: otherTenantID, EventType: "entry.grant", PayloadJSON: `{"n":2}`, CreatedUnixUTC: 1000},
+	} {
+		if err := gormstore.New(db).InsertOutboxEvent(ctx, event); err != nil {
+			test.Fatalf("insert outbox event: %v", err)
+		}
+	}
+
+	if due, err := store.ListDueEvents(ctx, []ledger.TenantID{tenantID}, 999, 10); err != nil || len(due) != 0 {
+		test.Fatalf("expected nothing due early, got %+v (%v)", due, err)
+	}
+	due, err := store.ListDueEvents(ctx, []ledger.TenantID{tenantID}, 1000, 10)
+	if err != nil || len(due) != 1 || due[0].TenantID != tenantID {
+		test.Fatalf("expected the tenant's event to be due, got %+v (%v)", due, err)
+	}
+	eventID := due[0].EventID
+
+	claimed, err := store.ClaimEvent(ctx, eventID, 1000, 1060)
+	if err != nil || !claimed {
+		test.Fatalf("claim: claimed=%v err=%v", claimed, err)
+	}
+	claimed, err = store.ClaimEvent(ctx, eventID, 1000, 1060)
+	if err != nil || claimed {
+		test.Fatalf("expected a second claim to lose, claimed=%v err=%v", claimed, err)
+	}
+
+	if err := store.UpdateDelivery(ctx, eventID, webhooks.DeliveryUpdate{Status: webhooks.StatusDead, Attempts: 3, NextAttemptUnixUTC: 1000, LastError: "status 500"}); err != nil {
+		test.Fatalf("update delivery: %v", err)
+	}
+	dead, err := store.ListEvents(ctx, tenantID, 0, 10, webhooks.ListFilter{Statuses: []webhooks.Status{webhooks.StatusDead}})
+	if err != nil || len(dead) != 1 || dead[0].Attempts != 3 || dead[0].LastError != "status 500" {
+		test.Fatalf("expected one dead event, got %+v (%v)", dead, err)
+	}
+	if due, err := store.ListDueEvents(ctx, []ledger.TenantID{tenantID}, 5000, 10); err != nil || len(due) != 0 {
+		test.Fatalf("expected dead events not to be due, got %+v (%v)", due, err)
+	}
+
+	if _, err := store.ResetEvent(ctx, otherTenantID, eventID, 2000); !errors.Is(err, webhooks.ErrUnknownEvent) {
+		test.Fatalf("expected unknown event for another tenant, got %v", err)
+	}
+	reset, err := store.ResetEvent(ctx, tenantID, eventID, 2000)
+	if err != nil {
+		test.Fatalf("reset: %v", err)
+	}
+	if reset.Status != webhooks.StatusPending || reset.Attempts != 0 || reset.LastError != "" || reset.NextAttemptUnixUTC != 2000 {
+		test.Fatalf("unexpected reset event: %+v", reset)
+	}
+	if err := store.UpdateDelivery(ctx, eventID, webhooks.DeliveryUpdate{Status: webhooks.StatusDelivered, Attempts: 1, NextAttemptUnixUTC: 2000, DeliveredUnixUTC: 2001}); err != nil {
+		test.Fatalf("mark delivered: %v", err)
+	}
+	delivered, err := store.ListEvents(ctx, tenantID, 0, 10, webhooks.ListFilter{Statuses: []webhooks.Status{webhooks.StatusDelivered}})
+	if err != nil || len(delivered) != 1 || delivered[0].DeliveredUnixUTC != 2001 {
+		test.Fatalf("expected one delivered event, got %+v (%v)", delivered, err)
+	}
+	unknownID, _ := webhooks.NewEventID("00000000-0000-0000-0000-000000000000")
+	if err := store.UpdateDelivery(ctx, unknownID, webhooks.DeliveryUpdate{Status: webhooks.StatusDelivered}); !errors.Is(err, webhooks.ErrUnknownEvent) {
+		test.Fatalf("expected unknown event, got %v", err)
+	}
+}
+
+func TestWebhookStoreListDueEventsWithoutTenants(test *testing.T) {
+	test.Parallel()
+	due, err := NewWebhookStore(newSQLiteDB(test)).ListDueEvents(context.Background(), nil, 1000, 10)
+	if err != nil || due != nil {
+		test.Fatalf("expected no events without tenants, got %+v (%v)", due, err)
+	}
+}
+
+func TestWebhookStoreRejectsCorruptRows(test *testing.T) {
+	test.Parallel()
+	db := newSQLiteDB(test)
+	ctx := context.Background()
+	tenantID := mustTenantID(test)
+	if err := gormstore.New(db).InsertOutboxEvent(ctx, ledger.OutboxEvent{TenantID: tenantID, EventType: "entry.grant", PayloadJSON: `{}`, CreatedUnixUTC: 1000}); err != nil {
+		test.Fatalf("insert outbox event: %v", err)
+	}
+	if err := db.Model(&gormstore.OutboxEvent{}).Where("tenant_id = ?", tenantID.String()).Update("event_id", " ").Error; err != nil {
+		test.Fatalf("corrupt row: %v", err)
+	}
+	if _, err := NewWebhookStore(db).ListDueEvents(ctx, []ledger.TenantID{tenantID}, 1000, 10); !errors.Is(err, webhooks.ErrInvalidEventID) {
+		test.Fatalf("expected invalid event id, got %v", err)
+	}
+	valid := gormstore.OutboxEvent{EventID: "event-1", TenantID: tenantID.String(), Status: webhooks.StatusPending.String()}
+	for _, testCase := range []struct {
+		name   string
+		mutate func(row *gormstore.OutboxEvent)
+	}{
+		{name: "tenant", mutate: func(row *gormstore.OutboxEvent) { row.TenantID = " " }},
+		{name: "status", mutate: func(row *gormstore.OutboxEvent) { row.Status = "lost" }},
+	} {
+		row := valid
+		testCase.mutate(&row)
+		if _, err := mapOutboxEvent(row); err == nil {
+			test.Fatalf("%s: expected a corrupt row to fail", testCase.name)
+		}
+	}
+}
+
+func TestWebhookStoreWrapsDatabaseErrors(test *testing.T) {
+	test.Parallel()
+	db := newSQLiteDB(test)
+	closeDB(test, db)
+	store := NewWebhookStore(db)
+	ctx := context.Background()
+	tenantID := mustTenantID(test)
+	eventID, _ := webhooks.NewEventID("00000000-0000-0000-0000-000000000000")
+	if _, err := store.ListEvents(ctx, tenantID, 0, 10, webhooks.ListFilter{}); err == nil {
+		test.Fatalf("expected list events to fail")
+	}
+	if _, err := store.ListDueEvents(ctx, []ledger.TenantID{tenantID}, 1000, 10); err == nil {
+		test.Fatalf("expected list due events to fail")
+	}
+	if _, err := store.ClaimEvent(ctx, eventID, 1000, 1060); err == nil {
+		test.Fatalf("expected claim to fail")
+	}
+	if err := store.UpdateDelivery(ctx, eventID, webhooks.DeliveryUpdate{Status: webhooks.StatusDelivered}); err == nil || errors.Is(err, webhooks.ErrUnknownEvent) {
+		test.Fatalf("expected update delivery to fail with a database error, got %v", err)
+	}
+	if _, err := store.ResetEvent(ctx, tenantID, eventID, 2000); err == nil || errors.Is(err, webhooks.ErrUnknownEvent) {
+		test.Fatalf("expected reset to fail with a database error, got %v", err)
+	}
+}
+
+func TestWebhookStoreResetEventReturnsUpdateErrors(test *testing.T) {
+	test.Parallel()
+	db := newSQLiteDB(test)
+	if err := db.Migrator().DropTable(&gormstore.OutboxEvent{}); err != nil {
+		test.Fatalf("drop outbox table: %v", err)
+	}
+	eventID, _ := webhooks.NewEventID("00000000-0000-0000-0000-000000000000")
+	if _, err := NewWebhookStore(db).ResetEvent(context.Background(), mustTenantID(test), eventID, 2000); err == nil || errors.Is(err, webhooks.ErrUnknownEvent) {
+		test.Fatalf("expected reset to fail with a database error, got %v", err)
+	}
+}
@@ -8,6 +8,8 @@ import (
 	"time"
 
 	"github.com/MarkoPoloResearchLab/ledger/api/credit/v1"
+	"github.com/MarkoPoloResearchLab/ledger/internal/schedules"
+	"github.com/MarkoPoloResearchLab/ledger/internal/webhooks"
 	"github.com/MarkoPoloResearchLab/ledger/pkg/ledger"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -17,6 +19,7 @@ const (
 	errorInsufficientFunds        = "insufficient_funds"
 	errorUnknownReservation       = "unknown_reservation"
 	errorUnknownEntry             = "unknown_entry"
+	errorUnknownAccount           = "unknown_account"
 	errorDuplicateIdempotencyKey  = "duplicate_idempotency_key"
 	errorInvalidUserID            = "invalid_user_id"
 	errorInvalidLedgerID          = "invalid_ledger_id"
@@ -40,29 +43,93 @@ const (
 	errorMissingRefundOriginal    = "missing_refund_original"
 	errorInvalidRefundOriginal    = "invalid_refund_original"
 	errorRefundExceedsDebit       = "refund_exceeds_debit"
+	errorInvalidEffectiveAt       = "invalid_effective_at"
+	errorInvalidCursor            = "invalid_cursor"
+	errorInvalidActor             = "invalid_actor"
+	errorMissingGrantReference    = "missing_grant_reference"
+	errorGrantNotCancellable      = "grant_not_cancellable"
+	errorArchivedEntry            = "archived_entry"
+	errorVelocityLimitExceeded    = "velocity_limit_exceeded"
+	errorBalanceLimitExceeded     = "balance_limit_exceeded"
+	errorUnknownLedger            = "unknown_ledger"
+	errorAmountLimitExceeded      = "amount_limit_exceeded"
+	errorExpiringGrantNotAllowed  = "expiring_grant_not_allowed"
+	errorReservationTTLExceeded   = "reservation_ttl_exceeded"
+	errorInvalidScheduleID        = "invalid_schedule_id"
+	errorInvalidScheduleInterval  = "invalid_schedule_interval"
+	errorInvalidScheduleStatus    = "invalid_schedule_status"
+	errorInvalidScheduleWindow    = "invalid_schedule_window"
+	errorInvalidGrantTTL          = "invalid_grant_ttl"
+	errorUnknownSchedule          = "unknown_schedule"
+	errorScheduleExists           = "schedule_exists"
+	errorScheduleClosed           = "schedule_closed"
+	errorGrantSchedulesDisabled   = "grant_schedules_disabled"
+	errorInvalidEventID           = "invalid_event_id"
+	errorInvalidEventStatus       = "invalid_event_status"
+	errorUnknownEvent             = "unknown_event"
+	errorWebhooksDisabled         = "webhooks_disabled"
 
 	defaultListEntriesLimit = 50
 	maxListEntriesLimit     = 200
 	maxBatchOperations      = 5000
+	watchEntriesBatchSize   = maxListEntriesLimit
+
+	defaultWatchPollInterval = time.Second
 )
 
 // CreditServiceServer exposes the credit ledger over gRPC.
 type CreditServiceServer struct {
 	creditv1.UnimplementedCreditServiceServer
-	creditService  *ledger.Service
-	allowedTenants map[string]struct{}
+	creditService     *ledger.Service
+	scheduleService   *schedules.Service
+	webhookService    *webhooks.Service
+	allowedTenants    map[string]struct{}
+	watchPollInterval time.Duration
+}
+
+// ServerOption configures optional CreditServiceServer dependencies.
+type ServerOption func(*CreditServiceServer)
+
+// WithGrantSchedules enables the grant schedule RPCs.
+func WithGrantSchedules(scheduleService *schedules.Service) ServerOption {
+	return func(server *CreditServiceServer) {
+		server.scheduleService = scheduleService
+	}
+}
+
+// WithWebhooks enables the webhook event RPCs.
+func WithWebhooks(webhookService *webhooks.Service) ServerOption {
+	return func(server *CreditServiceServer) {
+		server.webhookService = webhookService
+	}
+}
+
+// WithWatchPollInterval sets how often WatchEntries checks for newly committed entries once a stream has caught up.
+func WithWatchPollInterval(interval time.Duration) ServerOption {
+	return func(server *CreditServiceServer) {
+		if interval > 0 {
+			server.watchPollInterval = interval
+		}
+	}
 }
 
 // NewCreditServiceServer constructs a gRPC server for the ledger service.
-func NewCreditServiceServer(creditService *ledger.Service, allowedTenants []string) *CreditServiceServer {
+func NewCreditServiceServer(creditService *ledger.Service, allowedTenants []string, options ...ServerOption) *CreditServiceServer {
 	tenantsMap := make(map[string]struct{}, len(allowedTenants))
 	for _, id := range allowedTenants {
 		tenantsMap[id] = struct{}{}
 	}
-	return &CreditServiceServer{
-		creditService:  creditService,
-		allowedTenants: tenantsMap,
+	server := &CreditServiceServer{
+		creditService:     creditService,
+		allowedTenants:    tenantsMap,
+		watchPollInterval: defaultWatchPollInterval,
 	}
+	for _, option := range options {
+		if option != nil {
+			option(server)
+		}
+	}
+	return server
 }
 
 func (service *CreditServiceServer) validateTenant(tenantID string) error {
@@ -126,13 +193,61 @@ func (service *CreditServiceServer) Grant(ctx context.Context, request *creditv1
 	if err != nil {
 		return nil, mapToGRPCError(err)
 	}
-	entry, operationError := service.creditService.GrantEntry(ctx, tenantID, userID, ledgerID, amount, idem, request.GetExpiresAtUnixUtc(), metadata)
+	entry, operationError := service.creditService.ScheduleGrantEntry(ctx, tenantID, userID, ledgerID, amount, idem, request.GetEffectiveAtUnixUtc(), request.GetExpiresAtUnixUtc(), metadata)
 	if operationError != nil {
 		return nil, mapToGRPCError(operationError)
 	}
 	return &creditv1.Empty{EntryId: entry.EntryID().String(), CreatedUnixUtc: entry.CreatedUnixUTC()}, nil
 }
 
+func (service *CreditServiceServer) CancelGrant(ctx context.Context, request *creditv1.CancelGrantRequest) (*creditv1.Empty, error) {
+	if err := service.validateTenant(request.GetTenantId()); err != nil {
+		return nil, err
+	}
+	userID, err := ledger.NewUserID(request.GetUserId())
+	if err != nil {
+		return nil, mapToGRPCError(err)
+	}
+	ledgerID, err := ledger.NewLedgerID(request.GetLedgerId())
+	if err != nil {
+		return nil, mapToGRPCError(err)
+	}
+	tenantID, err := ledger.NewTenantID(request.GetTenantId())
+	if err != nil {
+		return nil, mapToGRPCError(err)
+	}
+	metadata, err := ledger.NewMetadataJSON(request.GetMetadataJson())
+	if err != nil {
+		return nil, mapToGRPCError(err)
+	}
+
+	if request.GetGrantEntryId() != "" {
+		grantEntryID, err := ledger.NewEntryID(request.GetGrantEntryId())
+		if err != nil {
+			return nil, mapToGRPCError(err)
+		}
+		entry, operationError := service.creditService.CancelGrantEntry(ctx, tenantID, userID, ledgerID, grantEntryID, metadata)
+		if operationError != nil {
+			return nil, mapToGRPCError(operationError)
+		}
+		return &creditv1.Empty{EntryId: entry.EntryID().String(), CreatedUnixUtc: entry.CreatedUnixUTC()}, nil
+	}
+
+	if request.GetGrantIdempotencyKey() != "" {
+		grantIdempotencyKey, err := ledger.NewIdempotencyKey(request.GetGrantIdempotencyKey())
+		if err != nil {
+			return nil, mapToGRPCError(err)
+		}
+		entry, operationError := service.creditService.CancelGrantByIdempotencyKeyEntry(ctx, tenantID, userID, ledgerID, grantIdempotencyKey, metadata)
+		if operationError != nil {
+			return nil, mapToGRPCError(operationError)
+		}
+		return &creditv1.Empty{EntryId: entry.EntryID().String(), CreatedUnixUtc: entry.CreatedUnixUTC()}, nil
+	}
+
+	return nil, status.Error(codes.InvalidArgument, errorMissingGrantReference)
+}
+
 func (service *CreditServiceServer) Reserve(ctx context.Context, request *creditv1.ReserveRequest) (*creditv1.Empty, error) {
 	if err := service.validateTenant(request.GetTenantId()); err != nil {
 		return nil, err
@@ -337,6 +452,103 @@ func (service *CreditServiceServer) Refund(ctx context.Context, request *creditv
 	return nil, status.Error(codes.InvalidArgument, errorMissingRefundOriginal)
 }
 
+func (service *CreditServiceServer) SpendAcrossLedgers(ctx context.Context, request *creditv1.SpendAcrossLedgersRequest) (*creditv1.SpendAcrossLedgersResponse, error) {
+	if err := service.validateTenant(request.GetTenantId()); err != nil {
+		return nil, err
+	}
+	userID, err := ledger.NewUserID(request.GetUserId())
+	if err != nil {
+		return nil, mapToGRPCError(err)
+	}
+	ledgerIDs, err := parseLedgerIDs(request.GetLedgerIds())
+	if err != nil {
+		return nil, mapToGRPCError(err)
+	}
+	tenantID, err := ledger.NewTenantID(request.GetTenantId())
+	if err != nil {
+		return nil, mapToGRPCError(err)
+	}
+	amount, err := ledger.NewPositiveAmountCents(request.GetAmountCents())
+	if err != nil {
+		return nil, mapToGRPCError(err)
+	}
+	idem, err := ledger.NewIdempotencyKey(request.GetIdempotencyKey())
+	if err != nil {
+		return nil, mapToGRPCError(err)
+	}
+	metadata, err := ledger.NewMetadataJSON(request.GetMetadataJson())
+	if err != nil {
+		return nil, mapToGRPCError(err)
+	}
+	debits, operationError := service.creditService.SpendAcrossLedgers(ctx, tenantID, userID, ledgerIDs, amount, idem, metadata)
+	if operationError != nil {
+		return nil, mapToGRPCError(operationError)
+	}
+	response := &creditv1.SpendAcrossLedgersResponse{Debits: make([]*creditv1.LedgerDebit, 0, len(debits))}
+	for _, debit := range debits {
+		response.Debits = append(response.Debits, &creditv1.LedgerDebit{
+			LedgerId:       debit.LedgerID.String(),
+			EntryId:        debit.Entry.EntryID().String(),
+			AmountCents:    -debit.Entry.AmountCents().Int64(),
+			CreatedUnixUtc: debit.Entry.CreatedUnixUTC(),
+		})
+	}
+	return response, nil
+}
+
+func (service *CreditServiceServer) RefundAcrossLedgers(ctx context.Context, request *creditv1.RefundAcrossLedgersRequest) (*creditv1.RefundAcrossLedgersResponse, error) {
+	if err := service.validateTenant(request.GetTenantId()); err != nil {
+		return nil, err
+	}
+	userID, err := ledger.NewUserID(request.GetUserId())
+	if err != nil {
+		return nil, mapToGRPCError(err)
+	}
+	ledgerIDs, err := parseLedgerIDs(request.GetLedgerIds())
+	if err != nil {
+		return nil, mapToGRPCError(err)
+	}
+	tenantID, err := ledger.NewTenantID(request.GetTenantId())
+	if err != nil {
+		return nil, mapToGRPCError(err)
+	}
+	originalIdempotencyKey, err := ledger.NewIdempotencyKey(request.GetOriginalIdempotencyKey())
+	if err != nil {
+		return nil, mapToGRPCError(err)
+	}
+	amount, err := ledger.NewPositiveAmountCents(request.GetAmountCents())
+	if err != nil {
+		return nil, mapToGRPCError(err)
+	}
+	idem, err := ledger.NewIdempotencyKey(request.GetIdempotencyKey())
+	if err != nil {
+		return nil, mapToGRPCError(err)
+	}
+	metadata, err := ledger.NewMetadataJSON(request.GetMetadataJson())
+	if err != nil {
+		return nil, mapToGRPCError(err)
+	}
+	refunds, operationError := service.creditService.RefundAcrossLedgers(ctx, tenantID, userID, ledgerIDs, originalIdempotencyKey, amount, idem, metadata)
+	if operationError != nil {
+		return nil, mapToGRPCError(operationError)
+	}
+	response := &creditv1.RefundAcrossLedgersResponse{Refunds: make([]*creditv1.LedgerRefund, 0, len(refunds))}
+	for _, refund := range refunds {
+		refundOfEntryIDValue := ""
+		if refundOfEntryID, hasRefundOf := refund.Entry.RefundOfEntryID(); hasRefundOf {
+			refundOfEntryIDValue = refundOfEntryID.String()
+		}
+		response.Refunds = append(response.Refunds, &creditv1.LedgerRefund{
+			LedgerId:        refund.LedgerID.String(),
+			EntryId:         refund.Entry.EntryID().String(),
+			AmountCents:     refund.Entry.AmountCents().Int64(),
+			CreatedUnixUtc:  refund.Entry.CreatedUnixUTC(),
+			RefundOfEntryId: refundOfEntryIDValue,
+		})
+	}
+	return response, nil
+}
+
 func (service *CreditServiceServer) Batch(ctx context.Context, request *creditv1.BatchRequest) (*creditv1.BatchResponse, error) {
 	account := request.GetAccount()
 	if account == nil {
@@ -393,10 +605,11 @@ func (service *CreditServiceServer) Batch(ctx context.Context, request *creditv1
 				return nil, mapToGRPCError(err)
 			}
 			parsedOperation.Grant = &ledger.BatchGrantOperation{
-				Amount:           amount,
-				IdempotencyKey:   idem,
-				ExpiresAtUnixUTC: operationValue.Grant.GetExpiresAtUnixUtc(),
-				Metadata:         metadata,
+				Amount:             amount,
+				IdempotencyKey:     idem,
+				EffectiveAtUnixUTC: operationValue.Grant.GetEffectiveAtUnixUtc(),
+				ExpiresAtUnixUTC:   operationValue.Grant.GetExpiresAtUnixUtc(),
+				Metadata:           metadata,
 			}
 		case *creditv1.BatchOperation_Spend:
 			if operationValue.Spend == nil {
@@ -634,40 +847,140 @@ func (service *CreditServiceServer) ListEntries(ctx context.Context, request *cr
 		idempotencyKeyPrefix = &parsedIdempotencyKey
 	}
 
+	requestActor := request.GetActor()
+	actor, err := ledger.NewActor(requestActor.GetApiKeyId(), requestActor.GetService(), requestActor.GetRequestId(), requestActor.GetClientAddress())
+	if err != nil {
+		return nil, mapToGRPCError(err)
+	}
+
 	entries, operationError := service.creditService.ListEntries(ctx, tenantID, userID, ledgerID, before, int(limit), ledger.ListEntriesFilter{
 		Types:                entryTypes,
 		ReservationID:        reservationID,
 		IdempotencyKeyPrefix: idempotencyKeyPrefix,
+		Actor:                actor,
 	})
 	if operationError != nil {
 		return nil, mapToGRPCError(operationError)
 	}
-	response := &creditv1.ListEntriesResponse{Entries: make([]*creditv1.Entry, 0, len(entries))}
+	archive, operationError := service.creditService.EntryArchive(ctx, tenantID, userID, ledgerID)
+	if operationError != nil {
+		return nil, mapToGRPCError(operationError)
+	}
+	response := &creditv1.ListEntriesResponse{
+		Entries:               make([]*creditv1.Entry, 0, len(entries)),
+		ArchivedBeforeUnixUtc: archive.CutoffUnixUTC,
+	}
 	for _, entryRecord := range entries {
-		reservationIDValue := ""
-		reservationID, hasReservation := entryRecord.ReservationID()
-		if hasReservation {
-			reservationIDValue = reservationID.String()
+		response.Entries = append(response.Entries, service.mapEntry(entryRecord))
+	}
+	return response, nil
+}
+
+// WatchEntries streams a tenant's committed entries in commit order, starting after request.after_cursor.
+// Once the stream has caught up it polls for new entries until the client disconnects.
+func (service *CreditServiceServer) WatchEntries(request *creditv1.WatchEntriesRequest, stream creditv1.CreditService_WatchEntriesServer) error {
+	if err := service.validateTenant(request.GetTenantId()); err != nil {
+		return err
+	}
+	tenantID, err := ledger.NewTenantID(request.GetTenantId())
+	if err != nil {
+		return mapToGRPCError(err)
+	}
+	var filter ledger.EntryChangeFilter
+	if request.GetLedgerId() != "" {
+		ledgerID, err := ledger.NewLedgerID(request.GetLedgerId())
+		if err != nil {
+			return mapToGRPCError(err)
 		}
-		refundOfEntryIDValue := ""
-		refundOfEntryID, hasRefundOf := entryRecord.RefundOfEntryID()
-		if hasRefundOf {
-			refundOfEntryIDValue = refundOfEntryID.String()
+		filter.LedgerID = &ledgerID
+	}
+	if request.GetUserId() != "" {
+		userID, err := ledger.NewUserID(request.GetUserId())
+		if err != nil {
+			return mapToGRPCError(err)
+		}
+		filter.UserID = &userID
+	}
+	for _, entryTypeValue := range request.GetTypes() {
+		parsedEntryType, err := ledger.ParseEntryType(entryTypeValue)
+		if err != nil {
+			return status.Error(codes.InvalidArgument, errorInvalidEntryType)
+		}
+		filter.Types = append(filter.Types, parsedEntryType)
+	}
+
+	ctx := stream.Context()
+	cursor := request.GetAfterCursor()
+	ticker := time.NewTicker(service.watchPollInterval)
+	defer ticker.Stop()
+	for {
+		changes, operationError := service.creditService.ListEntryChanges(ctx, tenantID, cursor, watchEntriesBatchSize, filter)
+		if operationError != nil {
+			if ctx.Err() != nil {
+				return status.FromContextError(ctx.Err()).Err()
+			}
+			return mapToGRPCError(operationError)
+		}
+		for _, change := range changes {
+			if err := stream.Send(&creditv1.EntryChange{
+				Cursor:   change.Cursor,
+				UserId:   change.UserID.String(),
+				LedgerId: change.LedgerID.String(),
+				Entry:    service.mapEntry(change.Entry),
+			}); err != nil {
+				return err
+			}
+			cursor = change.Cursor
+		}
+		if len(changes) == watchEntriesBatchSize {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		case <-ticker.C:
 		}
-		response.Entries = append(response.Entries, &creditv1.Entry{
-			EntryId:          entryRecord.EntryID().String(),
-			AccountId:        entryRecord.AccountID().String(),
-			Type:             entryRecord.Type().String(),
-			AmountCents:      entryRecord.AmountCents().Int64(),
-			ReservationId:    reservationIDValue,
-			IdempotencyKey:   entryRecord.IdempotencyKey().String(),
-			ExpiresAtUnixUtc: entryRecord.ExpiresAtUnixUTC(),
-			MetadataJson:     entryRecord.MetadataJSON().String(),
-			CreatedUnixUtc:   entryRecord.CreatedUnixUTC(),
-			RefundOfEntryId:  refundOfEntryIDValue,
-		})
 	}
-	return response, nil
+}
+
+func (service *CreditServiceServer) mapEntry(entryRecord ledger.Entry) *creditv1.Entry {
+	reservationIDValue := ""
+	reservationID, hasReservation := entryRecord.ReservationID()
+	if hasReservation {
+		reservationIDValue = reservationID.String()
+	}
+	refundOfEntryIDValue := ""
+	refundOfEntryID, hasRefundOf := entryRecord.RefundOfEntryID()
+	if hasRefundOf {
+		refundOfEntryIDValue = refundOfEntryID.String()
+	}
+	return &creditv1.Entry{
+		EntryId:            entryRecord.EntryID().String(),
+		AccountId:          entryRecord.AccountID().String(),
+		Type:               entryRecord.Type().String(),
+		AmountCents:        entryRecord.AmountCents().Int64(),
+		ReservationId:      reservationIDValue,
+		IdempotencyKey:     entryRecord.IdempotencyKey().String(),
+		ExpiresAtUnixUtc:   entryRecord.ExpiresAtUnixUTC(),
+		MetadataJson:       entryRecord.MetadataJSON().String(),
+		CreatedUnixUtc:     entryRecord.CreatedUnixUTC(),
+		RefundOfEntryId:    refundOfEntryIDValue,
+		EffectiveAtUnixUtc: entryRecord.EffectiveAtUnixUTC(),
+		Pending:            service.creditService.IsEntryPending(entryRecord),
+		Actor:              mapActor(entryRecord.Actor()),
+	}
+}
+
+func mapActor(actor ledger.Actor) *creditv1.Actor {
+	if actor.IsZero() {
+		return nil
+	}
+	return &creditv1.Actor{
+		ApiKeyId:      actor.APIKeyID(),
+		Service:       actor.Service(),
+		RequestId:     actor.RequestID(),
+		ClientAddress: actor.ClientAddress(),
+	}
 }
 
 func (service *CreditServiceServer) GetReservation(ctx context.Context, request *creditv1.GetReservationRequest) (*creditv1.GetReservationResponse, error) {
@@ -756,6 +1069,408 @@ func mapReservationState(state ledger.ReservationState) *creditv1.Reservation {
 	}
 }
 
+func (service *CreditServiceServer) CreateGrantSchedule(ctx context.Context, request *creditv1.CreateGrantScheduleRequest) (*creditv1.CreateGrantScheduleResponse, error) {
+	if err := service.validateTenant(request.GetTenantId()); err != nil {
+		return nil, err
+	}
+	if service.scheduleService == nil {
+		return nil, status.Error(codes.Unimplemented, errorGrantSchedulesDisabled)
+	}
+	userID, err := ledger.NewUserID(request.GetUserId())
+	if err != nil {
+		return nil, mapToGRPCError(err)
+	}
+	ledgerID, err := ledger.NewLedgerID(request.GetLedgerId())
+	if err != nil {
+		return nil, mapToGRPCError(err)
+	}
+	tenantID, err := ledger.NewTenantID(request.GetTenantId())
+	if err != nil {
+		return nil, mapToGRPCError(err)
+	}
+	scheduleID, err := schedules.NewScheduleID(request.GetScheduleId())
+	if err != nil {
+		return nil, mapToGRPCError(err)
+	}
+	amount, err := ledger.NewPositiveAmountCents(request.GetAmountCents())
+	if err != nil {
+		return nil, mapToGRPCError(err)
+	}
+	interval, err := schedules.ParseInterval(request.GetInterval())
+	if err != nil {
+		return nil, mapToGRPCError(err)
+	}
+	metadata, err := ledger.NewMetadataJSON(request.GetMetadataJson())
+	if err != nil {
+		return nil, mapToGRPCError(err)
+	}
+	schedule, operationError := service.scheduleService.Create(ctx, schedules.CreateRequest{
+		ScheduleID:      scheduleID,
+		TenantID:        tenantID,
+		UserID:          userID,
+		LedgerID:        ledgerID,
+		Amount:          amount,
+		Interval:        interval,
+		StartUnixUTC:    request.GetStartUnixUtc(),
+		EndUnixUTC:      request.GetEndUnixUtc(),
+		GrantTTLSeconds: request.GetGrantTtlSeconds(),
+		Metadata:        metadata,
+	})
+	if operationError != nil {
+		return nil, mapToGRPCError(operationError)
+	}
+	return &creditv1.CreateGrantScheduleResponse{Schedule: mapGrantSchedule(schedule)}, nil
+}
+
+func (service *CreditServiceServer) ListGrantSchedules(ctx context.Context, request *creditv1.ListGrantSchedulesRequest) (*creditv1.ListGrantSchedulesResponse, error) {
+	if err := service.validateTenant(request.GetTenantId()); err != nil {
+		return nil, err
+	}
+	if service.scheduleService == nil {
+		return nil, status.Error(codes.Unimplemented, errorGrantSchedulesDisabled)
+	}
+	tenantID, err := ledger.NewTenantID(request.GetTenantId())
+	if err != nil {
+		return nil, mapToGRPCError(err)
+	}
+	limit, err := normalizeListLimit(request.GetLimit())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, errorInvalidListLimit)
+	}
+
+	filter := schedules.ListFilter{}
+	if request.GetUserId() != "" {
+		userID, err := ledger.NewUserID(request.GetUserId())
+		if err != nil {
+			return nil, mapToGRPCError(err)
+		}
+		filter.UserID = &userID
+	}
+	if request.GetLedgerId() != "" {
+		ledgerID, err := ledger.NewLedgerID(request.GetLedgerId())
+		if err != nil {
+			return nil, mapToGRPCError(err)
+		}
+		filter.LedgerID = &ledgerID
+	}
+	for _, rawStatus := range request.GetStatuses() {
+		parsedStatus, err := schedules.ParseStatus(rawStatus)
+		if err != nil {
+			return nil, mapToGRPCError(err)
+		}
+		filter.Statuses = append(filter.Statuses, parsedStatus)
+	}
+
+	scheduleList, operationError := service.scheduleService.List(ctx, tenantID, request.GetBeforeCreatedUnixUtc(), int(limit), filter)
+	if operationError != nil {
+		return nil, mapToGRPCError(operationError)
+	}
+	response := &creditv1.ListGrantSchedulesResponse{Schedules: make([]*creditv1.GrantSchedule, 0, len(scheduleList))}
+	for _, schedule := range scheduleList {
+		response.Schedules = append(response.Schedules, mapGrantSchedule(schedule))
+	}
+	return response, nil
+}
+
+func (service *CreditServiceServer) CancelGrantSchedule(ctx context.Context, request *creditv1.CancelGrantScheduleRequest) (*creditv1.CancelGrantScheduleResponse, error) {
+	if err := service.validateTenant(request.GetTenantId()); err != nil {
+		return nil, err
+	}
+	if service.scheduleService == nil {
+		return nil, status.Error(codes.Unimplemented, errorGrantSchedulesDisabled)
+	}
+	tenantID, err := ledger.NewTenantID(request.GetTenantId())
+	if err != nil {
+		return nil, mapToGRPCError(err)
+	}
+	scheduleID, err := schedules.NewScheduleID(request.GetScheduleId())
+	if err != nil {
+		return nil, mapToGRPCError(err)
+	}
+	schedule, operationError := service.scheduleService.Cancel(ctx, tenantID, scheduleID)
+	if operationError != nil {
+		return nil, mapToGRPCError(operationError)
+	}
+	return &creditv1.CancelGrantScheduleResponse{Schedule: mapGrantSchedule(schedule)}, nil
+}
+
+func (service *CreditServiceServer) ListWebhookEvents(ctx context.Context, request *creditv1.ListWebhookEventsRequest) (*creditv1.ListWebhookEventsResponse, error) {
+	if err := service.validateTenant(request.GetTenantId()); err != nil {
+		return nil, err
+	}
+	if service.webhookService == nil {
+		return nil, status.Error(codes.Unimplemented, errorWebhooksDisabled)
+	}
+	tenantID, err := ledger.NewTenantID(request.GetTenantId())
+	if err != nil {
+		return nil, mapToGRPCError(err)
+	}
+	limit, err := normalizeListLimit(request.GetLimit())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, errorInvalidListLimit)
+	}
+	filter := webhooks.ListFilter{}
+	for _, rawStatus := range request.GetStatuses() {
+		parsedStatus, err := webhooks.ParseStatus(rawStatus)
+		if err != nil {
+			return nil, mapToGRPCError(err)
+		}
+		filter.Statuses = append(filter.Statuses, parsedStatus)
+	}
+
+	events, operationError := service.webhookService.List(ctx, tenantID, request.GetBeforeCreatedUnixUtc(), int(limit), filter)
+	if operationError != nil {
+		return nil, mapToGRPCError(operationError)
+	}
+	response := &creditv1.ListWebhookEventsResponse{Events: make([]*creditv1.WebhookEvent, 0, len(events))}
+	for _, event := range events {
+		response.Events = append(response.Events, mapWebhookEvent(event))
+	}
+	return response, nil
+}
+
+func (service *CreditServiceServer) ReplayWebhookEvent(ctx context.Context, request *creditv1.ReplayWebhookEventRequest) (*creditv1.ReplayWebhookEventResponse, error) {
+	if err := service.validateTenant(request.GetTenantId()); err != nil {
+		return nil, err
+	}
+	if service.webhookService == nil {
+		return nil, status.Error(codes.Unimplemented, errorWebhooksDisabled)
+	}
+	tenantID, err := ledger.NewTenantID(request.GetTenantId())
+	if err != nil {
+		return nil, mapToGRPCError(err)
+	}
+	eventID, err := webhooks.NewEventID(request.GetEventId())
+	if err != nil {
+		return nil, mapToGRPCError(err)
+	}
+	event, operationError := service.webhookService.Replay(ctx, tenantID, eventID)
+	if operationError != nil {
+		return nil, mapToGRPCError(operationError)
+	}
+	return &creditv1.ReplayWebhookEventResponse{Event: mapWebhookEvent(event)}, nil
+}
+
+func (service *CreditServiceServer) ListAccounts(ctx context.Context, request *creditv1.ListAccountsRequest) (*creditv1.ListAccountsResponse, error) {
+	if err := service.validateTenant(request.GetTenantId()); err != nil {
+		return nil, err
+	}
+	tenantID, err := ledger.NewTenantID(request.GetTenantId())
+	if err != nil {
+		return nil, mapToGRPCError(err)
+	}
+	limit, err := normalizeListLimit(request.GetLimit())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, errorInvalidListLimit)
+	}
+
+	filter := ledger.ListAccountsFilter{
+		UserIDPrefix:        strings.TrimSpace(request.GetUserIdPrefix()),
+		CreatedAfterUnixUTC: request.GetCreatedAfterUnixUtc(),
+		NonZeroBalance:      request.GetNonZeroBalance(),
+	}
+	if request.GetLedgerId() != "" {
+		ledgerID, err := ledger.NewLedgerID(request.GetLedgerId())
+		if err != nil {
+			return nil, mapToGRPCError(err)
+		}
+		filter.LedgerID = &ledgerID
+	}
+
+	accounts, operationError := service.creditService.ListAccounts(ctx, tenantID, request.GetBeforeCreatedUnixUtc(), int(limit), filter)
+	if operationError != nil {
+		return nil, mapToGRPCError(operationError)
+	}
+	response := &creditv1.ListAccountsResponse{Accounts: make([]*creditv1.Account, 0, len(accounts))}
+	for _, account := range accounts {
+		response.Accounts = append(response.Accounts, &creditv1.Account{
+			AccountId:      account.AccountID().String(),
+			UserId:         account.UserID().String(),
+			LedgerId:       account.LedgerID().String(),
+			CreatedUnixUtc: account.CreatedUnixUTC(),
+		})
+	}
+	return response, nil
+}
+
+func (service *CreditServiceServer) GetTrialBalance(ctx context.Context, request *creditv1.GetTrialBalanceRequest) (*creditv1.GetTrialBalanceResponse, error) {
+	if err := service.validateTenant(request.GetTenantId()); err != nil {
+		return nil, err
+	}
+	tenantID, err := ledger.NewTenantID(request.GetTenantId())
+	if err != nil {
+		return nil, mapToGRPCError(err)
+	}
+	trialBalance, operationError := service.creditService.TrialBalance(ctx, tenantID, request.GetAsOfUnixUtc())
+	if operationError != nil {
+		return nil, mapToGRPCError(operationError)
+	}
+	response := &creditv1.GetTrialBalanceResponse{
+		AsOfUnixUtc: trialBalance.AsOfUnixUTC,
+		Lines:       make([]*creditv1.TrialBalanceLine, 0, len(trialBalance.Lines)),
+		TotalCents:  trialBalance.TotalCents.Int64(),
+	}
+	for _, line := range trialBalance.Lines {
+		response.Lines = append(response.Lines, &creditv1.TrialBalanceLine{
+			LedgerId:     line.LedgerID.String(),
+			Account:      line.Account.String(),
+			BalanceCents: line.BalanceCents.Int64(),
+		})
+	}
+	return response, nil
+}
+
+func (service *CreditServiceServer) GetLiabilityReport(ctx context.Context, request *creditv1.GetLiabilityReportRequest) (*creditv1.GetLiabilityReportResponse, error) {
+	if err := service.validateTenant(request.GetTenantId()); err != nil {
+		return nil, err
+	}
+	tenantID, err := ledger.NewTenantID(request.GetTenantId())
+	if err != nil {
+		return nil, mapToGRPCError(err)
+	}
+	report, operationError := service.creditService.LiabilityReport(ctx, tenantID, request.GetAsOfUnixUtc())
+	if operationError != nil {
+		return nil, mapToGRPCError(operationError)
+	}
+	return &creditv1.GetLiabilityReportResponse{
+		AsOfUnixUtc: report.AsOfUnixUTC,
+		Outstanding: mapLiabilityBuckets(report.Outstanding),
+		Breakage:    mapLiabilityBuckets(report.Breakage),
+	}, nil
+}
+
+func (service *CreditServiceServer) VerifyChain(ctx context.Context, request *creditv1.VerifyChainRequest) (*creditv1.VerifyChainResponse, error) {
+	if err := service.validateTenant(request.GetTenantId()); err != nil {
+		return nil, err
+	}
+	tenantID, err := ledger.NewTenantID(request.GetTenantId())
+	if err != nil {
+		return nil, mapToGRPCError(err)
+	}
+	userID, err := ledger.NewUserID(request.GetUserId())
+	if err != nil {
+		return nil, mapToGRPCError(err)
+	}
+	ledgerID, err := ledger.NewLedgerID(request.GetLedgerId())
+	if err != nil {
+		return nil, mapToGRPCError(err)
+	}
+	verification, operationError := service.creditService.VerifyChain(ctx, tenantID, userID, ledgerID)
+	if operationError != nil {
+		return nil, mapToGRPCError(operationError)
+	}
+	response := &creditv1.VerifyChainResponse{
+		Valid:                   verification.Valid(),
+		HeadSequence:            verification.HeadSequence,
+		CheckedEntries:          verification.CheckedEntries,
+		ArchivedThroughSequence: verification.ArchivedThroughSequence,
+	}
+	if verification.Break != nil {
+		response.Break = &creditv1.ChainBreak{
+			Sequence: verification.Break.Sequence,
+			EntryId:  verification.Break.EntryID.String(),
+			Reason:   verification.Break.Reason.String(),
+		}
+	}
+	return response, nil
+}
+
+func (service *CreditServiceServer) VerifyAccount(ctx context.Context, request *creditv1.VerifyAccountRequest) (*creditv1.VerifyAccountResponse, error) {
+	if err := service.validateTenant(request.GetTenantId()); err != nil {
+		return nil, err
+	}
+	tenantID, err := ledger.NewTenantID(request.GetTenantId())
+	if err != nil {
+		return nil, mapToGRPCError(err)
+	}
+	userID, err := ledger.NewUserID(request.GetUserId())
+	if err != nil {
+		return nil, mapToGRPCError(err)
+	}
+	ledgerID, err := ledger.NewLedgerID(request.GetLedgerId())
+	if err != nil {
+		return nil, mapToGRPCError(err)
+	}
+	verification, operationError := service.creditService.VerifyAccount(ctx, tenantID, userID, ledgerID)
+	if operationError != nil {
+		return nil, mapToGRPCError(operationError)
+	}
+	response := &creditv1.VerifyAccountResponse{
+		Valid:               verification.Valid(),
+		CheckedUnixUtc:      verification.CheckedUnixUTC,
+		CheckedEntries:      int32(verification.CheckedEntries),
+		CheckedReservations: int32(verification.CheckedReservations),
+		Violations:          make([]*creditv1.InvariantViolation, 0, len(verification.Violations)),
+	}
+	for _, violation := range verification.Violations {
+		response.Violations = append(response.Violations, &creditv1.InvariantViolation{
+			Check:         violation.Check.String(),
+			EntryId:       violation.EntryID.String(),
+			ReservationId: violation.ReservationID.String(),
+			ExpectedCents: violation.ExpectedCents,
+			ActualCents:   violation.ActualCents,
+			Message:       violation.Message,
+		})
+	}
+	return response, nil
+}
+
+func mapLiabilityBuckets(buckets []ledger.LiabilityBucket) []*creditv1.LiabilityBucket {
+	mapped := make([]*creditv1.LiabilityBucket, 0, len(buckets))
+	for _, bucket := range buckets {
+		mapped = append(mapped, &creditv1.LiabilityBucket{
+			LedgerId:    bucket.LedgerID.String(),
+			ExpiryMonth: bucket.ExpiryMonth,
+			AmountCents: bucket.AmountCents.Int64(),
+		})
+	}
+	return mapped
+}
+
+func mapWebhookEvent(event webhooks.Event) *creditv1.WebhookEvent {
+	return &creditv1.WebhookEvent{
+		EventId:            event.EventID.String(),
+		EventType:          event.EventType,
+		Status:             event.Status.String(),
+		Attempts:           int32(event.Attempts),
+		NextAttemptUnixUtc: event.NextAttemptUnixUTC,
+		LastError:          event.LastError,
+		CreatedUnixUtc:     event.CreatedUnixUTC,
+		DeliveredUnixUtc:   event.DeliveredUnixUTC,
+		PayloadJson:        event.PayloadJSON,
+	}
+}
+
+func mapGrantSchedule(schedule schedules.Schedule) *creditv1.GrantSchedule {
+	return &creditv1.GrantSchedule{
+		ScheduleId:      schedule.ScheduleID.String(),
+		UserId:          schedule.UserID.String(),
+		LedgerId:        schedule.LedgerID.String(),
+		AmountCents:     schedule.Amount.Int64(),
+		Interval:        schedule.Interval.String(),
+		StartUnixUtc:    schedule.StartUnixUTC,
+		EndUnixUtc:      schedule.EndUnixUTC,
+		GrantTtlSeconds: schedule.GrantTTLSeconds,
+		MetadataJson:    schedule.Metadata.String(),
+		Status:          schedule.Status.String(),
+		NextRunUnixUtc:  schedule.NextRunUnixUTC,
+		CreatedUnixUtc:  schedule.CreatedUnixUTC,
+		UpdatedUnixUtc:  schedule.UpdatedUnixUTC,
+	}
+}
+
+func parseLedgerIDs(rawLedgerIDs []string) ([]ledger.LedgerID, error) {
+	ledgerIDs := make([]ledger.LedgerID, 0, len(rawLedgerIDs))
+	for _, rawLedgerID := range rawLedgerIDs {
+		ledgerID, err := ledger.NewLedgerID(rawLedgerID)
+		if err != nil {
+			return nil, err
+		}
+		ledgerIDs = append(ledgerIDs, ledgerID)
+	}
+	return ledgerIDs, nil
+}
+
 func normalizeListLimit(limit int32) (int32, error) {
 	if limit <= 0 {
 		return defaultListEntriesLimit, nil
@@ -806,6 +1521,9 @@ func mapToBatchErrorCode(source error) string {
 	if errors.Is(source, ledger.ErrUnknownEntry) {
 		return errorUnknownEntry
 	}
+	if errors.Is(source, ledger.ErrUnknownAccount) {
+		return errorUnknownAccount
+	}
 	if errors.Is(source, ledger.ErrDuplicateIdempotencyKey) {
 		return errorDuplicateIdempotencyKey
 	}
@@ -824,6 +1542,36 @@ func mapToBatchErrorCode(source error) string {
 	if errors.Is(source, ledger.ErrRefundExceedsDebit) {
 		return errorRefundExceedsDebit
 	}
+	if errors.Is(source, ledger.ErrInvalidEffectiveAt) {
+		return errorInvalidEffectiveAt
+	}
+	if errors.Is(source, ledger.ErrInvalidCursor) {
+		return errorInvalidCursor
+	}
+	if errors.Is(source, ledger.ErrGrantNotCancellable) {
+		return errorGrantNotCancellable
+	}
+	if errors.Is(source, ledger.ErrArchivedEntry) {
+		return errorArchivedEntry
+	}
+	if errors.Is(source, ledger.ErrVelocityLimitExceeded) {
+		return errorVelocityLimitExceeded
+	}
+	if errors.Is(source, ledger.ErrBalanceLimitExceeded) {
+		return errorBalanceLimitExceeded
+	}
+	if errors.Is(source, ledger.ErrUnknownLedger) {
+		return errorUnknownLedger
+	}
+	if errors.Is(source, ledger.ErrAmountLimitExceeded) {
+		return errorAmountLimitExceeded
+	}
+	if errors.Is(source, ledger.ErrExpiringGrantNotAllowed) {
+		return errorExpiringGrantNotAllowed
+	}
+	if errors.Is(source, ledger.ErrReservationTTLExceeded) {
+		return errorReservationTTLExceeded
+	}
 
 	var operationError ledger.OperationError
 	if errors.As(source, &operationError) {
@@ -872,6 +1620,9 @@ func mapToGRPCError(source error) error {
 	if errors.Is(source, ledger.ErrUnknownEntry) {
 		return status.Error(codes.NotFound, errorUnknownEntry)
 	}
+	if errors.Is(source, ledger.ErrUnknownAccount) {
+		return status.Error(codes.NotFound, errorUnknownAccount)
+	}
 	if errors.Is(source, ledger.ErrDuplicateIdempotencyKey) {
 		return status.Error(codes.AlreadyExists, errorDuplicateIdempotencyKey)
 	}
@@ -890,5 +1641,71 @@ func mapToGRPCError(source error) error {
 	if errors.Is(source, ledger.ErrRefundExceedsDebit) {
 		return status.Error(codes.FailedPrecondition, errorRefundExceedsDebit)
 	}
+	if errors.Is(source, ledger.ErrInvalidEffectiveAt) {
+		return status.Error(codes.InvalidArgument, errorInvalidEffectiveAt)
+	}
+	if errors.Is(source, ledger.ErrInvalidCursor) {
+		return status.Error(codes.InvalidArgument, errorInvalidCursor)
+	}
+	if errors.Is(source, ledger.ErrInvalidActor) {
+		return status.Error(codes.InvalidArgument, errorInvalidActor)
+	}
+	if errors.Is(source, ledger.ErrGrantNotCancellable) {
+		return status.Error(codes.FailedPrecondition, errorGrantNotCancellable)
+	}
+	if errors.Is(source, ledger.ErrArchivedEntry) {
+		return status.Error(codes.FailedPrecondition, errorArchivedEntry)
+	}
+	if errors.Is(source, ledger.ErrVelocityLimitExceeded) {
+		return status.Error(codes.ResourceExhausted, errorVelocityLimitExceeded)
+	}
+	if errors.Is(source, ledger.ErrBalanceLimitExceeded) {
+		return status.Error(codes.FailedPrecondition, errorBalanceLimitExceeded)
+	}
+	if errors.Is(source, ledger.ErrUnknownLedger) {
+		return status.Error(codes.NotFound, errorUnknownLedger)
+	}
+	if errors.Is(source, ledger.ErrAmountLimitExceeded) {
+		return status.Error(codes.InvalidArgument, errorAmountLimitExceeded)
+	}
+	if errors.Is(source, ledger.ErrExpiringGrantNotAllowed) {
+		return status.Error(codes.FailedPrecondition, errorExpiringGrantNotAllowed)
+	}
+	if errors.Is(source, ledger.ErrReservationTTLExceeded) {
+		return status.Error(codes.InvalidArgument, errorReservationTTLExceeded)
+	}
+	if errors.Is(source, schedules.ErrInvalidScheduleID) {
+		return status.Error(codes.InvalidArgument, errorInvalidScheduleID)
+	}
+	if errors.Is(source, schedules.ErrInvalidInterval) {
+		return status.Error(codes.InvalidArgument, errorInvalidScheduleInterval)
+	}
+	if errors.Is(source, schedules.ErrInvalidScheduleStatus) {
+		return status.Error(codes.InvalidArgument, errorInvalidScheduleStatus)
+	}
+	if errors.Is(source, schedules.ErrInvalidScheduleWindow) {
+		return status.Error(codes.InvalidArgument, errorInvalidScheduleWindow)
+	}
+	if errors.Is(source, schedules.ErrInvalidGrantTTL) {
+		return status.Error(codes.InvalidArgument, errorInvalidGrantTTL)
+	}
+	if errors.Is(source, schedules.ErrUnknownSchedule) {
+		return status.Error(codes.NotFound, errorUnknownSchedule)
+	}
+	if errors.Is(source, schedules.ErrScheduleExists) {
+		return status.Error(codes.AlreadyExists, errorScheduleExists)
+	}
+	if errors.Is(source, schedules.ErrScheduleClosed) {
+		return status.Error(codes.FailedPrecondition, errorScheduleClosed)
+	}
+	if errors.Is(source, webhooks.ErrInvalidEventID) {
+		return status.Error(codes.InvalidArgument, errorInvalidEventID)
+	}
+	if errors.Is(source, webhooks.ErrInvalidEventStatus) {
+		return status.Error(codes.InvalidArgument, errorInvalidEventStatus)
+	}
+	if errors.Is(source, webhooks.ErrUnknownEvent) {
+		return status.Error(codes.NotFound, errorUnknownEvent)
+	}
 	return status.Error(codes.Internal, source.Error())
 }
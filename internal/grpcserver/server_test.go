@@ -4,13 +4,23 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/MarkoPoloResearchLab/ledger/api/credit/v1"
-	"github.com/MarkoPoloResearchLab/ledger/internal/store/gormstore"
+	"github.com/MarkoPoloResearchLab/ledger/internal/gormadapters"
+	"github.com/MarkoPoloResearchLab/ledger/internal/schedules"
+	"github.com/MarkoPoloResearchLab/ledger/internal/webhooks"
 	"github.com/MarkoPoloResearchLab/ledger/pkg/ledger"
+	"github.com/MarkoPoloResearchLab/ledger/pkg/ledger/gormstore"
 	"github.com/glebarez/sqlite"
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 	"gorm.io/gorm"
@@ -71,11 +81,26 @@ func TestMapToGRPCError(test *testing.T) {
 		{name: "insufficient funds", input: ledger.ErrInsufficientFunds, wantCode: codes.FailedPrecondition, wantMessage: errorInsufficientFunds},
 		{name: "unknown reservation", input: ledger.ErrUnknownReservation, wantCode: codes.NotFound, wantMessage: errorUnknownReservation},
 		{name: "unknown entry", input: ledger.ErrUnknownEntry, wantCode: codes.NotFound, wantMessage: errorUnknownEntry},
+		{name: "unknown account", input: ledger.ErrUnknownAccount, wantCode: codes.NotFound, wantMessage: errorUnknownAccount},
+		{name: "invalid cursor", input: ledger.ErrInvalidCursor, wantCode: codes.InvalidArgument, wantMessage: errorInvalidCursor},
+		{name: "invalid effective at", input: ledger.ErrInvalidEffectiveAt, wantCode: codes.InvalidArgument, wantMessage: errorInvalidEffectiveAt},
+		{name: "grant not cancellable", input: ledger.ErrGrantNotCancellable, wantCode: codes.FailedPrecondition, wantMessage: errorGrantNotCancellable},
+		{name: "archived entry", input: ledger.ErrArchivedEntry, wantCode: codes.FailedPrecondition, wantMessage: errorArchivedEntry},
+		{name: "invalid actor", input: ledger.ErrInvalidActor, wantCode: codes.InvalidArgument, wantMessage: errorInvalidActor},
 		{name: "duplicate idempotency", input: ledger.ErrDuplicateIdempotencyKey, wantCode: codes.AlreadyExists, wantMessage: errorDuplicateIdempotencyKey},
 		{name: "reservation exists", input: ledger.ErrReservationExists, wantCode: codes.AlreadyExists, wantMessage: errorReservationExists},
 		{name: "reservation closed", input: ledger.ErrReservationClosed, wantCode: codes.FailedPrecondition, wantMessage: errorReservationClosed},
 		{name: "invalid refund original", input: ledger.ErrInvalidRefundOriginal, wantCode: codes.FailedPrecondition, wantMessage: errorInvalidRefundOriginal},
 		{name: "refund exceeds debit", input: ledger.ErrRefundExceedsDebit, wantCode: codes.FailedPrecondition, wantMessage: errorRefundExceedsDebit},
+		{name: "velocity limit exceeded", input: ledger.ErrVelocityLimitExceeded, wantCode: codes.ResourceExhausted, wantMessage: errorVelocityLimitExceeded},
+		{name: "balance limit exceeded", input: ledger.ErrBalanceLimitExceeded, wantCode: codes.FailedPrecondition, wantMessage: errorBalanceLimitExceeded},
+		{name: "unknown ledger", input: ledger.ErrUnknownLedger, wantCode: codes.NotFound, wantMessage: errorUnknownLedger},
+		{name: "amount limit exceeded", input: ledger.ErrAmountLimitExceeded, wantCode: codes.InvalidArgument, wantMessage: errorAmountLimitExceeded},
+		{name: "expiring grant not allowed", input: ledger.ErrExpiringGrantNotAllowed, wantCode: codes.FailedPrecondition, wantMessage: errorExpiringGrantNotAllowed},
+		{name: "reservation ttl exceeded", input: ledger.ErrReservationTTLExceeded, wantCode: codes.InvalidArgument, wantMessage: errorReservationTTLExceeded},
+		{name: "invalid event id", input: webhooks.ErrInvalidEventID, wantCode: codes.InvalidArgument, wantMessage: errorInvalidEventID},
+		{name: "invalid event status", input: webhooks.ErrInvalidEventStatus, wantCode: codes.InvalidArgument, wantMessage: errorInvalidEventStatus},
+		{name: "unknown event", input: webhooks.ErrUnknownEvent, wantCode: codes.NotFound, wantMessage: errorUnknownEvent},
 		{name: "fallback", input: errors.New("boom"), wantCode: codes.Internal, wantMessage: "boom"},
 	}
 	for _, testCase := range testCases {
@@ -117,11 +142,22 @@ func TestMapToBatchErrorCode(test *testing.T) {
 		{name: "insufficient funds", input: ledger.ErrInsufficientFunds, wantCode: errorInsufficientFunds},
 		{name: "unknown reservation", input: ledger.ErrUnknownReservation, wantCode: errorUnknownReservation},
 		{name: "unknown entry", input: ledger.ErrUnknownEntry, wantCode: errorUnknownEntry},
+		{name: "unknown account", input: ledger.ErrUnknownAccount, wantCode: errorUnknownAccount},
+		{name: "invalid cursor", input: ledger.ErrInvalidCursor, wantCode: errorInvalidCursor},
+		{name: "invalid effective at", input: ledger.ErrInvalidEffectiveAt, wantCode: errorInvalidEffectiveAt},
+		{name: "grant not cancellable", input: ledger.ErrGrantNotCancellable, wantCode: errorGrantNotCancellable},
+		{name: "archived entry", input: ledger.ErrArchivedEntry, wantCode: errorArchivedEntry},
 		{name: "duplicate idempotency", input: ledger.ErrDuplicateIdempotencyKey, wantCode: errorDuplicateIdempotencyKey},
 		{name: "reservation exists", input: ledger.ErrReservationExists, wantCode: errorReservationExists},
 		{name: "reservation closed", input: ledger.ErrReservationClosed, wantCode: errorReservationClosed},
 		{name: "invalid refund original", input: ledger.ErrInvalidRefundOriginal, wantCode: errorInvalidRefundOriginal},
 		{name: "refund exceeds debit", input: ledger.ErrRefundExceedsDebit, wantCode: errorRefundExceedsDebit},
+		{name: "velocity limit exceeded", input: ledger.ErrVelocityLimitExceeded, wantCode: errorVelocityLimitExceeded},
+		{name: "balance limit exceeded", input: ledger.ErrBalanceLimitExceeded, wantCode: errorBalanceLimitExceeded},
+		{name: "unknown ledger", input: ledger.ErrUnknownLedger, wantCode: errorUnknownLedger},
+		{name: "amount limit exceeded", input: ledger.ErrAmountLimitExceeded, wantCode: errorAmountLimitExceeded},
+		{name: "expiring grant not allowed", input: ledger.ErrExpiringGrantNotAllowed, wantCode: errorExpiringGrantNotAllowed},
+		{name: "reservation ttl exceeded", input: ledger.ErrReservationTTLExceeded, wantCode: errorReservationTTLExceeded},
 		{name: "operation error", input: ledger.WrapError("store", "entry", "insert", errors.New("boom")), wantCode: "store.entry.insert"},
 		{name: "fallback", input: errors.New("boom"), wantCode: errorInternal},
 	}
@@ -158,6 +194,399 @@ func TestCreditServiceServerTenantValidation(test *testing.T) {
 	}
 }
 
+func TestListAccountsDiscoversTenantAccounts(test *testing.T) {
+	test.Parallel()
+	creditService, err := newSQLiteLedgerService(test)
+	if err != nil {
+		test.Fatalf("new ledger service: %v", err)
+	}
+	server := NewCreditServiceServer(creditService, []string{"default"})
+	ctx := context.Background()
+
+	balance, err := server.GetBalance(ctx, &creditv1.BalanceRequest{UserId: "typo", TenantId: "default", LedgerId: "default"})
+	if err != nil {
+		test.Fatalf("get balance: %v", err)
+	}
+	if balance.GetTotalCents() != 0 || balance.GetAvailableCents() != 0 {
+		test.Fatalf("expected zero balance, got %+v", balance)
+	}
+	if _, err := server.Grant(ctx, &creditv1.GrantRequest{UserId: "alice", TenantId: "default", LedgerId: "default", AmountCents: 100, IdempotencyKey: "grant-1", MetadataJson: "{}"}); err != nil {
+		test.Fatalf("grant: %v", err)
+	}
+	if _, err := server.Grant(ctx, &creditv1.GrantRequest{UserId: "bob", TenantId: "default", LedgerId: "promo", AmountCents: 100, IdempotencyKey: "grant-2", MetadataJson: "{}"}); err != nil {
+		test.Fatalf("grant: %v", err)
+	}
+	if _, err := server.Spend(ctx, &creditv1.SpendRequest{UserId: "bob", TenantId: "default", LedgerId: "promo", AmountCents: 100, IdempotencyKey: "spend-1", MetadataJson: "{}"}); err != nil {
+		test.Fatalf("spend: %v", err)
+	}
+
+	listUsers := func(request *creditv1.ListAccountsRequest) []string {
+		test.Helper()
+		response, err := server.ListAccounts(ctx, request)
+		if err != nil {
+			test.Fatalf("list accounts: %v", err)
+		}
+		users := make([]string, 0, len(response.GetAccounts()))
+		for _, account := range response.GetAccounts() {
+			if account.GetAccountId() == "" || account.GetCreatedUnixUtc() == 0 {
+				test.Fatalf("unexpected account: %+v", account)
+			}
+			users = append(users, account.GetUserId()+"/"+account.GetLedgerId())
+		}
+		return users
+	}
+	if users := listUsers(&creditv1.ListAccountsRequest{TenantId: "default"}); len(users) != 2 {
+		test.Fatalf("expected the balance probe not to create an account, got %v", users)
+	}
+	if users := listUsers(&creditv1.ListAccountsRequest{TenantId: "default", LedgerId: "promo"}); len(users) != 1 || users[0] != "bob/promo" {
+		test.Fatalf("unexpected ledger filter result: %v", users)
+	}
+	if users := listUsers(&creditv1.ListAccountsRequest{TenantId: "default", UserIdPrefix: "al"}); len(users) != 1 || users[0] != "alice/default" {
+		test.Fatalf("unexpected prefix filter result: %v", users)
+	}
+	if users := listUsers(&creditv1.ListAccountsRequest{TenantId: "default", NonZeroBalance: true}); len(users) != 1 || users[0] != "alice/default" {
+		test.Fatalf("unexpected non-zero balance result: %v", users)
+	}
+
+	if _, err := server.ListAccounts(ctx, &creditv1.ListAccountsRequest{TenantId: "default", Limit: maxListEntriesLimit + 1}); status.Convert(err).Message() != errorInvalidListLimit {
+		test.Fatalf("expected invalid list limit, got %v", err)
+	}
+	if _, err := server.ListAccounts(ctx, &creditv1.ListAccountsRequest{TenantId: "default", LedgerId: " "}); status.Code(err) != codes.InvalidArgument {
+		test.Fatalf("expected invalid ledger id, got %v", err)
+	}
+	if _, err := server.ListAccounts(ctx, &creditv1.ListAccountsRequest{TenantId: "other"}); status.Code(err) != codes.PermissionDenied {
+		test.Fatalf("expected PermissionDenied for unauthorized tenant, got %v", err)
+	}
+}
+
+func TestReadOnlyQueriesDoNotCreateAccounts(test *testing.T) {
+	test.Parallel()
+	db, err := newSQLiteLedgerDB(test)
+	if err != nil {
+		test.Fatalf("new ledger db: %v", err)
+	}
+	clock := func() int64 { return 1700000000 }
+	creditService, err := ledger.NewService(gormstore.New(db), clock)
+	if err != nil {
+		test.Fatalf("new ledger service: %v", err)
+	}
+	server := NewCreditServiceServer(creditService, []string{"default"})
+	ctx := context.Background()
+
+	entries, err := server.ListEntries(ctx, &creditv1.ListEntriesRequest{UserId: "probe", TenantId: "default", LedgerId: "default"})
+	if err != nil || len(entries.GetEntries()) != 0 {
+		test.Fatalf("expected empty entries, got %v (%v)", entries, err)
+	}
+	reservations, err := server.ListReservations(ctx, &creditv1.ListReservationsRequest{UserId: "probe", TenantId: "default", LedgerId: "default"})
+	if err != nil || len(reservations.GetReservations()) != 0 {
+		test.Fatalf("expected empty reservations, got %v (%v)", reservations, err)
+	}
+	if _, err := server.GetReservation(ctx, &creditv1.GetReservationRequest{UserId: "probe", TenantId: "default", LedgerId: "default", ReservationId: "order-1"}); status.Convert(err).Message() != errorUnknownReservation {
+		test.Fatalf("expected unknown reservation, got %v", err)
+	}
+	var accountCount int64
+	if err := db.Model(&gormstore.Account{}).Count(&accountCount).Error; err != nil {
+		test.Fatalf("count accounts: %v", err)
+	}
+	if accountCount != 0 {
+		test.Fatalf("expected read-only queries not to create accounts, got %d", accountCount)
+	}
+
+	strictService, err := ledger.NewService(gormstore.New(db), clock, ledger.WithStrictAccountLookup(true))
+	if err != nil {
+		test.Fatalf("new strict ledger service: %v", err)
+	}
+	strictServer := NewCreditServiceServer(strictService, []string{"default"})
+	_, err = strictServer.GetBalance(ctx, &creditv1.BalanceRequest{UserId: "probe", TenantId: "default", LedgerId: "default"})
+	if status.Code(err) != codes.NotFound || status.Convert(err).Message() != errorUnknownAccount {
+		test.Fatalf("expected NotFound unknown_account, got %v", err)
+	}
+}
+
+func TestWatchEntriesStreamsCommittedEntriesAndResumes(test *testing.T) {
+	test.Parallel()
+	creditService, err := newSQLiteLedgerService(test)
+	if err != nil {
+		test.Fatalf("new ledger service: %v", err)
+	}
+	server := NewCreditServiceServer(creditService, []string{"default"}, WithWatchPollInterval(10*time.Millisecond))
+	ctx := context.Background()
+	grant := func(userID string, idempotencyKey string) {
+		test.Helper()
+		if _, err := server.Grant(ctx, &creditv1.GrantRequest{UserId: userID, TenantId: "default", LedgerId: "default", AmountCents: 100, IdempotencyKey: idempotencyKey, MetadataJson: "{}"}); err != nil {
+			test.Fatalf("grant %s: %v", idempotencyKey, err)
+		}
+	}
+	grant("alice", "grant-1")
+	grant("bob", "grant-2")
+	if _, err := server.Spend(ctx, &creditv1.SpendRequest{UserId: "alice", TenantId: "default", LedgerId: "default", AmountCents: 40, IdempotencyKey: "spend-1", MetadataJson: "{}"}); err != nil {
+		test.Fatalf("spend: %v", err)
+	}
+
+	stream := newWatchEntriesStream(4)
+	watchErr := make(chan error, 1)
+	go func() {
+		watchErr <- server.WatchEntries(&creditv1.WatchEntriesRequest{TenantId: "default"}, stream)
+	}()
+	for len(stream.changes()) < 3 {
+		time.Sleep(5 * time.Millisecond)
+	}
+	grant("carol", "grant-3")
+	if err := <-watchErr; status.Code(err) != codes.Canceled {
+		test.Fatalf("expected the stream to end with Canceled, got %v", err)
+	}
+	changes := stream.changes()
+	expectedKeys := []string{"grant-1", "grant-2", "spend-1", "grant-3"}
+	for index, change := range changes {
+		if change.GetCursor() != int64(index+1) || change.GetEntry().GetIdempotencyKey() != expectedKeys[index] || change.GetLedgerId() != "default" {
+			test.Fatalf("change %d: unexpected %+v", index, change)
+		}
+	}
+	if changes[3].GetUserId() != "carol" {
+		test.Fatalf("expected the live change to carry its user, got %q", changes[3].GetUserId())
+	}
+
+	resumed := newWatchEntriesStream(1)
+	if err := server.WatchEntries(&creditv1.WatchEntriesRequest{TenantId: "default", UserId: "alice", Types: []string{"spend"}, AfterCursor: 1}, resumed); status.Code(err) != codes.Canceled {
+		test.Fatalf("expected resumed stream to end with Canceled, got %v", err)
+	}
+	if resumedChanges := resumed.changes(); len(resumedChanges) != 1 || resumedChanges[0].GetCursor() != 3 {
+		test.Fatalf("expected the filtered spend after cursor 1, got %+v", resumedChanges)
+	}
+
+	if err := server.WatchEntries(&creditv1.WatchEntriesRequest{TenantId: "default", AfterCursor: -1}, newWatchEntriesStream(1)); status.Convert(err).Message() != errorInvalidCursor {
+		test.Fatalf("expected invalid cursor, got %v", err)
+	}
+	if err := server.WatchEntries(&creditv1.WatchEntriesRequest{TenantId: "default", Types: []string{"bogus"}}, newWatchEntriesStream(1)); status.Convert(err).Message() != errorInvalidEntryType {
+		test.Fatalf("expected invalid entry type, got %v", err)
+	}
+	if err := server.WatchEntries(&creditv1.WatchEntriesRequest{TenantId: "other"}, newWatchEntriesStream(1)); status.Code(err) != codes.PermissionDenied {
+		test.Fatalf("expected PermissionDenied for unauthorized tenant, got %v", err)
+	}
+}
+
+// watchEntriesStream collects streamed changes and cancels its context once it has received the expected number.
+type watchEntriesStream struct {
+	grpc.ServerStream
+	ctx      context.Context
+	cancel   context.CancelFunc
+	expected int
+	mutex    sync.Mutex
+	received []*creditv1.EntryChange
+}
+
+func newWatchEntriesStream(expected int) *watchEntriesStream {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &watchEntriesStream{ctx: ctx, cancel: cancel, expected: expected}
+}
+
+func (stream *watchEntriesStream) Context() context.Context {
+	return stream.ctx
+}
+
+func (stream *watchEntriesStream) Send(change *creditv1.EntryChange) error {
+	stream.mutex.Lock()
+	defer stream.mutex.Unlock()
+	stream.received = append(stream.received, change)
+	if len(stream.received) >= stream.expected {
+		stream.cancel()
+	}
+	return nil
+}
+
+func (stream *watchEntriesStream) changes() []*creditv1.EntryChange {
+	stream.mutex.Lock()
+	defer stream.mutex.Unlock()
+	return append([]*creditv1.EntryChange(nil), stream.received...)
+}
+
+func TestWatchEntriesPagesThroughBacklog(test *testing.T) {
+	test.Parallel()
+	creditService, err := newSQLiteLedgerService(test)
+	if err != nil {
+		test.Fatalf("new ledger service: %v", err)
+	}
+	server := NewCreditServiceServer(creditService, []string{"default"}, WithWatchPollInterval(10*time.Millisecond))
+	backlog := watchEntriesBatchSize + 1
+	operations := make([]*creditv1.BatchOperation, 0, backlog)
+	for operationIndex := 0; operationIndex < backlog; operationIndex++ {
+		operationID := fmt.Sprintf("grant-%d", operationIndex)
+		operations = append(operations, &creditv1.BatchOperation{
+			OperationId: operationID,
+			Operation:   &creditv1.BatchOperation_Grant{Grant: &creditv1.BatchGrantOp{AmountCents: 1, IdempotencyKey: operationID, MetadataJson: "{}"}},
+		})
+	}
+	if _, err := server.Batch(context.Background(), &creditv1.BatchRequest{
+		Account:    &creditv1.AccountContext{UserId: "alice", TenantId: "default", LedgerId: "default"},
+		Atomic:     true,
+		Operations: operations,
+	}); err != nil {
+		test.Fatalf("batch: %v", err)
+	}
+
+	stream := newWatchEntriesStream(backlog)
+	if err := server.WatchEntries(&creditv1.WatchEntriesRequest{TenantId: "default", LedgerId: "default", UserId: "alice"}, stream); status.Code(err) != codes.Canceled {
+		test.Fatalf("expected the stream to end with Canceled, got %v", err)
+	}
+	changes := stream.changes()
+	if len(changes) != backlog {
+		test.Fatalf("expected %d changes, got %d", backlog, len(changes))
+	}
+	for index, change := range changes {
+		if change.GetCursor() != int64(index+1) {
+			test.Fatalf("change %d: expected cursor %d, got %d", index, index+1, change.GetCursor())
+		}
+	}
+}
+
+func TestWatchEntriesRejectsInvalidRequests(test *testing.T) {
+	test.Parallel()
+	creditService, err := newSQLiteLedgerService(test)
+	if err != nil {
+		test.Fatalf("new ledger service: %v", err)
+	}
+	server := NewCreditServiceServer(creditService, []string{"default", ""})
+	testCases := []struct {
+		name        string
+		request     *creditv1.WatchEntriesRequest
+		wantMessage string
+	}{
+		{name: "invalid tenant id", request: &creditv1.WatchEntriesRequest{TenantId: ""}, wantMessage: errorInvalidTenantID},
+		{name: "invalid ledger id", request: &creditv1.WatchEntriesRequest{TenantId: "default", LedgerId: " "}, wantMessage: errorInvalidLedgerID},
+		{name: "invalid user id", request: &creditv1.WatchEntriesRequest{TenantId: "default", UserId: " "}, wantMessage: errorInvalidUserID},
+	}
+	for _, testCase := range testCases {
+		test.Run(testCase.name, func(test *testing.T) {
+			test.Parallel()
+			err := server.WatchEntries(testCase.request, newWatchEntriesStream(1))
+			if status.Code(err) != codes.InvalidArgument || status.Convert(err).Message() != testCase.wantMessage {
+				test.Fatalf("expected invalid argument %q, got %v", testCase.wantMessage, err)
+			}
+		})
+	}
+}
+
+func TestWatchEntriesEndsOnCancellationAndSendFailure(test *testing.T) {
+	test.Parallel()
+	creditService, err := newSQLiteLedgerService(test)
+	if err != nil {
+		test.Fatalf("new ledger service: %v", err)
+	}
+	server := NewCreditServiceServer(creditService, []string{"default"})
+	if _, err := server.Grant(context.Background(), &creditv1.GrantRequest{UserId: "alice", TenantId: "default", LedgerId: "default", AmountCents: 100, IdempotencyKey: "grant-1", MetadataJson: "{}"}); err != nil {
+		test.Fatalf("grant: %v", err)
+	}
+
+	canceled := newWatchEntriesStream(1)
+	canceled.cancel()
+	if err := server.WatchEntries(&creditv1.WatchEntriesRequest{TenantId: "default"}, canceled); status.Code(err) != codes.Canceled {
+		test.Fatalf("expected a poll on a canceled stream to end with Canceled, got %v", err)
+	}
+	if received := canceled.changes(); len(received) != 0 {
+		test.Fatalf("expected no changes on a canceled stream, got %d", len(received))
+	}
+
+	sendErr := errors.New("client gone")
+	failing := &failingWatchEntriesStream{ctx: context.Background(), err: sendErr}
+	if err := server.WatchEntries(&creditv1.WatchEntriesRequest{TenantId: "default"}, failing); !errors.Is(err, sendErr) {
+		test.Fatalf("expected the send error, got %v", err)
+	}
+}
+
+// failingWatchEntriesStream fails every Send with err.
+type failingWatchEntriesStream struct {
+	grpc.ServerStream
+	ctx context.Context
+	err error
+}
+
+func (stream *failingWatchEntriesStream) Context() context.Context {
+	return stream.ctx
+}
+
+func (stream *failingWatchEntriesStream) Send(*creditv1.EntryChange) error {
+	return stream.err
+}
+
+func TestGetTrialBalanceSumsToZero(test *testing.T) {
+	test.Parallel()
+	creditService, err := newSQLiteLedgerService(test)
+	if err != nil {
+		test.Fatalf("new ledger service: %v", err)
+	}
+	server := NewCreditServiceServer(creditService, []string{"default"})
+	ctx := context.Background()
+
+	if _, err := server.Grant(ctx, &creditv1.GrantRequest{UserId: "user-123", TenantId: "default", LedgerId: "default", AmountCents: 1000, IdempotencyKey: "grant-1", MetadataJson: "{}"}); err != nil {
+		test.Fatalf("grant: %v", err)
+	}
+	if _, err := server.Spend(ctx, &creditv1.SpendRequest{UserId: "user-123", TenantId: "default", LedgerId: "default", AmountCents: 400, IdempotencyKey: "spend-1", MetadataJson: "{}"}); err != nil {
+		test.Fatalf("spend: %v", err)
+	}
+
+	response, err := server.GetTrialBalance(ctx, &creditv1.GetTrialBalanceRequest{TenantId: "default"})
+	if err != nil {
+		test.Fatalf("get trial balance: %v", err)
+	}
+	if response.GetTotalCents() != 0 || response.GetAsOfUnixUtc() != 1700000000 {
+		test.Fatalf("unexpected trial balance: %+v", response)
+	}
+	balances := make(map[string]int64, len(response.GetLines()))
+	for _, line := range response.GetLines() {
+		balances[line.GetLedgerId()+"/"+line.GetAccount()] = line.GetBalanceCents()
+	}
+	if len(balances) != 3 || balances["default/user"] != 600 || balances["default/issuance"] != -1000 || balances["default/revenue"] != 400 {
+		test.Fatalf("unexpected trial balance lines: %+v", balances)
+	}
+
+	if _, err := server.GetTrialBalance(ctx, &creditv1.GetTrialBalanceRequest{TenantId: "other"}); status.Code(err) != codes.PermissionDenied {
+		test.Fatalf("expected PermissionDenied for unauthorized tenant, got %v", err)
+	}
+}
+
+func TestGetLiabilityReportSplitsByExpiryMonth(test *testing.T) {
+	test.Parallel()
+	creditService, err := newSQLiteLedgerService(test)
+	if err != nil {
+		test.Fatalf("new ledger service: %v", err)
+	}
+	server := NewCreditServiceServer(creditService, []string{"default"})
+	ctx := context.Background()
+
+	if _, err := server.Grant(ctx, &creditv1.GrantRequest{UserId: "user-123", TenantId: "default", LedgerId: "default", AmountCents: 1000, IdempotencyKey: "grant-1", MetadataJson: "{}"}); err != nil {
+		test.Fatalf("grant: %v", err)
+	}
+	if _, err := server.Grant(ctx, &creditv1.GrantRequest{UserId: "user-456", TenantId: "default", LedgerId: "default", AmountCents: 300, IdempotencyKey: "grant-2", ExpiresAtUnixUtc: 1701388800, MetadataJson: "{}"}); err != nil {
+		test.Fatalf("expiring grant: %v", err)
+	}
+
+	response, err := server.GetLiabilityReport(ctx, &creditv1.GetLiabilityReportRequest{TenantId: "default"})
+	if err != nil {
+		test.Fatalf("get liability report: %v", err)
+	}
+	if response.GetAsOfUnixUtc() != 1700000000 || len(response.GetBreakage()) != 0 || len(response.GetOutstanding()) != 2 {
+		test.Fatalf("unexpected liability report: %+v", response)
+	}
+	if bucket := response.GetOutstanding()[0]; bucket.GetLedgerId() != "default" || bucket.GetExpiryMonth() != "2023-12" || bucket.GetAmountCents() != 300 {
+		test.Fatalf("unexpected expiring bucket: %+v", bucket)
+	}
+	if bucket := response.GetOutstanding()[1]; bucket.GetExpiryMonth() != "" || bucket.GetAmountCents() != 1000 {
+		test.Fatalf("unexpected non-expiring bucket: %+v", bucket)
+	}
+
+	expired, err := server.GetLiabilityReport(ctx, &creditv1.GetLiabilityReportRequest{TenantId: "default", AsOfUnixUtc: 1701388800})
+	if err != nil {
+		test.Fatalf("get liability report: %v", err)
+	}
+	if len(expired.GetOutstanding()) != 1 || len(expired.GetBreakage()) != 1 || expired.GetBreakage()[0].GetExpiryMonth() != "2023-12" || expired.GetBreakage()[0].GetAmountCents() != 300 {
+		test.Fatalf("expected expired grant to be reported as breakage, got %+v", expired)
+	}
+
+	if _, err := server.GetLiabilityReport(ctx, &creditv1.GetLiabilityReportRequest{TenantId: "other"}); status.Code(err) != codes.PermissionDenied {
+		test.Fatalf("expected PermissionDenied for unauthorized tenant, got %v", err)
+	}
+}
+
 func TestCreditServiceServerFlow(test *testing.T) {
 	test.Parallel()
 	creditService, err := newSQLiteLedgerService(test)
@@ -479,205 +908,1165 @@ func TestCreditServiceServerFlow(test *testing.T) {
 	}
 }
 
-func TestCreditServiceServerReservationIntrospection(test *testing.T) {
+func TestCreditServiceServerGrantScheduleFlow(test *testing.T) {
 	test.Parallel()
-	creditService, err := newSQLiteLedgerService(test)
+	db, err := newSQLiteLedgerDB(test)
+	if err != nil {
+		test.Fatalf("new ledger db: %v", err)
+	}
+	nowUnixUTC := int64(1700000000)
+	clock := func() int64 { return nowUnixUTC }
+	creditService, err := ledger.NewService(gormstore.New(db), clock)
 	if err != nil {
 		test.Fatalf("new ledger service: %v", err)
 	}
-	server := NewCreditServiceServer(creditService, []string{"default"})
+	scheduleStore := gormadapters.NewScheduleStore(db)
+	scheduleService, err := schedules.NewService(scheduleStore, clock)
+	if err != nil {
+		test.Fatalf("new schedule service: %v", err)
+	}
+	worker, err := schedules.NewWorker(scheduleStore, creditService, clock)
+	if err != nil {
+		test.Fatalf("new schedule worker: %v", err)
+	}
+	server := NewCreditServiceServer(creditService, []string{"default"}, WithGrantSchedules(scheduleService))
 
 	ctx := context.Background()
 	userID := "user-123"
 	tenantID := "default"
 	ledgerID := "default"
 
-	if _, err := server.Grant(ctx, &creditv1.GrantRequest{
-		UserId:         userID,
-		TenantId:       tenantID,
-		LedgerId:       ledgerID,
-		AmountCents:    1000,
-		IdempotencyKey: "grant-1",
-		MetadataJson:   "{}",
-	}); err != nil {
-		test.Fatalf("grant: %v", err)
+	createResponse, err := server.CreateGrantSchedule(ctx, &creditv1.CreateGrantScheduleRequest{
+		UserId:          userID,
+		TenantId:        tenantID,
+		LedgerId:        ledgerID,
+		ScheduleId:      "daily-allowance",
+		AmountCents:     500,
+		Interval:        "daily",
+		GrantTtlSeconds: 3600,
+		MetadataJson:    `{"plan":"pro"}`,
+	})
+	if err != nil {
+		test.Fatalf("create grant schedule: %v", err)
 	}
-
-	if _, err := server.Reserve(ctx, &creditv1.ReserveRequest{
-		UserId:           userID,
-		TenantId:         tenantID,
-		LedgerId:         ledgerID,
-		AmountCents:      300,
-		ReservationId:    "order-1",
-		IdempotencyKey:   "reserve-1",
-		ExpiresAtUnixUtc: 1700000060,
-		MetadataJson:     "{}",
-	}); err != nil {
-		test.Fatalf("reserve: %v", err)
+	if created := createResponse.GetSchedule(); created.GetStatus() != "active" || created.GetNextRunUnixUtc() != nowUnixUTC || created.GetStartUnixUtc() != nowUnixUTC {
+		test.Fatalf("unexpected created schedule: %+v", created)
 	}
-
-	activeReservation, err := server.GetReservation(ctx, &creditv1.GetReservationRequest{
-		UserId:        userID,
-		TenantId:      tenantID,
-		LedgerId:      ledgerID,
-		ReservationId: "order-1",
+	_, err = server.CreateGrantSchedule(ctx, &creditv1.CreateGrantScheduleRequest{
+		UserId: userID, TenantId: tenantID, LedgerId: ledgerID, ScheduleId: "daily-allowance", AmountCents: 500, Interval: "daily", MetadataJson: "{}",
 	})
-	if err != nil {
-		test.Fatalf("get reservation: %v", err)
+	if status.Code(err) != codes.AlreadyExists {
+		test.Fatalf("expected already exists, got %v", err)
 	}
-	if activeReservation.GetReservation().GetReservationId() != "order-1" {
-		test.Fatalf("expected reservation_id order-1, got %q", activeReservation.GetReservation().GetReservationId())
+
+	for range 2 {
+		if _, err := worker.RunDue(ctx); err != nil {
+			test.Fatalf("run due: %v", err)
+		}
 	}
-	if activeReservation.GetReservation().GetAmountCents() != 300 {
-		test.Fatalf("expected amount 300, got %d", activeReservation.GetReservation().GetAmountCents())
+	balanceResponse, err := server.GetBalance(ctx, &creditv1.BalanceRequest{UserId: userID, TenantId: tenantID, LedgerId: ledgerID})
+	if err != nil {
+		test.Fatalf("get balance: %v", err)
 	}
-	if activeReservation.GetReservation().GetStatus() != ledger.ReservationStatusActive.String() {
-		test.Fatalf("expected status active, got %q", activeReservation.GetReservation().GetStatus())
+	if balanceResponse.GetTotalCents() != 500 {
+		test.Fatalf("expected a single scheduled grant, got total=%d", balanceResponse.GetTotalCents())
 	}
-	if activeReservation.GetReservation().GetExpiresAtUnixUtc() != 1700000060 {
-		test.Fatalf("expected expires_at_unix_utc 1700000060, got %d", activeReservation.GetReservation().GetExpiresAtUnixUtc())
+	entriesResponse, err := server.ListEntries(ctx, &creditv1.ListEntriesRequest{UserId: userID, TenantId: tenantID, LedgerId: ledgerID, BeforeUnixUtc: nowUnixUTC + 1, Limit: 10})
+	if err != nil {
+		test.Fatalf("list entries: %v", err)
 	}
-	if activeReservation.GetReservation().GetExpired() {
-		test.Fatalf("expected reservation to be not expired")
+	if len(entriesResponse.GetEntries()) != 1 {
+		test.Fatalf("expected one entry, got %d", len(entriesResponse.GetEntries()))
+	}
+	if entry := entriesResponse.GetEntries()[0]; entry.GetIdempotencyKey() != "schedule:daily-allowance:2023-11-14" || entry.GetExpiresAtUnixUtc() != nowUnixUTC+3600 {
+		test.Fatalf("unexpected scheduled grant entry: %+v", entry)
+	}
+
+	listResponse, err := server.ListGrantSchedules(ctx, &creditv1.ListGrantSchedulesRequest{TenantId: tenantID, UserId: userID, Statuses: []string{"active"}})
+	if err != nil {
+		test.Fatalf("list grant schedules: %v", err)
+	}
+	if len(listResponse.GetSchedules()) != 1 || listResponse.GetSchedules()[0].GetNextRunUnixUtc() != nowUnixUTC+24*60*60 {
+		test.Fatalf("unexpected schedules: %+v", listResponse.GetSchedules())
+	}
+
+	cancelResponse, err := server.CancelGrantSchedule(ctx, &creditv1.CancelGrantScheduleRequest{TenantId: tenantID, ScheduleId: "daily-allowance"})
+	if err != nil {
+		test.Fatalf("cancel grant schedule: %v", err)
+	}
+	if cancelResponse.GetSchedule().GetStatus() != "cancelled" {
+		test.Fatalf("expected cancelled schedule, got %q", cancelResponse.GetSchedule().GetStatus())
+	}
+	if _, err := server.CancelGrantSchedule(ctx, &creditv1.CancelGrantScheduleRequest{TenantId: tenantID, ScheduleId: "daily-allowance"}); status.Code(err) != codes.FailedPrecondition {
+		test.Fatalf("expected failed precondition, got %v", err)
+	}
+	if _, err := server.CancelGrantSchedule(ctx, &creditv1.CancelGrantScheduleRequest{TenantId: tenantID, ScheduleId: "missing"}); status.Code(err) != codes.NotFound {
+		test.Fatalf("expected not found, got %v", err)
+	}
+
+	nowUnixUTC += 3 * 24 * 60 * 60
+	if _, err := worker.RunDue(ctx); err != nil {
+		test.Fatalf("run due after cancel: %v", err)
+	}
+	balanceResponse, err = server.GetBalance(ctx, &creditv1.BalanceRequest{UserId: userID, TenantId: tenantID, LedgerId: ledgerID})
+	if err != nil {
+		test.Fatalf("get balance after cancel: %v", err)
+	}
+	if balanceResponse.GetTotalCents() != 0 {
+		test.Fatalf("expected expired grant and no new grants after cancel, got total=%d", balanceResponse.GetTotalCents())
+	}
+}
+
+func TestCreditServiceServerGrantScheduleValidation(test *testing.T) {
+	test.Parallel()
+	db, err := newSQLiteLedgerDB(test)
+	if err != nil {
+		test.Fatalf("new ledger db: %v", err)
+	}
+	clock := func() int64 { return 1700000000 }
+	creditService, err := ledger.NewService(gormstore.New(db), clock)
+	if err != nil {
+		test.Fatalf("new ledger service: %v", err)
+	}
+	scheduleService, err := schedules.NewService(gormadapters.NewScheduleStore(db), clock)
+	if err != nil {
+		test.Fatalf("new schedule service: %v", err)
+	}
+	server := NewCreditServiceServer(creditService, []string{"default", ""}, WithGrantSchedules(scheduleService))
+	ctx := context.Background()
+	validRequest := func() *creditv1.CreateGrantScheduleRequest {
+		return &creditv1.CreateGrantScheduleRequest{UserId: "user-1", TenantId: "default", LedgerId: "default", ScheduleId: "schedule-1", AmountCents: 100, Interval: "monthly", MetadataJson: "{}"}
+	}
+
+	testCases := []struct {
+		name    string
+		mutate  func(*creditv1.CreateGrantScheduleRequest)
+		message string
+	}{
+		{name: "schedule id", mutate: func(request *creditv1.CreateGrantScheduleRequest) { request.ScheduleId = " " }, message: errorInvalidScheduleID},
+		{name: "interval", mutate: func(request *creditv1.CreateGrantScheduleRequest) { request.Interval = "hourly" }, message: errorInvalidScheduleInterval},
+		{name: "window", mutate: func(request *creditv1.CreateGrantScheduleRequest) {
+			request.StartUnixUtc = 200
+			request.EndUnixUtc = 100
+		}, message: errorInvalidScheduleWindow},
+		{name: "ttl", mutate: func(request *creditv1.CreateGrantScheduleRequest) { request.GrantTtlSeconds = -1 }, message: errorInvalidGrantTTL},
+		{name: "amount", mutate: func(request *creditv1.CreateGrantScheduleRequest) { request.AmountCents = 0 }, message: errorInvalidAmount},
+		{name: "user", mutate: func(request *creditv1.CreateGrantScheduleRequest) { request.UserId = "" }, message: errorInvalidUserID},
+		{name: "ledger", mutate: func(request *creditv1.CreateGrantScheduleRequest) { request.LedgerId = " " }, message: errorInvalidLedgerID},
+		{name: "tenant", mutate: func(request *creditv1.CreateGrantScheduleRequest) { request.TenantId = "" }, message: errorInvalidTenantID},
+		{name: "metadata", mutate: func(request *creditv1.CreateGrantScheduleRequest) { request.MetadataJson = "{" }, message: errorInvalidMetadata},
+	}
+	for _, testCase := range testCases {
+		request := validRequest()
+		testCase.mutate(request)
+		_, err := server.CreateGrantSchedule(ctx, request)
+		if status.Code(err) != codes.InvalidArgument || status.Convert(err).Message() != testCase.message {
+			test.Fatalf("%s: expected invalid argument %q, got %v", testCase.name, testCase.message, err)
+		}
+	}
+	if _, err := server.ListGrantSchedules(ctx, &creditv1.ListGrantSchedulesRequest{TenantId: "default", Statuses: []string{"paused"}}); status.Convert(err).Message() != errorInvalidScheduleStatus {
+		test.Fatalf("expected invalid schedule status, got %v", err)
+	}
+	if _, err := server.ListGrantSchedules(ctx, &creditv1.ListGrantSchedulesRequest{TenantId: "default", Limit: maxListEntriesLimit + 1}); status.Convert(err).Message() != errorInvalidListLimit {
+		test.Fatalf("expected invalid list limit, got %v", err)
+	}
+	invalidListRequests := []struct {
+		name    string
+		request *creditv1.ListGrantSchedulesRequest
+		message string
+	}{
+		{name: "tenant", request: &creditv1.ListGrantSchedulesRequest{TenantId: ""}, message: errorInvalidTenantID},
+		{name: "user", request: &creditv1.ListGrantSchedulesRequest{TenantId: "default", UserId: " "}, message: errorInvalidUserID},
+		{name: "ledger", request: &creditv1.ListGrantSchedulesRequest{TenantId: "default", LedgerId: " "}, message: errorInvalidLedgerID},
+	}
+	for _, testCase := range invalidListRequests {
+		_, err := server.ListGrantSchedules(ctx, testCase.request)
+		if status.Code(err) != codes.InvalidArgument || status.Convert(err).Message() != testCase.message {
+			test.Fatalf("list %s: expected invalid argument %q, got %v", testCase.name, testCase.message, err)
+		}
+	}
+	filtered, err := server.ListGrantSchedules(ctx, &creditv1.ListGrantSchedulesRequest{TenantId: "default", UserId: "user-1", LedgerId: "default"})
+	if err != nil || len(filtered.GetSchedules()) != 0 {
+		test.Fatalf("expected empty filtered list, got %v, %v", filtered, err)
+	}
+	if _, err := server.CancelGrantSchedule(ctx, &creditv1.CancelGrantScheduleRequest{TenantId: "other", ScheduleId: "schedule-1"}); status.Code(err) != codes.PermissionDenied {
+		test.Fatalf("expected permission denied, got %v", err)
+	}
+	if _, err := server.CancelGrantSchedule(ctx, &creditv1.CancelGrantScheduleRequest{TenantId: "", ScheduleId: "schedule-1"}); status.Convert(err).Message() != errorInvalidTenantID {
+		test.Fatalf("expected invalid tenant id, got %v", err)
+	}
+	if _, err := server.CancelGrantSchedule(ctx, &creditv1.CancelGrantScheduleRequest{TenantId: "default", ScheduleId: " "}); status.Convert(err).Message() != errorInvalidScheduleID {
+		test.Fatalf("expected invalid schedule id, got %v", err)
+	}
+	if _, err := server.CreateGrantSchedule(ctx, &creditv1.CreateGrantScheduleRequest{TenantId: "other"}); status.Code(err) != codes.PermissionDenied {
+		test.Fatalf("expected permission denied create, got %v", err)
+	}
+	if _, err := server.ListGrantSchedules(ctx, &creditv1.ListGrantSchedulesRequest{TenantId: "other"}); status.Code(err) != codes.PermissionDenied {
+		test.Fatalf("expected permission denied list, got %v", err)
+	}
+
+	failingScheduleService, err := schedules.NewService(failingListScheduleStore{Store: gormadapters.NewScheduleStore(db)}, clock)
+	if err != nil {
+		test.Fatalf("new failing schedule service: %v", err)
+	}
+	failingServer := NewCreditServiceServer(creditService, []string{"default"}, WithGrantSchedules(failingScheduleService))
+	if _, err := failingServer.ListGrantSchedules(ctx, &creditv1.ListGrantSchedulesRequest{TenantId: "default"}); status.Code(err) != codes.Internal {
+		test.Fatalf("expected internal list error, got %v", err)
+	}
+
+	disabledServer := NewCreditServiceServer(creditService, []string{"default"})
+	if _, err := disabledServer.CreateGrantSchedule(ctx, validRequest()); status.Code(err) != codes.Unimplemented {
+		test.Fatalf("expected unimplemented create, got %v", err)
+	}
+	if _, err := disabledServer.ListGrantSchedules(ctx, &creditv1.ListGrantSchedulesRequest{TenantId: "default"}); status.Code(err) != codes.Unimplemented {
+		test.Fatalf("expected unimplemented list, got %v", err)
+	}
+	if _, err := disabledServer.CancelGrantSchedule(ctx, &creditv1.CancelGrantScheduleRequest{TenantId: "default", ScheduleId: "schedule-1"}); status.Code(err) != codes.Unimplemented {
+		test.Fatalf("expected unimplemented cancel, got %v", err)
+	}
+}
+
+type failingListScheduleStore struct {
+	schedules.Store
+}
+
+func (failingListScheduleStore) ListSchedules(context.Context, ledger.TenantID, int64, int, schedules.ListFilter) ([]schedules.Schedule, error) {
+	return nil, errors.New("list schedules failed")
+}
+
+func TestCreditServiceServerWebhookDeliveryAndReplay(test *testing.T) {
+	test.Parallel()
+	db, err := newSQLiteLedgerDB(test)
+	if err != nil {
+		test.Fatalf("new ledger db: %v", err)
+	}
+	nowUnixUTC := int64(1700000000)
+	clock := func() int64 { return nowUnixUTC }
+	tenantID, _ := ledger.NewTenantID("default")
+	creditService, err := ledger.NewService(gormstore.New(db), clock, ledger.WithOutbox(tenantID))
+	if err != nil {
+		test.Fatalf("new ledger service: %v", err)
+	}
+	webhookStore := gormadapters.NewWebhookStore(db)
+	webhookService, err := webhooks.NewService(webhookStore, clock)
+	if err != nil {
+		test.Fatalf("new webhook service: %v", err)
+	}
+	var receiverUp atomic.Bool
+	var receivedTypes []string
+	var receivedMutex sync.Mutex
+	receiver := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if !receiverUp.Load() {
+			writer.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		receivedMutex.Lock()
+		receivedTypes = append(receivedTypes, request.Header.Get(webhooks.EventTypeHeader))
+		receivedMutex.Unlock()
+		writer.WriteHeader(http.StatusOK)
+	}))
+	test.Cleanup(receiver.Close)
+	endpoint, err := webhooks.NewEndpoint(receiver.URL, "whsec")
+	if err != nil {
+		test.Fatalf("new endpoint: %v", err)
+	}
+	worker, err := webhooks.NewWorker(webhookStore, map[ledger.TenantID]webhooks.Endpoint{tenantID: endpoint}, clock, webhooks.WithMaxAttempts(1))
+	if err != nil {
+		test.Fatalf("new webhook worker: %v", err)
+	}
+	server := NewCreditServiceServer(creditService, []string{"default"}, WithWebhooks(webhookService))
+	ctx := context.Background()
+
+	if _, err := server.Grant(ctx, &creditv1.GrantRequest{UserId: "user-123", TenantId: "default", LedgerId: "default", AmountCents: 500, IdempotencyKey: "grant-1", MetadataJson: "{}"}); err != nil {
+		test.Fatalf("grant: %v", err)
+	}
+	if _, err := worker.RunDue(ctx); err == nil {
+		test.Fatalf("expected the delivery to fail while the receiver is down")
+	}
+	deadResponse, err := server.ListWebhookEvents(ctx, &creditv1.ListWebhookEventsRequest{TenantId: "default", Statuses: []string{"dead"}})
+	if err != nil {
+		test.Fatalf("list dead events: %v", err)
+	}
+	if len(deadResponse.GetEvents()) != 1 || deadResponse.GetEvents()[0].GetEventType() != "entry.grant" || deadResponse.GetEvents()[0].GetLastError() == "" {
+		test.Fatalf("expected one dead grant event, got %+v", deadResponse.GetEvents())
+	}
+
+	eventID := deadResponse.GetEvents()[0].GetEventId()
+	replayResponse, err := server.ReplayWebhookEvent(ctx, &creditv1.ReplayWebhookEventRequest{TenantId: "default", EventId: eventID})
+	if err != nil {
+		test.Fatalf("replay: %v", err)
+	}
+	if replayResponse.GetEvent().GetStatus() != "pending" || replayResponse.GetEvent().GetAttempts() != 0 {
+		test.Fatalf("unexpected replayed event: %+v", replayResponse.GetEvent())
+	}
+	receiverUp.Store(true)
+	if delivered, err := worker.RunDue(ctx); err != nil || delivered != 1 {
+		test.Fatalf("expected the replayed event to be delivered, got %d (%v)", delivered, err)
+	}
+	receivedMutex.Lock()
+	if len(receivedTypes) != 1 || receivedTypes[0] != "entry.grant" {
+		test.Fatalf("unexpected deliveries: %v", receivedTypes)
+	}
+	receivedMutex.Unlock()
+
+	if _, err := server.ReplayWebhookEvent(ctx, &creditv1.ReplayWebhookEventRequest{TenantId: "default", EventId: "00000000-0000-0000-0000-000000000000"}); status.Code(err) != codes.NotFound || status.Convert(err).Message() != errorUnknownEvent {
+		test.Fatalf("expected unknown event, got %v", err)
+	}
+	if _, err := server.ReplayWebhookEvent(ctx, &creditv1.ReplayWebhookEventRequest{TenantId: "default", EventId: " "}); status.Convert(err).Message() != errorInvalidEventID {
+		test.Fatalf("expected invalid event id, got %v", err)
+	}
+	if _, err := server.ListWebhookEvents(ctx, &creditv1.ListWebhookEventsRequest{TenantId: "default", Statuses: []string{"failed"}}); status.Convert(err).Message() != errorInvalidEventStatus {
+		test.Fatalf("expected invalid event status, got %v", err)
+	}
+	if _, err := server.ListWebhookEvents(ctx, &creditv1.ListWebhookEventsRequest{TenantId: "other"}); status.Code(err) != codes.PermissionDenied {
+		test.Fatalf("expected permission denied, got %v", err)
+	}
+	disabledServer := NewCreditServiceServer(creditService, []string{"default"})
+	if _, err := disabledServer.ListWebhookEvents(ctx, &creditv1.ListWebhookEventsRequest{TenantId: "default"}); status.Code(err) != codes.Unimplemented {
+		test.Fatalf("expected unimplemented list, got %v", err)
+	}
+	if _, err := disabledServer.ReplayWebhookEvent(ctx, &creditv1.ReplayWebhookEventRequest{TenantId: "default", EventId: eventID}); status.Code(err) != codes.Unimplemented {
+		test.Fatalf("expected unimplemented replay, got %v", err)
+	}
+}
+
+func TestCreditServiceServerWebhookValidationErrors(test *testing.T) {
+	test.Parallel()
+	db, err := newSQLiteLedgerDB(test)
+	if err != nil {
+		test.Fatalf("new ledger db: %v", err)
+	}
+	creditService, err := ledger.NewService(gormstore.New(db), func() int64 { return 1700000000 })
+	if err != nil {
+		test.Fatalf("new ledger service: %v", err)
+	}
+	webhookService, err := webhooks.NewService(gormadapters.NewWebhookStore(db), func() int64 { return 1700000000 })
+	if err != nil {
+		test.Fatalf("new webhook service: %v", err)
+	}
+	server := NewCreditServiceServer(creditService, []string{"default", ""}, WithWebhooks(webhookService))
+	ctx := context.Background()
+
+	testCases := []struct {
+		name        string
+		invoke      func() error
+		wantCode    codes.Code
+		wantMessage string
+	}{
+		{
+			name: "list invalid tenant id",
+			invoke: func() error {
+				_, err := server.ListWebhookEvents(ctx, &creditv1.ListWebhookEventsRequest{TenantId: ""})
+				return err
+			},
+			wantCode: codes.InvalidArgument, wantMessage: errorInvalidTenantID,
+		},
+		{
+			name: "list limit above maximum",
+			invoke: func() error {
+				_, err := server.ListWebhookEvents(ctx, &creditv1.ListWebhookEventsRequest{TenantId: "default", Limit: maxListEntriesLimit + 1})
+				return err
+			},
+			wantCode: codes.InvalidArgument, wantMessage: errorInvalidListLimit,
+		},
+		{
+			name: "replay unauthorized tenant",
+			invoke: func() error {
+				_, err := server.ReplayWebhookEvent(ctx, &creditv1.ReplayWebhookEventRequest{TenantId: "other", EventId: "evt-1"})
+				return err
+			},
+			wantCode: codes.PermissionDenied, wantMessage: "tenant \"other\" is not authorized",
+		},
+		{
+			name: "replay invalid tenant id",
+			invoke: func() error {
+				_, err := server.ReplayWebhookEvent(ctx, &creditv1.ReplayWebhookEventRequest{TenantId: "", EventId: "evt-1"})
+				return err
+			},
+			wantCode: codes.InvalidArgument, wantMessage: errorInvalidTenantID,
+		},
+	}
+	for _, testCase := range testCases {
+		test.Run(testCase.name, func(test *testing.T) {
+			err := testCase.invoke()
+			if status.Code(err) != testCase.wantCode || status.Convert(err).Message() != testCase.wantMessage {
+				test.Fatalf("expected %v %q, got %v", testCase.wantCode, testCase.wantMessage, err)
+			}
+		})
+	}
+
+	if err := db.Exec("DROP TABLE outbox_events").Error; err != nil {
+		test.Fatalf("drop outbox events: %v", err)
+	}
+	if _, err := server.ListWebhookEvents(ctx, &creditv1.ListWebhookEventsRequest{TenantId: "default"}); status.Code(err) != codes.Internal {
+		test.Fatalf("expected internal for a failing store, got %v", err)
+	}
+}
+
+func TestCreditServiceServerScheduledGrantFlow(test *testing.T) {
+	test.Parallel()
+	creditService, err := newSQLiteLedgerService(test)
+	if err != nil {
+		test.Fatalf("new ledger service: %v", err)
+	}
+	server := NewCreditServiceServer(creditService, []string{"default"})
+
+	ctx := context.Background()
+	userID := "user-123"
+	tenantID := "default"
+	ledgerID := "default"
+
+	for _, grant := range []*creditv1.GrantRequest{
+		{UserId: userID, TenantId: tenantID, LedgerId: ledgerID, AmountCents: 100, IdempotencyKey: "grant-now", MetadataJson: "{}"},
+		{UserId: userID, TenantId: tenantID, LedgerId: ledgerID, AmountCents: 500, IdempotencyKey: "allowance-next", EffectiveAtUnixUtc: 1700000500, MetadataJson: "{}"},
+	} {
+		if _, err := server.Grant(ctx, grant); err != nil {
+			test.Fatalf("grant %s: %v", grant.GetIdempotencyKey(), err)
+		}
+	}
+
+	balanceResponse, err := server.GetBalance(ctx, &creditv1.BalanceRequest{UserId: userID, TenantId: tenantID, LedgerId: ledgerID})
+	if err != nil {
+		test.Fatalf("get balance: %v", err)
+	}
+	if balanceResponse.GetTotalCents() != 100 || balanceResponse.GetAvailableCents() != 100 {
+		test.Fatalf("expected pending grant to be excluded, got total=%d available=%d", balanceResponse.GetTotalCents(), balanceResponse.GetAvailableCents())
+	}
+
+	listResponse, err := server.ListEntries(ctx, &creditv1.ListEntriesRequest{UserId: userID, TenantId: tenantID, LedgerId: ledgerID, BeforeUnixUtc: 1700000001, Types: []string{"grant"}, Limit: 10})
+	if err != nil {
+		test.Fatalf("list entries: %v", err)
+	}
+	pendingByKey := make(map[string]*creditv1.Entry, len(listResponse.GetEntries()))
+	for _, entry := range listResponse.GetEntries() {
+		pendingByKey[entry.GetIdempotencyKey()] = entry
+	}
+	if scheduled := pendingByKey["allowance-next"]; scheduled == nil || !scheduled.GetPending() || scheduled.GetEffectiveAtUnixUtc() != 1700000500 {
+		test.Fatalf("expected scheduled grant to be listed as pending, got %+v", scheduled)
+	}
+	if immediate := pendingByKey["grant-now"]; immediate == nil || immediate.GetPending() {
+		test.Fatalf("expected immediate grant not to be pending, got %+v", immediate)
+	}
+
+	cancelResponse, err := server.CancelGrant(ctx, &creditv1.CancelGrantRequest{
+		UserId:   userID,
+		TenantId: tenantID,
+		LedgerId: ledgerID,
+		Grant:    &creditv1.CancelGrantRequest_GrantIdempotencyKey{GrantIdempotencyKey: "allowance-next"},
+	})
+	if err != nil {
+		test.Fatalf("cancel grant: %v", err)
+	}
+	replayResponse, err := server.CancelGrant(ctx, &creditv1.CancelGrantRequest{
+		UserId:   userID,
+		TenantId: tenantID,
+		LedgerId: ledgerID,
+		Grant:    &creditv1.CancelGrantRequest_GrantEntryId{GrantEntryId: pendingByKey["allowance-next"].GetEntryId()},
+	})
+	if err != nil {
+		test.Fatalf("replay cancel grant: %v", err)
+	}
+	if replayResponse.GetEntryId() != cancelResponse.GetEntryId() {
+		test.Fatalf("expected replay to return entry %q, got %q", cancelResponse.GetEntryId(), replayResponse.GetEntryId())
+	}
+
+	_, err = server.CancelGrant(ctx, &creditv1.CancelGrantRequest{
+		UserId:   userID,
+		TenantId: tenantID,
+		LedgerId: ledgerID,
+		Grant:    &creditv1.CancelGrantRequest_GrantEntryId{GrantEntryId: pendingByKey["grant-now"].GetEntryId()},
+	})
+	if status.Code(err) != codes.FailedPrecondition || status.Convert(err).Message() != errorGrantNotCancellable {
+		test.Fatalf("expected grant_not_cancellable, got %v", err)
+	}
+
+	_, err = server.CancelGrant(ctx, &creditv1.CancelGrantRequest{UserId: userID, TenantId: tenantID, LedgerId: ledgerID})
+	if status.Code(err) != codes.InvalidArgument || status.Convert(err).Message() != errorMissingGrantReference {
+		test.Fatalf("expected missing_grant_reference, got %v", err)
+	}
+	_, err = server.CancelGrant(ctx, &creditv1.CancelGrantRequest{UserId: userID, TenantId: tenantID, LedgerId: ledgerID, Grant: &creditv1.CancelGrantRequest_GrantIdempotencyKey{GrantIdempotencyKey: " "}})
+	if status.Code(err) != codes.InvalidArgument || status.Convert(err).Message() != errorInvalidIdempotencyKey {
+		test.Fatalf("expected invalid_idempotency_key, got %v", err)
+	}
+	_, err = server.CancelGrant(ctx, &creditv1.CancelGrantRequest{UserId: userID, TenantId: tenantID, LedgerId: ledgerID, Grant: &creditv1.CancelGrantRequest_GrantEntryId{GrantEntryId: " "}})
+	if status.Code(err) != codes.InvalidArgument || status.Convert(err).Message() != errorInvalidEntryID {
+		test.Fatalf("expected invalid_entry_id, got %v", err)
+	}
+
+	_, err = server.Grant(ctx, &creditv1.GrantRequest{UserId: userID, TenantId: tenantID, LedgerId: ledgerID, AmountCents: 10, IdempotencyKey: "grant-invalid", EffectiveAtUnixUtc: 1700000500, ExpiresAtUnixUtc: 1700000400, MetadataJson: "{}"})
+	if status.Code(err) != codes.InvalidArgument || status.Convert(err).Message() != errorInvalidEffectiveAt {
+		test.Fatalf("expected invalid_effective_at, got %v", err)
+	}
+
+	batchResponse, err := server.Batch(ctx, &creditv1.BatchRequest{
+		Account: &creditv1.AccountContext{UserId: userID, TenantId: tenantID, LedgerId: ledgerID},
+		Operations: []*creditv1.BatchOperation{
+			{OperationId: "scheduled", Operation: &creditv1.BatchOperation_Grant{Grant: &creditv1.BatchGrantOp{AmountCents: 40, IdempotencyKey: "batch-allowance", EffectiveAtUnixUtc: 1700000500, MetadataJson: "{}"}}},
+			{OperationId: "invalid", Operation: &creditv1.BatchOperation_Grant{Grant: &creditv1.BatchGrantOp{AmountCents: 40, IdempotencyKey: "batch-invalid", EffectiveAtUnixUtc: -1, MetadataJson: "{}"}}},
+		},
+	})
+	if err != nil {
+		test.Fatalf("batch: %v", err)
+	}
+	if !batchResponse.GetResults()[0].GetOk() || batchResponse.GetResults()[1].GetErrorCode() != errorInvalidEffectiveAt {
+		test.Fatalf("unexpected batch results: %+v", batchResponse.GetResults())
+	}
+
+	balanceResponse, err = server.GetBalance(ctx, &creditv1.BalanceRequest{UserId: userID, TenantId: tenantID, LedgerId: ledgerID})
+	if err != nil {
+		test.Fatalf("get balance: %v", err)
+	}
+	if balanceResponse.GetTotalCents() != 100 {
+		test.Fatalf("expected scheduled batch grant to stay pending, got total=%d", balanceResponse.GetTotalCents())
+	}
+}
+
+func TestCreditServiceServerCancelGrantValidationErrors(test *testing.T) {
+	test.Parallel()
+	creditService, err := newSQLiteLedgerService(test)
+	if err != nil {
+		test.Fatalf("new ledger service: %v", err)
+	}
+	server := NewCreditServiceServer(creditService, []string{"default", ""})
+	ctx := context.Background()
+
+	cancelRequest := func(mutate func(request *creditv1.CancelGrantRequest)) *creditv1.CancelGrantRequest {
+		request := &creditv1.CancelGrantRequest{
+			UserId:       "user",
+			TenantId:     "default",
+			LedgerId:     "default",
+			MetadataJson: "{}",
+			Grant:        &creditv1.CancelGrantRequest_GrantIdempotencyKey{GrantIdempotencyKey: "allowance"},
+		}
+		mutate(request)
+		return request
+	}
+	testCases := []struct {
+		name        string
+		request     *creditv1.CancelGrantRequest
+		wantCode    codes.Code
+		wantMessage string
+	}{
+		{
+			name:        "unauthorized tenant",
+			request:     cancelRequest(func(request *creditv1.CancelGrantRequest) { request.TenantId = "unauthorized" }),
+			wantCode:    codes.PermissionDenied,
+			wantMessage: "tenant \"unauthorized\" is not authorized",
+		},
+		{name: "invalid user id", request: cancelRequest(func(request *creditv1.CancelGrantRequest) { request.UserId = "" }), wantCode: codes.InvalidArgument, wantMessage: errorInvalidUserID},
+		{name: "invalid ledger id", request: cancelRequest(func(request *creditv1.CancelGrantRequest) { request.LedgerId = "" }), wantCode: codes.InvalidArgument, wantMessage: errorInvalidLedgerID},
+		{name: "invalid tenant id", request: cancelRequest(func(request *creditv1.CancelGrantRequest) { request.TenantId = "" }), wantCode: codes.InvalidArgument, wantMessage: errorInvalidTenantID},
+		{name: "invalid metadata", request: cancelRequest(func(request *creditv1.CancelGrantRequest) { request.MetadataJson = "{" }), wantCode: codes.InvalidArgument, wantMessage: errorInvalidMetadata},
+		{name: "unknown grant key", request: cancelRequest(func(request *creditv1.CancelGrantRequest) {}), wantCode: codes.NotFound, wantMessage: errorUnknownEntry},
+	}
+	for _, testCase := range testCases {
+		test.Run(testCase.name, func(test *testing.T) {
+			test.Parallel()
+			_, err := server.CancelGrant(ctx, testCase.request)
+			if status.Code(err) != testCase.wantCode || status.Convert(err).Message() != testCase.wantMessage {
+				test.Fatalf("expected %v %q, got %v", testCase.wantCode, testCase.wantMessage, err)
+			}
+		})
+	}
+}
+
+func TestCreditServiceServerReservationIntrospection(test *testing.T) {
+	test.Parallel()
+	creditService, err := newSQLiteLedgerService(test)
+	if err != nil {
+		test.Fatalf("new ledger service: %v", err)
+	}
+	server := NewCreditServiceServer(creditService, []string{"default"})
+
+	ctx := context.Background()
+	userID := "user-123"
+	tenantID := "default"
+	ledgerID := "default"
+
+	if _, err := server.Grant(ctx, &creditv1.GrantRequest{
+		UserId:         userID,
+		TenantId:       tenantID,
+		LedgerId:       ledgerID,
+		AmountCents:    1000,
+		IdempotencyKey: "grant-1",
+		MetadataJson:   "{}",
+	}); err != nil {
+		test.Fatalf("grant: %v", err)
+	}
+
+	if _, err := server.Reserve(ctx, &creditv1.ReserveRequest{
+		UserId:           userID,
+		TenantId:         tenantID,
+		LedgerId:         ledgerID,
+		AmountCents:      300,
+		ReservationId:    "order-1",
+		IdempotencyKey:   "reserve-1",
+		ExpiresAtUnixUtc: 1700000060,
+		MetadataJson:     "{}",
+	}); err != nil {
+		test.Fatalf("reserve: %v", err)
+	}
+
+	activeReservation, err := server.GetReservation(ctx, &creditv1.GetReservationRequest{
+		UserId:        userID,
+		TenantId:      tenantID,
+		LedgerId:      ledgerID,
+		ReservationId: "order-1",
+	})
+	if err != nil {
+		test.Fatalf("get reservation: %v", err)
+	}
+	if activeReservation.GetReservation().GetReservationId() != "order-1" {
+		test.Fatalf("expected reservation_id order-1, got %q", activeReservation.GetReservation().GetReservationId())
+	}
+	if activeReservation.GetReservation().GetAmountCents() != 300 {
+		test.Fatalf("expected amount 300, got %d", activeReservation.GetReservation().GetAmountCents())
+	}
+	if activeReservation.GetReservation().GetStatus() != ledger.ReservationStatusActive.String() {
+		test.Fatalf("expected status active, got %q", activeReservation.GetReservation().GetStatus())
+	}
+	if activeReservation.GetReservation().GetExpiresAtUnixUtc() != 1700000060 {
+		test.Fatalf("expected expires_at_unix_utc 1700000060, got %d", activeReservation.GetReservation().GetExpiresAtUnixUtc())
+	}
+	if activeReservation.GetReservation().GetExpired() {
+		test.Fatalf("expected reservation to be not expired")
 	}
 	if activeReservation.GetReservation().GetHeldCents() != 300 {
 		test.Fatalf("expected held_cents 300, got %d", activeReservation.GetReservation().GetHeldCents())
 	}
-	if activeReservation.GetReservation().GetCapturedCents() != 0 {
-		test.Fatalf("expected captured_cents 0, got %d", activeReservation.GetReservation().GetCapturedCents())
+	if activeReservation.GetReservation().GetCapturedCents() != 0 {
+		test.Fatalf("expected captured_cents 0, got %d", activeReservation.GetReservation().GetCapturedCents())
+	}
+	if activeReservation.GetReservation().GetCreatedUnixUtc() == 0 || activeReservation.GetReservation().GetUpdatedUnixUtc() == 0 {
+		test.Fatalf("expected created/updated unix utc timestamps to be set")
+	}
+	if activeReservation.GetReservation().GetUpdatedUnixUtc() < activeReservation.GetReservation().GetCreatedUnixUtc() {
+		test.Fatalf("expected updated timestamp >= created timestamp")
+	}
+
+	if _, err := server.Capture(ctx, &creditv1.CaptureRequest{
+		UserId:         userID,
+		TenantId:       tenantID,
+		LedgerId:       ledgerID,
+		ReservationId:  "order-1",
+		IdempotencyKey: "capture-1",
+		AmountCents:    300,
+		MetadataJson:   "{}",
+	}); err != nil {
+		test.Fatalf("capture: %v", err)
+	}
+
+	capturedReservation, err := server.GetReservation(ctx, &creditv1.GetReservationRequest{
+		UserId:        userID,
+		TenantId:      tenantID,
+		LedgerId:      ledgerID,
+		ReservationId: "order-1",
+	})
+	if err != nil {
+		test.Fatalf("get reservation captured: %v", err)
+	}
+	if capturedReservation.GetReservation().GetStatus() != ledger.ReservationStatusCaptured.String() {
+		test.Fatalf("expected status captured, got %q", capturedReservation.GetReservation().GetStatus())
+	}
+	if capturedReservation.GetReservation().GetHeldCents() != 0 {
+		test.Fatalf("expected held_cents 0, got %d", capturedReservation.GetReservation().GetHeldCents())
+	}
+	if capturedReservation.GetReservation().GetCapturedCents() != 300 {
+		test.Fatalf("expected captured_cents 300, got %d", capturedReservation.GetReservation().GetCapturedCents())
+	}
+
+	if _, err := server.Reserve(ctx, &creditv1.ReserveRequest{
+		UserId:           userID,
+		TenantId:         tenantID,
+		LedgerId:         ledgerID,
+		AmountCents:      100,
+		ReservationId:    "order-expired",
+		IdempotencyKey:   "reserve-expired",
+		ExpiresAtUnixUtc: 1699999999,
+		MetadataJson:     "{}",
+	}); err != nil {
+		test.Fatalf("reserve expired: %v", err)
+	}
+
+	expiredReservation, err := server.GetReservation(ctx, &creditv1.GetReservationRequest{
+		UserId:        userID,
+		TenantId:      tenantID,
+		LedgerId:      ledgerID,
+		ReservationId: "order-expired",
+	})
+	if err != nil {
+		test.Fatalf("get expired reservation: %v", err)
+	}
+	if expiredReservation.GetReservation().GetStatus() != ledger.ReservationStatusActive.String() {
+		test.Fatalf("expected status active, got %q", expiredReservation.GetReservation().GetStatus())
+	}
+	if !expiredReservation.GetReservation().GetExpired() {
+		test.Fatalf("expected reservation to be expired")
+	}
+	if expiredReservation.GetReservation().GetHeldCents() != 0 {
+		test.Fatalf("expected expired reservation to have held_cents=0, got %d", expiredReservation.GetReservation().GetHeldCents())
+	}
+
+	activeReservations, err := server.ListReservations(ctx, &creditv1.ListReservationsRequest{
+		UserId:               userID,
+		TenantId:             tenantID,
+		LedgerId:             ledgerID,
+		BeforeCreatedUnixUtc: 0,
+		Limit:                10,
+		Statuses:             []string{ledger.ReservationStatusActive.String()},
+	})
+	if err != nil {
+		test.Fatalf("list active reservations: %v", err)
+	}
+	if len(activeReservations.GetReservations()) != 1 {
+		test.Fatalf("expected 1 active reservation, got %d", len(activeReservations.GetReservations()))
 	}
-	if activeReservation.GetReservation().GetCreatedUnixUtc() == 0 || activeReservation.GetReservation().GetUpdatedUnixUtc() == 0 {
-		test.Fatalf("expected created/updated unix utc timestamps to be set")
+	if activeReservations.GetReservations()[0].GetReservationId() != "order-expired" {
+		test.Fatalf("expected active reservation order-expired, got %q", activeReservations.GetReservations()[0].GetReservationId())
 	}
-	if activeReservation.GetReservation().GetUpdatedUnixUtc() < activeReservation.GetReservation().GetCreatedUnixUtc() {
-		test.Fatalf("expected updated timestamp >= created timestamp")
+
+	capturedReservations, err := server.ListReservations(ctx, &creditv1.ListReservationsRequest{
+		UserId:               userID,
+		TenantId:             tenantID,
+		LedgerId:             ledgerID,
+		BeforeCreatedUnixUtc: 0,
+		Limit:                10,
+		Statuses:             []string{ledger.ReservationStatusCaptured.String()},
+	})
+	if err != nil {
+		test.Fatalf("list captured reservations: %v", err)
+	}
+	if len(capturedReservations.GetReservations()) != 1 {
+		test.Fatalf("expected 1 captured reservation, got %d", len(capturedReservations.GetReservations()))
+	}
+	if capturedReservations.GetReservations()[0].GetReservationId() != "order-1" {
+		test.Fatalf("expected captured reservation order-1, got %q", capturedReservations.GetReservations()[0].GetReservationId())
+	}
+	if capturedReservations.GetReservations()[0].GetCapturedCents() != 300 {
+		test.Fatalf("expected captured reservation to report captured_cents=300, got %d", capturedReservations.GetReservations()[0].GetCapturedCents())
 	}
 
-	if _, err := server.Capture(ctx, &creditv1.CaptureRequest{
+	_, err = server.ListReservations(ctx, &creditv1.ListReservationsRequest{
+		UserId:   userID,
+		TenantId: tenantID,
+		LedgerId: ledgerID,
+		Statuses: []string{"not-a-status"},
+	})
+	if status.Code(err) != codes.InvalidArgument {
+		test.Fatalf("expected invalid argument, got %v", status.Code(err))
+	}
+	if status.Convert(err).Message() != errorInvalidReservationStatus {
+		test.Fatalf("expected %q, got %q", errorInvalidReservationStatus, status.Convert(err).Message())
+	}
+}
+
+func TestCreditServiceServerReservationIntrospectionValidationErrors(test *testing.T) {
+	test.Parallel()
+	creditService, err := newSQLiteLedgerService(test)
+	if err != nil {
+		test.Fatalf("new ledger service: %v", err)
+	}
+	server := NewCreditServiceServer(creditService, []string{"default"})
+	ctx := context.Background()
+
+	testCases := []struct {
+		name        string
+		invoke      func() error
+		wantCode    codes.Code
+		wantMessage string
+	}{
+		{
+			name: "get reservation invalid user id",
+			invoke: func() error {
+				_, err := server.GetReservation(ctx, &creditv1.GetReservationRequest{
+					UserId:        " ",
+					TenantId:      "default",
+					LedgerId:      "default",
+					ReservationId: "order-1",
+				})
+				return err
+			},
+			wantCode:    codes.InvalidArgument,
+			wantMessage: errorInvalidUserID,
+		},
+		{
+			name: "get reservation invalid ledger id",
+			invoke: func() error {
+				_, err := server.GetReservation(ctx, &creditv1.GetReservationRequest{
+					UserId:        "user-123",
+					TenantId:      "default",
+					LedgerId:      " ",
+					ReservationId: "order-1",
+				})
+				return err
+			},
+			wantCode:    codes.InvalidArgument,
+			wantMessage: errorInvalidLedgerID,
+		},
+		{
+			name: "get reservation invalid tenant id",
+			invoke: func() error {
+				_, err := server.GetReservation(ctx, &creditv1.GetReservationRequest{
+					UserId:        "user-123",
+					TenantId:      "unauthorized",
+					LedgerId:      "default",
+					ReservationId: "order-1",
+				})
+				return err
+			},
+			wantCode:    codes.PermissionDenied,
+			wantMessage: "tenant \"unauthorized\" is not authorized",
+		},
+		{
+			name: "get reservation invalid reservation id",
+			invoke: func() error {
+				_, err := server.GetReservation(ctx, &creditv1.GetReservationRequest{
+					UserId:        "user-123",
+					TenantId:      "default",
+					LedgerId:      "default",
+					ReservationId: " ",
+				})
+				return err
+			},
+			wantCode:    codes.InvalidArgument,
+			wantMessage: errorInvalidReservationID,
+		},
+		{
+			name: "list reservations invalid user id",
+			invoke: func() error {
+				_, err := server.ListReservations(ctx, &creditv1.ListReservationsRequest{
+					UserId:   " ",
+					TenantId: "default",
+					LedgerId: "default",
+				})
+				return err
+			},
+			wantCode:    codes.InvalidArgument,
+			wantMessage: errorInvalidUserID,
+		},
+		{
+			name: "list reservations invalid ledger id",
+			invoke: func() error {
+				_, err := server.ListReservations(ctx, &creditv1.ListReservationsRequest{
+					UserId:   "user-123",
+					TenantId: "default",
+					LedgerId: " ",
+				})
+				return err
+			},
+			wantCode:    codes.InvalidArgument,
+			wantMessage: errorInvalidLedgerID,
+		},
+		{
+			name: "list reservations invalid tenant id",
+			invoke: func() error {
+				_, err := server.ListReservations(ctx, &creditv1.ListReservationsRequest{
+					UserId:   "user-123",
+					TenantId: "unauthorized",
+					LedgerId: "default",
+				})
+				return err
+			},
+			wantCode:    codes.PermissionDenied,
+			wantMessage: "tenant \"unauthorized\" is not authorized",
+		},
+		{
+			name: "list reservations invalid limit",
+			invoke: func() error {
+				_, err := server.ListReservations(ctx, &creditv1.ListReservationsRequest{
+					UserId:   "user-123",
+					TenantId: "default",
+					LedgerId: "default",
+					Limit:    maxListEntriesLimit + 1,
+				})
+				return err
+			},
+			wantCode:    codes.InvalidArgument,
+			wantMessage: errorInvalidListLimit,
+		},
+		{
+			name: "list reservations invalid status filter",
+			invoke: func() error {
+				_, err := server.ListReservations(ctx, &creditv1.ListReservationsRequest{
+					UserId:   "user-123",
+					TenantId: "default",
+					LedgerId: "default",
+					Statuses: []string{"not-a-status"},
+				})
+				return err
+			},
+			wantCode:    codes.InvalidArgument,
+			wantMessage: errorInvalidReservationStatus,
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		test.Run(testCase.name, func(test *testing.T) {
+			test.Parallel()
+			err := testCase.invoke()
+			if status.Code(err) != testCase.wantCode {
+				test.Fatalf("expected %v, got %v", testCase.wantCode, status.Code(err))
+			}
+			if status.Convert(err).Message() != testCase.wantMessage {
+				test.Fatalf("expected %q, got %q", testCase.wantMessage, status.Convert(err).Message())
+			}
+		})
+	}
+}
+
+func TestCreditServiceServerGetReservationUnknownReservation(test *testing.T) {
+	test.Parallel()
+	creditService, err := newSQLiteLedgerService(test)
+	if err != nil {
+		test.Fatalf("new ledger service: %v", err)
+	}
+	server := NewCreditServiceServer(creditService, []string{"default"})
+
+	_, err = server.GetReservation(context.Background(), &creditv1.GetReservationRequest{
+		UserId:        "user-123",
+		TenantId:      "default",
+		LedgerId:      "default",
+		ReservationId: "order-unknown",
+	})
+	if status.Code(err) != codes.NotFound {
+		test.Fatalf("expected not found, got %v", status.Code(err))
+	}
+	if status.Convert(err).Message() != errorUnknownReservation {
+		test.Fatalf("expected %q, got %q", errorUnknownReservation, status.Convert(err).Message())
+	}
+}
+
+func TestCreditServiceServerRefundSpendFlow(test *testing.T) {
+	test.Parallel()
+	creditService, err := newSQLiteLedgerService(test)
+	if err != nil {
+		test.Fatalf("new ledger service: %v", err)
+	}
+	server := NewCreditServiceServer(creditService, []string{"default"})
+
+	ctx := context.Background()
+	userID := "user-123"
+	tenantID := "default"
+	ledgerID := "default"
+
+	if _, err := server.Grant(ctx, &creditv1.GrantRequest{
 		UserId:         userID,
 		TenantId:       tenantID,
 		LedgerId:       ledgerID,
-		ReservationId:  "order-1",
-		IdempotencyKey: "capture-1",
-		AmountCents:    300,
+		AmountCents:    1000,
+		IdempotencyKey: "grant-1",
 		MetadataJson:   "{}",
 	}); err != nil {
-		test.Fatalf("capture: %v", err)
+		test.Fatalf("grant: %v", err)
+	}
+
+	spendResponse, err := server.Spend(ctx, &creditv1.SpendRequest{
+		UserId:         userID,
+		TenantId:       tenantID,
+		LedgerId:       ledgerID,
+		AmountCents:    200,
+		IdempotencyKey: "spend-1",
+		MetadataJson:   "{}",
+	})
+	if err != nil {
+		test.Fatalf("spend: %v", err)
 	}
 
-	capturedReservation, err := server.GetReservation(ctx, &creditv1.GetReservationRequest{
-		UserId:        userID,
-		TenantId:      tenantID,
-		LedgerId:      ledgerID,
-		ReservationId: "order-1",
+	refundResponse, err := server.Refund(ctx, &creditv1.RefundRequest{
+		UserId:         userID,
+		TenantId:       tenantID,
+		LedgerId:       ledgerID,
+		Original:       &creditv1.RefundRequest_OriginalEntryId{OriginalEntryId: spendResponse.GetEntryId()},
+		AmountCents:    50,
+		IdempotencyKey: "refund-1",
+		MetadataJson:   "{}",
 	})
 	if err != nil {
-		test.Fatalf("get reservation captured: %v", err)
-	}
-	if capturedReservation.GetReservation().GetStatus() != ledger.ReservationStatusCaptured.String() {
-		test.Fatalf("expected status captured, got %q", capturedReservation.GetReservation().GetStatus())
+		test.Fatalf("refund: %v", err)
 	}
-	if capturedReservation.GetReservation().GetHeldCents() != 0 {
-		test.Fatalf("expected held_cents 0, got %d", capturedReservation.GetReservation().GetHeldCents())
+	if refundResponse.GetEntryId() == "" {
+		test.Fatalf("expected refund entry id")
 	}
-	if capturedReservation.GetReservation().GetCapturedCents() != 300 {
-		test.Fatalf("expected captured_cents 300, got %d", capturedReservation.GetReservation().GetCapturedCents())
+	if refundResponse.GetCreatedUnixUtc() != 1700000000 {
+		test.Fatalf("expected created unix utc 1700000000, got %d", refundResponse.GetCreatedUnixUtc())
 	}
 
-	if _, err := server.Reserve(ctx, &creditv1.ReserveRequest{
-		UserId:           userID,
-		TenantId:         tenantID,
-		LedgerId:         ledgerID,
-		AmountCents:      100,
-		ReservationId:    "order-expired",
-		IdempotencyKey:   "reserve-expired",
-		ExpiresAtUnixUtc: 1699999999,
-		MetadataJson:     "{}",
-	}); err != nil {
-		test.Fatalf("reserve expired: %v", err)
+	balanceResponse, err := server.GetBalance(ctx, &creditv1.BalanceRequest{
+		UserId:   userID,
+		TenantId: tenantID,
+		LedgerId: ledgerID,
+	})
+	if err != nil {
+		test.Fatalf("get balance: %v", err)
+	}
+	if balanceResponse.GetTotalCents() != 850 || balanceResponse.GetAvailableCents() != 850 {
+		test.Fatalf("expected 850/850, got total=%d available=%d", balanceResponse.GetTotalCents(), balanceResponse.GetAvailableCents())
 	}
 
-	expiredReservation, err := server.GetReservation(ctx, &creditv1.GetReservationRequest{
-		UserId:        userID,
-		TenantId:      tenantID,
-		LedgerId:      ledgerID,
-		ReservationId: "order-expired",
+	listRefunds, err := server.ListEntries(ctx, &creditv1.ListEntriesRequest{
+		UserId:   userID,
+		TenantId: tenantID,
+		LedgerId: ledgerID,
+		Types:    []string{"refund"},
+		Limit:    10,
 	})
 	if err != nil {
-		test.Fatalf("get expired reservation: %v", err)
+		test.Fatalf("list refunds: %v", err)
 	}
-	if expiredReservation.GetReservation().GetStatus() != ledger.ReservationStatusActive.String() {
-		test.Fatalf("expected status active, got %q", expiredReservation.GetReservation().GetStatus())
+	if len(listRefunds.GetEntries()) != 1 {
+		test.Fatalf("expected one refund entry, got %d", len(listRefunds.GetEntries()))
 	}
-	if !expiredReservation.GetReservation().GetExpired() {
-		test.Fatalf("expected reservation to be expired")
+	if listRefunds.GetEntries()[0].GetRefundOfEntryId() != spendResponse.GetEntryId() {
+		test.Fatalf("expected refund_of_entry_id %q, got %q", spendResponse.GetEntryId(), listRefunds.GetEntries()[0].GetRefundOfEntryId())
 	}
-	if expiredReservation.GetReservation().GetHeldCents() != 0 {
-		test.Fatalf("expected expired reservation to have held_cents=0, got %d", expiredReservation.GetReservation().GetHeldCents())
+}
+
+func TestCreditServiceServerSpendAndRefundAcrossLedgersFlow(test *testing.T) {
+	test.Parallel()
+	creditService, err := newSQLiteLedgerService(test)
+	if err != nil {
+		test.Fatalf("new ledger service: %v", err)
 	}
+	server := NewCreditServiceServer(creditService, []string{"default"})
 
-	activeReservations, err := server.ListReservations(ctx, &creditv1.ListReservationsRequest{
-		UserId:               userID,
-		TenantId:             tenantID,
-		LedgerId:             ledgerID,
-		BeforeCreatedUnixUtc: 0,
-		Limit:                10,
-		Statuses:             []string{ledger.ReservationStatusActive.String()},
+	ctx := context.Background()
+	userID := "user-123"
+	tenantID := "default"
+	ledgerIDs := []string{"promo", "purchased"}
+
+	for index, grantAmount := range []int64{300, 1000} {
+		if _, err := server.Grant(ctx, &creditv1.GrantRequest{
+			UserId:         userID,
+			TenantId:       tenantID,
+			LedgerId:       ledgerIDs[index],
+			AmountCents:    grantAmount,
+			IdempotencyKey: "grant-" + ledgerIDs[index],
+			MetadataJson:   "{}",
+		}); err != nil {
+			test.Fatalf("grant %s: %v", ledgerIDs[index], err)
+		}
+	}
+
+	spendResponse, err := server.SpendAcrossLedgers(ctx, &creditv1.SpendAcrossLedgersRequest{
+		UserId:         userID,
+		TenantId:       tenantID,
+		LedgerIds:      ledgerIDs,
+		AmountCents:    500,
+		IdempotencyKey: "spend-1",
+		MetadataJson:   "{}",
 	})
 	if err != nil {
-		test.Fatalf("list active reservations: %v", err)
+		test.Fatalf("spend across ledgers: %v", err)
 	}
-	if len(activeReservations.GetReservations()) != 1 {
-		test.Fatalf("expected 1 active reservation, got %d", len(activeReservations.GetReservations()))
+	debits := spendResponse.GetDebits()
+	if len(debits) != 2 || debits[0].GetLedgerId() != "promo" || debits[0].GetAmountCents() != 300 || debits[1].GetLedgerId() != "purchased" || debits[1].GetAmountCents() != 200 {
+		test.Fatalf("unexpected debits: %+v", debits)
 	}
-	if activeReservations.GetReservations()[0].GetReservationId() != "order-expired" {
-		test.Fatalf("expected active reservation order-expired, got %q", activeReservations.GetReservations()[0].GetReservationId())
+
+	_, err = server.SpendAcrossLedgers(ctx, &creditv1.SpendAcrossLedgersRequest{
+		UserId:         userID,
+		TenantId:       tenantID,
+		LedgerIds:      ledgerIDs,
+		AmountCents:    5000,
+		IdempotencyKey: "spend-2",
+		MetadataJson:   "{}",
+	})
+	if status.Code(err) != codes.FailedPrecondition {
+		test.Fatalf("expected failed precondition for insufficient funds, got %v", err)
 	}
 
-	capturedReservations, err := server.ListReservations(ctx, &creditv1.ListReservationsRequest{
-		UserId:               userID,
-		TenantId:             tenantID,
-		LedgerId:             ledgerID,
-		BeforeCreatedUnixUtc: 0,
-		Limit:                10,
-		Statuses:             []string{ledger.ReservationStatusCaptured.String()},
+	refundResponse, err := server.RefundAcrossLedgers(ctx, &creditv1.RefundAcrossLedgersRequest{
+		UserId:                 userID,
+		TenantId:               tenantID,
+		LedgerIds:              ledgerIDs,
+		OriginalIdempotencyKey: "spend-1",
+		AmountCents:            250,
+		IdempotencyKey:         "refund-1",
+		MetadataJson:           "{}",
 	})
 	if err != nil {
-		test.Fatalf("list captured reservations: %v", err)
+		test.Fatalf("refund across ledgers: %v", err)
 	}
-	if len(capturedReservations.GetReservations()) != 1 {
-		test.Fatalf("expected 1 captured reservation, got %d", len(capturedReservations.GetReservations()))
+	refunds := refundResponse.GetRefunds()
+	if len(refunds) != 2 || refunds[0].GetLedgerId() != "purchased" || refunds[0].GetAmountCents() != 200 || refunds[0].GetRefundOfEntryId() != debits[1].GetEntryId() {
+		test.Fatalf("unexpected purchased refund: %+v", refunds)
 	}
-	if capturedReservations.GetReservations()[0].GetReservationId() != "order-1" {
-		test.Fatalf("expected captured reservation order-1, got %q", capturedReservations.GetReservations()[0].GetReservationId())
+	if refunds[1].GetLedgerId() != "promo" || refunds[1].GetAmountCents() != 50 || refunds[1].GetRefundOfEntryId() != debits[0].GetEntryId() {
+		test.Fatalf("unexpected promo refund: %+v", refunds)
 	}
-	if capturedReservations.GetReservations()[0].GetCapturedCents() != 300 {
-		test.Fatalf("expected captured reservation to report captured_cents=300, got %d", capturedReservations.GetReservations()[0].GetCapturedCents())
+
+	expectedTotals := map[string]int64{"promo": 50, "purchased": 1000}
+	for ledgerID, expectedTotal := range expectedTotals {
+		balanceResponse, err := server.GetBalance(ctx, &creditv1.BalanceRequest{UserId: userID, TenantId: tenantID, LedgerId: ledgerID})
+		if err != nil {
+			test.Fatalf("get balance %s: %v", ledgerID, err)
+		}
+		if balanceResponse.GetTotalCents() != expectedTotal {
+			test.Fatalf("expected %s total %d, got %d", ledgerID, expectedTotal, balanceResponse.GetTotalCents())
+		}
 	}
 
-	_, err = server.ListReservations(ctx, &creditv1.ListReservationsRequest{
-		UserId:   userID,
-		TenantId: tenantID,
-		LedgerId: ledgerID,
-		Statuses: []string{"not-a-status"},
+	_, err = server.SpendAcrossLedgers(ctx, &creditv1.SpendAcrossLedgersRequest{
+		UserId:         userID,
+		TenantId:       tenantID,
+		LedgerIds:      []string{"promo", "promo"},
+		AmountCents:    10,
+		IdempotencyKey: "spend-3",
+		MetadataJson:   "{}",
 	})
 	if status.Code(err) != codes.InvalidArgument {
-		test.Fatalf("expected invalid argument, got %v", status.Code(err))
-	}
-	if status.Convert(err).Message() != errorInvalidReservationStatus {
-		test.Fatalf("expected %q, got %q", errorInvalidReservationStatus, status.Convert(err).Message())
+		test.Fatalf("expected invalid argument for duplicate ledger ids, got %v", err)
+	}
+	_, err = server.RefundAcrossLedgers(ctx, &creditv1.RefundAcrossLedgersRequest{
+		UserId:                 userID,
+		TenantId:               tenantID,
+		LedgerIds:              ledgerIDs,
+		OriginalIdempotencyKey: "missing",
+		AmountCents:            10,
+		IdempotencyKey:         "refund-2",
+		MetadataJson:           "{}",
+	})
+	if status.Code(err) != codes.NotFound {
+		test.Fatalf("expected not found for unknown original, got %v", err)
 	}
 }
 
-func TestCreditServiceServerReservationIntrospectionValidationErrors(test *testing.T) {
+func TestCreditServiceServerAcrossLedgersValidationErrors(test *testing.T) {
 	test.Parallel()
 	creditService, err := newSQLiteLedgerService(test)
 	if err != nil {
 		test.Fatalf("new ledger service: %v", err)
 	}
-	server := NewCreditServiceServer(creditService, []string{"default"})
+	server := NewCreditServiceServer(creditService, []string{"default", ""})
 	ctx := context.Background()
 
+	spendRequest := func(mutate func(request *creditv1.SpendAcrossLedgersRequest)) *creditv1.SpendAcrossLedgersRequest {
+		request := &creditv1.SpendAcrossLedgersRequest{
+			UserId:         "user",
+			TenantId:       "default",
+			LedgerIds:      []string{"promo", "purchased"},
+			AmountCents:    10,
+			IdempotencyKey: "spend-1",
+			MetadataJson:   "{}",
+		}
+		mutate(request)
+		return request
+	}
+	refundRequest := func(mutate func(request *creditv1.RefundAcrossLedgersRequest)) *creditv1.RefundAcrossLedgersRequest {
+		request := &creditv1.RefundAcrossLedgersRequest{
+			UserId:                 "user",
+			TenantId:               "default",
+			LedgerIds:              []string{"promo", "purchased"},
+			OriginalIdempotencyKey: "spend-1",
+			AmountCents:            10,
+			IdempotencyKey:         "refund-1",
+			MetadataJson:           "{}",
+		}
+		mutate(request)
+		return request
+	}
+
 	testCases := []struct {
 		name        string
 		invoke      func() error
@@ -685,249 +2074,201 @@ func TestCreditServiceServerReservationIntrospectionValidationErrors(test *testi
 		wantMessage string
 	}{
 		{
-			name: "get reservation invalid user id",
+			name: "spend unauthorized tenant",
 			invoke: func() error {
-				_, err := server.GetReservation(ctx, &creditv1.GetReservationRequest{
-					UserId:        " ",
-					TenantId:      "default",
-					LedgerId:      "default",
-					ReservationId: "order-1",
-				})
+				_, err := server.SpendAcrossLedgers(ctx, spendRequest(func(request *creditv1.SpendAcrossLedgersRequest) { request.TenantId = "unauthorized" }))
 				return err
 			},
-			wantCode:    codes.InvalidArgument,
-			wantMessage: errorInvalidUserID,
+			wantCode: codes.PermissionDenied, wantMessage: "tenant \"unauthorized\" is not authorized",
 		},
 		{
-			name: "get reservation invalid ledger id",
+			name: "spend invalid user id",
 			invoke: func() error {
-				_, err := server.GetReservation(ctx, &creditv1.GetReservationRequest{
-					UserId:        "user-123",
-					TenantId:      "default",
-					LedgerId:      " ",
-					ReservationId: "order-1",
-				})
+				_, err := server.SpendAcrossLedgers(ctx, spendRequest(func(request *creditv1.SpendAcrossLedgersRequest) { request.UserId = "" }))
 				return err
 			},
-			wantCode:    codes.InvalidArgument,
-			wantMessage: errorInvalidLedgerID,
+			wantCode: codes.InvalidArgument, wantMessage: errorInvalidUserID,
 		},
 		{
-			name: "get reservation invalid tenant id",
+			name: "spend invalid ledger id",
 			invoke: func() error {
-				_, err := server.GetReservation(ctx, &creditv1.GetReservationRequest{
-					UserId:        "user-123",
-					TenantId:      "unauthorized",
-					LedgerId:      "default",
-					ReservationId: "order-1",
-				})
+				_, err := server.SpendAcrossLedgers(ctx, spendRequest(func(request *creditv1.SpendAcrossLedgersRequest) { request.LedgerIds = []string{"promo", ""} }))
 				return err
 			},
-			wantCode:    codes.PermissionDenied,
-			wantMessage: "tenant \"unauthorized\" is not authorized",
+			wantCode: codes.InvalidArgument, wantMessage: errorInvalidLedgerID,
 		},
 		{
-			name: "get reservation invalid reservation id",
+			name: "spend invalid tenant id",
 			invoke: func() error {
-				_, err := server.GetReservation(ctx, &creditv1.GetReservationRequest{
-					UserId:        "user-123",
-					TenantId:      "default",
-					LedgerId:      "default",
-					ReservationId: " ",
-				})
+				_, err := server.SpendAcrossLedgers(ctx, spendRequest(func(request *creditv1.SpendAcrossLedgersRequest) { request.TenantId = "" }))
 				return err
 			},
-			wantCode:    codes.InvalidArgument,
-			wantMessage: errorInvalidReservationID,
+			wantCode: codes.InvalidArgument, wantMessage: errorInvalidTenantID,
 		},
 		{
-			name: "list reservations invalid user id",
+			name: "spend invalid amount",
 			invoke: func() error {
-				_, err := server.ListReservations(ctx, &creditv1.ListReservationsRequest{
-					UserId:   " ",
-					TenantId: "default",
-					LedgerId: "default",
-				})
+				_, err := server.SpendAcrossLedgers(ctx, spendRequest(func(request *creditv1.SpendAcrossLedgersRequest) { request.AmountCents = 0 }))
 				return err
 			},
-			wantCode:    codes.InvalidArgument,
-			wantMessage: errorInvalidUserID,
+			wantCode: codes.InvalidArgument, wantMessage: errorInvalidAmount,
 		},
 		{
-			name: "list reservations invalid ledger id",
+			name: "spend invalid idempotency key",
 			invoke: func() error {
-				_, err := server.ListReservations(ctx, &creditv1.ListReservationsRequest{
-					UserId:   "user-123",
-					TenantId: "default",
-					LedgerId: " ",
-				})
+				_, err := server.SpendAcrossLedgers(ctx, spendRequest(func(request *creditv1.SpendAcrossLedgersRequest) { request.IdempotencyKey = "" }))
 				return err
 			},
-			wantCode:    codes.InvalidArgument,
-			wantMessage: errorInvalidLedgerID,
+			wantCode: codes.InvalidArgument, wantMessage: errorInvalidIdempotencyKey,
+		},
+		{
+			name: "spend invalid metadata",
+			invoke: func() error {
+				_, err := server.SpendAcrossLedgers(ctx, spendRequest(func(request *creditv1.SpendAcrossLedgersRequest) { request.MetadataJson = "{" }))
+				return err
+			},
+			wantCode: codes.InvalidArgument, wantMessage: errorInvalidMetadata,
+		},
+		{
+			name: "spend insufficient funds",
+			invoke: func() error {
+				_, err := server.SpendAcrossLedgers(ctx, spendRequest(func(request *creditv1.SpendAcrossLedgersRequest) {}))
+				return err
+			},
+			wantCode: codes.FailedPrecondition, wantMessage: errorInsufficientFunds,
+		},
+		{
+			name: "refund unauthorized tenant",
+			invoke: func() error {
+				_, err := server.RefundAcrossLedgers(ctx, refundRequest(func(request *creditv1.RefundAcrossLedgersRequest) { request.TenantId = "unauthorized" }))
+				return err
+			},
+			wantCode: codes.PermissionDenied, wantMessage: "tenant \"unauthorized\" is not authorized",
+		},
+		{
+			name: "refund invalid user id",
+			invoke: func() error {
+				_, err := server.RefundAcrossLedgers(ctx, refundRequest(func(request *creditv1.RefundAcrossLedgersRequest) { request.UserId = "" }))
+				return err
+			},
+			wantCode: codes.InvalidArgument, wantMessage: errorInvalidUserID,
+		},
+		{
+			name: "refund invalid ledger id",
+			invoke: func() error {
+				_, err := server.RefundAcrossLedgers(ctx, refundRequest(func(request *creditv1.RefundAcrossLedgersRequest) { request.LedgerIds = []string{""} }))
+				return err
+			},
+			wantCode: codes.InvalidArgument, wantMessage: errorInvalidLedgerID,
+		},
+		{
+			name: "refund invalid tenant id",
+			invoke: func() error {
+				_, err := server.RefundAcrossLedgers(ctx, refundRequest(func(request *creditv1.RefundAcrossLedgersRequest) { request.TenantId = "" }))
+				return err
+			},
+			wantCode: codes.InvalidArgument, wantMessage: errorInvalidTenantID,
+		},
+		{
+			name: "refund invalid original idempotency key",
+			invoke: func() error {
+				_, err := server.RefundAcrossLedgers(ctx, refundRequest(func(request *creditv1.RefundAcrossLedgersRequest) { request.OriginalIdempotencyKey = "" }))
+				return err
+			},
+			wantCode: codes.InvalidArgument, wantMessage: errorInvalidIdempotencyKey,
+		},
+		{
+			name: "refund invalid amount",
+			invoke: func() error {
+				_, err := server.RefundAcrossLedgers(ctx, refundRequest(func(request *creditv1.RefundAcrossLedgersRequest) { request.AmountCents = -1 }))
+				return err
+			},
+			wantCode: codes.InvalidArgument, wantMessage: errorInvalidAmount,
 		},
 		{
-			name: "list reservations invalid tenant id",
+			name: "refund invalid idempotency key",
 			invoke: func() error {
-				_, err := server.ListReservations(ctx, &creditv1.ListReservationsRequest{
-					UserId:   "user-123",
-					TenantId: "unauthorized",
-					LedgerId: "default",
-				})
+				_, err := server.RefundAcrossLedgers(ctx, refundRequest(func(request *creditv1.RefundAcrossLedgersRequest) { request.IdempotencyKey = "" }))
 				return err
 			},
-			wantCode:    codes.PermissionDenied,
-			wantMessage: "tenant \"unauthorized\" is not authorized",
+			wantCode: codes.InvalidArgument, wantMessage: errorInvalidIdempotencyKey,
 		},
 		{
-			name: "list reservations invalid limit",
+			name: "refund invalid metadata",
 			invoke: func() error {
-				_, err := server.ListReservations(ctx, &creditv1.ListReservationsRequest{
-					UserId:   "user-123",
-					TenantId: "default",
-					LedgerId: "default",
-					Limit:    maxListEntriesLimit + 1,
-				})
+				_, err := server.RefundAcrossLedgers(ctx, refundRequest(func(request *creditv1.RefundAcrossLedgersRequest) { request.MetadataJson = "{" }))
 				return err
 			},
-			wantCode:    codes.InvalidArgument,
-			wantMessage: errorInvalidListLimit,
+			wantCode: codes.InvalidArgument, wantMessage: errorInvalidMetadata,
 		},
 		{
-			name: "list reservations invalid status filter",
+			name: "refund unknown original",
 			invoke: func() error {
-				_, err := server.ListReservations(ctx, &creditv1.ListReservationsRequest{
-					UserId:   "user-123",
-					TenantId: "default",
-					LedgerId: "default",
-					Statuses: []string{"not-a-status"},
-				})
+				_, err := server.RefundAcrossLedgers(ctx, refundRequest(func(request *creditv1.RefundAcrossLedgersRequest) {}))
 				return err
 			},
-			wantCode:    codes.InvalidArgument,
-			wantMessage: errorInvalidReservationStatus,
+			wantCode: codes.NotFound, wantMessage: errorUnknownEntry,
 		},
 	}
 
 	for _, testCase := range testCases {
-		testCase := testCase
 		test.Run(testCase.name, func(test *testing.T) {
-			test.Parallel()
 			err := testCase.invoke()
 			if status.Code(err) != testCase.wantCode {
-				test.Fatalf("expected %v, got %v", testCase.wantCode, status.Code(err))
+				test.Fatalf("expected code %v, got %v (%v)", testCase.wantCode, status.Code(err), err)
 			}
 			if status.Convert(err).Message() != testCase.wantMessage {
-				test.Fatalf("expected %q, got %q", testCase.wantMessage, status.Convert(err).Message())
+				test.Fatalf("expected message %q, got %q", testCase.wantMessage, status.Convert(err).Message())
 			}
 		})
 	}
 }
 
-func TestCreditServiceServerGetReservationUnknownReservation(test *testing.T) {
+func TestCreditServiceServerSpendAcrossLedgersRejectsSplitSpendAboveMaximum(test *testing.T) {
 	test.Parallel()
-	creditService, err := newSQLiteLedgerService(test)
+	db, err := newSQLiteLedgerDB(test)
 	if err != nil {
-		test.Fatalf("new ledger service: %v", err)
-	}
-	server := NewCreditServiceServer(creditService, []string{"default"})
-
-	_, err = server.GetReservation(context.Background(), &creditv1.GetReservationRequest{
-		UserId:        "user-123",
-		TenantId:      "default",
-		LedgerId:      "default",
-		ReservationId: "order-unknown",
-	})
-	if status.Code(err) != codes.NotFound {
-		test.Fatalf("expected not found, got %v", status.Code(err))
-	}
-	if status.Convert(err).Message() != errorUnknownReservation {
-		test.Fatalf("expected %q, got %q", errorUnknownReservation, status.Convert(err).Message())
+		test.Fatalf("new ledger db: %v", err)
 	}
-}
-
-func TestCreditServiceServerRefundSpendFlow(test *testing.T) {
-	test.Parallel()
-	creditService, err := newSQLiteLedgerService(test)
+	tenantID, err := ledger.NewTenantID("default")
 	if err != nil {
-		test.Fatalf("new ledger service: %v", err)
+		test.Fatalf("tenant id: %v", err)
 	}
-	server := NewCreditServiceServer(creditService, []string{"default"})
-
-	ctx := context.Background()
-	userID := "user-123"
-	tenantID := "default"
-	ledgerID := "default"
-
-	if _, err := server.Grant(ctx, &creditv1.GrantRequest{
-		UserId:         userID,
-		TenantId:       tenantID,
-		LedgerId:       ledgerID,
-		AmountCents:    1000,
-		IdempotencyKey: "grant-1",
-		MetadataJson:   "{}",
-	}); err != nil {
-		test.Fatalf("grant: %v", err)
+	ledgerPolicies := make([]ledger.LedgerPolicy, 0, 2)
+	for _, rawLedgerID := range []string{"promo", "purchased"} {
+		ledgerID, err := ledger.NewLedgerID(rawLedgerID)
+		if err != nil {
+			test.Fatalf("ledger id: %v", err)
+		}
+		ledgerPolicy, err := ledger.NewLedgerPolicy(ledgerID, ledger.LedgerPolicySettings{MaxSpendAmountCents: 300})
+		if err != nil {
+			test.Fatalf("ledger policy: %v", err)
+		}
+		ledgerPolicies = append(ledgerPolicies, ledgerPolicy)
 	}
-
-	spendResponse, err := server.Spend(ctx, &creditv1.SpendRequest{
-		UserId:         userID,
-		TenantId:       tenantID,
-		LedgerId:       ledgerID,
-		AmountCents:    200,
-		IdempotencyKey: "spend-1",
-		MetadataJson:   "{}",
-	})
+	tenantPolicy, err := ledger.NewTenantPolicy(tenantID, false, ledgerPolicies...)
 	if err != nil {
-		test.Fatalf("spend: %v", err)
+		test.Fatalf("tenant policy: %v", err)
 	}
-
-	refundResponse, err := server.Refund(ctx, &creditv1.RefundRequest{
-		UserId:         userID,
-		TenantId:       tenantID,
-		LedgerId:       ledgerID,
-		Original:       &creditv1.RefundRequest_OriginalEntryId{OriginalEntryId: spendResponse.GetEntryId()},
-		AmountCents:    50,
-		IdempotencyKey: "refund-1",
-		MetadataJson:   "{}",
-	})
+	creditService, err := ledger.NewService(gormstore.New(db), func() int64 { return 1700000000 }, ledger.WithTenantPolicies(tenantPolicy))
 	if err != nil {
-		test.Fatalf("refund: %v", err)
-	}
-	if refundResponse.GetEntryId() == "" {
-		test.Fatalf("expected refund entry id")
-	}
-	if refundResponse.GetCreatedUnixUtc() != 1700000000 {
-		test.Fatalf("expected created unix utc 1700000000, got %d", refundResponse.GetCreatedUnixUtc())
+		test.Fatalf("new ledger service: %v", err)
 	}
+	server := NewCreditServiceServer(creditService, []string{"default"})
+	ctx := context.Background()
 
-	balanceResponse, err := server.GetBalance(ctx, &creditv1.BalanceRequest{
-		UserId:   userID,
-		TenantId: tenantID,
-		LedgerId: ledgerID,
-	})
-	if err != nil {
-		test.Fatalf("get balance: %v", err)
-	}
-	if balanceResponse.GetTotalCents() != 850 || balanceResponse.GetAvailableCents() != 850 {
-		test.Fatalf("expected 850/850, got total=%d available=%d", balanceResponse.GetTotalCents(), balanceResponse.GetAvailableCents())
+	for _, ledgerID := range []string{"promo", "purchased"} {
+		if _, err := server.Grant(ctx, &creditv1.GrantRequest{
+			UserId: "user", TenantId: "default", LedgerId: ledgerID, AmountCents: 300, IdempotencyKey: "grant-" + ledgerID, MetadataJson: "{}",
+		}); err != nil {
+			test.Fatalf("grant %s: %v", ledgerID, err)
+		}
 	}
-
-	listRefunds, err := server.ListEntries(ctx, &creditv1.ListEntriesRequest{
-		UserId:   userID,
-		TenantId: tenantID,
-		LedgerId: ledgerID,
-		Types:    []string{"refund"},
-		Limit:    10,
+	_, err = server.SpendAcrossLedgers(ctx, &creditv1.SpendAcrossLedgersRequest{
+		UserId: "user", TenantId: "default", LedgerIds: []string{"promo", "purchased"}, AmountCents: 600, IdempotencyKey: "spend-1", MetadataJson: "{}",
 	})
-	if err != nil {
-		test.Fatalf("list refunds: %v", err)
-	}
-	if len(listRefunds.GetEntries()) != 1 {
-		test.Fatalf("expected one refund entry, got %d", len(listRefunds.GetEntries()))
-	}
-	if listRefunds.GetEntries()[0].GetRefundOfEntryId() != spendResponse.GetEntryId() {
-		test.Fatalf("expected refund_of_entry_id %q, got %q", spendResponse.GetEntryId(), listRefunds.GetEntries()[0].GetRefundOfEntryId())
+	if status.Code(err) != codes.InvalidArgument || status.Convert(err).Message() != errorAmountLimitExceeded {
+		test.Fatalf("expected invalid argument %q, got %v", errorAmountLimitExceeded, err)
 	}
 }
 
@@ -2073,98 +3414,314 @@ func TestCreditServiceServerBatchRefundOverRefundRejected(test *testing.T) {
 		},
 	})
 	if err != nil {
-		test.Fatalf("batch: %v", err)
+		test.Fatalf("batch: %v", err)
+	}
+	results := batchResponse.GetResults()
+	if len(results) != 4 {
+		test.Fatalf("expected 4 results, got %d", len(results))
+	}
+	if !results[2].GetOk() || results[2].GetEntryId() == "" {
+		test.Fatalf("unexpected refund-1 result: ok=%v entry_id=%q code=%q", results[2].GetOk(), results[2].GetEntryId(), results[2].GetErrorCode())
+	}
+	if results[3].GetOk() || results[3].GetErrorCode() != errorRefundExceedsDebit {
+		test.Fatalf("expected refund-2 to fail with %q, got ok=%v code=%q", errorRefundExceedsDebit, results[3].GetOk(), results[3].GetErrorCode())
+	}
+
+	balanceResponse, err := server.GetBalance(ctx, &creditv1.BalanceRequest{UserId: userID, TenantId: tenantID, LedgerId: ledgerID})
+	if err != nil {
+		test.Fatalf("get balance: %v", err)
+	}
+	if balanceResponse.GetTotalCents() != 880 || balanceResponse.GetAvailableCents() != 880 {
+		test.Fatalf("expected 880/880, got total=%d available=%d", balanceResponse.GetTotalCents(), balanceResponse.GetAvailableCents())
+	}
+}
+
+func TestCreditServiceServerListEntriesAppliesFilters(test *testing.T) {
+	test.Parallel()
+	creditService, err := newSQLiteLedgerService(test)
+	if err != nil {
+		test.Fatalf("new ledger service: %v", err)
+	}
+	server := NewCreditServiceServer(creditService, []string{"default"})
+
+	ctx := context.Background()
+	userID := "user-123"
+	tenantID := "default"
+	ledgerID := "default"
+
+	if _, err := server.Grant(ctx, &creditv1.GrantRequest{
+		UserId:         userID,
+		TenantId:       tenantID,
+		LedgerId:       ledgerID,
+		AmountCents:    1000,
+		IdempotencyKey: "grant-1",
+		MetadataJson:   "{}",
+	}); err != nil {
+		test.Fatalf("grant: %v", err)
+	}
+	if _, err := server.Reserve(ctx, &creditv1.ReserveRequest{
+		UserId:         userID,
+		TenantId:       tenantID,
+		LedgerId:       ledgerID,
+		AmountCents:    200,
+		ReservationId:  "order-1",
+		IdempotencyKey: "reserve-1",
+		MetadataJson:   "{}",
+	}); err != nil {
+		test.Fatalf("reserve: %v", err)
+	}
+	if _, err := server.Spend(ctx, &creditv1.SpendRequest{
+		UserId:         userID,
+		TenantId:       tenantID,
+		LedgerId:       ledgerID,
+		AmountCents:    100,
+		IdempotencyKey: "spend-1",
+		MetadataJson:   "{}",
+	}); err != nil {
+		test.Fatalf("spend: %v", err)
+	}
+
+	listResponse, err := server.ListEntries(ctx, &creditv1.ListEntriesRequest{
+		UserId:               userID,
+		TenantId:             tenantID,
+		LedgerId:             ledgerID,
+		BeforeUnixUtc:        0,
+		Limit:                10,
+		Types:                []string{"hold"},
+		ReservationId:        "order-1",
+		IdempotencyKeyPrefix: "reserve",
+	})
+	if err != nil {
+		test.Fatalf("list entries: %v", err)
+	}
+	if len(listResponse.GetEntries()) != 1 {
+		test.Fatalf("expected 1 entry, got %d", len(listResponse.GetEntries()))
+	}
+	entry := listResponse.GetEntries()[0]
+	if entry.GetType() != "hold" {
+		test.Fatalf("expected hold entry, got %q", entry.GetType())
+	}
+	if entry.GetReservationId() != "order-1" {
+		test.Fatalf("expected reservation order-1, got %q", entry.GetReservationId())
+	}
+	if entry.GetIdempotencyKey() != "reserve-1" {
+		test.Fatalf("expected idempotency reserve-1, got %q", entry.GetIdempotencyKey())
+	}
+}
+
+func TestCreditServiceServerListEntriesReturnsAndFiltersActor(test *testing.T) {
+	test.Parallel()
+	creditService, err := newSQLiteLedgerService(test)
+	if err != nil {
+		test.Fatalf("new ledger service: %v", err)
+	}
+	server := NewCreditServiceServer(creditService, []string{"default"})
+	actor, err := ledger.NewActor("sha256:0011", "billing", "req-1", "10.0.0.1:5000")
+	if err != nil {
+		test.Fatalf("actor: %v", err)
+	}
+
+	if _, err := server.Grant(ledger.ContextWithActor(context.Background(), actor), &creditv1.GrantRequest{
+		UserId: "user-123", TenantId: "default", LedgerId: "default", AmountCents: 1000, IdempotencyKey: "grant-1", MetadataJson: "{}",
+	}); err != nil {
+		test.Fatalf("grant: %v", err)
+	}
+	if _, err := server.Spend(context.Background(), &creditv1.SpendRequest{
+		UserId: "user-123", TenantId: "default", LedgerId: "default", AmountCents: 100, IdempotencyKey: "spend-1", MetadataJson: "{}",
+	}); err != nil {
+		test.Fatalf("spend: %v", err)
+	}
+
+	listResponse, err := server.ListEntries(context.Background(), &creditv1.ListEntriesRequest{
+		UserId: "user-123", TenantId: "default", LedgerId: "default", Limit: 10,
+		Actor: &creditv1.Actor{RequestId: "req-1"},
+	})
+	if err != nil {
+		test.Fatalf("list entries: %v", err)
+	}
+	if len(listResponse.GetEntries()) != 1 {
+		test.Fatalf("expected 1 entry, got %d", len(listResponse.GetEntries()))
+	}
+	entryActor := listResponse.GetEntries()[0].GetActor()
+	if entryActor.GetApiKeyId() != "sha256:0011" || entryActor.GetService() != "billing" || entryActor.GetRequestId() != "req-1" || entryActor.GetClientAddress() != "10.0.0.1:5000" {
+		test.Fatalf("unexpected actor %+v", entryActor)
+	}
+
+	allResponse, err := server.ListEntries(context.Background(), &creditv1.ListEntriesRequest{UserId: "user-123", TenantId: "default", LedgerId: "default", Limit: 10})
+	if err != nil {
+		test.Fatalf("list all entries: %v", err)
+	}
+	for _, entry := range allResponse.GetEntries() {
+		if entry.GetType() == "spend" && entry.GetActor() != nil {
+			test.Fatalf("expected unattributed spend, got %+v", entry.GetActor())
+		}
+	}
+
+	_, err = server.ListEntries(context.Background(), &creditv1.ListEntriesRequest{
+		UserId: "user-123", TenantId: "default", LedgerId: "default", Limit: 10,
+		Actor: &creditv1.Actor{Service: strings.Repeat("s", 300)},
+	})
+	if status.Code(err) != codes.InvalidArgument || status.Convert(err).Message() != errorInvalidActor {
+		test.Fatalf("expected invalid actor, got %v", err)
+	}
+}
+
+func TestCreditServiceServerVerifyChainReportsFirstBrokenLink(test *testing.T) {
+	test.Parallel()
+	db, err := newSQLiteLedgerDB(test)
+	if err != nil {
+		test.Fatalf("new sqlite db: %v", err)
 	}
-	results := batchResponse.GetResults()
-	if len(results) != 4 {
-		test.Fatalf("expected 4 results, got %d", len(results))
+	creditService, err := ledger.NewService(gormstore.New(db), func() int64 { return 1700000000 })
+	if err != nil {
+		test.Fatalf("new ledger service: %v", err)
 	}
-	if !results[2].GetOk() || results[2].GetEntryId() == "" {
-		test.Fatalf("unexpected refund-1 result: ok=%v entry_id=%q code=%q", results[2].GetOk(), results[2].GetEntryId(), results[2].GetErrorCode())
+	server := NewCreditServiceServer(creditService, []string{"default"})
+	for _, key := range []string{"grant-1", "grant-2"} {
+		if _, err := server.Grant(context.Background(), &creditv1.GrantRequest{
+			UserId: "user-123", TenantId: "default", LedgerId: "default", AmountCents: 1000, IdempotencyKey: key, MetadataJson: "{}",
+		}); err != nil {
+			test.Fatalf("grant %s: %v", key, err)
+		}
 	}
-	if results[3].GetOk() || results[3].GetErrorCode() != errorRefundExceedsDebit {
-		test.Fatalf("expected refund-2 to fail with %q, got ok=%v code=%q", errorRefundExceedsDebit, results[3].GetOk(), results[3].GetErrorCode())
+	request := &creditv1.VerifyChainRequest{TenantId: "default", UserId: "user-123", LedgerId: "default"}
+
+	response, err := server.VerifyChain(context.Background(), request)
+	if err != nil {
+		test.Fatalf("verify chain: %v", err)
+	}
+	if !response.GetValid() || response.GetHeadSequence() != 2 || response.GetCheckedEntries() != 2 || response.GetBreak() != nil {
+		test.Fatalf("expected intact chain, got %+v", response)
 	}
 
-	balanceResponse, err := server.GetBalance(ctx, &creditv1.BalanceRequest{UserId: userID, TenantId: tenantID, LedgerId: ledgerID})
+	if err := db.Exec("UPDATE ledger_entries SET amount_cents = 5000 WHERE idempotency_key = ?", "grant-2").Error; err != nil {
+		test.Fatalf("tamper: %v", err)
+	}
+	response, err = server.VerifyChain(context.Background(), request)
 	if err != nil {
-		test.Fatalf("get balance: %v", err)
+		test.Fatalf("verify tampered chain: %v", err)
 	}
-	if balanceResponse.GetTotalCents() != 880 || balanceResponse.GetAvailableCents() != 880 {
-		test.Fatalf("expected 880/880, got total=%d available=%d", balanceResponse.GetTotalCents(), balanceResponse.GetAvailableCents())
+	chainBreak := response.GetBreak()
+	if response.GetValid() || chainBreak.GetSequence() != 2 || chainBreak.GetReason() != "hash_mismatch" || chainBreak.GetEntryId() == "" {
+		test.Fatalf("expected hash mismatch at sequence 2, got %+v", response)
+	}
+
+	if _, err := server.VerifyChain(context.Background(), &creditv1.VerifyChainRequest{TenantId: "other", UserId: "user-123", LedgerId: "default"}); status.Code(err) != codes.PermissionDenied {
+		test.Fatalf("expected permission denied for unknown tenant, got %v", err)
 	}
 }
 
-func TestCreditServiceServerListEntriesAppliesFilters(test *testing.T) {
+func TestCreditServiceServerReportsCompactedHistory(test *testing.T) {
 	test.Parallel()
-	creditService, err := newSQLiteLedgerService(test)
+	db, err := newSQLiteLedgerDB(test)
+	if err != nil {
+		test.Fatalf("new sqlite db: %v", err)
+	}
+	nowUnixUTC := int64(1700000000)
+	creditService, err := ledger.NewService(gormstore.New(db), func() int64 { return nowUnixUTC })
 	if err != nil {
 		test.Fatalf("new ledger service: %v", err)
 	}
 	server := NewCreditServiceServer(creditService, []string{"default"})
+	for _, key := range []string{"grant-1", "grant-2"} {
+		if _, err := server.Grant(context.Background(), &creditv1.GrantRequest{
+			UserId: "user-123", TenantId: "default", LedgerId: "default", AmountCents: 1000, IdempotencyKey: key, MetadataJson: "{}",
+		}); err != nil {
+			test.Fatalf("grant %s: %v", key, err)
+		}
+	}
+	listRequest := &creditv1.ListEntriesRequest{UserId: "user-123", TenantId: "default", LedgerId: "default", Limit: 10}
+	listResponse, err := server.ListEntries(context.Background(), listRequest)
+	if err != nil {
+		test.Fatalf("list entries: %v", err)
+	}
+	if listResponse.GetArchivedBeforeUnixUtc() != 0 {
+		test.Fatalf("expected no archived history, got %d", listResponse.GetArchivedBeforeUnixUtc())
+	}
 
-	ctx := context.Background()
-	userID := "user-123"
-	tenantID := "default"
-	ledgerID := "default"
+	nowUnixUTC = 1700000100
+	writer, err := ledger.NewGzipJSONLArchiveWriter(filepath.Join(test.TempDir(), "archive.jsonl.gz"))
+	if err != nil {
+		test.Fatalf("archive writer: %v", err)
+	}
+	defer writer.Close()
+	tenantID, _ := ledger.NewTenantID("default")
+	userID, _ := ledger.NewUserID("user-123")
+	ledgerID, _ := ledger.NewLedgerID("default")
+	if _, err := creditService.CompactAccount(context.Background(), tenantID, userID, ledgerID, 1700000050, writer); err != nil {
+		test.Fatalf("compact: %v", err)
+	}
 
+	listResponse, err = server.ListEntries(context.Background(), listRequest)
+	if err != nil {
+		test.Fatalf("list entries: %v", err)
+	}
+	if listResponse.GetArchivedBeforeUnixUtc() != 1700000050 {
+		test.Fatalf("expected history archived before 1700000050, got %d", listResponse.GetArchivedBeforeUnixUtc())
+	}
+	if len(listResponse.GetEntries()) != 1 || listResponse.GetEntries()[0].GetType() != "opening_balance" || listResponse.GetEntries()[0].GetAmountCents() != 2000 {
+		test.Fatalf("expected a single opening balance of 2000, got %+v", listResponse.GetEntries())
+	}
+	verifyResponse, err := server.VerifyChain(context.Background(), &creditv1.VerifyChainRequest{TenantId: "default", UserId: "user-123", LedgerId: "default"})
+	if err != nil {
+		test.Fatalf("verify chain: %v", err)
+	}
+	if !verifyResponse.GetValid() || verifyResponse.GetArchivedThroughSequence() != 2 || verifyResponse.GetCheckedEntries() != 1 {
+		test.Fatalf("unexpected chain verification %+v", verifyResponse)
+	}
+}
+
+func TestCreditServiceServerVerifyAccountReportsViolations(test *testing.T) {
+	test.Parallel()
+	db, err := newSQLiteLedgerDB(test)
+	if err != nil {
+		test.Fatalf("new sqlite db: %v", err)
+	}
+	creditService, err := ledger.NewService(gormstore.New(db), func() int64 { return 1700000000 })
+	if err != nil {
+		test.Fatalf("new ledger service: %v", err)
+	}
+	server := NewCreditServiceServer(creditService, []string{"default"})
+	ctx := context.Background()
 	if _, err := server.Grant(ctx, &creditv1.GrantRequest{
-		UserId:         userID,
-		TenantId:       tenantID,
-		LedgerId:       ledgerID,
-		AmountCents:    1000,
-		IdempotencyKey: "grant-1",
-		MetadataJson:   "{}",
+		UserId: "user-123", TenantId: "default", LedgerId: "default", AmountCents: 1000, IdempotencyKey: "grant-1", MetadataJson: "{}",
 	}); err != nil {
 		test.Fatalf("grant: %v", err)
 	}
 	if _, err := server.Reserve(ctx, &creditv1.ReserveRequest{
-		UserId:         userID,
-		TenantId:       tenantID,
-		LedgerId:       ledgerID,
-		AmountCents:    200,
-		ReservationId:  "order-1",
-		IdempotencyKey: "reserve-1",
-		MetadataJson:   "{}",
+		UserId: "user-123", TenantId: "default", LedgerId: "default", AmountCents: 300, ReservationId: "order-1", IdempotencyKey: "reserve-1", MetadataJson: "{}",
 	}); err != nil {
 		test.Fatalf("reserve: %v", err)
 	}
-	if _, err := server.Spend(ctx, &creditv1.SpendRequest{
-		UserId:         userID,
-		TenantId:       tenantID,
-		LedgerId:       ledgerID,
-		AmountCents:    100,
-		IdempotencyKey: "spend-1",
-		MetadataJson:   "{}",
+	if _, err := server.Capture(ctx, &creditv1.CaptureRequest{
+		UserId: "user-123", TenantId: "default", LedgerId: "default", AmountCents: 300, ReservationId: "order-1", IdempotencyKey: "capture-1", MetadataJson: "{}",
 	}); err != nil {
-		test.Fatalf("spend: %v", err)
+		test.Fatalf("capture: %v", err)
 	}
+	request := &creditv1.VerifyAccountRequest{TenantId: "default", UserId: "user-123", LedgerId: "default"}
 
-	listResponse, err := server.ListEntries(ctx, &creditv1.ListEntriesRequest{
-		UserId:               userID,
-		TenantId:             tenantID,
-		LedgerId:             ledgerID,
-		BeforeUnixUtc:        0,
-		Limit:                10,
-		Types:                []string{"hold"},
-		ReservationId:        "order-1",
-		IdempotencyKeyPrefix: "reserve",
-	})
+	response, err := server.VerifyAccount(ctx, request)
 	if err != nil {
-		test.Fatalf("list entries: %v", err)
+		test.Fatalf("verify account: %v", err)
 	}
-	if len(listResponse.GetEntries()) != 1 {
-		test.Fatalf("expected 1 entry, got %d", len(listResponse.GetEntries()))
+	if !response.GetValid() || response.GetCheckedEntries() != 4 || response.GetCheckedReservations() != 1 || len(response.GetViolations()) != 0 {
+		test.Fatalf("expected consistent account, got %+v", response)
 	}
-	entry := listResponse.GetEntries()[0]
-	if entry.GetType() != "hold" {
-		test.Fatalf("expected hold entry, got %q", entry.GetType())
+
+	if err := db.Exec("UPDATE reservations SET status = ? WHERE reservation_id = ?", "released", "order-1").Error; err != nil {
+		test.Fatalf("tamper: %v", err)
 	}
-	if entry.GetReservationId() != "order-1" {
-		test.Fatalf("expected reservation order-1, got %q", entry.GetReservationId())
+	response, err = server.VerifyAccount(ctx, request)
+	if err != nil {
+		test.Fatalf("verify tampered account: %v", err)
 	}
-	if entry.GetIdempotencyKey() != "reserve-1" {
-		test.Fatalf("expected idempotency reserve-1, got %q", entry.GetIdempotencyKey())
+	violations := response.GetViolations()
+	if response.GetValid() || len(violations) != 1 || violations[0].GetCheck() != "reservation_settlement" || violations[0].GetReservationId() != "order-1" {
+		test.Fatalf("expected settlement violation, got %+v", response)
+	}
+
+	if _, err := server.VerifyAccount(ctx, &creditv1.VerifyAccountRequest{TenantId: "default", UserId: " ", LedgerId: "default"}); status.Code(err) != codes.InvalidArgument {
+		test.Fatalf("expected invalid argument, got %v", err)
 	}
 }
 
@@ -2501,6 +4058,17 @@ func TestCreditServiceServerBatchSupportsLargeBatches(test *testing.T) {
 }
 
 func newSQLiteLedgerService(test *testing.T) (*ledger.Service, error) {
+	test.Helper()
+	db, err := newSQLiteLedgerDB(test)
+	if err != nil {
+		return nil, err
+	}
+	store := gormstore.New(db)
+	clock := func() int64 { return 1700000000 }
+	return ledger.NewService(store, clock)
+}
+
+func newSQLiteLedgerDB(test *testing.T) (*gorm.DB, error) {
 	test.Helper()
 	tempDir := test.TempDir()
 	sqlitePath := filepath.Join(tempDir, "ledger.db")
@@ -2514,12 +4082,10 @@ func newSQLiteLedgerService(test *testing.T) (*ledger.Service, error) {
 		return nil, err
 	}
 	test.Cleanup(func() { _ = sqlDB.Close() })
-	if err := db.AutoMigrate(&gormstore.Account{}, &gormstore.LedgerEntry{}, &gormstore.Reservation{}); err != nil {
+	if _, err := gormstore.MigrateUp(context.Background(), db); err != nil {
 		return nil, err
 	}
-	store := gormstore.New(db)
-	clock := func() int64 { return 1700000000 }
-	return ledger.NewService(store, clock)
+	return db, nil
 }
 
 func TestCreditServiceServerValidationErrors(test *testing.T) {
@@ -2983,6 +4549,14 @@ func (store *alwaysErrorStore) GetOrCreateAccountID(ctx context.Context, tenantI
 	return ledger.AccountID{}, store.err
 }
 
+func (store *alwaysErrorStore) GetAccountID(ctx context.Context, tenantID ledger.TenantID, userID ledger.UserID, ledgerID ledger.LedgerID) (ledger.AccountID, error) {
+	return ledger.AccountID{}, store.err
+}
+
+func (store *alwaysErrorStore) ListAccounts(ctx context.Context, tenantID ledger.TenantID, beforeCreatedUnixUTC int64, limit int, filter ledger.ListAccountsFilter) ([]ledger.Account, error) {
+	return nil, store.err
+}
+
 func (store *alwaysErrorStore) InsertEntry(ctx context.Context, entry ledger.EntryInput) (ledger.Entry, error) {
 	return ledger.Entry{}, store.err
 }
@@ -3007,6 +4581,58 @@ func (store *alwaysErrorStore) SumActiveHolds(ctx context.Context, accountID led
 	return 0, store.err
 }
 
+func (store *alwaysErrorStore) SumDebitVelocity(ctx context.Context, accountID ledger.AccountID, sinceUnixUTC int64) (ledger.DebitVelocity, error) {
+	return ledger.DebitVelocity{}, store.err
+}
+
+func (store *alwaysErrorStore) InsertJournalLines(ctx context.Context, lines []ledger.JournalLine) error {
+	return store.err
+}
+
+func (store *alwaysErrorStore) SumJournalLines(ctx context.Context, tenantID ledger.TenantID, atUnixUTC int64) ([]ledger.TrialBalanceLine, error) {
+	return nil, store.err
+}
+
+func (store *alwaysErrorStore) SumEntriesByExpiry(ctx context.Context, tenantID ledger.TenantID, atUnixUTC int64) ([]ledger.ExpiryTotal, error) {
+	return nil, store.err
+}
+
+func (store *alwaysErrorStore) ListConsumableGrants(ctx context.Context, accountID ledger.AccountID, atUnixUTC int64) ([]ledger.Entry, error) {
+	return nil, store.err
+}
+
+func (store *alwaysErrorStore) ConsumeGrant(ctx context.Context, accountID ledger.AccountID, entryID ledger.EntryID, amountCents ledger.AmountCents) error {
+	return store.err
+}
+
+func (store *alwaysErrorStore) AppendEntryChange(ctx context.Context, tenantID ledger.TenantID, entryID ledger.EntryID) error {
+	return store.err
+}
+
+func (store *alwaysErrorStore) ListEntryChanges(ctx context.Context, tenantID ledger.TenantID, afterCursor int64, limit int, filter ledger.EntryChangeFilter) ([]ledger.EntryChange, error) {
+	return nil, store.err
+}
+
+func (store *alwaysErrorStore) InsertOutboxEvent(ctx context.Context, event ledger.OutboxEvent) error {
+	return store.err
+}
+
+func (store *alwaysErrorStore) LockChainHead(ctx context.Context, accountID ledger.AccountID) (ledger.ChainHead, error) {
+	return ledger.ChainHead{}, store.err
+}
+
+func (store *alwaysErrorStore) AppendChainLink(ctx context.Context, accountID ledger.AccountID, link ledger.ChainLink) error {
+	return store.err
+}
+
+func (store *alwaysErrorStore) GetChainHead(ctx context.Context, accountID ledger.AccountID) (ledger.ChainHead, error) {
+	return ledger.ChainHead{}, store.err
+}
+
+func (store *alwaysErrorStore) ListChainedEntries(ctx context.Context, accountID ledger.AccountID, afterSequence int64, limit int) ([]ledger.ChainedEntry, error) {
+	return nil, store.err
+}
+
 func (store *alwaysErrorStore) CreateReservation(ctx context.Context, reservation ledger.Reservation) error {
 	return store.err
 }
@@ -3058,6 +4684,16 @@ func TestNewTenantIDErrorPathInEveryHandler(test *testing.T) {
 				return err
 			},
 		},
+		{
+			name: "CancelGrant",
+			invoke: func() error {
+				_, err := server.CancelGrant(ctx, &creditv1.CancelGrantRequest{
+					UserId: "user", TenantId: " ", LedgerId: "default",
+					Grant: &creditv1.CancelGrantRequest_GrantEntryId{GrantEntryId: "entry-1"},
+				})
+				return err
+			},
+		},
 		{
 			name: "Reserve",
 			invoke: func() error {
@@ -3356,6 +4992,38 @@ func TestListEntriesMapsServiceErrors(test *testing.T) {
 	}
 }
 
+type entryArchiveErrorStore struct {
+	*gormstore.Store
+}
+
+func (store entryArchiveErrorStore) GetEntryArchive(context.Context, ledger.AccountID) (ledger.EntryArchive, error) {
+	return ledger.EntryArchive{}, errors.New("archive read failed")
+}
+
+func TestListEntriesMapsEntryArchiveErrors(test *testing.T) {
+	test.Parallel()
+	db, err := newSQLiteLedgerDB(test)
+	if err != nil {
+		test.Fatalf("new sqlite db: %v", err)
+	}
+	service, err := ledger.NewService(entryArchiveErrorStore{Store: gormstore.New(db)}, func() int64 { return 1700000000 })
+	if err != nil {
+		test.Fatalf("service init: %v", err)
+	}
+	server := NewCreditServiceServer(service, []string{"default"})
+	if _, err := server.Grant(context.Background(), &creditv1.GrantRequest{
+		UserId: "user", TenantId: "default", LedgerId: "default", AmountCents: 100, IdempotencyKey: "grant-1", MetadataJson: "{}",
+	}); err != nil {
+		test.Fatalf("grant: %v", err)
+	}
+	_, err = server.ListEntries(context.Background(), &creditv1.ListEntriesRequest{
+		UserId: "user", TenantId: "default", LedgerId: "default", Limit: 1,
+	})
+	if status.Code(err) != codes.Internal {
+		test.Fatalf("expected Internal, got %v", status.Code(err))
+	}
+}
+
 func TestListReservationsMapsServiceErrors(test *testing.T) {
 	test.Parallel()
 	clock := func() int64 { return 1700000000 }
@@ -3372,6 +5040,105 @@ func TestListReservationsMapsServiceErrors(test *testing.T) {
 	}
 }
 
+func TestGetTrialBalanceMapsServiceErrors(test *testing.T) {
+	test.Parallel()
+	clock := func() int64 { return 1700000000 }
+	service, err := ledger.NewService(&alwaysErrorStore{err: errors.New("boom")}, clock)
+	if err != nil {
+		test.Fatalf("service init: %v", err)
+	}
+	server := NewCreditServiceServer(service, []string{"default", ""})
+	if _, err := server.GetTrialBalance(context.Background(), &creditv1.GetTrialBalanceRequest{TenantId: ""}); status.Convert(err).Message() != errorInvalidTenantID {
+		test.Fatalf("expected invalid tenant id, got %v", err)
+	}
+	if _, err := server.GetTrialBalance(context.Background(), &creditv1.GetTrialBalanceRequest{TenantId: "default"}); status.Code(err) != codes.Internal {
+		test.Fatalf("expected Internal, got %v", status.Code(err))
+	}
+}
+
+func TestGetLiabilityReportMapsServiceErrors(test *testing.T) {
+	test.Parallel()
+	clock := func() int64 { return 1700000000 }
+	service, err := ledger.NewService(&alwaysErrorStore{err: errors.New("boom")}, clock)
+	if err != nil {
+		test.Fatalf("service init: %v", err)
+	}
+	server := NewCreditServiceServer(service, []string{"default", ""})
+	if _, err := server.GetLiabilityReport(context.Background(), &creditv1.GetLiabilityReportRequest{TenantId: ""}); status.Convert(err).Message() != errorInvalidTenantID {
+		test.Fatalf("expected invalid tenant id, got %v", err)
+	}
+	if _, err := server.GetLiabilityReport(context.Background(), &creditv1.GetLiabilityReportRequest{TenantId: "default"}); status.Code(err) != codes.Internal {
+		test.Fatalf("expected Internal, got %v", status.Code(err))
+	}
+}
+
+func TestListAccountsMapsServiceErrors(test *testing.T) {
+	test.Parallel()
+	clock := func() int64 { return 1700000000 }
+	service, err := ledger.NewService(&alwaysErrorStore{err: errors.New("boom")}, clock)
+	if err != nil {
+		test.Fatalf("service init: %v", err)
+	}
+	server := NewCreditServiceServer(service, []string{"default", ""})
+	if _, err := server.ListAccounts(context.Background(), &creditv1.ListAccountsRequest{TenantId: ""}); status.Convert(err).Message() != errorInvalidTenantID {
+		test.Fatalf("expected invalid tenant id, got %v", err)
+	}
+	if _, err := server.ListAccounts(context.Background(), &creditv1.ListAccountsRequest{TenantId: "default"}); status.Code(err) != codes.Internal {
+		test.Fatalf("expected Internal, got %v", status.Code(err))
+	}
+}
+
+func TestVerifyChainMapsServiceErrors(test *testing.T) {
+	test.Parallel()
+	clock := func() int64 { return 1700000000 }
+	service, err := ledger.NewService(&alwaysErrorStore{err: errors.New("boom")}, clock)
+	if err != nil {
+		test.Fatalf("service init: %v", err)
+	}
+	server := NewCreditServiceServer(service, []string{"default", ""})
+	if _, err := server.VerifyChain(context.Background(), &creditv1.VerifyChainRequest{TenantId: "", UserId: "user-1", LedgerId: "default"}); status.Convert(err).Message() != errorInvalidTenantID {
+		test.Fatalf("expected invalid tenant id, got %v", err)
+	}
+	for _, request := range []*creditv1.VerifyChainRequest{
+		{TenantId: "default", UserId: " ", LedgerId: "default"},
+		{TenantId: "default", UserId: "user-1", LedgerId: " "},
+	} {
+		if _, err := server.VerifyChain(context.Background(), request); status.Code(err) != codes.InvalidArgument {
+			test.Fatalf("expected InvalidArgument for %+v, got %v", request, err)
+		}
+	}
+	if _, err := server.VerifyChain(context.Background(), &creditv1.VerifyChainRequest{TenantId: "default", UserId: "user-1", LedgerId: "default"}); status.Code(err) != codes.Internal {
+		test.Fatalf("expected Internal, got %v", status.Code(err))
+	}
+}
+
+func TestVerifyAccountMapsServiceErrors(test *testing.T) {
+	test.Parallel()
+	clock := func() int64 { return 1700000000 }
+	service, err := ledger.NewService(&alwaysErrorStore{err: errors.New("boom")}, clock)
+	if err != nil {
+		test.Fatalf("service init: %v", err)
+	}
+	server := NewCreditServiceServer(service, []string{"default", ""})
+	if _, err := server.VerifyAccount(context.Background(), &creditv1.VerifyAccountRequest{TenantId: "", UserId: "user-1", LedgerId: "default"}); status.Convert(err).Message() != errorInvalidTenantID {
+		test.Fatalf("expected invalid tenant id, got %v", err)
+	}
+	if _, err := server.VerifyAccount(context.Background(), &creditv1.VerifyAccountRequest{TenantId: "other", UserId: "user-1", LedgerId: "default"}); status.Code(err) != codes.PermissionDenied {
+		test.Fatalf("expected PermissionDenied, got %v", err)
+	}
+	for _, request := range []*creditv1.VerifyAccountRequest{
+		{TenantId: "default", UserId: " ", LedgerId: "default"},
+		{TenantId: "default", UserId: "user-1", LedgerId: " "},
+	} {
+		if _, err := server.VerifyAccount(context.Background(), request); status.Code(err) != codes.InvalidArgument {
+			test.Fatalf("expected InvalidArgument for %+v, got %v", request, err)
+		}
+	}
+	if _, err := server.VerifyAccount(context.Background(), &creditv1.VerifyAccountRequest{TenantId: "default", UserId: "user-1", LedgerId: "default"}); status.Code(err) != codes.Internal {
+		test.Fatalf("expected Internal, got %v", status.Code(err))
+	}
+}
+
 func TestMapToGRPCErrorIdempotencyKeyConflict(test *testing.T) {
 	test.Parallel()
 	err := mapToGRPCError(fmt.Errorf("%w: existing entry is grant", ledger.ErrIdempotencyKeyConflict))
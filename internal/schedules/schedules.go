@@ -0,0 +1,189 @@
+package schedules
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/MarkoPoloResearchLab/ledger/pkg/ledger"
+)
+
+const (
+	errorEmptyValue          = "empty value"
+	errorUnknownValue        = "unknown value"
+	errorMustBeZeroOrGreater = "must be zero or greater"
+	errorEndBeforeStart      = "must be after start"
+
+	idempotencyKeyPrefix    = "schedule"
+	idempotencyKeyDelimiter = ":"
+	periodKeyLayout         = "2006-01-02"
+)
+
+// Schedule-level error values returned by the schedules service.
+var (
+	ErrInvalidScheduleID     = errors.New("invalid schedule id")
+	ErrInvalidInterval       = errors.New("invalid schedule interval")
+	ErrInvalidScheduleStatus = errors.New("invalid schedule status")
+	ErrInvalidScheduleWindow = errors.New("invalid schedule window")
+	ErrInvalidGrantTTL       = errors.New("invalid grant ttl")
+	ErrUnknownSchedule       = errors.New("unknown schedule")
+	ErrScheduleExists        = errors.New("schedule already exists")
+	ErrScheduleClosed        = errors.New("schedule closed")
+)
+
+// ScheduleID identifies a grant schedule within a tenant.
+type ScheduleID struct {
+	value string
+}
+
+// NewScheduleID validates and normalizes a schedule id.
+func NewScheduleID(raw string) (ScheduleID, error) {
+	trimmed := strings.TrimSpace(raw)
+	if trimmed == "" {
+		return ScheduleID{}, fmt.Errorf("%w: %s", ErrInvalidScheduleID, errorEmptyValue)
+	}
+	return ScheduleID{value: trimmed}, nil
+}
+
+// String returns the normalized identifier.
+func (id ScheduleID) String() string {
+	return id.value
+}
+
+// Interval defines how often a schedule grants credits.
+type Interval string
+
+const (
+	IntervalDaily   Interval = "daily"
+	IntervalWeekly  Interval = "weekly"
+	IntervalMonthly Interval = "monthly"
+)
+
+// ParseInterval validates schedule interval values.
+func ParseInterval(raw string) (Interval, error) {
+	interval := Interval(strings.TrimSpace(raw))
+	if !interval.IsValid() {
+		return "", fmt.Errorf("%w: %s", ErrInvalidInterval, errorUnknownValue)
+	}
+	return interval, nil
+}
+
+// String returns the interval as a primitive value.
+func (interval Interval) String() string {
+	return string(interval)
+}
+
+// IsValid reports whether the interval is recognized.
+func (interval Interval) IsValid() bool {
+	switch interval {
+	case IntervalDaily, IntervalWeekly, IntervalMonthly:
+		return true
+	default:
+		return false
+	}
+}
+
+// RunAtUnixUTC returns the run time of the supplied period, counted from startUnixUTC.
+// Monthly schedules clamp to the last day of shorter months, so a schedule starting on
+// the 31st runs on the 30th in April and the 28th/29th in February.
+func (interval Interval) RunAtUnixUTC(startUnixUTC int64, period int64) int64 {
+	start := time.Unix(startUnixUTC, 0).UTC()
+	switch interval {
+	case IntervalWeekly:
+		return start.AddDate(0, 0, int(7*period)).Unix()
+	case IntervalMonthly:
+		firstOfMonth := time.Date(start.Year(), start.Month()+time.Month(period), 1, start.Hour(), start.Minute(), start.Second(), 0, time.UTC)
+		lastDay := firstOfMonth.AddDate(0, 1, -1).Day()
+		return firstOfMonth.AddDate(0, 0, min(start.Day(), lastDay)-1).Unix()
+	default:
+		return start.AddDate(0, 0, int(period)).Unix()
+	}
+}
+
+// Status defines the schedule lifecycle.
+type Status string
+
+const (
+	StatusActive    Status = "active"
+	StatusCancelled Status = "cancelled"
+	StatusCompleted Status = "completed"
+	// StatusFailed marks a schedule whose grants the ledger rejects for good, for example under a tenant policy.
+	StatusFailed Status = "failed"
+)
+
+// ParseStatus validates schedule status values.
+func ParseStatus(raw string) (Status, error) {
+	status := Status(strings.TrimSpace(raw))
+	if !status.IsValid() {
+		return "", fmt.Errorf("%w: %s", ErrInvalidScheduleStatus, errorUnknownValue)
+	}
+	return status, nil
+}
+
+// String returns the status as a primitive value.
+func (status Status) String() string {
+	return string(status)
+}
+
+// IsValid reports whether the status is recognized.
+func (status Status) IsValid() bool {
+	switch status {
+	case StatusActive, StatusCancelled, StatusCompleted, StatusFailed:
+		return true
+	default:
+		return false
+	}
+}
+
+// Schedule is a recurring grant for one account.
+type Schedule struct {
+	ScheduleID      ScheduleID
+	TenantID        ledger.TenantID
+	UserID          ledger.UserID
+	LedgerID        ledger.LedgerID
+	Amount          ledger.PositiveAmountCents
+	Interval        Interval
+	StartUnixUTC    int64
+	EndUnixUTC      int64
+	GrantTTLSeconds int64
+	Metadata        ledger.MetadataJSON
+	Status          Status
+	NextPeriod      int64
+	NextRunUnixUTC  int64
+	CreatedUnixUTC  int64
+	UpdatedUnixUTC  int64
+}
+
+// RunAtUnixUTC returns the run time of the supplied period.
+func (schedule Schedule) RunAtUnixUTC(period int64) int64 {
+	return schedule.Interval.RunAtUnixUTC(schedule.StartUnixUTC, period)
+}
+
+// IdempotencyKey derives the grant idempotency key for the supplied period.
+// Every worker derives the same key for the same period, so the ledger's
+// per-account idempotency constraint prevents double grants.
+func (schedule Schedule) IdempotencyKey(period int64) (ledger.IdempotencyKey, error) {
+	periodKey := time.Unix(schedule.RunAtUnixUTC(period), 0).UTC().Format(periodKeyLayout)
+	return ledger.NewIdempotencyKey(strings.Join([]string{idempotencyKeyPrefix, schedule.ScheduleID.String(), periodKey}, idempotencyKeyDelimiter))
+}
+
+// ListFilter narrows ListSchedules queries.
+type ListFilter struct {
+	UserID   *ledger.UserID
+	LedgerID *ledger.LedgerID
+	Statuses []Status
+}
+
+// Store is the persistence contract used by the schedules Service and Worker.
+type Store interface {
+	CreateSchedule(ctx context.Context, schedule Schedule) (Schedule, error)
+	GetSchedule(ctx context.Context, tenantID ledger.TenantID, scheduleID ScheduleID) (Schedule, error)
+	ListSchedules(ctx context.Context, tenantID ledger.TenantID, beforeCreatedUnixUTC int64, limit int, filter ListFilter) ([]Schedule, error)
+	ListDueSchedules(ctx context.Context, atUnixUTC int64, limit int) ([]Schedule, error)
+	// UpdateScheduleStatus moves a schedule from one status to another, returning ErrScheduleClosed when it is no longer in from.
+	UpdateScheduleStatus(ctx context.Context, tenantID ledger.TenantID, scheduleID ScheduleID, from, to Status, updatedUnixUTC int64) error
+	// AdvanceSchedule moves an active schedule from fromPeriod to the next period, reporting false when another worker already did.
+	AdvanceSchedule(ctx context.Context, tenantID ledger.TenantID, scheduleID ScheduleID, fromPeriod int64, nextRunUnixUTC int64, status Status, updatedUnixUTC int64) (bool, error)
+}
@@ -0,0 +1,99 @@
+package schedules
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestIntervalRunAtUnixUTC(test *testing.T) {
+	test.Parallel()
+	start := time.Date(2026, time.January, 31, 9, 30, 0, 0, time.UTC).Unix()
+	testCases := []struct {
+		name     string
+		interval Interval
+		period   int64
+		expected time.Time
+	}{
+		{name: "daily", interval: IntervalDaily, period: 3, expected: time.Date(2026, time.February, 3, 9, 30, 0, 0, time.UTC)},
+		{name: "weekly", interval: IntervalWeekly, period: 2, expected: time.Date(2026, time.February, 14, 9, 30, 0, 0, time.UTC)},
+		{name: "monthly first period", interval: IntervalMonthly, period: 0, expected: time.Date(2026, time.January, 31, 9, 30, 0, 0, time.UTC)},
+		{name: "monthly clamps february", interval: IntervalMonthly, period: 1, expected: time.Date(2026, time.February, 28, 9, 30, 0, 0, time.UTC)},
+		{name: "monthly restores day", interval: IntervalMonthly, period: 2, expected: time.Date(2026, time.March, 31, 9, 30, 0, 0, time.UTC)},
+		{name: "monthly clamps april", interval: IntervalMonthly, period: 3, expected: time.Date(2026, time.April, 30, 9, 30, 0, 0, time.UTC)},
+		{name: "monthly crosses year", interval: IntervalMonthly, period: 13, expected: time.Date(2027, time.February, 28, 9, 30, 0, 0, time.UTC)},
+	}
+	for _, testCase := range testCases {
+		testCase := testCase
+		test.Run(testCase.name, func(test *testing.T) {
+			test.Parallel()
+			actual := testCase.interval.RunAtUnixUTC(start, testCase.period)
+			if actual != testCase.expected.Unix() {
+				test.Fatalf("expected %s, got %s", testCase.expected, time.Unix(actual, 0).UTC())
+			}
+		})
+	}
+}
+
+func TestScheduleIdempotencyKeyDerivesFromScheduleAndPeriod(test *testing.T) {
+	test.Parallel()
+	schedule := Schedule{
+		ScheduleID:   mustScheduleID(test, "pro-monthly"),
+		Interval:     IntervalMonthly,
+		StartUnixUTC: time.Date(2026, time.March, 1, 0, 0, 0, 0, time.UTC).Unix(),
+	}
+	key, err := schedule.IdempotencyKey(2)
+	if err != nil {
+		test.Fatalf("idempotency key: %v", err)
+	}
+	if key.String() != "schedule:pro-monthly:2026-05-01" {
+		test.Fatalf("unexpected idempotency key %q", key.String())
+	}
+}
+
+func TestParsersRejectUnknownValues(test *testing.T) {
+	test.Parallel()
+	if _, err := NewScheduleID("  "); !errors.Is(err, ErrInvalidScheduleID) {
+		test.Fatalf("expected invalid schedule id, got %v", err)
+	}
+	if _, err := ParseInterval("hourly"); !errors.Is(err, ErrInvalidInterval) {
+		test.Fatalf("expected invalid interval, got %v", err)
+	}
+	if _, err := ParseStatus("paused"); !errors.Is(err, ErrInvalidScheduleStatus) {
+		test.Fatalf("expected invalid status, got %v", err)
+	}
+	interval, err := ParseInterval(" weekly ")
+	if err != nil || interval != IntervalWeekly {
+		test.Fatalf("expected weekly, got %q err=%v", interval, err)
+	}
+	status, err := ParseStatus("completed")
+	if err != nil || status != StatusCompleted {
+		test.Fatalf("expected completed, got %q err=%v", status, err)
+	}
+	status, err = ParseStatus("failed")
+	if err != nil || status != StatusFailed {
+		test.Fatalf("expected failed, got %q err=%v", status, err)
+	}
+}
+
+func TestIntervalAndStatusStrings(test *testing.T) {
+	test.Parallel()
+	if IntervalDaily.String() != "daily" || IntervalWeekly.String() != "weekly" || IntervalMonthly.String() != "monthly" {
+		test.Fatalf("unexpected interval strings")
+	}
+	for _, status := range []Status{StatusActive, StatusCancelled, StatusCompleted, StatusFailed} {
+		parsed, err := ParseStatus(status.String())
+		if err != nil || parsed != status {
+			test.Fatalf("expected %q to round trip, got %q err=%v", status, parsed, err)
+		}
+	}
+}
+
+func mustScheduleID(test *testing.T, raw string) ScheduleID {
+	test.Helper()
+	scheduleID, err := NewScheduleID(raw)
+	if err != nil {
+		test.Fatalf("schedule id: %v", err)
+	}
+	return scheduleID
+}
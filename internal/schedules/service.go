@@ -0,0 +1,90 @@
+package schedules
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/MarkoPoloResearchLab/ledger/pkg/ledger"
+)
+
+// CreateRequest describes a new grant schedule.
+type CreateRequest struct {
+	ScheduleID      ScheduleID
+	TenantID        ledger.TenantID
+	UserID          ledger.UserID
+	LedgerID        ledger.LedgerID
+	Amount          ledger.PositiveAmountCents
+	Interval        Interval
+	StartUnixUTC    int64
+	EndUnixUTC      int64
+	GrantTTLSeconds int64
+	Metadata        ledger.MetadataJSON
+}
+
+// Service manages grant schedules.
+type Service struct {
+	store Store
+	nowFn func() int64
+}
+
+// NewService wires a schedules Service.
+func NewService(store Store, now func() int64) (*Service, error) {
+	if store == nil {
+		return nil, fmt.Errorf("%w: store dependency is nil", ledger.ErrInvalidServiceConfig)
+	}
+	if now == nil {
+		return nil, fmt.Errorf("%w: clock dependency is nil", ledger.ErrInvalidServiceConfig)
+	}
+	return &Service{store: store, nowFn: now}, nil
+}
+
+// Create persists a new active schedule. A zero StartUnixUTC starts the schedule now.
+func (service *Service) Create(ctx context.Context, request CreateRequest) (Schedule, error) {
+	if !request.Interval.IsValid() {
+		return Schedule{}, fmt.Errorf("%w: %s", ErrInvalidInterval, errorUnknownValue)
+	}
+	if request.StartUnixUTC < 0 || request.EndUnixUTC < 0 {
+		return Schedule{}, fmt.Errorf("%w: %s", ErrInvalidScheduleWindow, errorMustBeZeroOrGreater)
+	}
+	if request.GrantTTLSeconds < 0 {
+		return Schedule{}, fmt.Errorf("%w: %s", ErrInvalidGrantTTL, errorMustBeZeroOrGreater)
+	}
+	nowUnixUTC := service.nowFn()
+	startUnixUTC := request.StartUnixUTC
+	if startUnixUTC == 0 {
+		startUnixUTC = nowUnixUTC
+	}
+	if request.EndUnixUTC != 0 && request.EndUnixUTC <= startUnixUTC {
+		return Schedule{}, fmt.Errorf("%w: %s", ErrInvalidScheduleWindow, errorEndBeforeStart)
+	}
+	return service.store.CreateSchedule(ctx, Schedule{
+		ScheduleID:      request.ScheduleID,
+		TenantID:        request.TenantID,
+		UserID:          request.UserID,
+		LedgerID:        request.LedgerID,
+		Amount:          request.Amount,
+		Interval:        request.Interval,
+		StartUnixUTC:    startUnixUTC,
+		EndUnixUTC:      request.EndUnixUTC,
+		GrantTTLSeconds: request.GrantTTLSeconds,
+		Metadata:        request.Metadata,
+		Status:          StatusActive,
+		NextPeriod:      0,
+		NextRunUnixUTC:  startUnixUTC,
+		CreatedUnixUTC:  nowUnixUTC,
+		UpdatedUnixUTC:  nowUnixUTC,
+	})
+}
+
+// List returns tenant schedules in reverse-chronological order of creation.
+func (service *Service) List(ctx context.Context, tenantID ledger.TenantID, beforeCreatedUnixUTC int64, limit int, filter ListFilter) ([]Schedule, error) {
+	return service.store.ListSchedules(ctx, tenantID, beforeCreatedUnixUTC, limit, filter)
+}
+
+// Cancel stops an active schedule. Grants already emitted are left untouched.
+func (service *Service) Cancel(ctx context.Context, tenantID ledger.TenantID, scheduleID ScheduleID) (Schedule, error) {
+	if err := service.store.UpdateScheduleStatus(ctx, tenantID, scheduleID, StatusActive, StatusCancelled, service.nowFn()); err != nil {
+		return Schedule{}, err
+	}
+	return service.store.GetSchedule(ctx, tenantID, scheduleID)
+}
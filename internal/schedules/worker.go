@@ -0,0 +1,140 @@
+package schedules
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/MarkoPoloResearchLab/ledger/pkg/ledger"
+)
+
+const defaultDueBatchSize = 100
+
+// Granter emits a single idempotent grant. *ledger.Service satisfies it.
+type Granter interface {
+	GrantEntry(ctx context.Context, tenantID ledger.TenantID, userID ledger.UserID, ledgerID ledger.LedgerID, amount ledger.PositiveAmountCents, idempotencyKey ledger.IdempotencyKey, expiresAtUnixUTC int64, metadata ledger.MetadataJSON) (ledger.Entry, error)
+}
+
+// Worker emits grants for due schedule periods.
+type Worker struct {
+	store     Store
+	granter   Granter
+	nowFn     func() int64
+	batchSize int
+}
+
+// NewWorker wires a schedules Worker.
+func NewWorker(store Store, granter Granter, now func() int64) (*Worker, error) {
+	if store == nil {
+		return nil, fmt.Errorf("%w: store dependency is nil", ledger.ErrInvalidServiceConfig)
+	}
+	if granter == nil {
+		return nil, fmt.Errorf("%w: granter dependency is nil", ledger.ErrInvalidServiceConfig)
+	}
+	if now == nil {
+		return nil, fmt.Errorf("%w: clock dependency is nil", ledger.ErrInvalidServiceConfig)
+	}
+	return &Worker{store: store, granter: granter, nowFn: now, batchSize: defaultDueBatchSize}, nil
+}
+
+// Run calls RunDue every pollInterval until ctx is cancelled. Errors are reported through onError and do not stop the loop.
+func (worker *Worker) Run(ctx context.Context, pollInterval time.Duration, onError func(error)) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		if _, err := worker.RunDue(ctx); err != nil && onError != nil && ctx.Err() == nil {
+			onError(err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunDue emits every grant whose period is due, catching up on missed periods, and returns the number of periods processed.
+// Each grant uses the idempotency key derived from the schedule and period, so concurrent workers never double-grant.
+func (worker *Worker) RunDue(ctx context.Context) (int, error) {
+	nowUnixUTC := worker.nowFn()
+	dueSchedules, err := worker.store.ListDueSchedules(ctx, nowUnixUTC, worker.batchSize)
+	if err != nil {
+		return 0, err
+	}
+	processed := 0
+	var runErrors []error
+	for _, schedule := range dueSchedules {
+		count, err := worker.runSchedule(ctx, schedule, nowUnixUTC)
+		processed += count
+		if err != nil {
+			runErrors = append(runErrors, fmt.Errorf("schedule %s: %w", schedule.ScheduleID.String(), err))
+		}
+	}
+	return processed, errors.Join(runErrors...)
+}
+
+func (worker *Worker) runSchedule(ctx context.Context, schedule Schedule, nowUnixUTC int64) (int, error) {
+	processed := 0
+	period := schedule.NextPeriod
+	runAtUnixUTC := schedule.RunAtUnixUTC(period)
+	for runAtUnixUTC <= nowUnixUTC {
+		if schedule.EndUnixUTC != 0 && runAtUnixUTC >= schedule.EndUnixUTC {
+			_, err := worker.store.AdvanceSchedule(ctx, schedule.TenantID, schedule.ScheduleID, period, runAtUnixUTC, StatusCompleted, nowUnixUTC)
+			return processed, err
+		}
+		if err := worker.grant(ctx, schedule, period, runAtUnixUTC, nowUnixUTC); err != nil {
+			if !isPermanentGrantError(err) {
+				return processed, err
+			}
+			if failErr := worker.store.UpdateScheduleStatus(ctx, schedule.TenantID, schedule.ScheduleID, StatusActive, StatusFailed, nowUnixUTC); failErr != nil {
+				return processed, errors.Join(err, failErr)
+			}
+			return processed, fmt.Errorf("%w; schedule marked %s", err, StatusFailed)
+		}
+		nextRunUnixUTC := schedule.RunAtUnixUTC(period + 1)
+		nextStatus := StatusActive
+		if schedule.EndUnixUTC != 0 && nextRunUnixUTC >= schedule.EndUnixUTC {
+			nextStatus = StatusCompleted
+		}
+		advanced, err := worker.store.AdvanceSchedule(ctx, schedule.TenantID, schedule.ScheduleID, period, nextRunUnixUTC, nextStatus, nowUnixUTC)
+		if err != nil {
+			return processed, err
+		}
+		processed++
+		if !advanced || nextStatus != StatusActive {
+			return processed, nil
+		}
+		period++
+		runAtUnixUTC = nextRunUnixUTC
+	}
+	return processed, nil
+}
+
+// grant emits the grant of one period. A period caught up after its grant would already have expired is
+// skipped, since the grant could never be spent.
+func (worker *Worker) grant(ctx context.Context, schedule Schedule, period int64, runAtUnixUTC int64, nowUnixUTC int64) error {
+	expiresAtUnixUTC := int64(0)
+	if schedule.GrantTTLSeconds > 0 {
+		expiresAtUnixUTC = runAtUnixUTC + schedule.GrantTTLSeconds
+		if expiresAtUnixUTC <= nowUnixUTC {
+			return nil
+		}
+	}
+	idempotencyKey, err := schedule.IdempotencyKey(period)
+	if err == nil {
+		_, err = worker.granter.GrantEntry(ctx, schedule.TenantID, schedule.UserID, schedule.LedgerID, schedule.Amount, idempotencyKey, expiresAtUnixUTC, schedule.Metadata)
+	}
+	if errors.Is(err, ledger.ErrDuplicateIdempotencyKey) {
+		return nil
+	}
+	return err
+}
+
+// isPermanentGrantError reports whether the ledger rejects the schedule's grants whatever the period, so
+// retrying on the next poll cannot succeed.
+func isPermanentGrantError(err error) bool {
+	return errors.Is(err, ledger.ErrExpiringGrantNotAllowed) ||
+		errors.Is(err, ledger.ErrAmountLimitExceeded) ||
+		errors.Is(err, ledger.ErrUnknownLedger)
+}
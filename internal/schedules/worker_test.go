@@ -0,0 +1,652 @@
+package schedules
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/MarkoPoloResearchLab/ledger/pkg/ledger"
+)
+
+const (
+	testDay          = int64(24 * 60 * 60)
+	testStartUnixUTC = int64(1767225600) // 2026-01-01T00:00:00Z
+)
+
+func TestServiceCreateValidatesAndDefaultsStart(test *testing.T) {
+	test.Parallel()
+	store := newMemoryStore()
+	service, err := NewService(store, func() int64 { return testStartUnixUTC })
+	if err != nil {
+		test.Fatalf("new service: %v", err)
+	}
+	ctx := context.Background()
+
+	request := newCreateRequest(test, "monthly")
+	schedule, err := service.Create(ctx, request)
+	if err != nil {
+		test.Fatalf("create: %v", err)
+	}
+	if schedule.StartUnixUTC != testStartUnixUTC || schedule.NextRunUnixUTC != testStartUnixUTC || schedule.Status != StatusActive {
+		test.Fatalf("unexpected schedule: %+v", schedule)
+	}
+
+	invalidInterval := newCreateRequest(test, "invalid-interval")
+	invalidInterval.Interval = Interval("hourly")
+	if _, err := service.Create(ctx, invalidInterval); !errors.Is(err, ErrInvalidInterval) {
+		test.Fatalf("expected invalid interval, got %v", err)
+	}
+	invalidWindow := newCreateRequest(test, "invalid-window")
+	invalidWindow.EndUnixUTC = testStartUnixUTC
+	if _, err := service.Create(ctx, invalidWindow); !errors.Is(err, ErrInvalidScheduleWindow) {
+		test.Fatalf("expected invalid window, got %v", err)
+	}
+	negativeStart := newCreateRequest(test, "negative-start")
+	negativeStart.StartUnixUTC = -1
+	if _, err := service.Create(ctx, negativeStart); !errors.Is(err, ErrInvalidScheduleWindow) {
+		test.Fatalf("expected invalid window, got %v", err)
+	}
+	invalidTTL := newCreateRequest(test, "invalid-ttl")
+	invalidTTL.GrantTTLSeconds = -1
+	if _, err := service.Create(ctx, invalidTTL); !errors.Is(err, ErrInvalidGrantTTL) {
+		test.Fatalf("expected invalid grant ttl, got %v", err)
+	}
+
+	cancelled, err := service.Cancel(ctx, request.TenantID, request.ScheduleID)
+	if err != nil {
+		test.Fatalf("cancel: %v", err)
+	}
+	if cancelled.Status != StatusCancelled {
+		test.Fatalf("expected cancelled status, got %q", cancelled.Status)
+	}
+	if _, err := service.Cancel(ctx, request.TenantID, request.ScheduleID); !errors.Is(err, ErrScheduleClosed) {
+		test.Fatalf("expected schedule closed, got %v", err)
+	}
+	listed, err := service.List(ctx, request.TenantID, 0, 10, ListFilter{})
+	if err != nil {
+		test.Fatalf("list: %v", err)
+	}
+	if len(listed) != 1 {
+		test.Fatalf("expected one schedule, got %d", len(listed))
+	}
+}
+
+func TestNewServiceAndWorkerRejectMissingDependencies(test *testing.T) {
+	test.Parallel()
+	clock := func() int64 { return 0 }
+	if _, err := NewService(nil, clock); !errors.Is(err, ledger.ErrInvalidServiceConfig) {
+		test.Fatalf("expected invalid config for nil store, got %v", err)
+	}
+	if _, err := NewService(newMemoryStore(), nil); !errors.Is(err, ledger.ErrInvalidServiceConfig) {
+		test.Fatalf("expected invalid config for nil clock, got %v", err)
+	}
+	if _, err := NewWorker(nil, newRecordingGranter(), clock); !errors.Is(err, ledger.ErrInvalidServiceConfig) {
+		test.Fatalf("expected invalid config for nil store, got %v", err)
+	}
+	if _, err := NewWorker(newMemoryStore(), nil, clock); !errors.Is(err, ledger.ErrInvalidServiceConfig) {
+		test.Fatalf("expected invalid config for nil granter, got %v", err)
+	}
+	if _, err := NewWorker(newMemoryStore(), newRecordingGranter(), nil); !errors.Is(err, ledger.ErrInvalidServiceConfig) {
+		test.Fatalf("expected invalid config for nil clock, got %v", err)
+	}
+}
+
+func TestWorkerRunDueCatchesUpAndExpiresGrants(test *testing.T) {
+	test.Parallel()
+	store := newMemoryStore()
+	granter := newRecordingGranter()
+	nowUnixUTC := testStartUnixUTC
+	clock := func() int64 { return nowUnixUTC }
+	service, err := NewService(store, clock)
+	if err != nil {
+		test.Fatalf("new service: %v", err)
+	}
+	worker, err := NewWorker(store, granter, clock)
+	if err != nil {
+		test.Fatalf("new worker: %v", err)
+	}
+	ctx := context.Background()
+	request := newCreateRequest(test, "daily")
+	request.Interval = IntervalDaily
+	if _, err := service.Create(ctx, request); err != nil {
+		test.Fatalf("create: %v", err)
+	}
+
+	nowUnixUTC = testStartUnixUTC + 2*testDay
+	processed, err := worker.RunDue(ctx)
+	if err != nil {
+		test.Fatalf("run due: %v", err)
+	}
+	if processed != 3 {
+		test.Fatalf("expected three periods, got %d", processed)
+	}
+	expectedKeys := []string{"schedule:daily:2026-01-01", "schedule:daily:2026-01-02", "schedule:daily:2026-01-03"}
+	if keys := granter.keys(); !slices.Equal(keys, expectedKeys) {
+		test.Fatalf("expected keys %v, got %v", expectedKeys, keys)
+	}
+	if expiresAt := granter.grants["schedule:daily:2026-01-02"]; expiresAt != testStartUnixUTC+testDay+request.GrantTTLSeconds {
+		test.Fatalf("unexpected expiry %d", expiresAt)
+	}
+
+	processed, err = worker.RunDue(ctx)
+	if err != nil {
+		test.Fatalf("run due again: %v", err)
+	}
+	if processed != 0 {
+		test.Fatalf("expected no further periods, got %d", processed)
+	}
+	stored, err := store.GetSchedule(ctx, request.TenantID, request.ScheduleID)
+	if err != nil {
+		test.Fatalf("get schedule: %v", err)
+	}
+	if stored.NextPeriod != 3 || stored.NextRunUnixUTC != testStartUnixUTC+3*testDay {
+		test.Fatalf("unexpected schedule cursor: %+v", stored)
+	}
+}
+
+func TestWorkerRunDueToleratesDuplicateGrantsFromConcurrentWorkers(test *testing.T) {
+	test.Parallel()
+	store := newMemoryStore()
+	granter := newRecordingGranter()
+	clock := func() int64 { return testStartUnixUTC }
+	service, err := NewService(store, clock)
+	if err != nil {
+		test.Fatalf("new service: %v", err)
+	}
+	request := newCreateRequest(test, "concurrent")
+	ctx := context.Background()
+	schedule, err := service.Create(ctx, request)
+	if err != nil {
+		test.Fatalf("create: %v", err)
+	}
+	firstKey, err := schedule.IdempotencyKey(0)
+	if err != nil {
+		test.Fatalf("idempotency key: %v", err)
+	}
+	granter.grants[firstKey.String()] = 0
+
+	worker, err := NewWorker(store, granter, clock)
+	if err != nil {
+		test.Fatalf("new worker: %v", err)
+	}
+	processed, err := worker.RunDue(ctx)
+	if err != nil {
+		test.Fatalf("run due: %v", err)
+	}
+	if processed != 1 || len(granter.grants) != 1 {
+		test.Fatalf("expected duplicate grant to be treated as granted, processed=%d grants=%d", processed, len(granter.grants))
+	}
+}
+
+func TestWorkerRunDueCompletesAtEnd(test *testing.T) {
+	test.Parallel()
+	store := newMemoryStore()
+	granter := newRecordingGranter()
+	nowUnixUTC := testStartUnixUTC
+	clock := func() int64 { return nowUnixUTC }
+	service, err := NewService(store, clock)
+	if err != nil {
+		test.Fatalf("new service: %v", err)
+	}
+	worker, err := NewWorker(store, granter, clock)
+	if err != nil {
+		test.Fatalf("new worker: %v", err)
+	}
+	ctx := context.Background()
+	request := newCreateRequest(test, "two-weeks")
+	request.Interval = IntervalWeekly
+	request.GrantTTLSeconds = 0
+	request.EndUnixUTC = testStartUnixUTC + 14*testDay
+	if _, err := service.Create(ctx, request); err != nil {
+		test.Fatalf("create: %v", err)
+	}
+
+	nowUnixUTC = testStartUnixUTC + 60*testDay
+	processed, err := worker.RunDue(ctx)
+	if err != nil {
+		test.Fatalf("run due: %v", err)
+	}
+	if processed != 2 || len(granter.grants) != 2 {
+		test.Fatalf("expected two grants before end, processed=%d grants=%d", processed, len(granter.grants))
+	}
+	stored, err := store.GetSchedule(ctx, request.TenantID, request.ScheduleID)
+	if err != nil {
+		test.Fatalf("get schedule: %v", err)
+	}
+	if stored.Status != StatusCompleted {
+		test.Fatalf("expected completed schedule, got %q", stored.Status)
+	}
+}
+
+func TestWorkerRunDueReportsGrantErrors(test *testing.T) {
+	test.Parallel()
+	store := newMemoryStore()
+	granter := newRecordingGranter()
+	granter.err = errors.New("grant failed")
+	clock := func() int64 { return testStartUnixUTC }
+	service, err := NewService(store, clock)
+	if err != nil {
+		test.Fatalf("new service: %v", err)
+	}
+	worker, err := NewWorker(store, granter, clock)
+	if err != nil {
+		test.Fatalf("new worker: %v", err)
+	}
+	ctx := context.Background()
+	request := newCreateRequest(test, "failing")
+	if _, err := service.Create(ctx, request); err != nil {
+		test.Fatalf("create: %v", err)
+	}
+	processed, err := worker.RunDue(ctx)
+	if !errors.Is(err, granter.err) {
+		test.Fatalf("expected grant error, got %v", err)
+	}
+	if processed != 0 {
+		test.Fatalf("expected no processed periods, got %d", processed)
+	}
+	stored, err := store.GetSchedule(ctx, request.TenantID, request.ScheduleID)
+	if err != nil {
+		test.Fatalf("get schedule: %v", err)
+	}
+	if stored.NextPeriod != 0 {
+		test.Fatalf("expected schedule to stay on the failed period, got %d", stored.NextPeriod)
+	}
+}
+
+func TestWorkerRunDueSkipsGrantsExpiredBeforeCatchUp(test *testing.T) {
+	test.Parallel()
+	store := newMemoryStore()
+	granter := newRecordingGranter()
+	nowUnixUTC := testStartUnixUTC
+	clock := func() int64 { return nowUnixUTC }
+	service, err := NewService(store, clock)
+	if err != nil {
+		test.Fatalf("new service: %v", err)
+	}
+	worker, err := NewWorker(store, granter, clock)
+	if err != nil {
+		test.Fatalf("new worker: %v", err)
+	}
+	ctx := context.Background()
+	request := newCreateRequest(test, "daily")
+	request.Interval = IntervalDaily
+	request.GrantTTLSeconds = 2 * testDay
+	if _, err := service.Create(ctx, request); err != nil {
+		test.Fatalf("create: %v", err)
+	}
+
+	nowUnixUTC = testStartUnixUTC + 5*testDay
+	processed, err := worker.RunDue(ctx)
+	if err != nil {
+		test.Fatalf("run due: %v", err)
+	}
+	if processed != 6 {
+		test.Fatalf("expected six periods, got %d", processed)
+	}
+	expectedKeys := []string{"schedule:daily:2026-01-05", "schedule:daily:2026-01-06"}
+	if keys := granter.keys(); !slices.Equal(keys, expectedKeys) {
+		test.Fatalf("expected only unexpired grants %v, got %v", expectedKeys, keys)
+	}
+	stored, err := store.GetSchedule(ctx, request.TenantID, request.ScheduleID)
+	if err != nil {
+		test.Fatalf("get schedule: %v", err)
+	}
+	if stored.NextPeriod != 6 || stored.Status != StatusActive {
+		test.Fatalf("unexpected schedule cursor: %+v", stored)
+	}
+}
+
+func TestWorkerRunDueFailsScheduleOnPermanentGrantError(test *testing.T) {
+	test.Parallel()
+	for _, grantErr := range []error{ledger.ErrExpiringGrantNotAllowed, ledger.ErrAmountLimitExceeded, ledger.ErrUnknownLedger} {
+		test.Run(grantErr.Error(), func(test *testing.T) {
+			test.Parallel()
+			store := newMemoryStore()
+			granter := newRecordingGranter()
+			granter.err = grantErr
+			clock := func() int64 { return testStartUnixUTC }
+			service, err := NewService(store, clock)
+			if err != nil {
+				test.Fatalf("new service: %v", err)
+			}
+			worker, err := NewWorker(store, granter, clock)
+			if err != nil {
+				test.Fatalf("new worker: %v", err)
+			}
+			ctx := context.Background()
+			request := newCreateRequest(test, "rejected")
+			if _, err := service.Create(ctx, request); err != nil {
+				test.Fatalf("create: %v", err)
+			}
+			if _, err := worker.RunDue(ctx); !errors.Is(err, grantErr) {
+				test.Fatalf("expected %v, got %v", grantErr, err)
+			}
+			stored, err := store.GetSchedule(ctx, request.TenantID, request.ScheduleID)
+			if err != nil {
+				test.Fatalf("get schedule: %v", err)
+			}
+			if stored.Status != StatusFailed || stored.NextPeriod != 0 {
+				test.Fatalf("expected a failed schedule on its first period, got %+v", stored)
+			}
+			processed, err := worker.RunDue(ctx)
+			if err != nil || processed != 0 {
+				test.Fatalf("expected a failed schedule not to be retried, processed=%d err=%v", processed, err)
+			}
+		})
+	}
+}
+
+func TestWorkerRunDueReportsFailedStatusUpdates(test *testing.T) {
+	test.Parallel()
+	store := newMemoryStore()
+	granter := newRecordingGranter()
+	granter.err = ledger.ErrExpiringGrantNotAllowed
+	clock := func() int64 { return testStartUnixUTC }
+	service, err := NewService(store, clock)
+	if err != nil {
+		test.Fatalf("new service: %v", err)
+	}
+	worker, err := NewWorker(store, granter, clock)
+	if err != nil {
+		test.Fatalf("new worker: %v", err)
+	}
+	ctx := context.Background()
+	if _, err := service.Create(ctx, newCreateRequest(test, "rejected")); err != nil {
+		test.Fatalf("create: %v", err)
+	}
+	store.updateErr = errors.New("update failed")
+	if _, err := worker.RunDue(ctx); !errors.Is(err, granter.err) || !errors.Is(err, store.updateErr) {
+		test.Fatalf("expected grant and update errors, got %v", err)
+	}
+}
+
+func TestWorkerRunDueReportsAdvanceErrors(test *testing.T) {
+	test.Parallel()
+	store := newMemoryStore()
+	granter := newRecordingGranter()
+	clock := func() int64 { return testStartUnixUTC }
+	service, err := NewService(store, clock)
+	if err != nil {
+		test.Fatalf("new service: %v", err)
+	}
+	worker, err := NewWorker(store, granter, clock)
+	if err != nil {
+		test.Fatalf("new worker: %v", err)
+	}
+	ctx := context.Background()
+	if _, err := service.Create(ctx, newCreateRequest(test, "stuck")); err != nil {
+		test.Fatalf("create: %v", err)
+	}
+	store.advanceErr = errors.New("advance failed")
+	processed, err := worker.RunDue(ctx)
+	if !errors.Is(err, store.advanceErr) || processed != 0 {
+		test.Fatalf("expected advance error with nothing processed, processed=%d err=%v", processed, err)
+	}
+}
+
+func TestWorkerRunDueCompletesScheduleAlreadyPastItsEnd(test *testing.T) {
+	test.Parallel()
+	store := newMemoryStore()
+	granter := newRecordingGranter()
+	worker, err := NewWorker(store, granter, func() int64 { return testStartUnixUTC + 2*testDay })
+	if err != nil {
+		test.Fatalf("new worker: %v", err)
+	}
+	ctx := context.Background()
+	request := newCreateRequest(test, "ended")
+	schedule := Schedule{
+		ScheduleID:     request.ScheduleID,
+		TenantID:       request.TenantID,
+		UserID:         request.UserID,
+		LedgerID:       request.LedgerID,
+		Amount:         request.Amount,
+		Interval:       IntervalDaily,
+		StartUnixUTC:   testStartUnixUTC,
+		EndUnixUTC:     testStartUnixUTC + testDay,
+		Metadata:       request.Metadata,
+		Status:         StatusActive,
+		NextPeriod:     1,
+		NextRunUnixUTC: testStartUnixUTC + testDay,
+	}
+	if _, err := store.CreateSchedule(ctx, schedule); err != nil {
+		test.Fatalf("create schedule: %v", err)
+	}
+	processed, err := worker.RunDue(ctx)
+	if err != nil || processed != 0 || len(granter.grants) != 0 {
+		test.Fatalf("expected no grants, processed=%d grants=%d err=%v", processed, len(granter.grants), err)
+	}
+	stored, err := store.GetSchedule(ctx, request.TenantID, request.ScheduleID)
+	if err != nil {
+		test.Fatalf("get schedule: %v", err)
+	}
+	if stored.Status != StatusCompleted {
+		test.Fatalf("expected completed schedule, got %q", stored.Status)
+	}
+}
+
+func TestWorkerRunPollsEveryInterval(test *testing.T) {
+	test.Parallel()
+	store := newMemoryStore()
+	store.listErr = errors.New("list failed")
+	worker, err := NewWorker(store, newRecordingGranter(), func() int64 { return testStartUnixUTC })
+	if err != nil {
+		test.Fatalf("new worker: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	errorsSeen := make(chan error, 2)
+	go worker.Run(ctx, time.Millisecond, func(runErr error) {
+		select {
+		case errorsSeen <- runErr:
+		default:
+		}
+	})
+	for range 2 {
+		select {
+		case <-errorsSeen:
+		case <-time.After(5 * time.Second):
+			test.Fatalf("worker did not poll again")
+		}
+	}
+}
+
+func TestWorkerRunStopsWhenContextIsCancelled(test *testing.T) {
+	test.Parallel()
+	store := newMemoryStore()
+	store.listErr = errors.New("list failed")
+	worker, err := NewWorker(store, newRecordingGranter(), func() int64 { return testStartUnixUTC })
+	if err != nil {
+		test.Fatalf("new worker: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	errorsSeen := make(chan error, 1)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		worker.Run(ctx, time.Hour, func(runErr error) {
+			errorsSeen <- runErr
+		})
+	}()
+	if runErr := <-errorsSeen; !errors.Is(runErr, store.listErr) {
+		test.Fatalf("expected list error, got %v", runErr)
+	}
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		test.Fatalf("worker did not stop after cancellation")
+	}
+}
+
+func newCreateRequest(test *testing.T, rawScheduleID string) CreateRequest {
+	test.Helper()
+	tenantID, err := ledger.NewTenantID("tenant-default")
+	if err != nil {
+		test.Fatalf("tenant id: %v", err)
+	}
+	userID, err := ledger.NewUserID("user-1")
+	if err != nil {
+		test.Fatalf("user id: %v", err)
+	}
+	ledgerID, err := ledger.NewLedgerID("ledger-default")
+	if err != nil {
+		test.Fatalf("ledger id: %v", err)
+	}
+	amount, err := ledger.NewPositiveAmountCents(500)
+	if err != nil {
+		test.Fatalf("amount: %v", err)
+	}
+	metadata, err := ledger.NewMetadataJSON("{}")
+	if err != nil {
+		test.Fatalf("metadata: %v", err)
+	}
+	return CreateRequest{
+		ScheduleID:      mustScheduleID(test, rawScheduleID),
+		TenantID:        tenantID,
+		UserID:          userID,
+		LedgerID:        ledgerID,
+		Amount:          amount,
+		Interval:        IntervalMonthly,
+		GrantTTLSeconds: 30 * testDay,
+		Metadata:        metadata,
+	}
+}
+
+type recordingGranter struct {
+	mutex  sync.Mutex
+	grants map[string]int64
+	err    error
+}
+
+func newRecordingGranter() *recordingGranter {
+	return &recordingGranter{grants: map[string]int64{}}
+}
+
+func (granter *recordingGranter) GrantEntry(_ context.Context, _ ledger.TenantID, _ ledger.UserID, _ ledger.LedgerID, _ ledger.PositiveAmountCents, idempotencyKey ledger.IdempotencyKey, expiresAtUnixUTC int64, _ ledger.MetadataJSON) (ledger.Entry, error) {
+	granter.mutex.Lock()
+	defer granter.mutex.Unlock()
+	if granter.err != nil {
+		return ledger.Entry{}, granter.err
+	}
+	if _, exists := granter.grants[idempotencyKey.String()]; exists {
+		return ledger.Entry{}, ledger.ErrDuplicateIdempotencyKey
+	}
+	granter.grants[idempotencyKey.String()] = expiresAtUnixUTC
+	return ledger.Entry{}, nil
+}
+
+func (granter *recordingGranter) keys() []string {
+	keys := make([]string, 0, len(granter.grants))
+	for key := range granter.grants {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+type memoryStore struct {
+	mutex      sync.Mutex
+	schedules  map[string]Schedule
+	listErr    error
+	updateErr  error
+	advanceErr error
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{schedules: map[string]Schedule{}}
+}
+
+func memoryKey(tenantID ledger.TenantID, scheduleID ScheduleID) string {
+	return tenantID.String() + "/" + scheduleID.String()
+}
+
+func (store *memoryStore) CreateSchedule(_ context.Context, schedule Schedule) (Schedule, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	key := memoryKey(schedule.TenantID, schedule.ScheduleID)
+	if _, exists := store.schedules[key]; exists {
+		return Schedule{}, ErrScheduleExists
+	}
+	store.schedules[key] = schedule
+	return schedule, nil
+}
+
+func (store *memoryStore) GetSchedule(_ context.Context, tenantID ledger.TenantID, scheduleID ScheduleID) (Schedule, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	schedule, exists := store.schedules[memoryKey(tenantID, scheduleID)]
+	if !exists {
+		return Schedule{}, ErrUnknownSchedule
+	}
+	return schedule, nil
+}
+
+func (store *memoryStore) ListSchedules(_ context.Context, tenantID ledger.TenantID, _ int64, limit int, _ ListFilter) ([]Schedule, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	result := make([]Schedule, 0, len(store.schedules))
+	for _, schedule := range store.schedules {
+		if schedule.TenantID == tenantID && len(result) < limit {
+			result = append(result, schedule)
+		}
+	}
+	return result, nil
+}
+
+func (store *memoryStore) ListDueSchedules(_ context.Context, atUnixUTC int64, limit int) ([]Schedule, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	if store.listErr != nil {
+		return nil, store.listErr
+	}
+	result := make([]Schedule, 0, len(store.schedules))
+	for _, schedule := range store.schedules {
+		if schedule.Status == StatusActive && schedule.NextRunUnixUTC <= atUnixUTC && len(result) < limit {
+			result = append(result, schedule)
+		}
+	}
+	return result, nil
+}
+
+func (store *memoryStore) UpdateScheduleStatus(_ context.Context, tenantID ledger.TenantID, scheduleID ScheduleID, from, to Status, updatedUnixUTC int64) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	if store.updateErr != nil {
+		return store.updateErr
+	}
+	key := memoryKey(tenantID, scheduleID)
+	schedule, exists := store.schedules[key]
+	if !exists {
+		return ErrUnknownSchedule
+	}
+	if schedule.Status != from {
+		return ErrScheduleClosed
+	}
+	schedule.Status = to
+	schedule.UpdatedUnixUTC = updatedUnixUTC
+	store.schedules[key] = schedule
+	return nil
+}
+
+func (store *memoryStore) AdvanceSchedule(_ context.Context, tenantID ledger.TenantID, scheduleID ScheduleID, fromPeriod int64, nextRunUnixUTC int64, status Status, updatedUnixUTC int64) (bool, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	if store.advanceErr != nil {
+		return false, store.advanceErr
+	}
+	key := memoryKey(tenantID, scheduleID)
+	schedule, exists := store.schedules[key]
+	if !exists || schedule.Status != StatusActive || schedule.NextPeriod != fromPeriod {
+		return false, nil
+	}
+	schedule.NextPeriod = fromPeriod + 1
+	schedule.NextRunUnixUTC = nextRunUnixUTC
+	schedule.Status = status
+	schedule.UpdatedUnixUTC = updatedUnixUTC
+	store.schedules[key] = schedule
+	return true, nil
+}
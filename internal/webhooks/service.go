@@ -0,0 +1,35 @@
+package webhooks
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/MarkoPoloResearchLab/ledger/pkg/ledger"
+)
+
+// Service lists outbox events and replays their delivery.
+type Service struct {
+	store Store
+	nowFn func() int64
+}
+
+// NewService wires a webhooks Service.
+func NewService(store Store, now func() int64) (*Service, error) {
+	if store == nil {
+		return nil, fmt.Errorf("%w: store dependency is nil", ledger.ErrInvalidServiceConfig)
+	}
+	if now == nil {
+		return nil, fmt.Errorf("%w: clock dependency is nil", ledger.ErrInvalidServiceConfig)
+	}
+	return &Service{store: store, nowFn: now}, nil
+}
+
+// List pages a tenant's events in reverse-chronological order.
+func (service *Service) List(ctx context.Context, tenantID ledger.TenantID, beforeCreatedUnixUTC int64, limit int, filter ListFilter) ([]Event, error) {
+	return service.store.ListEvents(ctx, tenantID, beforeCreatedUnixUTC, limit, filter)
+}
+
+// Replay queues an event for immediate redelivery with a fresh retry budget, whatever its current status.
+func (service *Service) Replay(ctx context.Context, tenantID ledger.TenantID, eventID EventID) (Event, error) {
+	return service.store.ResetEvent(ctx, tenantID, eventID, service.nowFn())
+}
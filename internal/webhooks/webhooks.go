@@ -0,0 +1,159 @@
+package webhooks
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/MarkoPoloResearchLab/ledger/pkg/ledger"
+)
+
+const (
+	errorEmptyValue        = "empty value"
+	errorUnknownValue      = "unknown value"
+	errorMustBeAbsoluteURL = "must be an absolute http or https url"
+
+	// SignatureHeader carries "t=<unix seconds>,v1=<hex HMAC-SHA256 of "<t>.<body>">" keyed by the tenant's signing secret.
+	SignatureHeader = "X-Ledger-Signature"
+	// EventIDHeader carries the event id, which stays the same across retries and replays.
+	EventIDHeader = "X-Ledger-Event-Id"
+	// EventTypeHeader carries the event type, for example "entry.spend".
+	EventTypeHeader = "X-Ledger-Event-Type"
+
+	signatureVersion = "v1"
+)
+
+// Webhook-level error values returned by the webhooks service.
+var (
+	ErrInvalidEventID     = errors.New("invalid event id")
+	ErrInvalidEventStatus = errors.New("invalid event status")
+	ErrInvalidEndpoint    = errors.New("invalid webhook endpoint")
+	ErrUnknownEvent       = errors.New("unknown event")
+)
+
+// EventID identifies an outbox event.
+type EventID struct {
+	value string
+}
+
+// NewEventID validates and normalizes an event id.
+func NewEventID(raw string) (EventID, error) {
+	trimmed := strings.TrimSpace(raw)
+	if trimmed == "" {
+		return EventID{}, fmt.Errorf("%w: %s", ErrInvalidEventID, errorEmptyValue)
+	}
+	return EventID{value: trimmed}, nil
+}
+
+// String returns the normalized identifier.
+func (id EventID) String() string {
+	return id.value
+}
+
+// Status defines the delivery lifecycle of an event.
+type Status string
+
+const (
+	StatusPending   Status = "pending"
+	StatusDelivered Status = "delivered"
+	StatusDead      Status = "dead"
+)
+
+// ParseStatus validates event status values.
+func ParseStatus(raw string) (Status, error) {
+	status := Status(strings.TrimSpace(raw))
+	if !status.IsValid() {
+		return "", fmt.Errorf("%w: %s", ErrInvalidEventStatus, errorUnknownValue)
+	}
+	return status, nil
+}
+
+// String returns the status as a primitive value.
+func (status Status) String() string {
+	return string(status)
+}
+
+// IsValid reports whether the status is recognized.
+func (status Status) IsValid() bool {
+	switch status {
+	case StatusPending, StatusDelivered, StatusDead:
+		return true
+	default:
+		return false
+	}
+}
+
+// Event is an outbox event and its delivery state.
+type Event struct {
+	EventID            EventID
+	TenantID           ledger.TenantID
+	EventType          string
+	PayloadJSON        string
+	Status             Status
+	Attempts           int
+	NextAttemptUnixUTC int64
+	LastError          string
+	CreatedUnixUTC     int64
+	DeliveredUnixUTC   int64
+}
+
+// DeliveryUpdate records the outcome of a delivery attempt.
+type DeliveryUpdate struct {
+	Status             Status
+	Attempts           int
+	NextAttemptUnixUTC int64
+	LastError          string
+	DeliveredUnixUTC   int64
+}
+
+// Endpoint is where a tenant's events are POSTed and the secret their signatures are keyed with.
+type Endpoint struct {
+	URL           string
+	SigningSecret string
+}
+
+// NewEndpoint validates a webhook URL and signing secret.
+func NewEndpoint(rawURL string, signingSecret string) (Endpoint, error) {
+	trimmedURL := strings.TrimSpace(rawURL)
+	parsedURL, err := url.Parse(trimmedURL)
+	if err != nil || (parsedURL.Scheme != "http" && parsedURL.Scheme != "https") || parsedURL.Host == "" {
+		return Endpoint{}, fmt.Errorf("%w: url %s", ErrInvalidEndpoint, errorMustBeAbsoluteURL)
+	}
+	if strings.TrimSpace(signingSecret) == "" {
+		return Endpoint{}, fmt.Errorf("%w: signing secret %s", ErrInvalidEndpoint, errorEmptyValue)
+	}
+	return Endpoint{URL: trimmedURL, SigningSecret: signingSecret}, nil
+}
+
+// Sign returns the SignatureHeader value for a body sent at timestampUnixUTC.
+// Receivers recompute the HMAC over "<t>.<body>" and should reject stale timestamps.
+func Sign(signingSecret string, timestampUnixUTC int64, body []byte) string {
+	timestamp := strconv.FormatInt(timestampUnixUTC, 10)
+	mac := hmac.New(sha256.New, []byte(signingSecret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "t=" + timestamp + "," + signatureVersion + "=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// ListFilter narrows ListEvents queries.
+type ListFilter struct {
+	Statuses []Status
+}
+
+// Store is the persistence contract used by the webhooks Service and Worker.
+type Store interface {
+	ListEvents(ctx context.Context, tenantID ledger.TenantID, beforeCreatedUnixUTC int64, limit int, filter ListFilter) ([]Event, error)
+	ListDueEvents(ctx context.Context, tenantIDs []ledger.TenantID, atUnixUTC int64, limit int) ([]Event, error)
+	// ClaimEvent pushes a due pending event's next attempt to leaseUntilUnixUTC, reporting false when another worker claimed it first.
+	ClaimEvent(ctx context.Context, eventID EventID, atUnixUTC int64, leaseUntilUnixUTC int64) (bool, error)
+	UpdateDelivery(ctx context.Context, eventID EventID, update DeliveryUpdate) error
+	// ResetEvent makes an event pending again with no attempts, returning ErrUnknownEvent when the tenant has no such event.
+	ResetEvent(ctx context.Context, tenantID ledger.TenantID, eventID EventID, nextAttemptUnixUTC int64) (Event, error)
+}
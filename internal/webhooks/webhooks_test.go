@@ -0,0 +1,65 @@
+package webhooks
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"testing"
+)
+
+func TestNewEndpointValidatesURLAndSecret(test *testing.T) {
+	test.Parallel()
+	testCases := []struct {
+		name    string
+		url     string
+		secret  string
+		wantErr bool
+	}{
+		{name: "https", url: "https://hooks.example.com/ledger", secret: "secret"},
+		{name: "http", url: " http://localhost:8080/hook ", secret: "secret"},
+		{name: "relative", url: "/hook", secret: "secret", wantErr: true},
+		{name: "scheme", url: "ftp://hooks.example.com", secret: "secret", wantErr: true},
+		{name: "secret", url: "https://hooks.example.com", secret: " ", wantErr: true},
+	}
+	for _, testCase := range testCases {
+		endpoint, err := NewEndpoint(testCase.url, testCase.secret)
+		if testCase.wantErr {
+			if !errors.Is(err, ErrInvalidEndpoint) {
+				test.Fatalf("%s: expected invalid endpoint, got %v", testCase.name, err)
+			}
+			continue
+		}
+		if err != nil || endpoint.URL == "" || endpoint.URL[0] == ' ' {
+			test.Fatalf("%s: unexpected endpoint %+v (%v)", testCase.name, endpoint, err)
+		}
+	}
+}
+
+func TestSignIsKeyedAndTimestamped(test *testing.T) {
+	test.Parallel()
+	body := []byte(`{"id":"evt-1"}`)
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write([]byte("1700000000." + string(body)))
+	expected := "t=1700000000,v1=" + hex.EncodeToString(mac.Sum(nil))
+	signature := Sign("secret", 1700000000, body)
+	if signature != expected {
+		test.Fatalf("expected %q, got %q", expected, signature)
+	}
+	if Sign("other", 1700000000, body) == signature || Sign("secret", 1700000001, body) == signature || Sign("secret", 1700000000, []byte("{}")) == signature {
+		test.Fatalf("expected secret, timestamp, and body to change the signature")
+	}
+}
+
+func TestParsersRejectUnknownValues(test *testing.T) {
+	test.Parallel()
+	if _, err := ParseStatus("retrying"); !errors.Is(err, ErrInvalidEventStatus) {
+		test.Fatalf("expected invalid event status, got %v", err)
+	}
+	if status, err := ParseStatus(" dead "); err != nil || status != StatusDead {
+		test.Fatalf("expected dead status, got %q (%v)", status, err)
+	}
+	if _, err := NewEventID(" "); !errors.Is(err, ErrInvalidEventID) {
+		test.Fatalf("expected invalid event id, got %v", err)
+	}
+}
@@ -0,0 +1,222 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/MarkoPoloResearchLab/ledger/pkg/ledger"
+)
+
+const (
+	defaultDueBatchSize   = 100
+	defaultMaxAttempts    = 10
+	defaultRetryBackoff   = 30 * time.Second
+	defaultMaxBackoff     = time.Hour
+	defaultRequestTimeout = 10 * time.Second
+	// claimLeaseSeconds keeps other workers off an event while a delivery is in flight.
+	claimLeaseSeconds = 60
+	contentTypeJSON   = "application/json"
+	maxErrorBodyBytes = 512
+)
+
+// WorkerOption configures optional Worker settings.
+type WorkerOption func(*Worker)
+
+// WithMaxAttempts sets how many failed deliveries move an event to the dead-letter state.
+func WithMaxAttempts(maxAttempts int) WorkerOption {
+	return func(worker *Worker) {
+		if maxAttempts > 0 {
+			worker.maxAttempts = maxAttempts
+		}
+	}
+}
+
+// WithRetryBackoff sets the delay after the first failure; it doubles per attempt up to maxBackoff.
+func WithRetryBackoff(backoff time.Duration, maxBackoff time.Duration) WorkerOption {
+	return func(worker *Worker) {
+		if backoff > 0 {
+			worker.retryBackoff = backoff
+		}
+		if maxBackoff > 0 {
+			worker.maxBackoff = maxBackoff
+		}
+	}
+}
+
+// WithHTTPClient replaces the HTTP client used for deliveries.
+func WithHTTPClient(client *http.Client) WorkerOption {
+	return func(worker *Worker) {
+		if client != nil {
+			worker.client = client
+		}
+	}
+}
+
+// Worker POSTs due outbox events to their tenant's endpoint.
+type Worker struct {
+	store        Store
+	endpoints    map[ledger.TenantID]Endpoint
+	tenantIDs    []ledger.TenantID
+	client       *http.Client
+	nowFn        func() int64
+	batchSize    int
+	maxAttempts  int
+	retryBackoff time.Duration
+	maxBackoff   time.Duration
+}
+
+// NewWorker wires a webhooks Worker delivering events of the tenants in endpoints.
+func NewWorker(store Store, endpoints map[ledger.TenantID]Endpoint, now func() int64, options ...WorkerOption) (*Worker, error) {
+	if store == nil {
+		return nil, fmt.Errorf("%w: store dependency is nil", ledger.ErrInvalidServiceConfig)
+	}
+	if now == nil {
+		return nil, fmt.Errorf("%w: clock dependency is nil", ledger.ErrInvalidServiceConfig)
+	}
+	worker := &Worker{
+		store:        store,
+		endpoints:    endpoints,
+		client:       &http.Client{Timeout: defaultRequestTimeout},
+		nowFn:        now,
+		batchSize:    defaultDueBatchSize,
+		maxAttempts:  defaultMaxAttempts,
+		retryBackoff: defaultRetryBackoff,
+		maxBackoff:   defaultMaxBackoff,
+	}
+	for tenantID := range endpoints {
+		worker.tenantIDs = append(worker.tenantIDs, tenantID)
+	}
+	for _, option := range options {
+		if option != nil {
+			option(worker)
+		}
+	}
+	return worker, nil
+}
+
+// Run calls RunDue every pollInterval until ctx is cancelled. Errors are reported through onError and do not stop the loop.
+func (worker *Worker) Run(ctx context.Context, pollInterval time.Duration, onError func(error)) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		if _, err := worker.RunDue(ctx); err != nil && onError != nil && ctx.Err() == nil {
+			onError(err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunDue attempts every due event once and returns the number delivered.
+// Failed deliveries are rescheduled with exponential backoff and dead-lettered after the last attempt.
+func (worker *Worker) RunDue(ctx context.Context) (int, error) {
+	if len(worker.tenantIDs) == 0 {
+		return 0, nil
+	}
+	nowUnixUTC := worker.nowFn()
+	dueEvents, err := worker.store.ListDueEvents(ctx, worker.tenantIDs, nowUnixUTC, worker.batchSize)
+	if err != nil {
+		return 0, err
+	}
+	delivered := 0
+	var runErrors []error
+	for _, event := range dueEvents {
+		claimed, err := worker.store.ClaimEvent(ctx, event.EventID, nowUnixUTC, nowUnixUTC+claimLeaseSeconds)
+		if err != nil {
+			runErrors = append(runErrors, fmt.Errorf("event %s: %w", event.EventID.String(), err))
+			continue
+		}
+		if !claimed {
+			continue
+		}
+		deliveryErr := worker.deliver(ctx, event)
+		if err := worker.store.UpdateDelivery(ctx, event.EventID, worker.outcome(event, deliveryErr)); err != nil {
+			runErrors = append(runErrors, fmt.Errorf("event %s: %w", event.EventID.String(), err))
+			continue
+		}
+		if deliveryErr != nil {
+			runErrors = append(runErrors, fmt.Errorf("event %s: %w", event.EventID.String(), deliveryErr))
+			continue
+		}
+		delivered++
+	}
+	return delivered, errors.Join(runErrors...)
+}
+
+func (worker *Worker) outcome(event Event, deliveryErr error) DeliveryUpdate {
+	nowUnixUTC := worker.nowFn()
+	attempts := event.Attempts + 1
+	if deliveryErr == nil {
+		return DeliveryUpdate{Status: StatusDelivered, Attempts: attempts, NextAttemptUnixUTC: event.NextAttemptUnixUTC, DeliveredUnixUTC: nowUnixUTC}
+	}
+	if attempts >= worker.maxAttempts {
+		return DeliveryUpdate{Status: StatusDead, Attempts: attempts, NextAttemptUnixUTC: event.NextAttemptUnixUTC, LastError: deliveryErr.Error()}
+	}
+	return DeliveryUpdate{
+		Status:             StatusPending,
+		Attempts:           attempts,
+		NextAttemptUnixUTC: nowUnixUTC + int64(worker.backoff(attempts)/time.Second),
+		LastError:          deliveryErr.Error(),
+	}
+}
+
+// backoff doubles the retry delay with every failed attempt, capped at maxBackoff.
+func (worker *Worker) backoff(attempts int) time.Duration {
+	delay := worker.retryBackoff
+	for attempt := 1; attempt < attempts && delay < worker.maxBackoff; attempt++ {
+		delay *= 2
+	}
+	return min(delay, worker.maxBackoff)
+}
+
+type eventEnvelope struct {
+	ID             string          `json:"id"`
+	Type           string          `json:"type"`
+	TenantID       string          `json:"tenant_id"`
+	CreatedUnixUTC int64           `json:"created_unix_utc"`
+	Data           json.RawMessage `json:"data"`
+}
+
+func (worker *Worker) deliver(ctx context.Context, event Event) error {
+	endpoint, ok := worker.endpoints[event.TenantID]
+	if !ok {
+		return fmt.Errorf("%w: no endpoint for tenant %s", ErrInvalidEndpoint, event.TenantID.String())
+	}
+	body, err := json.Marshal(eventEnvelope{
+		ID:             event.EventID.String(),
+		Type:           event.EventType,
+		TenantID:       event.TenantID.String(),
+		CreatedUnixUTC: event.CreatedUnixUTC,
+		Data:           json.RawMessage(event.PayloadJSON),
+	})
+	if err != nil {
+		return err
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", contentTypeJSON)
+	request.Header.Set(EventIDHeader, event.EventID.String())
+	request.Header.Set(EventTypeHeader, event.EventType)
+	request.Header.Set(SignatureHeader, Sign(endpoint.SigningSecret, worker.nowFn(), body))
+	response, err := worker.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = response.Body.Close() }()
+	if response.StatusCode < http.StatusOK || response.StatusCode >= http.StatusMultipleChoices {
+		responseBody, _ := io.ReadAll(io.LimitReader(response.Body, maxErrorBodyBytes))
+		return fmt.Errorf("webhook responded %d: %s", response.StatusCode, bytes.TrimSpace(responseBody))
+	}
+	return nil
+}
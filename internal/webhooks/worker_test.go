@@ -0,0 +1,465 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/MarkoPoloResearchLab/ledger/pkg/ledger"
+)
+
+const testNowUnixUTC = int64(1767225600) // 2026-01-01T00:00:00Z
+
+func TestWorkerDeliversSignedEvents(test *testing.T) {
+	test.Parallel()
+	tenantID := mustTenantID(test, "tenant-a")
+	var received atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		body, _ := io.ReadAll(request.Body)
+		if request.Header.Get(SignatureHeader) != Sign("tenant-a-secret", testNowUnixUTC, body) {
+			writer.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var envelope map[string]any
+		if err := json.Unmarshal(body, &envelope); err != nil || envelope["id"] != "evt-1" || envelope["type"] != "entry.spend" || envelope["tenant_id"] != "tenant-a" {
+			writer.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if data, ok := envelope["data"].(map[string]any); !ok || data["amount_cents"] != float64(-200) {
+			writer.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if request.Header.Get(EventIDHeader) != "evt-1" || request.Header.Get(EventTypeHeader) != "entry.spend" {
+			writer.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		received.Add(1)
+		writer.WriteHeader(http.StatusNoContent)
+	}))
+	test.Cleanup(server.Close)
+
+	store := newMemoryStore()
+	store.add(Event{EventID: mustEventID(test, "evt-1"), TenantID: tenantID, EventType: "entry.spend", PayloadJSON: `{"amount_cents":-200}`, Status: StatusPending, NextAttemptUnixUTC: testNowUnixUTC, CreatedUnixUTC: testNowUnixUTC})
+	store.add(Event{EventID: mustEventID(test, "evt-other"), TenantID: mustTenantID(test, "tenant-b"), EventType: "entry.grant", PayloadJSON: `{}`, Status: StatusPending, NextAttemptUnixUTC: testNowUnixUTC, CreatedUnixUTC: testNowUnixUTC})
+	worker := mustNewWorker(test, store, map[ledger.TenantID]Endpoint{tenantID: mustEndpoint(test, server.URL, "tenant-a-secret")}, func() int64 { return testNowUnixUTC })
+
+	delivered, err := worker.RunDue(context.Background())
+	if err != nil {
+		test.Fatalf("run due: %v", err)
+	}
+	if delivered != 1 || received.Load() != 1 {
+		test.Fatalf("expected one verified delivery, got %d delivered and %d received", delivered, received.Load())
+	}
+	event := store.get("evt-1")
+	if event.Status != StatusDelivered || event.Attempts != 1 || event.DeliveredUnixUTC != testNowUnixUTC {
+		test.Fatalf("unexpected delivered event: %+v", event)
+	}
+	if store.get("evt-other").Status != StatusPending {
+		test.Fatalf("expected events of tenants without an endpoint to stay pending")
+	}
+	if delivered, err := worker.RunDue(context.Background()); err != nil || delivered != 0 {
+		test.Fatalf("expected delivered events not to be sent again, got %d (%v)", delivered, err)
+	}
+}
+
+func TestWorkerRetriesWithBackoffDeadLettersAndReplays(test *testing.T) {
+	test.Parallel()
+	tenantID := mustTenantID(test, "tenant-a")
+	var healthy atomic.Bool
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		requests.Add(1)
+		if !healthy.Load() {
+			http.Error(writer, "maintenance", http.StatusServiceUnavailable)
+			return
+		}
+		writer.WriteHeader(http.StatusOK)
+	}))
+	test.Cleanup(server.Close)
+
+	var nowUnixUTC atomic.Int64
+	nowUnixUTC.Store(testNowUnixUTC)
+	clock := func() int64 { return nowUnixUTC.Load() }
+	store := newMemoryStore()
+	store.add(Event{EventID: mustEventID(test, "evt-1"), TenantID: tenantID, EventType: "entry.grant", PayloadJSON: `{}`, Status: StatusPending, NextAttemptUnixUTC: testNowUnixUTC, CreatedUnixUTC: testNowUnixUTC})
+	worker := mustNewWorker(test, store, map[ledger.TenantID]Endpoint{tenantID: mustEndpoint(test, server.URL, "secret")}, clock,
+		WithMaxAttempts(3), WithRetryBackoff(10*time.Second, 15*time.Second))
+	ctx := context.Background()
+
+	if _, err := worker.RunDue(ctx); err == nil || !strings.Contains(err.Error(), "503") {
+		test.Fatalf("expected the failed delivery to be reported, got %v", err)
+	}
+	event := store.get("evt-1")
+	if event.Status != StatusPending || event.Attempts != 1 || event.NextAttemptUnixUTC != testNowUnixUTC+10 || !strings.Contains(event.LastError, "maintenance") {
+		test.Fatalf("unexpected event after first failure: %+v", event)
+	}
+	if _, err := worker.RunDue(ctx); err != nil || requests.Load() != 1 {
+		test.Fatalf("expected no attempt before the backoff elapses, got %d requests (%v)", requests.Load(), err)
+	}
+	nowUnixUTC.Add(10)
+	_, _ = worker.RunDue(ctx)
+	if event := store.get("evt-1"); event.Attempts != 2 || event.NextAttemptUnixUTC != nowUnixUTC.Load()+15 {
+		test.Fatalf("expected the doubled backoff to be capped, got %+v", event)
+	}
+	nowUnixUTC.Add(15)
+	_, _ = worker.RunDue(ctx)
+	if event := store.get("evt-1"); event.Status != StatusDead || event.Attempts != 3 {
+		test.Fatalf("expected the event to be dead-lettered, got %+v", event)
+	}
+	nowUnixUTC.Add(3600)
+	if _, err := worker.RunDue(ctx); err != nil || requests.Load() != 3 {
+		test.Fatalf("expected dead events not to be retried, got %d requests (%v)", requests.Load(), err)
+	}
+
+	service, err := NewService(store, clock)
+	if err != nil {
+		test.Fatalf("new service: %v", err)
+	}
+	if _, err := service.Replay(ctx, mustTenantID(test, "tenant-b"), mustEventID(test, "evt-1")); !errors.Is(err, ErrUnknownEvent) {
+		test.Fatalf("expected unknown event for another tenant, got %v", err)
+	}
+	replayed, err := service.Replay(ctx, tenantID, mustEventID(test, "evt-1"))
+	if err != nil {
+		test.Fatalf("replay: %v", err)
+	}
+	if replayed.Status != StatusPending || replayed.Attempts != 0 || replayed.NextAttemptUnixUTC != nowUnixUTC.Load() || replayed.LastError != "" {
+		test.Fatalf("unexpected replayed event: %+v", replayed)
+	}
+	healthy.Store(true)
+	if delivered, err := worker.RunDue(ctx); err != nil || delivered != 1 {
+		test.Fatalf("expected the replayed event to be delivered, got %d (%v)", delivered, err)
+	}
+	deadEvents, err := service.List(ctx, tenantID, 0, 10, ListFilter{Statuses: []Status{StatusDead}})
+	if err != nil || len(deadEvents) != 0 {
+		test.Fatalf("expected no dead events after replay, got %+v (%v)", deadEvents, err)
+	}
+}
+
+func TestWorkerSkipsEventsClaimedByAnotherWorker(test *testing.T) {
+	test.Parallel()
+	tenantID := mustTenantID(test, "tenant-a")
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		requests.Add(1)
+		writer.WriteHeader(http.StatusOK)
+	}))
+	test.Cleanup(server.Close)
+	store := newMemoryStore()
+	store.add(Event{EventID: mustEventID(test, "evt-1"), TenantID: tenantID, EventType: "entry.grant", PayloadJSON: `{}`, Status: StatusPending, NextAttemptUnixUTC: testNowUnixUTC, CreatedUnixUTC: testNowUnixUTC})
+	store.claimLost = true
+	worker := mustNewWorker(test, store, map[ledger.TenantID]Endpoint{tenantID: mustEndpoint(test, server.URL, "secret")}, func() int64 { return testNowUnixUTC })
+
+	delivered, err := worker.RunDue(context.Background())
+	if err != nil || delivered != 0 || requests.Load() != 0 {
+		test.Fatalf("expected a claimed event to be skipped, got %d delivered, %d requests (%v)", delivered, requests.Load(), err)
+	}
+}
+
+func TestWorkerRunDeliversRetriesAndDeadLettersOverTLS(test *testing.T) {
+	test.Parallel()
+	tenantID := mustTenantID(test, "tenant-a")
+	var flakyRequests atomic.Int32
+	server := httptest.NewTLSServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		switch request.Header.Get(EventIDHeader) {
+		case "evt-flaky":
+			if flakyRequests.Add(1) == 1 {
+				http.Error(writer, "warming up", http.StatusServiceUnavailable)
+				return
+			}
+		case "evt-down":
+			http.Error(writer, "down", http.StatusServiceUnavailable)
+			return
+		}
+		writer.WriteHeader(http.StatusOK)
+	}))
+	test.Cleanup(server.Close)
+
+	// Every reading moves the clock past the one-second backoff, so each poll retries the failed events.
+	var nowUnixUTC atomic.Int64
+	nowUnixUTC.Store(testNowUnixUTC)
+	clock := func() int64 { return nowUnixUTC.Add(2) }
+	store := newMemoryStore()
+	for index, rawEventID := range []string{"evt-ok", "evt-flaky", "evt-down"} {
+		store.add(Event{EventID: mustEventID(test, rawEventID), TenantID: tenantID, EventType: "entry.grant", PayloadJSON: `{}`, Status: StatusPending, NextAttemptUnixUTC: testNowUnixUTC, CreatedUnixUTC: testNowUnixUTC + int64(index)})
+	}
+	// The test server's certificate is trusted only by its own client, so every delivery goes through WithHTTPClient.
+	worker := mustNewWorker(test, store, map[ledger.TenantID]Endpoint{tenantID: mustEndpoint(test, server.URL, "secret")}, clock,
+		WithMaxAttempts(3), WithRetryBackoff(time.Second, time.Second), WithHTTPClient(server.Client()))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	runErrors := make(chan error, 16)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		worker.Run(ctx, time.Millisecond, func(runErr error) {
+			select {
+			case runErrors <- runErr:
+			default:
+			}
+		})
+	}()
+	deadline := time.Now().Add(5 * time.Second)
+	for store.get("evt-ok").Status != StatusDelivered || store.get("evt-flaky").Status != StatusDelivered || store.get("evt-down").Status != StatusDead {
+		if time.Now().After(deadline) {
+			test.Fatalf("worker did not settle the events: %+v, %+v, %+v", store.get("evt-ok"), store.get("evt-flaky"), store.get("evt-down"))
+		}
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	<-done
+
+	if event := store.get("evt-ok"); event.Attempts != 1 {
+		test.Fatalf("expected one attempt for the healthy event, got %+v", event)
+	}
+	if event := store.get("evt-flaky"); event.Attempts != 2 || flakyRequests.Load() != 2 {
+		test.Fatalf("expected the flaky event to succeed on its retry, got %+v after %d requests", event, flakyRequests.Load())
+	}
+	if event := store.get("evt-down"); event.Status.String() != "dead" || event.Attempts != 3 || !strings.Contains(event.LastError, "503") {
+		test.Fatalf("expected the failing event to be dead-lettered after 3 attempts, got %+v", event)
+	}
+	select {
+	case runErr := <-runErrors:
+		if !strings.Contains(runErr.Error(), "503") {
+			test.Fatalf("expected failed deliveries to be reported, got %v", runErr)
+		}
+	default:
+		test.Fatalf("expected failed deliveries to be reported through onError")
+	}
+}
+
+func TestWorkerRunDueReportsStoreErrors(test *testing.T) {
+	test.Parallel()
+	tenantID := mustTenantID(test, "tenant-a")
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.WriteHeader(http.StatusOK)
+	}))
+	test.Cleanup(server.Close)
+	endpoints := map[ledger.TenantID]Endpoint{tenantID: mustEndpoint(test, server.URL, "secret")}
+	clock := func() int64 { return testNowUnixUTC }
+
+	if delivered, err := mustNewWorker(test, newMemoryStore(), nil, clock).RunDue(context.Background()); err != nil || delivered != 0 {
+		test.Fatalf("expected a worker without endpoints to do nothing, got %d (%v)", delivered, err)
+	}
+
+	storeErr := errors.New("store failed")
+	testCases := []struct {
+		name      string
+		configure func(store *memoryStore)
+	}{
+		{name: "list due events", configure: func(store *memoryStore) { store.listErr = storeErr }},
+		{name: "claim event", configure: func(store *memoryStore) { store.claimErr = storeErr }},
+		{name: "update delivery", configure: func(store *memoryStore) { store.updateErr = storeErr }},
+	}
+	for _, testCase := range testCases {
+		test.Run(testCase.name, func(test *testing.T) {
+			test.Parallel()
+			store := newMemoryStore()
+			store.add(Event{EventID: mustEventID(test, "evt-1"), TenantID: tenantID, EventType: "entry.grant", PayloadJSON: `{}`, Status: StatusPending, NextAttemptUnixUTC: testNowUnixUTC, CreatedUnixUTC: testNowUnixUTC})
+			testCase.configure(store)
+			delivered, err := mustNewWorker(test, store, endpoints, clock).RunDue(context.Background())
+			if !errors.Is(err, storeErr) || delivered != 0 {
+				test.Fatalf("expected %v and no deliveries, got %d (%v)", storeErr, delivered, err)
+			}
+		})
+	}
+}
+
+func TestWorkerDeliverRejectsUndeliverableEvents(test *testing.T) {
+	test.Parallel()
+	tenantID := mustTenantID(test, "tenant-a")
+	closedServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {}))
+	closedServer.Close()
+	event := Event{EventID: mustEventID(test, "evt-1"), TenantID: tenantID, EventType: "entry.grant", PayloadJSON: `{}`, Status: StatusPending, CreatedUnixUTC: testNowUnixUTC}
+	testCases := []struct {
+		name     string
+		endpoint Endpoint
+		event    func(event Event) Event
+	}{
+		{name: "tenant without endpoint", endpoint: mustEndpoint(test, closedServer.URL, "secret"), event: func(event Event) Event {
+			event.TenantID = mustTenantID(test, "tenant-b")
+			return event
+		}},
+		{name: "invalid payload", endpoint: mustEndpoint(test, closedServer.URL, "secret"), event: func(event Event) Event {
+			event.PayloadJSON = "{"
+			return event
+		}},
+		{name: "invalid url", endpoint: Endpoint{URL: "http://[::1", SigningSecret: "secret"}, event: func(event Event) Event { return event }},
+		{name: "unreachable endpoint", endpoint: mustEndpoint(test, closedServer.URL, "secret"), event: func(event Event) Event { return event }},
+	}
+	for _, testCase := range testCases {
+		test.Run(testCase.name, func(test *testing.T) {
+			test.Parallel()
+			worker := mustNewWorker(test, newMemoryStore(), map[ledger.TenantID]Endpoint{tenantID: testCase.endpoint}, func() int64 { return testNowUnixUTC })
+			if err := worker.deliver(context.Background(), testCase.event(event)); err == nil {
+				test.Fatalf("expected the delivery to fail")
+			}
+		})
+	}
+}
+
+func TestNewServiceAndWorkerRejectMissingDependencies(test *testing.T) {
+	test.Parallel()
+	clock := func() int64 { return 0 }
+	if _, err := NewService(nil, clock); !errors.Is(err, ledger.ErrInvalidServiceConfig) {
+		test.Fatalf("expected invalid service config, got %v", err)
+	}
+	if _, err := NewService(newMemoryStore(), nil); !errors.Is(err, ledger.ErrInvalidServiceConfig) {
+		test.Fatalf("expected invalid service config, got %v", err)
+	}
+	if _, err := NewWorker(nil, nil, clock); !errors.Is(err, ledger.ErrInvalidServiceConfig) {
+		test.Fatalf("expected invalid service config, got %v", err)
+	}
+	if _, err := NewWorker(newMemoryStore(), nil, nil); !errors.Is(err, ledger.ErrInvalidServiceConfig) {
+		test.Fatalf("expected invalid service config, got %v", err)
+	}
+}
+
+func mustTenantID(test *testing.T, raw string) ledger.TenantID {
+	test.Helper()
+	tenantID, err := ledger.NewTenantID(raw)
+	if err != nil {
+		test.Fatalf("tenant id: %v", err)
+	}
+	return tenantID
+}
+
+func mustEventID(test *testing.T, raw string) EventID {
+	test.Helper()
+	eventID, err := NewEventID(raw)
+	if err != nil {
+		test.Fatalf("event id: %v", err)
+	}
+	return eventID
+}
+
+func mustEndpoint(test *testing.T, rawURL string, signingSecret string) Endpoint {
+	test.Helper()
+	endpoint, err := NewEndpoint(rawURL, signingSecret)
+	if err != nil {
+		test.Fatalf("endpoint: %v", err)
+	}
+	return endpoint
+}
+
+func mustNewWorker(test *testing.T, store Store, endpoints map[ledger.TenantID]Endpoint, now func() int64, options ...WorkerOption) *Worker {
+	test.Helper()
+	worker, err := NewWorker(store, endpoints, now, options...)
+	if err != nil {
+		test.Fatalf("new worker: %v", err)
+	}
+	return worker
+}
+
+type memoryStore struct {
+	mutex     sync.Mutex
+	events    map[string]Event
+	claimLost bool
+	listErr   error
+	claimErr  error
+	updateErr error
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{events: make(map[string]Event)}
+}
+
+func (store *memoryStore) add(event Event) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	store.events[event.EventID.String()] = event
+}
+
+func (store *memoryStore) get(rawEventID string) Event {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	return store.events[rawEventID]
+}
+
+func (store *memoryStore) ListEvents(_ context.Context, tenantID ledger.TenantID, _ int64, limit int, filter ListFilter) ([]Event, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	var events []Event
+	for _, event := range store.events {
+		if event.TenantID == tenantID && (len(filter.Statuses) == 0 || slices.Contains(filter.Statuses, event.Status)) && len(events) < limit {
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+func (store *memoryStore) ListDueEvents(_ context.Context, tenantIDs []ledger.TenantID, atUnixUTC int64, limit int) ([]Event, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	if store.listErr != nil {
+		return nil, store.listErr
+	}
+	var events []Event
+	for _, event := range store.events {
+		if event.Status == StatusPending && event.NextAttemptUnixUTC <= atUnixUTC && slices.Contains(tenantIDs, event.TenantID) {
+			events = append(events, event)
+		}
+	}
+	sort.Slice(events, func(left, right int) bool { return events[left].CreatedUnixUTC < events[right].CreatedUnixUTC })
+	if len(events) > limit {
+		events = events[:limit]
+	}
+	return events, nil
+}
+
+func (store *memoryStore) ClaimEvent(_ context.Context, eventID EventID, atUnixUTC int64, leaseUntilUnixUTC int64) (bool, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	if store.claimErr != nil {
+		return false, store.claimErr
+	}
+	event, ok := store.events[eventID.String()]
+	if store.claimLost || !ok || event.Status != StatusPending || event.NextAttemptUnixUTC > atUnixUTC {
+		return false, nil
+	}
+	event.NextAttemptUnixUTC = leaseUntilUnixUTC
+	store.events[eventID.String()] = event
+	return true, nil
+}
+
+func (store *memoryStore) UpdateDelivery(_ context.Context, eventID EventID, update DeliveryUpdate) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	if store.updateErr != nil {
+		return store.updateErr
+	}
+	event := store.events[eventID.String()]
+	event.Status = update.Status
+	event.Attempts = update.Attempts
+	event.NextAttemptUnixUTC = update.NextAttemptUnixUTC
+	event.LastError = update.LastError
+	event.DeliveredUnixUTC = update.DeliveredUnixUTC
+	store.events[eventID.String()] = event
+	return nil
+}
+
+func (store *memoryStore) ResetEvent(_ context.Context, tenantID ledger.TenantID, eventID EventID, nextAttemptUnixUTC int64) (Event, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	event, ok := store.events[eventID.String()]
+	if !ok || event.TenantID != tenantID {
+		return Event{}, ErrUnknownEvent
+	}
+	event.Status = StatusPending
+	event.Attempts = 0
+	event.NextAttemptUnixUTC = nextAttemptUnixUTC
+	event.LastError = ""
+	event.DeliveredUnixUTC = 0
+	store.events[eventID.String()] = event
+	return event, nil
+}
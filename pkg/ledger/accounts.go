@@ -0,0 +1,54 @@
+package ledger
+
+import (
+	"context"
+	"errors"
+)
+
+// WithStrictAccountLookup controls how read-only queries treat a user without an account in the ledger.
+// By default Balance reports zero and list queries return no items; in strict mode they fail with
+// ErrUnknownAccount. Reads never create accounts either way.
+func WithStrictAccountLookup(strict bool) ServiceOption {
+	return func(service *Service) {
+		service.strictAccounts = strict
+	}
+}
+
+// ListAccounts pages a tenant's accounts in reverse-chronological order of creation.
+// A NonZeroBalance filter without BalanceAtUnixUTC is evaluated at the current time. It requires a store that
+// implements AccountStore.
+func (service *Service) ListAccounts(ctx context.Context, tenantID TenantID, beforeCreatedUnixUTC int64, limit int, filter ListAccountsFilter) ([]Account, error) {
+	if filter.LedgerID != nil {
+		if err := service.checkLedgerAllowed(tenantID, *filter.LedgerID); err != nil {
+			return nil, err
+		}
+	}
+	accountStore, ok := service.store.(AccountStore)
+	if !ok {
+		return nil, ErrAccountListUnsupported
+	}
+	if filter.NonZeroBalance && filter.BalanceAtUnixUTC == 0 {
+		filter.BalanceAtUnixUTC = service.nowFn()
+	}
+	return accountStore.ListAccounts(ctx, tenantID, beforeCreatedUnixUTC, limit, filter)
+}
+
+// lookupAccountID resolves an existing account for read paths. Unlike resolveAccountID it never
+// creates the account, and fails with ErrUnknownAccount when the user has none in the ledger. Stores without
+// AccountStore cannot look an account up without creating it, so they get the resolveAccountID behavior.
+func (service *Service) lookupAccountID(ctx context.Context, store Store, tenantID TenantID, userID UserID, ledgerID LedgerID) (AccountID, error) {
+	if err := service.checkLedgerAllowed(tenantID, ledgerID); err != nil {
+		return AccountID{}, err
+	}
+	accountStore, ok := store.(AccountStore)
+	if !ok {
+		return store.GetOrCreateAccountID(ctx, tenantID, userID, ledgerID)
+	}
+	return accountStore.GetAccountID(ctx, tenantID, userID, ledgerID)
+}
+
+// isLenientUnknownAccount reports whether a lookup failed only because the account does not exist
+// and the service answers such reads with empty results.
+func (service *Service) isLenientUnknownAccount(err error) bool {
+	return !service.strictAccounts && errors.Is(err, ErrUnknownAccount)
+}
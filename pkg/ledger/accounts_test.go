@@ -0,0 +1,131 @@
+package ledger
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestNewAccountValidation(test *testing.T) {
+	test.Parallel()
+	accountID := mustAccountID(test, "acct-1")
+	tenantID := mustTenantID(test, defaultTenantIDValue)
+	userID := mustUserID(test, "user-1")
+	ledgerID := mustLedgerID(test, defaultLedgerIDValue)
+	if _, err := NewAccount(AccountID{}, tenantID, userID, ledgerID, 0); !errors.Is(err, ErrInvalidAccountID) {
+		test.Fatalf("expected invalid account id, got %v", err)
+	}
+	if _, err := NewAccount(accountID, TenantID{}, userID, ledgerID, 0); !errors.Is(err, ErrInvalidTenantID) {
+		test.Fatalf("expected invalid tenant id, got %v", err)
+	}
+	if _, err := NewAccount(accountID, tenantID, UserID{}, ledgerID, 0); !errors.Is(err, ErrInvalidUserID) {
+		test.Fatalf("expected invalid user id, got %v", err)
+	}
+	if _, err := NewAccount(accountID, tenantID, userID, LedgerID{}, 0); !errors.Is(err, ErrInvalidLedgerID) {
+		test.Fatalf("expected invalid ledger id, got %v", err)
+	}
+	account, err := NewAccount(accountID, tenantID, userID, ledgerID, 42)
+	if err != nil {
+		test.Fatalf("new account: %v", err)
+	}
+	if account.AccountID() != accountID || account.TenantID() != tenantID || account.UserID() != userID || account.LedgerID() != ledgerID || account.CreatedUnixUTC() != 42 {
+		test.Fatalf("unexpected account accessors: %+v", account)
+	}
+}
+
+func TestBalanceOfUnknownAccountIsZero(test *testing.T) {
+	test.Parallel()
+	store := newStubStore(test, mustSignedAmount(test, 500))
+	store.accountMissing = true
+	service := mustNewService(test, store)
+
+	balance, err := service.Balance(context.Background(), mustTenantID(test, defaultTenantIDValue), mustUserID(test, "typo"), mustLedgerID(test, defaultLedgerIDValue))
+	if err != nil {
+		test.Fatalf("balance: %v", err)
+	}
+	if balance.TotalCents != 0 || balance.AvailableCents != 0 {
+		test.Fatalf("expected zero balance for unknown account, got %+v", balance)
+	}
+}
+
+func TestReadsOfUnknownAccountReturnEmptyResults(test *testing.T) {
+	test.Parallel()
+	ctx := context.Background()
+	tenantID := mustTenantID(test, defaultTenantIDValue)
+	userID := mustUserID(test, "typo")
+	ledgerID := mustLedgerID(test, defaultLedgerIDValue)
+	reservationID := mustReservationID(test, "order-1")
+
+	store := newStubStore(test, mustSignedAmount(test, 500))
+	store.accountMissing = true
+	service := mustNewService(test, store)
+	entries, err := service.ListEntries(ctx, tenantID, userID, ledgerID, 0, 10, ListEntriesFilter{})
+	if err != nil || len(entries) != 0 {
+		test.Fatalf("expected no entries, got %v (%v)", entries, err)
+	}
+	states, err := service.ListReservationStates(ctx, tenantID, userID, ledgerID, 0, 10, ListReservationsFilter{})
+	if err != nil || len(states) != 0 {
+		test.Fatalf("expected no reservations, got %v (%v)", states, err)
+	}
+	if _, err := service.GetReservationState(ctx, tenantID, userID, ledgerID, reservationID); !errors.Is(err, ErrUnknownReservation) {
+		test.Fatalf("expected unknown reservation, got %v", err)
+	}
+
+	strictService, err := NewService(store, func() int64 { return 100 }, WithStrictAccountLookup(true))
+	if err != nil {
+		test.Fatalf("new service: %v", err)
+	}
+	if _, err := strictService.Balance(ctx, tenantID, userID, ledgerID); !errors.Is(err, ErrUnknownAccount) {
+		test.Fatalf("expected unknown account from balance, got %v", err)
+	}
+	if _, err := strictService.ListEntries(ctx, tenantID, userID, ledgerID, 0, 10, ListEntriesFilter{}); !errors.Is(err, ErrUnknownAccount) {
+		test.Fatalf("expected unknown account from entries, got %v", err)
+	}
+	if _, err := strictService.ListReservationStates(ctx, tenantID, userID, ledgerID, 0, 10, ListReservationsFilter{}); !errors.Is(err, ErrUnknownAccount) {
+		test.Fatalf("expected unknown account from reservations, got %v", err)
+	}
+	if _, err := strictService.GetReservationState(ctx, tenantID, userID, ledgerID, reservationID); !errors.Is(err, ErrUnknownAccount) {
+		test.Fatalf("expected unknown account from reservation, got %v", err)
+	}
+}
+
+func TestListAccountsAppliesBalanceTimeAndPolicy(test *testing.T) {
+	test.Parallel()
+	store := newStubStore(test, 0)
+	store.accounts = []Account{mustAccount(test, "acct-1", "user-1")}
+	service := mustNewService(test, store)
+	tenantID := mustTenantID(test, defaultTenantIDValue)
+	ledgerID := mustLedgerID(test, defaultLedgerIDValue)
+
+	accounts, err := service.ListAccounts(context.Background(), tenantID, 0, 10, ListAccountsFilter{LedgerID: &ledgerID, NonZeroBalance: true})
+	if err != nil {
+		test.Fatalf("list accounts: %v", err)
+	}
+	if len(accounts) != 1 || store.listAccountsFilter.BalanceAtUnixUTC != 100 || store.listAccountsFilter.LedgerID == nil {
+		test.Fatalf("unexpected list result %+v with filter %+v", accounts, store.listAccountsFilter)
+	}
+
+	policy, err := NewTenantPolicy(tenantID, true)
+	if err != nil {
+		test.Fatalf("tenant policy: %v", err)
+	}
+	strictService, err := NewService(store, func() int64 { return 100 }, WithTenantPolicies(policy))
+	if err != nil {
+		test.Fatalf("new service: %v", err)
+	}
+	if _, err := strictService.ListAccounts(context.Background(), tenantID, 0, 10, ListAccountsFilter{LedgerID: &ledgerID}); !errors.Is(err, ErrUnknownLedger) {
+		test.Fatalf("expected unknown ledger, got %v", err)
+	}
+	if _, err := strictService.ListAccounts(context.Background(), tenantID, 0, 10, ListAccountsFilter{}); err != nil {
+		test.Fatalf("expected unfiltered listing to be allowed, got %v", err)
+	}
+}
+
+func mustAccount(test *testing.T, rawAccountID string, rawUserID string) Account {
+	test.Helper()
+	account, err := NewAccount(mustAccountID(test, rawAccountID), mustTenantID(test, defaultTenantIDValue), mustUserID(test, rawUserID), mustLedgerID(test, defaultLedgerIDValue), 100)
+	if err != nil {
+		test.Fatalf("new account: %v", err)
+	}
+	return account
+}
@@ -0,0 +1,73 @@
+package ledger
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// maxActorFieldLength bounds caller-supplied attribution values so they cannot bloat every entry row.
+const maxActorFieldLength = 256
+
+// Actor identifies who wrote an entry: the API key used, the calling service, the request, and the client address.
+// Every field is optional; the zero Actor means the writer is unknown.
+type Actor struct {
+	apiKeyID      string
+	service       string
+	requestID     string
+	clientAddress string
+}
+
+// NewActor trims the supplied values and rejects any longer than 256 characters.
+func NewActor(apiKeyID string, service string, requestID string, clientAddress string) (Actor, error) {
+	actor := Actor{
+		apiKeyID:      strings.TrimSpace(apiKeyID),
+		service:       strings.TrimSpace(service),
+		requestID:     strings.TrimSpace(requestID),
+		clientAddress: strings.TrimSpace(clientAddress),
+	}
+	for _, value := range []string{actor.apiKeyID, actor.service, actor.requestID, actor.clientAddress} {
+		if len(value) > maxActorFieldLength {
+			return Actor{}, fmt.Errorf("%w: value exceeds %d characters", ErrInvalidActor, maxActorFieldLength)
+		}
+	}
+	return actor, nil
+}
+
+// APIKeyID returns the identifier of the API key the request authenticated with. It never holds the secret itself.
+func (actor Actor) APIKeyID() string {
+	return actor.apiKeyID
+}
+
+// Service returns the name of the calling service.
+func (actor Actor) Service() string {
+	return actor.service
+}
+
+// RequestID returns the caller's request identifier.
+func (actor Actor) RequestID() string {
+	return actor.requestID
+}
+
+// ClientAddress returns the network address the request came from.
+func (actor Actor) ClientAddress() string {
+	return actor.clientAddress
+}
+
+// IsZero reports whether no attribution is recorded.
+func (actor Actor) IsZero() bool {
+	return actor == Actor{}
+}
+
+type actorContextKey struct{}
+
+// ContextWithActor returns a context whose Service writes attribute their entries to actor.
+func ContextWithActor(ctx context.Context, actor Actor) context.Context {
+	return context.WithValue(ctx, actorContextKey{}, actor)
+}
+
+// ActorFromContext returns the actor stored by ContextWithActor, or the zero Actor.
+func ActorFromContext(ctx context.Context) Actor {
+	actor, _ := ctx.Value(actorContextKey{}).(Actor)
+	return actor
+}
@@ -0,0 +1,57 @@
+package ledger
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestNewActorTrimsAndBoundsValues(test *testing.T) {
+	test.Parallel()
+	actor, err := NewActor(" key-1 ", "billing", "req-1", "10.0.0.1:5000")
+	if err != nil {
+		test.Fatalf("new actor: %v", err)
+	}
+	if actor.APIKeyID() != "key-1" || actor.Service() != "billing" || actor.RequestID() != "req-1" || actor.ClientAddress() != "10.0.0.1:5000" || actor.IsZero() {
+		test.Fatalf("unexpected actor accessors: %+v", actor)
+	}
+	if _, err := NewActor("", strings.Repeat("s", maxActorFieldLength+1), "", ""); !errors.Is(err, ErrInvalidActor) {
+		test.Fatalf("expected invalid actor, got %v", err)
+	}
+	if empty, err := NewActor(" ", "", "", ""); err != nil || !empty.IsZero() {
+		test.Fatalf("expected zero actor, got %+v (%v)", empty, err)
+	}
+}
+
+func TestServiceAttributesEntriesToContextActor(test *testing.T) {
+	test.Parallel()
+	store := newStubStore(test, 0)
+	service := mustNewService(test, store)
+	actor, err := NewActor("key-1", "billing", "req-1", "10.0.0.1:5000")
+	if err != nil {
+		test.Fatalf("new actor: %v", err)
+	}
+	if ActorFromContext(context.Background()) != (Actor{}) {
+		test.Fatalf("expected zero actor without attribution")
+	}
+	ctx := ContextWithActor(context.Background(), actor)
+	tenantID := mustTenantID(test, defaultTenantIDValue)
+	userID := mustUserID(test, "user-1")
+	ledgerID := mustLedgerID(test, defaultLedgerIDValue)
+	metadata := mustMetadata(test, "{}")
+
+	grantEntry, err := service.GrantEntry(ctx, tenantID, userID, ledgerID, mustPositiveAmount(test, 1000), mustIdempotencyKey(test, "grant-1"), 0, metadata)
+	if err != nil {
+		test.Fatalf("grant: %v", err)
+	}
+	if grantEntry.Actor() != actor {
+		test.Fatalf("expected returned entry to carry the actor, got %+v", grantEntry.Actor())
+	}
+	if err := service.Spend(context.Background(), tenantID, userID, ledgerID, mustPositiveAmount(test, 100), mustIdempotencyKey(test, "spend-1"), metadata); err != nil {
+		test.Fatalf("spend: %v", err)
+	}
+	if len(store.entries) != 2 || store.entries[0].Actor() != actor || !store.entries[1].Actor().IsZero() {
+		test.Fatalf("unexpected stored actors: %+v", store.entries)
+	}
+}
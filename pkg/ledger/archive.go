@@ -0,0 +1,244 @@
+package ledger
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+const (
+	stagedArchiveSuffix      = ".staged-*"
+	archiveRecordAccount     = "account"
+	archiveRecordEntry       = "entry"
+	archiveRecordReservation = "reservation"
+)
+
+// EntryArchive records how much of an account's history compaction has removed. Entries created before
+// CutoffUnixUTC are archived unless they were still open when compacted; ThroughSequence and ThroughHash are
+// the last archived link of the hash chain, where verification resumes. The zero value means nothing was archived.
+type EntryArchive struct {
+	AccountID       AccountID
+	CutoffUnixUTC   int64
+	ThroughSequence int64
+	ThroughHash     string
+	ArchivedEntries int64
+	Location        string
+	ArchivedUnixUTC int64
+}
+
+// IsZero reports whether nothing of the account was archived.
+func (archive EntryArchive) IsZero() bool {
+	return archive.CutoffUnixUTC == 0
+}
+
+// AccountArchive is the history one compaction removes from an account. Entries are in chain order, entries
+// written before hash chaining first with a zero Link. OpeningBalanceCents is the amount of the opening_balance
+// entry that replaces them.
+type AccountArchive struct {
+	TenantID            TenantID
+	UserID              UserID
+	LedgerID            LedgerID
+	AccountID           AccountID
+	CutoffUnixUTC       int64
+	OpeningBalanceCents SignedAmountCents
+	ThroughSequence     int64
+	ThroughHash         string
+	Entries             []ChainedEntry
+	Reservations        []Reservation
+}
+
+// ArchiveWriter stores the history compaction removes. StageAccountArchive runs inside the compaction's
+// transaction and must have made the archive durable when it returns, because the entries are deleted right
+// after, but the staged archive must not show up at its location yet: compaction publishes it once the
+// transaction commits and discards it when the transaction rolls back.
+type ArchiveWriter interface {
+	StageAccountArchive(ctx context.Context, archive AccountArchive) (StagedArchive, error)
+}
+
+// StagedArchive is an account archive written by StageAccountArchive that awaits the outcome of its
+// compaction. Location is where Publish makes it visible; Discard drops it.
+type StagedArchive interface {
+	Location() string
+	Publish() error
+	Discard() error
+}
+
+type archiveAccountLine struct {
+	Record              string `json:"record"`
+	TenantID            string `json:"tenant_id"`
+	UserID              string `json:"user_id"`
+	LedgerID            string `json:"ledger_id"`
+	AccountID           string `json:"account_id"`
+	CutoffUnixUTC       int64  `json:"cutoff_unix_utc"`
+	OpeningBalanceCents int64  `json:"opening_balance_cents"`
+	ThroughSequence     int64  `json:"through_sequence"`
+	ThroughHash         string `json:"through_hash"`
+	Entries             int    `json:"entries"`
+	Reservations        int    `json:"reservations"`
+}
+
+type archiveEntryLine struct {
+	Record string `json:"record"`
+	chainContent
+	ConsumedCents int64  `json:"consumed_cents,omitempty"`
+	PreviousHash  string `json:"previous_hash"`
+	Hash          string `json:"hash"`
+}
+
+type archiveReservationLine struct {
+	Record           string `json:"record"`
+	AccountID        string `json:"account_id"`
+	ReservationID    string `json:"reservation_id"`
+	AmountCents      int64  `json:"amount_cents"`
+	Status           string `json:"status"`
+	ExpiresAtUnixUTC int64  `json:"expires_at_unix_utc"`
+	CreatedUnixUTC   int64  `json:"created_unix_utc"`
+	UpdatedUnixUTC   int64  `json:"updated_unix_utc"`
+}
+
+// GzipJSONLArchiveWriter appends each account archive to a file as its own gzip member holding one JSON object
+// per line: an "account" summary, then an "entry" line per entry with the fields its chain hash covers, then a
+// "reservation" line per reservation. Gzip readers decompress the concatenated members as one stream. A
+// member is staged in a "<file>.staged-*" file next to the archive until it is published.
+type GzipJSONLArchiveWriter struct {
+	mutex sync.Mutex
+	path  string
+	file  *os.File
+}
+
+// NewGzipJSONLArchiveWriter opens path for appending, creating it when missing.
+func NewGzipJSONLArchiveWriter(path string) (*GzipJSONLArchiveWriter, error) {
+	if path == "" {
+		return nil, fmt.Errorf("%w: archive path is empty", ErrInvalidServiceConfig)
+	}
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, jsonlFileMode)
+	if err != nil {
+		return nil, fmt.Errorf("open archive file: %w", err)
+	}
+	return &GzipJSONLArchiveWriter{path: path, file: file}, nil
+}
+
+// StageAccountArchive writes the archive as one gzip member to a synced staging file next to the archive.
+func (writer *GzipJSONLArchiveWriter) StageAccountArchive(ctx context.Context, archive AccountArchive) (StagedArchive, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	lines := make([]any, 0, 1+len(archive.Entries)+len(archive.Reservations))
+	lines = append(lines, archiveAccountLine{
+		Record:              archiveRecordAccount,
+		TenantID:            archive.TenantID.String(),
+		UserID:              archive.UserID.String(),
+		LedgerID:            archive.LedgerID.String(),
+		AccountID:           archive.AccountID.String(),
+		CutoffUnixUTC:       archive.CutoffUnixUTC,
+		OpeningBalanceCents: archive.OpeningBalanceCents.Int64(),
+		ThroughSequence:     archive.ThroughSequence,
+		ThroughHash:         archive.ThroughHash,
+		Entries:             len(archive.Entries),
+		Reservations:        len(archive.Reservations),
+	})
+	for _, chained := range archive.Entries {
+		lines = append(lines, archiveEntryLine{
+			Record:        archiveRecordEntry,
+			chainContent:  newChainContent(chained.Link.Sequence, chained.Entry),
+			ConsumedCents: chained.Entry.ConsumedCents().Int64(),
+			PreviousHash:  chained.Link.PreviousHash,
+			Hash:          chained.Link.Hash,
+		})
+	}
+	for _, reservation := range archive.Reservations {
+		lines = append(lines, archiveReservationLine{
+			Record:           archiveRecordReservation,
+			AccountID:        reservation.AccountID().String(),
+			ReservationID:    reservation.ReservationID().String(),
+			AmountCents:      reservation.AmountCents().Int64(),
+			Status:           reservation.Status().String(),
+			ExpiresAtUnixUTC: reservation.ExpiresAtUnixUTC(),
+			CreatedUnixUTC:   reservation.CreatedUnixUTC(),
+			UpdatedUnixUTC:   reservation.UpdatedUnixUTC(),
+		})
+	}
+
+	file, err := os.CreateTemp(filepath.Dir(writer.path), filepath.Base(writer.path)+stagedArchiveSuffix)
+	if err != nil {
+		return nil, fmt.Errorf("create staged archive: %w", err)
+	}
+	staged := &gzipStagedArchive{writer: writer, path: file.Name()}
+	if err := writeGzipJSONL(file, lines); err != nil {
+		_ = file.Close()
+		return nil, errors.Join(err, staged.Discard())
+	}
+	if err := file.Close(); err != nil {
+		return nil, errors.Join(fmt.Errorf("close staged archive: %w", err), staged.Discard())
+	}
+	return staged, nil
+}
+
+func writeGzipJSONL(file *os.File, lines []any) error {
+	compressor := gzip.NewWriter(file)
+	encoder := json.NewEncoder(compressor)
+	for _, line := range lines {
+		if err := encoder.Encode(line); err != nil {
+			return fmt.Errorf("write archive: %w", err)
+		}
+	}
+	if err := compressor.Close(); err != nil {
+		return fmt.Errorf("write archive: %w", err)
+	}
+	if err := file.Sync(); err != nil {
+		return fmt.Errorf("sync archive: %w", err)
+	}
+	return nil
+}
+
+// gzipStagedArchive is one gzip member waiting in its own file to be appended to the archive.
+type gzipStagedArchive struct {
+	writer *GzipJSONLArchiveWriter
+	path   string
+}
+
+func (staged *gzipStagedArchive) Location() string {
+	return staged.writer.path
+}
+
+// Publish appends the staged member to the archive, syncs it, and removes the staging file. When it fails,
+// the staging file is left in place so the member can be appended by hand.
+func (staged *gzipStagedArchive) Publish() error {
+	source, err := os.Open(staged.path)
+	if err != nil {
+		return fmt.Errorf("publish staged archive %s: %w", staged.path, err)
+	}
+	defer source.Close()
+	staged.writer.mutex.Lock()
+	defer staged.writer.mutex.Unlock()
+	if _, err := io.Copy(staged.writer.file, source); err != nil {
+		return fmt.Errorf("publish staged archive %s: %w", staged.path, err)
+	}
+	if err := staged.writer.file.Sync(); err != nil {
+		return fmt.Errorf("publish staged archive %s: %w", staged.path, err)
+	}
+	if err := os.Remove(staged.path); err != nil {
+		return fmt.Errorf("remove staged archive: %w", err)
+	}
+	return nil
+}
+
+func (staged *gzipStagedArchive) Discard() error {
+	if err := os.Remove(staged.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("discard staged archive: %w", err)
+	}
+	return nil
+}
+
+// Close closes the underlying file.
+func (writer *GzipJSONLArchiveWriter) Close() error {
+	writer.mutex.Lock()
+	defer writer.mutex.Unlock()
+	return writer.file.Close()
+}
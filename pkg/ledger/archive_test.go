@@ -0,0 +1,467 @@
+package ledger
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGzipJSONLArchiveWriterAppendsReadableMembers(test *testing.T) {
+	test.Parallel()
+	path := filepath.Join(test.TempDir(), "archive.jsonl.gz")
+	writer, err := NewGzipJSONLArchiveWriter(path)
+	if err != nil {
+		test.Fatalf("new writer: %v", err)
+	}
+	accountID := mustAccountID(test, "acct-1")
+	entry := mustEntry(test, mustEntryID(test, "entry-1"), accountID, EntryGrant, EntryAmountCents(100), mustIdempotencyKey(test, "grant-1"), mustMetadata(test, "{}"))
+	link := ChainLink{EntryID: entry.EntryID(), Sequence: 1, Hash: ComputeChainHash("", 1, entry)}
+	reservation, err := NewReservation(accountID, mustReservationID(test, "order-1"), mustPositiveAmount(test, 40), ReservationStatusCaptured, 0)
+	if err != nil {
+		test.Fatalf("reservation: %v", err)
+	}
+	archive := AccountArchive{
+		TenantID:            mustTenantID(test, defaultTenantIDValue),
+		UserID:              mustUserID(test, "user-1"),
+		LedgerID:            mustLedgerID(test, defaultLedgerIDValue),
+		AccountID:           accountID,
+		CutoffUnixUTC:       50,
+		OpeningBalanceCents: 100,
+		ThroughSequence:     1,
+		ThroughHash:         link.Hash,
+		Entries:             []ChainedEntry{{Entry: entry, Link: link}},
+		Reservations:        []Reservation{reservation},
+	}
+	for range 2 {
+		staged, err := writer.StageAccountArchive(context.Background(), archive)
+		if err != nil {
+			test.Fatalf("stage archive: %v", err)
+		}
+		if staged.Location() != path {
+			test.Fatalf("expected location %s, got %s", path, staged.Location())
+		}
+		if err := staged.Publish(); err != nil {
+			test.Fatalf("publish archive: %v", err)
+		}
+	}
+	discarded, err := writer.StageAccountArchive(context.Background(), archive)
+	if err != nil {
+		test.Fatalf("stage archive: %v", err)
+	}
+	if err := discarded.Discard(); err != nil {
+		test.Fatalf("discard archive: %v", err)
+	}
+	if leftovers, err := filepath.Glob(path + stagedArchiveSuffix); err != nil || len(leftovers) != 0 {
+		test.Fatalf("expected no staging files left, got %v, %v", leftovers, err)
+	}
+	if err := writer.Close(); err != nil {
+		test.Fatalf("close: %v", err)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		test.Fatalf("open archive: %v", err)
+	}
+	defer file.Close()
+	reader, err := gzip.NewReader(file)
+	if err != nil {
+		test.Fatalf("gzip reader: %v", err)
+	}
+	var records []map[string]any
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		var record map[string]any
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			test.Fatalf("decode line %q: %v", scanner.Text(), err)
+		}
+		records = append(records, record)
+	}
+	if err := scanner.Err(); err != nil {
+		test.Fatalf("read archive: %v", err)
+	}
+	if len(records) != 6 {
+		test.Fatalf("expected 6 lines across both published members, got %d", len(records))
+	}
+	expectedRecords := []string{archiveRecordAccount, archiveRecordEntry, archiveRecordReservation}
+	for index, record := range records {
+		if record["record"] != expectedRecords[index%3] {
+			test.Fatalf("line %d: expected %s record, got %v", index, expectedRecords[index%3], record["record"])
+		}
+	}
+	if records[0]["opening_balance_cents"] != float64(100) || records[0]["through_hash"] != link.Hash {
+		test.Fatalf("unexpected account line %v", records[0])
+	}
+	if records[1]["entry_id"] != entry.EntryID().String() || records[1]["hash"] != link.Hash || records[1]["sequence"] != float64(1) {
+		test.Fatalf("unexpected entry line %v", records[1])
+	}
+	if records[2]["reservation_id"] != "order-1" || records[2]["status"] != ReservationStatusCaptured.String() {
+		test.Fatalf("unexpected reservation line %v", records[2])
+	}
+}
+
+func TestCompactAccountRequiresArchiveStore(test *testing.T) {
+	test.Parallel()
+	service := mustNewService(test, newStubStore(test, 0))
+	tenantID := mustTenantID(test, defaultTenantIDValue)
+	userID := mustUserID(test, "user-1")
+	ledgerID := mustLedgerID(test, defaultLedgerIDValue)
+	writer, err := NewGzipJSONLArchiveWriter(filepath.Join(test.TempDir(), "archive.jsonl.gz"))
+	if err != nil {
+		test.Fatalf("new writer: %v", err)
+	}
+	defer writer.Close()
+
+	if _, err := service.CompactAccount(context.Background(), tenantID, userID, ledgerID, 50, writer); !errors.Is(err, ErrArchiveUnsupported) {
+		test.Fatalf("expected ErrArchiveUnsupported, got %v", err)
+	}
+	if _, err := service.CompactAccounts(context.Background(), tenantID, ListAccountsFilter{}, 50, writer); !errors.Is(err, ErrArchiveUnsupported) {
+		test.Fatalf("expected ErrArchiveUnsupported, got %v", err)
+	}
+	archive, err := service.EntryArchive(context.Background(), tenantID, userID, ledgerID)
+	if err != nil {
+		test.Fatalf("entry archive: %v", err)
+	}
+	if !archive.IsZero() {
+		test.Fatalf("expected zero archive, got %+v", archive)
+	}
+	if _, err := NewGzipJSONLArchiveWriter(""); !errors.Is(err, ErrInvalidServiceConfig) {
+		test.Fatalf("expected ErrInvalidServiceConfig for an empty path, got %v", err)
+	}
+}
+
+// archivingStubStore adds ArchiveStore to the stub, failing ArchiveEntries with archiveErr, and the chain,
+// archive and reservation reads with lockErr, getArchiveErr, chainErr and reservationsErr, when they are set.
+type archivingStubStore struct {
+	*stubStore
+	archives        *[]EntryArchive
+	archiveErr      error
+	lockErr         error
+	getArchiveErr   error
+	chainErr        error
+	reservationsErr error
+}
+
+func (store archivingStubStore) WithTx(ctx context.Context, fn func(ctx context.Context, txStore Store) error) error {
+	return store.stubStore.WithTx(ctx, func(ctx context.Context, txStore Store) error {
+		transactionStore := store
+		transactionStore.stubStore = txStore.(*stubStore)
+		return fn(ctx, transactionStore)
+	})
+}
+
+func (store archivingStubStore) LockChainHead(ctx context.Context, accountID AccountID) (ChainHead, error) {
+	if store.lockErr != nil {
+		return ChainHead{}, store.lockErr
+	}
+	return store.stubStore.LockChainHead(ctx, accountID)
+}
+
+func (store archivingStubStore) ListChainedEntries(ctx context.Context, accountID AccountID, afterSequence int64, limit int) ([]ChainedEntry, error) {
+	if store.chainErr != nil {
+		return nil, store.chainErr
+	}
+	return store.stubStore.ListChainedEntries(ctx, accountID, afterSequence, limit)
+}
+
+func (store archivingStubStore) ListReservations(ctx context.Context, accountID AccountID, beforeCreatedUnixUTC int64, limit int, filter ListReservationsFilter) ([]Reservation, error) {
+	if store.reservationsErr != nil {
+		return nil, store.reservationsErr
+	}
+	return store.stubStore.ListReservations(ctx, accountID, beforeCreatedUnixUTC, limit, filter)
+}
+
+func (store archivingStubStore) GetEntryArchive(ctx context.Context, accountID AccountID) (EntryArchive, error) {
+	if store.getArchiveErr != nil {
+		return EntryArchive{}, store.getArchiveErr
+	}
+	if len(*store.archives) == 0 {
+		return EntryArchive{}, nil
+	}
+	return (*store.archives)[len(*store.archives)-1], nil
+}
+
+func (store archivingStubStore) ArchiveEntries(ctx context.Context, archive EntryArchive, entryIDs []EntryID, reservationIDs []ReservationID) error {
+	if store.archiveErr != nil {
+		return store.archiveErr
+	}
+	archived := make(map[string]struct{}, len(entryIDs))
+	for _, entryID := range entryIDs {
+		archived[entryID.String()] = struct{}{}
+	}
+	var entries []EntryInput
+	for _, entryInput := range store.entries {
+		if _, ok := archived[entryInput.IdempotencyKey().String()]; !ok {
+			entries = append(entries, entryInput)
+		}
+	}
+	var chainLinks []ChainLink
+	for _, link := range store.chainLinks {
+		if _, ok := archived[link.EntryID.String()]; !ok {
+			chainLinks = append(chainLinks, link)
+		}
+	}
+	store.entries, store.chainLinks = entries, chainLinks
+	for _, reservationID := range reservationIDs {
+		delete(store.reservations, reservationID)
+	}
+	*store.archives = append(*store.archives, archive)
+	return nil
+}
+
+// newCompactionFixture grants 100 cents at unix 10 and returns a service that compacts at unix 100.
+func newCompactionFixture(test *testing.T, archiveErr error) (*Service, *[]EntryArchive) {
+	test.Helper()
+	nowUnixUTC := int64(10)
+	archives := &[]EntryArchive{}
+	store := archivingStubStore{stubStore: newStubStore(test, 0), archives: archives, archiveErr: archiveErr}
+	service, err := NewService(store, func() int64 { return nowUnixUTC })
+	if err != nil {
+		test.Fatalf("new service: %v", err)
+	}
+	if _, err := service.GrantEntry(context.Background(), mustTenantID(test, defaultTenantIDValue), mustUserID(test, "user-1"), mustLedgerID(test, defaultLedgerIDValue), mustPositiveAmount(test, 100), mustIdempotencyKey(test, "grant-1"), 0, mustMetadata(test, "{}")); err != nil {
+		test.Fatalf("grant: %v", err)
+	}
+	nowUnixUTC = 100
+	return service, archives
+}
+
+func stagedArchiveFiles(test *testing.T, path string) []string {
+	test.Helper()
+	files, err := filepath.Glob(path + stagedArchiveSuffix)
+	if err != nil {
+		test.Fatalf("glob staged archives: %v", err)
+	}
+	return files
+}
+
+func archiveSize(test *testing.T, path string) int64 {
+	test.Helper()
+	info, err := os.Stat(path)
+	if err != nil {
+		test.Fatalf("stat archive: %v", err)
+	}
+	return info.Size()
+}
+
+func TestCompactAccountPublishesArchiveAfterCommit(test *testing.T) {
+	test.Parallel()
+	service, archives := newCompactionFixture(test, nil)
+	path := filepath.Join(test.TempDir(), "archive.jsonl.gz")
+	writer, err := NewGzipJSONLArchiveWriter(path)
+	if err != nil {
+		test.Fatalf("new writer: %v", err)
+	}
+	defer writer.Close()
+
+	result, err := service.CompactAccount(context.Background(), mustTenantID(test, defaultTenantIDValue), mustUserID(test, "user-1"), mustLedgerID(test, defaultLedgerIDValue), 50, writer)
+	if err != nil {
+		test.Fatalf("compact: %v", err)
+	}
+	if result.ArchivedEntries != 1 || result.OpeningBalanceCents != 100 || result.Location != path {
+		test.Fatalf("unexpected compaction result %+v", result)
+	}
+	if len(*archives) != 1 || (*archives)[0].ArchivedEntries != 1 {
+		test.Fatalf("expected one archive record, got %+v", *archives)
+	}
+	if archiveSize(test, path) == 0 {
+		test.Fatalf("expected the archive member to be published")
+	}
+	if files := stagedArchiveFiles(test, path); len(files) != 0 {
+		test.Fatalf("expected no staging files left, got %v", files)
+	}
+}
+
+func TestCompactAccountDiscardsStagedArchiveOnRollback(test *testing.T) {
+	test.Parallel()
+	archiveErr := errors.New("archive entries failed")
+	service, archives := newCompactionFixture(test, archiveErr)
+	path := filepath.Join(test.TempDir(), "archive.jsonl.gz")
+	writer, err := NewGzipJSONLArchiveWriter(path)
+	if err != nil {
+		test.Fatalf("new writer: %v", err)
+	}
+	defer writer.Close()
+
+	if _, err := service.CompactAccount(context.Background(), mustTenantID(test, defaultTenantIDValue), mustUserID(test, "user-1"), mustLedgerID(test, defaultLedgerIDValue), 50, writer); !errors.Is(err, archiveErr) {
+		test.Fatalf("expected the archive error, got %v", err)
+	}
+	if len(*archives) != 0 {
+		test.Fatalf("expected no archive record after the rollback, got %+v", *archives)
+	}
+	if files := stagedArchiveFiles(test, path); len(files) != 0 {
+		test.Fatalf("expected the staged archive to be discarded, got %v", files)
+	}
+	if size := archiveSize(test, path); size != 0 {
+		test.Fatalf("expected nothing published after the rollback, got %d bytes", size)
+	}
+}
+
+func TestCompactAccountReportsPublishFailureAndKeepsStagingFile(test *testing.T) {
+	test.Parallel()
+	service, archives := newCompactionFixture(test, nil)
+	path := filepath.Join(test.TempDir(), "archive.jsonl.gz")
+	writer, err := NewGzipJSONLArchiveWriter(path)
+	if err != nil {
+		test.Fatalf("new writer: %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		test.Fatalf("close: %v", err)
+	}
+
+	_, err = service.CompactAccount(context.Background(), mustTenantID(test, defaultTenantIDValue), mustUserID(test, "user-1"), mustLedgerID(test, defaultLedgerIDValue), 50, writer)
+	if err == nil || !strings.Contains(err.Error(), "publish staged archive") {
+		test.Fatalf("expected a publish error, got %v", err)
+	}
+	if len(*archives) != 1 {
+		test.Fatalf("expected the archive record to stay committed, got %+v", *archives)
+	}
+	files := stagedArchiveFiles(test, path)
+	if len(files) != 1 {
+		test.Fatalf("expected the staging file to be kept for a manual append, got %v", files)
+	}
+	if !strings.Contains(err.Error(), files[0]) {
+		test.Fatalf("expected the error to name the staging file %s, got %v", files[0], err)
+	}
+}
+
+func TestGzipStagedArchiveFailures(test *testing.T) {
+	test.Parallel()
+	directory := test.TempDir()
+	path := filepath.Join(directory, "archive.jsonl.gz")
+	writer, err := NewGzipJSONLArchiveWriter(path)
+	if err != nil {
+		test.Fatalf("new writer: %v", err)
+	}
+	defer writer.Close()
+	archive := AccountArchive{AccountID: mustAccountID(test, "acct-1")}
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := writer.StageAccountArchive(canceled, archive); !errors.Is(err, context.Canceled) {
+		test.Fatalf("expected context.Canceled, got %v", err)
+	}
+	missing := &GzipJSONLArchiveWriter{path: filepath.Join(directory, "missing", "archive.jsonl.gz")}
+	if _, err := missing.StageAccountArchive(context.Background(), archive); err == nil || !strings.Contains(err.Error(), "create staged archive") {
+		test.Fatalf("expected a staging error, got %v", err)
+	}
+	if _, err := NewGzipJSONLArchiveWriter(directory); err == nil || !strings.Contains(err.Error(), "open archive file") {
+		test.Fatalf("expected an open error for a directory, got %v", err)
+	}
+
+	staged, err := writer.StageAccountArchive(context.Background(), archive)
+	if err != nil {
+		test.Fatalf("stage archive: %v", err)
+	}
+	if err := staged.Discard(); err != nil {
+		test.Fatalf("discard: %v", err)
+	}
+	if err := staged.Discard(); err != nil {
+		test.Fatalf("expected a second discard to be a no-op, got %v", err)
+	}
+	if err := staged.Publish(); err == nil || !strings.Contains(err.Error(), "publish staged archive") {
+		test.Fatalf("expected publishing a discarded archive to fail, got %v", err)
+	}
+
+	blocked := &gzipStagedArchive{writer: writer, path: filepath.Join(directory, "blocked")}
+	if err := os.MkdirAll(filepath.Join(blocked.path, "child"), 0o755); err != nil {
+		test.Fatalf("mkdir: %v", err)
+	}
+	if err := blocked.Discard(); err == nil || !strings.Contains(err.Error(), "discard staged archive") {
+		test.Fatalf("expected a discard error for a non-empty directory, got %v", err)
+	}
+
+	closed, err := os.CreateTemp(directory, "closed")
+	if err != nil {
+		test.Fatalf("create temp: %v", err)
+	}
+	if err := closed.Close(); err != nil {
+		test.Fatalf("close temp: %v", err)
+	}
+	if err := writeGzipJSONL(closed, []any{archiveAccountLine{}}); err == nil || !strings.Contains(err.Error(), "write archive") {
+		test.Fatalf("expected a write error on a closed file, got %v", err)
+	}
+	if err := writeGzipJSONL(closed, nil); err == nil || !strings.Contains(err.Error(), "write archive") {
+		test.Fatalf("expected a flush error on a closed file, got %v", err)
+	}
+
+	// /dev/null takes writes but cannot be synced.
+	unsyncable, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		test.Fatalf("open %s: %v", os.DevNull, err)
+	}
+	defer unsyncable.Close()
+	if err := writeGzipJSONL(unsyncable, []any{archiveAccountLine{}}); err == nil || !strings.Contains(err.Error(), "sync archive") {
+		test.Fatalf("expected a sync error, got %v", err)
+	}
+	staged, err = writer.StageAccountArchive(context.Background(), archive)
+	if err != nil {
+		test.Fatalf("stage archive: %v", err)
+	}
+	unsyncableWriter := &GzipJSONLArchiveWriter{path: path, file: unsyncable}
+	staged.(*gzipStagedArchive).writer = unsyncableWriter
+	if err := staged.Publish(); err == nil || !strings.Contains(err.Error(), "publish staged archive") {
+		test.Fatalf("expected a publish sync error, got %v", err)
+	}
+	if err := staged.Discard(); err != nil {
+		test.Fatalf("discard: %v", err)
+	}
+}
+
+// failingArchiveWriter fails staging with stageErr, or stages archives whose Discard fails with discardErr.
+type failingArchiveWriter struct {
+	stageErr   error
+	discardErr error
+}
+
+func (writer failingArchiveWriter) StageAccountArchive(ctx context.Context, archive AccountArchive) (StagedArchive, error) {
+	if writer.stageErr != nil {
+		return nil, writer.stageErr
+	}
+	return failingStagedArchive{discardErr: writer.discardErr}, nil
+}
+
+type failingStagedArchive struct {
+	discardErr error
+}
+
+func (staged failingStagedArchive) Location() string {
+	return "archive"
+}
+
+func (staged failingStagedArchive) Publish() error {
+	return nil
+}
+
+func (staged failingStagedArchive) Discard() error {
+	return staged.discardErr
+}
+
+func TestCompactAccountReportsStagingAndDiscardFailures(test *testing.T) {
+	test.Parallel()
+	tenantID := mustTenantID(test, defaultTenantIDValue)
+	userID := mustUserID(test, "user-1")
+	ledgerID := mustLedgerID(test, defaultLedgerIDValue)
+
+	stageErr := errors.New("stage failed")
+	service, archives := newCompactionFixture(test, nil)
+	if _, err := service.CompactAccount(context.Background(), tenantID, userID, ledgerID, 50, failingArchiveWriter{stageErr: stageErr}); !errors.Is(err, stageErr) {
+		test.Fatalf("expected the staging error, got %v", err)
+	}
+	if len(*archives) != 0 {
+		test.Fatalf("expected no archive record, got %+v", *archives)
+	}
+
+	archiveErr := errors.New("archive entries failed")
+	discardErr := errors.New("discard failed")
+	service, _ = newCompactionFixture(test, archiveErr)
+	_, err := service.CompactAccount(context.Background(), tenantID, userID, ledgerID, 50, failingArchiveWriter{discardErr: discardErr})
+	if !errors.Is(err, archiveErr) || !errors.Is(err, discardErr) {
+		test.Fatalf("expected both the archive and discard errors, got %v", err)
+	}
+}
@@ -0,0 +1,102 @@
+package ledger
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+)
+
+const errorBalanceLimitMax = "max balance must be positive"
+
+// BalanceLimit caps the total balance of every account in one tenant ledger.
+type BalanceLimit struct {
+	tenantID        TenantID
+	ledgerID        LedgerID
+	maxBalanceCents int64
+}
+
+// NewBalanceLimit validates a maximum total balance for a tenant ledger.
+func NewBalanceLimit(tenantID TenantID, ledgerID LedgerID, maxBalanceCents int64) (BalanceLimit, error) {
+	if maxBalanceCents <= 0 {
+		return BalanceLimit{}, fmt.Errorf("%w: %s", ErrInvalidBalanceLimit, errorBalanceLimitMax)
+	}
+	return BalanceLimit{
+		tenantID:        tenantID,
+		ledgerID:        ledgerID,
+		maxBalanceCents: maxBalanceCents,
+	}, nil
+}
+
+// TenantID returns the tenant the limit applies to.
+func (limit BalanceLimit) TenantID() TenantID {
+	return limit.tenantID
+}
+
+// LedgerID returns the ledger the limit applies to.
+func (limit BalanceLimit) LedgerID() LedgerID {
+	return limit.ledgerID
+}
+
+// MaxBalanceCents returns the maximum total balance an account may hold.
+func (limit BalanceLimit) MaxBalanceCents() int64 {
+	return limit.maxBalanceCents
+}
+
+// WithBalanceLimits rejects grants and refunds that would lift an account's total balance above a limit.
+func WithBalanceLimits(limits ...BalanceLimit) ServiceOption {
+	return func(service *Service) {
+		service.balanceLimits = append(service.balanceLimits, limits...)
+	}
+}
+
+// checkBalanceLimit rejects a credit of amount when the account's projected total would exceed any limit
+// configured for the tenant ledger while the credit counts: from fromUnixUTC until it expires at untilUnixUTC
+// (0 = never). Grants, immediate or scheduled, and refunds all go through this one check.
+func (service *Service) checkBalanceLimit(ctx context.Context, store Store, tenantID TenantID, ledgerID LedgerID, accountID AccountID, amount int64, fromUnixUTC int64, untilUnixUTC int64) error {
+	var peak int64
+	projected := false
+	for _, limit := range service.balanceLimits {
+		if limit.tenantID != tenantID || limit.ledgerID != ledgerID {
+			continue
+		}
+		if !projected {
+			var err error
+			if peak, err = projectedPeakTotal(ctx, store, accountID, fromUnixUTC, untilUnixUTC); err != nil {
+				return err
+			}
+			projected = true
+		}
+		if peak+amount > limit.maxBalanceCents {
+			return fmt.Errorf("%w: balance would exceed %d cents", ErrBalanceLimitExceeded, limit.maxBalanceCents)
+		}
+	}
+	return nil
+}
+
+// projectedPeakTotal returns the highest total the account's entries give it from fromUnixUTC until untilUnixUTC
+// (0 = never). A total only rises when an entry takes effect, so it peaks at fromUnixUTC or when one of the
+// account's scheduled grants takes effect.
+func projectedPeakTotal(ctx context.Context, store Store, accountID AccountID, fromUnixUTC int64, untilUnixUTC int64) (int64, error) {
+	scheduled, err := listAccountEntries(ctx, store, accountID, ListEntriesFilter{Types: []EntryType{EntryGrant}, EffectiveAfterUnixUTC: fromUnixUTC})
+	if err != nil {
+		return 0, err
+	}
+	times := []int64{fromUnixUTC}
+	for _, entry := range scheduled {
+		effectiveAtUnixUTC := entry.EffectiveAtUnixUTC()
+		if effectiveAtUnixUTC <= fromUnixUTC || (untilUnixUTC != 0 && effectiveAtUnixUTC >= untilUnixUTC) || slices.Contains(times, effectiveAtUnixUTC) {
+			continue
+		}
+		times = append(times, effectiveAtUnixUTC)
+	}
+	peak := int64(math.MinInt64)
+	for _, atUnixUTC := range times {
+		total, err := store.SumTotal(ctx, accountID, atUnixUTC)
+		if err != nil {
+			return 0, err
+		}
+		peak = max(peak, total.Int64())
+	}
+	return peak, nil
+}
@@ -0,0 +1,204 @@
+package ledger
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestNewBalanceLimitValidation(test *testing.T) {
+	test.Parallel()
+	tenantID := mustTenantID(test, defaultTenantIDValue)
+	ledgerID := mustLedgerID(test, defaultLedgerIDValue)
+	for _, maxBalanceCents := range []int64{0, -1} {
+		if _, err := NewBalanceLimit(tenantID, ledgerID, maxBalanceCents); !errors.Is(err, ErrInvalidBalanceLimit) {
+			test.Fatalf("expected invalid balance limit for %d, got %v", maxBalanceCents, err)
+		}
+	}
+	limit, err := NewBalanceLimit(tenantID, ledgerID, 5000)
+	if err != nil {
+		test.Fatalf("new balance limit: %v", err)
+	}
+	if limit.TenantID() != tenantID || limit.LedgerID() != ledgerID || limit.MaxBalanceCents() != 5000 {
+		test.Fatalf("unexpected limit accessors: %+v", limit)
+	}
+}
+
+func TestGrantEnforcesBalanceLimit(test *testing.T) {
+	test.Parallel()
+	store := newStubStore(test, mustSignedAmount(test, 800))
+	service := mustNewBalanceLimitService(test, store, mustBalanceLimit(test, defaultLedgerIDValue, 1000))
+	ctx := context.Background()
+	tenantID := mustTenantID(test, defaultTenantIDValue)
+	userID := mustUserID(test, "user-1")
+	ledgerID := mustLedgerID(test, defaultLedgerIDValue)
+
+	if _, err := service.GrantEntry(ctx, tenantID, userID, ledgerID, mustPositiveAmount(test, 200), mustIdempotencyKey(test, "grant-1"), 0, mustMetadata(test, "{}")); err != nil {
+		test.Fatalf("grant up to the limit: %v", err)
+	}
+	_, err := service.GrantEntry(ctx, tenantID, userID, ledgerID, mustPositiveAmount(test, 1), mustIdempotencyKey(test, "grant-2"), 0, mustMetadata(test, "{}"))
+	if !errors.Is(err, ErrBalanceLimitExceeded) {
+		test.Fatalf("expected balance limit exceeded, got %v", err)
+	}
+	if store.total.Int64() != 1000 {
+		test.Fatalf("expected total 1000, got %d", store.total.Int64())
+	}
+	if _, err := service.ScheduleGrantEntry(ctx, tenantID, userID, ledgerID, mustPositiveAmount(test, 1), mustIdempotencyKey(test, "grant-3"), 500, 0, mustMetadata(test, "{}")); !errors.Is(err, ErrBalanceLimitExceeded) {
+		test.Fatalf("expected scheduled grant to be limited, got %v", err)
+	}
+	if _, err := service.GrantEntry(ctx, tenantID, userID, mustLedgerID(test, "other"), mustPositiveAmount(test, 1), mustIdempotencyKey(test, "grant-other"), 0, mustMetadata(test, "{}")); err != nil {
+		test.Fatalf("expected limit to be scoped to its ledger, got %v", err)
+	}
+	listErr := errors.New("list entries failed")
+	store.listErr = listErr
+	if _, err := service.GrantEntry(ctx, tenantID, userID, ledgerID, mustPositiveAmount(test, 1), mustIdempotencyKey(test, "grant-4"), 0, mustMetadata(test, "{}")); !errors.Is(err, listErr) {
+		test.Fatalf("expected the scheduled grant lookup error, got %v", err)
+	}
+}
+
+func TestRefundEnforcesBalanceLimit(test *testing.T) {
+	test.Parallel()
+	store := newStubStore(test, mustSignedAmount(test, 1000))
+	service := mustNewBalanceLimitService(test, store, mustBalanceLimit(test, defaultLedgerIDValue, 1000))
+	ctx := context.Background()
+	tenantID := mustTenantID(test, defaultTenantIDValue)
+	userID := mustUserID(test, "user-1")
+	ledgerID := mustLedgerID(test, defaultLedgerIDValue)
+
+	spendEntry, err := service.SpendEntry(ctx, tenantID, userID, ledgerID, mustPositiveAmount(test, 300), mustIdempotencyKey(test, "spend-1"), mustMetadata(test, "{}"))
+	if err != nil {
+		test.Fatalf("spend: %v", err)
+	}
+	if _, err := service.GrantEntry(ctx, tenantID, userID, ledgerID, mustPositiveAmount(test, 200), mustIdempotencyKey(test, "grant-1"), 0, mustMetadata(test, "{}")); err != nil {
+		test.Fatalf("grant: %v", err)
+	}
+	_, err = service.RefundByEntryIDEntry(ctx, tenantID, userID, ledgerID, spendEntry.EntryID(), mustPositiveAmount(test, 200), mustIdempotencyKey(test, "refund-1"), mustMetadata(test, "{}"))
+	if !errors.Is(err, ErrBalanceLimitExceeded) {
+		test.Fatalf("expected balance limit exceeded, got %v", err)
+	}
+	if _, err := service.RefundByOriginalIdempotencyKeyEntry(ctx, tenantID, userID, ledgerID, mustIdempotencyKey(test, "spend-1"), mustPositiveAmount(test, 100), mustIdempotencyKey(test, "refund-2"), mustMetadata(test, "{}")); err != nil {
+		test.Fatalf("refund up to the limit: %v", err)
+	}
+	originalEntryID := spendEntry.EntryID()
+	operations := []BatchOperation{
+		{OperationID: "refund-over", Refund: &BatchRefundOperation{OriginalEntryID: &originalEntryID, Amount: mustPositiveAmount(test, 100), IdempotencyKey: mustIdempotencyKey(test, "batch-refund-1"), Metadata: mustMetadata(test, "{}")}},
+	}
+	results, err := service.Batch(ctx, tenantID, userID, ledgerID, operations, false)
+	if err != nil {
+		test.Fatalf("batch: %v", err)
+	}
+	if !errors.Is(results[0].Error, ErrBalanceLimitExceeded) {
+		test.Fatalf("expected a batch refund to be limited, got %v", results[0].Error)
+	}
+}
+
+func TestBatchEnforcesBalanceLimit(test *testing.T) {
+	test.Parallel()
+	for _, atomic := range []bool{false, true} {
+		store := newStubStore(test, mustSignedAmount(test, 900))
+		service := mustNewBalanceLimitService(test, store, mustBalanceLimit(test, defaultLedgerIDValue, 1000))
+		operations := []BatchOperation{
+			{OperationID: "grant-ok", Grant: &BatchGrantOperation{Amount: mustPositiveAmount(test, 100), IdempotencyKey: mustIdempotencyKey(test, "batch-grant-1"), Metadata: mustMetadata(test, "{}")}},
+			{OperationID: "grant-over", Grant: &BatchGrantOperation{Amount: mustPositiveAmount(test, 1), IdempotencyKey: mustIdempotencyKey(test, "batch-grant-2"), Metadata: mustMetadata(test, "{}")}},
+		}
+		results, err := service.Batch(context.Background(), mustTenantID(test, defaultTenantIDValue), mustUserID(test, "user-1"), mustLedgerID(test, defaultLedgerIDValue), operations, atomic)
+		if err != nil {
+			test.Fatalf("batch atomic=%v: %v", atomic, err)
+		}
+		if !errors.Is(results[1].Error, ErrBalanceLimitExceeded) {
+			test.Fatalf("atomic=%v: expected balance limit exceeded, got %v", atomic, results[1].Error)
+		}
+		if results[0].RolledBack != atomic {
+			test.Fatalf("atomic=%v: expected rolled_back=%v, got %+v", atomic, atomic, results[0])
+		}
+		expectedTotal := int64(1000)
+		if atomic {
+			expectedTotal = 900
+		}
+		if store.total.Int64() != expectedTotal {
+			test.Fatalf("atomic=%v: expected total %d, got %d", atomic, expectedTotal, store.total.Int64())
+		}
+	}
+}
+
+func TestRefundAcrossLedgersEnforcesBalanceLimitPerLedger(test *testing.T) {
+	test.Parallel()
+	store := newMultiLedgerStore(test, map[string]int64{"promo": 100, "purchased": 1000})
+	service := mustNewBalanceLimitService(test, store, mustBalanceLimit(test, "purchased", 850))
+	ctx := context.Background()
+	tenantID := mustTenantID(test, defaultTenantIDValue)
+	userID := mustUserID(test, "user-1")
+	ledgerIDs := []LedgerID{mustLedgerID(test, "promo"), mustLedgerID(test, "purchased")}
+
+	if _, err := service.SpendAcrossLedgers(ctx, tenantID, userID, ledgerIDs, mustPositiveAmount(test, 300), mustIdempotencyKey(test, "spend-1"), mustMetadata(test, "{}")); err != nil {
+		test.Fatalf("spend: %v", err)
+	}
+	_, err := service.RefundAcrossLedgers(ctx, tenantID, userID, ledgerIDs, mustIdempotencyKey(test, "spend-1"), mustPositiveAmount(test, 100), mustIdempotencyKey(test, "refund-1"), mustMetadata(test, "{}"))
+	if !errors.Is(err, ErrBalanceLimitExceeded) {
+		test.Fatalf("expected balance limit exceeded, got %v", err)
+	}
+	if store.ledger(test, "purchased").total.Int64() != 800 {
+		test.Fatalf("expected rejected refund to leave purchased at 800, got %d", store.ledger(test, "purchased").total.Int64())
+	}
+}
+
+func TestBalanceLimitCountsScheduledGrants(test *testing.T) {
+	test.Parallel()
+	ctx := context.Background()
+	tenantID := mustTenantID(test, defaultTenantIDValue)
+	userID := mustUserID(test, "user-1")
+	ledgerID := mustLedgerID(test, defaultLedgerIDValue)
+	metadata := mustMetadata(test, "{}")
+
+	store := newStubStore(test, 0)
+	service := mustNewBalanceLimitService(test, store, mustBalanceLimit(test, defaultLedgerIDValue, 1000))
+	if _, err := service.ScheduleGrantEntry(ctx, tenantID, userID, ledgerID, mustPositiveAmount(test, 700), mustIdempotencyKey(test, "scheduled"), 500, 0, metadata); err != nil {
+		test.Fatalf("scheduled grant: %v", err)
+	}
+	if _, err := service.GrantEntry(ctx, tenantID, userID, ledgerID, mustPositiveAmount(test, 400), mustIdempotencyKey(test, "immediate-over"), 0, metadata); !errors.Is(err, ErrBalanceLimitExceeded) {
+		test.Fatalf("expected an immediate grant that exceeds the limit once the scheduled grant takes effect to fail, got %v", err)
+	}
+	if _, err := service.GrantEntry(ctx, tenantID, userID, ledgerID, mustPositiveAmount(test, 400), mustIdempotencyKey(test, "immediate-expiring"), 500, metadata); err != nil {
+		test.Fatalf("expected a grant that expires when the scheduled grant takes effect to fit, got %v", err)
+	}
+	if _, err := service.GrantEntry(ctx, tenantID, userID, ledgerID, mustPositiveAmount(test, 300), mustIdempotencyKey(test, "immediate-fits"), 0, metadata); err != nil {
+		test.Fatalf("expected a grant up to the projected limit to fit, got %v", err)
+	}
+
+	store = newStubStore(test, 0)
+	service = mustNewBalanceLimitService(test, store, mustBalanceLimit(test, defaultLedgerIDValue, 1000))
+	if _, err := service.ScheduleGrantEntry(ctx, tenantID, userID, ledgerID, mustPositiveAmount(test, 600), mustIdempotencyKey(test, "scheduled-late"), 500, 0, metadata); err != nil {
+		test.Fatalf("late scheduled grant: %v", err)
+	}
+	if _, err := service.ScheduleGrantEntry(ctx, tenantID, userID, ledgerID, mustPositiveAmount(test, 500), mustIdempotencyKey(test, "scheduled-early"), 300, 0, metadata); !errors.Is(err, ErrBalanceLimitExceeded) {
+		test.Fatalf("expected an earlier scheduled grant to count the later one, got %v", err)
+	}
+	operations := []BatchOperation{
+		{OperationID: "grant-over", Grant: &BatchGrantOperation{Amount: mustPositiveAmount(test, 500), IdempotencyKey: mustIdempotencyKey(test, "batch-grant"), Metadata: metadata}},
+	}
+	results, err := service.Batch(ctx, tenantID, userID, ledgerID, operations, false)
+	if err != nil {
+		test.Fatalf("batch: %v", err)
+	}
+	if !errors.Is(results[0].Error, ErrBalanceLimitExceeded) {
+		test.Fatalf("expected a batch grant to count the scheduled grant, got %v", results[0].Error)
+	}
+}
+
+func mustNewBalanceLimitService(test *testing.T, store Store, limits ...BalanceLimit) *Service {
+	test.Helper()
+	service, err := NewService(store, func() int64 { return 100 }, WithBalanceLimits(limits...))
+	if err != nil {
+		test.Fatalf("new service: %v", err)
+	}
+	return service
+}
+
+func mustBalanceLimit(test *testing.T, rawLedgerID string, maxBalanceCents int64) BalanceLimit {
+	test.Helper()
+	limit, err := NewBalanceLimit(mustTenantID(test, defaultTenantIDValue), mustLedgerID(test, rawLedgerID), maxBalanceCents)
+	if err != nil {
+		test.Fatalf("balance limit: %v", err)
+	}
+	return limit
+}
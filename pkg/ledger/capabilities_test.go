@@ -0,0 +1,243 @@
+package ledger
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// baseStore exposes only the Store methods of the wrapped store, hiding every optional capability.
+type baseStore struct {
+	Store
+}
+
+func (store baseStore) WithTx(ctx context.Context, fn func(ctx context.Context, txStore Store) error) error {
+	return store.Store.WithTx(ctx, func(ctx context.Context, txStore Store) error {
+		return fn(ctx, baseStore{Store: txStore})
+	})
+}
+
+func TestBaseStoreWritesEntriesWithoutOptionalRows(test *testing.T) {
+	test.Parallel()
+	store := newStubStore(test, mustSignedAmount(test, 0))
+	store.accountMissing = true
+	service := mustNewService(test, baseStore{Store: store})
+	tenantID := mustTenantID(test, defaultTenantIDValue)
+	userID := mustUserID(test, "user-123")
+	ledgerID := mustLedgerID(test, defaultLedgerIDValue)
+
+	if _, err := service.GrantEntry(context.Background(), tenantID, userID, ledgerID, mustPositiveAmount(test, 100), mustIdempotencyKey(test, "grant-1"), 1000, mustMetadata(test, "{}")); err != nil {
+		test.Fatalf("grant: %v", err)
+	}
+	if _, err := service.SpendEntry(context.Background(), tenantID, userID, ledgerID, mustPositiveAmount(test, 40), mustIdempotencyKey(test, "spend-1"), mustMetadata(test, "{}")); err != nil {
+		test.Fatalf("spend: %v", err)
+	}
+	if len(store.entries) != 2 {
+		test.Fatalf("expected 2 entries, got %d", len(store.entries))
+	}
+	if len(store.chainLinks) != 0 || len(store.changedEntryIDs) != 0 || len(store.consumedGrants) != 0 || len(store.journalLines) != 0 {
+		test.Fatalf("expected no optional rows, got %d links, %d changes, %d consumptions, %d journal lines", len(store.chainLinks), len(store.changedEntryIDs), len(store.consumedGrants), len(store.journalLines))
+	}
+	balance, err := service.Balance(context.Background(), tenantID, userID, ledgerID)
+	if err != nil {
+		test.Fatalf("balance: %v", err)
+	}
+	if balance.TotalCents.Int64() != 60 {
+		test.Fatalf("expected a total of 60, got %d", balance.TotalCents.Int64())
+	}
+}
+
+func TestOptionalFeaturesRequireTheirCapability(test *testing.T) {
+	test.Parallel()
+	tenantID := mustTenantID(test, defaultTenantIDValue)
+	userID := mustUserID(test, "user-123")
+	ledgerID := mustLedgerID(test, defaultLedgerIDValue)
+	testCases := []struct {
+		name     string
+		options  []ServiceOption
+		run      func(service *Service) error
+		expected error
+	}{
+		{
+			name: "list accounts",
+			run: func(service *Service) error {
+				_, err := service.ListAccounts(context.Background(), tenantID, 0, 10, ListAccountsFilter{})
+				return err
+			},
+			expected: ErrAccountListUnsupported,
+		},
+		{
+			name: "trial balance",
+			run: func(service *Service) error {
+				_, err := service.TrialBalance(context.Background(), tenantID, 100)
+				return err
+			},
+			expected: ErrJournalUnsupported,
+		},
+		{
+			name: "liability report",
+			run: func(service *Service) error {
+				_, err := service.LiabilityReport(context.Background(), tenantID, 100)
+				return err
+			},
+			expected: ErrLiabilityUnsupported,
+		},
+		{
+			name: "change feed",
+			run: func(service *Service) error {
+				_, err := service.ListEntryChanges(context.Background(), tenantID, 0, 10, EntryChangeFilter{})
+				return err
+			},
+			expected: ErrChangeFeedUnsupported,
+		},
+		{
+			name: "verify chain",
+			run: func(service *Service) error {
+				_, err := service.VerifyChain(context.Background(), tenantID, userID, ledgerID)
+				return err
+			},
+			expected: ErrChainUnsupported,
+		},
+		{
+			name:    "velocity rule",
+			options: []ServiceOption{WithVelocityRules(mustVelocityRule(test, defaultLedgerIDValue, 60, 0, 5))},
+			run: func(service *Service) error {
+				_, err := service.SpendEntry(context.Background(), tenantID, userID, ledgerID, mustPositiveAmount(test, 10), mustIdempotencyKey(test, "spend-1"), mustMetadata(test, "{}"))
+				return err
+			},
+			expected: ErrVelocityUnsupported,
+		},
+		{
+			name:    "outbox",
+			options: []ServiceOption{WithOutbox(tenantID)},
+			run: func(service *Service) error {
+				_, err := service.GrantEntry(context.Background(), tenantID, userID, ledgerID, mustPositiveAmount(test, 10), mustIdempotencyKey(test, "grant-1"), 0, mustMetadata(test, "{}"))
+				return err
+			},
+			expected: ErrOutboxUnsupported,
+		},
+	}
+	for _, testCase := range testCases {
+		test.Run(testCase.name, func(test *testing.T) {
+			test.Parallel()
+			store := newStubStore(test, mustSignedAmount(test, 100))
+			service, err := NewService(baseStore{Store: store}, func() int64 { return 100 }, testCase.options...)
+			if err != nil {
+				test.Fatalf("new service: %v", err)
+			}
+			if err := testCase.run(service); !errors.Is(err, testCase.expected) {
+				test.Fatalf("expected %v, got %v", testCase.expected, err)
+			}
+		})
+	}
+}
+
+func TestThresholdEventRequiresOutboxStore(test *testing.T) {
+	test.Parallel()
+	tenantID := mustTenantID(test, defaultTenantIDValue)
+	store := newStubStore(test, mustSignedAmount(test, 0))
+	service, err := NewService(store, func() int64 { return 100 }, WithOutbox(tenantID))
+	if err != nil {
+		test.Fatalf("new service: %v", err)
+	}
+	crossing := BalanceThresholdCrossing{TenantID: tenantID, CrossedUnixUTC: 100}
+	if err := service.recordThresholdEvent(context.Background(), baseStore{Store: store}, crossing); !errors.Is(err, ErrOutboxUnsupported) {
+		test.Fatalf("expected ErrOutboxUnsupported, got %v", err)
+	}
+	if len(store.outboxEvents) != 0 {
+		test.Fatalf("expected no outbox events, got %d", len(store.outboxEvents))
+	}
+}
+
+// failingCapabilityStore fails the named optional store method and delegates everything else to the stub.
+type failingCapabilityStore struct {
+	*stubStore
+	failing string
+	err     error
+}
+
+func (store failingCapabilityStore) WithTx(ctx context.Context, fn func(ctx context.Context, txStore Store) error) error {
+	return store.stubStore.WithTx(ctx, func(ctx context.Context, txStore Store) error {
+		return fn(ctx, failingCapabilityStore{stubStore: txStore.(*stubStore), failing: store.failing, err: store.err})
+	})
+}
+
+func (store failingCapabilityStore) fail(method string) error {
+	if store.failing == method {
+		return store.err
+	}
+	return nil
+}
+
+func (store failingCapabilityStore) LockChainHead(ctx context.Context, accountID AccountID) (ChainHead, error) {
+	if err := store.fail("LockChainHead"); err != nil {
+		return ChainHead{}, err
+	}
+	return store.stubStore.LockChainHead(ctx, accountID)
+}
+
+func (store failingCapabilityStore) AppendChainLink(ctx context.Context, accountID AccountID, link ChainLink) error {
+	if err := store.fail("AppendChainLink"); err != nil {
+		return err
+	}
+	return store.stubStore.AppendChainLink(ctx, accountID, link)
+}
+
+func (store failingCapabilityStore) AppendEntryChange(ctx context.Context, tenantID TenantID, entryID EntryID) error {
+	if err := store.fail("AppendEntryChange"); err != nil {
+		return err
+	}
+	return store.stubStore.AppendEntryChange(ctx, tenantID, entryID)
+}
+
+func (store failingCapabilityStore) ListConsumableGrants(ctx context.Context, accountID AccountID, atUnixUTC int64) ([]Entry, error) {
+	if err := store.fail("ListConsumableGrants"); err != nil {
+		return nil, err
+	}
+	return store.stubStore.ListConsumableGrants(ctx, accountID, atUnixUTC)
+}
+
+func (store failingCapabilityStore) ConsumeGrant(ctx context.Context, accountID AccountID, entryID EntryID, amountCents AmountCents) error {
+	if err := store.fail("ConsumeGrant"); err != nil {
+		return err
+	}
+	return store.stubStore.ConsumeGrant(ctx, accountID, entryID, amountCents)
+}
+
+func (store failingCapabilityStore) InsertOutboxEvent(ctx context.Context, event OutboxEvent) error {
+	if err := store.fail("InsertOutboxEvent"); err != nil {
+		return err
+	}
+	return store.stubStore.InsertOutboxEvent(ctx, event)
+}
+
+func (store failingCapabilityStore) InsertJournalLines(ctx context.Context, lines []JournalLine) error {
+	if err := store.fail("InsertJournalLines"); err != nil {
+		return err
+	}
+	return store.stubStore.InsertJournalLines(ctx, lines)
+}
+
+func TestEntryWritesReturnCapabilityErrors(test *testing.T) {
+	test.Parallel()
+	tenantID := mustTenantID(test, defaultTenantIDValue)
+	userID := mustUserID(test, "user-123")
+	ledgerID := mustLedgerID(test, defaultLedgerIDValue)
+	for _, method := range []string{"LockChainHead", "AppendChainLink", "AppendEntryChange", "ListConsumableGrants", "ConsumeGrant", "InsertOutboxEvent", "InsertJournalLines"} {
+		test.Run(method, func(test *testing.T) {
+			test.Parallel()
+			sentinel := errors.New(method + " failed")
+			stub := newStubStore(test, mustSignedAmount(test, 0))
+			store := failingCapabilityStore{stubStore: stub, failing: method, err: sentinel}
+			service, err := NewService(store, func() int64 { return 100 }, WithOutbox(tenantID))
+			if err != nil {
+				test.Fatalf("new service: %v", err)
+			}
+			_, grantErr := service.GrantEntry(context.Background(), tenantID, userID, ledgerID, mustPositiveAmount(test, 100), mustIdempotencyKey(test, "grant-1"), 1000, mustMetadata(test, "{}"))
+			_, spendErr := service.SpendEntry(context.Background(), tenantID, userID, ledgerID, mustPositiveAmount(test, 40), mustIdempotencyKey(test, "spend-1"), mustMetadata(test, "{}"))
+			if !errors.Is(grantErr, sentinel) && !errors.Is(spendErr, sentinel) {
+				test.Fatalf("expected %v, got %v from the grant and %v from the spend", sentinel, grantErr, spendErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,223 @@
+package ledger
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+)
+
+const verifyChainPageSize = 500
+
+// ChainBreakReason explains why VerifyChain rejected a link.
+type ChainBreakReason string
+
+const (
+	// ChainBreakMissingEntry means a sequence number has no entry: a chained entry was deleted or renumbered.
+	ChainBreakMissingEntry ChainBreakReason = "missing_entry"
+	// ChainBreakPreviousHashMismatch means an entry does not point at the hash of the entry before it.
+	ChainBreakPreviousHashMismatch ChainBreakReason = "previous_hash_mismatch"
+	// ChainBreakHashMismatch means an entry's content no longer matches its stored hash.
+	ChainBreakHashMismatch ChainBreakReason = "hash_mismatch"
+	// ChainBreakHeadMismatch means the chain does not end at the account's recorded head.
+	ChainBreakHeadMismatch ChainBreakReason = "head_mismatch"
+)
+
+// String returns the reason as a primitive value.
+func (reason ChainBreakReason) String() string {
+	return string(reason)
+}
+
+// ChainHead is the last link of an account's hash chain. The zero value is an empty chain.
+type ChainHead struct {
+	Sequence int64
+	Hash     string
+}
+
+// ChainLink places an entry in its account's hash chain: Hash covers the entry's canonical content,
+// its Sequence, and PreviousHash, which is empty for the first entry.
+type ChainLink struct {
+	EntryID      EntryID
+	Sequence     int64
+	PreviousHash string
+	Hash         string
+}
+
+// ChainedEntry is a stored entry together with its chain link.
+type ChainedEntry struct {
+	Entry Entry
+	Link  ChainLink
+}
+
+// ChainBreak is the first link VerifyChain could not validate. EntryID is empty for ChainBreakMissingEntry.
+type ChainBreak struct {
+	Sequence int64
+	EntryID  EntryID
+	Reason   ChainBreakReason
+}
+
+// ChainVerification reports the result of walking an account's hash chain. Break is nil when the chain is intact.
+// ArchivedThroughSequence is the last link removed by compaction; the walk starts after it.
+type ChainVerification struct {
+	AccountID               AccountID
+	HeadSequence            int64
+	ArchivedThroughSequence int64
+	CheckedEntries          int64
+	Break                   *ChainBreak
+}
+
+// Valid reports whether every link checked out.
+func (verification ChainVerification) Valid() bool {
+	return verification.Break == nil
+}
+
+type chainContent struct {
+	Sequence           int64           `json:"sequence"`
+	EntryID            string          `json:"entry_id"`
+	AccountID          string          `json:"account_id"`
+	Type               string          `json:"type"`
+	AmountCents        int64           `json:"amount_cents"`
+	ReservationID      string          `json:"reservation_id"`
+	RefundOfEntryID    string          `json:"refund_of_entry_id"`
+	IdempotencyKey     string          `json:"idempotency_key"`
+	ExpiresAtUnixUTC   int64           `json:"expires_at_unix_utc"`
+	EffectiveAtUnixUTC int64           `json:"effective_at_unix_utc"`
+	CreatedUnixUTC     int64           `json:"created_unix_utc"`
+	Metadata           json.RawMessage `json:"metadata"`
+	ActorAPIKeyID      string          `json:"actor_api_key_id"`
+	ActorService       string          `json:"actor_service"`
+	ActorRequestID     string          `json:"actor_request_id"`
+	ActorClientAddress string          `json:"actor_client_address"`
+}
+
+// ComputeChainHash returns the hex SHA-256 of previousHash followed by the canonical JSON of the entry at
+// sequence. Metadata is re-encoded with sorted keys so databases that normalize JSON hash the same content.
+func ComputeChainHash(previousHash string, sequence int64, entry Entry) string {
+	encoded, _ := json.Marshal(newChainContent(sequence, entry))
+	digest := sha256.New()
+	digest.Write([]byte(previousHash))
+	digest.Write([]byte{'\n'})
+	digest.Write(encoded)
+	return hex.EncodeToString(digest.Sum(nil))
+}
+
+func newChainContent(sequence int64, entry Entry) chainContent {
+	content := chainContent{
+		Sequence:           sequence,
+		EntryID:            entry.EntryID().String(),
+		AccountID:          entry.AccountID().String(),
+		Type:               entry.Type().String(),
+		AmountCents:        entry.AmountCents().Int64(),
+		IdempotencyKey:     entry.IdempotencyKey().String(),
+		ExpiresAtUnixUTC:   entry.ExpiresAtUnixUTC(),
+		EffectiveAtUnixUTC: entry.EffectiveAtUnixUTC(),
+		CreatedUnixUTC:     entry.CreatedUnixUTC(),
+		Metadata:           canonicalMetadata(entry.MetadataJSON()),
+		ActorAPIKeyID:      entry.Actor().APIKeyID(),
+		ActorService:       entry.Actor().Service(),
+		ActorRequestID:     entry.Actor().RequestID(),
+		ActorClientAddress: entry.Actor().ClientAddress(),
+	}
+	if reservationID, ok := entry.ReservationID(); ok {
+		content.ReservationID = reservationID.String()
+	}
+	if refundOfEntryID, ok := entry.RefundOfEntryID(); ok {
+		content.RefundOfEntryID = refundOfEntryID.String()
+	}
+	return content
+}
+
+func canonicalMetadata(metadata MetadataJSON) json.RawMessage {
+	var value any
+	if err := json.Unmarshal([]byte(metadata.String()), &value); err != nil {
+		return json.RawMessage(metadata.String())
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return json.RawMessage(metadata.String())
+	}
+	return encoded
+}
+
+// appendChainLink adds a persisted entry to the end of its account's chain. LockChainHead holds the head
+// until the transaction ends, so concurrent writers to one account extend the chain one at a time.
+func appendChainLink(ctx context.Context, store ChainStore, entry Entry) error {
+	head, err := store.LockChainHead(ctx, entry.AccountID())
+	if err != nil {
+		return err
+	}
+	return store.AppendChainLink(ctx, entry.AccountID(), newChainLink(head, entry))
+}
+
+// newChainLink places entry right after head.
+func newChainLink(head ChainHead, entry Entry) ChainLink {
+	sequence := head.Sequence + 1
+	return ChainLink{
+		EntryID:      entry.EntryID(),
+		Sequence:     sequence,
+		PreviousHash: head.Hash,
+		Hash:         ComputeChainHash(head.Hash, sequence, entry),
+	}
+}
+
+// VerifyChain walks an account's hash chain from the first entry, or from the last archived link after
+// compaction, and reports the first broken link. Entries written before chaining was introduced are not part
+// of the chain and are not checked. It requires a store that implements ChainStore.
+func (service *Service) VerifyChain(ctx context.Context, tenantID TenantID, userID UserID, ledgerID LedgerID) (ChainVerification, error) {
+	chainStore, ok := service.store.(ChainStore)
+	if !ok {
+		return ChainVerification{}, ErrChainUnsupported
+	}
+	accountID, err := service.lookupAccountID(ctx, service.store, tenantID, userID, ledgerID)
+	if service.isLenientUnknownAccount(err) {
+		return ChainVerification{}, nil
+	}
+	if err != nil {
+		return ChainVerification{}, err
+	}
+	head, err := chainStore.GetChainHead(ctx, accountID)
+	if err != nil {
+		return ChainVerification{}, err
+	}
+	archive, err := getEntryArchive(ctx, service.store, accountID)
+	if err != nil {
+		return ChainVerification{}, err
+	}
+	verification := ChainVerification{AccountID: accountID, HeadSequence: head.Sequence, ArchivedThroughSequence: archive.ThroughSequence}
+	lastSequence := archive.ThroughSequence
+	previousHash := archive.ThroughHash
+	for {
+		page, err := chainStore.ListChainedEntries(ctx, accountID, lastSequence, verifyChainPageSize)
+		if err != nil {
+			return ChainVerification{}, err
+		}
+		for _, chained := range page {
+			sequence := lastSequence + 1
+			if chained.Link.Sequence != sequence {
+				verification.Break = &ChainBreak{Sequence: sequence, Reason: ChainBreakMissingEntry}
+				return verification, nil
+			}
+			if chained.Link.PreviousHash != previousHash {
+				verification.Break = &ChainBreak{Sequence: sequence, EntryID: chained.Entry.EntryID(), Reason: ChainBreakPreviousHashMismatch}
+				return verification, nil
+			}
+			if ComputeChainHash(previousHash, sequence, chained.Entry) != chained.Link.Hash {
+				verification.Break = &ChainBreak{Sequence: sequence, EntryID: chained.Entry.EntryID(), Reason: ChainBreakHashMismatch}
+				return verification, nil
+			}
+			previousHash = chained.Link.Hash
+			lastSequence = sequence
+			verification.CheckedEntries++
+		}
+		if len(page) < verifyChainPageSize {
+			break
+		}
+	}
+	switch {
+	case lastSequence < head.Sequence:
+		verification.Break = &ChainBreak{Sequence: lastSequence + 1, Reason: ChainBreakMissingEntry}
+	case lastSequence > head.Sequence || previousHash != head.Hash:
+		verification.Break = &ChainBreak{Sequence: head.Sequence, Reason: ChainBreakHeadMismatch}
+	}
+	return verification, nil
+}
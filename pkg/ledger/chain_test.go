@@ -0,0 +1,216 @@
+package ledger
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestVerifyChainDetectsFirstBrokenLink(test *testing.T) {
+	test.Parallel()
+	tenantID := mustTenantID(test, defaultTenantIDValue)
+	userID := mustUserID(test, "user-1")
+	ledgerID := mustLedgerID(test, defaultLedgerIDValue)
+	newChainedStore := func() (*Service, *stubStore) {
+		store := newStubStore(test, 0)
+		service := mustNewService(test, store)
+		for _, key := range []string{"grant-1", "grant-2", "grant-3"} {
+			if err := service.Grant(context.Background(), tenantID, userID, ledgerID, mustPositiveAmount(test, 100), mustIdempotencyKey(test, key), 0, mustMetadata(test, "{}")); err != nil {
+				test.Fatalf("grant %s: %v", key, err)
+			}
+		}
+		return service, store
+	}
+
+	testCases := []struct {
+		name           string
+		tamper         func(store *stubStore)
+		expectedBreak  *ChainBreak
+		expectedChecks int64
+	}{
+		{
+			name:           "intact",
+			tamper:         func(store *stubStore) {},
+			expectedChecks: 3,
+		},
+		{
+			name:           "hash rewritten",
+			tamper:         func(store *stubStore) { store.chainLinks[1].Hash = "deadbeef" },
+			expectedBreak:  &ChainBreak{Sequence: 2, Reason: ChainBreakHashMismatch},
+			expectedChecks: 1,
+		},
+		{
+			name:           "previous hash rewritten",
+			tamper:         func(store *stubStore) { store.chainLinks[2].PreviousHash = "deadbeef" },
+			expectedBreak:  &ChainBreak{Sequence: 3, Reason: ChainBreakPreviousHashMismatch},
+			expectedChecks: 2,
+		},
+		{
+			name:           "entry deleted",
+			tamper:         func(store *stubStore) { store.chainLinks = append(store.chainLinks[:1], store.chainLinks[2]) },
+			expectedBreak:  &ChainBreak{Sequence: 2, Reason: ChainBreakMissingEntry},
+			expectedChecks: 1,
+		},
+		{
+			name:           "last entry deleted",
+			tamper:         func(store *stubStore) { store.entries = store.entries[:2] },
+			expectedBreak:  &ChainBreak{Sequence: 3, Reason: ChainBreakMissingEntry},
+			expectedChecks: 2,
+		},
+	}
+	for _, testCase := range testCases {
+		testCase := testCase
+		test.Run(testCase.name, func(test *testing.T) {
+			test.Parallel()
+			service, store := newChainedStore()
+			testCase.tamper(store)
+			verification, err := service.VerifyChain(context.Background(), tenantID, userID, ledgerID)
+			if err != nil {
+				test.Fatalf("verify chain: %v", err)
+			}
+			if verification.CheckedEntries != testCase.expectedChecks {
+				test.Fatalf("expected %d checked entries, got %d", testCase.expectedChecks, verification.CheckedEntries)
+			}
+			if testCase.expectedBreak == nil {
+				if !verification.Valid() {
+					test.Fatalf("expected valid chain, got break %+v", verification.Break)
+				}
+				return
+			}
+			if verification.Valid() || verification.Break.Sequence != testCase.expectedBreak.Sequence || verification.Break.Reason != testCase.expectedBreak.Reason {
+				test.Fatalf("expected break %+v, got %+v", testCase.expectedBreak, verification.Break)
+			}
+		})
+	}
+}
+
+// chainHeadStore overrides the chain head and chain reads of the stub.
+type chainHeadStore struct {
+	*stubStore
+	head    *ChainHead
+	headErr error
+	listErr error
+}
+
+func (store chainHeadStore) GetChainHead(ctx context.Context, accountID AccountID) (ChainHead, error) {
+	if store.headErr != nil {
+		return ChainHead{}, store.headErr
+	}
+	if store.head != nil {
+		return *store.head, nil
+	}
+	return store.stubStore.GetChainHead(ctx, accountID)
+}
+
+func (store chainHeadStore) ListChainedEntries(ctx context.Context, accountID AccountID, afterSequence int64, limit int) ([]ChainedEntry, error) {
+	if store.listErr != nil {
+		return nil, store.listErr
+	}
+	return store.stubStore.ListChainedEntries(ctx, accountID, afterSequence, limit)
+}
+
+func TestVerifyChainReportsStoreFailuresAndHeadMismatches(test *testing.T) {
+	test.Parallel()
+	ctx := context.Background()
+	tenantID := mustTenantID(test, defaultTenantIDValue)
+	userID := mustUserID(test, "user-1")
+	ledgerID := mustLedgerID(test, defaultLedgerIDValue)
+	storeError := errors.New("chain read failed")
+
+	missing := newStubStore(test, 0)
+	missing.accountMissing = true
+	verification, err := mustNewService(test, missing).VerifyChain(ctx, tenantID, userID, ledgerID)
+	if err != nil || verification.CheckedEntries != 0 || !verification.Valid() {
+		test.Fatalf("expected an empty verification for an unknown account, got %+v (%v)", verification, err)
+	}
+
+	testCases := []struct {
+		name          string
+		configure     func(store *chainHeadStore)
+		expectedError error
+		expectedBreak *ChainBreak
+	}{
+		{name: "account lookup", configure: func(store *chainHeadStore) { store.getAccountError = storeError }, expectedError: storeError},
+		{name: "chain head", configure: func(store *chainHeadStore) { store.headErr = storeError }, expectedError: storeError},
+		{name: "chained entries", configure: func(store *chainHeadStore) { store.listErr = storeError }, expectedError: storeError},
+		{
+			name: "head behind chain",
+			configure: func(store *chainHeadStore) {
+				store.head = &ChainHead{Sequence: 1, Hash: store.chainLinks[0].Hash}
+			},
+			expectedBreak: &ChainBreak{Sequence: 1, Reason: ChainBreakHeadMismatch},
+		},
+		{
+			name: "head hash rewritten",
+			configure: func(store *chainHeadStore) {
+				store.head = &ChainHead{Sequence: 2, Hash: "deadbeef"}
+			},
+			expectedBreak: &ChainBreak{Sequence: 2, Reason: ChainBreakHeadMismatch},
+		},
+	}
+	for _, testCase := range testCases {
+		test.Run(testCase.name, func(test *testing.T) {
+			test.Parallel()
+			stub := newStubStore(test, 0)
+			granting := mustNewService(test, stub)
+			for _, key := range []string{"grant-1", "grant-2"} {
+				if err := granting.Grant(ctx, tenantID, userID, ledgerID, mustPositiveAmount(test, 100), mustIdempotencyKey(test, key), 0, mustMetadata(test, "{}")); err != nil {
+					test.Fatalf("grant %s: %v", key, err)
+				}
+			}
+			store := &chainHeadStore{stubStore: stub}
+			testCase.configure(store)
+			verification, err := mustNewService(test, *store).VerifyChain(ctx, tenantID, userID, ledgerID)
+			if testCase.expectedError != nil {
+				if !errors.Is(err, testCase.expectedError) {
+					test.Fatalf("expected %v, got %v", testCase.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				test.Fatalf("verify chain: %v", err)
+			}
+			if verification.Valid() || verification.Break.Sequence != testCase.expectedBreak.Sequence || verification.Break.Reason != testCase.expectedBreak.Reason {
+				test.Fatalf("expected break %+v, got %+v", testCase.expectedBreak, verification.Break)
+			}
+			if verification.Break.Reason.String() != string(ChainBreakHeadMismatch) {
+				test.Fatalf("unexpected break reason %s", verification.Break.Reason.String())
+			}
+		})
+	}
+}
+
+func TestAppendChainLinkReturnsLockFailure(test *testing.T) {
+	test.Parallel()
+	sentinel := errors.New("lock failed")
+	store := failingCapabilityStore{stubStore: newStubStore(test, 0), failing: "LockChainHead", err: sentinel}
+	if err := appendChainLink(context.Background(), store, Entry{}); !errors.Is(err, sentinel) {
+		test.Fatalf("expected %v, got %v", sentinel, err)
+	}
+	if len(store.chainLinks) != 0 {
+		test.Fatalf("expected no chain links, got %d", len(store.chainLinks))
+	}
+}
+
+func TestComputeChainHashCanonicalizesMetadata(test *testing.T) {
+	test.Parallel()
+	store := newStubStore(test, 0)
+	service := mustNewService(test, store)
+	entry, err := service.GrantEntry(context.Background(), mustTenantID(test, defaultTenantIDValue), mustUserID(test, "user-1"), mustLedgerID(test, defaultLedgerIDValue), mustPositiveAmount(test, 100), mustIdempotencyKey(test, "grant-1"), 0, mustMetadata(test, `{"b": 1, "a": 2}`))
+	if err != nil {
+		test.Fatalf("grant: %v", err)
+	}
+	hash := ComputeChainHash("", 1, entry)
+	if store.chainLinks[0].Hash != hash {
+		test.Fatalf("expected stored link hash %s, got %s", hash, store.chainLinks[0].Hash)
+	}
+	if ComputeChainHash("other", 1, entry) == hash || ComputeChainHash("", 2, entry) == hash {
+		test.Fatalf("expected previous hash and sequence to change the hash")
+	}
+	if canonical := string(canonicalMetadata(mustMetadata(test, `{"b": 1, "a": 2}`))); canonical != `{"a":2,"b":1}` {
+		test.Fatalf("expected canonical metadata, got %s", canonical)
+	}
+	if raw := string(canonicalMetadata(MetadataJSON{})); raw != "" {
+		test.Fatalf("expected undecodable metadata to be kept verbatim, got %q", raw)
+	}
+}
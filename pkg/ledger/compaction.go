@@ -0,0 +1,376 @@
+package ledger
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+const openingBalanceKeyPrefix = "opening_balance"
+
+// CompactionResult reports what CompactAccount removed from one account. RetainedBeforeCutoff counts the
+// entries created before the cutoff that stayed because they, or entries they belong with, were still open.
+type CompactionResult struct {
+	AccountID            AccountID
+	UserID               UserID
+	LedgerID             LedgerID
+	ArchivedEntries      int
+	ArchivedReservations int
+	RetainedBeforeCutoff int
+	OpeningBalanceCents  SignedAmountCents
+	Location             string
+}
+
+type openingBalanceMetadata struct {
+	CutoffUnixUTC   int64  `json:"cutoff_unix_utc"`
+	ArchivedEntries int64  `json:"archived_entries"`
+	ArchiveLocation string `json:"archive_location"`
+}
+
+// CompactAccount archives the account's entries created before cutoffUnixUTC. The entries, with the
+// reservations they settle, are staged with writer, deleted, and replaced by one opening_balance entry
+// carrying their balance forward; the staged archive is published only after the transaction commits.
+// Compaction removes the oldest entries in chain order and stops at the first entry that is still open:
+// pending or unexpired, of an active reservation, or part of a reservation or refunded debit with entries at
+// or after the cutoff. It requires a store that implements ArchiveStore.
+func (service *Service) CompactAccount(ctx context.Context, tenantID TenantID, userID UserID, ledgerID LedgerID, cutoffUnixUTC int64, writer ArchiveWriter) (CompactionResult, error) {
+	if err := service.checkCompaction(cutoffUnixUTC, writer); err != nil {
+		return CompactionResult{}, err
+	}
+	accountID, err := service.lookupAccountID(ctx, service.store, tenantID, userID, ledgerID)
+	if service.isLenientUnknownAccount(err) {
+		return CompactionResult{UserID: userID, LedgerID: ledgerID}, nil
+	}
+	if err != nil {
+		return CompactionResult{}, err
+	}
+	return service.compactAccount(ctx, tenantID, userID, ledgerID, accountID, cutoffUnixUTC, writer)
+}
+
+// CompactAccounts runs CompactAccount for every account of a tenant that matches filter, each in its own
+// transaction. Accounts are listed a page at a time, newest first, and compacted as each page is read.
+func (service *Service) CompactAccounts(ctx context.Context, tenantID TenantID, filter ListAccountsFilter, cutoffUnixUTC int64, writer ArchiveWriter) ([]CompactionResult, error) {
+	if err := service.checkCompaction(cutoffUnixUTC, writer); err != nil {
+		return nil, err
+	}
+	var results []CompactionResult
+	err := forEachByCreation(
+		func(beforeUnixUTC int64, limit int) ([]Account, error) {
+			return service.ListAccounts(ctx, tenantID, beforeUnixUTC, limit, filter)
+		},
+		func(account Account) string { return account.AccountID().String() },
+		Account.CreatedUnixUTC,
+		func(account Account) error {
+			result, err := service.compactAccount(ctx, tenantID, account.UserID(), account.LedgerID(), account.AccountID(), cutoffUnixUTC, writer)
+			if err != nil {
+				return err
+			}
+			results = append(results, result)
+			return nil
+		},
+	)
+	return results, err
+}
+
+// EntryArchive returns what compaction has archived of an account. It is the zero EntryArchive when nothing
+// was, when the store does not support archiving, or for an unknown account outside strict lookup.
+func (service *Service) EntryArchive(ctx context.Context, tenantID TenantID, userID UserID, ledgerID LedgerID) (EntryArchive, error) {
+	if _, ok := service.store.(ArchiveStore); !ok {
+		return EntryArchive{}, nil
+	}
+	accountID, err := service.lookupAccountID(ctx, service.store, tenantID, userID, ledgerID)
+	if service.isLenientUnknownAccount(err) {
+		return EntryArchive{}, nil
+	}
+	if err != nil {
+		return EntryArchive{}, err
+	}
+	return getEntryArchive(ctx, service.store, accountID)
+}
+
+func (service *Service) checkCompaction(cutoffUnixUTC int64, writer ArchiveWriter) error {
+	if writer == nil {
+		return fmt.Errorf("%w: archive writer is nil", ErrInvalidServiceConfig)
+	}
+	if _, ok := service.store.(ArchiveStore); !ok {
+		return ErrArchiveUnsupported
+	}
+	if cutoffUnixUTC <= 0 || cutoffUnixUTC > service.nowFn() {
+		return fmt.Errorf("%w: cutoff must be positive and not in the future", ErrInvalidArchiveCutoff)
+	}
+	return nil
+}
+
+func (service *Service) compactAccount(ctx context.Context, tenantID TenantID, userID UserID, ledgerID LedgerID, accountID AccountID, cutoffUnixUTC int64, writer ArchiveWriter) (CompactionResult, error) {
+	result := CompactionResult{AccountID: accountID, UserID: userID, LedgerID: ledgerID}
+	nowUnixUTC := service.nowFn()
+	var staged StagedArchive
+	err := service.store.WithTx(ctx, func(ctx context.Context, transactionStore Store) error {
+		archiveStore, ok := transactionStore.(ArchiveStore)
+		if !ok {
+			return ErrArchiveUnsupported
+		}
+		if _, err := archiveStore.LockChainHead(ctx, accountID); err != nil {
+			return err
+		}
+		previous, err := archiveStore.GetEntryArchive(ctx, accountID)
+		if err != nil {
+			return err
+		}
+		history, err := loadAccountHistory(ctx, transactionStore, archiveStore, accountID, previous.ThroughSequence)
+		if err != nil {
+			return err
+		}
+		archived := history.archivablePrefix(cutoffUnixUTC, nowUnixUTC)
+		for _, chained := range history.entries[len(archived):] {
+			if chained.Entry.CreatedUnixUTC() < cutoffUnixUTC {
+				result.RetainedBeforeCutoff++
+			}
+		}
+		if len(archived) == 0 {
+			return nil
+		}
+
+		accountArchive := AccountArchive{
+			TenantID:        tenantID,
+			UserID:          userID,
+			LedgerID:        ledgerID,
+			AccountID:       accountID,
+			CutoffUnixUTC:   cutoffUnixUTC,
+			ThroughSequence: previous.ThroughSequence,
+			ThroughHash:     previous.ThroughHash,
+			Entries:         archived,
+			Reservations:    history.settledReservations(archived),
+		}
+		entryIDs := make([]EntryID, 0, len(archived))
+		var openingCents int64
+		for _, chained := range archived {
+			entryIDs = append(entryIDs, chained.Entry.EntryID())
+			if chained.Link.Sequence != 0 {
+				accountArchive.ThroughSequence = chained.Link.Sequence
+				accountArchive.ThroughHash = chained.Link.Hash
+			}
+			openingCents += chained.Entry.BalanceCentsAt(nowUnixUTC)
+		}
+		accountArchive.OpeningBalanceCents = SignedAmountCents(openingCents)
+		reservationIDs := make([]ReservationID, 0, len(accountArchive.Reservations))
+		for _, reservation := range accountArchive.Reservations {
+			reservationIDs = append(reservationIDs, reservation.ReservationID())
+		}
+
+		staged, err = writer.StageAccountArchive(ctx, accountArchive)
+		if err != nil {
+			return fmt.Errorf("write archive: %w", err)
+		}
+		location := staged.Location()
+		archive := EntryArchive{
+			AccountID:       accountID,
+			CutoffUnixUTC:   max(previous.CutoffUnixUTC, cutoffUnixUTC),
+			ThroughSequence: accountArchive.ThroughSequence,
+			ThroughHash:     accountArchive.ThroughHash,
+			ArchivedEntries: previous.ArchivedEntries + int64(len(archived)),
+			Location:        location,
+			ArchivedUnixUTC: nowUnixUTC,
+		}
+		if err := archiveStore.ArchiveEntries(ctx, archive, entryIDs, reservationIDs); err != nil {
+			return err
+		}
+		if openingCents != 0 {
+			if err := insertOpeningBalance(ctx, transactionStore, archiveStore, archive, cutoffUnixUTC, EntryAmountCents(openingCents)); err != nil {
+				return err
+			}
+		}
+		result.ArchivedEntries = len(archived)
+		result.ArchivedReservations = len(reservationIDs)
+		result.OpeningBalanceCents = accountArchive.OpeningBalanceCents
+		result.Location = location
+		return nil
+	})
+	if err != nil {
+		if staged != nil {
+			if discardErr := staged.Discard(); discardErr != nil {
+				return CompactionResult{}, errors.Join(err, discardErr)
+			}
+		}
+		return CompactionResult{}, err
+	}
+	if staged != nil {
+		if err := staged.Publish(); err != nil {
+			return CompactionResult{}, err
+		}
+	}
+	return result, nil
+}
+
+// insertOpeningBalance appends the entry that carries an archived balance forward. It is chained like any other
+// entry but not published: the change feed, outbox, and journal already hold the entries it replaces.
+func insertOpeningBalance(ctx context.Context, store Store, chainStore ChainStore, archive EntryArchive, cutoffUnixUTC int64, amount EntryAmountCents) error {
+	idempotencyKey, err := NewIdempotencyKey(fmt.Sprintf("%s%s%d%s%d", openingBalanceKeyPrefix, idempotencyKeyDelimiter, cutoffUnixUTC, idempotencyKeyDelimiter, archive.ArchivedEntries))
+	if err != nil {
+		return err
+	}
+	encoded, err := json.Marshal(openingBalanceMetadata{CutoffUnixUTC: cutoffUnixUTC, ArchivedEntries: archive.ArchivedEntries, ArchiveLocation: archive.Location})
+	if err != nil {
+		return err
+	}
+	metadata, err := NewMetadataJSON(string(encoded))
+	if err != nil {
+		return err
+	}
+	entryInput, err := NewEntryInput(archive.AccountID, EntryOpeningBalance, amount, nil, nil, idempotencyKey, 0, metadata, cutoffUnixUTC)
+	if err != nil {
+		return err
+	}
+	entry, err := store.InsertEntry(ctx, entryInput.WithActor(ActorFromContext(ctx)))
+	if err != nil {
+		return err
+	}
+	return appendChainLink(ctx, chainStore, entry)
+}
+
+// getEntryArchive reads an account's archive record, or the zero EntryArchive when the store cannot archive.
+func getEntryArchive(ctx context.Context, store Store, accountID AccountID) (EntryArchive, error) {
+	archiveStore, ok := store.(ArchiveStore)
+	if !ok {
+		return EntryArchive{}, nil
+	}
+	return archiveStore.GetEntryArchive(ctx, accountID)
+}
+
+// accountHistory is every stored entry and reservation of an account, entries in chain order with the
+// entries written before hash chaining first, oldest to newest.
+type accountHistory struct {
+	entries      []ChainedEntry
+	reservations map[ReservationID]Reservation
+}
+
+func loadAccountHistory(ctx context.Context, store Store, chainStore ChainStore, accountID AccountID, afterSequence int64) (accountHistory, error) {
+	entries, err := listAccountEntries(ctx, store, accountID, ListEntriesFilter{})
+	if err != nil {
+		return accountHistory{}, err
+	}
+	var chained []ChainedEntry
+	for sequence := afterSequence; ; {
+		page, err := chainStore.ListChainedEntries(ctx, accountID, sequence, verifyChainPageSize)
+		if err != nil {
+			return accountHistory{}, err
+		}
+		chained = append(chained, page...)
+		if len(page) < verifyChainPageSize {
+			break
+		}
+		sequence = page[len(page)-1].Link.Sequence
+	}
+	linked := make(map[EntryID]struct{}, len(chained))
+	for _, item := range chained {
+		linked[item.Entry.EntryID()] = struct{}{}
+	}
+	var unchained []ChainedEntry
+	for _, entry := range entries {
+		if _, ok := linked[entry.EntryID()]; !ok {
+			unchained = append(unchained, ChainedEntry{Entry: entry})
+		}
+	}
+	sort.SliceStable(unchained, func(left, right int) bool {
+		if unchained[left].Entry.CreatedUnixUTC() != unchained[right].Entry.CreatedUnixUTC() {
+			return unchained[left].Entry.CreatedUnixUTC() < unchained[right].Entry.CreatedUnixUTC()
+		}
+		return unchained[left].Entry.EntryID().String() < unchained[right].Entry.EntryID().String()
+	})
+	reservations, err := listByCreation(
+		func(beforeUnixUTC int64, limit int) ([]Reservation, error) {
+			return store.ListReservations(ctx, accountID, beforeUnixUTC, limit, ListReservationsFilter{})
+		},
+		func(reservation Reservation) string { return reservation.ReservationID().String() },
+		Reservation.CreatedUnixUTC,
+	)
+	if err != nil {
+		return accountHistory{}, err
+	}
+	history := accountHistory{entries: append(unchained, chained...), reservations: make(map[ReservationID]Reservation, len(reservations))}
+	for _, reservation := range reservations {
+		history.reservations[reservation.ReservationID()] = reservation
+	}
+	return history, nil
+}
+
+// archivablePrefix returns the longest run of oldest entries that can be archived together: each is settled
+// before the cutoff, and no reservation or refunded debit has entries on both sides of the run's end.
+func (history accountHistory) archivablePrefix(cutoffUnixUTC int64, nowUnixUTC int64) []ChainedEntry {
+	end := 0
+	for end < len(history.entries) && history.isSettled(history.entries[end].Entry, cutoffUnixUTC, nowUnixUTC) {
+		end++
+	}
+	type span struct{ first, last int }
+	groups := make(map[string]*span)
+	extend := func(key string, index int) {
+		if group, ok := groups[key]; ok {
+			group.last = index
+			return
+		}
+		groups[key] = &span{first: index, last: index}
+	}
+	for index, chained := range history.entries {
+		entry := chained.Entry
+		if reservationID, ok := entry.ReservationID(); ok {
+			extend("reservation:"+reservationID.String(), index)
+		}
+		if originalEntryID, ok := entry.RefundOfEntryID(); ok {
+			extend("debit:"+originalEntryID.String(), index)
+		} else if entry.AmountCents().Int64() < 0 {
+			extend("debit:"+entry.EntryID().String(), index)
+		}
+	}
+	for shrunk := true; shrunk; {
+		shrunk = false
+		for _, group := range groups {
+			if group.first < end && group.last >= end {
+				end = group.first
+				shrunk = true
+			}
+		}
+	}
+	return history.entries[:end]
+}
+
+// isSettled reports whether an entry can no longer change the account: it was created before the cutoff, is
+// in effect, and has expired or never expires. Entries of a reservation instead need the reservation to be
+// captured or released, or to have expired before the cutoff; archivablePrefix keeps its entries together.
+func (history accountHistory) isSettled(entry Entry, cutoffUnixUTC int64, nowUnixUTC int64) bool {
+	if entry.CreatedUnixUTC() >= cutoffUnixUTC || entry.IsPendingAt(nowUnixUTC) {
+		return false
+	}
+	if reservationID, ok := entry.ReservationID(); ok {
+		reservation, known := history.reservations[reservationID]
+		if !known {
+			return false
+		}
+		if reservation.Status() == ReservationStatusActive {
+			return reservation.ExpiresAtUnixUTC() != 0 && reservation.ExpiresAtUnixUTC() < cutoffUnixUTC
+		}
+		return true
+	}
+	return entry.ExpiresAtUnixUTC() == 0 || entry.ExpiresAtUnixUTC() <= nowUnixUTC
+}
+
+// settledReservations returns the reservations whose entries are all among archived.
+func (history accountHistory) settledReservations(archived []ChainedEntry) []Reservation {
+	var reservations []Reservation
+	seen := make(map[ReservationID]struct{})
+	for _, chained := range archived {
+		reservationID, ok := chained.Entry.ReservationID()
+		if !ok {
+			continue
+		}
+		if _, done := seen[reservationID]; done {
+			continue
+		}
+		seen[reservationID] = struct{}{}
+		if reservation, known := history.reservations[reservationID]; known {
+			reservations = append(reservations, reservation)
+		}
+	}
+	return reservations
+}
@@ -0,0 +1,381 @@
+package ledger
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// compactionEntry builds a stored entry of account acct-1 whose entry id and idempotency key are both key.
+func compactionEntry(test *testing.T, key string, entryType EntryType, cents int64, reservationID *ReservationID, refundOfEntryID *EntryID, expiresAtUnixUTC int64, createdUnixUTC int64) ChainedEntry {
+	test.Helper()
+	entry, err := NewEntry(mustEntryID(test, key), mustAccountID(test, "acct-1"), entryType, EntryAmountCents(cents), reservationID, refundOfEntryID, mustIdempotencyKey(test, key), expiresAtUnixUTC, mustMetadata(test, "{}"), createdUnixUTC)
+	if err != nil {
+		test.Fatalf("entry: %v", err)
+	}
+	return ChainedEntry{Entry: entry}
+}
+
+func compactionReservation(test *testing.T, rawReservationID string, status ReservationStatus, expiresAtUnixUTC int64) Reservation {
+	test.Helper()
+	reservation, err := NewReservation(mustAccountID(test, "acct-1"), mustReservationID(test, rawReservationID), mustPositiveAmount(test, 10), status, expiresAtUnixUTC)
+	if err != nil {
+		test.Fatalf("reservation: %v", err)
+	}
+	return reservation
+}
+
+func TestArchivablePrefixStopsAtTheFirstOpenEntry(test *testing.T) {
+	test.Parallel()
+	const cutoffUnixUTC, nowUnixUTC = 50, 100
+	order := mustReservationID(test, "order")
+	spendID := mustEntryID(test, "spend")
+	reservations := map[string]Reservation{
+		"captured":        compactionReservation(test, "order", ReservationStatusCaptured, 0),
+		"active":          compactionReservation(test, "order", ReservationStatusActive, 0),
+		"active expiring": compactionReservation(test, "order", ReservationStatusActive, 40),
+	}
+	testCases := []struct {
+		name         string
+		entries      []ChainedEntry
+		reservation  string
+		wantArchived int
+	}{
+		{
+			name:         "entry after the cutoff",
+			entries:      []ChainedEntry{compactionEntry(test, "old", EntryGrant, 10, nil, nil, 0, 10), compactionEntry(test, "new", EntryGrant, 10, nil, nil, 0, 60)},
+			wantArchived: 1,
+		},
+		{
+			name:         "pending entry",
+			entries:      []ChainedEntry{{Entry: compactionEntry(test, "pending", EntryGrant, 10, nil, nil, 0, 10).Entry.WithEffectiveAtUnixUTC(200)}},
+			wantArchived: 0,
+		},
+		{
+			name:         "unexpired grant",
+			entries:      []ChainedEntry{compactionEntry(test, "grant", EntryGrant, 10, nil, nil, 200, 10)},
+			wantArchived: 0,
+		},
+		{
+			name:         "expired grant",
+			entries:      []ChainedEntry{compactionEntry(test, "grant", EntryGrant, 10, nil, nil, 90, 10)},
+			wantArchived: 1,
+		},
+		{
+			name:         "unknown reservation",
+			entries:      []ChainedEntry{compactionEntry(test, "hold", EntryHold, -10, &order, nil, 0, 10)},
+			wantArchived: 0,
+		},
+		{
+			name:         "active reservation",
+			entries:      []ChainedEntry{compactionEntry(test, "hold", EntryHold, -10, &order, nil, 0, 10)},
+			reservation:  "active",
+			wantArchived: 0,
+		},
+		{
+			name:         "active reservation expired before the cutoff",
+			entries:      []ChainedEntry{compactionEntry(test, "hold", EntryHold, -10, &order, nil, 0, 10)},
+			reservation:  "active expiring",
+			wantArchived: 1,
+		},
+		{
+			name:         "reservation across the cutoff",
+			entries:      []ChainedEntry{compactionEntry(test, "hold", EntryHold, -10, &order, nil, 0, 10), compactionEntry(test, "capture", EntrySpend, -10, &order, nil, 0, 60)},
+			reservation:  "captured",
+			wantArchived: 0,
+		},
+		{
+			name:         "refunded debit across the cutoff",
+			entries:      []ChainedEntry{compactionEntry(test, "spend", EntrySpend, -10, nil, nil, 0, 10), compactionEntry(test, "refund", EntryRefund, 10, nil, &spendID, 0, 60)},
+			wantArchived: 0,
+		},
+		{
+			name:         "refunded debit before the cutoff",
+			entries:      []ChainedEntry{compactionEntry(test, "spend", EntrySpend, -10, nil, nil, 0, 10), compactionEntry(test, "refund", EntryRefund, 10, nil, &spendID, 0, 20)},
+			wantArchived: 2,
+		},
+	}
+	for _, testCase := range testCases {
+		test.Run(testCase.name, func(test *testing.T) {
+			test.Parallel()
+			history := accountHistory{entries: testCase.entries, reservations: map[ReservationID]Reservation{}}
+			if testCase.reservation != "" {
+				history.reservations[order] = reservations[testCase.reservation]
+			}
+			if archived := history.archivablePrefix(cutoffUnixUTC, nowUnixUTC); len(archived) != testCase.wantArchived {
+				test.Fatalf("expected %d archivable entries, got %d", testCase.wantArchived, len(archived))
+			}
+		})
+	}
+}
+
+func TestSettledReservationsListsEachKnownReservationOnce(test *testing.T) {
+	test.Parallel()
+	order := mustReservationID(test, "order")
+	unknown := mustReservationID(test, "unknown")
+	history := accountHistory{reservations: map[ReservationID]Reservation{order: compactionReservation(test, "order", ReservationStatusCaptured, 0)}}
+	archived := []ChainedEntry{
+		compactionEntry(test, "grant", EntryGrant, 10, nil, nil, 0, 10),
+		compactionEntry(test, "hold", EntryHold, -10, &order, nil, 0, 10),
+		compactionEntry(test, "capture", EntrySpend, -10, &order, nil, 0, 10),
+		compactionEntry(test, "other", EntryHold, -10, &unknown, nil, 0, 10),
+	}
+	reservations := history.settledReservations(archived)
+	if len(reservations) != 1 || reservations[0].ReservationID() != order {
+		test.Fatalf("expected only the known reservation, got %+v", reservations)
+	}
+}
+
+// pagedChainStore returns one full page of chained entries and then an empty one.
+type pagedChainStore struct {
+	*stubStore
+	afterSequences *[]int64
+}
+
+func (store pagedChainStore) ListChainedEntries(ctx context.Context, accountID AccountID, afterSequence int64, limit int) ([]ChainedEntry, error) {
+	*store.afterSequences = append(*store.afterSequences, afterSequence)
+	if afterSequence > 0 {
+		return nil, nil
+	}
+	page := make([]ChainedEntry, limit)
+	for index := range page {
+		page[index].Link.Sequence = int64(index + 1)
+	}
+	return page, nil
+}
+
+func TestLoadAccountHistoryPagesChainAndOrdersUnchainedEntries(test *testing.T) {
+	test.Parallel()
+	store := newStubStore(test, 0)
+	store.listEntries = []Entry{
+		compactionEntry(test, "b-late", EntryGrant, 10, nil, nil, 0, 20).Entry,
+		compactionEntry(test, "b-early", EntryGrant, 10, nil, nil, 0, 10).Entry,
+		compactionEntry(test, "a-early", EntryGrant, 10, nil, nil, 0, 10).Entry,
+	}
+	afterSequences := &[]int64{}
+	history, err := loadAccountHistory(context.Background(), store, pagedChainStore{stubStore: store, afterSequences: afterSequences}, store.accountID, 0)
+	if err != nil {
+		test.Fatalf("load history: %v", err)
+	}
+	if len(*afterSequences) != 2 || (*afterSequences)[1] != verifyChainPageSize {
+		test.Fatalf("expected a second page after sequence %d, got %v", verifyChainPageSize, *afterSequences)
+	}
+	if len(history.entries) != 3+verifyChainPageSize {
+		test.Fatalf("expected every entry in the history, got %d", len(history.entries))
+	}
+	for index, want := range []string{"a-early", "b-early", "b-late"} {
+		if got := history.entries[index].Entry.EntryID().String(); got != want {
+			test.Fatalf("unchained entry %d: expected %s, got %s", index, want, got)
+		}
+	}
+}
+
+func TestCompactAccountArchivesSettledReservations(test *testing.T) {
+	test.Parallel()
+	ctx := context.Background()
+	tenantID := mustTenantID(test, defaultTenantIDValue)
+	userID := mustUserID(test, "user-1")
+	ledgerID := mustLedgerID(test, defaultLedgerIDValue)
+	nowUnixUTC := int64(10)
+	archives := &[]EntryArchive{}
+	store := archivingStubStore{stubStore: newStubStore(test, 0), archives: archives}
+	service, err := NewService(store, func() int64 { return nowUnixUTC })
+	if err != nil {
+		test.Fatalf("new service: %v", err)
+	}
+	if _, err := service.GrantEntry(ctx, tenantID, userID, ledgerID, mustPositiveAmount(test, 100), mustIdempotencyKey(test, "grant-1"), 0, mustMetadata(test, "{}")); err != nil {
+		test.Fatalf("grant: %v", err)
+	}
+	orderID := mustReservationID(test, "order-1")
+	if err := service.Reserve(ctx, tenantID, userID, ledgerID, mustPositiveAmount(test, 40), orderID, mustIdempotencyKey(test, "hold-1"), 0, mustMetadata(test, "{}")); err != nil {
+		test.Fatalf("reserve: %v", err)
+	}
+	if err := service.Capture(ctx, tenantID, userID, ledgerID, orderID, mustIdempotencyKey(test, "capture-1"), mustPositiveAmount(test, 40), mustMetadata(test, "{}")); err != nil {
+		test.Fatalf("capture: %v", err)
+	}
+	if _, err := service.GrantEntry(ctx, tenantID, userID, ledgerID, mustPositiveAmount(test, 5), mustIdempotencyKey(test, "expiring"), 1000, mustMetadata(test, "{}")); err != nil {
+		test.Fatalf("expiring grant: %v", err)
+	}
+	nowUnixUTC = 60
+	if _, err := service.GrantEntry(ctx, tenantID, userID, ledgerID, mustPositiveAmount(test, 5), mustIdempotencyKey(test, "grant-2"), 0, mustMetadata(test, "{}")); err != nil {
+		test.Fatalf("grant after the cutoff: %v", err)
+	}
+	nowUnixUTC = 100
+
+	result, err := service.CompactAccount(ctx, tenantID, userID, ledgerID, 50, failingArchiveWriter{})
+	if err != nil {
+		test.Fatalf("compact: %v", err)
+	}
+	if result.ArchivedReservations != 1 || result.ArchivedEntries != 4 || result.RetainedBeforeCutoff != 1 {
+		test.Fatalf("expected the captured reservation archived, got %+v", result)
+	}
+	archive, err := service.EntryArchive(ctx, tenantID, userID, ledgerID)
+	if err != nil {
+		test.Fatalf("entry archive: %v", err)
+	}
+	if archive.ArchivedEntries != int64(result.ArchivedEntries) || archive.Location != "archive" {
+		test.Fatalf("unexpected archive record %+v", archive)
+	}
+
+	again, err := service.CompactAccount(ctx, tenantID, userID, ledgerID, 50, failingArchiveWriter{})
+	if err != nil {
+		test.Fatalf("compact again: %v", err)
+	}
+	if again.ArchivedEntries != 0 || again.Location != "" {
+		test.Fatalf("expected nothing left to archive, got %+v", again)
+	}
+}
+
+func TestCompactAccountsCompactsEveryListedAccount(test *testing.T) {
+	test.Parallel()
+	ctx := context.Background()
+	tenantID := mustTenantID(test, defaultTenantIDValue)
+	service, archives := newCompactionFixture(test, nil)
+	store := service.store.(archivingStubStore)
+	account, err := NewAccount(store.accountID, tenantID, mustUserID(test, "user-1"), mustLedgerID(test, defaultLedgerIDValue), 10)
+	if err != nil {
+		test.Fatalf("account: %v", err)
+	}
+	store.accounts = []Account{account}
+
+	results, err := service.CompactAccounts(ctx, tenantID, ListAccountsFilter{}, 50, failingArchiveWriter{})
+	if err != nil {
+		test.Fatalf("compact accounts: %v", err)
+	}
+	if len(results) != 1 || results[0].ArchivedEntries != 1 || len(*archives) != 1 {
+		test.Fatalf("expected the account compacted, got %+v", results)
+	}
+
+	stageErr := errors.New("stage failed")
+	if _, err := service.CompactAccounts(ctx, tenantID, ListAccountsFilter{}, 50, failingArchiveWriter{stageErr: stageErr}); err != nil {
+		test.Fatalf("expected nothing left to stage, got %v", err)
+	}
+	failing, _ := newCompactionFixture(test, nil)
+	failing.store.(archivingStubStore).stubStore.accounts = []Account{account}
+	if _, err := failing.CompactAccounts(ctx, tenantID, ListAccountsFilter{}, 50, failingArchiveWriter{stageErr: stageErr}); !errors.Is(err, stageErr) {
+		test.Fatalf("expected the staging error, got %v", err)
+	}
+}
+
+// archiveOutsideTransactionStore archives only outside transactions: its transaction store is the plain stub.
+type archiveOutsideTransactionStore struct {
+	archivingStubStore
+}
+
+func (store archiveOutsideTransactionStore) WithTx(ctx context.Context, fn func(ctx context.Context, txStore Store) error) error {
+	return store.stubStore.WithTx(ctx, fn)
+}
+
+func TestCompactAccountReportsInvalidInputsAndStoreFailures(test *testing.T) {
+	test.Parallel()
+	ctx := context.Background()
+	tenantID := mustTenantID(test, defaultTenantIDValue)
+	userID := mustUserID(test, "user-1")
+	ledgerID := mustLedgerID(test, defaultLedgerIDValue)
+	storeErr := errors.New("store failed")
+	writer := failingArchiveWriter{}
+
+	service, _ := newCompactionFixture(test, nil)
+	if _, err := service.CompactAccount(ctx, tenantID, userID, ledgerID, 50, nil); !errors.Is(err, ErrInvalidServiceConfig) {
+		test.Fatalf("expected ErrInvalidServiceConfig for a nil writer, got %v", err)
+	}
+	for _, cutoffUnixUTC := range []int64{0, 101} {
+		if _, err := service.CompactAccount(ctx, tenantID, userID, ledgerID, cutoffUnixUTC, writer); !errors.Is(err, ErrInvalidArchiveCutoff) {
+			test.Fatalf("cutoff %d: expected ErrInvalidArchiveCutoff, got %v", cutoffUnixUTC, err)
+		}
+	}
+
+	store := service.store.(archivingStubStore)
+	store.accountMissing = true
+	result, err := service.CompactAccount(ctx, tenantID, userID, ledgerID, 50, writer)
+	if err != nil || result.UserID != userID || result.ArchivedEntries != 0 {
+		test.Fatalf("expected an empty result for an unknown account, got %+v (%v)", result, err)
+	}
+	archive, err := service.EntryArchive(ctx, tenantID, userID, ledgerID)
+	if err != nil || !archive.IsZero() {
+		test.Fatalf("expected a zero archive for an unknown account, got %+v (%v)", archive, err)
+	}
+	store.accountMissing = false
+	store.getAccountError = storeErr
+	if _, err := service.CompactAccount(ctx, tenantID, userID, ledgerID, 50, writer); !errors.Is(err, storeErr) {
+		test.Fatalf("expected the lookup error, got %v", err)
+	}
+	if _, err := service.EntryArchive(ctx, tenantID, userID, ledgerID); !errors.Is(err, storeErr) {
+		test.Fatalf("expected the lookup error from the archive read, got %v", err)
+	}
+
+	failures := map[string]func(store *archivingStubStore){
+		"lock":             func(store *archivingStubStore) { store.lockErr = storeErr },
+		"archive read":     func(store *archivingStubStore) { store.getArchiveErr = storeErr },
+		"entries":          func(store *archivingStubStore) { store.listErr = storeErr },
+		"chain":            func(store *archivingStubStore) { store.chainErr = storeErr },
+		"opening balance":  func(store *archivingStubStore) { store.insertEntryError, store.insertEntryErrorAtCall = storeErr, 2 },
+		"reservation list": func(store *archivingStubStore) { store.reservationsErr = storeErr },
+	}
+	for name, configure := range failures {
+		service, _ := newCompactionFixture(test, nil)
+		store := service.store.(archivingStubStore)
+		configure(&store)
+		service.store = store
+		if _, err := service.CompactAccount(ctx, tenantID, userID, ledgerID, 50, writer); !errors.Is(err, storeErr) {
+			test.Fatalf("%s: expected the store error, got %v", name, err)
+		}
+	}
+
+	outside, _ := newCompactionFixture(test, nil)
+	outside.store = archiveOutsideTransactionStore{archivingStubStore: outside.store.(archivingStubStore)}
+	if _, err := outside.CompactAccount(ctx, tenantID, userID, ledgerID, 50, writer); !errors.Is(err, ErrArchiveUnsupported) {
+		test.Fatalf("expected ErrArchiveUnsupported inside the transaction, got %v", err)
+	}
+}
+
+// archivedKeyStore reports every idempotency key as taken by an archived entry.
+type archivedKeyStore struct {
+	*stubStore
+}
+
+func (store archivedKeyStore) WithTx(ctx context.Context, fn func(ctx context.Context, txStore Store) error) error {
+	return store.stubStore.WithTx(ctx, func(ctx context.Context, txStore Store) error {
+		return fn(ctx, archivedKeyStore{stubStore: txStore.(*stubStore)})
+	})
+}
+
+func (store archivedKeyStore) GetEntryByIdempotencyKey(context.Context, AccountID, IdempotencyKey) (Entry, error) {
+	return Entry{}, ErrArchivedEntry
+}
+
+func TestRefundsRejectIdempotencyKeysOfArchivedEntries(test *testing.T) {
+	test.Parallel()
+	ctx := context.Background()
+	tenantID := mustTenantID(test, defaultTenantIDValue)
+	userID := mustUserID(test, "user-1")
+	ledgerID := mustLedgerID(test, defaultLedgerIDValue)
+	store := archivedKeyStore{stubStore: newStubStore(test, 0)}
+	service := mustNewService(test, store)
+	originalEntryID := mustEntryID(test, "spend-1")
+
+	if err := service.RefundByEntryID(ctx, tenantID, userID, ledgerID, originalEntryID, mustPositiveAmount(test, 10), mustIdempotencyKey(test, "refund-1"), mustMetadata(test, "{}")); !errors.Is(err, ErrDuplicateIdempotencyKey) {
+		test.Fatalf("expected ErrDuplicateIdempotencyKey, got %v", err)
+	}
+	_, err := service.applyBatchRefund(ctx, store, tenantID, userID, ledgerID, store.accountID, BatchRefundOperation{
+		OriginalEntryID: &originalEntryID,
+		Amount:          mustPositiveAmount(test, 10),
+		IdempotencyKey:  mustIdempotencyKey(test, "refund-1"),
+		Metadata:        mustMetadata(test, "{}"),
+	})
+	if !errors.Is(err, ErrIdempotencyKeyConflict) {
+		test.Fatalf("expected ErrIdempotencyKeyConflict, got %v", err)
+	}
+}
+
+func TestVerifyChainReturnsArchiveReadFailure(test *testing.T) {
+	test.Parallel()
+	storeErr := errors.New("archive read failed")
+	service, _ := newCompactionFixture(test, nil)
+	store := service.store.(archivingStubStore)
+	store.getArchiveErr = storeErr
+	service.store = store
+	if _, err := service.VerifyChain(context.Background(), mustTenantID(test, defaultTenantIDValue), mustUserID(test, "user-1"), mustLedgerID(test, defaultLedgerIDValue)); !errors.Is(err, storeErr) {
+		test.Fatalf("expected the archive read error, got %v", err)
+	}
+}
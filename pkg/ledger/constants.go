@@ -8,10 +8,20 @@ const (
 	operationSpend   = "spend"
 	operationRefund  = "refund"
 
-	operationStatusOK    = "ok"
-	operationStatusError = "error"
+	operationCancelGrant = "cancel_grant"
+
+	operationSpendAcrossLedgers  = "spend_across_ledgers"
+	operationRefundAcrossLedgers = "refund_across_ledgers"
+
+	operationBatch = "batch"
+
+	operationStatusOK         = "ok"
+	operationStatusError      = "error"
+	operationStatusDuplicate  = "duplicate"
+	operationStatusRolledBack = "rolled_back"
 
 	idempotencyKeyDelimiter  = ":"
 	idempotencySuffixReverse = "reverse"
 	idempotencySuffixSpend   = "spend"
+	idempotencySuffixCancel  = "cancel"
 )
@@ -0,0 +1,38 @@
+package ledger
+
+import "context"
+
+// planGrantConsumption draws a spend on the account's expiring grants, earliest expiry first, and returns the
+// part of each grant it consumes. Consumed credit stays in the account's total after its grant expires, so only
+// the unconsumed remainder breaks. The returned lines move the consumed part back from breakage at each
+// grant's expiry; whatever the grants cannot cover is drawn on non-expiring credit. It only reads; the caller
+// records the consumptions with the spend. Stores without GrantConsumptionStore consume nothing.
+func planGrantConsumption(ctx context.Context, store Store, tenantID TenantID, ledgerID LedgerID, spend Entry) ([]GrantConsumption, []JournalLine, error) {
+	remaining := -spend.AmountCents().Int64()
+	consumptionStore, ok := store.(GrantConsumptionStore)
+	if !ok || spend.Type() != EntrySpend || remaining <= 0 {
+		return nil, nil, nil
+	}
+	grants, err := consumptionStore.ListConsumableGrants(ctx, spend.AccountID(), spend.CreatedUnixUTC())
+	if err != nil {
+		return nil, nil, err
+	}
+	var consumptions []GrantConsumption
+	var lines []JournalLine
+	for _, grant := range grants {
+		if remaining == 0 {
+			break
+		}
+		consumed := min(grant.AmountCents().Int64()-grant.ConsumedCents().Int64(), remaining)
+		if consumed <= 0 {
+			continue
+		}
+		remaining -= consumed
+		consumptions = append(consumptions, GrantConsumption{GrantEntryID: grant.EntryID(), AmountCents: AmountCents(consumed)})
+		lines = append(lines,
+			newJournalLine(tenantID, ledgerID, spend, JournalAccountUser, consumed, grant.ExpiresAtUnixUTC()),
+			newJournalLine(tenantID, ledgerID, spend, JournalAccountBreakage, -consumed, grant.ExpiresAtUnixUTC()),
+		)
+	}
+	return consumptions, lines, nil
+}
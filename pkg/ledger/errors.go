@@ -10,12 +10,20 @@ var (
 	ErrInsufficientFunds        = errors.New("insufficient funds")
 	ErrUnknownReservation       = errors.New("unknown reservation")
 	ErrUnknownEntry             = errors.New("unknown entry")
+	ErrUnknownAccount           = errors.New("unknown account")
 	ErrDuplicateIdempotencyKey  = errors.New("duplicate idempotency key")
 	ErrIdempotencyKeyConflict   = errors.New("idempotency key conflict")
 	ErrReservationExists        = errors.New("reservation already exists")
 	ErrReservationClosed        = errors.New("reservation closed")
 	ErrInvalidRefundOriginal    = errors.New("invalid refund original")
 	ErrRefundExceedsDebit       = errors.New("refund exceeds debit")
+	ErrGrantNotCancellable      = errors.New("grant not cancellable")
+	ErrVelocityLimitExceeded    = errors.New("velocity limit exceeded")
+	ErrBalanceLimitExceeded     = errors.New("balance limit exceeded")
+	ErrUnknownLedger            = errors.New("unknown ledger")
+	ErrAmountLimitExceeded      = errors.New("amount limit exceeded")
+	ErrExpiringGrantNotAllowed  = errors.New("expiring grant not allowed")
+	ErrReservationTTLExceeded   = errors.New("reservation ttl exceeded")
 	ErrInvalidAccountID         = errors.New("invalid account id")
 	ErrInvalidEntryID           = errors.New("invalid entry id")
 	ErrInvalidUserID            = errors.New("invalid user id")
@@ -28,8 +36,26 @@ var (
 	ErrInvalidEntryType         = errors.New("invalid entry type")
 	ErrInvalidReservationStatus = errors.New("invalid reservation status")
 	ErrInvalidMetadataJSON      = errors.New("invalid metadata json")
+	ErrInvalidEffectiveAt       = errors.New("invalid effective at")
 	ErrInvalidServiceConfig     = errors.New("invalid service config")
+	ErrInvalidVelocityRule      = errors.New("invalid velocity rule")
+	ErrInvalidBalanceLimit      = errors.New("invalid balance limit")
+	ErrInvalidBalanceThreshold  = errors.New("invalid balance threshold")
+	ErrInvalidPolicy            = errors.New("invalid policy")
+	ErrInvalidJournalAccount    = errors.New("invalid journal account")
 	ErrInvalidBalance           = errors.New("invalid balance")
+	ErrInvalidCursor            = errors.New("invalid cursor")
+	ErrInvalidActor             = errors.New("invalid actor")
+	ErrInvalidArchiveCutoff     = errors.New("invalid archive cutoff")
+	ErrArchiveUnsupported       = errors.New("store does not support archiving")
+	ErrAccountListUnsupported   = errors.New("store does not support listing accounts")
+	ErrVelocityUnsupported      = errors.New("store does not support velocity limits")
+	ErrJournalUnsupported       = errors.New("store does not support the journal")
+	ErrLiabilityUnsupported     = errors.New("store does not support liability reports")
+	ErrChangeFeedUnsupported    = errors.New("store does not support the change feed")
+	ErrOutboxUnsupported        = errors.New("store does not support the outbox")
+	ErrChainUnsupported         = errors.New("store does not support hash chains")
+	ErrArchivedEntry            = errors.New("archived entry")
 )
 
 // OperationError wraps a failure with a stable operation code.
@@ -0,0 +1,81 @@
+package ledger
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+)
+
+const jsonlFileMode = 0o600
+
+// JSONLFileSink appends every event to a file as one JSON object per line.
+type JSONLFileSink struct {
+	mutex sync.Mutex
+	file  *os.File
+}
+
+// NewJSONLFileSink opens path for appending, creating it when missing.
+func NewJSONLFileSink(path string) (*JSONLFileSink, error) {
+	if path == "" {
+		return nil, fmt.Errorf("%w: event sink path is empty", ErrInvalidServiceConfig)
+	}
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, jsonlFileMode)
+	if err != nil {
+		return nil, fmt.Errorf("open event sink file: %w", err)
+	}
+	return &JSONLFileSink{file: file}, nil
+}
+
+// HandleEvent writes the event as a single line.
+func (sink *JSONLFileSink) HandleEvent(ctx context.Context, event DomainEvent) error {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("encode event: %w", err)
+	}
+	line = append(line, '\n')
+	sink.mutex.Lock()
+	defer sink.mutex.Unlock()
+	if _, err := sink.file.Write(line); err != nil {
+		return fmt.Errorf("write event: %w", err)
+	}
+	return nil
+}
+
+// Close closes the underlying file.
+func (sink *JSONLFileSink) Close() error {
+	sink.mutex.Lock()
+	defer sink.mutex.Unlock()
+	return sink.file.Close()
+}
+
+// ChannelSink hands events to an in-process consumer over a channel.
+type ChannelSink struct {
+	events chan DomainEvent
+}
+
+// NewChannelSink creates a sink whose channel buffers up to buffer events.
+func NewChannelSink(buffer int) (*ChannelSink, error) {
+	if buffer < 0 {
+		return nil, fmt.Errorf("%w: event sink buffer is negative", ErrInvalidServiceConfig)
+	}
+	return &ChannelSink{events: make(chan DomainEvent, buffer)}, nil
+}
+
+// Events returns the channel the sink delivers to.
+func (sink *ChannelSink) Events() <-chan DomainEvent {
+	return sink.events
+}
+
+// HandleEvent blocks until the consumer has room for the event or ctx is done, in which case the event is
+// dropped and the error is logged. It runs after the transaction commits, so a slow consumer delays only the
+// operation's return, not the database.
+func (sink *ChannelSink) HandleEvent(ctx context.Context, event DomainEvent) error {
+	select {
+	case sink.events <- event:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,303 @@
+package ledger
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+const operationEventSink = "event_sink"
+
+// DomainEventType names the kind of change a DomainEvent describes.
+type DomainEventType string
+
+const (
+	// DomainEventEntryCreated is published for every persisted entry.
+	DomainEventEntryCreated DomainEventType = "entry_created"
+	// DomainEventReservationStateChanged is published when a reservation is created, captured, or released.
+	DomainEventReservationStateChanged DomainEventType = "reservation_state_changed"
+	// DomainEventRefundApplied is published after the entry_created event of every refund entry.
+	DomainEventRefundApplied DomainEventType = "refund_applied"
+	// DomainEventBalanceThresholdCrossed is published when a transaction moves an account across a BalanceThreshold.
+	DomainEventBalanceThresholdCrossed DomainEventType = "balance_threshold_crossed"
+)
+
+// String returns the event type as a primitive value.
+func (eventType DomainEventType) String() string {
+	return string(eventType)
+}
+
+// ReservationStateChange describes a reservation moving between states. From is empty for a new reservation.
+type ReservationStateChange struct {
+	ReservationID    ReservationID
+	From             ReservationStatus
+	To               ReservationStatus
+	AmountCents      PositiveAmountCents
+	ExpiresAtUnixUTC int64
+}
+
+// RefundApplied links a refund entry to the debit it refunds.
+type RefundApplied struct {
+	RefundEntryID   EntryID
+	OriginalEntryID EntryID
+	AmountCents     int64
+}
+
+// DomainEvent is a change made by a Service operation. Which of the optional fields are set depends on Type:
+// Entry and Balance for entry_created, Reservation for reservation_state_changed, Refund and Balance for
+// refund_applied, and ThresholdCrossing for balance_threshold_crossed. Balance is the account balance right
+// after the change, within the transaction.
+type DomainEvent struct {
+	Type              DomainEventType
+	TenantID          TenantID
+	UserID            UserID
+	LedgerID          LedgerID
+	AccountID         AccountID
+	OccurredUnixUTC   int64
+	Entry             *Entry
+	Balance           *Balance
+	Reservation       *ReservationStateChange
+	Refund            *RefundApplied
+	ThresholdCrossing *BalanceThresholdCrossing
+}
+
+// EventSink receives domain events. Events are collected while an operation's transaction runs and handed
+// to HandleEvent, in the order the changes were made, only after the transaction commits, so a sink never sees
+// a change that was rolled back and never holds the transaction open. The change is already committed when a
+// sink runs, so a HandleEvent error is reported to the operation logger and does not fail the operation.
+// Integrations that must not miss an event should use the webhook outbox, which is written in the transaction.
+type EventSink interface {
+	HandleEvent(ctx context.Context, event DomainEvent) error
+}
+
+// WithEventSink adds a sink that receives every domain event. Sinks run in the order they were added.
+func WithEventSink(sink EventSink) ServiceOption {
+	return func(service *Service) {
+		if sink != nil {
+			service.eventSinks = append(service.eventSinks, sink)
+		}
+	}
+}
+
+type eventBufferKey struct{}
+
+// eventBuffer holds the events of a transaction until it commits.
+type eventBuffer struct {
+	events []DomainEvent
+}
+
+// mark returns the buffer position, for discardFrom once a savepoint rolls back.
+func (buffer *eventBuffer) mark() int {
+	if buffer == nil {
+		return 0
+	}
+	return len(buffer.events)
+}
+
+// discardFrom drops the events buffered since position.
+func (buffer *eventBuffer) discardFrom(position int) {
+	if buffer == nil {
+		return
+	}
+	buffer.events = buffer.events[:position]
+}
+
+func transactionEvents(ctx context.Context) *eventBuffer {
+	buffer, _ := ctx.Value(eventBufferKey{}).(*eventBuffer)
+	return buffer
+}
+
+// publishEvent buffers event in the transaction running on ctx.
+func (service *Service) publishEvent(ctx context.Context, event DomainEvent) {
+	if buffer := transactionEvents(ctx); buffer != nil {
+		buffer.events = append(buffer.events, event)
+	}
+}
+
+// dispatchEvents hands the events of a committed transaction to every sink.
+func (service *Service) dispatchEvents(ctx context.Context, events []DomainEvent) {
+	for _, event := range events {
+		for _, sink := range service.eventSinks {
+			if err := sink.HandleEvent(ctx, event); err != nil {
+				service.logOperation(ctx, OperationLog{
+					Operation: operationEventSink,
+					TenantID:  event.TenantID,
+					UserID:    event.UserID,
+					LedgerID:  event.LedgerID,
+					Error:     fmt.Errorf("event sink %s: %w", event.Type, err),
+				})
+			}
+		}
+	}
+}
+
+// publishEntryEvents publishes entry_created for entry and, for refunds, refund_applied.
+func (service *Service) publishEntryEvents(ctx context.Context, store Store, tenantID TenantID, userID UserID, ledgerID LedgerID, entry Entry) error {
+	if len(service.eventSinks) == 0 {
+		return nil
+	}
+	nowUnixUTC := service.nowFn()
+	balance, err := accountBalance(ctx, store, entry.AccountID(), nowUnixUTC)
+	if err != nil {
+		return err
+	}
+	event := DomainEvent{
+		Type:            DomainEventEntryCreated,
+		TenantID:        tenantID,
+		UserID:          userID,
+		LedgerID:        ledgerID,
+		AccountID:       entry.AccountID(),
+		OccurredUnixUTC: nowUnixUTC,
+		Entry:           &entry,
+		Balance:         &balance,
+	}
+	service.publishEvent(ctx, event)
+	originalEntryID, isRefund := entry.RefundOfEntryID()
+	if !isRefund {
+		return nil
+	}
+	event.Type = DomainEventRefundApplied
+	event.Entry = nil
+	event.Refund = &RefundApplied{
+		RefundEntryID:   entry.EntryID(),
+		OriginalEntryID: originalEntryID,
+		AmountCents:     entry.AmountCents().Int64(),
+	}
+	service.publishEvent(ctx, event)
+	return nil
+}
+
+// createReservation persists a new active reservation and publishes its state change.
+func (service *Service) createReservation(ctx context.Context, store Store, tenantID TenantID, userID UserID, ledgerID LedgerID, reservation Reservation) error {
+	if err := store.CreateReservation(ctx, reservation); err != nil {
+		return err
+	}
+	service.publishReservationChange(ctx, tenantID, userID, ledgerID, reservation, "", reservation.Status())
+	return nil
+}
+
+// closeReservation moves an active reservation to status and publishes the state change.
+func (service *Service) closeReservation(ctx context.Context, store Store, tenantID TenantID, userID UserID, ledgerID LedgerID, reservation Reservation, status ReservationStatus) error {
+	if err := store.UpdateReservationStatus(ctx, reservation.AccountID(), reservation.ReservationID(), ReservationStatusActive, status); err != nil {
+		return err
+	}
+	service.publishReservationChange(ctx, tenantID, userID, ledgerID, reservation, ReservationStatusActive, status)
+	return nil
+}
+
+func (service *Service) publishReservationChange(ctx context.Context, tenantID TenantID, userID UserID, ledgerID LedgerID, reservation Reservation, from ReservationStatus, to ReservationStatus) {
+	if len(service.eventSinks) == 0 {
+		return
+	}
+	service.publishEvent(ctx, DomainEvent{
+		Type:            DomainEventReservationStateChanged,
+		TenantID:        tenantID,
+		UserID:          userID,
+		LedgerID:        ledgerID,
+		AccountID:       reservation.AccountID(),
+		OccurredUnixUTC: service.nowFn(),
+		Reservation: &ReservationStateChange{
+			ReservationID:    reservation.ReservationID(),
+			From:             from,
+			To:               to,
+			AmountCents:      reservation.AmountCents(),
+			ExpiresAtUnixUTC: reservation.ExpiresAtUnixUTC(),
+		},
+	})
+}
+
+func (service *Service) publishThresholdCrossing(ctx context.Context, crossing BalanceThresholdCrossing) {
+	if len(service.eventSinks) == 0 {
+		return
+	}
+	service.publishEvent(ctx, DomainEvent{
+		Type:              DomainEventBalanceThresholdCrossed,
+		TenantID:          crossing.TenantID,
+		UserID:            crossing.UserID,
+		LedgerID:          crossing.LedgerID,
+		AccountID:         crossing.AccountID,
+		OccurredUnixUTC:   crossing.CrossedUnixUTC,
+		ThresholdCrossing: &crossing,
+	})
+}
+
+func accountBalance(ctx context.Context, store Store, accountID AccountID, atUnixUTC int64) (Balance, error) {
+	total, holds, err := sumBalance(ctx, store, accountID, atUnixUTC)
+	if err != nil {
+		return Balance{}, err
+	}
+	return Balance{TotalCents: total, AvailableCents: calculateAvailable(total, holds)}, nil
+}
+
+type balanceEventPayload struct {
+	TotalCents     int64 `json:"total_cents"`
+	AvailableCents int64 `json:"available_cents"`
+}
+
+type reservationEventPayload struct {
+	ReservationID    string `json:"reservation_id"`
+	From             string `json:"from,omitempty"`
+	To               string `json:"to"`
+	AmountCents      int64  `json:"amount_cents"`
+	ExpiresAtUnixUTC int64  `json:"expires_at_unix_utc,omitempty"`
+}
+
+type refundEventPayload struct {
+	RefundEntryID   string `json:"refund_entry_id"`
+	OriginalEntryID string `json:"original_entry_id"`
+	AmountCents     int64  `json:"amount_cents"`
+}
+
+type domainEventPayload struct {
+	Type              string                   `json:"type"`
+	TenantID          string                   `json:"tenant_id"`
+	UserID            string                   `json:"user_id"`
+	LedgerID          string                   `json:"ledger_id"`
+	AccountID         string                   `json:"account_id"`
+	OccurredUnixUTC   int64                    `json:"occurred_unix_utc"`
+	Entry             *entryEventPayload       `json:"entry,omitempty"`
+	Balance           *balanceEventPayload     `json:"balance,omitempty"`
+	Reservation       *reservationEventPayload `json:"reservation,omitempty"`
+	Refund            *refundEventPayload      `json:"refund,omitempty"`
+	ThresholdCrossing *thresholdEventPayload   `json:"threshold_crossing,omitempty"`
+}
+
+// MarshalJSON encodes the event with snake_case fields, omitting the parts its type does not carry.
+func (event DomainEvent) MarshalJSON() ([]byte, error) {
+	payload := domainEventPayload{
+		Type:            event.Type.String(),
+		TenantID:        event.TenantID.String(),
+		UserID:          event.UserID.String(),
+		LedgerID:        event.LedgerID.String(),
+		AccountID:       event.AccountID.String(),
+		OccurredUnixUTC: event.OccurredUnixUTC,
+	}
+	if event.Entry != nil {
+		entryPayload := newEntryEventPayload(event.TenantID, event.UserID, event.LedgerID, *event.Entry)
+		payload.Entry = &entryPayload
+	}
+	if event.Balance != nil {
+		payload.Balance = &balanceEventPayload{TotalCents: event.Balance.TotalCents.Int64(), AvailableCents: event.Balance.AvailableCents.Int64()}
+	}
+	if event.Reservation != nil {
+		payload.Reservation = &reservationEventPayload{
+			ReservationID:    event.Reservation.ReservationID.String(),
+			From:             event.Reservation.From.String(),
+			To:               event.Reservation.To.String(),
+			AmountCents:      event.Reservation.AmountCents.Int64(),
+			ExpiresAtUnixUTC: event.Reservation.ExpiresAtUnixUTC,
+		}
+	}
+	if event.Refund != nil {
+		payload.Refund = &refundEventPayload{
+			RefundEntryID:   event.Refund.RefundEntryID.String(),
+			OriginalEntryID: event.Refund.OriginalEntryID.String(),
+			AmountCents:     event.Refund.AmountCents,
+		}
+	}
+	if event.ThresholdCrossing != nil {
+		crossingPayload := newThresholdEventPayload(*event.ThresholdCrossing)
+		payload.ThresholdCrossing = &crossingPayload
+	}
+	return json.Marshal(payload)
+}
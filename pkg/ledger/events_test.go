@@ -0,0 +1,352 @@
+package ledger
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type recordingSink struct {
+	events []DomainEvent
+	err    error
+}
+
+func (sink *recordingSink) HandleEvent(ctx context.Context, event DomainEvent) error {
+	if sink.err != nil {
+		return sink.err
+	}
+	sink.events = append(sink.events, event)
+	return nil
+}
+
+func TestEventSinkReceivesEntryReservationAndRefundEvents(test *testing.T) {
+	test.Parallel()
+	store := newStubStore(test, 0)
+	sink := &recordingSink{}
+	tenantID := mustTenantID(test, defaultTenantIDValue)
+	userID := mustUserID(test, "user-1")
+	ledgerID := mustLedgerID(test, defaultLedgerIDValue)
+	service, err := NewService(store, func() int64 { return 100 }, WithEventSink(sink), WithBalanceThresholds(mustBalanceThreshold(test, tenantID, ledgerID, 0)))
+	if err != nil {
+		test.Fatalf("new service: %v", err)
+	}
+	ctx := context.Background()
+	metadata := mustMetadata(test, "{}")
+	reservationID := mustReservationID(test, "order-1")
+
+	if err := service.Grant(ctx, tenantID, userID, ledgerID, mustPositiveAmount(test, 1000), mustIdempotencyKey(test, "grant-1"), 0, metadata); err != nil {
+		test.Fatalf("grant: %v", err)
+	}
+	if err := service.Reserve(ctx, tenantID, userID, ledgerID, mustPositiveAmount(test, 300), reservationID, mustIdempotencyKey(test, "reserve-1"), 0, metadata); err != nil {
+		test.Fatalf("reserve: %v", err)
+	}
+	if err := service.Capture(ctx, tenantID, userID, ledgerID, reservationID, mustIdempotencyKey(test, "capture-1"), mustPositiveAmount(test, 300), metadata); err != nil {
+		test.Fatalf("capture: %v", err)
+	}
+	spendEntry, err := service.SpendEntry(ctx, tenantID, userID, ledgerID, mustPositiveAmount(test, 200), mustIdempotencyKey(test, "spend-1"), metadata)
+	if err != nil {
+		test.Fatalf("spend: %v", err)
+	}
+	refundEntry, err := service.RefundByEntryIDEntry(ctx, tenantID, userID, ledgerID, spendEntry.EntryID(), mustPositiveAmount(test, 50), mustIdempotencyKey(test, "refund-1"), metadata)
+	if err != nil {
+		test.Fatalf("refund: %v", err)
+	}
+
+	expectedTypes := []DomainEventType{
+		DomainEventEntryCreated,
+		DomainEventBalanceThresholdCrossed,
+		DomainEventReservationStateChanged,
+		DomainEventEntryCreated,
+		DomainEventReservationStateChanged,
+		DomainEventEntryCreated,
+		DomainEventEntryCreated,
+		DomainEventEntryCreated,
+		DomainEventEntryCreated,
+		DomainEventRefundApplied,
+	}
+	if len(sink.events) != len(expectedTypes) {
+		test.Fatalf("expected %d events, got %d: %+v", len(expectedTypes), len(sink.events), sink.events)
+	}
+	for index, expectedType := range expectedTypes {
+		event := sink.events[index]
+		if event.Type != expectedType || event.TenantID != tenantID || event.UserID != userID || event.LedgerID != ledgerID || event.OccurredUnixUTC != 100 {
+			test.Fatalf("event %d: expected %s, got %+v", index, expectedType, event)
+		}
+	}
+	grantEvent := sink.events[0]
+	if grantEvent.Entry == nil || grantEvent.Entry.Type() != EntryGrant || grantEvent.Balance == nil || grantEvent.Balance.TotalCents != 1000 || grantEvent.Balance.AvailableCents != 1000 {
+		test.Fatalf("unexpected grant event: %+v", grantEvent)
+	}
+	reserved := sink.events[2].Reservation
+	if reserved == nil || reserved.ReservationID != reservationID || reserved.From != "" || reserved.To != ReservationStatusActive || reserved.AmountCents.Int64() != 300 {
+		test.Fatalf("unexpected reservation event: %+v", reserved)
+	}
+	if holdEvent := sink.events[3]; holdEvent.Balance == nil || holdEvent.Balance.AvailableCents != 700 {
+		test.Fatalf("expected hold event to carry the reduced available balance, got %+v", holdEvent.Balance)
+	}
+	captured := sink.events[4].Reservation
+	if captured == nil || captured.From != ReservationStatusActive || captured.To != ReservationStatusCaptured {
+		test.Fatalf("unexpected capture event: %+v", captured)
+	}
+	refund := sink.events[9].Refund
+	if refund == nil || refund.RefundEntryID != refundEntry.EntryID() || refund.OriginalEntryID != spendEntry.EntryID() || refund.AmountCents != 50 {
+		test.Fatalf("unexpected refund event: %+v", refund)
+	}
+}
+
+func TestEventSinkErrorIsLoggedAfterCommit(test *testing.T) {
+	test.Parallel()
+	store := newStubStore(test, 0)
+	sinkErr := errors.New("sink unavailable")
+	logger := &recorderLogger{}
+	service, err := NewService(store, func() int64 { return 100 }, WithEventSink(&recordingSink{err: sinkErr}), WithOperationLogger(logger))
+	if err != nil {
+		test.Fatalf("new service: %v", err)
+	}
+	err = service.Grant(context.Background(), mustTenantID(test, defaultTenantIDValue), mustUserID(test, "user-1"), mustLedgerID(test, defaultLedgerIDValue), mustPositiveAmount(test, 1000), mustIdempotencyKey(test, "grant-1"), 0, mustMetadata(test, "{}"))
+	if err != nil {
+		test.Fatalf("expected the committed grant to succeed despite the sink, got %v", err)
+	}
+	if store.total != 1000 {
+		test.Fatalf("expected grant to commit, got total %d", store.total)
+	}
+	var sinkLogs []OperationLog
+	for _, entry := range logger.entries {
+		if entry.Operation == operationEventSink {
+			sinkLogs = append(sinkLogs, entry)
+		}
+	}
+	if len(sinkLogs) != 1 || !errors.Is(sinkLogs[0].Error, sinkErr) || sinkLogs[0].Status != operationStatusError {
+		test.Fatalf("expected one logged sink failure, got %+v", sinkLogs)
+	}
+}
+
+// committedSink records each event with the number of entries the store had committed when it arrived.
+type committedSink struct {
+	store     *stubStore
+	events    []DomainEvent
+	committed []int
+}
+
+func (sink *committedSink) HandleEvent(ctx context.Context, event DomainEvent) error {
+	sink.events = append(sink.events, event)
+	sink.committed = append(sink.committed, len(sink.store.entries))
+	return nil
+}
+
+func TestEventSinkRunsOnlyAfterCommit(test *testing.T) {
+	test.Parallel()
+	ctx := context.Background()
+	tenantID := mustTenantID(test, defaultTenantIDValue)
+	userID := mustUserID(test, "user-1")
+	ledgerID := mustLedgerID(test, defaultLedgerIDValue)
+
+	store := newStubStore(test, 0)
+	sink := &committedSink{store: store}
+	service, err := NewService(store, func() int64 { return 100 }, WithEventSink(sink))
+	if err != nil {
+		test.Fatalf("new service: %v", err)
+	}
+	if err := service.Grant(ctx, tenantID, userID, ledgerID, mustPositiveAmount(test, 1000), mustIdempotencyKey(test, "grant-1"), 0, mustMetadata(test, "{}")); err != nil {
+		test.Fatalf("grant: %v", err)
+	}
+	if len(sink.committed) != 1 || sink.committed[0] != 1 {
+		test.Fatalf("expected the event after the grant committed, got committed counts %v", sink.committed)
+	}
+
+	atomic := []BatchOperation{
+		newBatchGrantOperation(test, "grant-2", 100, "grant-2"),
+		newBatchSpendOperation(test, "spend-1", 5000, "spend-1"),
+	}
+	if _, err := service.Batch(ctx, tenantID, userID, ledgerID, atomic, true); err != nil {
+		test.Fatalf("atomic batch: %v", err)
+	}
+	if len(sink.events) != 1 {
+		test.Fatalf("expected a rolled back batch to publish nothing, got %+v", sink.events[1:])
+	}
+
+	bestEffort := []BatchOperation{
+		newBatchGrantOperation(test, "grant-3", 100, "grant-3"),
+		newBatchReserveOperation(test, "reserve-1", 50, "res-1", "grant-3"),
+		newBatchGrantOperation(test, "grant-4", 100, "grant-4"),
+	}
+	results, err := service.Batch(ctx, tenantID, userID, ledgerID, bestEffort, false)
+	if err != nil {
+		test.Fatalf("best-effort batch: %v", err)
+	}
+	if results[1].Entry != nil {
+		test.Fatalf("expected the reserve to fail on its reused key, got %+v", results[1])
+	}
+	published := sink.events[1:]
+	if len(published) != 2 {
+		test.Fatalf("expected events for the two committed grants only, got %+v", published)
+	}
+	for index, event := range published {
+		if event.Type != DomainEventEntryCreated || event.Entry == nil || event.Entry.Type() != EntryGrant {
+			test.Fatalf("event %d: expected a grant entry_created, got %+v", index, event)
+		}
+	}
+}
+
+func TestJSONLFileSinkAppendsOneLinePerEvent(test *testing.T) {
+	test.Parallel()
+	path := filepath.Join(test.TempDir(), "events.jsonl")
+	sink, err := NewJSONLFileSink(path)
+	if err != nil {
+		test.Fatalf("new sink: %v", err)
+	}
+	service, err := NewService(newStubStore(test, 0), func() int64 { return 100 }, WithEventSink(sink))
+	if err != nil {
+		test.Fatalf("new service: %v", err)
+	}
+	ctx := context.Background()
+	tenantID := mustTenantID(test, defaultTenantIDValue)
+	userID := mustUserID(test, "user-1")
+	ledgerID := mustLedgerID(test, defaultLedgerIDValue)
+	metadata := mustMetadata(test, "{}")
+	if err := service.Grant(ctx, tenantID, userID, ledgerID, mustPositiveAmount(test, 1000), mustIdempotencyKey(test, "grant-1"), 0, metadata); err != nil {
+		test.Fatalf("grant: %v", err)
+	}
+	if err := service.Reserve(ctx, tenantID, userID, ledgerID, mustPositiveAmount(test, 300), mustReservationID(test, "order-1"), mustIdempotencyKey(test, "reserve-1"), 0, metadata); err != nil {
+		test.Fatalf("reserve: %v", err)
+	}
+	if err := sink.Close(); err != nil {
+		test.Fatalf("close sink: %v", err)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		test.Fatalf("open events: %v", err)
+	}
+	defer file.Close()
+	var lines []map[string]any
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var line map[string]any
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			test.Fatalf("decode line %q: %v", scanner.Text(), err)
+		}
+		lines = append(lines, line)
+	}
+	if len(lines) != 3 {
+		test.Fatalf("expected three lines, got %d", len(lines))
+	}
+	balance, _ := lines[0]["balance"].(map[string]any)
+	entry, _ := lines[0]["entry"].(map[string]any)
+	if lines[0]["type"] != "entry_created" || balance["available_cents"] != float64(1000) || entry["entry_id"] == nil {
+		test.Fatalf("unexpected entry line: %v", lines[0])
+	}
+	reservation, _ := lines[1]["reservation"].(map[string]any)
+	if lines[1]["type"] != "reservation_state_changed" || reservation["to"] != "active" || lines[1]["entry"] != nil {
+		test.Fatalf("unexpected reservation line: %v", lines[1])
+	}
+
+	if _, err := NewJSONLFileSink(""); !errors.Is(err, ErrInvalidServiceConfig) {
+		test.Fatalf("expected invalid config for empty path, got %v", err)
+	}
+}
+
+func TestJSONLFileSinkReportsFileErrors(test *testing.T) {
+	test.Parallel()
+	directory := test.TempDir()
+	if _, err := NewJSONLFileSink(filepath.Join(directory, "missing", "events.jsonl")); err == nil {
+		test.Fatalf("expected an open error for a missing directory")
+	}
+	sink, err := NewJSONLFileSink(filepath.Join(directory, "events.jsonl"))
+	if err != nil {
+		test.Fatalf("new sink: %v", err)
+	}
+	if err := sink.Close(); err != nil {
+		test.Fatalf("close sink: %v", err)
+	}
+	event := DomainEvent{Type: DomainEventEntryCreated, TenantID: mustTenantID(test, defaultTenantIDValue)}
+	if err := sink.HandleEvent(context.Background(), event); err == nil {
+		test.Fatalf("expected a write error after close")
+	}
+}
+
+func TestDomainEventJSONIncludesRefundAndThresholdCrossing(test *testing.T) {
+	test.Parallel()
+	tenantID := mustTenantID(test, defaultTenantIDValue)
+	event := DomainEvent{
+		Type:     DomainEventRefundApplied,
+		TenantID: tenantID,
+		Refund:   &RefundApplied{RefundEntryID: mustEntryID(test, "refund-1"), OriginalEntryID: mustEntryID(test, "entry-1"), AmountCents: 250},
+		ThresholdCrossing: &BalanceThresholdCrossing{
+			TenantID:       tenantID,
+			ThresholdCents: 500,
+			Direction:      ThresholdCrossedUp,
+			AvailableCents: 750,
+		},
+	}
+	encoded, err := json.Marshal(event)
+	if err != nil {
+		test.Fatalf("marshal event: %v", err)
+	}
+	var decoded map[string]any
+	if err := json.Unmarshal(encoded, &decoded); err != nil {
+		test.Fatalf("decode event: %v", err)
+	}
+	refund, _ := decoded["refund"].(map[string]any)
+	if refund["refund_entry_id"] != "refund-1" || refund["original_entry_id"] != "entry-1" || refund["amount_cents"] != float64(250) {
+		test.Fatalf("unexpected refund payload: %s", encoded)
+	}
+	crossing, _ := decoded["threshold_crossing"].(map[string]any)
+	if crossing["threshold_cents"] != float64(500) || crossing["available_cents"] != float64(750) {
+		test.Fatalf("unexpected threshold payload: %s", encoded)
+	}
+}
+
+func TestEventSinkReturnsBalanceFailures(test *testing.T) {
+	test.Parallel()
+	store := newStubStore(test, 0)
+	sumTotalError := errors.New("sum total failed")
+	store.sumTotalError = sumTotalError
+	sink := &recordingSink{}
+	service, err := NewService(store, func() int64 { return 100 }, WithEventSink(sink))
+	if err != nil {
+		test.Fatalf("new service: %v", err)
+	}
+	if err := service.Grant(context.Background(), mustTenantID(test, defaultTenantIDValue), mustUserID(test, "user-1"), mustLedgerID(test, defaultLedgerIDValue), mustPositiveAmount(test, 100), mustIdempotencyKey(test, "grant-1"), 0, mustMetadata(test, "{}")); !errors.Is(err, sumTotalError) {
+		test.Fatalf("expected %v, got %v", sumTotalError, err)
+	}
+	if len(sink.events) != 0 {
+		test.Fatalf("expected no events, got %d", len(sink.events))
+	}
+}
+
+func TestEventBufferOutsideTransactionIsNoop(test *testing.T) {
+	test.Parallel()
+	var buffer *eventBuffer
+	if position := buffer.mark(); position != 0 {
+		test.Fatalf("expected position 0, got %d", position)
+	}
+	buffer.discardFrom(0)
+}
+
+func TestChannelSinkDeliversAndHonoursContext(test *testing.T) {
+	test.Parallel()
+	sink, err := NewChannelSink(1)
+	if err != nil {
+		test.Fatalf("new sink: %v", err)
+	}
+	event := DomainEvent{Type: DomainEventEntryCreated}
+	if err := sink.HandleEvent(context.Background(), event); err != nil {
+		test.Fatalf("handle event: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := sink.HandleEvent(ctx, event); !errors.Is(err, context.Canceled) {
+		test.Fatalf("expected cancellation while channel is full, got %v", err)
+	}
+	if received := <-sink.Events(); received.Type != DomainEventEntryCreated {
+		test.Fatalf("unexpected event %+v", received)
+	}
+	if _, err := NewChannelSink(-1); !errors.Is(err, ErrInvalidServiceConfig) {
+		test.Fatalf("expected invalid config for negative buffer, got %v", err)
+	}
+}
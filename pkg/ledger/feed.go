@@ -0,0 +1,41 @@
+package ledger
+
+import (
+	"context"
+	"fmt"
+)
+
+// EntryChange is one committed entry in a tenant's change feed.
+// Cursor is assigned per tenant in commit order, so resuming after the last delivered cursor
+// neither skips nor repeats entries.
+type EntryChange struct {
+	Cursor   int64
+	UserID   UserID
+	LedgerID LedgerID
+	Entry    Entry
+}
+
+// EntryChangeFilter narrows a tenant's change feed. Nil or empty fields match every entry.
+type EntryChangeFilter struct {
+	LedgerID *LedgerID
+	UserID   *UserID
+	Types    []EntryType
+}
+
+// ListEntryChanges returns up to limit entries of a tenant committed after afterCursor, oldest first.
+// An afterCursor of 0 starts at the beginning of the feed.
+func (service *Service) ListEntryChanges(ctx context.Context, tenantID TenantID, afterCursor int64, limit int, filter EntryChangeFilter) ([]EntryChange, error) {
+	if afterCursor < 0 {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidCursor, errorAmountZeroOrGreater)
+	}
+	if filter.LedgerID != nil {
+		if err := service.checkLedgerAllowed(tenantID, *filter.LedgerID); err != nil {
+			return nil, err
+		}
+	}
+	feedStore, ok := service.store.(ChangeFeedStore)
+	if !ok {
+		return nil, ErrChangeFeedUnsupported
+	}
+	return feedStore.ListEntryChanges(ctx, tenantID, afterCursor, limit, filter)
+}
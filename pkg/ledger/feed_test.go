@@ -0,0 +1,68 @@
+package ledger
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestInsertedEntriesJoinTheChangeFeed(test *testing.T) {
+	test.Parallel()
+	store := newStubStore(test, 0)
+	service := mustNewService(test, store)
+	ctx := context.Background()
+	tenantID := mustTenantID(test, defaultTenantIDValue)
+	userID := mustUserID(test, "user-1")
+	ledgerID := mustLedgerID(test, defaultLedgerIDValue)
+
+	if err := service.Grant(ctx, tenantID, userID, ledgerID, mustPositiveAmount(test, 500), mustIdempotencyKey(test, "grant-1"), 0, mustMetadata(test, "{}")); err != nil {
+		test.Fatalf("grant: %v", err)
+	}
+	if err := service.Spend(ctx, tenantID, userID, ledgerID, mustPositiveAmount(test, 200), mustIdempotencyKey(test, "spend-1"), mustMetadata(test, "{}")); err != nil {
+		test.Fatalf("spend: %v", err)
+	}
+	if len(store.changedEntryIDs) != 2 {
+		test.Fatalf("expected both entries in the change feed, got %v", store.changedEntryIDs)
+	}
+}
+
+func TestListEntryChangesValidatesCursorAndLedger(test *testing.T) {
+	test.Parallel()
+	store := newStubStore(test, 0)
+	tenantID := mustTenantID(test, defaultTenantIDValue)
+	ledgerID := mustLedgerID(test, defaultLedgerIDValue)
+	userID := mustUserID(test, "user-1")
+	store.entryChanges = []EntryChange{
+		{Cursor: 1, UserID: userID, LedgerID: ledgerID},
+		{Cursor: 2, UserID: userID, LedgerID: ledgerID},
+		{Cursor: 3, UserID: userID, LedgerID: ledgerID},
+	}
+	service := mustNewService(test, store)
+	ctx := context.Background()
+
+	changes, err := service.ListEntryChanges(ctx, tenantID, 1, 1, EntryChangeFilter{UserID: &userID, Types: []EntryType{EntryGrant}})
+	if err != nil {
+		test.Fatalf("list entry changes: %v", err)
+	}
+	if len(changes) != 1 || changes[0].Cursor != 2 {
+		test.Fatalf("expected the change after cursor 1, got %+v", changes)
+	}
+	if store.entryChangeFilter.UserID == nil || len(store.entryChangeFilter.Types) != 1 {
+		test.Fatalf("expected filter to reach the store, got %+v", store.entryChangeFilter)
+	}
+	if _, err := service.ListEntryChanges(ctx, tenantID, -1, 10, EntryChangeFilter{}); !errors.Is(err, ErrInvalidCursor) {
+		test.Fatalf("expected invalid cursor, got %v", err)
+	}
+
+	policy, err := NewTenantPolicy(tenantID, true)
+	if err != nil {
+		test.Fatalf("tenant policy: %v", err)
+	}
+	strictService, err := NewService(store, func() int64 { return 100 }, WithTenantPolicies(policy))
+	if err != nil {
+		test.Fatalf("new service: %v", err)
+	}
+	if _, err := strictService.ListEntryChanges(ctx, tenantID, 0, 10, EntryChangeFilter{LedgerID: &ledgerID}); !errors.Is(err, ErrUnknownLedger) {
+		test.Fatalf("expected unknown ledger, got %v", err)
+	}
+}
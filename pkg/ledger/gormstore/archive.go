@@ -0,0 +1,115 @@
+package gormstore
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/MarkoPoloResearchLab/ledger/pkg/ledger"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	errorSubjectArchive    = "archive"
+	errorCodeDelete        = "delete"
+	errorCodeTombstone     = "tombstone"
+	archiveDeleteBatchSize = 500
+)
+
+const sqlInsertArchivedEntryKeys = "INSERT INTO archived_entry_keys (account_id, idempotency_key, entry_id)" +
+	" SELECT account_id, idempotency_key, entry_id FROM ledger_entries WHERE account_id = ? AND entry_id IN ?"
+
+// GetEntryArchive reads the account's archive record. Accounts never compacted have the zero record.
+func (store *Store) GetEntryArchive(ctx context.Context, accountID ledger.AccountID) (ledger.EntryArchive, error) {
+	var row EntryArchive
+	err := store.db.WithContext(ctx).Where("account_id = ?", accountID.String()).Take(&row).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ledger.EntryArchive{}, nil
+	}
+	if err != nil {
+		return ledger.EntryArchive{}, wrapStoreError(errorSubjectArchive, errorCodeGet, err)
+	}
+	return ledger.EntryArchive{
+		AccountID:       accountID,
+		CutoffUnixUTC:   row.CutoffAt.UTC().Unix(),
+		ThroughSequence: row.ThroughSequence,
+		ThroughHash:     row.ThroughHash,
+		ArchivedEntries: row.ArchivedEntries,
+		Location:        row.Location,
+		ArchivedUnixUTC: row.ArchivedAt.UTC().Unix(),
+	}, nil
+}
+
+// ArchiveEntries deletes the account's listed entries and reservations in batches and replaces its archive
+// record. The idempotency keys and IDs of the deleted entries move to archived_entry_keys. Journal lines and change-feed positions of deleted entries stay behind; the feed skips them.
+func (store *Store) ArchiveEntries(ctx context.Context, archive ledger.EntryArchive, entryIDs []ledger.EntryID, reservationIDs []ledger.ReservationID) error {
+	accountID := archive.AccountID.String()
+	return store.db.WithContext(ctx).Transaction(func(transaction *gorm.DB) error {
+		for start := 0; start < len(entryIDs); start += archiveDeleteBatchSize {
+			batch := entryIDs[start:min(start+archiveDeleteBatchSize, len(entryIDs))]
+			values := make([]string, 0, len(batch))
+			for _, entryID := range batch {
+				values = append(values, entryID.String())
+			}
+			if err := transaction.Exec(sqlInsertArchivedEntryKeys, accountID, values).Error; err != nil {
+				return wrapStoreError(errorSubjectArchive, errorCodeTombstone, err)
+			}
+			result := transaction.Where("account_id = ? AND entry_id IN ?", accountID, values).Delete(&LedgerEntry{})
+			if result.Error != nil {
+				return wrapStoreError(errorSubjectArchive, errorCodeDelete, result.Error)
+			}
+			if result.RowsAffected != int64(len(batch)) {
+				return wrapStoreError(errorSubjectArchive, errorCodeDelete, ledger.ErrUnknownEntry)
+			}
+		}
+		for start := 0; start < len(reservationIDs); start += archiveDeleteBatchSize {
+			batch := reservationIDs[start:min(start+archiveDeleteBatchSize, len(reservationIDs))]
+			values := make([]string, 0, len(batch))
+			for _, reservationID := range batch {
+				values = append(values, reservationID.String())
+			}
+			result := transaction.Where("account_id = ? AND reservation_id IN ?", accountID, values).Delete(&Reservation{})
+			if result.Error != nil {
+				return wrapStoreError(errorSubjectArchive, errorCodeDelete, result.Error)
+			}
+			if result.RowsAffected != int64(len(batch)) {
+				return wrapStoreError(errorSubjectArchive, errorCodeDelete, ledger.ErrUnknownReservation)
+			}
+		}
+		row := EntryArchive{
+			AccountID:       accountID,
+			CutoffAt:        time.Unix(archive.CutoffUnixUTC, 0).UTC(),
+			ThroughSequence: archive.ThroughSequence,
+			ThroughHash:     archive.ThroughHash,
+			ArchivedEntries: archive.ArchivedEntries,
+			Location:        archive.Location,
+			ArchivedAt:      time.Unix(archive.ArchivedUnixUTC, 0).UTC(),
+		}
+		err := transaction.Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "account_id"}}, UpdateAll: true}).Create(&row).Error
+		if err != nil {
+			return wrapStoreError(errorSubjectArchive, errorCodeUpdate, err)
+		}
+		return nil
+	})
+}
+
+// archivedEntryExists reports whether compaction archived an entry matching condition.
+func (store *Store) archivedEntryExists(ctx context.Context, condition string, args ...any) (bool, error) {
+	var count int64
+	err := store.db.WithContext(ctx).Model(&ArchivedEntryKey{}).Where(condition, args...).Count(&count).Error
+	return count > 0, err
+}
+
+// missingEntryError explains an entry lookup that found no row: ErrArchivedEntry when compaction archived a
+// matching entry, ErrUnknownEntry otherwise.
+func (store *Store) missingEntryError(ctx context.Context, condition string, args ...any) error {
+	archived, err := store.archivedEntryExists(ctx, condition, args...)
+	if err != nil {
+		return wrapStoreError(errorSubjectEntry, errorCodeGet, err)
+	}
+	if archived {
+		return wrapStoreError(errorSubjectEntry, errorCodeGet, ledger.ErrArchivedEntry)
+	}
+	return wrapStoreError(errorSubjectEntry, errorCodeGet, ledger.ErrUnknownEntry)
+}
@@ -0,0 +1,104 @@
+package gormstore
+
+import (
+	"context"
+	"errors"
+
+	"github.com/MarkoPoloResearchLab/ledger/pkg/ledger"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	errorSubjectChain = "chain"
+	errorCodeLock     = "lock"
+)
+
+// LockChainHead returns the account's chain head, creating an empty one on first use. The no-op upsert locks
+// the head row until the surrounding transaction commits, so writers to one account extend the chain in turn.
+func (store *Store) LockChainHead(ctx context.Context, accountID ledger.AccountID) (ledger.ChainHead, error) {
+	head := AccountChainHead{AccountID: accountID.String()}
+	err := store.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "account_id"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{"last_sequence": gorm.Expr("account_chain_heads.last_sequence")}),
+		}).
+		Create(&head).Error
+	if err != nil {
+		return ledger.ChainHead{}, wrapStoreError(errorSubjectChain, errorCodeLock, err)
+	}
+	if err := store.db.WithContext(ctx).Where("account_id = ?", accountID.String()).Take(&head).Error; err != nil {
+		return ledger.ChainHead{}, wrapStoreError(errorSubjectChain, errorCodeLock, err)
+	}
+	return ledger.ChainHead{Sequence: head.LastSequence, Hash: head.LastHash}, nil
+}
+
+// AppendChainLink stores the link on its entry and moves the account's chain head to it.
+func (store *Store) AppendChainLink(ctx context.Context, accountID ledger.AccountID, link ledger.ChainLink) error {
+	return store.db.WithContext(ctx).Transaction(func(transaction *gorm.DB) error {
+		sequence := link.Sequence
+		result := transaction.Model(&LedgerEntry{}).
+			Where("account_id = ? AND entry_id = ?", accountID.String(), link.EntryID.String()).
+			Updates(map[string]interface{}{
+				"chain_sequence": sequence,
+				"previous_hash":  link.PreviousHash,
+				"entry_hash":     link.Hash,
+			})
+		if result.Error != nil {
+			return wrapStoreError(errorSubjectChain, errorCodeUpdate, result.Error)
+		}
+		if result.RowsAffected != 1 {
+			return wrapStoreError(errorSubjectChain, errorCodeUpdate, ledger.ErrUnknownEntry)
+		}
+		err := transaction.Model(&AccountChainHead{}).
+			Where("account_id = ?", accountID.String()).
+			Updates(map[string]interface{}{"last_sequence": sequence, "last_hash": link.Hash}).Error
+		if err != nil {
+			return wrapStoreError(errorSubjectChain, errorCodeUpdate, err)
+		}
+		return nil
+	})
+}
+
+// GetChainHead reads the account's chain head without locking it. Accounts without chained entries have the zero head.
+func (store *Store) GetChainHead(ctx context.Context, accountID ledger.AccountID) (ledger.ChainHead, error) {
+	var head AccountChainHead
+	err := store.db.WithContext(ctx).Where("account_id = ?", accountID.String()).Take(&head).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ledger.ChainHead{}, nil
+	}
+	if err != nil {
+		return ledger.ChainHead{}, wrapStoreError(errorSubjectChain, errorCodeGet, err)
+	}
+	return ledger.ChainHead{Sequence: head.LastSequence, Hash: head.LastHash}, nil
+}
+
+// ListChainedEntries returns the account's chained entries after afterSequence in chain order.
+func (store *Store) ListChainedEntries(ctx context.Context, accountID ledger.AccountID, afterSequence int64, limit int) ([]ledger.ChainedEntry, error) {
+	var rows []LedgerEntry
+	err := store.db.WithContext(ctx).
+		Where("account_id = ? AND chain_sequence > ?", accountID.String(), afterSequence).
+		Order("chain_sequence ASC").
+		Limit(limit).
+		Find(&rows).Error
+	if err != nil {
+		return nil, wrapStoreError(errorSubjectChain, errorCodeList, err)
+	}
+	chained := make([]ledger.ChainedEntry, 0, len(rows))
+	for _, row := range rows {
+		entry, err := mapLedgerEntry(row)
+		if err != nil {
+			return nil, wrapStoreError(errorSubjectChain, errorCodeInvalid, err)
+		}
+		chained = append(chained, ledger.ChainedEntry{
+			Entry: entry,
+			Link: ledger.ChainLink{
+				EntryID:      entry.EntryID(),
+				Sequence:     *row.ChainSequence,
+				PreviousHash: row.PreviousHash,
+				Hash:         row.EntryHash,
+			},
+		})
+	}
+	return chained, nil
+}
@@ -0,0 +1,87 @@
+package gormstore
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/MarkoPoloResearchLab/ledger/pkg/ledger"
+	"github.com/MarkoPoloResearchLab/ledger/pkg/ledger/storetest"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// postgresURLEnv names the database the Postgres conformance run uses; the run is skipped when it is unset.
+const postgresURLEnv = "LEDGER_TEST_POSTGRES_URL"
+
+// Store implements every optional capability, so RunConformance skips none of its subtests.
+var _ interface {
+	ledger.Store
+	ledger.AccountStore
+	ledger.VelocityStore
+	ledger.JournalStore
+	ledger.GrantConsumptionStore
+	ledger.LiabilityStore
+	ledger.ChangeFeedStore
+	ledger.OutboxStore
+	ledger.ArchiveStore
+} = (*Store)(nil)
+
+func TestConformanceSQLite(test *testing.T) {
+	test.Parallel()
+	storetest.RunConformance(test, func(test *testing.T) ledger.Store {
+		return New(newSQLiteDB(test))
+	})
+}
+
+func TestConformancePostgres(test *testing.T) {
+	test.Parallel()
+	databaseURL := os.Getenv(postgresURLEnv)
+	if databaseURL == "" {
+		test.Skipf("%s is not set", postgresURLEnv)
+	}
+	storetest.RunConformance(test, func(test *testing.T) ledger.Store {
+		return New(newPostgresSchemaDB(test, databaseURL))
+	})
+}
+
+// newPostgresSchemaDB migrates a throwaway schema so parallel subtests never see each other's rows.
+func newPostgresSchemaDB(test *testing.T, databaseURL string) *gorm.DB {
+	test.Helper()
+	schema := "conformance_" + strings.ReplaceAll(uuid.NewString(), "-", "")
+	admin := openPostgres(test, databaseURL, "")
+	if err := admin.Exec(fmt.Sprintf("CREATE SCHEMA %s", schema)).Error; err != nil {
+		test.Fatalf("create schema: %v", err)
+	}
+	test.Cleanup(func() {
+		_ = admin.Exec(fmt.Sprintf("DROP SCHEMA %s CASCADE", schema)).Error
+	})
+	db := openPostgres(test, databaseURL, schema)
+	if _, err := MigrateUp(context.Background(), db); err != nil {
+		test.Fatalf("migrate up: %v", err)
+	}
+	return db
+}
+
+func openPostgres(test *testing.T, databaseURL string, schema string) *gorm.DB {
+	test.Helper()
+	config, err := pgx.ParseConfig(databaseURL)
+	if err != nil {
+		test.Fatalf("parse %s: %v", postgresURLEnv, err)
+	}
+	if schema != "" {
+		config.RuntimeParams["search_path"] = schema
+	}
+	sqlDB := stdlib.OpenDB(*config)
+	test.Cleanup(func() { _ = sqlDB.Close() })
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{})
+	if err != nil {
+		test.Fatalf("open postgres: %v", err)
+	}
+	return db
+}
@@ -0,0 +1,950 @@
+package gormstore
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/MarkoPoloResearchLab/ledger/pkg/ledger"
+	gosqlite "github.com/glebarez/go-sqlite"
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	constraintAccountIdempotencyKey = "ledger_entries_account_id_idempotency_key_key"
+	constraintLedgerEntriesPrimary  = "ledger_entries_pkey"
+	constraintReservationPrimary    = "reservations_pkey"
+	defaultMetadataJSON             = "{}"
+	outboxStatusPending             = "pending"
+	pgUniqueViolationCode           = "23505"
+	sqliteConstraintCode            = 19
+	errorOperationStore             = "store"
+	errorSubjectAccount             = "account"
+	errorSubjectBalance             = "balance"
+	errorSubjectEntry               = "entry"
+	errorSubjectEntryFeed           = "entry_feed"
+	errorSubjectJournal             = "journal"
+	errorSubjectOutbox              = "outbox"
+	errorSubjectReservation         = "reservation"
+	errorCodeConsumeGrant           = "consume_grant"
+	errorCodeCreate                 = "create"
+	errorCodeDuplicate              = "duplicate"
+	errorCodeGet                    = "get"
+	errorCodeInsert                 = "insert"
+	errorCodeInvalid                = "invalid"
+	errorCodeList                   = "list"
+	errorCodeLookup                 = "lookup"
+	errorCodeSequence               = "sequence"
+	errorCodeSumActiveHolds         = "sum_active_holds"
+	errorCodeSumDebitVelocity       = "sum_debit_velocity"
+	errorCodeSumEntriesByExpiry     = "sum_entries_by_expiry"
+	errorCodeSumJournalLines        = "sum_journal_lines"
+	errorCodeSumRefunds             = "sum_refunds"
+	errorCodeSumTotal               = "sum_total"
+	errorCodeUpdate                 = "update"
+	errorCodeUpdateStatus           = "update_status"
+)
+
+// entryBalanceExpression is what an entry adds to its account's total at the bound time: an expired entry keeps
+// only the part debits consumed. Callers filter out holds and pending entries.
+const entryBalanceExpression = "case when ledger_entries.expires_at is not null and ledger_entries.expires_at <= ? then ledger_entries.consumed_cents else ledger_entries.amount_cents end"
+
+// Store implements ledger.Store and ledger.ArchiveStore using GORM.
+type Store struct {
+	db *gorm.DB
+}
+
+// New returns a Store backed by gorm.DB.
+func New(db *gorm.DB) *Store {
+	return &Store{db: db}
+}
+
+// WithTx executes fn within a transaction.
+func (store *Store) WithTx(ctx context.Context, fn func(ctx context.Context, txStore ledger.Store) error) error {
+	return store.db.WithContext(ctx).Transaction(func(transaction *gorm.DB) error {
+		return fn(ctx, &Store{db: transaction})
+	})
+}
+
+func (store *Store) GetOrCreateAccountID(ctx context.Context, tenantID ledger.TenantID, userID ledger.UserID, ledgerID ledger.LedgerID) (ledger.AccountID, error) {
+	var account Account
+	err := store.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "tenant_id"}, {Name: "user_id"}, {Name: "ledger_id"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{
+				"tenant_id": clause.Expr{SQL: "excluded.tenant_id"},
+				"user_id":   clause.Expr{SQL: "excluded.user_id"},
+				"ledger_id": clause.Expr{SQL: "excluded.ledger_id"},
+			}),
+		}).
+		FirstOrCreate(&account, Account{TenantID: tenantID.String(), UserID: userID.String(), LedgerID: ledgerID.String()}).Error
+	if err != nil {
+		return ledger.AccountID{}, wrapStoreError(errorSubjectAccount, errorCodeLookup, err)
+	}
+	accountID, err := ledger.NewAccountID(account.AccountID)
+	if err != nil {
+		return ledger.AccountID{}, wrapStoreError(errorSubjectAccount, errorCodeInvalid, err)
+	}
+	return accountID, nil
+}
+
+func (store *Store) GetAccountID(ctx context.Context, tenantID ledger.TenantID, userID ledger.UserID, ledgerID ledger.LedgerID) (ledger.AccountID, error) {
+	var account Account
+	err := store.db.WithContext(ctx).
+		Where("tenant_id = ? AND user_id = ? AND ledger_id = ?", tenantID.String(), userID.String(), ledgerID.String()).
+		Take(&account).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ledger.AccountID{}, wrapStoreError(errorSubjectAccount, errorCodeGet, ledger.ErrUnknownAccount)
+		}
+		return ledger.AccountID{}, wrapStoreError(errorSubjectAccount, errorCodeGet, err)
+	}
+	accountID, err := ledger.NewAccountID(account.AccountID)
+	if err != nil {
+		return ledger.AccountID{}, wrapStoreError(errorSubjectAccount, errorCodeInvalid, err)
+	}
+	return accountID, nil
+}
+
+func (store *Store) ListAccounts(ctx context.Context, tenantID ledger.TenantID, beforeCreatedUnixUTC int64, limit int, filter ledger.ListAccountsFilter) ([]ledger.Account, error) {
+	before := time.Unix(beforeCreatedUnixUTC, 0).UTC()
+	if beforeCreatedUnixUTC == 0 {
+		before = time.Now().UTC().Add(time.Second)
+	}
+
+	var rows []Account
+	query := store.db.WithContext(ctx).
+		Model(&Account{}).
+		Where("tenant_id = ? AND created_at < ?", tenantID.String(), before).
+		Order("created_at DESC").
+		Limit(limit)
+	if filter.LedgerID != nil {
+		query = query.Where("ledger_id = ?", filter.LedgerID.String())
+	}
+	if filter.UserIDPrefix != "" {
+		query = query.Where(`user_id like ? escape '\'`, likePrefixPattern(filter.UserIDPrefix))
+	}
+	if filter.CreatedAfterUnixUTC != 0 {
+		query = query.Where("created_at >= ?", time.Unix(filter.CreatedAfterUnixUTC, 0).UTC())
+	}
+	if filter.NonZeroBalance {
+		at := time.Unix(filter.BalanceAtUnixUTC, 0).UTC()
+		query = query.Where(
+			"(select coalesce(sum("+entryBalanceExpression+"),0) from ledger_entries"+
+				" where ledger_entries.account_id = accounts.account_id"+
+				" and ledger_entries.type not in ('hold','reverse_hold')"+
+				" and (ledger_entries.effective_at is null or ledger_entries.effective_at <= ?)) <> 0",
+			at, at,
+		)
+	}
+
+	if err := query.Find(&rows).Error; err != nil {
+		return nil, wrapStoreError(errorSubjectAccount, errorCodeList, err)
+	}
+
+	accounts := make([]ledger.Account, 0, len(rows))
+	for _, row := range rows {
+		account, err := mapAccount(row)
+		if err != nil {
+			return nil, wrapStoreError(errorSubjectAccount, errorCodeInvalid, err)
+		}
+		accounts = append(accounts, account)
+	}
+	return accounts, nil
+}
+
+func (store *Store) InsertEntry(ctx context.Context, entryInput ledger.EntryInput) (ledger.Entry, error) {
+	var expiresAt *time.Time
+	if entryInput.ExpiresAtUnixUTC() != 0 {
+		value := time.Unix(entryInput.ExpiresAtUnixUTC(), 0).UTC()
+		expiresAt = &value
+	}
+	var effectiveAt *time.Time
+	if entryInput.EffectiveAtUnixUTC() != 0 {
+		value := time.Unix(entryInput.EffectiveAtUnixUTC(), 0).UTC()
+		effectiveAt = &value
+	}
+	var reservationID *string
+	reservationValue, hasReservation := entryInput.ReservationID()
+	if hasReservation {
+		value := reservationValue.String()
+		reservationID = &value
+	}
+	var refundOfEntryID *string
+	refundOfValue, hasRefundOf := entryInput.RefundOfEntryID()
+	if hasRefundOf {
+		value := refundOfValue.String()
+		refundOfEntryID = &value
+	}
+	createdUnixUTC := entryInput.CreatedUnixUTC()
+	createdAt := time.Now().UTC().Truncate(time.Second)
+	if createdUnixUTC != 0 {
+		createdAt = time.Unix(createdUnixUTC, 0).UTC()
+	}
+	entry := LedgerEntry{
+		AccountID:       entryInput.AccountID().String(),
+		Type:            entryInput.Type().String(),
+		AmountCents:     entryInput.AmountCents().Int64(),
+		ReservationID:   reservationID,
+		RefundOfEntryID: refundOfEntryID,
+		IdempotencyKey:  entryInput.IdempotencyKey().String(),
+		ExpiresAt:       expiresAt,
+		EffectiveAt:     effectiveAt,
+		Metadata:        datatypesJSON(entryInput.MetadataJSON().String()),
+		CreatedAt:       createdAt,
+
+		ActorAPIKeyID:      entryInput.Actor().APIKeyID(),
+		ActorService:       entryInput.Actor().Service(),
+		ActorRequestID:     entryInput.Actor().RequestID(),
+		ActorClientAddress: entryInput.Actor().ClientAddress(),
+	}
+	archived, err := store.archivedEntryExists(ctx, "account_id = ? AND idempotency_key = ?", entry.AccountID, entry.IdempotencyKey)
+	if err != nil {
+		return ledger.Entry{}, wrapStoreError(errorSubjectEntry, errorCodeInsert, err)
+	}
+	if archived {
+		return ledger.Entry{}, wrapStoreError(errorSubjectEntry, errorCodeDuplicate, ledger.ErrDuplicateIdempotencyKey)
+	}
+	err = store.db.WithContext(ctx).Create(&entry).Error
+	if isIdempotencyConflict(err) {
+		return ledger.Entry{}, wrapStoreError(errorSubjectEntry, errorCodeDuplicate, ledger.ErrDuplicateIdempotencyKey)
+	}
+	if err != nil {
+		return ledger.Entry{}, wrapStoreError(errorSubjectEntry, errorCodeInsert, err)
+	}
+	persistedEntry, err := mapLedgerEntry(entry)
+	if err != nil {
+		return ledger.Entry{}, wrapStoreError(errorSubjectEntry, errorCodeInvalid, err)
+	}
+	return persistedEntry, nil
+}
+
+func (store *Store) GetEntry(ctx context.Context, accountID ledger.AccountID, entryID ledger.EntryID) (ledger.Entry, error) {
+	var model LedgerEntry
+	err := store.db.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("account_id = ? AND entry_id = ?", accountID.String(), entryID.String()).
+		Take(&model).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ledger.Entry{}, store.missingEntryError(ctx, "account_id = ? AND entry_id = ?", accountID.String(), entryID.String())
+		}
+		return ledger.Entry{}, wrapStoreError(errorSubjectEntry, errorCodeGet, err)
+	}
+	entry, err := mapLedgerEntry(model)
+	if err != nil {
+		return ledger.Entry{}, wrapStoreError(errorSubjectEntry, errorCodeInvalid, err)
+	}
+	return entry, nil
+}
+
+func (store *Store) GetEntryByIdempotencyKey(ctx context.Context, accountID ledger.AccountID, idempotencyKey ledger.IdempotencyKey) (ledger.Entry, error) {
+	var model LedgerEntry
+	err := store.db.WithContext(ctx).
+		Where("account_id = ? AND idempotency_key = ?", accountID.String(), idempotencyKey.String()).
+		Take(&model).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ledger.Entry{}, store.missingEntryError(ctx, "account_id = ? AND idempotency_key = ?", accountID.String(), idempotencyKey.String())
+		}
+		return ledger.Entry{}, wrapStoreError(errorSubjectEntry, errorCodeGet, err)
+	}
+	entry, err := mapLedgerEntry(model)
+	if err != nil {
+		return ledger.Entry{}, wrapStoreError(errorSubjectEntry, errorCodeInvalid, err)
+	}
+	return entry, nil
+}
+
+func (store *Store) SumRefunds(ctx context.Context, accountID ledger.AccountID, originalEntryID ledger.EntryID) (ledger.AmountCents, error) {
+	var sum sqlSum
+	err := store.db.WithContext(ctx).
+		Model(&LedgerEntry{}).
+		Select("coalesce(sum(amount_cents),0) as total").
+		Where("account_id = ?", accountID.String()).
+		Where("type = ?", ledger.EntryRefund.String()).
+		Where("refund_of_entry_id = ?", originalEntryID.String()).
+		Scan(&sum).Error
+	if err != nil {
+		return 0, wrapStoreError(errorSubjectBalance, errorCodeSumRefunds, err)
+	}
+	refunded, err := ledger.NewAmountCents(sum.Total)
+	if err != nil {
+		return 0, wrapStoreError(errorSubjectBalance, errorCodeInvalid, err)
+	}
+	return refunded, nil
+}
+
+func (store *Store) SumTotal(ctx context.Context, accountID ledger.AccountID, atUnixUTC int64) (ledger.SignedAmountCents, error) {
+	at := time.Unix(atUnixUTC, 0).UTC()
+	var sum sqlSum
+	err := store.db.WithContext(ctx).
+		Model(&LedgerEntry{}).
+		Select("coalesce(sum("+entryBalanceExpression+"),0) as total", at).
+		Where("account_id = ?", accountID.String()).
+		Where("type not in ('hold','reverse_hold')").
+		Where("(effective_at is null or effective_at <= ?)", at).
+		Scan(&sum).Error
+	if err != nil {
+		return 0, wrapStoreError(errorSubjectBalance, errorCodeSumTotal, err)
+	}
+	return ledger.SignedAmountCents(sum.Total), nil
+}
+
+// ListConsumableGrants returns the account's effective grants that expire after atUnixUTC and are not fully
+// consumed, earliest expiry first.
+func (store *Store) ListConsumableGrants(ctx context.Context, accountID ledger.AccountID, atUnixUTC int64) ([]ledger.Entry, error) {
+	at := time.Unix(atUnixUTC, 0).UTC()
+	var rows []LedgerEntry
+	err := store.db.WithContext(ctx).
+		Where("account_id = ? AND type = ?", accountID.String(), ledger.EntryGrant.String()).
+		Where("expires_at > ?", at).
+		Where("(effective_at is null or effective_at <= ?)", at).
+		Where("consumed_cents < amount_cents").
+		Order("expires_at, created_at, entry_id").
+		Find(&rows).Error
+	if err != nil {
+		return nil, wrapStoreError(errorSubjectEntry, errorCodeList, err)
+	}
+	grants := make([]ledger.Entry, 0, len(rows))
+	for _, row := range rows {
+		grant, err := mapLedgerEntry(row)
+		if err != nil {
+			return nil, wrapStoreError(errorSubjectEntry, errorCodeInvalid, err)
+		}
+		grants = append(grants, grant)
+	}
+	return grants, nil
+}
+
+// ConsumeGrant adds amountCents to the consumed part of one of the account's grants.
+func (store *Store) ConsumeGrant(ctx context.Context, accountID ledger.AccountID, entryID ledger.EntryID, amountCents ledger.AmountCents) error {
+	result := store.db.WithContext(ctx).
+		Model(&LedgerEntry{}).
+		Where("account_id = ? AND entry_id = ? AND type = ?", accountID.String(), entryID.String(), ledger.EntryGrant.String()).
+		Update("consumed_cents", gorm.Expr("consumed_cents + ?", amountCents.Int64()))
+	if result.Error != nil {
+		return wrapStoreError(errorSubjectEntry, errorCodeConsumeGrant, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return wrapStoreError(errorSubjectEntry, errorCodeConsumeGrant, ledger.ErrUnknownEntry)
+	}
+	return nil
+}
+
+func (store *Store) SumActiveHolds(ctx context.Context, accountID ledger.AccountID, atUnixUTC int64) (ledger.AmountCents, error) {
+	at := time.Unix(atUnixUTC, 0).UTC()
+	var sum sqlSum
+	err := store.db.WithContext(ctx).
+		Model(&Reservation{}).
+		Select("coalesce(sum(amount_cents),0) as total").
+		Where("account_id = ? AND status = ?", accountID.String(), ledger.ReservationStatusActive.String()).
+		Where("(expires_at is null or expires_at > ?)", at).
+		Scan(&sum).Error
+	if err != nil {
+		return 0, wrapStoreError(errorSubjectBalance, errorCodeSumActiveHolds, err)
+	}
+	activeHolds, err := ledger.NewAmountCents(sum.Total)
+	if err != nil {
+		return 0, wrapStoreError(errorSubjectBalance, errorCodeInvalid, err)
+	}
+	return activeHolds, nil
+}
+
+func (store *Store) SumDebitVelocity(ctx context.Context, accountID ledger.AccountID, sinceUnixUTC int64) (ledger.DebitVelocity, error) {
+	since := time.Unix(sinceUnixUTC, 0).UTC()
+	var sum struct {
+		Total      int64
+		Operations int64
+	}
+	err := store.db.WithContext(ctx).
+		Model(&LedgerEntry{}).
+		Select(
+			"coalesce(sum(amount_cents),0) as total, coalesce(sum(case when type = ? or reservation_id is null then 1 else 0 end),0) as operations",
+			ledger.EntryHold.String(),
+		).
+		Where("account_id = ?", accountID.String()).
+		Where("type in ?", []string{ledger.EntrySpend.String(), ledger.EntryHold.String(), ledger.EntryReverseHold.String()}).
+		Where("created_at >= ?", since).
+		Where(
+			"type <> ? or exists (select 1 from ledger_entries holds where holds.account_id = ledger_entries.account_id and holds.reservation_id = ledger_entries.reservation_id and holds.type = ? and holds.created_at >= ?)",
+			ledger.EntryReverseHold.String(), ledger.EntryHold.String(), since,
+		).
+		Scan(&sum).Error
+	if err != nil {
+		return ledger.DebitVelocity{}, wrapStoreError(errorSubjectBalance, errorCodeSumDebitVelocity, err)
+	}
+	return ledger.DebitVelocity{
+		AmountCents: ledger.AmountCents(max(-sum.Total, 0)),
+		Operations:  sum.Operations,
+	}, nil
+}
+
+func (store *Store) InsertJournalLines(ctx context.Context, lines []ledger.JournalLine) error {
+	if len(lines) == 0 {
+		return nil
+	}
+	createdAt := time.Now().UTC()
+	records := make([]JournalLine, 0, len(lines))
+	for _, line := range lines {
+		var userAccountID *string
+		if line.Account == ledger.JournalAccountUser {
+			value := line.UserAccountID.String()
+			userAccountID = &value
+		}
+		records = append(records, JournalLine{
+			TenantID:      line.TenantID.String(),
+			LedgerID:      line.LedgerID.String(),
+			EntryID:       line.EntryID.String(),
+			Account:       line.Account.String(),
+			UserAccountID: userAccountID,
+			AmountCents:   line.AmountCents.Int64(),
+			PostedAt:      time.Unix(line.PostedUnixUTC, 0).UTC(),
+			CreatedAt:     createdAt,
+		})
+	}
+	if err := store.db.WithContext(ctx).Create(&records).Error; err != nil {
+		return wrapStoreError(errorSubjectJournal, errorCodeInsert, err)
+	}
+	return nil
+}
+
+func (store *Store) SumJournalLines(ctx context.Context, tenantID ledger.TenantID, atUnixUTC int64) ([]ledger.TrialBalanceLine, error) {
+	at := time.Unix(atUnixUTC, 0).UTC()
+	var rows []struct {
+		LedgerID string
+		Account  string
+		Total    int64
+	}
+	err := store.db.WithContext(ctx).
+		Model(&JournalLine{}).
+		Select("ledger_id, account, coalesce(sum(amount_cents),0) as total").
+		Where("tenant_id = ?", tenantID.String()).
+		Where("posted_at <= ?", at).
+		Group("ledger_id, account").
+		Order("ledger_id, account").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, wrapStoreError(errorSubjectJournal, errorCodeSumJournalLines, err)
+	}
+	balances := make([]ledger.TrialBalanceLine, 0, len(rows))
+	for _, row := range rows {
+		ledgerID, err := ledger.NewLedgerID(row.LedgerID)
+		if err != nil {
+			return nil, wrapStoreError(errorSubjectJournal, errorCodeInvalid, err)
+		}
+		account, err := ledger.ParseJournalAccount(row.Account)
+		if err != nil {
+			return nil, wrapStoreError(errorSubjectJournal, errorCodeInvalid, err)
+		}
+		balances = append(balances, ledger.TrialBalanceLine{LedgerID: ledgerID, Account: account, BalanceCents: ledger.SignedAmountCents(row.Total)})
+	}
+	return balances, nil
+}
+
+func (store *Store) SumEntriesByExpiry(ctx context.Context, tenantID ledger.TenantID, atUnixUTC int64) ([]ledger.ExpiryTotal, error) {
+	at := time.Unix(atUnixUTC, 0).UTC()
+	var rows []struct {
+		LedgerID  string
+		ExpiresAt *time.Time
+		Total     int64
+		Consumed  int64
+	}
+	err := store.db.WithContext(ctx).
+		Model(&LedgerEntry{}).
+		Select("accounts.ledger_id as ledger_id, ledger_entries.expires_at as expires_at,"+
+			" coalesce(sum(ledger_entries.amount_cents - ledger_entries.consumed_cents),0) as total, coalesce(sum(ledger_entries.consumed_cents),0) as consumed").
+		Joins("join accounts on accounts.account_id = ledger_entries.account_id").
+		Where("accounts.tenant_id = ?", tenantID.String()).
+		Where("ledger_entries.type not in ('hold','reverse_hold')").
+		Where("(ledger_entries.effective_at is null or ledger_entries.effective_at <= ?)", at).
+		Where("(ledger_entries.effective_at is null or ledger_entries.expires_at is null or ledger_entries.effective_at < ledger_entries.expires_at)").
+		Group("accounts.ledger_id, ledger_entries.expires_at").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, wrapStoreError(errorSubjectBalance, errorCodeSumEntriesByExpiry, err)
+	}
+	totals := make([]ledger.ExpiryTotal, 0, len(rows))
+	for _, row := range rows {
+		ledgerID, err := ledger.NewLedgerID(row.LedgerID)
+		if err != nil {
+			return nil, wrapStoreError(errorSubjectBalance, errorCodeInvalid, err)
+		}
+		var expiresAtUnixUTC int64
+		if row.ExpiresAt != nil {
+			expiresAtUnixUTC = row.ExpiresAt.UTC().Unix()
+		}
+		consumedCents, err := ledger.NewAmountCents(row.Consumed)
+		if err != nil {
+			return nil, wrapStoreError(errorSubjectBalance, errorCodeInvalid, err)
+		}
+		totals = append(totals, ledger.ExpiryTotal{LedgerID: ledgerID, ExpiresAtUnixUTC: expiresAtUnixUTC, AmountCents: ledger.SignedAmountCents(row.Total), ConsumedCents: consumedCents})
+	}
+	return totals, nil
+}
+
+// InsertOutboxEvent queues an event as pending delivery; it shares the caller's transaction with the entry it describes.
+func (store *Store) InsertOutboxEvent(ctx context.Context, event ledger.OutboxEvent) error {
+	createdAt := time.Unix(event.CreatedUnixUTC, 0).UTC()
+	record := OutboxEvent{
+		TenantID:      event.TenantID.String(),
+		EventType:     event.EventType,
+		Payload:       datatypesJSON(event.PayloadJSON),
+		Status:        outboxStatusPending,
+		NextAttemptAt: createdAt,
+		CreatedAt:     createdAt,
+	}
+	if err := store.db.WithContext(ctx).Create(&record).Error; err != nil {
+		return wrapStoreError(errorSubjectOutbox, errorCodeInsert, err)
+	}
+	return nil
+}
+
+// AppendEntryChange numbers an entry in its tenant's change feed. The upsert locks the tenant's feed head
+// until the surrounding transaction commits, so sequences are handed out in commit order.
+func (store *Store) AppendEntryChange(ctx context.Context, tenantID ledger.TenantID, entryID ledger.EntryID) error {
+	return store.db.WithContext(ctx).Transaction(func(transaction *gorm.DB) error {
+		head := EntryFeedHead{TenantID: tenantID.String(), LastSequence: 1}
+		err := transaction.
+			Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "tenant_id"}},
+				DoUpdates: clause.Assignments(map[string]interface{}{"last_sequence": gorm.Expr("entry_feed_heads.last_sequence + 1")}),
+			}).
+			Create(&head).Error
+		if err != nil {
+			return wrapStoreError(errorSubjectEntryFeed, errorCodeSequence, err)
+		}
+		if err := transaction.Where("tenant_id = ?", tenantID.String()).Take(&head).Error; err != nil {
+			return wrapStoreError(errorSubjectEntryFeed, errorCodeSequence, err)
+		}
+		change := EntryChange{
+			TenantID:  tenantID.String(),
+			Sequence:  head.LastSequence,
+			EntryID:   entryID.String(),
+			CreatedAt: time.Now().UTC(),
+		}
+		if err := transaction.Create(&change).Error; err != nil {
+			return wrapStoreError(errorSubjectEntryFeed, errorCodeInsert, err)
+		}
+		return nil
+	})
+}
+
+func (store *Store) ListEntryChanges(ctx context.Context, tenantID ledger.TenantID, afterCursor int64, limit int, filter ledger.EntryChangeFilter) ([]ledger.EntryChange, error) {
+	var rows []struct {
+		Sequence int64
+		UserID   string
+		LedgerID string
+		LedgerEntry
+	}
+	query := store.db.WithContext(ctx).
+		Model(&EntryChange{}).
+		Select("entry_changes.sequence as sequence, accounts.user_id as user_id, accounts.ledger_id as ledger_id, ledger_entries.*").
+		Joins("join ledger_entries on ledger_entries.entry_id = entry_changes.entry_id").
+		Joins("join accounts on accounts.account_id = ledger_entries.account_id").
+		Where("entry_changes.tenant_id = ? AND entry_changes.sequence > ?", tenantID.String(), afterCursor).
+		Order("entry_changes.sequence ASC")
+	if filter.LedgerID != nil {
+		query = query.Where("accounts.ledger_id = ?", filter.LedgerID.String())
+	}
+	if filter.UserID != nil {
+		query = query.Where("accounts.user_id = ?", filter.UserID.String())
+	}
+	if len(filter.Types) > 0 {
+		typeValues := make([]string, 0, len(filter.Types))
+		for _, entryType := range filter.Types {
+			typeValues = append(typeValues, entryType.String())
+		}
+		query = query.Where("ledger_entries.type in ?", typeValues)
+	}
+	if err := query.Limit(limit).Scan(&rows).Error; err != nil {
+		return nil, wrapStoreError(errorSubjectEntryFeed, errorCodeList, err)
+	}
+
+	changes := make([]ledger.EntryChange, 0, len(rows))
+	for _, row := range rows {
+		userID, err := ledger.NewUserID(row.UserID)
+		if err != nil {
+			return nil, wrapStoreError(errorSubjectEntryFeed, errorCodeInvalid, err)
+		}
+		ledgerID, err := ledger.NewLedgerID(row.LedgerID)
+		if err != nil {
+			return nil, wrapStoreError(errorSubjectEntryFeed, errorCodeInvalid, err)
+		}
+		entry, err := mapLedgerEntry(row.LedgerEntry)
+		if err != nil {
+			return nil, wrapStoreError(errorSubjectEntryFeed, errorCodeInvalid, err)
+		}
+		changes = append(changes, ledger.EntryChange{Cursor: row.Sequence, UserID: userID, LedgerID: ledgerID, Entry: entry})
+	}
+	return changes, nil
+}
+
+func (store *Store) CreateReservation(ctx context.Context, reservation ledger.Reservation) error {
+	var expiresAt *time.Time
+	if reservation.ExpiresAtUnixUTC() != 0 {
+		value := time.Unix(reservation.ExpiresAtUnixUTC(), 0).UTC()
+		expiresAt = &value
+	}
+	model := Reservation{
+		AccountID:     reservation.AccountID().String(),
+		ReservationID: reservation.ReservationID().String(),
+		AmountCents:   reservation.AmountCents().Int64(),
+		Status:        reservation.Status().String(),
+		ExpiresAt:     expiresAt,
+	}
+	err := store.db.WithContext(ctx).Create(&model).Error
+	if isReservationConflict(err) {
+		return wrapStoreError(errorSubjectReservation, errorCodeDuplicate, ledger.ErrReservationExists)
+	}
+	if err != nil {
+		return wrapStoreError(errorSubjectReservation, errorCodeCreate, err)
+	}
+	return nil
+}
+
+func (store *Store) GetReservation(ctx context.Context, accountID ledger.AccountID, reservationID ledger.ReservationID) (ledger.Reservation, error) {
+	var model Reservation
+	err := store.db.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("account_id = ? AND reservation_id = ?", accountID.String(), reservationID.String()).
+		Take(&model).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ledger.Reservation{}, wrapStoreError(errorSubjectReservation, errorCodeGet, ledger.ErrUnknownReservation)
+		}
+		return ledger.Reservation{}, wrapStoreError(errorSubjectReservation, errorCodeGet, err)
+	}
+	parsedAccountID, err := ledger.NewAccountID(model.AccountID)
+	if err != nil {
+		return ledger.Reservation{}, wrapStoreError(errorSubjectReservation, errorCodeInvalid, err)
+	}
+	parsedReservationID, err := ledger.NewReservationID(model.ReservationID)
+	if err != nil {
+		return ledger.Reservation{}, wrapStoreError(errorSubjectReservation, errorCodeInvalid, err)
+	}
+	amountCents, err := ledger.NewPositiveAmountCents(model.AmountCents)
+	if err != nil {
+		return ledger.Reservation{}, wrapStoreError(errorSubjectReservation, errorCodeInvalid, err)
+	}
+	status, err := ledger.ParseReservationStatus(model.Status)
+	if err != nil {
+		return ledger.Reservation{}, wrapStoreError(errorSubjectReservation, errorCodeInvalid, err)
+	}
+	expiresAtUnixUTC := int64(0)
+	if model.ExpiresAt != nil {
+		expiresAtUnixUTC = model.ExpiresAt.UTC().Unix()
+	}
+	return ledger.NewReservationWithTimestamps(
+		parsedAccountID,
+		parsedReservationID,
+		amountCents,
+		status,
+		expiresAtUnixUTC,
+		model.CreatedAt.UTC().Unix(),
+		model.UpdatedAt.UTC().Unix(),
+	)
+}
+
+func (store *Store) UpdateReservationStatus(ctx context.Context, accountID ledger.AccountID, reservationID ledger.ReservationID, from, to ledger.ReservationStatus) error {
+	result := store.db.WithContext(ctx).
+		Model(&Reservation{}).
+		Where("account_id = ? AND reservation_id = ? AND status = ?", accountID.String(), reservationID.String(), from.String()).
+		Update("status", to.String())
+	if result.Error != nil {
+		return wrapStoreError(errorSubjectReservation, errorCodeUpdateStatus, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return wrapStoreError(errorSubjectReservation, errorCodeUpdateStatus, ledger.ErrReservationClosed)
+	}
+	return nil
+}
+
+func (store *Store) ListReservations(ctx context.Context, accountID ledger.AccountID, beforeCreatedUnixUTC int64, limit int, filter ledger.ListReservationsFilter) ([]ledger.Reservation, error) {
+	before := time.Unix(beforeCreatedUnixUTC, 0).UTC()
+	if beforeCreatedUnixUTC == 0 {
+		before = time.Now().UTC().Add(time.Second)
+	}
+
+	var rows []Reservation
+	query := store.db.WithContext(ctx).
+		Model(&Reservation{}).
+		Where("account_id = ? AND created_at < ?", accountID.String(), before).
+		Order("created_at DESC").
+		Limit(limit)
+	if len(filter.Statuses) > 0 {
+		statusValues := make([]string, 0, len(filter.Statuses))
+		for _, status := range filter.Statuses {
+			statusValues = append(statusValues, status.String())
+		}
+		query = query.Where("status in ?", statusValues)
+	}
+
+	if err := query.Find(&rows).Error; err != nil {
+		return nil, wrapStoreError(errorSubjectReservation, errorCodeList, err)
+	}
+
+	reservations := make([]ledger.Reservation, 0, len(rows))
+	for _, row := range rows {
+		reservationID, err := ledger.NewReservationID(row.ReservationID)
+		if err != nil {
+			return nil, wrapStoreError(errorSubjectReservation, errorCodeInvalid, err)
+		}
+		amountCents, err := ledger.NewPositiveAmountCents(row.AmountCents)
+		if err != nil {
+			return nil, wrapStoreError(errorSubjectReservation, errorCodeInvalid, err)
+		}
+		status, err := ledger.ParseReservationStatus(row.Status)
+		if err != nil {
+			return nil, wrapStoreError(errorSubjectReservation, errorCodeInvalid, err)
+		}
+		expiresAtUnixUTC := int64(0)
+		if row.ExpiresAt != nil {
+			expiresAtUnixUTC = row.ExpiresAt.UTC().Unix()
+		}
+		reservation, err := ledger.NewReservationWithTimestamps(
+			accountID,
+			reservationID,
+			amountCents,
+			status,
+			expiresAtUnixUTC,
+			row.CreatedAt.UTC().Unix(),
+			row.UpdatedAt.UTC().Unix(),
+		)
+		if err != nil {
+			return nil, wrapStoreError(errorSubjectReservation, errorCodeInvalid, err)
+		}
+		reservations = append(reservations, reservation)
+	}
+	return reservations, nil
+}
+
+func (store *Store) ListEntries(ctx context.Context, accountID ledger.AccountID, beforeUnixUTC int64, limit int, filter ledger.ListEntriesFilter) ([]ledger.Entry, error) {
+	before := time.Unix(beforeUnixUTC, 0).UTC()
+	if filter.BeforeEntryID != nil {
+		before = before.Add(time.Second)
+	}
+	if beforeUnixUTC == 0 {
+		before = time.Now().UTC().Add(time.Second)
+	}
+
+	var rows []LedgerEntry
+	query := store.db.WithContext(ctx).
+		Where("account_id = ? AND created_at < ?", accountID.String(), before).
+		Order("created_at DESC, entry_id DESC")
+	if filter.BeforeEntryID != nil {
+		query = query.Where("(created_at < ? OR entry_id < ?)", time.Unix(beforeUnixUTC, 0).UTC(), filter.BeforeEntryID.String())
+	}
+	if len(filter.Types) > 0 {
+		typeValues := make([]string, 0, len(filter.Types))
+		for _, entryType := range filter.Types {
+			typeValues = append(typeValues, entryType.String())
+		}
+		query = query.Where("type in ?", typeValues)
+	}
+	if filter.ReservationID != nil {
+		query = query.Where("reservation_id = ?", filter.ReservationID.String())
+	}
+	if filter.EffectiveAfterUnixUTC != 0 {
+		query = query.Where("effective_at > ?", time.Unix(filter.EffectiveAfterUnixUTC, 0).UTC())
+	}
+	if filter.IdempotencyKeyPrefix != nil {
+		query = query.Where(`idempotency_key like ? escape '\'`, likePrefixPattern(filter.IdempotencyKeyPrefix.String()))
+	}
+	for column, value := range map[string]string{
+		"actor_api_key_id":     filter.Actor.APIKeyID(),
+		"actor_service":        filter.Actor.Service(),
+		"actor_request_id":     filter.Actor.RequestID(),
+		"actor_client_address": filter.Actor.ClientAddress(),
+	} {
+		if value != "" {
+			query = query.Where(column+" = ?", value)
+		}
+	}
+	err := query.Limit(limit).Find(&rows).Error
+	if err != nil {
+		return nil, wrapStoreError(errorSubjectEntry, errorCodeList, err)
+	}
+
+	entries := make([]ledger.Entry, 0, len(rows))
+	for _, row := range rows {
+		entry, err := mapLedgerEntry(row)
+		if err != nil {
+			return nil, wrapStoreError(errorSubjectEntry, errorCodeInvalid, err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+func wrapStoreError(subject string, code string, err error) error {
+	return ledger.WrapError(errorOperationStore, subject, code, err)
+}
+
+type sqlSum struct {
+	Total int64
+}
+
+func mapAccount(row Account) (ledger.Account, error) {
+	accountID, err := ledger.NewAccountID(row.AccountID)
+	if err != nil {
+		return ledger.Account{}, err
+	}
+	tenantID, err := ledger.NewTenantID(row.TenantID)
+	if err != nil {
+		return ledger.Account{}, err
+	}
+	userID, err := ledger.NewUserID(row.UserID)
+	if err != nil {
+		return ledger.Account{}, err
+	}
+	ledgerID, err := ledger.NewLedgerID(row.LedgerID)
+	if err != nil {
+		return ledger.Account{}, err
+	}
+	return ledger.NewAccount(accountID, tenantID, userID, ledgerID, row.CreatedAt.UTC().Unix())
+}
+
+func mapLedgerEntry(row LedgerEntry) (ledger.Entry, error) {
+	entryID, err := ledger.NewEntryID(row.EntryID)
+	if err != nil {
+		return ledger.Entry{}, err
+	}
+	accountID, err := ledger.NewAccountID(row.AccountID)
+	if err != nil {
+		return ledger.Entry{}, err
+	}
+	entryType, err := ledger.ParseEntryType(row.Type)
+	if err != nil {
+		return ledger.Entry{}, err
+	}
+	amountCents, err := ledger.NewEntryAmountCents(row.AmountCents)
+	if err != nil {
+		return ledger.Entry{}, err
+	}
+	var reservationID *ledger.ReservationID
+	if row.ReservationID != nil {
+		parsedReservationID, err := ledger.NewReservationID(*row.ReservationID)
+		if err != nil {
+			return ledger.Entry{}, err
+		}
+		reservationID = &parsedReservationID
+	}
+	var refundOfEntryID *ledger.EntryID
+	if row.RefundOfEntryID != nil {
+		parsedRefundOfEntryID, err := ledger.NewEntryID(*row.RefundOfEntryID)
+		if err != nil {
+			return ledger.Entry{}, err
+		}
+		refundOfEntryID = &parsedRefundOfEntryID
+	}
+	idempotencyKey, err := ledger.NewIdempotencyKey(row.IdempotencyKey)
+	if err != nil {
+		return ledger.Entry{}, err
+	}
+	metadata, err := ledger.NewMetadataJSON(string(row.Metadata))
+	if err != nil {
+		return ledger.Entry{}, err
+	}
+	entry, err := ledger.NewEntry(
+		entryID,
+		accountID,
+		entryType,
+		amountCents,
+		reservationID,
+		refundOfEntryID,
+		idempotencyKey,
+		timeOrZero(row.ExpiresAt),
+		metadata,
+		row.CreatedAt.Unix(),
+	)
+	if err != nil {
+		return ledger.Entry{}, err
+	}
+	actor, err := ledger.NewActor(row.ActorAPIKeyID, row.ActorService, row.ActorRequestID, row.ActorClientAddress)
+	if err != nil {
+		return ledger.Entry{}, err
+	}
+	consumedCents, err := ledger.NewAmountCents(row.ConsumedCents)
+	if err != nil {
+		return ledger.Entry{}, err
+	}
+	return entry.WithEffectiveAtUnixUTC(timeOrZero(row.EffectiveAt)).WithActor(actor).WithConsumedCents(consumedCents), nil
+}
+
+func timeOrZero(value *time.Time) int64 {
+	if value == nil {
+		return 0
+	}
+	return value.Unix()
+}
+
+func datatypesJSON(raw string) datatypes.JSON {
+	if raw == "" {
+		return datatypes.JSON([]byte(defaultMetadataJSON))
+	}
+	return datatypes.JSON([]byte(raw))
+}
+
+// likeEscaper escapes the LIKE wildcards and the escape character itself, for patterns used with ESCAPE '\'.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// likePrefixPattern matches values that start with prefix taken literally.
+func likePrefixPattern(prefix string) string {
+	return likeEscaper.Replace(prefix) + "%"
+}
+
+func isIdempotencyConflict(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return true
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		if pgErr.Code != pgUniqueViolationCode {
+			return false
+		}
+		if pgErr.ConstraintName == constraintLedgerEntriesPrimary {
+			return false
+		}
+		if pgErr.ConstraintName == constraintAccountIdempotencyKey {
+			return true
+		}
+		return true
+	}
+	var sqliteErr *gosqlite.Error
+	if errors.As(err, &sqliteErr) {
+		return sqliteErr.Code()&0xFF == sqliteConstraintCode
+	}
+	return false
+}
+
+func isReservationConflict(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return true
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		if pgErr.Code != pgUniqueViolationCode {
+			return false
+		}
+		if pgErr.ConstraintName == constraintReservationPrimary {
+			return true
+		}
+		return true
+	}
+	var sqliteErr *gosqlite.Error
+	if errors.As(err, &sqliteErr) {
+		return sqliteErr.Code()&0xFF == sqliteConstraintCode
+	}
+	return false
+}
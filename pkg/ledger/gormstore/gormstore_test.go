@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -225,10 +226,17 @@ func TestStoreListEntriesAppliesFilters(test *testing.T) {
 	if err != nil {
 		test.Fatalf("spend input: %v", err)
 	}
-	spendEntry, err := store.InsertEntry(ctx, spendInput)
+	actor, err := ledger.NewActor("sha256:0011", "billing", "req-1", "10.0.0.1:5000")
+	if err != nil {
+		test.Fatalf("actor: %v", err)
+	}
+	spendEntry, err := store.InsertEntry(ctx, spendInput.WithActor(actor))
 	if err != nil {
 		test.Fatalf("insert spend: %v", err)
 	}
+	if spendEntry.Actor() != actor {
+		test.Fatalf("expected inserted entry to carry actor, got %+v", spendEntry.Actor())
+	}
 
 	grantOnly, err := store.ListEntries(ctx, accountID, 0, 10, ledger.ListEntriesFilter{Types: []ledger.EntryType{ledger.EntryGrant}})
 	if err != nil {
@@ -258,6 +266,18 @@ func TestStoreListEntriesAppliesFilters(test *testing.T) {
 		test.Fatalf("expected spend entry %s, got %+v", spendEntry.EntryID().String(), byPrefix)
 	}
 
+	serviceOnly, err := ledger.NewActor("", "billing", "", "")
+	if err != nil {
+		test.Fatalf("actor filter: %v", err)
+	}
+	byActor, err := store.ListEntries(ctx, accountID, 0, 10, ledger.ListEntriesFilter{Actor: serviceOnly})
+	if err != nil {
+		test.Fatalf("list entries by actor: %v", err)
+	}
+	if len(byActor) != 1 || byActor[0].EntryID() != spendEntry.EntryID() || byActor[0].Actor() != actor {
+		test.Fatalf("expected spend entry %s with actor, got %+v", spendEntry.EntryID().String(), byActor)
+	}
+
 	combined, err := store.ListEntries(ctx, accountID, 0, 10, ledger.ListEntriesFilter{
 		Types:                []ledger.EntryType{ledger.EntryHold},
 		ReservationID:        &reservationID,
@@ -269,6 +289,13 @@ func TestStoreListEntriesAppliesFilters(test *testing.T) {
 	if len(combined) != 0 {
 		test.Fatalf("expected no entries, got %+v", combined)
 	}
+
+	if err := db.Exec("UPDATE ledger_entries SET actor_request_id = ?", strings.Repeat("r", 257)).Error; err != nil {
+		test.Fatalf("corrupt actor: %v", err)
+	}
+	if _, err := store.ListEntries(ctx, accountID, 0, 10, ledger.ListEntriesFilter{}); !errors.Is(err, ledger.ErrInvalidActor) {
+		test.Fatalf("expected invalid actor, got %v", err)
+	}
 }
 
 func TestStoreWithTxCommitsAndRollsBack(test *testing.T) {
@@ -557,170 +584,1298 @@ func TestStoreSumActiveHoldsIgnoresExpiredReservations(test *testing.T) {
 	}
 }
 
-func TestStoreWrapsDatabaseErrors(test *testing.T) {
+func TestStoreSumTotalExcludesEntriesBeforeEffectiveAt(test *testing.T) {
 	test.Parallel()
 	db := newSQLiteDB(test)
-	sqlDB, err := db.DB()
+	store := New(db)
+
+	ctx := context.Background()
+	accountID, err := store.GetOrCreateAccountID(ctx, mustTenantID(test), mustUserID(test), mustLedgerID(test))
 	if err != nil {
-		test.Fatalf("sql db: %v", err)
+		test.Fatalf("account: %v", err)
 	}
-	if err := sqlDB.Close(); err != nil {
-		test.Fatalf("close db: %v", err)
+	metadata, err := ledger.NewMetadataJSON("{}")
+	if err != nil {
+		test.Fatalf("metadata: %v", err)
+	}
+	effectiveAtUnixUTC := int64(1700000100)
+	insert := func(rawKey string, rawAmount int64, effectiveAt int64) ledger.Entry {
+		test.Helper()
+		idempotencyKey, err := ledger.NewIdempotencyKey(rawKey)
+		if err != nil {
+			test.Fatalf("idempotency key: %v", err)
+		}
+		amount, err := ledger.NewEntryAmountCents(rawAmount)
+		if err != nil {
+			test.Fatalf("amount: %v", err)
+		}
+		entryInput, err := ledger.NewEntryInput(accountID, ledger.EntryGrant, amount, nil, nil, idempotencyKey, 0, metadata, 1700000000)
+		if err != nil {
+			test.Fatalf("entry input: %v", err)
+		}
+		entry, err := store.InsertEntry(ctx, entryInput.WithEffectiveAtUnixUTC(effectiveAt))
+		if err != nil {
+			test.Fatalf("insert entry: %v", err)
+		}
+		return entry
+	}
+	insert("grant-now", 100, 0)
+	scheduled := insert("grant-scheduled", 250, effectiveAtUnixUTC)
+	if scheduled.EffectiveAtUnixUTC() != effectiveAtUnixUTC {
+		test.Fatalf("expected effective_at %d, got %d", effectiveAtUnixUTC, scheduled.EffectiveAtUnixUTC())
+	}
+	loaded, err := store.GetEntry(ctx, accountID, scheduled.EntryID())
+	if err != nil {
+		test.Fatalf("get entry: %v", err)
+	}
+	if loaded.EffectiveAtUnixUTC() != effectiveAtUnixUTC || !loaded.IsPendingAt(effectiveAtUnixUTC-1) || loaded.IsPendingAt(effectiveAtUnixUTC) {
+		test.Fatalf("unexpected persisted effective_at %d", loaded.EffectiveAtUnixUTC())
+	}
+
+	total, err := store.SumTotal(ctx, accountID, effectiveAtUnixUTC-1)
+	if err != nil {
+		test.Fatalf("sum total before effective: %v", err)
+	}
+	if total.Int64() != 100 {
+		test.Fatalf("expected total 100 before effective_at, got %d", total.Int64())
+	}
+	total, err = store.SumTotal(ctx, accountID, effectiveAtUnixUTC)
+	if err != nil {
+		test.Fatalf("sum total at effective: %v", err)
+	}
+	if total.Int64() != 350 {
+		test.Fatalf("expected total 350 at effective_at, got %d", total.Int64())
 	}
+}
+
+func TestStoreGetAccountIDDoesNotCreateAccounts(test *testing.T) {
+	test.Parallel()
+	db := newSQLiteDB(test)
 	store := New(db)
-	ctx := context.Background()
 
-	_, err = store.GetOrCreateAccountID(ctx, mustTenantID(test), mustUserID(test), mustLedgerID(test))
-	var operationError ledger.OperationError
-	if !errors.As(err, &operationError) {
-		test.Fatalf("expected operation error, got %v", err)
+	ctx := context.Background()
+	_, err := store.GetAccountID(ctx, mustTenantID(test), mustUserID(test), mustLedgerID(test))
+	if !errors.Is(err, ledger.ErrUnknownAccount) {
+		test.Fatalf("expected unknown account, got %v", err)
 	}
-	if operationError.Subject() != errorSubjectAccount || operationError.Code() != errorCodeLookup {
-		test.Fatalf("unexpected operation error: %s.%s.%s", operationError.Operation(), operationError.Subject(), operationError.Code())
+	var count int64
+	if err := db.Model(&Account{}).Count(&count).Error; err != nil {
+		test.Fatalf("count accounts: %v", err)
+	}
+	if count != 0 {
+		test.Fatalf("expected lookup not to create accounts, got %d", count)
 	}
 
-	accountID, err := ledger.NewAccountID("account-1")
+	created, err := store.GetOrCreateAccountID(ctx, mustTenantID(test), mustUserID(test), mustLedgerID(test))
 	if err != nil {
-		test.Fatalf("account id: %v", err)
+		test.Fatalf("create account: %v", err)
 	}
-	amount, err := ledger.NewPositiveAmountCents(100)
+	found, err := store.GetAccountID(ctx, mustTenantID(test), mustUserID(test), mustLedgerID(test))
 	if err != nil {
-		test.Fatalf("amount: %v", err)
+		test.Fatalf("get account: %v", err)
 	}
-	idempotencyKey, err := ledger.NewIdempotencyKey("grant-1")
-	if err != nil {
-		test.Fatalf("idempotency: %v", err)
+	if found != created {
+		test.Fatalf("expected %s, got %s", created.String(), found.String())
 	}
-	metadata, err := ledger.NewMetadataJSON("{}")
+}
+
+func TestStoreListAccountsPagesAndFilters(test *testing.T) {
+	test.Parallel()
+	db := newSQLiteDB(test)
+	store := New(db)
+
+	ctx := context.Background()
+	tenantID := mustTenantID(test)
+	otherTenantID, err := ledger.NewTenantID("other")
 	if err != nil {
-		test.Fatalf("metadata: %v", err)
+		test.Fatalf("tenant id: %v", err)
 	}
-	entryInput, err := ledger.NewEntryInput(
-		accountID,
-		ledger.EntryGrant,
-		amount.ToEntryAmountCents(),
-		nil,
-		nil,
-		idempotencyKey,
-		0,
-		metadata,
-		time.Now().UTC().Unix(),
-	)
+	promoLedgerID, err := ledger.NewLedgerID("promo")
 	if err != nil {
-		test.Fatalf("entry input: %v", err)
+		test.Fatalf("ledger id: %v", err)
 	}
-	_, err = store.InsertEntry(ctx, entryInput)
-	if !errors.As(err, &operationError) {
-		test.Fatalf("expected operation error, got %v", err)
+	metadata, err := ledger.NewMetadataJSON("{}")
+	if err != nil {
+		test.Fatalf("metadata: %v", err)
 	}
-	if operationError.Subject() != errorSubjectEntry || operationError.Code() != errorCodeInsert {
-		test.Fatalf("unexpected operation error: %s.%s.%s", operationError.Operation(), operationError.Subject(), operationError.Code())
+	createAccount := func(accountTenantID ledger.TenantID, rawUserID string, accountLedgerID ledger.LedgerID, createdUnixUTC int64, balanceCents int64) ledger.AccountID {
+		test.Helper()
+		userID, err := ledger.NewUserID(rawUserID)
+		if err != nil {
+			test.Fatalf("user id: %v", err)
+		}
+		accountID, err := store.GetOrCreateAccountID(ctx, accountTenantID, userID, accountLedgerID)
+		if err != nil {
+			test.Fatalf("account: %v", err)
+		}
+		if err := db.Model(&Account{}).Where("account_id = ?", accountID.String()).Update("created_at", time.Unix(createdUnixUTC, 0).UTC()).Error; err != nil {
+			test.Fatalf("set created_at: %v", err)
+		}
+		if balanceCents != 0 {
+			amount, err := ledger.NewEntryAmountCents(balanceCents)
+			if err != nil {
+				test.Fatalf("amount: %v", err)
+			}
+			idempotencyKey, err := ledger.NewIdempotencyKey("seed-" + accountID.String())
+			if err != nil {
+				test.Fatalf("idempotency key: %v", err)
+			}
+			entryInput, err := ledger.NewEntryInput(accountID, ledger.EntryGrant, amount, nil, nil, idempotencyKey, 0, metadata, createdUnixUTC)
+			if err != nil {
+				test.Fatalf("entry input: %v", err)
+			}
+			if _, err := store.InsertEntry(ctx, entryInput); err != nil {
+				test.Fatalf("insert entry: %v", err)
+			}
+		}
+		return accountID
 	}
+	alice := createAccount(tenantID, "alice", mustLedgerID(test), 1700000100, 500)
+	aliceSmith := createAccount(tenantID, "alice-smith", promoLedgerID, 1700000200, 0)
+	bob := createAccount(tenantID, "bob", mustLedgerID(test), 1700000300, 0)
+	createAccount(otherTenantID, "alice", mustLedgerID(test), 1700000400, 100)
 
-	_, err = store.SumTotal(ctx, accountID, time.Now().UTC().Unix())
-	if !errors.As(err, &operationError) {
-		test.Fatalf("expected operation error, got %v", err)
+	listIDs := func(beforeCreatedUnixUTC int64, limit int, filter ledger.ListAccountsFilter) []ledger.AccountID {
+		test.Helper()
+		accounts, err := store.ListAccounts(ctx, tenantID, beforeCreatedUnixUTC, limit, filter)
+		if err != nil {
+			test.Fatalf("list accounts: %v", err)
+		}
+		accountIDs := make([]ledger.AccountID, 0, len(accounts))
+		for _, account := range accounts {
+			if account.TenantID() != tenantID {
+				test.Fatalf("unexpected tenant: %+v", account)
+			}
+			accountIDs = append(accountIDs, account.AccountID())
+		}
+		return accountIDs
 	}
-	if operationError.Subject() != errorSubjectBalance || operationError.Code() != errorCodeSumTotal {
-		test.Fatalf("unexpected operation error: %s.%s.%s", operationError.Operation(), operationError.Subject(), operationError.Code())
+	assertIDs := func(name string, got []ledger.AccountID, want ...ledger.AccountID) {
+		test.Helper()
+		if len(got) != len(want) {
+			test.Fatalf("%s: expected %v, got %v", name, want, got)
+		}
+		for index := range want {
+			if got[index] != want[index] {
+				test.Fatalf("%s: expected %v, got %v", name, want, got)
+			}
+		}
 	}
 
-	_, err = store.SumActiveHolds(ctx, accountID, time.Now().UTC().Unix())
-	if !errors.As(err, &operationError) {
-		test.Fatalf("expected operation error, got %v", err)
+	assertIDs("all", listIDs(0, 10, ledger.ListAccountsFilter{}), bob, aliceSmith, alice)
+	assertIDs("first page", listIDs(0, 2, ledger.ListAccountsFilter{}), bob, aliceSmith)
+	assertIDs("next page", listIDs(1700000200, 2, ledger.ListAccountsFilter{}), alice)
+	ledgerID := mustLedgerID(test)
+	assertIDs("ledger", listIDs(0, 10, ledger.ListAccountsFilter{LedgerID: &ledgerID}), bob, alice)
+	assertIDs("user prefix", listIDs(0, 10, ledger.ListAccountsFilter{UserIDPrefix: "alice"}), aliceSmith, alice)
+	assertIDs("created after", listIDs(0, 10, ledger.ListAccountsFilter{CreatedAfterUnixUTC: 1700000200}), bob, aliceSmith)
+	assertIDs("non-zero balance", listIDs(0, 10, ledger.ListAccountsFilter{NonZeroBalance: true, BalanceAtUnixUTC: 1700001000}), alice)
+
+	accounts, err := store.ListAccounts(ctx, tenantID, 0, 1, ledger.ListAccountsFilter{})
+	if err != nil {
+		test.Fatalf("list accounts: %v", err)
 	}
-	if operationError.Subject() != errorSubjectBalance || operationError.Code() != errorCodeSumActiveHolds {
-		test.Fatalf("unexpected operation error: %s.%s.%s", operationError.Operation(), operationError.Subject(), operationError.Code())
+	if accounts[0].UserID().String() != "bob" || accounts[0].LedgerID() != ledgerID || accounts[0].CreatedUnixUTC() != 1700000300 {
+		test.Fatalf("unexpected account fields: %+v", accounts[0])
 	}
+}
 
-	reservationID, err := ledger.NewReservationID("order-1")
+func TestStoreSumJournalLinesGroupsByLedgerAndAccount(test *testing.T) {
+	test.Parallel()
+	db := newSQLiteDB(test)
+	store := New(db)
+
+	ctx := context.Background()
+	tenantID := mustTenantID(test)
+	ledgerID := mustLedgerID(test)
+	otherTenantID, err := ledger.NewTenantID("other")
 	if err != nil {
-		test.Fatalf("reservation id: %v", err)
+		test.Fatalf("tenant id: %v", err)
 	}
-	reservationAmount, err := ledger.NewPositiveAmountCents(10)
+	accountID, err := store.GetOrCreateAccountID(ctx, tenantID, mustUserID(test), ledgerID)
 	if err != nil {
-		test.Fatalf("amount: %v", err)
+		test.Fatalf("account: %v", err)
 	}
-	reservation, err := ledger.NewReservation(accountID, reservationID, reservationAmount, ledger.ReservationStatusActive, 0)
+	entryID, err := ledger.NewEntryID("11111111-1111-1111-1111-111111111111")
 	if err != nil {
-		test.Fatalf("reservation: %v", err)
+		test.Fatalf("entry id: %v", err)
 	}
-	err = store.CreateReservation(ctx, reservation)
-	if !errors.As(err, &operationError) {
-		test.Fatalf("expected operation error, got %v", err)
+	line := func(lineTenantID ledger.TenantID, account ledger.JournalAccount, amountCents int64, postedUnixUTC int64) ledger.JournalLine {
+		journalLine := ledger.JournalLine{
+			TenantID:      lineTenantID,
+			LedgerID:      ledgerID,
+			EntryID:       entryID,
+			Account:       account,
+			AmountCents:   ledger.SignedAmountCents(amountCents),
+			PostedUnixUTC: postedUnixUTC,
+		}
+		if account == ledger.JournalAccountUser {
+			journalLine.UserAccountID = accountID
+		}
+		return journalLine
 	}
-	if operationError.Subject() != errorSubjectReservation || operationError.Code() != errorCodeCreate {
-		test.Fatalf("unexpected operation error: %s.%s.%s", operationError.Operation(), operationError.Subject(), operationError.Code())
+	lines := []ledger.JournalLine{
+		line(tenantID, ledger.JournalAccountUser, 500, 1700000000),
+		line(tenantID, ledger.JournalAccountIssuance, -500, 1700000000),
+		line(tenantID, ledger.JournalAccountUser, -200, 1700000100),
+		line(tenantID, ledger.JournalAccountRevenue, 200, 1700000100),
+		line(tenantID, ledger.JournalAccountUser, -300, 1700000500),
+		line(tenantID, ledger.JournalAccountBreakage, 300, 1700000500),
+		line(otherTenantID, ledger.JournalAccountUser, 900, 1700000000),
+		line(otherTenantID, ledger.JournalAccountIssuance, -900, 1700000000),
 	}
-
-	err = store.UpdateReservationStatus(ctx, accountID, reservationID, ledger.ReservationStatusActive, ledger.ReservationStatusCaptured)
-	if !errors.As(err, &operationError) {
-		test.Fatalf("expected operation error, got %v", err)
+	if err := store.InsertJournalLines(ctx, lines); err != nil {
+		test.Fatalf("insert journal lines: %v", err)
 	}
-	if operationError.Subject() != errorSubjectReservation || operationError.Code() != errorCodeUpdateStatus {
-		test.Fatalf("unexpected operation error: %s.%s.%s", operationError.Operation(), operationError.Subject(), operationError.Code())
+	if err := store.InsertJournalLines(ctx, nil); err != nil {
+		test.Fatalf("insert empty journal lines: %v", err)
 	}
 
-	_, err = store.GetReservation(ctx, accountID, reservationID)
-	if !errors.As(err, &operationError) {
-		test.Fatalf("expected operation error, got %v", err)
+	balances, err := store.SumJournalLines(ctx, tenantID, 1700000100)
+	if err != nil {
+		test.Fatalf("sum journal lines: %v", err)
 	}
-	if operationError.Subject() != errorSubjectReservation || operationError.Code() != errorCodeGet {
-		test.Fatalf("unexpected operation error: %s.%s.%s", operationError.Operation(), operationError.Subject(), operationError.Code())
+	expected := map[ledger.JournalAccount]int64{
+		ledger.JournalAccountIssuance: -500,
+		ledger.JournalAccountRevenue:  200,
+		ledger.JournalAccountUser:     300,
 	}
-
-	_, err = store.ListEntries(ctx, accountID, 0, 10, ledger.ListEntriesFilter{})
-	if !errors.As(err, &operationError) {
-		test.Fatalf("expected operation error, got %v", err)
+	if len(balances) != len(expected) {
+		test.Fatalf("expected %d balances, got %+v", len(expected), balances)
 	}
-	if operationError.Subject() != errorSubjectEntry || operationError.Code() != errorCodeList {
-		test.Fatalf("unexpected operation error: %s.%s.%s", operationError.Operation(), operationError.Subject(), operationError.Code())
+	for _, balance := range balances {
+		if balance.LedgerID != ledgerID || balance.BalanceCents.Int64() != expected[balance.Account] {
+			test.Fatalf("unexpected balance: %+v", balance)
+		}
 	}
 
-	entryID, err := ledger.NewEntryID("entry-1")
-	if err != nil {
-		test.Fatalf("entry id: %v", err)
+	var userLine JournalLine
+	if err := db.Where("account = ?", ledger.JournalAccountUser.String()).First(&userLine).Error; err != nil {
+		test.Fatalf("load user line: %v", err)
 	}
-	_, err = store.GetEntry(ctx, accountID, entryID)
-	if !errors.As(err, &operationError) {
-		test.Fatalf("expected operation error, got %v", err)
-	}
-	if operationError.Subject() != errorSubjectEntry || operationError.Code() != errorCodeGet {
-		test.Fatalf("unexpected operation error: %s.%s.%s", operationError.Operation(), operationError.Subject(), operationError.Code())
+	if userLine.UserAccountID == nil || *userLine.UserAccountID != accountID.String() {
+		test.Fatalf("expected user line to reference the account, got %+v", userLine)
 	}
+}
 
-	_, err = store.GetEntryByIdempotencyKey(ctx, accountID, idempotencyKey)
-	if !errors.As(err, &operationError) {
-		test.Fatalf("expected operation error, got %v", err)
+func TestStoreGrantConsumptionAndJournalRejectCorruptRows(test *testing.T) {
+	test.Parallel()
+	testCases := []struct {
+		name      string
+		statement string
+		read      func(context.Context, *Store, ledger.AccountID) error
+	}{
+		{
+			name:      "consumable grant consumed cents",
+			statement: "UPDATE ledger_entries SET consumed_cents = -1",
+			read: func(ctx context.Context, store *Store, accountID ledger.AccountID) error {
+				_, err := store.ListConsumableGrants(ctx, accountID, 1700000000)
+				return err
+			},
+		},
+		{
+			name:      "expiry total consumed cents",
+			statement: "UPDATE ledger_entries SET consumed_cents = -1",
+			read: func(ctx context.Context, store *Store, _ ledger.AccountID) error {
+				_, err := store.SumEntriesByExpiry(ctx, mustTenantID(test), 1700000000)
+				return err
+			},
+		},
+		{
+			name:      "expiry total ledger id",
+			statement: "UPDATE accounts SET ledger_id = ' '",
+			read: func(ctx context.Context, store *Store, _ ledger.AccountID) error {
+				_, err := store.SumEntriesByExpiry(ctx, mustTenantID(test), 1700000000)
+				return err
+			},
+		},
+		{
+			name:      "journal ledger id",
+			statement: "UPDATE journal_lines SET ledger_id = ' '",
+			read: func(ctx context.Context, store *Store, _ ledger.AccountID) error {
+				_, err := store.SumJournalLines(ctx, mustTenantID(test), 1700000000)
+				return err
+			},
+		},
+		{
+			name:      "journal account",
+			statement: "UPDATE journal_lines SET account = 'bogus'",
+			read: func(ctx context.Context, store *Store, _ ledger.AccountID) error {
+				_, err := store.SumJournalLines(ctx, mustTenantID(test), 1700000000)
+				return err
+			},
+		},
 	}
-	if operationError.Subject() != errorSubjectEntry || operationError.Code() != errorCodeGet {
-		test.Fatalf("unexpected operation error: %s.%s.%s", operationError.Operation(), operationError.Subject(), operationError.Code())
+	for _, testCase := range testCases {
+		test.Run(testCase.name, func(test *testing.T) {
+			test.Parallel()
+			db := newSQLiteDB(test)
+			store := New(db)
+			ctx := context.Background()
+			accountID, err := store.GetOrCreateAccountID(ctx, mustTenantID(test), mustUserID(test), mustLedgerID(test))
+			if err != nil {
+				test.Fatalf("account: %v", err)
+			}
+			idempotencyKey, err := ledger.NewIdempotencyKey("grant-expiring")
+			if err != nil {
+				test.Fatalf("idempotency: %v", err)
+			}
+			metadata, err := ledger.NewMetadataJSON("{}")
+			if err != nil {
+				test.Fatalf("metadata: %v", err)
+			}
+			entryInput, err := ledger.NewEntryInput(accountID, ledger.EntryGrant, ledger.EntryAmountCents(100), nil, nil, idempotencyKey, 1700003600, metadata, 1699990000)
+			if err != nil {
+				test.Fatalf("entry input: %v", err)
+			}
+			entry, err := store.InsertEntry(ctx, entryInput)
+			if err != nil {
+				test.Fatalf("insert entry: %v", err)
+			}
+			journalLine := ledger.JournalLine{
+				TenantID:      mustTenantID(test),
+				LedgerID:      mustLedgerID(test),
+				EntryID:       entry.EntryID(),
+				Account:       ledger.JournalAccountIssuance,
+				AmountCents:   -100,
+				PostedUnixUTC: 1699990000,
+			}
+			if err := store.InsertJournalLines(ctx, []ledger.JournalLine{journalLine}); err != nil {
+				test.Fatalf("insert journal line: %v", err)
+			}
+			if err := db.WithContext(ctx).Exec(testCase.statement).Error; err != nil {
+				test.Fatalf("corrupt rows: %v", err)
+			}
+
+			err = testCase.read(ctx, store, accountID)
+			var operationError ledger.OperationError
+			if !errors.As(err, &operationError) {
+				test.Fatalf("expected operation error, got %v", err)
+			}
+			if operationError.Code() != errorCodeInvalid {
+				test.Fatalf("expected code %q, got %q", errorCodeInvalid, operationError.Code())
+			}
+		})
 	}
 }
 
-func TestStoreGetOrCreateAccountIDRejectsInvalidAccountID(test *testing.T) {
+func TestStoreEntryChangesFollowCommitOrderPerTenant(test *testing.T) {
 	test.Parallel()
 	db := newSQLiteDB(test)
 	store := New(db)
 
-	account := Account{
-		AccountID: " ",
-		TenantID:  mustTenantID(test).String(),
-		UserID:    mustUserID(test).String(),
-		LedgerID:  mustLedgerID(test).String(),
-		CreatedAt: time.Now().UTC(),
+	ctx := context.Background()
+	tenantID := mustTenantID(test)
+	ledgerID := mustLedgerID(test)
+	otherTenantID, err := ledger.NewTenantID("other")
+	if err != nil {
+		test.Fatalf("tenant id: %v", err)
 	}
-	if err := db.WithContext(context.Background()).Create(&account).Error; err != nil {
-		test.Fatalf("create account: %v", err)
+	otherUserID, err := ledger.NewUserID("user-456")
+	if err != nil {
+		test.Fatalf("user id: %v", err)
 	}
-
-	_, err := store.GetOrCreateAccountID(context.Background(), mustTenantID(test), mustUserID(test), mustLedgerID(test))
-	var operationError ledger.OperationError
-	if !errors.As(err, &operationError) {
-		test.Fatalf("expected operation error, got %v", err)
+	metadata, err := ledger.NewMetadataJSON("{}")
+	if err != nil {
+		test.Fatalf("metadata: %v", err)
 	}
-	if operationError.Subject() != errorSubjectAccount || operationError.Code() != errorCodeInvalid {
-		test.Fatalf("unexpected operation error: %s.%s.%s", operationError.Operation(), operationError.Subject(), operationError.Code())
+	insert := func(entryTenantID ledger.TenantID, userID ledger.UserID, rawKey string, entryType ledger.EntryType, rawAmount int64, failAfter bool) {
+		test.Helper()
+		rollback := errors.New("rollback")
+		err := store.WithTx(ctx, func(ctx context.Context, txStore ledger.Store) error {
+			accountID, err := txStore.GetOrCreateAccountID(ctx, entryTenantID, userID, ledgerID)
+			if err != nil {
+				return err
+			}
+			idempotencyKey, err := ledger.NewIdempotencyKey(rawKey)
+			if err != nil {
+				return err
+			}
+			amount, err := ledger.NewEntryAmountCents(rawAmount)
+			if err != nil {
+				return err
+			}
+			entryInput, err := ledger.NewEntryInput(accountID, entryType, amount, nil, nil, idempotencyKey, 0, metadata, 1700000000)
+			if err != nil {
+				return err
+			}
+			entry, err := txStore.InsertEntry(ctx, entryInput)
+			if err != nil {
+				return err
+			}
+			if err := txStore.(*Store).AppendEntryChange(ctx, entryTenantID, entry.EntryID()); err != nil {
+				return err
+			}
+			if failAfter {
+				return rollback
+			}
+			return nil
+		})
+		if err != nil && !(failAfter && errors.Is(err, rollback)) {
+			test.Fatalf("insert %s: %v", rawKey, err)
+		}
+	}
+	insert(tenantID, mustUserID(test), "grant-1", ledger.EntryGrant, 1000, false)
+	insert(otherTenantID, mustUserID(test), "other-grant", ledger.EntryGrant, 500, false)
+	insert(tenantID, otherUserID, "grant-2", ledger.EntryGrant, 700, false)
+	insert(tenantID, mustUserID(test), "rolled-back", ledger.EntryGrant, 50, true)
+	insert(tenantID, mustUserID(test), "spend-1", ledger.EntrySpend, -200, false)
+
+	changes, err := store.ListEntryChanges(ctx, tenantID, 0, 10, ledger.EntryChangeFilter{})
+	if err != nil {
+		test.Fatalf("list entry changes: %v", err)
+	}
+	expectedKeys := []string{"grant-1", "grant-2", "spend-1"}
+	if len(changes) != len(expectedKeys) {
+		test.Fatalf("expected %d changes, got %+v", len(expectedKeys), changes)
+	}
+	for index, change := range changes {
+		if change.Cursor != int64(index+1) || change.Entry.IdempotencyKey().String() != expectedKeys[index] || change.LedgerID != ledgerID {
+			test.Fatalf("change %d: unexpected %+v", index, change)
+		}
+	}
+	if changes[1].UserID != otherUserID {
+		test.Fatalf("expected change to carry its user, got %s", changes[1].UserID)
+	}
+
+	resumed, err := store.ListEntryChanges(ctx, tenantID, 1, 1, ledger.EntryChangeFilter{})
+	if err != nil {
+		test.Fatalf("resume entry changes: %v", err)
+	}
+	if len(resumed) != 1 || resumed[0].Cursor != 2 {
+		test.Fatalf("expected to resume after cursor 1, got %+v", resumed)
+	}
+	userID := mustUserID(test)
+	filtered, err := store.ListEntryChanges(ctx, tenantID, 0, 10, ledger.EntryChangeFilter{UserID: &userID, Types: []ledger.EntryType{ledger.EntrySpend}})
+	if err != nil {
+		test.Fatalf("filter entry changes: %v", err)
+	}
+	if len(filtered) != 1 || filtered[0].Cursor != 3 {
+		test.Fatalf("expected the spend only, got %+v", filtered)
+	}
+	otherChanges, err := store.ListEntryChanges(ctx, otherTenantID, 0, 10, ledger.EntryChangeFilter{LedgerID: &ledgerID})
+	if err != nil {
+		test.Fatalf("list other tenant changes: %v", err)
+	}
+	if len(otherChanges) != 1 || otherChanges[0].Cursor != 1 {
+		test.Fatalf("expected sequences to be numbered per tenant, got %+v", otherChanges)
+	}
+}
+
+func TestStoreEntryFeedWrapsErrors(test *testing.T) {
+	test.Parallel()
+	ctx := context.Background()
+	tenantID := mustTenantID(test)
+	expectFeedError := func(test *testing.T, err error, code string) {
+		test.Helper()
+		var operationError ledger.OperationError
+		if !errors.As(err, &operationError) {
+			test.Fatalf("expected operation error, got %v", err)
+		}
+		if operationError.Subject() != errorSubjectEntryFeed || operationError.Code() != code {
+			test.Fatalf("unexpected operation error: %s.%s.%s", operationError.Operation(), operationError.Subject(), operationError.Code())
+		}
+	}
+	insertChangedEntry := func(test *testing.T, store *Store) ledger.Entry {
+		test.Helper()
+		accountID, err := store.GetOrCreateAccountID(ctx, tenantID, mustUserID(test), mustLedgerID(test))
+		if err != nil {
+			test.Fatalf("account: %v", err)
+		}
+		idempotencyKey, keyErr := ledger.NewIdempotencyKey("grant-1")
+		amount, amountErr := ledger.NewEntryAmountCents(100)
+		metadata, metadataErr := ledger.NewMetadataJSON("{}")
+		if err := errors.Join(keyErr, amountErr, metadataErr); err != nil {
+			test.Fatalf("entry values: %v", err)
+		}
+		entryInput, err := ledger.NewEntryInput(accountID, ledger.EntryGrant, amount, nil, nil, idempotencyKey, 0, metadata, 1700000000)
+		if err != nil {
+			test.Fatalf("entry input: %v", err)
+		}
+		entry, err := store.InsertEntry(ctx, entryInput)
+		if err != nil {
+			test.Fatalf("insert entry: %v", err)
+		}
+		if err := store.AppendEntryChange(ctx, tenantID, entry.EntryID()); err != nil {
+			test.Fatalf("append entry change: %v", err)
+		}
+		return entry
+	}
+
+	test.Run("closed database", func(test *testing.T) {
+		test.Parallel()
+		db := newSQLiteDB(test)
+		sqlDB, err := db.DB()
+		if err != nil {
+			test.Fatalf("sql db: %v", err)
+		}
+		if err := sqlDB.Close(); err != nil {
+			test.Fatalf("close db: %v", err)
+		}
+		_, err = New(db).ListEntryChanges(ctx, tenantID, 0, 10, ledger.EntryChangeFilter{})
+		expectFeedError(test, err, errorCodeList)
+	})
+
+	test.Run("feed heads missing", func(test *testing.T) {
+		test.Parallel()
+		db := newSQLiteDB(test)
+		if err := db.Exec("DROP TABLE entry_feed_heads").Error; err != nil {
+			test.Fatalf("drop table: %v", err)
+		}
+		entryID, err := ledger.NewEntryID("entry-1")
+		if err != nil {
+			test.Fatalf("entry id: %v", err)
+		}
+		expectFeedError(test, New(db).AppendEntryChange(ctx, tenantID, entryID), errorCodeSequence)
+	})
+
+	test.Run("head row missing after upsert", func(test *testing.T) {
+		test.Parallel()
+		db := newSQLiteDB(test)
+		if err := db.Exec("CREATE TRIGGER drop_feed_head AFTER INSERT ON entry_feed_heads BEGIN DELETE FROM entry_feed_heads WHERE tenant_id = NEW.tenant_id; END").Error; err != nil {
+			test.Fatalf("create trigger: %v", err)
+		}
+		entryID, err := ledger.NewEntryID("entry-1")
+		if err != nil {
+			test.Fatalf("entry id: %v", err)
+		}
+		expectFeedError(test, New(db).AppendEntryChange(ctx, tenantID, entryID), errorCodeSequence)
+	})
+
+	test.Run("entry appended twice", func(test *testing.T) {
+		test.Parallel()
+		store := New(newSQLiteDB(test))
+		entry := insertChangedEntry(test, store)
+		expectFeedError(test, store.AppendEntryChange(ctx, tenantID, entry.EntryID()), errorCodeInsert)
+	})
+
+	for _, corruption := range []struct {
+		name      string
+		statement string
+	}{
+		{name: "corrupt user id", statement: "UPDATE accounts SET user_id = ''"},
+		{name: "corrupt ledger id", statement: "UPDATE accounts SET ledger_id = ''"},
+		{name: "corrupt entry", statement: "UPDATE ledger_entries SET type = 'bogus'"},
+	} {
+		test.Run(corruption.name, func(test *testing.T) {
+			test.Parallel()
+			db := newSQLiteDB(test)
+			store := New(db)
+			insertChangedEntry(test, store)
+			if err := db.Exec(corruption.statement).Error; err != nil {
+				test.Fatalf("corrupt row: %v", err)
+			}
+			_, err := store.ListEntryChanges(ctx, tenantID, 0, 10, ledger.EntryChangeFilter{})
+			expectFeedError(test, err, errorCodeInvalid)
+		})
+	}
+}
+
+func TestStoreChainLinksEntriesAndDetectsTampering(test *testing.T) {
+	test.Parallel()
+	db := newSQLiteDB(test)
+	service, err := ledger.NewService(New(db), func() int64 { return 1700000000 })
+	if err != nil {
+		test.Fatalf("new service: %v", err)
+	}
+	ctx := context.Background()
+	tenantID := mustTenantID(test)
+	userID := mustUserID(test)
+	ledgerID := mustLedgerID(test)
+	metadata, err := ledger.NewMetadataJSON(`{"source": "test", "attempt": 1}`)
+	if err != nil {
+		test.Fatalf("metadata: %v", err)
+	}
+	amount, err := ledger.NewPositiveAmountCents(100)
+	if err != nil {
+		test.Fatalf("amount: %v", err)
+	}
+	for _, rawKey := range []string{"grant-1", "grant-2", "grant-3"} {
+		idempotencyKey, err := ledger.NewIdempotencyKey(rawKey)
+		if err != nil {
+			test.Fatalf("idempotency key: %v", err)
+		}
+		if err := service.Grant(ctx, tenantID, userID, ledgerID, amount, idempotencyKey, 0, metadata); err != nil {
+			test.Fatalf("grant %s: %v", rawKey, err)
+		}
+	}
+
+	verification, err := service.VerifyChain(ctx, tenantID, userID, ledgerID)
+	if err != nil {
+		test.Fatalf("verify chain: %v", err)
+	}
+	if !verification.Valid() || verification.HeadSequence != 3 || verification.CheckedEntries != 3 {
+		test.Fatalf("expected intact chain of three entries, got %+v", verification)
+	}
+
+	if err := db.Exec("UPDATE ledger_entries SET metadata = ? WHERE idempotency_key = ?", `{"source":"edited"}`, "grant-3").Error; err != nil {
+		test.Fatalf("tamper metadata: %v", err)
+	}
+	verification, err = service.VerifyChain(ctx, tenantID, userID, ledgerID)
+	if err != nil {
+		test.Fatalf("verify tampered chain: %v", err)
+	}
+	if verification.Valid() || verification.Break.Sequence != 3 || verification.Break.Reason != ledger.ChainBreakHashMismatch {
+		test.Fatalf("expected hash mismatch at sequence 3, got %+v", verification.Break)
+	}
+
+	if err := db.Exec("DELETE FROM ledger_entries WHERE idempotency_key = ?", "grant-2").Error; err != nil {
+		test.Fatalf("delete entry: %v", err)
+	}
+	verification, err = service.VerifyChain(ctx, tenantID, userID, ledgerID)
+	if err != nil {
+		test.Fatalf("verify chain with deleted entry: %v", err)
+	}
+	if verification.Valid() || verification.Break.Sequence != 2 || verification.Break.Reason != ledger.ChainBreakMissingEntry || verification.CheckedEntries != 1 {
+		test.Fatalf("expected missing entry at sequence 2, got %+v", verification)
+	}
+}
+
+func TestStoreChainAndArchiveRejectBrokenTablesAndCorruptRows(test *testing.T) {
+	test.Parallel()
+	testCases := []struct {
+		name            string
+		statement       string
+		run             func(context.Context, *Store, ledger.Entry) error
+		expectedSubject string
+		expectedCode    string
+	}{
+		{
+			name:      "chain head vanishes after lock",
+			statement: "CREATE TRIGGER drop_chain_head AFTER INSERT ON account_chain_heads BEGIN DELETE FROM account_chain_heads WHERE account_id = NEW.account_id; END",
+			run: func(ctx context.Context, store *Store, _ ledger.Entry) error {
+				accountID, err := ledger.NewAccountID("account-unchained")
+				if err != nil {
+					return err
+				}
+				_, err = store.LockChainHead(ctx, accountID)
+				return err
+			},
+			expectedSubject: errorSubjectChain,
+			expectedCode:    errorCodeLock,
+		},
+		{
+			name:      "entry link update",
+			statement: "ALTER TABLE ledger_entries DROP COLUMN entry_hash",
+			run: func(ctx context.Context, store *Store, entry ledger.Entry) error {
+				return store.AppendChainLink(ctx, entry.AccountID(), ledger.ChainLink{EntryID: entry.EntryID(), Sequence: 2, Hash: "next"})
+			},
+			expectedSubject: errorSubjectChain,
+			expectedCode:    errorCodeUpdate,
+		},
+		{
+			name:      "chain head update",
+			statement: "DROP TABLE account_chain_heads",
+			run: func(ctx context.Context, store *Store, entry ledger.Entry) error {
+				return store.AppendChainLink(ctx, entry.AccountID(), ledger.ChainLink{EntryID: entry.EntryID(), Sequence: 2, Hash: "next"})
+			},
+			expectedSubject: errorSubjectChain,
+			expectedCode:    errorCodeUpdate,
+		},
+		{
+			name:      "chained entry actor",
+			statement: "UPDATE ledger_entries SET actor_request_id = '" + strings.Repeat("r", 257) + "'",
+			run: func(ctx context.Context, store *Store, entry ledger.Entry) error {
+				_, err := store.ListChainedEntries(ctx, entry.AccountID(), 0, 10)
+				return err
+			},
+			expectedSubject: errorSubjectChain,
+			expectedCode:    errorCodeInvalid,
+		},
+		{
+			name:      "archived key tombstone",
+			statement: "DROP TABLE archived_entry_keys",
+			run: func(ctx context.Context, store *Store, entry ledger.Entry) error {
+				return store.ArchiveEntries(ctx, ledger.EntryArchive{AccountID: entry.AccountID(), ArchivedEntries: 1}, []ledger.EntryID{entry.EntryID()}, nil)
+			},
+			expectedSubject: errorSubjectArchive,
+			expectedCode:    errorCodeTombstone,
+		},
+		{
+			name:      "archived key lookup",
+			statement: "DROP TABLE archived_entry_keys",
+			run: func(ctx context.Context, store *Store, entry ledger.Entry) error {
+				entryID, err := ledger.NewEntryID("entry-missing")
+				if err != nil {
+					return err
+				}
+				_, err = store.GetEntry(ctx, entry.AccountID(), entryID)
+				return err
+			},
+			expectedSubject: errorSubjectEntry,
+			expectedCode:    errorCodeGet,
+		},
+		{
+			name:      "entry delete",
+			statement: "CREATE TRIGGER keep_entries BEFORE DELETE ON ledger_entries BEGIN SELECT RAISE(ABORT, 'entries are kept'); END",
+			run: func(ctx context.Context, store *Store, entry ledger.Entry) error {
+				return store.ArchiveEntries(ctx, ledger.EntryArchive{AccountID: entry.AccountID(), ArchivedEntries: 1}, []ledger.EntryID{entry.EntryID()}, nil)
+			},
+			expectedSubject: errorSubjectArchive,
+			expectedCode:    errorCodeDelete,
+		},
+		{
+			name:      "reservation delete",
+			statement: "CREATE TRIGGER keep_reservations BEFORE DELETE ON reservations BEGIN SELECT RAISE(ABORT, 'reservations are kept'); END",
+			run: func(ctx context.Context, store *Store, entry ledger.Entry) error {
+				reservationID, err := ledger.NewReservationID("order-1")
+				if err != nil {
+					return err
+				}
+				amount, err := ledger.NewPositiveAmountCents(10)
+				if err != nil {
+					return err
+				}
+				reservation, err := ledger.NewReservation(entry.AccountID(), reservationID, amount, ledger.ReservationStatusCaptured, 0)
+				if err != nil {
+					return err
+				}
+				if err := store.CreateReservation(ctx, reservation); err != nil {
+					return err
+				}
+				return store.ArchiveEntries(ctx, ledger.EntryArchive{AccountID: entry.AccountID()}, nil, []ledger.ReservationID{reservationID})
+			},
+			expectedSubject: errorSubjectArchive,
+			expectedCode:    errorCodeDelete,
+		},
+		{
+			name:      "archive record write",
+			statement: "DROP TABLE entry_archives",
+			run: func(ctx context.Context, store *Store, entry ledger.Entry) error {
+				return store.ArchiveEntries(ctx, ledger.EntryArchive{AccountID: entry.AccountID(), ArchivedEntries: 1}, []ledger.EntryID{entry.EntryID()}, nil)
+			},
+			expectedSubject: errorSubjectArchive,
+			expectedCode:    errorCodeUpdate,
+		},
+		{
+			name:      "archive record read",
+			statement: "DROP TABLE entry_archives",
+			run: func(ctx context.Context, store *Store, entry ledger.Entry) error {
+				_, err := store.GetEntryArchive(ctx, entry.AccountID())
+				return err
+			},
+			expectedSubject: errorSubjectArchive,
+			expectedCode:    errorCodeGet,
+		},
+	}
+	for _, testCase := range testCases {
+		test.Run(testCase.name, func(test *testing.T) {
+			test.Parallel()
+			db := newSQLiteDB(test)
+			store := New(db)
+			service, err := ledger.NewService(store, func() int64 { return 1700000000 })
+			if err != nil {
+				test.Fatalf("new service: %v", err)
+			}
+			ctx := context.Background()
+			amount, err := ledger.NewPositiveAmountCents(100)
+			if err != nil {
+				test.Fatalf("amount: %v", err)
+			}
+			idempotencyKey, err := ledger.NewIdempotencyKey("grant-1")
+			if err != nil {
+				test.Fatalf("idempotency key: %v", err)
+			}
+			metadata, err := ledger.NewMetadataJSON("{}")
+			if err != nil {
+				test.Fatalf("metadata: %v", err)
+			}
+			entry, err := service.GrantEntry(ctx, mustTenantID(test), mustUserID(test), mustLedgerID(test), amount, idempotencyKey, 0, metadata)
+			if err != nil {
+				test.Fatalf("grant: %v", err)
+			}
+			if err := db.Exec(testCase.statement).Error; err != nil {
+				test.Fatalf("prepare: %v", err)
+			}
+			var operationError ledger.OperationError
+			if err := testCase.run(ctx, store, entry); !errors.As(err, &operationError) {
+				test.Fatalf("expected operation error, got %v", err)
+			}
+			if operationError.Subject() != testCase.expectedSubject || operationError.Code() != testCase.expectedCode {
+				test.Fatalf("unexpected operation error: %s.%s.%s", operationError.Operation(), operationError.Subject(), operationError.Code())
+			}
+		})
+	}
+}
+
+func TestStoreSumEntriesByExpiryAggregatesTenantLedgers(test *testing.T) {
+	test.Parallel()
+	db := newSQLiteDB(test)
+	store := New(db)
+
+	ctx := context.Background()
+	tenantID := mustTenantID(test)
+	ledgerID := mustLedgerID(test)
+	promoLedgerID, err := ledger.NewLedgerID("promo")
+	if err != nil {
+		test.Fatalf("ledger id: %v", err)
+	}
+	otherTenantID, err := ledger.NewTenantID("other")
+	if err != nil {
+		test.Fatalf("tenant id: %v", err)
+	}
+	accountID, err := store.GetOrCreateAccountID(ctx, tenantID, mustUserID(test), ledgerID)
+	if err != nil {
+		test.Fatalf("account: %v", err)
+	}
+	promoAccountID, err := store.GetOrCreateAccountID(ctx, tenantID, mustUserID(test), promoLedgerID)
+	if err != nil {
+		test.Fatalf("promo account: %v", err)
+	}
+	otherAccountID, err := store.GetOrCreateAccountID(ctx, otherTenantID, mustUserID(test), ledgerID)
+	if err != nil {
+		test.Fatalf("other account: %v", err)
+	}
+	metadata, err := ledger.NewMetadataJSON("{}")
+	if err != nil {
+		test.Fatalf("metadata: %v", err)
+	}
+	insert := func(entryAccountID ledger.AccountID, rawKey string, entryType ledger.EntryType, rawAmount int64, expiresAtUnixUTC int64, effectiveAtUnixUTC int64) {
+		test.Helper()
+		idempotencyKey, err := ledger.NewIdempotencyKey(rawKey)
+		if err != nil {
+			test.Fatalf("idempotency key: %v", err)
+		}
+		amount, err := ledger.NewEntryAmountCents(rawAmount)
+		if err != nil {
+			test.Fatalf("amount: %v", err)
+		}
+		entryInput, err := ledger.NewEntryInput(entryAccountID, entryType, amount, nil, nil, idempotencyKey, expiresAtUnixUTC, metadata, 1700000000)
+		if err != nil {
+			test.Fatalf("entry input: %v", err)
+		}
+		if _, err := store.InsertEntry(ctx, entryInput.WithEffectiveAtUnixUTC(effectiveAtUnixUTC)); err != nil {
+			test.Fatalf("insert entry: %v", err)
+		}
+	}
+	const expiredAt = int64(1700000500)
+	const expiresAt = int64(1700100000)
+	insert(accountID, "grant", ledger.EntryGrant, 1000, 0, 0)
+	insert(accountID, "spend", ledger.EntrySpend, -300, 0, 0)
+	insert(accountID, "grant-expiring", ledger.EntryGrant, 500, expiresAt, 0)
+	insert(accountID, "grant-expired", ledger.EntryGrant, 200, expiredAt, 0)
+	insert(accountID, "grant-scheduled", ledger.EntryGrant, 400, 0, 1700002000)
+	insert(accountID, "grant-effective-after-expiry", ledger.EntryGrant, 600, expiredAt, expiredAt)
+	insert(accountID, "hold", ledger.EntryHold, -50, 0, 0)
+	insert(promoAccountID, "promo-grant", ledger.EntryGrant, 70, expiresAt, 0)
+	insert(otherAccountID, "other-grant", ledger.EntryGrant, 999, 0, 0)
+
+	totals, err := store.SumEntriesByExpiry(ctx, tenantID, 1700001000)
+	if err != nil {
+		test.Fatalf("sum entries by expiry: %v", err)
+	}
+	type totalKey struct {
+		ledgerID  string
+		expiresAt int64
+	}
+	expected := map[totalKey]int64{
+		{ledgerID: "default", expiresAt: 0}:         700,
+		{ledgerID: "default", expiresAt: expiresAt}: 500,
+		{ledgerID: "default", expiresAt: expiredAt}: 200,
+		{ledgerID: "promo", expiresAt: expiresAt}:   70,
+	}
+	if len(totals) != len(expected) {
+		test.Fatalf("expected %d totals, got %+v", len(expected), totals)
+	}
+	for _, total := range totals {
+		key := totalKey{ledgerID: total.LedgerID.String(), expiresAt: total.ExpiresAtUnixUTC}
+		if amount, ok := expected[key]; !ok || total.AmountCents.Int64() != amount {
+			test.Fatalf("unexpected total: %+v", total)
+		}
+	}
+}
+
+func TestStoreSumDebitVelocityNetsHoldsAndCountsDebits(test *testing.T) {
+	test.Parallel()
+	db := newSQLiteDB(test)
+	store := New(db)
+
+	ctx := context.Background()
+	accountID, err := store.GetOrCreateAccountID(ctx, mustTenantID(test), mustUserID(test), mustLedgerID(test))
+	if err != nil {
+		test.Fatalf("account: %v", err)
+	}
+	metadata, err := ledger.NewMetadataJSON("{}")
+	if err != nil {
+		test.Fatalf("metadata: %v", err)
+	}
+	insert := func(rawKey string, entryType ledger.EntryType, rawAmount int64, rawReservationID string, createdUnixUTC int64) {
+		test.Helper()
+		idempotencyKey, err := ledger.NewIdempotencyKey(rawKey)
+		if err != nil {
+			test.Fatalf("idempotency key: %v", err)
+		}
+		amount, err := ledger.NewEntryAmountCents(rawAmount)
+		if err != nil {
+			test.Fatalf("amount: %v", err)
+		}
+		var reservationID *ledger.ReservationID
+		if rawReservationID != "" {
+			parsed, err := ledger.NewReservationID(rawReservationID)
+			if err != nil {
+				test.Fatalf("reservation id: %v", err)
+			}
+			reservationID = &parsed
+		}
+		entryInput, err := ledger.NewEntryInput(accountID, entryType, amount, reservationID, nil, idempotencyKey, 0, metadata, createdUnixUTC)
+		if err != nil {
+			test.Fatalf("entry input: %v", err)
+		}
+		if _, err := store.InsertEntry(ctx, entryInput); err != nil {
+			test.Fatalf("insert entry: %v", err)
+		}
+	}
+	insert("grant", ledger.EntryGrant, 5000, "", 1700000000)
+	insert("old-spend", ledger.EntrySpend, -700, "", 1700000000)
+	insert("spend", ledger.EntrySpend, -100, "", 1700000100)
+	insert("hold-captured", ledger.EntryHold, -200, "order-1", 1700000110)
+	insert("release-captured", ledger.EntryReverseHold, 200, "order-1", 1700000120)
+	insert("capture", ledger.EntrySpend, -200, "order-1", 1700000120)
+	insert("hold-released", ledger.EntryHold, -300, "order-2", 1700000130)
+	insert("release-released", ledger.EntryReverseHold, 300, "order-2", 1700000140)
+
+	velocity, err := store.SumDebitVelocity(ctx, accountID, 1700000050)
+	if err != nil {
+		test.Fatalf("sum debit velocity: %v", err)
+	}
+	if velocity.AmountCents.Int64() != 300 {
+		test.Fatalf("expected 300 cents of debits, got %d", velocity.AmountCents.Int64())
+	}
+	if velocity.Operations != 3 {
+		test.Fatalf("expected 3 debit operations, got %d", velocity.Operations)
+	}
+	velocity, err = store.SumDebitVelocity(ctx, accountID, 1700000000)
+	if err != nil {
+		test.Fatalf("sum debit velocity with older entries: %v", err)
+	}
+	if velocity.AmountCents.Int64() != 1000 || velocity.Operations != 4 {
+		test.Fatalf("expected 1000 cents over 4 operations, got %d over %d", velocity.AmountCents.Int64(), velocity.Operations)
+	}
+}
+
+func TestStoreWrapsDatabaseErrors(test *testing.T) {
+	test.Parallel()
+	db := newSQLiteDB(test)
+	sqlDB, err := db.DB()
+	if err != nil {
+		test.Fatalf("sql db: %v", err)
+	}
+	if err := sqlDB.Close(); err != nil {
+		test.Fatalf("close db: %v", err)
+	}
+	store := New(db)
+	ctx := context.Background()
+
+	_, err = store.GetOrCreateAccountID(ctx, mustTenantID(test), mustUserID(test), mustLedgerID(test))
+	var operationError ledger.OperationError
+	if !errors.As(err, &operationError) {
+		test.Fatalf("expected operation error, got %v", err)
+	}
+	if operationError.Subject() != errorSubjectAccount || operationError.Code() != errorCodeLookup {
+		test.Fatalf("unexpected operation error: %s.%s.%s", operationError.Operation(), operationError.Subject(), operationError.Code())
+	}
+
+	accountID, err := ledger.NewAccountID("account-1")
+	if err != nil {
+		test.Fatalf("account id: %v", err)
+	}
+	amount, err := ledger.NewPositiveAmountCents(100)
+	if err != nil {
+		test.Fatalf("amount: %v", err)
+	}
+	idempotencyKey, err := ledger.NewIdempotencyKey("grant-1")
+	if err != nil {
+		test.Fatalf("idempotency: %v", err)
+	}
+	metadata, err := ledger.NewMetadataJSON("{}")
+	if err != nil {
+		test.Fatalf("metadata: %v", err)
+	}
+	entryInput, err := ledger.NewEntryInput(
+		accountID,
+		ledger.EntryGrant,
+		amount.ToEntryAmountCents(),
+		nil,
+		nil,
+		idempotencyKey,
+		0,
+		metadata,
+		time.Now().UTC().Unix(),
+	)
+	if err != nil {
+		test.Fatalf("entry input: %v", err)
+	}
+	_, err = store.InsertEntry(ctx, entryInput)
+	if !errors.As(err, &operationError) {
+		test.Fatalf("expected operation error, got %v", err)
+	}
+	if operationError.Subject() != errorSubjectEntry || operationError.Code() != errorCodeInsert {
+		test.Fatalf("unexpected operation error: %s.%s.%s", operationError.Operation(), operationError.Subject(), operationError.Code())
+	}
+
+	_, err = store.SumTotal(ctx, accountID, time.Now().UTC().Unix())
+	if !errors.As(err, &operationError) {
+		test.Fatalf("expected operation error, got %v", err)
+	}
+	if operationError.Subject() != errorSubjectBalance || operationError.Code() != errorCodeSumTotal {
+		test.Fatalf("unexpected operation error: %s.%s.%s", operationError.Operation(), operationError.Subject(), operationError.Code())
+	}
+
+	_, err = store.SumActiveHolds(ctx, accountID, time.Now().UTC().Unix())
+	if !errors.As(err, &operationError) {
+		test.Fatalf("expected operation error, got %v", err)
+	}
+	if operationError.Subject() != errorSubjectBalance || operationError.Code() != errorCodeSumActiveHolds {
+		test.Fatalf("unexpected operation error: %s.%s.%s", operationError.Operation(), operationError.Subject(), operationError.Code())
+	}
+
+	_, err = store.SumDebitVelocity(ctx, accountID, time.Now().UTC().Unix())
+	if !errors.As(err, &operationError) {
+		test.Fatalf("expected operation error, got %v", err)
+	}
+	if operationError.Subject() != errorSubjectBalance || operationError.Code() != errorCodeSumDebitVelocity {
+		test.Fatalf("unexpected operation error: %s.%s.%s", operationError.Operation(), operationError.Subject(), operationError.Code())
+	}
+
+	_, err = store.GetAccountID(ctx, mustTenantID(test), mustUserID(test), mustLedgerID(test))
+	if !errors.As(err, &operationError) {
+		test.Fatalf("expected operation error, got %v", err)
+	}
+	if operationError.Subject() != errorSubjectAccount || operationError.Code() != errorCodeGet {
+		test.Fatalf("unexpected operation error: %s.%s.%s", operationError.Operation(), operationError.Subject(), operationError.Code())
+	}
+
+	_, err = store.ListAccounts(ctx, mustTenantID(test), 0, 10, ledger.ListAccountsFilter{})
+	if !errors.As(err, &operationError) {
+		test.Fatalf("expected operation error, got %v", err)
+	}
+	if operationError.Subject() != errorSubjectAccount || operationError.Code() != errorCodeList {
+		test.Fatalf("unexpected operation error: %s.%s.%s", operationError.Operation(), operationError.Subject(), operationError.Code())
+	}
+
+	_, err = store.SumEntriesByExpiry(ctx, mustTenantID(test), time.Now().UTC().Unix())
+	if !errors.As(err, &operationError) {
+		test.Fatalf("expected operation error, got %v", err)
+	}
+	if operationError.Subject() != errorSubjectBalance || operationError.Code() != errorCodeSumEntriesByExpiry {
+		test.Fatalf("unexpected operation error: %s.%s.%s", operationError.Operation(), operationError.Subject(), operationError.Code())
+	}
+
+	_, err = store.ListConsumableGrants(ctx, accountID, time.Now().UTC().Unix())
+	if !errors.As(err, &operationError) {
+		test.Fatalf("expected operation error, got %v", err)
+	}
+	if operationError.Subject() != errorSubjectEntry || operationError.Code() != errorCodeList {
+		test.Fatalf("unexpected operation error: %s.%s.%s", operationError.Operation(), operationError.Subject(), operationError.Code())
+	}
+
+	grantEntryID, err := ledger.NewEntryID("grant-1")
+	if err != nil {
+		test.Fatalf("entry id: %v", err)
+	}
+	err = store.ConsumeGrant(ctx, accountID, grantEntryID, 10)
+	if !errors.As(err, &operationError) {
+		test.Fatalf("expected operation error, got %v", err)
+	}
+	if operationError.Subject() != errorSubjectEntry || operationError.Code() != errorCodeConsumeGrant {
+		test.Fatalf("unexpected operation error: %s.%s.%s", operationError.Operation(), operationError.Subject(), operationError.Code())
+	}
+
+	err = store.InsertJournalLines(ctx, []ledger.JournalLine{{TenantID: mustTenantID(test), LedgerID: mustLedgerID(test), EntryID: grantEntryID, Account: ledger.JournalAccountIssuance, AmountCents: -10}})
+	if !errors.As(err, &operationError) {
+		test.Fatalf("expected operation error, got %v", err)
+	}
+	if operationError.Subject() != errorSubjectJournal || operationError.Code() != errorCodeInsert {
+		test.Fatalf("unexpected operation error: %s.%s.%s", operationError.Operation(), operationError.Subject(), operationError.Code())
+	}
+
+	_, err = store.SumJournalLines(ctx, mustTenantID(test), time.Now().UTC().Unix())
+	if !errors.As(err, &operationError) {
+		test.Fatalf("expected operation error, got %v", err)
+	}
+	if operationError.Subject() != errorSubjectJournal || operationError.Code() != errorCodeSumJournalLines {
+		test.Fatalf("unexpected operation error: %s.%s.%s", operationError.Operation(), operationError.Subject(), operationError.Code())
+	}
+
+	reservationID, err := ledger.NewReservationID("order-1")
+	if err != nil {
+		test.Fatalf("reservation id: %v", err)
+	}
+	reservationAmount, err := ledger.NewPositiveAmountCents(10)
+	if err != nil {
+		test.Fatalf("amount: %v", err)
+	}
+	reservation, err := ledger.NewReservation(accountID, reservationID, reservationAmount, ledger.ReservationStatusActive, 0)
+	if err != nil {
+		test.Fatalf("reservation: %v", err)
+	}
+	err = store.CreateReservation(ctx, reservation)
+	if !errors.As(err, &operationError) {
+		test.Fatalf("expected operation error, got %v", err)
+	}
+	if operationError.Subject() != errorSubjectReservation || operationError.Code() != errorCodeCreate {
+		test.Fatalf("unexpected operation error: %s.%s.%s", operationError.Operation(), operationError.Subject(), operationError.Code())
+	}
+
+	err = store.UpdateReservationStatus(ctx, accountID, reservationID, ledger.ReservationStatusActive, ledger.ReservationStatusCaptured)
+	if !errors.As(err, &operationError) {
+		test.Fatalf("expected operation error, got %v", err)
+	}
+	if operationError.Subject() != errorSubjectReservation || operationError.Code() != errorCodeUpdateStatus {
+		test.Fatalf("unexpected operation error: %s.%s.%s", operationError.Operation(), operationError.Subject(), operationError.Code())
+	}
+
+	_, err = store.GetReservation(ctx, accountID, reservationID)
+	if !errors.As(err, &operationError) {
+		test.Fatalf("expected operation error, got %v", err)
+	}
+	if operationError.Subject() != errorSubjectReservation || operationError.Code() != errorCodeGet {
+		test.Fatalf("unexpected operation error: %s.%s.%s", operationError.Operation(), operationError.Subject(), operationError.Code())
+	}
+
+	_, err = store.ListEntries(ctx, accountID, 0, 10, ledger.ListEntriesFilter{})
+	if !errors.As(err, &operationError) {
+		test.Fatalf("expected operation error, got %v", err)
+	}
+	if operationError.Subject() != errorSubjectEntry || operationError.Code() != errorCodeList {
+		test.Fatalf("unexpected operation error: %s.%s.%s", operationError.Operation(), operationError.Subject(), operationError.Code())
+	}
+
+	entryID, err := ledger.NewEntryID("entry-1")
+	if err != nil {
+		test.Fatalf("entry id: %v", err)
+	}
+	_, err = store.GetEntry(ctx, accountID, entryID)
+	if !errors.As(err, &operationError) {
+		test.Fatalf("expected operation error, got %v", err)
+	}
+	if operationError.Subject() != errorSubjectEntry || operationError.Code() != errorCodeGet {
+		test.Fatalf("unexpected operation error: %s.%s.%s", operationError.Operation(), operationError.Subject(), operationError.Code())
+	}
+
+	_, err = store.GetEntryByIdempotencyKey(ctx, accountID, idempotencyKey)
+	if !errors.As(err, &operationError) {
+		test.Fatalf("expected operation error, got %v", err)
+	}
+	if operationError.Subject() != errorSubjectEntry || operationError.Code() != errorCodeGet {
+		test.Fatalf("unexpected operation error: %s.%s.%s", operationError.Operation(), operationError.Subject(), operationError.Code())
+	}
+
+	err = store.InsertOutboxEvent(ctx, ledger.OutboxEvent{TenantID: mustTenantID(test), EventType: "entry.grant", PayloadJSON: "{}", CreatedUnixUTC: time.Now().UTC().Unix()})
+	if !errors.As(err, &operationError) {
+		test.Fatalf("expected operation error, got %v", err)
+	}
+	if operationError.Subject() != errorSubjectOutbox || operationError.Code() != errorCodeInsert {
+		test.Fatalf("unexpected operation error: %s.%s.%s", operationError.Operation(), operationError.Subject(), operationError.Code())
+	}
+
+	_, err = store.LockChainHead(ctx, accountID)
+	if !errors.As(err, &operationError) {
+		test.Fatalf("expected operation error, got %v", err)
+	}
+	if operationError.Subject() != errorSubjectChain || operationError.Code() != errorCodeLock {
+		test.Fatalf("unexpected operation error: %s.%s.%s", operationError.Operation(), operationError.Subject(), operationError.Code())
+	}
+
+	_, err = store.GetChainHead(ctx, accountID)
+	if !errors.As(err, &operationError) {
+		test.Fatalf("expected operation error, got %v", err)
+	}
+	if operationError.Subject() != errorSubjectChain || operationError.Code() != errorCodeGet {
+		test.Fatalf("unexpected operation error: %s.%s.%s", operationError.Operation(), operationError.Subject(), operationError.Code())
+	}
+
+	_, err = store.ListChainedEntries(ctx, accountID, 0, 10)
+	if !errors.As(err, &operationError) {
+		test.Fatalf("expected operation error, got %v", err)
+	}
+	if operationError.Subject() != errorSubjectChain || operationError.Code() != errorCodeList {
+		test.Fatalf("unexpected operation error: %s.%s.%s", operationError.Operation(), operationError.Subject(), operationError.Code())
+	}
+}
+
+func TestStoreGetOrCreateAccountIDRejectsInvalidAccountID(test *testing.T) {
+	test.Parallel()
+	db := newSQLiteDB(test)
+	store := New(db)
+
+	account := Account{
+		AccountID: " ",
+		TenantID:  mustTenantID(test).String(),
+		UserID:    mustUserID(test).String(),
+		LedgerID:  mustLedgerID(test).String(),
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := db.WithContext(context.Background()).Create(&account).Error; err != nil {
+		test.Fatalf("create account: %v", err)
+	}
+
+	_, err := store.GetOrCreateAccountID(context.Background(), mustTenantID(test), mustUserID(test), mustLedgerID(test))
+	var operationError ledger.OperationError
+	if !errors.As(err, &operationError) {
+		test.Fatalf("expected operation error, got %v", err)
+	}
+	if operationError.Subject() != errorSubjectAccount || operationError.Code() != errorCodeInvalid {
+		test.Fatalf("unexpected operation error: %s.%s.%s", operationError.Operation(), operationError.Subject(), operationError.Code())
+	}
+}
+
+func TestStoreAccountReadsRejectCorruptRows(test *testing.T) {
+	test.Parallel()
+	validAccount := func() Account {
+		return Account{
+			AccountID: "account-1",
+			TenantID:  mustTenantID(test).String(),
+			UserID:    mustUserID(test).String(),
+			LedgerID:  mustLedgerID(test).String(),
+			CreatedAt: time.Now().UTC().Add(-time.Minute),
+		}
+	}
+	listAccounts := func(tenantID ledger.TenantID) func(context.Context, *Store) error {
+		return func(ctx context.Context, store *Store) error {
+			_, err := store.ListAccounts(ctx, tenantID, 0, 10, ledger.ListAccountsFilter{})
+			return err
+		}
+	}
+	testCases := []struct {
+		name   string
+		mutate func(*Account)
+		read   func(context.Context, *Store) error
+	}{
+		{
+			name:   "get account id",
+			mutate: func(account *Account) { account.AccountID = " " },
+			read: func(ctx context.Context, store *Store) error {
+				_, err := store.GetAccountID(ctx, mustTenantID(test), mustUserID(test), mustLedgerID(test))
+				return err
+			},
+		},
+		{name: "list account id", mutate: func(account *Account) { account.AccountID = " " }, read: listAccounts(mustTenantID(test))},
+		{name: "list tenant id", mutate: func(account *Account) { account.TenantID = "" }, read: listAccounts(ledger.TenantID{})},
+		{name: "list user id", mutate: func(account *Account) { account.UserID = " " }, read: listAccounts(mustTenantID(test))},
+		{name: "list ledger id", mutate: func(account *Account) { account.LedgerID = " " }, read: listAccounts(mustTenantID(test))},
+	}
+	for _, testCase := range testCases {
+		test.Run(testCase.name, func(test *testing.T) {
+			test.Parallel()
+			db := newSQLiteDB(test)
+			store := New(db)
+			ctx := context.Background()
+			account := validAccount()
+			testCase.mutate(&account)
+			if err := db.WithContext(ctx).Create(&account).Error; err != nil {
+				test.Fatalf("create account: %v", err)
+			}
+
+			err := testCase.read(ctx, store)
+			var operationError ledger.OperationError
+			if !errors.As(err, &operationError) {
+				test.Fatalf("expected operation error, got %v", err)
+			}
+			if operationError.Subject() != errorSubjectAccount || operationError.Code() != errorCodeInvalid {
+				test.Fatalf("unexpected operation error: %s.%s.%s", operationError.Operation(), operationError.Subject(), operationError.Code())
+			}
+		})
 	}
 }
 
@@ -1841,8 +2996,8 @@ func newSQLiteDB(test *testing.T) *gorm.DB {
 		test.Fatalf("sql db: %v", err)
 	}
 	test.Cleanup(func() { _ = sqlDB.Close() })
-	if err := db.AutoMigrate(&Account{}, &LedgerEntry{}, &Reservation{}); err != nil {
-		test.Fatalf("auto migrate: %v", err)
+	if _, err := MigrateUp(context.Background(), db); err != nil {
+		test.Fatalf("migrate up: %v", err)
 	}
 	return db
 }
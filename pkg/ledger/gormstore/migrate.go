@@ -0,0 +1,331 @@
+package gormstore
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	migrationsTable        = "schema_migrations"
+	migrationUpSuffix      = ".up.sql"
+	migrationDownSuffix    = ".down.sql"
+	dialectPostgres        = "postgres"
+	dialectSQLite          = "sqlite"
+	migrationAdvisoryLock  = 4826351077
+	migrationCommentPrefix = "--"
+)
+
+//go:embed migrations
+var embeddedMigrationFiles embed.FS
+
+// migrationFiles is the file system the migrations are loaded from; tests replace it with broken script sets.
+var migrationFiles fs.FS = embeddedMigrationFiles
+
+var migrationsTableDDL = map[string]string{
+	dialectPostgres: "CREATE TABLE IF NOT EXISTS " + migrationsTable + " (version bigint PRIMARY KEY, name text NOT NULL, applied_at timestamptz NOT NULL)",
+	dialectSQLite:   "CREATE TABLE IF NOT EXISTS " + migrationsTable + " (version integer PRIMARY KEY, name text NOT NULL, applied_at datetime NOT NULL)",
+}
+
+// Migration is one versioned schema change, shipped as an up and a down SQL script per database dialect.
+type Migration struct {
+	Version int64
+	Name    string
+	up      string
+	down    string
+}
+
+// MigrationState reports whether a migration has been applied. AppliedAt is nil for a pending migration.
+// Known is false for a version recorded in the database that this build does not ship.
+type MigrationState struct {
+	Version   int64
+	Name      string
+	AppliedAt *time.Time
+	Known     bool
+}
+
+type appliedMigration struct {
+	Version   int64
+	Name      string
+	AppliedAt time.Time
+}
+
+// Migrations returns the migrations shipped for the database's dialect in version order.
+func Migrations(db *gorm.DB) ([]Migration, error) {
+	dialect, err := migrationDialect(db)
+	if err != nil {
+		return nil, err
+	}
+	return loadMigrations(dialect)
+}
+
+// MigrateUp applies every pending migration in version order, each in its own transaction together with
+// its schema_migrations row. On Postgres each transaction holds an advisory lock, so replicas starting
+// together apply every migration once. It returns the migrations it applied.
+func MigrateUp(ctx context.Context, db *gorm.DB) ([]Migration, error) {
+	dialect, err := migrationDialect(db)
+	if err != nil {
+		return nil, err
+	}
+	migrations, err := loadMigrations(dialect)
+	if err != nil {
+		return nil, err
+	}
+	var applied []Migration
+	for _, migration := range migrations {
+		ran := false
+		err := lockedMigrationTx(ctx, db, dialect, func(transaction *gorm.DB, appliedVersions map[int64]appliedMigration) error {
+			if _, ok := appliedVersions[migration.Version]; ok {
+				return nil
+			}
+			if err := execScript(transaction, migration.up); err != nil {
+				return err
+			}
+			ran = true
+			return transaction.Exec("INSERT INTO "+migrationsTable+" (version, name, applied_at) VALUES (?, ?, ?)", migration.Version, migration.Name, time.Now().UTC()).Error
+		})
+		if err != nil {
+			return applied, fmt.Errorf("migrate up %s: %w", migration.label(), err)
+		}
+		if ran {
+			applied = append(applied, migration)
+		}
+	}
+	return applied, nil
+}
+
+// MigrateDown reverts the steps most recently applied migrations, newest first, and returns them.
+func MigrateDown(ctx context.Context, db *gorm.DB, steps int) ([]Migration, error) {
+	if steps <= 0 {
+		return nil, fmt.Errorf("migrate down: steps must be positive, got %d", steps)
+	}
+	dialect, err := migrationDialect(db)
+	if err != nil {
+		return nil, err
+	}
+	migrations, err := loadMigrations(dialect)
+	if err != nil {
+		return nil, err
+	}
+	byVersion := make(map[int64]Migration, len(migrations))
+	for _, migration := range migrations {
+		byVersion[migration.Version] = migration
+	}
+	var reverted []Migration
+	for len(reverted) < steps {
+		var migration Migration
+		done := false
+		err := lockedMigrationTx(ctx, db, dialect, func(transaction *gorm.DB, appliedVersions map[int64]appliedMigration) error {
+			latest, ok := latestApplied(appliedVersions)
+			if !ok {
+				done = true
+				return nil
+			}
+			known, ok := byVersion[latest.Version]
+			if !ok {
+				return fmt.Errorf("applied version %d is not shipped with this build", latest.Version)
+			}
+			migration = known
+			if err := execScript(transaction, migration.down); err != nil {
+				return err
+			}
+			return transaction.Exec("DELETE FROM "+migrationsTable+" WHERE version = ?", migration.Version).Error
+		})
+		if err != nil {
+			return reverted, fmt.Errorf("migrate down %s: %w", migration.label(), err)
+		}
+		if done {
+			break
+		}
+		reverted = append(reverted, migration)
+	}
+	return reverted, nil
+}
+
+// MigrationStatus lists every shipped migration and every applied version in version order.
+func MigrationStatus(ctx context.Context, db *gorm.DB) ([]MigrationState, error) {
+	dialect, err := migrationDialect(db)
+	if err != nil {
+		return nil, err
+	}
+	migrations, err := loadMigrations(dialect)
+	if err != nil {
+		return nil, err
+	}
+	appliedVersions, err := readAppliedMigrations(db.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	states := make([]MigrationState, 0, len(migrations))
+	for _, migration := range migrations {
+		state := MigrationState{Version: migration.Version, Name: migration.Name, Known: true}
+		if applied, ok := appliedVersions[migration.Version]; ok {
+			appliedAt := applied.AppliedAt
+			state.AppliedAt = &appliedAt
+			delete(appliedVersions, migration.Version)
+		}
+		states = append(states, state)
+	}
+	for _, applied := range appliedVersions {
+		appliedAt := applied.AppliedAt
+		states = append(states, MigrationState{Version: applied.Version, Name: applied.Name, AppliedAt: &appliedAt})
+	}
+	sort.Slice(states, func(left, right int) bool { return states[left].Version < states[right].Version })
+	return states, nil
+}
+
+// PendingMigrations returns the shipped migrations that have not been applied yet.
+func PendingMigrations(ctx context.Context, db *gorm.DB) ([]Migration, error) {
+	dialect, err := migrationDialect(db)
+	if err != nil {
+		return nil, err
+	}
+	migrations, err := loadMigrations(dialect)
+	if err != nil {
+		return nil, err
+	}
+	appliedVersions, err := readAppliedMigrations(db.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	var pending []Migration
+	for _, migration := range migrations {
+		if _, ok := appliedVersions[migration.Version]; !ok {
+			pending = append(pending, migration)
+		}
+	}
+	return pending, nil
+}
+
+func (migration Migration) label() string {
+	if migration.Version == 0 {
+		return "schema"
+	}
+	return fmt.Sprintf("%04d_%s", migration.Version, migration.Name)
+}
+
+func migrationDialect(db *gorm.DB) (string, error) {
+	if db == nil || db.Dialector == nil {
+		return "", fmt.Errorf("migrations: database dialect is unknown")
+	}
+	dialect := db.Dialector.Name()
+	if _, ok := migrationsTableDDL[dialect]; !ok {
+		return "", fmt.Errorf("migrations: unsupported database dialect %q", dialect)
+	}
+	return dialect, nil
+}
+
+func loadMigrations(dialect string) ([]Migration, error) {
+	directory := path.Join("migrations", dialect)
+	upFiles, err := fs.Glob(migrationFiles, path.Join(directory, "*"+migrationUpSuffix))
+	if err != nil {
+		return nil, fmt.Errorf("list migrations: %w", err)
+	}
+	migrations := make([]Migration, 0, len(upFiles))
+	for _, upFile := range upFiles {
+		base := strings.TrimSuffix(path.Base(upFile), migrationUpSuffix)
+		rawVersion, name, ok := strings.Cut(base, "_")
+		version, err := strconv.ParseInt(rawVersion, 10, 64)
+		if !ok || err != nil || version <= 0 {
+			return nil, fmt.Errorf("migration %s: file name must be <version>_<name>%s", upFile, migrationUpSuffix)
+		}
+		up, err := fs.ReadFile(migrationFiles, upFile)
+		if err != nil {
+			return nil, fmt.Errorf("read migration %s: %w", upFile, err)
+		}
+		down, err := fs.ReadFile(migrationFiles, path.Join(directory, base+migrationDownSuffix))
+		if err != nil {
+			return nil, fmt.Errorf("read migration %s: %w", base+migrationDownSuffix, err)
+		}
+		migrations = append(migrations, Migration{Version: version, Name: name, up: string(up), down: string(down)})
+	}
+	sort.Slice(migrations, func(left, right int) bool { return migrations[left].Version < migrations[right].Version })
+	for index := 1; index < len(migrations); index++ {
+		if migrations[index].Version == migrations[index-1].Version {
+			return nil, fmt.Errorf("migrations: version %d is defined twice", migrations[index].Version)
+		}
+	}
+	return migrations, nil
+}
+
+// lockedMigrationTx runs fn in a transaction that holds the migration lock and sees the applied versions.
+func lockedMigrationTx(ctx context.Context, db *gorm.DB, dialect string, fn func(transaction *gorm.DB, appliedVersions map[int64]appliedMigration) error) error {
+	return db.WithContext(ctx).Transaction(func(transaction *gorm.DB) error {
+		if dialect == dialectPostgres {
+			if err := transaction.Exec("SELECT pg_advisory_xact_lock(?)", migrationAdvisoryLock).Error; err != nil {
+				return fmt.Errorf("acquire migration lock: %w", err)
+			}
+		}
+		if err := transaction.Exec(migrationsTableDDL[dialect]).Error; err != nil {
+			return fmt.Errorf("create %s: %w", migrationsTable, err)
+		}
+		appliedVersions, err := readAppliedMigrations(transaction)
+		if err != nil {
+			return err
+		}
+		return fn(transaction, appliedVersions)
+	})
+}
+
+func readAppliedMigrations(db *gorm.DB) (map[int64]appliedMigration, error) {
+	if !db.Migrator().HasTable(migrationsTable) {
+		return map[int64]appliedMigration{}, nil
+	}
+	var rows []appliedMigration
+	if err := db.Raw("SELECT version, name, applied_at FROM " + migrationsTable + " ORDER BY version").Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("read %s: %w", migrationsTable, err)
+	}
+	appliedVersions := make(map[int64]appliedMigration, len(rows))
+	for _, row := range rows {
+		appliedVersions[row.Version] = row
+	}
+	return appliedVersions, nil
+}
+
+func latestApplied(appliedVersions map[int64]appliedMigration) (appliedMigration, bool) {
+	var latest appliedMigration
+	found := false
+	for _, applied := range appliedVersions {
+		if !found || applied.Version > latest.Version {
+			latest = applied
+			found = true
+		}
+	}
+	return latest, found
+}
+
+// execScript runs a migration script one statement at a time. Statements end with a semicolon at the end
+// of a line; lines starting with -- are comments.
+func execScript(transaction *gorm.DB, script string) error {
+	var statement strings.Builder
+	flush := func() error {
+		sql := strings.TrimSpace(statement.String())
+		statement.Reset()
+		if sql == "" {
+			return nil
+		}
+		return transaction.Exec(sql).Error
+	}
+	for _, line := range strings.Split(script, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, migrationCommentPrefix) {
+			continue
+		}
+		statement.WriteString(line)
+		statement.WriteString("\n")
+		if strings.HasSuffix(trimmed, ";") {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	return flush()
+}
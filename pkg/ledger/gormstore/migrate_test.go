@@ -0,0 +1,364 @@
+package gormstore
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+var migratedModels = []any{&Account{}, &LedgerEntry{}, &Reservation{}, &GrantSchedule{}, &JournalLine{}, &EntryFeedHead{}, &EntryChange{}, &OutboxEvent{}, &AccountChainHead{}}
+
+func TestMigrationsMatchModels(test *testing.T) {
+	test.Parallel()
+	db := newSQLiteDB(test)
+	migrator := db.Migrator()
+	for _, model := range migratedModels {
+		statement := &gorm.Statement{DB: db}
+		if err := statement.Parse(model); err != nil {
+			test.Fatalf("parse %T: %v", model, err)
+		}
+		if !migrator.HasTable(model) {
+			test.Fatalf("table %s missing", statement.Schema.Table)
+		}
+		for _, field := range statement.Schema.Fields {
+			if field.DBName == "" {
+				continue
+			}
+			if !migrator.HasColumn(model, field.DBName) {
+				test.Errorf("column %s.%s missing", statement.Schema.Table, field.DBName)
+			}
+		}
+		for _, index := range statement.Schema.ParseIndexes() {
+			if !migrator.HasIndex(model, index.Name) {
+				test.Errorf("index %s on %s missing", index.Name, statement.Schema.Table)
+			}
+		}
+	}
+}
+
+func TestMigrateUpIsIdempotentAndReportsStatus(test *testing.T) {
+	test.Parallel()
+	ctx := context.Background()
+	db := newSQLiteDB(test)
+
+	applied, err := MigrateUp(ctx, db)
+	if err != nil {
+		test.Fatalf("second migrate up: %v", err)
+	}
+	if len(applied) != 0 {
+		test.Fatalf("expected no migrations on second run, got %d", len(applied))
+	}
+	migrations, err := Migrations(db)
+	if err != nil {
+		test.Fatalf("migrations: %v", err)
+	}
+	states, err := MigrationStatus(ctx, db)
+	if err != nil {
+		test.Fatalf("status: %v", err)
+	}
+	if len(states) != len(migrations) {
+		test.Fatalf("expected %d states, got %d", len(migrations), len(states))
+	}
+	for index, state := range states {
+		if state.Version != migrations[index].Version || !state.Known || state.AppliedAt == nil {
+			test.Fatalf("unexpected state %+v", state)
+		}
+	}
+	pending, err := PendingMigrations(ctx, db)
+	if err != nil {
+		test.Fatalf("pending: %v", err)
+	}
+	if len(pending) != 0 {
+		test.Fatalf("expected no pending migrations, got %d", len(pending))
+	}
+}
+
+func TestMigrateDownRevertsAndUpReapplies(test *testing.T) {
+	test.Parallel()
+	ctx := context.Background()
+	db := newSQLiteDB(test)
+	migrations, err := Migrations(db)
+	if err != nil {
+		test.Fatalf("migrations: %v", err)
+	}
+
+	reverted, err := MigrateDown(ctx, db, 1)
+	if err != nil {
+		test.Fatalf("migrate down: %v", err)
+	}
+	latest := migrations[len(migrations)-1]
+	if len(reverted) != 1 || reverted[0].Version != latest.Version {
+		test.Fatalf("expected to revert %d, got %+v", latest.Version, reverted)
+	}
+	if db.Migrator().HasTable(&ArchivedEntryKey{}) {
+		test.Fatalf("expected archived_entry_keys to be dropped")
+	}
+	pending, err := PendingMigrations(ctx, db)
+	if err != nil {
+		test.Fatalf("pending: %v", err)
+	}
+	if len(pending) != 1 || pending[0].Version != latest.Version {
+		test.Fatalf("expected %d pending, got %+v", latest.Version, pending)
+	}
+
+	reverted, err = MigrateDown(ctx, db, len(migrations)+1)
+	if err != nil {
+		test.Fatalf("migrate down all: %v", err)
+	}
+	if len(reverted) != len(migrations)-1 {
+		test.Fatalf("expected %d reverted, got %d", len(migrations)-1, len(reverted))
+	}
+	for _, model := range migratedModels {
+		if db.Migrator().HasTable(model) {
+			test.Fatalf("expected table for %T to be dropped", model)
+		}
+	}
+
+	applied, err := MigrateUp(ctx, db)
+	if err != nil {
+		test.Fatalf("migrate up: %v", err)
+	}
+	if len(applied) != len(migrations) {
+		test.Fatalf("expected %d applied, got %d", len(migrations), len(applied))
+	}
+	if _, err := MigrateDown(ctx, db, 0); err == nil {
+		test.Fatalf("expected error for zero steps")
+	}
+}
+
+func TestMigrateUpAdoptsBaselineSchema(test *testing.T) {
+	test.Parallel()
+	ctx := context.Background()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(test.TempDir(), "ledger.db")), &gorm.Config{})
+	if err != nil {
+		test.Fatalf("open sqlite: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		test.Fatalf("sql db: %v", err)
+	}
+	test.Cleanup(func() { _ = sqlDB.Close() })
+
+	migrations, err := Migrations(db)
+	if err != nil {
+		test.Fatalf("migrations: %v", err)
+	}
+	// A database created before versioned migrations has the baseline tables but no schema_migrations.
+	if err := execScript(db, migrations[0].up); err != nil {
+		test.Fatalf("baseline: %v", err)
+	}
+	if err := db.Exec("INSERT INTO accounts (account_id, tenant_id, user_id, ledger_id, created_at) VALUES ('00000000-0000-0000-0000-000000000001', 'default', 'user-1', 'default', CURRENT_TIMESTAMP)").Error; err != nil {
+		test.Fatalf("seed account: %v", err)
+	}
+
+	applied, err := MigrateUp(ctx, db)
+	if err != nil {
+		test.Fatalf("migrate up: %v", err)
+	}
+	if len(applied) != len(migrations) {
+		test.Fatalf("expected %d applied, got %d", len(migrations), len(applied))
+	}
+	var accounts int64
+	if err := db.Model(&Account{}).Count(&accounts).Error; err != nil {
+		test.Fatalf("count accounts: %v", err)
+	}
+	if accounts != 1 {
+		test.Fatalf("expected the existing account to survive, got %d", accounts)
+	}
+}
+
+// unsupportedDialector reports a dialect the migrations do not ship scripts for.
+type unsupportedDialector struct {
+	gorm.Dialector
+}
+
+func (unsupportedDialector) Name() string {
+	return "mysql"
+}
+
+// migrationCalls runs every exported migration function against db and returns their errors by name.
+func migrationCalls(ctx context.Context, db *gorm.DB) map[string]error {
+	_, migrationsErr := Migrations(db)
+	_, upErr := MigrateUp(ctx, db)
+	_, downErr := MigrateDown(ctx, db, 1)
+	_, statusErr := MigrationStatus(ctx, db)
+	_, pendingErr := PendingMigrations(ctx, db)
+	return map[string]error{"migrations": migrationsErr, "up": upErr, "down": downErr, "status": statusErr, "pending": pendingErr}
+}
+
+func newUnmigratedSQLiteDB(test *testing.T) *gorm.DB {
+	test.Helper()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(test.TempDir(), "ledger.db")), &gorm.Config{})
+	if err != nil {
+		test.Fatalf("open sqlite: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		test.Fatalf("sql db: %v", err)
+	}
+	test.Cleanup(func() { _ = sqlDB.Close() })
+	return db
+}
+
+func TestMigrationsRejectUnknownDialects(test *testing.T) {
+	test.Parallel()
+	ctx := context.Background()
+	for name, db := range map[string]*gorm.DB{
+		"nil":         nil,
+		"unsupported": {Config: &gorm.Config{Dialector: unsupportedDialector{}}},
+	} {
+		for call, err := range migrationCalls(ctx, db) {
+			if err == nil || !strings.HasPrefix(err.Error(), "migrations: ") {
+				test.Fatalf("%s %s: expected a dialect error, got %v", name, call, err)
+			}
+		}
+	}
+	if _, err := MigrateDown(ctx, nil, 0); err == nil || !strings.Contains(err.Error(), "steps must be positive") {
+		test.Fatalf("expected a steps error, got %v", err)
+	}
+}
+
+func TestMigrationStatusReportsUnshippedVersions(test *testing.T) {
+	test.Parallel()
+	ctx := context.Background()
+	fresh := newUnmigratedSQLiteDB(test)
+	pending, err := PendingMigrations(ctx, fresh)
+	if err != nil {
+		test.Fatalf("pending: %v", err)
+	}
+	migrations, err := Migrations(fresh)
+	if err != nil {
+		test.Fatalf("migrations: %v", err)
+	}
+	if len(pending) != len(migrations) {
+		test.Fatalf("expected every migration to be pending, got %d of %d", len(pending), len(migrations))
+	}
+
+	db := newSQLiteDB(test)
+	if err := db.Exec("INSERT INTO " + migrationsTable + " (version, name, applied_at) VALUES (9999, 'future', CURRENT_TIMESTAMP)").Error; err != nil {
+		test.Fatalf("record future version: %v", err)
+	}
+	states, err := MigrationStatus(ctx, db)
+	if err != nil {
+		test.Fatalf("status: %v", err)
+	}
+	last := states[len(states)-1]
+	if last.Version != 9999 || last.Known || last.AppliedAt == nil {
+		test.Fatalf("expected the unshipped version to be reported, got %+v", last)
+	}
+	if _, err := MigrateDown(ctx, db, 1); err == nil || !strings.Contains(err.Error(), "migrate down schema: applied version 9999 is not shipped") {
+		test.Fatalf("expected an unshipped version error, got %v", err)
+	}
+}
+
+func TestMigrationsReportBrokenMigrationsTable(test *testing.T) {
+	test.Parallel()
+	ctx := context.Background()
+	db := newUnmigratedSQLiteDB(test)
+	if err := db.Exec("CREATE TABLE " + migrationsTable + " (id integer)").Error; err != nil {
+		test.Fatalf("prepare: %v", err)
+	}
+	calls := migrationCalls(ctx, db)
+	delete(calls, "migrations")
+	for call, err := range calls {
+		if err == nil || !strings.Contains(err.Error(), "read "+migrationsTable) {
+			test.Fatalf("%s: expected a read error, got %v", call, err)
+		}
+	}
+
+	sqlitePath := filepath.Join(test.TempDir(), "readonly.db")
+	if err := os.WriteFile(sqlitePath, nil, 0o600); err != nil {
+		test.Fatalf("create database file: %v", err)
+	}
+	readOnly, err := gorm.Open(sqlite.Open("file:"+sqlitePath+"?mode=ro"), &gorm.Config{})
+	if err != nil {
+		test.Fatalf("open read-only sqlite: %v", err)
+	}
+	sqlDB, err := readOnly.DB()
+	if err != nil {
+		test.Fatalf("sql db: %v", err)
+	}
+	test.Cleanup(func() { _ = sqlDB.Close() })
+	if _, err := MigrateUp(ctx, readOnly); err == nil || !strings.Contains(err.Error(), "create "+migrationsTable) {
+		test.Fatalf("expected a create error, got %v", err)
+	}
+}
+
+func TestMigrationsRejectInvalidScriptSets(test *testing.T) {
+	originalFiles := migrationFiles
+	test.Cleanup(func() { migrationFiles = originalFiles })
+	script := &fstest.MapFile{Data: []byte("CREATE TABLE widgets (id integer);\n")}
+	testCases := []struct {
+		name     string
+		files    fstest.MapFS
+		expected string
+	}{
+		{
+			name:     "file name",
+			files:    fstest.MapFS{"migrations/sqlite/widgets.up.sql": script, "migrations/sqlite/widgets.down.sql": script},
+			expected: "file name must be <version>_<name>.up.sql",
+		},
+		{
+			name:     "unreadable up script",
+			files:    fstest.MapFS{"migrations/sqlite/0001_widgets.up.sql/nested": script},
+			expected: "read migration migrations/sqlite/0001_widgets.up.sql",
+		},
+		{
+			name:     "missing down script",
+			files:    fstest.MapFS{"migrations/sqlite/0001_widgets.up.sql": script},
+			expected: "read migration 0001_widgets.down.sql",
+		},
+		{
+			name: "duplicate version",
+			files: fstest.MapFS{
+				"migrations/sqlite/0001_widgets.up.sql":   script,
+				"migrations/sqlite/0001_widgets.down.sql": script,
+				"migrations/sqlite/01_gadgets.up.sql":     script,
+				"migrations/sqlite/01_gadgets.down.sql":   script,
+			},
+			expected: "version 1 is defined twice",
+		},
+	}
+	for _, testCase := range testCases {
+		test.Run(testCase.name, func(test *testing.T) {
+			migrationFiles = testCase.files
+			for call, err := range migrationCalls(context.Background(), newUnmigratedSQLiteDB(test)) {
+				if err == nil || !strings.Contains(err.Error(), testCase.expected) {
+					test.Fatalf("%s: expected an error containing %q, got %v", call, testCase.expected, err)
+				}
+			}
+		})
+	}
+}
+
+func TestMigrationsReportFailingScripts(test *testing.T) {
+	originalFiles := migrationFiles
+	test.Cleanup(func() { migrationFiles = originalFiles })
+	ctx := context.Background()
+
+	migrationFiles = fstest.MapFS{
+		"migrations/sqlite/0001_widgets.up.sql":   {Data: []byte("CREATE TABLE widgets (id integer);\nCREATE TABLE widgets (id integer);\n")},
+		"migrations/sqlite/0001_widgets.down.sql": {Data: []byte("DROP TABLE widgets;\n")},
+	}
+	if _, err := MigrateUp(ctx, newUnmigratedSQLiteDB(test)); err == nil || !strings.Contains(err.Error(), "migrate up 0001_widgets") {
+		test.Fatalf("expected the failing up script to be reported, got %v", err)
+	}
+
+	migrationFiles = fstest.MapFS{
+		"migrations/sqlite/0001_widgets.up.sql":   {Data: []byte("CREATE TABLE widgets (id integer);\n")},
+		"migrations/sqlite/0001_widgets.down.sql": {Data: []byte("DROP TABLE gadgets\n")},
+	}
+	db := newUnmigratedSQLiteDB(test)
+	if _, err := MigrateUp(ctx, db); err != nil {
+		test.Fatalf("migrate up: %v", err)
+	}
+	if _, err := MigrateDown(ctx, db, 1); err == nil || !strings.Contains(err.Error(), "migrate down 0001_widgets") {
+		test.Fatalf("expected the failing down script to be reported, got %v", err)
+	}
+}
@@ -0,0 +1,2 @@
+DROP TABLE grant_schedules;
+ALTER TABLE ledger_entries DROP COLUMN effective_at;
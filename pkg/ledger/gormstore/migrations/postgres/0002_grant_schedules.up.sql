@@ -0,0 +1,22 @@
+ALTER TABLE ledger_entries ADD COLUMN effective_at timestamptz;
+
+CREATE TABLE grant_schedules (
+    tenant_id text NOT NULL,
+    schedule_id text NOT NULL,
+    user_id text NOT NULL,
+    ledger_id text NOT NULL,
+    amount_cents bigint NOT NULL,
+    "interval" text NOT NULL,
+    start_at timestamptz NOT NULL,
+    end_at timestamptz,
+    grant_ttl_seconds bigint NOT NULL,
+    metadata jsonb NOT NULL,
+    status text NOT NULL,
+    next_period bigint NOT NULL,
+    next_run_at timestamptz NOT NULL,
+    created_at timestamptz NOT NULL,
+    updated_at timestamptz NOT NULL,
+    PRIMARY KEY (tenant_id, schedule_id)
+);
+CREATE INDEX idx_grant_schedules_tenant_created ON grant_schedules (tenant_id, created_at);
+CREATE INDEX idx_grant_schedules_status_next_run ON grant_schedules (status, next_run_at);
@@ -0,0 +1,13 @@
+CREATE TABLE entry_feed_heads (
+    tenant_id text PRIMARY KEY,
+    last_sequence bigint NOT NULL
+);
+
+CREATE TABLE entry_changes (
+    tenant_id text NOT NULL,
+    sequence bigint NOT NULL,
+    entry_id uuid NOT NULL,
+    created_at timestamptz NOT NULL,
+    PRIMARY KEY (tenant_id, sequence)
+);
+CREATE UNIQUE INDEX idx_entry_changes_entry ON entry_changes (entry_id);
@@ -0,0 +1,14 @@
+CREATE TABLE outbox_events (
+    event_id uuid PRIMARY KEY,
+    tenant_id text NOT NULL,
+    event_type text NOT NULL,
+    payload jsonb NOT NULL,
+    status text NOT NULL,
+    attempts bigint NOT NULL,
+    next_attempt_at timestamptz NOT NULL,
+    last_error text NOT NULL,
+    created_at timestamptz NOT NULL,
+    delivered_at timestamptz
+);
+CREATE INDEX idx_outbox_events_tenant_created ON outbox_events (tenant_id, created_at);
+CREATE INDEX idx_outbox_events_status_next_attempt ON outbox_events (status, next_attempt_at);
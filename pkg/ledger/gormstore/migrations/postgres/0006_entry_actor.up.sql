@@ -0,0 +1,4 @@
+ALTER TABLE ledger_entries ADD COLUMN actor_api_key_id text NOT NULL DEFAULT '';
+ALTER TABLE ledger_entries ADD COLUMN actor_service text NOT NULL DEFAULT '';
+ALTER TABLE ledger_entries ADD COLUMN actor_request_id text NOT NULL DEFAULT '';
+ALTER TABLE ledger_entries ADD COLUMN actor_client_address text NOT NULL DEFAULT '';
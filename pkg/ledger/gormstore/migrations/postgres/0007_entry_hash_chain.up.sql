@@ -0,0 +1,11 @@
+-- Entries written before this migration keep a null chain_sequence and are not part of any chain.
+ALTER TABLE ledger_entries ADD COLUMN chain_sequence bigint;
+ALTER TABLE ledger_entries ADD COLUMN previous_hash text NOT NULL DEFAULT '';
+ALTER TABLE ledger_entries ADD COLUMN entry_hash text NOT NULL DEFAULT '';
+CREATE UNIQUE INDEX uniq_entry_chain ON ledger_entries (account_id, chain_sequence);
+
+CREATE TABLE account_chain_heads (
+    account_id uuid PRIMARY KEY,
+    last_sequence bigint NOT NULL,
+    last_hash text NOT NULL
+);
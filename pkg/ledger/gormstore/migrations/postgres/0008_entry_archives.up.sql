@@ -0,0 +1,10 @@
+-- One row per compacted account: the cutoff, the last archived link of its hash chain, and the latest archive file.
+CREATE TABLE entry_archives (
+    account_id uuid PRIMARY KEY,
+    cutoff_at timestamptz NOT NULL,
+    through_sequence bigint NOT NULL,
+    through_hash text NOT NULL,
+    archived_entries bigint NOT NULL,
+    location text NOT NULL,
+    archived_at timestamptz NOT NULL
+);
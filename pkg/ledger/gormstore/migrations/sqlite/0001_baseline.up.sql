@@ -0,0 +1,37 @@
+-- Tables of the last release. IF NOT EXISTS lets databases created by AutoMigrate adopt versioned migrations.
+CREATE TABLE IF NOT EXISTS accounts (
+    account_id uuid PRIMARY KEY,
+    tenant_id text NOT NULL,
+    user_id text NOT NULL,
+    ledger_id text NOT NULL,
+    created_at datetime NOT NULL
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_accounts_tenant_user_ledger ON accounts (tenant_id, user_id, ledger_id);
+
+CREATE TABLE IF NOT EXISTS ledger_entries (
+    entry_id uuid PRIMARY KEY,
+    account_id uuid NOT NULL,
+    type text NOT NULL,
+    amount_cents integer NOT NULL,
+    reservation_id text,
+    refund_of_entry_id uuid,
+    idempotency_key text NOT NULL,
+    expires_at datetime,
+    metadata JSON NOT NULL,
+    created_at datetime NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_ledger_account_created ON ledger_entries (account_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_ledger_account_reservation ON ledger_entries (account_id, reservation_id);
+CREATE INDEX IF NOT EXISTS idx_ledger_account_refund_of ON ledger_entries (account_id, refund_of_entry_id);
+CREATE UNIQUE INDEX IF NOT EXISTS uniq_entry_idem ON ledger_entries (account_id, idempotency_key);
+
+CREATE TABLE IF NOT EXISTS reservations (
+    account_id uuid NOT NULL,
+    reservation_id text NOT NULL,
+    amount_cents integer NOT NULL,
+    status text NOT NULL,
+    expires_at datetime,
+    created_at datetime NOT NULL,
+    updated_at datetime NOT NULL,
+    PRIMARY KEY (account_id, reservation_id)
+);
@@ -0,0 +1,22 @@
+ALTER TABLE ledger_entries ADD COLUMN effective_at datetime;
+
+CREATE TABLE grant_schedules (
+    tenant_id text NOT NULL,
+    schedule_id text NOT NULL,
+    user_id text NOT NULL,
+    ledger_id text NOT NULL,
+    amount_cents integer NOT NULL,
+    "interval" text NOT NULL,
+    start_at datetime NOT NULL,
+    end_at datetime,
+    grant_ttl_seconds integer NOT NULL,
+    metadata JSON NOT NULL,
+    status text NOT NULL,
+    next_period integer NOT NULL,
+    next_run_at datetime NOT NULL,
+    created_at datetime NOT NULL,
+    updated_at datetime NOT NULL,
+    PRIMARY KEY (tenant_id, schedule_id)
+);
+CREATE INDEX idx_grant_schedules_tenant_created ON grant_schedules (tenant_id, created_at);
+CREATE INDEX idx_grant_schedules_status_next_run ON grant_schedules (status, next_run_at);
@@ -0,0 +1,13 @@
+CREATE TABLE journal_lines (
+    line_id uuid PRIMARY KEY,
+    tenant_id text NOT NULL,
+    ledger_id text NOT NULL,
+    entry_id uuid NOT NULL,
+    account text NOT NULL,
+    user_account_id uuid,
+    amount_cents integer NOT NULL,
+    posted_at datetime NOT NULL,
+    created_at datetime NOT NULL
+);
+CREATE INDEX idx_journal_lines_tenant_posted ON journal_lines (tenant_id, posted_at);
+CREATE INDEX idx_journal_lines_entry ON journal_lines (entry_id);
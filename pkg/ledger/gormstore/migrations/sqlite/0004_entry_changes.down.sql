@@ -0,0 +1,2 @@
+DROP TABLE entry_changes;
+DROP TABLE entry_feed_heads;
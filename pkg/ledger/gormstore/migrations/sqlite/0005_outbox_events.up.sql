@@ -0,0 +1,14 @@
+CREATE TABLE outbox_events (
+    event_id uuid PRIMARY KEY,
+    tenant_id text NOT NULL,
+    event_type text NOT NULL,
+    payload JSON NOT NULL,
+    status text NOT NULL,
+    attempts integer NOT NULL,
+    next_attempt_at datetime NOT NULL,
+    last_error text NOT NULL,
+    created_at datetime NOT NULL,
+    delivered_at datetime
+);
+CREATE INDEX idx_outbox_events_tenant_created ON outbox_events (tenant_id, created_at);
+CREATE INDEX idx_outbox_events_status_next_attempt ON outbox_events (status, next_attempt_at);
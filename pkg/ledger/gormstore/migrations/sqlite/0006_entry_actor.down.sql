@@ -0,0 +1,4 @@
+ALTER TABLE ledger_entries DROP COLUMN actor_client_address;
+ALTER TABLE ledger_entries DROP COLUMN actor_request_id;
+ALTER TABLE ledger_entries DROP COLUMN actor_service;
+ALTER TABLE ledger_entries DROP COLUMN actor_api_key_id;
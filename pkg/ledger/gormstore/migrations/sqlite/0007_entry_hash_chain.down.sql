@@ -0,0 +1,5 @@
+DROP TABLE account_chain_heads;
+DROP INDEX uniq_entry_chain;
+ALTER TABLE ledger_entries DROP COLUMN entry_hash;
+ALTER TABLE ledger_entries DROP COLUMN previous_hash;
+ALTER TABLE ledger_entries DROP COLUMN chain_sequence;
@@ -0,0 +1,2 @@
+-- The part of an expiring grant that debits consumed; it stays in the account total after the grant expires.
+ALTER TABLE ledger_entries ADD COLUMN consumed_cents integer NOT NULL DEFAULT 0;
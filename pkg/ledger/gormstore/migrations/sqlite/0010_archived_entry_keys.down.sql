@@ -0,0 +1 @@
+DROP TABLE archived_entry_keys;
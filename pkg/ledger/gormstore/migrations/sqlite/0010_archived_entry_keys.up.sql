@@ -0,0 +1,8 @@
+-- Idempotency keys and entry IDs of entries compaction deleted, so replays stay duplicates after archiving.
+CREATE TABLE archived_entry_keys (
+    account_id uuid NOT NULL,
+    idempotency_key text NOT NULL,
+    entry_id uuid NOT NULL,
+    PRIMARY KEY (account_id, idempotency_key)
+);
+CREATE INDEX idx_archived_entry_keys_entry ON archived_entry_keys (account_id, entry_id);
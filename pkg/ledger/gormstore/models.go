@@ -0,0 +1,190 @@
+package gormstore
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// Account represents the accounts table.
+type Account struct {
+	AccountID string    `gorm:"type:uuid;primaryKey"`
+	TenantID  string    `gorm:"not null;index:idx_accounts_tenant_user_ledger,unique,priority:1"`
+	UserID    string    `gorm:"not null;index:idx_accounts_tenant_user_ledger,unique,priority:2"`
+	LedgerID  string    `gorm:"not null;index:idx_accounts_tenant_user_ledger,unique,priority:3"`
+	CreatedAt time.Time `gorm:"not null"`
+}
+
+func (Account) TableName() string { return "accounts" }
+
+func (account *Account) BeforeCreate(tx *gorm.DB) error {
+	if account.AccountID == "" {
+		account.AccountID = uuid.NewString()
+	}
+	return nil
+}
+
+// LedgerEntry mirrors the ledger_entries table.
+type LedgerEntry struct {
+	EntryID         string         `gorm:"type:uuid;primaryKey"`
+	AccountID       string         `gorm:"type:uuid;not null;index:idx_ledger_account_created,priority:1;index:idx_ledger_account_reservation,priority:1;index:idx_ledger_account_refund_of,priority:1;index:uniq_entry_idem,unique,priority:1;index:uniq_entry_chain,unique,priority:1"`
+	Type            string         `gorm:"not null"`
+	AmountCents     int64          `gorm:"not null"`
+	ReservationID   *string        `gorm:"index:idx_ledger_account_reservation,priority:2"`
+	RefundOfEntryID *string        `gorm:"type:uuid;index:idx_ledger_account_refund_of,priority:2"`
+	IdempotencyKey  string         `gorm:"not null;index:uniq_entry_idem,unique,priority:2"`
+	ExpiresAt       *time.Time     `gorm:""`
+	EffectiveAt     *time.Time     `gorm:""`
+	Metadata        datatypes.JSON `gorm:"type:jsonb;not null"`
+	CreatedAt       time.Time      `gorm:"not null;index:idx_ledger_account_created,priority:2"`
+	// Chain columns link the entry into its account's hash chain; ChainSequence is null for entries written before chaining.
+	ChainSequence *int64 `gorm:"index:uniq_entry_chain,unique,priority:2"`
+	PreviousHash  string `gorm:"not null;default:''"`
+	EntryHash     string `gorm:"not null;default:''"`
+	// Actor columns record who wrote the entry; empty when unknown.
+	ActorAPIKeyID      string `gorm:"column:actor_api_key_id;not null;default:''"`
+	ActorService       string `gorm:"column:actor_service;not null;default:''"`
+	ActorRequestID     string `gorm:"column:actor_request_id;not null;default:''"`
+	ActorClientAddress string `gorm:"column:actor_client_address;not null;default:''"`
+	// ConsumedCents is how much of an expiring grant debits have drawn on; it stays in the total after expiry.
+	ConsumedCents int64 `gorm:"not null;default:0"`
+}
+
+func (LedgerEntry) TableName() string { return "ledger_entries" }
+
+func (entry *LedgerEntry) BeforeCreate(tx *gorm.DB) error {
+	if entry.EntryID == "" {
+		entry.EntryID = uuid.NewString()
+	}
+	return nil
+}
+
+// Reservation mirrors the reservations table.
+type Reservation struct {
+	AccountID     string     `gorm:"type:uuid;primaryKey"`
+	ReservationID string     `gorm:"primaryKey"`
+	AmountCents   int64      `gorm:"not null"`
+	Status        string     `gorm:"not null"`
+	ExpiresAt     *time.Time `gorm:""`
+	CreatedAt     time.Time  `gorm:"not null"`
+	UpdatedAt     time.Time  `gorm:"not null"`
+}
+
+func (Reservation) TableName() string { return "reservations" }
+
+// GrantSchedule mirrors the grant_schedules table.
+type GrantSchedule struct {
+	TenantID        string         `gorm:"primaryKey;index:idx_grant_schedules_tenant_created,priority:1"`
+	ScheduleID      string         `gorm:"primaryKey"`
+	UserID          string         `gorm:"not null"`
+	LedgerID        string         `gorm:"not null"`
+	AmountCents     int64          `gorm:"not null"`
+	Interval        string         `gorm:"not null"`
+	StartAt         time.Time      `gorm:"not null"`
+	EndAt           *time.Time     `gorm:""`
+	GrantTTLSeconds int64          `gorm:"not null"`
+	Metadata        datatypes.JSON `gorm:"type:jsonb;not null"`
+	Status          string         `gorm:"not null;index:idx_grant_schedules_status_next_run,priority:1"`
+	NextPeriod      int64          `gorm:"not null"`
+	NextRunAt       time.Time      `gorm:"not null;index:idx_grant_schedules_status_next_run,priority:2"`
+	CreatedAt       time.Time      `gorm:"not null;index:idx_grant_schedules_tenant_created,priority:2"`
+	UpdatedAt       time.Time      `gorm:"not null"`
+}
+
+func (GrantSchedule) TableName() string { return "grant_schedules" }
+
+// JournalLine mirrors the journal_lines table.
+type JournalLine struct {
+	LineID        string    `gorm:"type:uuid;primaryKey"`
+	TenantID      string    `gorm:"not null;index:idx_journal_lines_tenant_posted,priority:1"`
+	LedgerID      string    `gorm:"not null"`
+	EntryID       string    `gorm:"type:uuid;not null;index:idx_journal_lines_entry"`
+	Account       string    `gorm:"not null"`
+	UserAccountID *string   `gorm:"type:uuid"`
+	AmountCents   int64     `gorm:"not null"`
+	PostedAt      time.Time `gorm:"not null;index:idx_journal_lines_tenant_posted,priority:2"`
+	CreatedAt     time.Time `gorm:"not null"`
+}
+
+func (JournalLine) TableName() string { return "journal_lines" }
+
+func (line *JournalLine) BeforeCreate(tx *gorm.DB) error {
+	if line.LineID == "" {
+		line.LineID = uuid.NewString()
+	}
+	return nil
+}
+
+// EntryFeedHead mirrors the entry_feed_heads table: the last change-feed sequence issued per tenant.
+type EntryFeedHead struct {
+	TenantID     string `gorm:"primaryKey"`
+	LastSequence int64  `gorm:"not null"`
+}
+
+func (EntryFeedHead) TableName() string { return "entry_feed_heads" }
+
+// EntryChange mirrors the entry_changes table: one row per committed entry, numbered per tenant in commit order.
+type EntryChange struct {
+	TenantID  string    `gorm:"primaryKey;autoIncrement:false"`
+	Sequence  int64     `gorm:"primaryKey;autoIncrement:false"`
+	EntryID   string    `gorm:"type:uuid;not null;uniqueIndex:idx_entry_changes_entry"`
+	CreatedAt time.Time `gorm:"not null"`
+}
+
+func (EntryChange) TableName() string { return "entry_changes" }
+
+// AccountChainHead mirrors the account_chain_heads table: the last link of each account's entry hash chain.
+type AccountChainHead struct {
+	AccountID    string `gorm:"type:uuid;primaryKey"`
+	LastSequence int64  `gorm:"not null"`
+	LastHash     string `gorm:"not null"`
+}
+
+func (AccountChainHead) TableName() string { return "account_chain_heads" }
+
+// EntryArchive mirrors the entry_archives table: what compaction has removed from each account.
+type EntryArchive struct {
+	AccountID       string    `gorm:"type:uuid;primaryKey"`
+	CutoffAt        time.Time `gorm:"not null"`
+	ThroughSequence int64     `gorm:"not null"`
+	ThroughHash     string    `gorm:"not null"`
+	ArchivedEntries int64     `gorm:"not null"`
+	Location        string    `gorm:"not null"`
+	ArchivedAt      time.Time `gorm:"not null"`
+}
+
+func (EntryArchive) TableName() string { return "entry_archives" }
+
+// ArchivedEntryKey mirrors the archived_entry_keys table: the idempotency key and ID of each entry compaction deleted.
+type ArchivedEntryKey struct {
+	AccountID      string `gorm:"type:uuid;primaryKey"`
+	IdempotencyKey string `gorm:"primaryKey"`
+	EntryID        string `gorm:"type:uuid;not null"`
+}
+
+func (ArchivedEntryKey) TableName() string { return "archived_entry_keys" }
+
+// OutboxEvent mirrors the outbox_events table: notifications written with the entries they describe and their webhook delivery state.
+type OutboxEvent struct {
+	EventID       string         `gorm:"type:uuid;primaryKey"`
+	TenantID      string         `gorm:"not null;index:idx_outbox_events_tenant_created,priority:1"`
+	EventType     string         `gorm:"not null"`
+	Payload       datatypes.JSON `gorm:"type:jsonb;not null"`
+	Status        string         `gorm:"not null;index:idx_outbox_events_status_next_attempt,priority:1"`
+	Attempts      int            `gorm:"not null"`
+	NextAttemptAt time.Time      `gorm:"not null;index:idx_outbox_events_status_next_attempt,priority:2"`
+	LastError     string         `gorm:"not null"`
+	CreatedAt     time.Time      `gorm:"not null;index:idx_outbox_events_tenant_created,priority:2"`
+	DeliveredAt   *time.Time     `gorm:""`
+}
+
+func (OutboxEvent) TableName() string { return "outbox_events" }
+
+func (event *OutboxEvent) BeforeCreate(tx *gorm.DB) error {
+	if event.EventID == "" {
+		event.EventID = uuid.NewString()
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package gormstore
+
+import (
+	"context"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// sqliteBusyTimeoutMilliseconds bounds how long a SQLite statement waits on another connection's lock.
+const sqliteBusyTimeoutMilliseconds = 5000
+
+// Open prepares db the way ledgerd does on startup and returns a Store backed by it: SQLite connections
+// are configured with ConfigureSQLite, then every pending migration is applied with MigrateUp. Use New
+// instead when the schema is managed elsewhere, for example by running `ledgerd migrate up` in a deploy step.
+func Open(ctx context.Context, db *gorm.DB) (*Store, error) {
+	dialect, err := migrationDialect(db)
+	if err != nil {
+		return nil, err
+	}
+	if dialect == dialectSQLite {
+		if err := ConfigureSQLite(db); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := MigrateUp(ctx, db); err != nil {
+		return nil, fmt.Errorf("migrate schema: %w", err)
+	}
+	return New(db), nil
+}
+
+// ConfigureSQLite applies the connection settings the store relies on for SQLite: a single open
+// connection, so writers queue instead of failing with SQLITE_BUSY, WAL journaling, a busy timeout, and
+// enforced foreign keys. The pragmas run on that one connection, so they hold for every later statement.
+func ConfigureSQLite(db *gorm.DB) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return fmt.Errorf("sql database: %w", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	sqlDB.SetMaxIdleConns(1)
+
+	if err := db.Exec("PRAGMA journal_mode=WAL;").Error; err != nil {
+		return fmt.Errorf("pragma journal_mode: %w", err)
+	}
+	if err := db.Exec(fmt.Sprintf("PRAGMA busy_timeout=%d;", sqliteBusyTimeoutMilliseconds)).Error; err != nil {
+		return fmt.Errorf("pragma busy_timeout: %w", err)
+	}
+	if err := db.Exec("PRAGMA foreign_keys=ON;").Error; err != nil {
+		return fmt.Errorf("pragma foreign_keys: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package gormstore
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/MarkoPoloResearchLab/ledger/pkg/ledger"
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestOpenPreparesSQLiteAndMigrates(test *testing.T) {
+	test.Parallel()
+	ctx := context.Background()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(test.TempDir(), "ledger.db")), &gorm.Config{})
+	if err != nil {
+		test.Fatalf("open sqlite: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		test.Fatalf("sql db: %v", err)
+	}
+	test.Cleanup(func() { _ = sqlDB.Close() })
+
+	store, err := Open(ctx, db)
+	if err != nil {
+		test.Fatalf("open: %v", err)
+	}
+	pending, err := PendingMigrations(ctx, db)
+	if err != nil {
+		test.Fatalf("pending migrations: %v", err)
+	}
+	if len(pending) != 0 {
+		test.Fatalf("expected every migration applied, got %d pending", len(pending))
+	}
+	var journalMode string
+	if err := db.Raw("PRAGMA journal_mode;").Scan(&journalMode).Error; err != nil {
+		test.Fatalf("journal_mode: %v", err)
+	}
+	if !strings.EqualFold(journalMode, "wal") {
+		test.Fatalf("expected WAL journaling, got %q", journalMode)
+	}
+	var foreignKeys int
+	if err := db.Raw("PRAGMA foreign_keys;").Scan(&foreignKeys).Error; err != nil {
+		test.Fatalf("foreign_keys: %v", err)
+	}
+	if foreignKeys != 1 {
+		test.Fatalf("expected foreign keys enabled, got %d", foreignKeys)
+	}
+	if maxOpen := sqlDB.Stats().MaxOpenConnections; maxOpen != 1 {
+		test.Fatalf("expected one open connection, got %d", maxOpen)
+	}
+
+	service, err := ledger.NewService(store, func() int64 { return 1700000000 })
+	if err != nil {
+		test.Fatalf("new service: %v", err)
+	}
+	amount, err := ledger.NewPositiveAmountCents(500)
+	if err != nil {
+		test.Fatalf("amount: %v", err)
+	}
+	key, err := ledger.NewIdempotencyKey("grant-1")
+	if err != nil {
+		test.Fatalf("idempotency key: %v", err)
+	}
+	metadata, err := ledger.NewMetadataJSON("{}")
+	if err != nil {
+		test.Fatalf("metadata: %v", err)
+	}
+	if err := service.Grant(ctx, mustTenantID(test), mustUserID(test), mustLedgerID(test), amount, key, 0, metadata); err != nil {
+		test.Fatalf("grant: %v", err)
+	}
+
+	if _, err := Open(ctx, db); err != nil {
+		test.Fatalf("reopen a migrated database: %v", err)
+	}
+}
+
+func TestOpenRejectsUnknownDialect(test *testing.T) {
+	test.Parallel()
+	if _, err := Open(context.Background(), &gorm.DB{Config: &gorm.Config{}}); err == nil {
+		test.Fatalf("expected an error for a database without a dialect")
+	}
+}
+
+func TestOpenReportsConfigurationAndMigrationFailures(test *testing.T) {
+	test.Parallel()
+	ctx := context.Background()
+
+	broken := newUnmigratedSQLiteDB(test)
+	if err := broken.Exec("CREATE TABLE " + migrationsTable + " (id integer)").Error; err != nil {
+		test.Fatalf("prepare: %v", err)
+	}
+	if _, err := Open(ctx, broken); err == nil || !strings.Contains(err.Error(), "migrate schema") {
+		test.Fatalf("expected a migration error, got %v", err)
+	}
+
+	closed := newUnmigratedSQLiteDB(test)
+	sqlDB, err := closed.DB()
+	if err != nil {
+		test.Fatalf("sql db: %v", err)
+	}
+	if err := sqlDB.Close(); err != nil {
+		test.Fatalf("close: %v", err)
+	}
+	if _, err := Open(ctx, closed); err == nil || !strings.Contains(err.Error(), "pragma journal_mode") {
+		test.Fatalf("expected a pragma error, got %v", err)
+	}
+}
+
+func TestConfigureSQLiteReportsFailingPragmas(test *testing.T) {
+	test.Parallel()
+	if err := ConfigureSQLite(&gorm.DB{Config: &gorm.Config{}}); err == nil || !strings.Contains(err.Error(), "sql database") {
+		test.Fatalf("expected a connection error, got %v", err)
+	}
+	for _, pragma := range []string{"journal_mode", "busy_timeout", "foreign_keys"} {
+		db := newUnmigratedSQLiteDB(test)
+		err := db.Callback().Raw().Before("gorm:raw").Register("fail_pragma", func(tx *gorm.DB) {
+			if strings.Contains(tx.Statement.SQL.String(), pragma) {
+				_ = tx.AddError(errors.New("pragma rejected"))
+			}
+		})
+		if err != nil {
+			test.Fatalf("register callback: %v", err)
+		}
+		if err := ConfigureSQLite(db); err == nil || !strings.Contains(err.Error(), "pragma "+pragma) {
+			test.Fatalf("%s: expected a pragma error, got %v", pragma, err)
+		}
+	}
+}
@@ -0,0 +1,335 @@
+package gormstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	entriesTable              = "ledger_entries"
+	unpartitionedEntriesTable = "ledger_entries_unpartitioned"
+	defaultEntryPartition     = "ledger_entries_default"
+	entryIdempotencyTable     = "ledger_entry_idempotency"
+	defaultHashPartitions     = 16
+	defaultMonthsAhead        = 3
+	partitionBoundLayout      = "2006-01-02T15:04:05Z"
+	monthPartitionLayout      = "200601"
+	movingRowsSetting         = "ledger.moving_partition_rows"
+	partitionStrategyRange    = "r"
+	partitionStrategyHash     = "h"
+)
+
+// PartitionStrategy names how ledger_entries is split into Postgres partitions.
+type PartitionStrategy string
+
+const (
+	// PartitionNone is a plain, unpartitioned ledger_entries table.
+	PartitionNone PartitionStrategy = ""
+	// PartitionByMonth range-partitions ledger_entries by calendar month (UTC) of created_at.
+	PartitionByMonth PartitionStrategy = "month"
+	// PartitionByAccountHash hash-partitions ledger_entries by account_id.
+	PartitionByAccountHash PartitionStrategy = "account_hash"
+)
+
+// PartitionOptions configures PartitionLedgerEntries.
+type PartitionOptions struct {
+	Strategy PartitionStrategy
+	// HashPartitions is the number of account_hash partitions; zero means 16.
+	HashPartitions int
+	// MonthsAhead is how many months after the current one get a partition up front; zero means 3.
+	MonthsAhead int
+	// DryRun runs the whole conversion and rolls it back, so the returned partitions are the ones a real
+	// run would create.
+	DryRun bool
+}
+
+// errPartitionDryRun rolls back the partitioning transaction of a dry run.
+var errPartitionDryRun = errors.New("partition dry run")
+
+// entryIndexStatements rebuild the ledger_entries indexes on the partitioned table. Postgres only allows
+// unique indexes that contain the partition key, so under month partitioning the idempotency key is claimed
+// in ledger_entry_idempotency instead, and the chain index is not unique: the locked chain head already
+// hands out each sequence once. Deleting an entry releases its claim, except while createMonthPartition moves
+// rows out of the default partition.
+var entryIndexStatements = map[PartitionStrategy][]string{
+	PartitionByMonth: {
+		"ALTER TABLE ledger_entries ADD CONSTRAINT ledger_entries_pkey PRIMARY KEY (entry_id, created_at)",
+		"CREATE INDEX idx_ledger_account_created ON ledger_entries (account_id, created_at)",
+		"CREATE INDEX idx_ledger_account_reservation ON ledger_entries (account_id, reservation_id)",
+		"CREATE INDEX idx_ledger_account_refund_of ON ledger_entries (account_id, refund_of_entry_id)",
+		"CREATE INDEX idx_ledger_account_idempotency ON ledger_entries (account_id, idempotency_key)",
+		"CREATE INDEX uniq_entry_chain ON ledger_entries (account_id, chain_sequence)",
+		"CREATE TABLE " + entryIdempotencyTable + " (account_id uuid NOT NULL, idempotency_key text NOT NULL, entry_id uuid NOT NULL," +
+			" CONSTRAINT uniq_entry_idem PRIMARY KEY (account_id, idempotency_key))",
+		"INSERT INTO " + entryIdempotencyTable + " (account_id, idempotency_key, entry_id) SELECT account_id, idempotency_key, entry_id FROM ledger_entries",
+		"CREATE OR REPLACE FUNCTION ledger_entries_claim_idempotency() RETURNS trigger LANGUAGE plpgsql AS $$ BEGIN" +
+			" INSERT INTO " + entryIdempotencyTable + " (account_id, idempotency_key, entry_id) VALUES (NEW.account_id, NEW.idempotency_key, NEW.entry_id);" +
+			" RETURN NULL; END $$",
+		"CREATE TRIGGER ledger_entries_claim_idempotency AFTER INSERT ON ledger_entries FOR EACH ROW EXECUTE FUNCTION ledger_entries_claim_idempotency()",
+		"CREATE OR REPLACE FUNCTION ledger_entries_release_idempotency() RETURNS trigger LANGUAGE plpgsql AS $$ BEGIN" +
+			" IF coalesce(current_setting('" + movingRowsSetting + "', true), '') <> 'on' THEN" +
+			" DELETE FROM " + entryIdempotencyTable + " WHERE account_id = OLD.account_id AND idempotency_key = OLD.idempotency_key;" +
+			" END IF; RETURN NULL; END $$",
+		"CREATE TRIGGER ledger_entries_release_idempotency AFTER DELETE ON ledger_entries FOR EACH ROW EXECUTE FUNCTION ledger_entries_release_idempotency()",
+	},
+	PartitionByAccountHash: {
+		"ALTER TABLE ledger_entries ADD CONSTRAINT ledger_entries_pkey PRIMARY KEY (entry_id, account_id)",
+		"CREATE INDEX idx_ledger_account_created ON ledger_entries (account_id, created_at)",
+		"CREATE INDEX idx_ledger_account_reservation ON ledger_entries (account_id, reservation_id)",
+		"CREATE INDEX idx_ledger_account_refund_of ON ledger_entries (account_id, refund_of_entry_id)",
+		"CREATE UNIQUE INDEX uniq_entry_idem ON ledger_entries (account_id, idempotency_key)",
+		"CREATE UNIQUE INDEX uniq_entry_chain ON ledger_entries (account_id, chain_sequence)",
+	},
+}
+
+var partitionKeys = map[PartitionStrategy]string{
+	PartitionByMonth:       "RANGE (created_at)",
+	PartitionByAccountHash: "HASH (account_id)",
+}
+
+// EntryPartitioning reports how ledger_entries is partitioned. It is PartitionNone on SQLite.
+func EntryPartitioning(ctx context.Context, db *gorm.DB) (PartitionStrategy, error) {
+	dialect, err := migrationDialect(db)
+	if err != nil {
+		return PartitionNone, err
+	}
+	if dialect != dialectPostgres {
+		return PartitionNone, nil
+	}
+	return readEntryPartitioning(db.WithContext(ctx))
+}
+
+// PartitionLedgerEntries converts ledger_entries on Postgres into a partitioned table, by month of
+// created_at or by hash of account_id, and returns the partitions it created. It copies every row and
+// rebuilds the indexes in one transaction that holds the migration lock, so writes wait until it commits.
+// Month partitioning creates the months from the oldest entry through MonthsAhead months after now, plus a
+// default partition for anything outside them; EnsureEntryPartitions keeps creating months from then on.
+// Running it again with the strategy already in place only ensures the partitions. A dry run still takes the
+// migration lock and does all of the work before rolling it back, so it blocks writes for as long as a real run.
+func PartitionLedgerEntries(ctx context.Context, db *gorm.DB, options PartitionOptions, now time.Time) ([]string, error) {
+	options, err := options.withDefaults()
+	if err != nil {
+		return nil, err
+	}
+	dialect, err := migrationDialect(db)
+	if err != nil {
+		return nil, err
+	}
+	if dialect != dialectPostgres {
+		return nil, fmt.Errorf("partition ledger_entries: PostgreSQL is required, got %q", dialect)
+	}
+	var created []string
+	err = lockedMigrationTx(ctx, db, dialect, func(transaction *gorm.DB, _ map[int64]appliedMigration) error {
+		current, err := readEntryPartitioning(transaction)
+		if err != nil {
+			return err
+		}
+		switch current {
+		case options.Strategy:
+			created, err = ensureMonthPartitions(transaction, current, now, options.MonthsAhead)
+		case PartitionNone:
+			created, err = convertEntriesTable(transaction, options, now)
+		default:
+			return fmt.Errorf("ledger_entries is already partitioned by %s", current)
+		}
+		if err == nil && options.DryRun {
+			return errPartitionDryRun
+		}
+		return err
+	})
+	if err != nil && !errors.Is(err, errPartitionDryRun) {
+		return nil, fmt.Errorf("partition ledger_entries: %w", err)
+	}
+	return created, nil
+}
+
+// EnsureEntryPartitions creates the month partitions from now's month through monthsAhead months later
+// (zero means 3) that do not exist yet, moving their rows out of the default partition, and returns them.
+// It does nothing unless ledger_entries is partitioned by month, so servers can call it on any database.
+func EnsureEntryPartitions(ctx context.Context, db *gorm.DB, now time.Time, monthsAhead int) ([]string, error) {
+	if monthsAhead < 0 {
+		return nil, fmt.Errorf("ensure entry partitions: months ahead must not be negative, got %d", monthsAhead)
+	}
+	if monthsAhead == 0 {
+		monthsAhead = defaultMonthsAhead
+	}
+	dialect, err := migrationDialect(db)
+	if err != nil {
+		return nil, err
+	}
+	if dialect != dialectPostgres {
+		return nil, nil
+	}
+	strategy, err := readEntryPartitioning(db.WithContext(ctx))
+	if err != nil || strategy != PartitionByMonth {
+		return nil, err
+	}
+	var created []string
+	err = lockedMigrationTx(ctx, db, dialect, func(transaction *gorm.DB, _ map[int64]appliedMigration) error {
+		created, err = ensureMonthPartitions(transaction, strategy, now, monthsAhead)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("ensure entry partitions: %w", err)
+	}
+	return created, nil
+}
+
+func (options PartitionOptions) withDefaults() (PartitionOptions, error) {
+	if _, ok := partitionKeys[options.Strategy]; !ok {
+		return options, fmt.Errorf("partition ledger_entries: unknown strategy %q", options.Strategy)
+	}
+	if options.HashPartitions < 0 || options.MonthsAhead < 0 {
+		return options, fmt.Errorf("partition ledger_entries: partition counts must not be negative")
+	}
+	if options.HashPartitions == 0 {
+		options.HashPartitions = defaultHashPartitions
+	}
+	if options.MonthsAhead == 0 {
+		options.MonthsAhead = defaultMonthsAhead
+	}
+	return options, nil
+}
+
+func readEntryPartitioning(db *gorm.DB) (PartitionStrategy, error) {
+	var strategies []string
+	err := db.Raw("SELECT partstrat::text FROM pg_partitioned_table WHERE partrelid = to_regclass(?)", entriesTable).Scan(&strategies).Error
+	if err != nil {
+		return PartitionNone, fmt.Errorf("read ledger_entries partitioning: %w", err)
+	}
+	if len(strategies) == 0 {
+		return PartitionNone, nil
+	}
+	switch strategies[0] {
+	case partitionStrategyRange:
+		return PartitionByMonth, nil
+	case partitionStrategyHash:
+		return PartitionByAccountHash, nil
+	default:
+		return PartitionNone, fmt.Errorf("ledger_entries has unsupported partition strategy %q", strategies[0])
+	}
+}
+
+// convertEntriesTable swaps the plain table for a partitioned one with the same columns and copies the rows
+// over before building the indexes, which is faster than maintaining them row by row.
+func convertEntriesTable(transaction *gorm.DB, options PartitionOptions, now time.Time) ([]string, error) {
+	statements := []string{
+		"LOCK TABLE " + entriesTable + " IN ACCESS EXCLUSIVE MODE",
+		"ALTER TABLE " + entriesTable + " RENAME TO " + unpartitionedEntriesTable,
+		fmt.Sprintf("CREATE TABLE %s (LIKE %s INCLUDING DEFAULTS) PARTITION BY %s", entriesTable, unpartitionedEntriesTable, partitionKeys[options.Strategy]),
+	}
+	if err := execStatements(transaction, statements); err != nil {
+		return nil, err
+	}
+
+	var created []string
+	switch options.Strategy {
+	case PartitionByMonth:
+		var oldest sql.NullTime
+		if err := transaction.Raw("SELECT min(created_at) FROM " + unpartitionedEntriesTable).Row().Scan(&oldest); err != nil {
+			return nil, fmt.Errorf("read oldest entry: %w", err)
+		}
+		if err := transaction.Exec("CREATE TABLE " + defaultEntryPartition + " PARTITION OF " + entriesTable + " DEFAULT").Error; err != nil {
+			return nil, fmt.Errorf("create %s: %w", defaultEntryPartition, err)
+		}
+		created = append(created, defaultEntryPartition)
+		from := now
+		if oldest.Valid && oldest.Time.Before(now) {
+			from = oldest.Time
+		}
+		for month := monthStart(from); !month.After(monthStart(now).AddDate(0, options.MonthsAhead, 0)); month = month.AddDate(0, 1, 0) {
+			name, err := createMonthPartition(transaction, month)
+			if err != nil {
+				return nil, err
+			}
+			created = append(created, name)
+		}
+	case PartitionByAccountHash:
+		for remainder := 0; remainder < options.HashPartitions; remainder++ {
+			name := hashPartitionName(remainder)
+			statement := fmt.Sprintf("CREATE TABLE %s PARTITION OF %s FOR VALUES WITH (MODULUS %d, REMAINDER %d)", name, entriesTable, options.HashPartitions, remainder)
+			if err := transaction.Exec(statement).Error; err != nil {
+				return nil, fmt.Errorf("create %s: %w", name, err)
+			}
+			created = append(created, name)
+		}
+	}
+
+	statements = []string{
+		"INSERT INTO " + entriesTable + " SELECT * FROM " + unpartitionedEntriesTable,
+		"DROP TABLE " + unpartitionedEntriesTable,
+	}
+	statements = append(statements, entryIndexStatements[options.Strategy]...)
+	if err := execStatements(transaction, statements); err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+func ensureMonthPartitions(transaction *gorm.DB, strategy PartitionStrategy, now time.Time, monthsAhead int) ([]string, error) {
+	if strategy != PartitionByMonth {
+		return nil, nil
+	}
+	var created []string
+	for month := monthStart(now); !month.After(monthStart(now).AddDate(0, monthsAhead, 0)); month = month.AddDate(0, 1, 0) {
+		name := monthPartitionName(month)
+		var exists bool
+		if err := transaction.Raw("SELECT to_regclass(?) IS NOT NULL", name).Row().Scan(&exists); err != nil {
+			return nil, fmt.Errorf("look up %s: %w", name, err)
+		}
+		if exists {
+			continue
+		}
+		if _, err := createMonthPartition(transaction, month); err != nil {
+			return nil, err
+		}
+		created = append(created, name)
+	}
+	return created, nil
+}
+
+// createMonthPartition attaches a partition for the month starting at month. Rows already written to the
+// default partition for that month move into it first, because Postgres refuses to attach a range the
+// default partition still holds rows for. The move keeps their idempotency claims.
+func createMonthPartition(transaction *gorm.DB, month time.Time) (string, error) {
+	name := monthPartitionName(month)
+	from, to := month.Format(partitionBoundLayout), month.AddDate(0, 1, 0).Format(partitionBoundLayout)
+	statements := []string{
+		fmt.Sprintf("CREATE TABLE %s (LIKE %s INCLUDING DEFAULTS)", name, entriesTable),
+		fmt.Sprintf("SET LOCAL %s = 'on'", movingRowsSetting),
+		fmt.Sprintf("WITH moved AS (DELETE FROM %s WHERE created_at >= '%s' AND created_at < '%s' RETURNING *) INSERT INTO %s SELECT * FROM moved", defaultEntryPartition, from, to, name),
+		fmt.Sprintf("SET LOCAL %s = 'off'", movingRowsSetting),
+		fmt.Sprintf("ALTER TABLE %s ATTACH PARTITION %s FOR VALUES FROM ('%s') TO ('%s')", entriesTable, name, from, to),
+	}
+	if err := execStatements(transaction, statements); err != nil {
+		return "", fmt.Errorf("create %s: %w", name, err)
+	}
+	return name, nil
+}
+
+func execStatements(transaction *gorm.DB, statements []string) error {
+	for _, statement := range statements {
+		if err := transaction.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func monthStart(moment time.Time) time.Time {
+	moment = moment.UTC()
+	return time.Date(moment.Year(), moment.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+func monthPartitionName(month time.Time) string {
+	return entriesTable + "_" + month.UTC().Format(monthPartitionLayout)
+}
+
+func hashPartitionName(remainder int) string {
+	return fmt.Sprintf("%s_h%03d", entriesTable, remainder)
+}
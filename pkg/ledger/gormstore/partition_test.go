@@ -0,0 +1,250 @@
+package gormstore
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MarkoPoloResearchLab/ledger/pkg/ledger"
+	"github.com/MarkoPoloResearchLab/ledger/pkg/ledger/storetest"
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestPartitionConformancePostgres(test *testing.T) {
+	test.Parallel()
+	databaseURL := os.Getenv(postgresURLEnv)
+	if databaseURL == "" {
+		test.Skipf("%s is not set", postgresURLEnv)
+	}
+	for _, strategy := range []PartitionStrategy{PartitionByMonth, PartitionByAccountHash} {
+		test.Run(string(strategy), func(test *testing.T) {
+			test.Parallel()
+			storetest.RunConformance(test, func(test *testing.T) ledger.Store {
+				db := newPostgresSchemaDB(test, databaseURL)
+				if _, err := PartitionLedgerEntries(context.Background(), db, PartitionOptions{Strategy: strategy, HashPartitions: 4}, time.Now()); err != nil {
+					test.Fatalf("partition: %v", err)
+				}
+				return New(db)
+			})
+		})
+	}
+}
+
+func TestPartitionByMonthKeepsIdempotencyAcrossMonthsPostgres(test *testing.T) {
+	test.Parallel()
+	databaseURL := os.Getenv(postgresURLEnv)
+	if databaseURL == "" {
+		test.Skipf("%s is not set", postgresURLEnv)
+	}
+	ctx := context.Background()
+	db := newPostgresSchemaDB(test, databaseURL)
+	store := New(db)
+	accountID, err := store.GetOrCreateAccountID(ctx, mustTenantID(test), mustUserID(test), mustLedgerID(test))
+	if err != nil {
+		test.Fatalf("account: %v", err)
+	}
+	now := time.Date(2026, time.March, 15, 12, 0, 0, 0, time.UTC)
+	insert := func(key string, createdAt time.Time) error {
+		idempotencyKey, err := ledger.NewIdempotencyKey(key)
+		if err != nil {
+			test.Fatalf("idempotency key: %v", err)
+		}
+		metadata, err := ledger.NewMetadataJSON("{}")
+		if err != nil {
+			test.Fatalf("metadata: %v", err)
+		}
+		amount, err := ledger.NewEntryAmountCents(100)
+		if err != nil {
+			test.Fatalf("amount: %v", err)
+		}
+		input, err := ledger.NewEntryInput(accountID, ledger.EntryGrant, amount, nil, nil, idempotencyKey, 0, metadata, createdAt.Unix())
+		if err != nil {
+			test.Fatalf("entry input: %v", err)
+		}
+		_, err = store.InsertEntry(ctx, input)
+		return err
+	}
+	if err := insert("before-partitioning", now.AddDate(0, -2, 0)); err != nil {
+		test.Fatalf("insert before partitioning: %v", err)
+	}
+
+	created, err := PartitionLedgerEntries(ctx, db, PartitionOptions{Strategy: PartitionByMonth, MonthsAhead: 1}, now)
+	if err != nil {
+		test.Fatalf("partition: %v", err)
+	}
+	expected := []string{"ledger_entries_default", "ledger_entries_202601", "ledger_entries_202602", "ledger_entries_202603", "ledger_entries_202604"}
+	if len(created) != len(expected) {
+		test.Fatalf("expected partitions %v, got %v", expected, created)
+	}
+	for index := range expected {
+		if created[index] != expected[index] {
+			test.Fatalf("expected partitions %v, got %v", expected, created)
+		}
+	}
+	if strategy, err := EntryPartitioning(ctx, db); err != nil || strategy != PartitionByMonth {
+		test.Fatalf("expected month partitioning, got %q (%v)", strategy, err)
+	}
+
+	if err := insert("before-partitioning", now); !errors.Is(err, ledger.ErrDuplicateIdempotencyKey) {
+		test.Fatalf("expected a key from an older month to stay taken, got %v", err)
+	}
+	if err := insert("future", now.AddDate(1, 0, 0)); err != nil {
+		test.Fatalf("insert into the default partition: %v", err)
+	}
+	if err := insert("future", now); !errors.Is(err, ledger.ErrDuplicateIdempotencyKey) {
+		test.Fatalf("expected a key from the default partition to stay taken, got %v", err)
+	}
+
+	later := now.AddDate(1, 0, 0)
+	created, err = EnsureEntryPartitions(ctx, db, later, 1)
+	if err != nil {
+		test.Fatalf("ensure partitions: %v", err)
+	}
+	if len(created) != 2 || created[0] != "ledger_entries_202703" || created[1] != "ledger_entries_202704" {
+		test.Fatalf("unexpected partitions %v", created)
+	}
+	var defaultRows int64
+	if err := db.Raw("SELECT count(*) FROM ledger_entries_default").Scan(&defaultRows).Error; err != nil {
+		test.Fatalf("count default partition: %v", err)
+	}
+	if defaultRows != 0 {
+		test.Fatalf("expected the new month to take its rows from the default partition, %d left", defaultRows)
+	}
+	entries, err := store.ListEntries(ctx, accountID, 0, 10, ledger.ListEntriesFilter{})
+	if err != nil || len(entries) != 2 {
+		test.Fatalf("expected both entries after partitioning, got %d (%v)", len(entries), err)
+	}
+}
+
+func TestPartitionDryRunRollsBackPostgres(test *testing.T) {
+	test.Parallel()
+	databaseURL := os.Getenv(postgresURLEnv)
+	if databaseURL == "" {
+		test.Skipf("%s is not set", postgresURLEnv)
+	}
+	ctx := context.Background()
+	db := newPostgresSchemaDB(test, databaseURL)
+	created, err := PartitionLedgerEntries(ctx, db, PartitionOptions{Strategy: PartitionByAccountHash, HashPartitions: 4, DryRun: true}, time.Now())
+	if err != nil || len(created) != 4 {
+		test.Fatalf("expected a dry run to report 4 partitions, got %v (%v)", created, err)
+	}
+	if strategy, err := EntryPartitioning(ctx, db); err != nil || strategy != PartitionNone {
+		test.Fatalf("expected a dry run to leave ledger_entries unpartitioned, got %q (%v)", strategy, err)
+	}
+}
+
+func TestPartitionLedgerEntriesRequiresPostgres(test *testing.T) {
+	test.Parallel()
+	db := newSQLiteDB(test)
+	if _, err := PartitionLedgerEntries(context.Background(), db, PartitionOptions{Strategy: PartitionByMonth}, time.Now()); err == nil {
+		test.Fatalf("expected partitioning to require Postgres")
+	}
+	strategy, err := EntryPartitioning(context.Background(), db)
+	if err != nil || strategy != PartitionNone {
+		test.Fatalf("expected SQLite to report no partitioning, got %q (%v)", strategy, err)
+	}
+	created, err := EnsureEntryPartitions(context.Background(), db, time.Now(), 0)
+	if err != nil || len(created) != 0 {
+		test.Fatalf("expected ensuring partitions on SQLite to do nothing, got %v (%v)", created, err)
+	}
+}
+
+// postgresNamedDialector runs SQLite under the Postgres dialect name, so the Postgres-only paths reach the
+// database and fail on their first Postgres statement.
+type postgresNamedDialector struct {
+	gorm.Dialector
+}
+
+func (postgresNamedDialector) Name() string {
+	return dialectPostgres
+}
+
+func TestPartitionFunctionsReportDialectAndQueryFailures(test *testing.T) {
+	test.Parallel()
+	ctx := context.Background()
+	unknown := &gorm.DB{Config: &gorm.Config{Dialector: unsupportedDialector{}}}
+	if _, err := EntryPartitioning(ctx, unknown); err == nil {
+		test.Fatalf("expected entry partitioning to reject an unknown dialect")
+	}
+	if _, err := EnsureEntryPartitions(ctx, unknown, time.Now(), 0); err == nil {
+		test.Fatalf("expected ensuring partitions to reject an unknown dialect")
+	}
+	if _, err := PartitionLedgerEntries(ctx, unknown, PartitionOptions{Strategy: PartitionByMonth}, time.Now()); err == nil {
+		test.Fatalf("expected partitioning to reject an unknown dialect")
+	}
+
+	db, err := gorm.Open(postgresNamedDialector{Dialector: sqlite.Open(filepath.Join(test.TempDir(), "ledger.db"))}, &gorm.Config{})
+	if err != nil {
+		test.Fatalf("open sqlite: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		test.Fatalf("sql db: %v", err)
+	}
+	test.Cleanup(func() { _ = sqlDB.Close() })
+	if _, err := EntryPartitioning(ctx, db); err == nil || !strings.Contains(err.Error(), "read ledger_entries partitioning") {
+		test.Fatalf("expected a partitioning read error, got %v", err)
+	}
+	if _, err := EnsureEntryPartitions(ctx, db, time.Now(), 0); err == nil || !strings.Contains(err.Error(), "read ledger_entries partitioning") {
+		test.Fatalf("expected a partitioning read error, got %v", err)
+	}
+	if _, err := PartitionLedgerEntries(ctx, db, PartitionOptions{Strategy: PartitionByMonth}, time.Now()); err == nil || !strings.Contains(err.Error(), "acquire migration lock") {
+		test.Fatalf("expected a migration lock error, got %v", err)
+	}
+}
+
+func TestPartitionOptionsValidation(test *testing.T) {
+	test.Parallel()
+	testCases := []struct {
+		name    string
+		options PartitionOptions
+		wantErr bool
+	}{
+		{name: "month", options: PartitionOptions{Strategy: PartitionByMonth}},
+		{name: "account hash", options: PartitionOptions{Strategy: PartitionByAccountHash, HashPartitions: 8}},
+		{name: "no strategy", options: PartitionOptions{}, wantErr: true},
+		{name: "unknown strategy", options: PartitionOptions{Strategy: "weekly"}, wantErr: true},
+		{name: "negative hash partitions", options: PartitionOptions{Strategy: PartitionByAccountHash, HashPartitions: -1}, wantErr: true},
+		{name: "negative months ahead", options: PartitionOptions{Strategy: PartitionByMonth, MonthsAhead: -1}, wantErr: true},
+	}
+	for _, testCase := range testCases {
+		test.Run(testCase.name, func(test *testing.T) {
+			test.Parallel()
+			options, err := testCase.options.withDefaults()
+			if testCase.wantErr {
+				if err == nil {
+					test.Fatalf("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				test.Fatalf("unexpected error: %v", err)
+			}
+			if options.HashPartitions <= 0 || options.MonthsAhead <= 0 {
+				test.Fatalf("expected defaults to fill zero counts, got %+v", options)
+			}
+		})
+	}
+	if _, err := EnsureEntryPartitions(context.Background(), newSQLiteDB(test), time.Now(), -1); err == nil {
+		test.Fatalf("expected negative months ahead to fail")
+	}
+}
+
+func TestPartitionNames(test *testing.T) {
+	test.Parallel()
+	month := monthStart(time.Date(2026, time.October, 18, 23, 30, 0, 0, time.FixedZone("east", 3*60*60)))
+	if month != time.Date(2026, time.October, 1, 0, 0, 0, 0, time.UTC) {
+		test.Fatalf("unexpected month start %v", month)
+	}
+	if name := monthPartitionName(month); name != "ledger_entries_202610" {
+		test.Fatalf("unexpected month partition %q", name)
+	}
+	if name := hashPartitionName(7); name != "ledger_entries_h007" {
+		test.Fatalf("unexpected hash partition %q", name)
+	}
+}
@@ -0,0 +1,232 @@
+package ledger
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// JournalAccount names one side of a journal line. User lines post to the account that owns the entry;
+// the remaining values are per-tenant system accounts.
+type JournalAccount string
+
+const (
+	JournalAccountUser     JournalAccount = "user"
+	JournalAccountIssuance JournalAccount = "issuance"
+	JournalAccountRevenue  JournalAccount = "revenue"
+	JournalAccountBreakage JournalAccount = "breakage"
+	JournalAccountRefunds  JournalAccount = "refunds"
+)
+
+// ParseJournalAccount validates journal account values.
+func ParseJournalAccount(raw string) (JournalAccount, error) {
+	account := JournalAccount(strings.TrimSpace(raw))
+	switch account {
+	case JournalAccountUser, JournalAccountIssuance, JournalAccountRevenue, JournalAccountBreakage, JournalAccountRefunds:
+		return account, nil
+	default:
+		return "", fmt.Errorf("%w: %s", ErrInvalidJournalAccount, errorUnknownValue)
+	}
+}
+
+// String returns the account name.
+func (account JournalAccount) String() string {
+	return string(account)
+}
+
+// JournalLine is one side of a balanced posting derived from a ledger entry.
+// A user line carries the change to the user's balance; its system line carries the opposite amount.
+// PostedUnixUTC is when the line starts to count, so future-dated lines mirror effective_at and expires_at.
+type JournalLine struct {
+	TenantID      TenantID
+	LedgerID      LedgerID
+	EntryID       EntryID
+	Account       JournalAccount
+	UserAccountID AccountID
+	AmountCents   SignedAmountCents
+	PostedUnixUTC int64
+}
+
+// TrialBalanceLine is the balance of one journal account in one ledger. User accounts are aggregated.
+type TrialBalanceLine struct {
+	LedgerID     LedgerID
+	Account      JournalAccount
+	BalanceCents SignedAmountCents
+}
+
+// TrialBalance lists journal account balances for a tenant as of a point in time.
+// Every posting is balanced, so TotalCents is zero for a consistent journal.
+type TrialBalance struct {
+	AsOfUnixUTC int64
+	Lines       []TrialBalanceLine
+	TotalCents  SignedAmountCents
+}
+
+// TrialBalance sums the tenant's journal lines posted up to atUnixUTC (0 = now). It requires a store that
+// implements JournalStore.
+func (service *Service) TrialBalance(ctx context.Context, tenantID TenantID, atUnixUTC int64) (TrialBalance, error) {
+	if atUnixUTC == 0 {
+		atUnixUTC = service.nowFn()
+	}
+	journalStore, ok := service.store.(JournalStore)
+	if !ok {
+		return TrialBalance{}, ErrJournalUnsupported
+	}
+	lines, err := journalStore.SumJournalLines(ctx, tenantID, atUnixUTC)
+	if err != nil {
+		return TrialBalance{}, err
+	}
+	var total int64
+	for _, line := range lines {
+		total += line.BalanceCents.Int64()
+	}
+	return TrialBalance{AsOfUnixUTC: atUnixUTC, Lines: lines, TotalCents: SignedAmountCents(total)}, nil
+}
+
+// insertEntry persists an entry, attributed to the context's Actor, together with its hash-chain link,
+// change-feed position, grant consumptions, outbox event, and balanced journal lines, each where the store
+// supports it. Stores that implement EntryBatchStore write them in one round trip.
+func (service *Service) insertEntry(ctx context.Context, store Store, tenantID TenantID, userID UserID, ledgerID LedgerID, entryInput EntryInput) (Entry, error) {
+	entryInput = entryInput.WithActor(ActorFromContext(ctx))
+	prepare := func(entry Entry, head ChainHead) (EntryWrite, error) {
+		return service.prepareEntryWrite(ctx, store, tenantID, userID, ledgerID, entry, head)
+	}
+	var entry Entry
+	var err error
+	if batchStore, ok := store.(EntryBatchStore); ok {
+		entry, err = batchStore.InsertEntryBatch(ctx, entryInput, prepare)
+	} else {
+		entry, err = insertEntryRows(ctx, store, entryInput, prepare)
+	}
+	if err != nil {
+		return Entry{}, err
+	}
+	if err := service.publishEntryEvents(ctx, store, tenantID, userID, ledgerID, entry); err != nil {
+		return Entry{}, err
+	}
+	return entry, nil
+}
+
+// prepareEntryWrite builds the rows written together with entry, linked after head.
+func (service *Service) prepareEntryWrite(ctx context.Context, store Store, tenantID TenantID, userID UserID, ledgerID LedgerID, entry Entry, head ChainHead) (EntryWrite, error) {
+	outboxEvent, err := service.entryOutboxEvent(tenantID, userID, ledgerID, entry)
+	if err != nil {
+		return EntryWrite{}, err
+	}
+	consumptions, consumptionLines, err := planGrantConsumption(ctx, store, tenantID, ledgerID, entry)
+	if err != nil {
+		return EntryWrite{}, err
+	}
+	return EntryWrite{
+		TenantID:          tenantID,
+		Link:              newChainLink(head, entry),
+		GrantConsumptions: consumptions,
+		OutboxEvent:       outboxEvent,
+		JournalLines:      append(journalLinesForEntry(tenantID, ledgerID, entry), consumptionLines...),
+	}, nil
+}
+
+// insertEntryRows writes an entry and the rows prepare returns one store call at a time, for stores without
+// EntryBatchStore. Rows of capabilities the store lacks are skipped, except outbox events: a tenant configured
+// with WithOutbox expects every event, so a store without OutboxStore fails the write with ErrOutboxUnsupported.
+func insertEntryRows(ctx context.Context, store Store, entryInput EntryInput, prepare func(entry Entry, head ChainHead) (EntryWrite, error)) (Entry, error) {
+	entry, err := store.InsertEntry(ctx, entryInput)
+	if err != nil {
+		return Entry{}, err
+	}
+	chainStore, chained := store.(ChainStore)
+	var head ChainHead
+	if chained {
+		if head, err = chainStore.LockChainHead(ctx, entry.AccountID()); err != nil {
+			return Entry{}, err
+		}
+	}
+	write, err := prepare(entry, head)
+	if err != nil {
+		return Entry{}, err
+	}
+	if chained {
+		if err := chainStore.AppendChainLink(ctx, entry.AccountID(), write.Link); err != nil {
+			return Entry{}, err
+		}
+	}
+	if consumptionStore, ok := store.(GrantConsumptionStore); ok {
+		for _, consumption := range write.GrantConsumptions {
+			if err := consumptionStore.ConsumeGrant(ctx, entry.AccountID(), consumption.GrantEntryID, consumption.AmountCents); err != nil {
+				return Entry{}, err
+			}
+		}
+	}
+	if write.OutboxEvent != nil {
+		outboxStore, ok := store.(OutboxStore)
+		if !ok {
+			return Entry{}, ErrOutboxUnsupported
+		}
+		if err := outboxStore.InsertOutboxEvent(ctx, *write.OutboxEvent); err != nil {
+			return Entry{}, err
+		}
+	}
+	if journalStore, ok := store.(JournalStore); ok && len(write.JournalLines) > 0 {
+		if err := journalStore.InsertJournalLines(ctx, write.JournalLines); err != nil {
+			return Entry{}, err
+		}
+	}
+	// The feed row goes last: appending it locks the tenant's feed head until commit (see ChangeFeedStore).
+	if feedStore, ok := store.(ChangeFeedStore); ok {
+		if err := feedStore.AppendEntryChange(ctx, write.TenantID, entry.EntryID()); err != nil {
+			return Entry{}, err
+		}
+	}
+	return entry, nil
+}
+
+// journalLinesForEntry derives the postings for an entry. Grants and their cancellations post against
+// issuance, spends against revenue, and refunds against refunds, from the time the entry takes effect.
+// Entries with an expiry also post their amount to breakage when they expire; spends that later consume part
+// of the grant move that part back (see planGrantConsumption), so breakage ends up with the unconsumed
+// remainder and every user account's journal balance matches its ledger total at any time. Holds only
+// earmark funds and post nothing.
+func journalLinesForEntry(tenantID TenantID, ledgerID LedgerID, entry Entry) []JournalLine {
+	var counterAccount JournalAccount
+	switch entry.Type() {
+	case EntryGrant, EntryGrantCancel:
+		counterAccount = JournalAccountIssuance
+	case EntrySpend:
+		counterAccount = JournalAccountRevenue
+	case EntryRefund:
+		counterAccount = JournalAccountRefunds
+	default:
+		return nil
+	}
+	amount := entry.AmountCents().Int64()
+	postedUnixUTC := entry.EffectiveAtUnixUTC()
+	if postedUnixUTC == 0 {
+		postedUnixUTC = entry.CreatedUnixUTC()
+	}
+	lines := []JournalLine{
+		newJournalLine(tenantID, ledgerID, entry, JournalAccountUser, amount, postedUnixUTC),
+		newJournalLine(tenantID, ledgerID, entry, counterAccount, -amount, postedUnixUTC),
+	}
+	if entry.ExpiresAtUnixUTC() != 0 {
+		lines = append(lines,
+			newJournalLine(tenantID, ledgerID, entry, JournalAccountUser, -amount, entry.ExpiresAtUnixUTC()),
+			newJournalLine(tenantID, ledgerID, entry, JournalAccountBreakage, amount, entry.ExpiresAtUnixUTC()),
+		)
+	}
+	return lines
+}
+
+func newJournalLine(tenantID TenantID, ledgerID LedgerID, entry Entry, account JournalAccount, amount int64, postedUnixUTC int64) JournalLine {
+	line := JournalLine{
+		TenantID:      tenantID,
+		LedgerID:      ledgerID,
+		EntryID:       entry.EntryID(),
+		Account:       account,
+		AmountCents:   SignedAmountCents(amount),
+		PostedUnixUTC: postedUnixUTC,
+	}
+	if account == JournalAccountUser {
+		line.UserAccountID = entry.AccountID()
+	}
+	return line
+}
@@ -0,0 +1,401 @@
+package ledger
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseJournalAccount(test *testing.T) {
+	test.Parallel()
+	for _, raw := range []string{"user", "issuance", "revenue", "breakage", "refunds"} {
+		account, err := ParseJournalAccount(raw)
+		if err != nil || account.String() != raw {
+			test.Fatalf("parse %q: account=%q err=%v", raw, account, err)
+		}
+	}
+	if _, err := ParseJournalAccount("cash"); !errors.Is(err, ErrInvalidJournalAccount) {
+		test.Fatalf("expected invalid journal account, got %v", err)
+	}
+}
+
+func TestJournalLinesForEntry(test *testing.T) {
+	test.Parallel()
+	tenantID := mustTenantID(test, defaultTenantIDValue)
+	ledgerID := mustLedgerID(test, defaultLedgerIDValue)
+	accountID := mustAccountID(test, "acct-1")
+	testCases := []struct {
+		name         string
+		entryType    EntryType
+		amountCents  int64
+		effectiveAt  int64
+		expiresAt    int64
+		wantAccounts []JournalAccount
+		wantAmounts  []int64
+		wantPostedAt []int64
+	}{
+		{name: "grant", entryType: EntryGrant, amountCents: 500, wantAccounts: []JournalAccount{JournalAccountUser, JournalAccountIssuance}, wantAmounts: []int64{500, -500}, wantPostedAt: []int64{100, 100}},
+		{
+			name: "expiring scheduled grant", entryType: EntryGrant, amountCents: 500, effectiveAt: 150, expiresAt: 300,
+			wantAccounts: []JournalAccount{JournalAccountUser, JournalAccountIssuance, JournalAccountUser, JournalAccountBreakage},
+			wantAmounts:  []int64{500, -500, -500, 500},
+			wantPostedAt: []int64{150, 150, 300, 300},
+		},
+		{name: "spend", entryType: EntrySpend, amountCents: -200, wantAccounts: []JournalAccount{JournalAccountUser, JournalAccountRevenue}, wantAmounts: []int64{-200, 200}, wantPostedAt: []int64{100, 100}},
+		{name: "refund", entryType: EntryRefund, amountCents: 50, wantAccounts: []JournalAccount{JournalAccountUser, JournalAccountRefunds}, wantAmounts: []int64{50, -50}, wantPostedAt: []int64{100, 100}},
+		{name: "grant cancel", entryType: EntryGrantCancel, amountCents: -500, wantAccounts: []JournalAccount{JournalAccountUser, JournalAccountIssuance}, wantAmounts: []int64{-500, 500}, wantPostedAt: []int64{100, 100}},
+		{name: "hold", entryType: EntryHold, amountCents: -100},
+		{name: "reverse hold", entryType: EntryReverseHold, amountCents: 100},
+	}
+	for _, testCase := range testCases {
+		testCase := testCase
+		test.Run(testCase.name, func(test *testing.T) {
+			test.Parallel()
+			amount, err := NewEntryAmountCents(testCase.amountCents)
+			if err != nil {
+				test.Fatalf("entry amount: %v", err)
+			}
+			entry, err := NewEntry(mustEntryID(test, "entry-1"), accountID, testCase.entryType, amount, nil, nil, mustIdempotencyKey(test, "entry-1"), testCase.expiresAt, mustMetadata(test, "{}"), 100)
+			if err != nil {
+				test.Fatalf("new entry: %v", err)
+			}
+			lines := journalLinesForEntry(tenantID, ledgerID, entry.WithEffectiveAtUnixUTC(testCase.effectiveAt))
+			if len(lines) != len(testCase.wantAccounts) {
+				test.Fatalf("expected %d lines, got %+v", len(testCase.wantAccounts), lines)
+			}
+			var total int64
+			for index, line := range lines {
+				if line.Account != testCase.wantAccounts[index] || line.AmountCents.Int64() != testCase.wantAmounts[index] || line.PostedUnixUTC != testCase.wantPostedAt[index] {
+					test.Fatalf("unexpected line %d: %+v", index, line)
+				}
+				if line.TenantID != tenantID || line.LedgerID != ledgerID || line.EntryID != entry.EntryID() {
+					test.Fatalf("unexpected line identity %d: %+v", index, line)
+				}
+				if (line.Account == JournalAccountUser) != (line.UserAccountID == accountID) {
+					test.Fatalf("unexpected user account on line %d: %+v", index, line)
+				}
+				total += line.AmountCents.Int64()
+			}
+			if total != 0 {
+				test.Fatalf("expected balanced lines, got total %d", total)
+			}
+		})
+	}
+}
+
+func TestTrialBalanceSumsToZeroAcrossMutations(test *testing.T) {
+	test.Parallel()
+	store := newStubStore(test, 0)
+	service := mustNewService(test, store)
+	ctx := context.Background()
+	tenantID := mustTenantID(test, defaultTenantIDValue)
+	userID := mustUserID(test, "user-1")
+	ledgerID := mustLedgerID(test, defaultLedgerIDValue)
+	metadata := mustMetadata(test, "{}")
+
+	if _, err := service.GrantEntry(ctx, tenantID, userID, ledgerID, mustPositiveAmount(test, 1000), mustIdempotencyKey(test, "grant-1"), 0, metadata); err != nil {
+		test.Fatalf("grant: %v", err)
+	}
+	if _, err := service.GrantEntry(ctx, tenantID, userID, ledgerID, mustPositiveAmount(test, 300), mustIdempotencyKey(test, "grant-expiring"), 200, metadata); err != nil {
+		test.Fatalf("expiring grant: %v", err)
+	}
+	scheduled, err := service.ScheduleGrantEntry(ctx, tenantID, userID, ledgerID, mustPositiveAmount(test, 200), mustIdempotencyKey(test, "grant-scheduled"), 150, 0, metadata)
+	if err != nil {
+		test.Fatalf("scheduled grant: %v", err)
+	}
+	spend, err := service.SpendEntry(ctx, tenantID, userID, ledgerID, mustPositiveAmount(test, 400), mustIdempotencyKey(test, "spend-1"), metadata)
+	if err != nil {
+		test.Fatalf("spend: %v", err)
+	}
+	if _, err := service.ReserveEntry(ctx, tenantID, userID, ledgerID, mustPositiveAmount(test, 100), mustReservationID(test, "order-1"), mustIdempotencyKey(test, "reserve-1"), 0, metadata); err != nil {
+		test.Fatalf("reserve: %v", err)
+	}
+	if err := service.Capture(ctx, tenantID, userID, ledgerID, mustReservationID(test, "order-1"), mustIdempotencyKey(test, "capture-1"), mustPositiveAmount(test, 100), metadata); err != nil {
+		test.Fatalf("capture: %v", err)
+	}
+	if _, err := service.ReserveEntry(ctx, tenantID, userID, ledgerID, mustPositiveAmount(test, 50), mustReservationID(test, "order-2"), mustIdempotencyKey(test, "reserve-2"), 0, metadata); err != nil {
+		test.Fatalf("reserve: %v", err)
+	}
+	if err := service.Release(ctx, tenantID, userID, ledgerID, mustReservationID(test, "order-2"), mustIdempotencyKey(test, "release-2"), metadata); err != nil {
+		test.Fatalf("release: %v", err)
+	}
+	if _, err := service.RefundByEntryIDEntry(ctx, tenantID, userID, ledgerID, spend.EntryID(), mustPositiveAmount(test, 150), mustIdempotencyKey(test, "refund-1"), metadata); err != nil {
+		test.Fatalf("refund: %v", err)
+	}
+	operations := []BatchOperation{{OperationID: "grant", Grant: &BatchGrantOperation{Amount: mustPositiveAmount(test, 10), IdempotencyKey: mustIdempotencyKey(test, "batch-grant"), Metadata: metadata}}}
+	if _, err := service.Batch(ctx, tenantID, userID, ledgerID, operations, true); err != nil {
+		test.Fatalf("batch: %v", err)
+	}
+
+	assertTrialBalance(test, service, 0, map[JournalAccount]int64{
+		JournalAccountUser:     960,
+		JournalAccountIssuance: -1310,
+		JournalAccountRevenue:  500,
+		JournalAccountRefunds:  -150,
+	})
+	assertTrialBalance(test, service, 250, map[JournalAccount]int64{
+		JournalAccountUser:     1160,
+		JournalAccountIssuance: -1510,
+		JournalAccountRevenue:  500,
+		JournalAccountRefunds:  -150,
+		JournalAccountBreakage: 0,
+	})
+
+	if err := service.CancelGrant(ctx, tenantID, userID, ledgerID, scheduled.EntryID(), metadata); err != nil {
+		test.Fatalf("cancel grant: %v", err)
+	}
+	assertTrialBalance(test, service, 250, map[JournalAccount]int64{
+		JournalAccountUser:     960,
+		JournalAccountIssuance: -1310,
+		JournalAccountRevenue:  500,
+		JournalAccountRefunds:  -150,
+		JournalAccountBreakage: 0,
+	})
+
+	otherTenant, err := service.TrialBalance(ctx, mustTenantID(test, "other-tenant"), 0)
+	if err != nil {
+		test.Fatalf("trial balance: %v", err)
+	}
+	if len(otherTenant.Lines) != 0 {
+		test.Fatalf("expected trial balance to be scoped to the tenant, got %+v", otherTenant.Lines)
+	}
+}
+
+func TestBreakagePostsOnlyUnconsumedRemainder(test *testing.T) {
+	test.Parallel()
+	store := newStubStore(test, 0)
+	service := mustNewService(test, store)
+	ctx := context.Background()
+	tenantID := mustTenantID(test, defaultTenantIDValue)
+	userID := mustUserID(test, "user-1")
+	ledgerID := mustLedgerID(test, defaultLedgerIDValue)
+	metadata := mustMetadata(test, "{}")
+
+	if _, err := service.GrantEntry(ctx, tenantID, userID, ledgerID, mustPositiveAmount(test, 1000), mustIdempotencyKey(test, "grant-permanent"), 0, metadata); err != nil {
+		test.Fatalf("grant: %v", err)
+	}
+	if _, err := service.GrantEntry(ctx, tenantID, userID, ledgerID, mustPositiveAmount(test, 300), mustIdempotencyKey(test, "grant-late"), 400, metadata); err != nil {
+		test.Fatalf("late expiring grant: %v", err)
+	}
+	if _, err := service.GrantEntry(ctx, tenantID, userID, ledgerID, mustPositiveAmount(test, 100), mustIdempotencyKey(test, "grant-early"), 200, metadata); err != nil {
+		test.Fatalf("early expiring grant: %v", err)
+	}
+	if _, err := service.SpendEntry(ctx, tenantID, userID, ledgerID, mustPositiveAmount(test, 180), mustIdempotencyKey(test, "spend-1"), metadata); err != nil {
+		test.Fatalf("spend: %v", err)
+	}
+
+	if store.consumedGrants[mustEntryID(test, "grant-early")] != 100 || store.consumedGrants[mustEntryID(test, "grant-late")] != 80 {
+		test.Fatalf("expected the earliest expiring grant to be consumed first, got %v", store.consumedGrants)
+	}
+	assertTrialBalance(test, service, 250, map[JournalAccount]int64{
+		JournalAccountUser:     1220,
+		JournalAccountIssuance: -1400,
+		JournalAccountRevenue:  180,
+		JournalAccountBreakage: 0,
+	})
+	assertTrialBalance(test, service, 450, map[JournalAccount]int64{
+		JournalAccountUser:     1000,
+		JournalAccountIssuance: -1400,
+		JournalAccountRevenue:  180,
+		JournalAccountBreakage: 220,
+	})
+}
+
+func TestPlanGrantConsumptionSkipsExhaustedGrantsAndStopsWhenCovered(test *testing.T) {
+	test.Parallel()
+	accountID := mustAccountID(test, "acct-1")
+	newGrant := func(rawEntryID string, expiresAtUnixUTC int64, consumedCents AmountCents) Entry {
+		test.Helper()
+		entry, err := NewEntry(mustEntryID(test, rawEntryID), accountID, EntryGrant, mustEntryAmount(test, 100), nil, nil, mustIdempotencyKey(test, rawEntryID), expiresAtUnixUTC, mustMetadata(test, "{}"), 10)
+		if err != nil {
+			test.Fatalf("grant %s: %v", rawEntryID, err)
+		}
+		return entry.WithConsumedCents(consumedCents)
+	}
+	store := fixedConsumableGrantsStore{stubStore: newStubStore(test, 0), grants: []Entry{
+		newGrant("grant-exhausted", 200, 100),
+		newGrant("grant-early", 300, 0),
+		newGrant("grant-late", 400, 0),
+	}}
+	spend, err := NewEntry(mustEntryID(test, "spend-1"), accountID, EntrySpend, mustEntryAmount(test, -50), nil, nil, mustIdempotencyKey(test, "spend-1"), 0, mustMetadata(test, "{}"), 100)
+	if err != nil {
+		test.Fatalf("spend: %v", err)
+	}
+
+	consumptions, lines, err := planGrantConsumption(context.Background(), store, mustTenantID(test, defaultTenantIDValue), mustLedgerID(test, defaultLedgerIDValue), spend)
+	if err != nil {
+		test.Fatalf("plan grant consumption: %v", err)
+	}
+	if !reflect.DeepEqual(consumptions, []GrantConsumption{{GrantEntryID: mustEntryID(test, "grant-early"), AmountCents: 50}}) {
+		test.Fatalf("expected only the earliest grant with credit left to be consumed, got %+v", consumptions)
+	}
+	if len(lines) != 2 {
+		test.Fatalf("expected one pair of journal lines, got %+v", lines)
+	}
+}
+
+// fixedConsumableGrantsStore returns its grants as listed, including exhausted ones a store would normally skip.
+type fixedConsumableGrantsStore struct {
+	*stubStore
+	grants []Entry
+}
+
+func (store fixedConsumableGrantsStore) ListConsumableGrants(context.Context, AccountID, int64) ([]Entry, error) {
+	return store.grants, nil
+}
+
+func TestEntryBalanceCentsAtKeepsOnlyConsumedCreditAfterExpiry(test *testing.T) {
+	test.Parallel()
+	grant, err := NewEntry(mustEntryID(test, "grant-1"), mustAccountID(test, "acct-1"), EntryGrant, mustEntryAmount(test, 100), nil, nil, mustIdempotencyKey(test, "grant-1"), 200, mustMetadata(test, "{}"), 10)
+	if err != nil {
+		test.Fatalf("grant: %v", err)
+	}
+	grant = grant.WithConsumedCents(30)
+	if balance := grant.BalanceCentsAt(199); balance != 100 {
+		test.Fatalf("expected the full grant before expiry, got %d", balance)
+	}
+	if balance := grant.BalanceCentsAt(200); balance != 30 {
+		test.Fatalf("expected only the consumed part after expiry, got %d", balance)
+	}
+}
+
+func TestJournalLinesRollBackWithFailedOperations(test *testing.T) {
+	test.Parallel()
+	store := newStubStore(test, 0)
+	service := mustNewService(test, store)
+	operations := []BatchOperation{
+		{OperationID: "grant", Grant: &BatchGrantOperation{Amount: mustPositiveAmount(test, 100), IdempotencyKey: mustIdempotencyKey(test, "batch-grant"), Metadata: mustMetadata(test, "{}")}},
+		{OperationID: "spend", Spend: &BatchSpendOperation{Amount: mustPositiveAmount(test, 500), IdempotencyKey: mustIdempotencyKey(test, "batch-spend"), Metadata: mustMetadata(test, "{}")}},
+	}
+	if _, err := service.Batch(context.Background(), mustTenantID(test, defaultTenantIDValue), mustUserID(test, "user-1"), mustLedgerID(test, defaultLedgerIDValue), operations, true); err != nil {
+		test.Fatalf("batch: %v", err)
+	}
+	if len(store.journalLines) != 0 {
+		test.Fatalf("expected rolled back batch to leave no journal lines, got %+v", store.journalLines)
+	}
+}
+
+func TestTrialBalanceReturnsStoreErrors(test *testing.T) {
+	test.Parallel()
+	storeErr := errors.New("journal query failed")
+	service := mustNewService(test, newFailingStore(test, storeErr))
+	if _, err := service.TrialBalance(context.Background(), mustTenantID(test, defaultTenantIDValue), 0); !errors.Is(err, storeErr) {
+		test.Fatalf("expected store error, got %v", err)
+	}
+}
+
+func assertTrialBalance(test *testing.T, service *Service, atUnixUTC int64, want map[JournalAccount]int64) {
+	test.Helper()
+	trialBalance, err := service.TrialBalance(context.Background(), mustTenantID(test, defaultTenantIDValue), atUnixUTC)
+	if err != nil {
+		test.Fatalf("trial balance: %v", err)
+	}
+	if trialBalance.TotalCents != 0 {
+		test.Fatalf("expected trial balance at %d to sum to zero, got %d", atUnixUTC, trialBalance.TotalCents)
+	}
+	got := make(map[JournalAccount]int64, len(trialBalance.Lines))
+	for _, line := range trialBalance.Lines {
+		if line.LedgerID != mustLedgerID(test, defaultLedgerIDValue) {
+			test.Fatalf("unexpected ledger on trial balance line: %+v", line)
+		}
+		got[line.Account] = line.BalanceCents.Int64()
+	}
+	for account, amount := range want {
+		if got[account] != amount {
+			test.Fatalf("as of %d: expected %s=%d, got %+v", atUnixUTC, account, amount, got)
+		}
+	}
+	if len(got) != len(want) {
+		test.Fatalf("as of %d: expected accounts %+v, got %+v", atUnixUTC, want, got)
+	}
+}
+
+func TestInsertEntryWritesTheSameRowsThroughEntryBatchStore(test *testing.T) {
+	test.Parallel()
+	tenantID := mustTenantID(test, defaultTenantIDValue)
+	userID := mustUserID(test, "user-1")
+	ledgerID := mustLedgerID(test, defaultLedgerIDValue)
+	metadata := mustMetadata(test, "{}")
+	run := func(store Store) {
+		service, err := NewService(store, func() int64 { return 100 }, WithOutbox(tenantID))
+		if err != nil {
+			test.Fatalf("new service: %v", err)
+		}
+		ctx := context.Background()
+		if _, err := service.GrantEntry(ctx, tenantID, userID, ledgerID, mustPositiveAmount(test, 100), mustIdempotencyKey(test, "grant-early"), 200, metadata); err != nil {
+			test.Fatalf("grant: %v", err)
+		}
+		if _, err := service.SpendEntry(ctx, tenantID, userID, ledgerID, mustPositiveAmount(test, 60), mustIdempotencyKey(test, "spend-1"), metadata); err != nil {
+			test.Fatalf("spend: %v", err)
+		}
+	}
+
+	rowByRow := newStubStore(test, 0)
+	run(rowByRow)
+	batched := &batchingStore{stubStore: newStubStore(test, 0)}
+	run(batched)
+
+	if len(batched.writes) != 2 {
+		test.Fatalf("expected one batch per entry, got %d", len(batched.writes))
+	}
+	spendWrite := batched.writes[1]
+	if spendWrite.TenantID != tenantID || spendWrite.Link.Sequence != 2 || spendWrite.Link.PreviousHash != batched.writes[0].Link.Hash {
+		test.Fatalf("unexpected spend write: %+v", spendWrite)
+	}
+	if spendWrite.OutboxEvent == nil || spendWrite.OutboxEvent.EventType != "entry.spend" || len(spendWrite.GrantConsumptions) != 1 {
+		test.Fatalf("expected the spend's outbox event and grant consumption, got %+v", spendWrite)
+	}
+	if !reflect.DeepEqual(batched.chainLinks, rowByRow.chainLinks) ||
+		!reflect.DeepEqual(batched.changedEntryIDs, rowByRow.changedEntryIDs) ||
+		!reflect.DeepEqual(batched.consumedGrants, rowByRow.consumedGrants) ||
+		!reflect.DeepEqual(batched.outboxEvents, rowByRow.outboxEvents) ||
+		!reflect.DeepEqual(batched.journalLines, rowByRow.journalLines) {
+		test.Fatalf("batched rows differ from row-by-row rows:\n%+v\n%+v", batched.stubStore, rowByRow)
+	}
+}
+
+// batchingStore adds EntryBatchStore to stubStore: it applies each EntryWrite at once and records it.
+type batchingStore struct {
+	*stubStore
+	writes []EntryWrite
+}
+
+func (store *batchingStore) WithTx(ctx context.Context, fn func(ctx context.Context, txStore Store) error) error {
+	return store.stubStore.WithTx(ctx, func(ctx context.Context, txStore Store) error {
+		transactionStore := &batchingStore{stubStore: txStore.(*stubStore)}
+		if err := fn(ctx, transactionStore); err != nil {
+			return err
+		}
+		store.writes = append(store.writes, transactionStore.writes...)
+		return nil
+	})
+}
+
+func (store *batchingStore) InsertEntryBatch(ctx context.Context, entryInput EntryInput, prepare func(entry Entry, head ChainHead) (EntryWrite, error)) (Entry, error) {
+	head, err := store.LockChainHead(ctx, entryInput.AccountID())
+	if err != nil {
+		return Entry{}, err
+	}
+	entry, err := store.stubStore.InsertEntry(ctx, entryInput)
+	if err != nil {
+		return Entry{}, err
+	}
+	write, err := prepare(entry, head)
+	if err != nil {
+		return Entry{}, err
+	}
+	store.writes = append(store.writes, write)
+	store.chainLinks = append(store.chainLinks, write.Link)
+	store.changedEntryIDs = append(store.changedEntryIDs, entry.EntryID())
+	for _, consumption := range write.GrantConsumptions {
+		if err := store.ConsumeGrant(ctx, entry.AccountID(), consumption.GrantEntryID, consumption.AmountCents); err != nil {
+			return Entry{}, err
+		}
+	}
+	if write.OutboxEvent != nil {
+		store.outboxEvents = append(store.outboxEvents, *write.OutboxEvent)
+	}
+	store.journalLines = append(store.journalLines, write.JournalLines...)
+	return entry, nil
+}
@@ -0,0 +1,100 @@
+package ledger
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+const liabilityExpiryMonthLayout = "2006-01"
+
+// ExpiryTotal sums the balance-bearing entries of every account in one tenant ledger that share an expiry.
+// ExpiresAtUnixUTC is 0 for entries that never expire. AmountCents leaves out the part of expiring grants that
+// debits consumed; ConsumedCents is that part, which belongs to the users' balances even after the grants expire.
+type ExpiryTotal struct {
+	LedgerID         LedgerID
+	ExpiresAtUnixUTC int64
+	AmountCents      SignedAmountCents
+	ConsumedCents    AmountCents
+}
+
+// LiabilityBucket is the amount of one tenant ledger attributed to an expiry month (YYYY-MM, UTC).
+// ExpiryMonth is empty for credit that never expires.
+type LiabilityBucket struct {
+	LedgerID    LedgerID
+	ExpiryMonth string
+	AmountCents SignedAmountCents
+}
+
+// LiabilityReport aggregates a tenant's credit as of a point in time.
+// Outstanding is the balance still owed to users, split by the month it expires in; it sums to the
+// users' total balances. Breakage is the credit that expired unconsumed, by the month it expired in.
+type LiabilityReport struct {
+	AsOfUnixUTC int64
+	Outstanding []LiabilityBucket
+	Breakage    []LiabilityBucket
+}
+
+// LiabilityReport aggregates outstanding credit and breakage across all accounts of a tenant as of atUnixUTC (0 = now).
+// It requires a store that implements LiabilityStore.
+func (service *Service) LiabilityReport(ctx context.Context, tenantID TenantID, atUnixUTC int64) (LiabilityReport, error) {
+	if atUnixUTC == 0 {
+		atUnixUTC = service.nowFn()
+	}
+	liabilityStore, ok := service.store.(LiabilityStore)
+	if !ok {
+		return LiabilityReport{}, ErrLiabilityUnsupported
+	}
+	totals, err := liabilityStore.SumEntriesByExpiry(ctx, tenantID, atUnixUTC)
+	if err != nil {
+		return LiabilityReport{}, err
+	}
+	outstanding := make(map[liabilityKey]int64)
+	breakage := make(map[liabilityKey]int64)
+	for _, total := range totals {
+		key := liabilityKey{ledgerID: total.LedgerID}
+		if total.ExpiresAtUnixUTC != 0 {
+			key.expiryMonth = time.Unix(total.ExpiresAtUnixUTC, 0).UTC().Format(liabilityExpiryMonthLayout)
+		}
+		if total.ConsumedCents != 0 {
+			outstanding[liabilityKey{ledgerID: total.LedgerID}] += total.ConsumedCents.Int64()
+		}
+		if total.ExpiresAtUnixUTC != 0 && total.ExpiresAtUnixUTC <= atUnixUTC {
+			breakage[key] += total.AmountCents.Int64()
+			continue
+		}
+		outstanding[key] += total.AmountCents.Int64()
+	}
+	return LiabilityReport{
+		AsOfUnixUTC: atUnixUTC,
+		Outstanding: liabilityBuckets(outstanding),
+		Breakage:    liabilityBuckets(breakage),
+	}, nil
+}
+
+type liabilityKey struct {
+	ledgerID    LedgerID
+	expiryMonth string
+}
+
+// liabilityBuckets drops buckets that net to zero and orders the rest by ledger and expiry month,
+// with non-expiring credit last.
+func liabilityBuckets(amounts map[liabilityKey]int64) []LiabilityBucket {
+	buckets := make([]LiabilityBucket, 0, len(amounts))
+	for key, amount := range amounts {
+		if amount == 0 {
+			continue
+		}
+		buckets = append(buckets, LiabilityBucket{LedgerID: key.ledgerID, ExpiryMonth: key.expiryMonth, AmountCents: SignedAmountCents(amount)})
+	}
+	sort.Slice(buckets, func(left, right int) bool {
+		if buckets[left].LedgerID != buckets[right].LedgerID {
+			return buckets[left].LedgerID.String() < buckets[right].LedgerID.String()
+		}
+		if buckets[left].ExpiryMonth == "" || buckets[right].ExpiryMonth == "" {
+			return buckets[right].ExpiryMonth == ""
+		}
+		return buckets[left].ExpiryMonth < buckets[right].ExpiryMonth
+	})
+	return buckets
+}
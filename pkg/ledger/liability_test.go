@@ -0,0 +1,103 @@
+package ledger
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestLiabilityReportBucketsByExpiryMonth(test *testing.T) {
+	test.Parallel()
+	store := newStubStore(test, 0)
+	defaultLedgerID := mustLedgerID(test, defaultLedgerIDValue)
+	promoLedgerID := mustLedgerID(test, "promo")
+	const (
+		november2023 = int64(1699999999)
+		december2023 = int64(1701388800)
+		january2024  = int64(1704067200)
+	)
+	store.expiryTotals = []ExpiryTotal{
+		{LedgerID: defaultLedgerID, ExpiresAtUnixUTC: 0, AmountCents: 700},
+		{LedgerID: defaultLedgerID, ExpiresAtUnixUTC: january2024, AmountCents: 300},
+		{LedgerID: defaultLedgerID, ExpiresAtUnixUTC: december2023, AmountCents: 200},
+		{LedgerID: defaultLedgerID, ExpiresAtUnixUTC: december2023 + 86400, AmountCents: 50},
+		{LedgerID: defaultLedgerID, ExpiresAtUnixUTC: november2023, AmountCents: 40},
+		{LedgerID: promoLedgerID, ExpiresAtUnixUTC: january2024, AmountCents: 0},
+		{LedgerID: promoLedgerID, ExpiresAtUnixUTC: november2023 - 60, AmountCents: 10},
+	}
+	service, err := NewService(store, func() int64 { return 1700000000 })
+	if err != nil {
+		test.Fatalf("new service: %v", err)
+	}
+
+	report, err := service.LiabilityReport(context.Background(), mustTenantID(test, defaultTenantIDValue), 0)
+	if err != nil {
+		test.Fatalf("liability report: %v", err)
+	}
+	if report.AsOfUnixUTC != 1700000000 {
+		test.Fatalf("expected as-of to default to now, got %d", report.AsOfUnixUTC)
+	}
+	expectedOutstanding := []LiabilityBucket{
+		{LedgerID: defaultLedgerID, ExpiryMonth: "2023-12", AmountCents: 250},
+		{LedgerID: defaultLedgerID, ExpiryMonth: "2024-01", AmountCents: 300},
+		{LedgerID: defaultLedgerID, ExpiryMonth: "", AmountCents: 700},
+	}
+	assertLiabilityBuckets(test, report.Outstanding, expectedOutstanding)
+	expectedBreakage := []LiabilityBucket{
+		{LedgerID: defaultLedgerID, ExpiryMonth: "2023-11", AmountCents: 40},
+		{LedgerID: promoLedgerID, ExpiryMonth: "2023-11", AmountCents: 10},
+	}
+	assertLiabilityBuckets(test, report.Breakage, expectedBreakage)
+
+	later, err := service.LiabilityReport(context.Background(), mustTenantID(test, defaultTenantIDValue), january2024)
+	if err != nil {
+		test.Fatalf("liability report: %v", err)
+	}
+	assertLiabilityBuckets(test, later.Outstanding, []LiabilityBucket{{LedgerID: defaultLedgerID, ExpiryMonth: "", AmountCents: 700}})
+	if len(later.Breakage) != 4 || later.Breakage[2].ExpiryMonth != "2024-01" || later.Breakage[2].AmountCents != 300 {
+		test.Fatalf("expected january expiry to move into breakage, got %+v", later.Breakage)
+	}
+}
+
+func TestLiabilityReportCountsConsumedCreditAsOutstanding(test *testing.T) {
+	test.Parallel()
+	store := newStubStore(test, 0)
+	defaultLedgerID := mustLedgerID(test, defaultLedgerIDValue)
+	store.expiryTotals = []ExpiryTotal{
+		{LedgerID: defaultLedgerID, ExpiresAtUnixUTC: 1699999999, AmountCents: 40, ConsumedCents: 60},
+	}
+	service, err := NewService(store, func() int64 { return 1700000000 })
+	if err != nil {
+		test.Fatalf("new service: %v", err)
+	}
+
+	report, err := service.LiabilityReport(context.Background(), mustTenantID(test, defaultTenantIDValue), 0)
+	if err != nil {
+		test.Fatalf("liability report: %v", err)
+	}
+	assertLiabilityBuckets(test, report.Outstanding, []LiabilityBucket{{LedgerID: defaultLedgerID, ExpiryMonth: "", AmountCents: 60}})
+	assertLiabilityBuckets(test, report.Breakage, []LiabilityBucket{{LedgerID: defaultLedgerID, ExpiryMonth: "2023-11", AmountCents: 40}})
+}
+
+func TestLiabilityReportReturnsStoreErrors(test *testing.T) {
+	test.Parallel()
+	storeErr := errors.New("expiry query failed")
+	store := newStubStore(test, 0)
+	store.expiryTotalsError = storeErr
+	service := mustNewService(test, store)
+	if _, err := service.LiabilityReport(context.Background(), mustTenantID(test, defaultTenantIDValue), 0); !errors.Is(err, storeErr) {
+		test.Fatalf("expected store error, got %v", err)
+	}
+}
+
+func assertLiabilityBuckets(test *testing.T, got []LiabilityBucket, want []LiabilityBucket) {
+	test.Helper()
+	if len(got) != len(want) {
+		test.Fatalf("expected buckets %+v, got %+v", want, got)
+	}
+	for index := range want {
+		if got[index] != want[index] {
+			test.Fatalf("bucket %d: expected %+v, got %+v", index, want[index], got[index])
+		}
+	}
+}
@@ -22,6 +22,24 @@ type OperationLog struct {
 	Metadata       MetadataJSON
 	Status         string
 	Error          error
+	// ThresholdCrossing is set on balance_threshold_crossed events.
+	ThresholdCrossing *BalanceThresholdCrossing
+	// BatchID groups the records of one Batch call: one per operation and a closing summary.
+	BatchID string
+	// OperationID is the caller-supplied ID of a batch operation.
+	OperationID string
+	// Batch is set on the summary record of a Batch call.
+	Batch *BatchSummary
+}
+
+// BatchSummary counts the outcomes of a Batch call.
+type BatchSummary struct {
+	Atomic     bool
+	Operations int
+	Succeeded  int
+	Duplicates int
+	Failed     int
+	RolledBack int
 }
 
 // WithOperationLogger wires a logger that receives callbacks for every operation.
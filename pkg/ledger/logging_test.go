@@ -68,3 +68,68 @@ func TestServiceLogsErrorStatus(test *testing.T) {
 		test.Fatalf("expected error log entry, got %+v", logger.entries[0])
 	}
 }
+
+func TestBatchLogsEachOperationAndSummary(test *testing.T) {
+	test.Parallel()
+	store := newStubStore(test, mustSignedAmount(test, 0))
+	logger := &recorderLogger{}
+	service, err := NewService(store, func() int64 { return 100 }, WithOperationLogger(logger))
+	if err != nil {
+		test.Fatalf("service init failed: %v", err)
+	}
+	tenantID := mustTenantID(test, defaultTenantIDValue)
+	userID := mustUserID(test, "user-123")
+	ledgerID := mustLedgerID(test, defaultLedgerIDValue)
+	ctx := context.Background()
+
+	if _, err := service.Batch(ctx, tenantID, userID, ledgerID, []BatchOperation{newBatchGrantOperation(test, "grant-1", 100, "grant-1")}, false); err != nil {
+		test.Fatalf("seed batch: %v", err)
+	}
+	logger.entries = nil
+	operations := []BatchOperation{
+		newBatchGrantOperation(test, "grant-dup", 100, "grant-1"),
+		newBatchSpendOperation(test, "spend-1", 50, "spend-1"),
+		newBatchSpendOperation(test, "spend-2", 1000, "spend-2"),
+	}
+	if _, err := service.Batch(ctx, tenantID, userID, ledgerID, operations, false); err != nil {
+		test.Fatalf("batch: %v", err)
+	}
+	assertBatchLogs(test, logger.entries, []string{operationStatusDuplicate, operationStatusOK, operationStatusError}, operationStatusError, BatchSummary{Operations: 3, Succeeded: 1, Duplicates: 1, Failed: 1})
+	if logger.entries[1].Operation != operationSpend || logger.entries[1].Amount != 50 || logger.entries[1].OperationID != "spend-1" {
+		test.Fatalf("unexpected spend log: %+v", logger.entries[1])
+	}
+	firstBatchID := logger.entries[0].BatchID
+
+	logger.entries = nil
+	operations = []BatchOperation{
+		newBatchSpendOperation(test, "spend-3", 10, "spend-3"),
+		newBatchSpendOperation(test, "spend-4", 1000, "spend-4"),
+	}
+	if _, err := service.Batch(ctx, tenantID, userID, ledgerID, operations, true); err != nil {
+		test.Fatalf("atomic batch: %v", err)
+	}
+	assertBatchLogs(test, logger.entries, []string{operationStatusRolledBack, operationStatusError}, operationStatusRolledBack, BatchSummary{Atomic: true, Operations: 2, Failed: 1, RolledBack: 1})
+	if logger.entries[0].BatchID == firstBatchID {
+		test.Fatalf("expected a new batch id per call")
+	}
+}
+
+func assertBatchLogs(test *testing.T, entries []OperationLog, operationStatuses []string, summaryStatus string, summary BatchSummary) {
+	test.Helper()
+	if len(entries) != len(operationStatuses)+1 {
+		test.Fatalf("expected %d log entries, got %d: %+v", len(operationStatuses)+1, len(entries), entries)
+	}
+	batchID := entries[0].BatchID
+	if batchID == "" {
+		test.Fatalf("expected batch id on %+v", entries[0])
+	}
+	for index, status := range operationStatuses {
+		if entries[index].Status != status || entries[index].BatchID != batchID || entries[index].OperationID == "" || entries[index].Batch != nil {
+			test.Fatalf("entry %d: expected status %s, got %+v", index, status, entries[index])
+		}
+	}
+	last := entries[len(entries)-1]
+	if last.Operation != operationBatch || last.Status != summaryStatus || last.BatchID != batchID || last.Batch == nil || *last.Batch != summary {
+		test.Fatalf("unexpected summary %+v (%+v)", last, last.Batch)
+	}
+}
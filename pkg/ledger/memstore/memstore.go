@@ -0,0 +1,1019 @@
+// Package memstore provides an in-memory ledger.Store for tests, simulators, and other short-lived processes.
+package memstore
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/MarkoPoloResearchLab/ledger/pkg/ledger"
+	"github.com/google/uuid"
+)
+
+const (
+	errorOperationStore     = "store"
+	errorSubjectAccount     = "account"
+	errorSubjectArchive     = "archive"
+	errorSubjectBalance     = "balance"
+	errorSubjectChain       = "chain"
+	errorSubjectEntry       = "entry"
+	errorSubjectEntryFeed   = "entry_feed"
+	errorSubjectReservation = "reservation"
+	errorCodeDelete         = "delete"
+	errorCodeDuplicate      = "duplicate"
+	errorCodeGet            = "get"
+	errorCodeInsert         = "insert"
+	errorCodeInvalid        = "invalid"
+	errorCodeLock           = "lock"
+	errorCodeUpdate         = "update"
+	errorCodeUpdateStatus   = "update_status"
+)
+
+var (
+	errDuplicateEntryChange = errors.New("entry already in change feed")
+	errDuplicateChainLink   = errors.New("chain sequence already linked")
+	errMissingChainHead     = errors.New("chain head not locked")
+)
+
+// Store implements ledger.Store and ledger.ArchiveStore in memory. It is safe for concurrent use: every call,
+// and every WithTx callback as a whole, runs under one lock, so transactions are serializable. Nested WithTx
+// calls behave like savepoints: a failing inner callback rolls back only its own writes.
+//
+// A WithTx callback must use the transaction store it receives; calling the outer Store from inside the
+// callback blocks forever.
+type Store struct {
+	lock          *sync.Mutex
+	state         *state
+	inTransaction bool
+}
+
+type state struct {
+	accounts       map[string]*accountRecord
+	accountsByKey  map[accountKey]string
+	accountOrder   []string
+	entries        map[string]*entryRecord
+	accountEntries map[string][]string
+	idempotency    map[string]map[string]string
+	reservations   map[string]map[string]*reservationRecord
+	reservationIDs map[string][]string
+	journalLines   []ledger.JournalLine
+	feedHeads      map[string]int64
+	entryChanges   map[string][]entryChangeRecord
+	changedEntries map[string]bool
+	outboxEvents   []ledger.OutboxEvent
+	chainHeads     map[string]ledger.ChainHead
+	chainSequences map[string]map[int64]string
+	archives       map[string]ledger.EntryArchive
+	// archivedKeys and archivedEntries keep the idempotency keys and IDs of archived entries, by account.
+	archivedKeys    map[string]map[string]string
+	archivedEntries map[string]string
+	// undo holds the inverse of every write made by the open transaction, oldest first; nil outside one.
+	undo []func()
+}
+
+type accountKey struct {
+	tenantID string
+	userID   string
+	ledgerID string
+}
+
+type accountRecord struct {
+	account ledger.Account
+	key     accountKey
+}
+
+type entryRecord struct {
+	entry ledger.Entry
+	link  *ledger.ChainLink
+}
+
+type reservationRecord struct {
+	reservation ledger.Reservation
+}
+
+type entryChangeRecord struct {
+	sequence int64
+	entryID  string
+}
+
+// New returns an empty in-memory Store.
+func New() *Store {
+	return &Store{
+		lock: &sync.Mutex{},
+		state: &state{
+			accounts:        map[string]*accountRecord{},
+			accountsByKey:   map[accountKey]string{},
+			entries:         map[string]*entryRecord{},
+			accountEntries:  map[string][]string{},
+			idempotency:     map[string]map[string]string{},
+			reservations:    map[string]map[string]*reservationRecord{},
+			reservationIDs:  map[string][]string{},
+			feedHeads:       map[string]int64{},
+			entryChanges:    map[string][]entryChangeRecord{},
+			changedEntries:  map[string]bool{},
+			chainHeads:      map[string]ledger.ChainHead{},
+			chainSequences:  map[string]map[int64]string{},
+			archives:        map[string]ledger.EntryArchive{},
+			archivedKeys:    map[string]map[string]string{},
+			archivedEntries: map[string]string{},
+		},
+	}
+}
+
+// WithTx runs fn against a transaction store. Writes made through it are discarded when fn returns an
+// error or panics.
+func (store *Store) WithTx(ctx context.Context, fn func(ctx context.Context, txStore ledger.Store) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if !store.inTransaction {
+		store.lock.Lock()
+		defer store.lock.Unlock()
+		store.state.undo = []func(){}
+		defer func() { store.state.undo = nil }()
+	}
+	savepoint := len(store.state.undo)
+	committed := false
+	defer func() {
+		if !committed {
+			store.state.rollback(savepoint)
+		}
+	}()
+	if err := fn(ctx, &Store{lock: store.lock, state: store.state, inTransaction: true}); err != nil {
+		return err
+	}
+	committed = true
+	return nil
+}
+
+func (store *Store) GetOrCreateAccountID(ctx context.Context, tenantID ledger.TenantID, userID ledger.UserID, ledgerID ledger.LedgerID) (ledger.AccountID, error) {
+	var accountID ledger.AccountID
+	err := store.run(ctx, func(state *state) error {
+		key := accountKey{tenantID: tenantID.String(), userID: userID.String(), ledgerID: ledgerID.String()}
+		if existing, ok := state.accountsByKey[key]; ok {
+			accountID = state.accounts[existing].account.AccountID()
+			return nil
+		}
+		newAccountID, err := ledger.NewAccountID(uuid.NewString())
+		if err != nil {
+			return wrapStoreError(errorSubjectAccount, errorCodeInvalid, err)
+		}
+		account, err := ledger.NewAccount(newAccountID, tenantID, userID, ledgerID, time.Now().UTC().Unix())
+		if err != nil {
+			return wrapStoreError(errorSubjectAccount, errorCodeInvalid, err)
+		}
+		id := newAccountID.String()
+		state.accounts[id] = &accountRecord{account: account, key: key}
+		state.accountsByKey[key] = id
+		state.accountOrder = append(state.accountOrder, id)
+		state.record(func() {
+			delete(state.accounts, id)
+			delete(state.accountsByKey, key)
+			state.accountOrder = state.accountOrder[:len(state.accountOrder)-1]
+		})
+		accountID = newAccountID
+		return nil
+	})
+	return accountID, err
+}
+
+func (store *Store) GetAccountID(ctx context.Context, tenantID ledger.TenantID, userID ledger.UserID, ledgerID ledger.LedgerID) (ledger.AccountID, error) {
+	var accountID ledger.AccountID
+	err := store.run(ctx, func(state *state) error {
+		existing, ok := state.accountsByKey[accountKey{tenantID: tenantID.String(), userID: userID.String(), ledgerID: ledgerID.String()}]
+		if !ok {
+			return wrapStoreError(errorSubjectAccount, errorCodeGet, ledger.ErrUnknownAccount)
+		}
+		accountID = state.accounts[existing].account.AccountID()
+		return nil
+	})
+	return accountID, err
+}
+
+func (store *Store) ListAccounts(ctx context.Context, tenantID ledger.TenantID, beforeCreatedUnixUTC int64, limit int, filter ledger.ListAccountsFilter) ([]ledger.Account, error) {
+	before := beforeOrNow(beforeCreatedUnixUTC)
+	var accounts []ledger.Account
+	err := store.run(ctx, func(state *state) error {
+		for index := len(state.accountOrder) - 1; index >= 0; index-- {
+			record := state.accounts[state.accountOrder[index]]
+			account := record.account
+			if record.key.tenantID != tenantID.String() || account.CreatedUnixUTC() >= before {
+				continue
+			}
+			if filter.LedgerID != nil && record.key.ledgerID != filter.LedgerID.String() {
+				continue
+			}
+			if filter.UserIDPrefix != "" && !strings.HasPrefix(record.key.userID, filter.UserIDPrefix) {
+				continue
+			}
+			if filter.CreatedAfterUnixUTC != 0 && account.CreatedUnixUTC() < filter.CreatedAfterUnixUTC {
+				continue
+			}
+			if filter.NonZeroBalance && state.sumTotal(account.AccountID().String(), filter.BalanceAtUnixUTC) == 0 {
+				continue
+			}
+			accounts = append(accounts, account)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(accounts, func(left, right int) bool {
+		return accounts[left].CreatedUnixUTC() > accounts[right].CreatedUnixUTC()
+	})
+	return applyLimit(accounts, limit), nil
+}
+
+func (store *Store) InsertEntry(ctx context.Context, entryInput ledger.EntryInput) (ledger.Entry, error) {
+	var entry ledger.Entry
+	err := store.run(ctx, func(state *state) error {
+		accountID := entryInput.AccountID().String()
+		idempotencyKey := entryInput.IdempotencyKey().String()
+		if _, ok := state.idempotency[accountID][idempotencyKey]; ok {
+			return wrapStoreError(errorSubjectEntry, errorCodeDuplicate, ledger.ErrDuplicateIdempotencyKey)
+		}
+		if _, ok := state.archivedKeys[accountID][idempotencyKey]; ok {
+			return wrapStoreError(errorSubjectEntry, errorCodeDuplicate, ledger.ErrDuplicateIdempotencyKey)
+		}
+		entryID, err := ledger.NewEntryID(uuid.NewString())
+		if err != nil {
+			return wrapStoreError(errorSubjectEntry, errorCodeInvalid, err)
+		}
+		createdUnixUTC := entryInput.CreatedUnixUTC()
+		if createdUnixUTC == 0 {
+			createdUnixUTC = time.Now().UTC().Unix()
+		}
+		metadata, err := ledger.NewMetadataJSON(entryInput.MetadataJSON().String())
+		if err != nil {
+			return wrapStoreError(errorSubjectEntry, errorCodeInvalid, err)
+		}
+		var reservationID *ledger.ReservationID
+		if value, ok := entryInput.ReservationID(); ok {
+			reservationID = &value
+		}
+		var refundOfEntryID *ledger.EntryID
+		if value, ok := entryInput.RefundOfEntryID(); ok {
+			refundOfEntryID = &value
+		}
+		created, err := ledger.NewEntry(entryID, entryInput.AccountID(), entryInput.Type(), entryInput.AmountCents(), reservationID, refundOfEntryID, entryInput.IdempotencyKey(), entryInput.ExpiresAtUnixUTC(), metadata, createdUnixUTC)
+		if err != nil {
+			return wrapStoreError(errorSubjectEntry, errorCodeInvalid, err)
+		}
+		entry = created.WithEffectiveAtUnixUTC(entryInput.EffectiveAtUnixUTC()).WithActor(entryInput.Actor())
+
+		id := entryID.String()
+		state.entries[id] = &entryRecord{entry: entry}
+		state.accountEntries[accountID] = append(state.accountEntries[accountID], id)
+		if state.idempotency[accountID] == nil {
+			state.idempotency[accountID] = map[string]string{}
+		}
+		state.idempotency[accountID][idempotencyKey] = id
+		state.record(func() {
+			delete(state.entries, id)
+			state.accountEntries[accountID] = state.accountEntries[accountID][:len(state.accountEntries[accountID])-1]
+			delete(state.idempotency[accountID], idempotencyKey)
+		})
+		return nil
+	})
+	return entry, err
+}
+
+func (store *Store) GetEntry(ctx context.Context, accountID ledger.AccountID, entryID ledger.EntryID) (ledger.Entry, error) {
+	var entry ledger.Entry
+	err := store.run(ctx, func(state *state) error {
+		record, ok := state.entries[entryID.String()]
+		if !ok || record.entry.AccountID() != accountID {
+			if state.archivedEntries[entryID.String()] == accountID.String() {
+				return wrapStoreError(errorSubjectEntry, errorCodeGet, ledger.ErrArchivedEntry)
+			}
+			return wrapStoreError(errorSubjectEntry, errorCodeGet, ledger.ErrUnknownEntry)
+		}
+		entry = record.entry
+		return nil
+	})
+	return entry, err
+}
+
+func (store *Store) GetEntryByIdempotencyKey(ctx context.Context, accountID ledger.AccountID, idempotencyKey ledger.IdempotencyKey) (ledger.Entry, error) {
+	var entry ledger.Entry
+	err := store.run(ctx, func(state *state) error {
+		id, ok := state.idempotency[accountID.String()][idempotencyKey.String()]
+		if !ok {
+			if _, archived := state.archivedKeys[accountID.String()][idempotencyKey.String()]; archived {
+				return wrapStoreError(errorSubjectEntry, errorCodeGet, ledger.ErrArchivedEntry)
+			}
+			return wrapStoreError(errorSubjectEntry, errorCodeGet, ledger.ErrUnknownEntry)
+		}
+		entry = state.entries[id].entry
+		return nil
+	})
+	return entry, err
+}
+
+func (store *Store) SumRefunds(ctx context.Context, accountID ledger.AccountID, originalEntryID ledger.EntryID) (ledger.AmountCents, error) {
+	var refunded ledger.AmountCents
+	err := store.run(ctx, func(state *state) error {
+		var total int64
+		for _, record := range state.accountEntryRecords(accountID.String()) {
+			refundOf, ok := record.entry.RefundOfEntryID()
+			if record.entry.Type() == ledger.EntryRefund && ok && refundOf == originalEntryID {
+				total += record.entry.AmountCents().Int64()
+			}
+		}
+		amount, err := ledger.NewAmountCents(total)
+		if err != nil {
+			return wrapStoreError(errorSubjectBalance, errorCodeInvalid, err)
+		}
+		refunded = amount
+		return nil
+	})
+	return refunded, err
+}
+
+func (store *Store) SumTotal(ctx context.Context, accountID ledger.AccountID, atUnixUTC int64) (ledger.SignedAmountCents, error) {
+	var total ledger.SignedAmountCents
+	err := store.run(ctx, func(state *state) error {
+		total = ledger.SignedAmountCents(state.sumTotal(accountID.String(), atUnixUTC))
+		return nil
+	})
+	return total, err
+}
+
+func (store *Store) SumActiveHolds(ctx context.Context, accountID ledger.AccountID, atUnixUTC int64) (ledger.AmountCents, error) {
+	var activeHolds ledger.AmountCents
+	err := store.run(ctx, func(state *state) error {
+		var total int64
+		for _, record := range state.reservations[accountID.String()] {
+			reservation := record.reservation
+			if reservation.Status() != ledger.ReservationStatusActive {
+				continue
+			}
+			if reservation.ExpiresAtUnixUTC() != 0 && reservation.ExpiresAtUnixUTC() <= atUnixUTC {
+				continue
+			}
+			total += reservation.AmountCents().Int64()
+		}
+		amount, err := ledger.NewAmountCents(total)
+		if err != nil {
+			return wrapStoreError(errorSubjectBalance, errorCodeInvalid, err)
+		}
+		activeHolds = amount
+		return nil
+	})
+	return activeHolds, err
+}
+
+func (store *Store) SumDebitVelocity(ctx context.Context, accountID ledger.AccountID, sinceUnixUTC int64) (ledger.DebitVelocity, error) {
+	var velocity ledger.DebitVelocity
+	err := store.run(ctx, func(state *state) error {
+		var total int64
+		records := state.accountEntryRecords(accountID.String())
+		windowHolds := make(map[ledger.ReservationID]struct{})
+		for _, record := range records {
+			if reservationID, ok := record.entry.ReservationID(); ok && record.entry.Type() == ledger.EntryHold && record.entry.CreatedUnixUTC() >= sinceUnixUTC {
+				windowHolds[reservationID] = struct{}{}
+			}
+		}
+		for _, record := range records {
+			entry := record.entry
+			switch entry.Type() {
+			case ledger.EntrySpend, ledger.EntryHold:
+			case ledger.EntryReverseHold:
+				reservationID, _ := entry.ReservationID()
+				if _, ok := windowHolds[reservationID]; !ok {
+					continue
+				}
+			default:
+				continue
+			}
+			if entry.CreatedUnixUTC() < sinceUnixUTC {
+				continue
+			}
+			total += entry.AmountCents().Int64()
+			if _, hasReservation := entry.ReservationID(); entry.Type() == ledger.EntryHold || !hasReservation {
+				velocity.Operations++
+			}
+		}
+		velocity.AmountCents = ledger.AmountCents(max(-total, 0))
+		return nil
+	})
+	return velocity, err
+}
+
+// ListConsumableGrants returns the account's effective grants that expire after atUnixUTC and are not fully
+// consumed, earliest expiry first.
+func (store *Store) ListConsumableGrants(ctx context.Context, accountID ledger.AccountID, atUnixUTC int64) ([]ledger.Entry, error) {
+	var grants []ledger.Entry
+	err := store.run(ctx, func(state *state) error {
+		for _, record := range state.accountEntryRecords(accountID.String()) {
+			entry := record.entry
+			if entry.Type() != ledger.EntryGrant || entry.ExpiresAtUnixUTC() <= atUnixUTC || entry.IsPendingAt(atUnixUTC) {
+				continue
+			}
+			if entry.ConsumedCents().Int64() >= entry.AmountCents().Int64() {
+				continue
+			}
+			grants = append(grants, entry)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(grants, func(left, right int) bool {
+		if grants[left].ExpiresAtUnixUTC() != grants[right].ExpiresAtUnixUTC() {
+			return grants[left].ExpiresAtUnixUTC() < grants[right].ExpiresAtUnixUTC()
+		}
+		return grants[left].CreatedUnixUTC() < grants[right].CreatedUnixUTC()
+	})
+	return grants, nil
+}
+
+// ConsumeGrant adds amountCents to the consumed part of one of the account's grants.
+func (store *Store) ConsumeGrant(ctx context.Context, accountID ledger.AccountID, entryID ledger.EntryID, amountCents ledger.AmountCents) error {
+	return store.run(ctx, func(state *state) error {
+		record, ok := state.entries[entryID.String()]
+		if !ok || record.entry.AccountID() != accountID || record.entry.Type() != ledger.EntryGrant {
+			return wrapStoreError(errorSubjectEntry, errorCodeUpdate, ledger.ErrUnknownEntry)
+		}
+		current := record.entry
+		record.entry = current.WithConsumedCents(current.ConsumedCents() + amountCents)
+		state.record(func() { record.entry = current })
+		return nil
+	})
+}
+
+func (store *Store) CreateReservation(ctx context.Context, reservation ledger.Reservation) error {
+	return store.run(ctx, func(state *state) error {
+		accountID := reservation.AccountID().String()
+		reservationID := reservation.ReservationID().String()
+		if _, ok := state.reservations[accountID][reservationID]; ok {
+			return wrapStoreError(errorSubjectReservation, errorCodeDuplicate, ledger.ErrReservationExists)
+		}
+		nowUnixUTC := time.Now().UTC().Unix()
+		stored, err := ledger.NewReservationWithTimestamps(reservation.AccountID(), reservation.ReservationID(), reservation.AmountCents(), reservation.Status(), reservation.ExpiresAtUnixUTC(), nowUnixUTC, nowUnixUTC)
+		if err != nil {
+			return wrapStoreError(errorSubjectReservation, errorCodeInvalid, err)
+		}
+		if state.reservations[accountID] == nil {
+			state.reservations[accountID] = map[string]*reservationRecord{}
+		}
+		state.reservations[accountID][reservationID] = &reservationRecord{reservation: stored}
+		state.reservationIDs[accountID] = append(state.reservationIDs[accountID], reservationID)
+		state.record(func() {
+			delete(state.reservations[accountID], reservationID)
+			state.reservationIDs[accountID] = state.reservationIDs[accountID][:len(state.reservationIDs[accountID])-1]
+		})
+		return nil
+	})
+}
+
+func (store *Store) GetReservation(ctx context.Context, accountID ledger.AccountID, reservationID ledger.ReservationID) (ledger.Reservation, error) {
+	var reservation ledger.Reservation
+	err := store.run(ctx, func(state *state) error {
+		record, ok := state.reservations[accountID.String()][reservationID.String()]
+		if !ok {
+			return wrapStoreError(errorSubjectReservation, errorCodeGet, ledger.ErrUnknownReservation)
+		}
+		reservation = record.reservation
+		return nil
+	})
+	return reservation, err
+}
+
+func (store *Store) UpdateReservationStatus(ctx context.Context, accountID ledger.AccountID, reservationID ledger.ReservationID, from, to ledger.ReservationStatus) error {
+	return store.run(ctx, func(state *state) error {
+		record, ok := state.reservations[accountID.String()][reservationID.String()]
+		if !ok || record.reservation.Status() != from {
+			return wrapStoreError(errorSubjectReservation, errorCodeUpdateStatus, ledger.ErrReservationClosed)
+		}
+		current := record.reservation
+		updated, err := ledger.NewReservationWithTimestamps(current.AccountID(), current.ReservationID(), current.AmountCents(), to, current.ExpiresAtUnixUTC(), current.CreatedUnixUTC(), time.Now().UTC().Unix())
+		if err != nil {
+			return wrapStoreError(errorSubjectReservation, errorCodeUpdateStatus, err)
+		}
+		record.reservation = updated
+		state.record(func() { record.reservation = current })
+		return nil
+	})
+}
+
+func (store *Store) ListReservations(ctx context.Context, accountID ledger.AccountID, beforeCreatedUnixUTC int64, limit int, filter ledger.ListReservationsFilter) ([]ledger.Reservation, error) {
+	before := beforeOrNow(beforeCreatedUnixUTC)
+	var reservations []ledger.Reservation
+	err := store.run(ctx, func(state *state) error {
+		reservationIDs := state.reservationIDs[accountID.String()]
+		for index := len(reservationIDs) - 1; index >= 0; index-- {
+			reservation := state.reservations[accountID.String()][reservationIDs[index]].reservation
+			if reservation.CreatedUnixUTC() >= before {
+				continue
+			}
+			if len(filter.Statuses) > 0 && !containsStatus(filter.Statuses, reservation.Status()) {
+				continue
+			}
+			reservations = append(reservations, reservation)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(reservations, func(left, right int) bool {
+		return reservations[left].CreatedUnixUTC() > reservations[right].CreatedUnixUTC()
+	})
+	return applyLimit(reservations, limit), nil
+}
+
+func (store *Store) ListEntries(ctx context.Context, accountID ledger.AccountID, beforeUnixUTC int64, limit int, filter ledger.ListEntriesFilter) ([]ledger.Entry, error) {
+	before := beforeOrNow(beforeUnixUTC)
+	var entries []ledger.Entry
+	err := store.run(ctx, func(state *state) error {
+		records := state.accountEntryRecords(accountID.String())
+		for index := len(records) - 1; index >= 0; index-- {
+			entry := records[index].entry
+			if !entryBeforeCursor(entry, before, filter.BeforeEntryID) || !matchesEntryFilter(entry, filter) {
+				continue
+			}
+			entries = append(entries, entry)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(entries, func(left, right int) bool {
+		if entries[left].CreatedUnixUTC() != entries[right].CreatedUnixUTC() {
+			return entries[left].CreatedUnixUTC() > entries[right].CreatedUnixUTC()
+		}
+		return entries[left].EntryID().String() > entries[right].EntryID().String()
+	})
+	return applyLimit(entries, limit), nil
+}
+
+func (store *Store) InsertJournalLines(ctx context.Context, lines []ledger.JournalLine) error {
+	if len(lines) == 0 {
+		return nil
+	}
+	return store.run(ctx, func(state *state) error {
+		previousLength := len(state.journalLines)
+		state.journalLines = append(state.journalLines, lines...)
+		state.record(func() { state.journalLines = state.journalLines[:previousLength] })
+		return nil
+	})
+}
+
+func (store *Store) SumJournalLines(ctx context.Context, tenantID ledger.TenantID, atUnixUTC int64) ([]ledger.TrialBalanceLine, error) {
+	type journalKey struct {
+		ledgerID string
+		account  string
+	}
+	var balances []ledger.TrialBalanceLine
+	err := store.run(ctx, func(state *state) error {
+		totals := map[journalKey]*ledger.TrialBalanceLine{}
+		for _, line := range state.journalLines {
+			if line.TenantID != tenantID || line.PostedUnixUTC > atUnixUTC {
+				continue
+			}
+			key := journalKey{ledgerID: line.LedgerID.String(), account: line.Account.String()}
+			total, ok := totals[key]
+			if !ok {
+				total = &ledger.TrialBalanceLine{LedgerID: line.LedgerID, Account: line.Account}
+				totals[key] = total
+			}
+			total.BalanceCents += line.AmountCents
+		}
+		balances = make([]ledger.TrialBalanceLine, 0, len(totals))
+		for _, total := range totals {
+			balances = append(balances, *total)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(balances, func(left, right int) bool {
+		if balances[left].LedgerID != balances[right].LedgerID {
+			return balances[left].LedgerID.String() < balances[right].LedgerID.String()
+		}
+		return balances[left].Account.String() < balances[right].Account.String()
+	})
+	return balances, nil
+}
+
+func (store *Store) SumEntriesByExpiry(ctx context.Context, tenantID ledger.TenantID, atUnixUTC int64) ([]ledger.ExpiryTotal, error) {
+	type expiryKey struct {
+		ledgerID         string
+		expiresAtUnixUTC int64
+	}
+	var totals []ledger.ExpiryTotal
+	err := store.run(ctx, func(state *state) error {
+		byKey := map[expiryKey]*ledger.ExpiryTotal{}
+		for _, accountID := range state.accountOrder {
+			record := state.accounts[accountID]
+			if record.key.tenantID != tenantID.String() {
+				continue
+			}
+			for _, entryRecord := range state.accountEntryRecords(accountID) {
+				entry := entryRecord.entry
+				if isHoldType(entry.Type()) {
+					continue
+				}
+				effectiveAt := entry.EffectiveAtUnixUTC()
+				if effectiveAt != 0 && (effectiveAt > atUnixUTC || (entry.ExpiresAtUnixUTC() != 0 && effectiveAt >= entry.ExpiresAtUnixUTC())) {
+					continue
+				}
+				key := expiryKey{ledgerID: record.key.ledgerID, expiresAtUnixUTC: entry.ExpiresAtUnixUTC()}
+				total, ok := byKey[key]
+				if !ok {
+					total = &ledger.ExpiryTotal{LedgerID: record.account.LedgerID(), ExpiresAtUnixUTC: key.expiresAtUnixUTC}
+					byKey[key] = total
+				}
+				total.AmountCents += ledger.SignedAmountCents(entry.AmountCents().Int64() - entry.ConsumedCents().Int64())
+				total.ConsumedCents += entry.ConsumedCents()
+			}
+		}
+		totals = make([]ledger.ExpiryTotal, 0, len(byKey))
+		for _, total := range byKey {
+			totals = append(totals, *total)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(totals, func(left, right int) bool {
+		if totals[left].LedgerID != totals[right].LedgerID {
+			return totals[left].LedgerID.String() < totals[right].LedgerID.String()
+		}
+		return totals[left].ExpiresAtUnixUTC < totals[right].ExpiresAtUnixUTC
+	})
+	return totals, nil
+}
+
+// AppendEntryChange numbers an entry in its tenant's change feed.
+func (store *Store) AppendEntryChange(ctx context.Context, tenantID ledger.TenantID, entryID ledger.EntryID) error {
+	return store.run(ctx, func(state *state) error {
+		tenant := tenantID.String()
+		id := entryID.String()
+		if state.changedEntries[id] {
+			return wrapStoreError(errorSubjectEntryFeed, errorCodeInsert, errDuplicateEntryChange)
+		}
+		previousHead := state.feedHeads[tenant]
+		state.feedHeads[tenant] = previousHead + 1
+		state.entryChanges[tenant] = append(state.entryChanges[tenant], entryChangeRecord{sequence: previousHead + 1, entryID: id})
+		state.changedEntries[id] = true
+		state.record(func() {
+			state.feedHeads[tenant] = previousHead
+			state.entryChanges[tenant] = state.entryChanges[tenant][:len(state.entryChanges[tenant])-1]
+			delete(state.changedEntries, id)
+		})
+		return nil
+	})
+}
+
+func (store *Store) ListEntryChanges(ctx context.Context, tenantID ledger.TenantID, afterCursor int64, limit int, filter ledger.EntryChangeFilter) ([]ledger.EntryChange, error) {
+	var changes []ledger.EntryChange
+	err := store.run(ctx, func(state *state) error {
+		for _, change := range state.entryChanges[tenantID.String()] {
+			if change.sequence <= afterCursor {
+				continue
+			}
+			entryRecord, ok := state.entries[change.entryID]
+			if !ok {
+				continue
+			}
+			account, ok := state.accounts[entryRecord.entry.AccountID().String()]
+			if !ok {
+				continue
+			}
+			if filter.LedgerID != nil && account.account.LedgerID() != *filter.LedgerID {
+				continue
+			}
+			if filter.UserID != nil && account.account.UserID() != *filter.UserID {
+				continue
+			}
+			if len(filter.Types) > 0 && !containsType(filter.Types, entryRecord.entry.Type()) {
+				continue
+			}
+			changes = append(changes, ledger.EntryChange{Cursor: change.sequence, UserID: account.account.UserID(), LedgerID: account.account.LedgerID(), Entry: entryRecord.entry})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return applyLimit(changes, limit), nil
+}
+
+// InsertOutboxEvent queues an event as pending delivery. The in-memory store keeps queued events only so
+// transactions roll them back with the entries they describe; nothing delivers them.
+func (store *Store) InsertOutboxEvent(ctx context.Context, event ledger.OutboxEvent) error {
+	return store.run(ctx, func(state *state) error {
+		previousLength := len(state.outboxEvents)
+		state.outboxEvents = append(state.outboxEvents, event)
+		state.record(func() { state.outboxEvents = state.outboxEvents[:previousLength] })
+		return nil
+	})
+}
+
+// LockChainHead returns the account's chain head, creating an empty one on first use. The store lock
+// already serializes writers, so no further locking is needed.
+func (store *Store) LockChainHead(ctx context.Context, accountID ledger.AccountID) (ledger.ChainHead, error) {
+	var head ledger.ChainHead
+	err := store.run(ctx, func(state *state) error {
+		id := accountID.String()
+		existing, ok := state.chainHeads[id]
+		if !ok {
+			state.chainHeads[id] = ledger.ChainHead{}
+			state.record(func() { delete(state.chainHeads, id) })
+		}
+		head = existing
+		return nil
+	})
+	return head, err
+}
+
+// AppendChainLink stores the link on its entry and moves the account's chain head to it.
+func (store *Store) AppendChainLink(ctx context.Context, accountID ledger.AccountID, link ledger.ChainLink) error {
+	return store.run(ctx, func(state *state) error {
+		id := accountID.String()
+		record, ok := state.entries[link.EntryID.String()]
+		if !ok || record.entry.AccountID() != accountID {
+			return wrapStoreError(errorSubjectChain, errorCodeUpdate, ledger.ErrUnknownEntry)
+		}
+		if linked, ok := state.chainSequences[id][link.Sequence]; ok && linked != link.EntryID.String() {
+			return wrapStoreError(errorSubjectChain, errorCodeUpdate, errDuplicateChainLink)
+		}
+		previousHead, ok := state.chainHeads[id]
+		if !ok {
+			return wrapStoreError(errorSubjectChain, errorCodeLock, errMissingChainHead)
+		}
+		previousLink := record.link
+		storedLink := link
+		record.link = &storedLink
+		if state.chainSequences[id] == nil {
+			state.chainSequences[id] = map[int64]string{}
+		}
+		if previousLink != nil {
+			delete(state.chainSequences[id], previousLink.Sequence)
+		}
+		state.chainSequences[id][link.Sequence] = link.EntryID.String()
+		state.chainHeads[id] = ledger.ChainHead{Sequence: link.Sequence, Hash: link.Hash}
+		state.record(func() {
+			record.link = previousLink
+			delete(state.chainSequences[id], link.Sequence)
+			if previousLink != nil {
+				state.chainSequences[id][previousLink.Sequence] = link.EntryID.String()
+			}
+			state.chainHeads[id] = previousHead
+		})
+		return nil
+	})
+}
+
+// GetChainHead reads the account's chain head. Accounts without chained entries have the zero head.
+func (store *Store) GetChainHead(ctx context.Context, accountID ledger.AccountID) (ledger.ChainHead, error) {
+	var head ledger.ChainHead
+	err := store.run(ctx, func(state *state) error {
+		head = state.chainHeads[accountID.String()]
+		return nil
+	})
+	return head, err
+}
+
+// ListChainedEntries returns the account's chained entries after afterSequence in chain order.
+func (store *Store) ListChainedEntries(ctx context.Context, accountID ledger.AccountID, afterSequence int64, limit int) ([]ledger.ChainedEntry, error) {
+	var chained []ledger.ChainedEntry
+	err := store.run(ctx, func(state *state) error {
+		for _, record := range state.accountEntryRecords(accountID.String()) {
+			if record.link == nil || record.link.Sequence <= afterSequence {
+				continue
+			}
+			chained = append(chained, ledger.ChainedEntry{Entry: record.entry, Link: *record.link})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(chained, func(left, right int) bool { return chained[left].Link.Sequence < chained[right].Link.Sequence })
+	return applyLimit(chained, limit), nil
+}
+
+// GetEntryArchive reads the account's archive record. Accounts never compacted have the zero record.
+func (store *Store) GetEntryArchive(ctx context.Context, accountID ledger.AccountID) (ledger.EntryArchive, error) {
+	var archive ledger.EntryArchive
+	err := store.run(ctx, func(state *state) error {
+		archive = state.archives[accountID.String()]
+		return nil
+	})
+	return archive, err
+}
+
+// ArchiveEntries deletes the account's listed entries and reservations and replaces its archive record. The
+// idempotency keys and IDs of the deleted entries stay taken. Change-feed positions of deleted entries stay behind and are skipped when the feed is read.
+func (store *Store) ArchiveEntries(ctx context.Context, archive ledger.EntryArchive, entryIDs []ledger.EntryID, reservationIDs []ledger.ReservationID) error {
+	return store.run(ctx, func(state *state) error {
+		id := archive.AccountID.String()
+		removedEntries := make(map[string]*entryRecord, len(entryIDs))
+		for _, entryID := range entryIDs {
+			record, ok := state.entries[entryID.String()]
+			if !ok || record.entry.AccountID() != archive.AccountID {
+				return wrapStoreError(errorSubjectArchive, errorCodeDelete, ledger.ErrUnknownEntry)
+			}
+			removedEntries[entryID.String()] = record
+		}
+		removedReservations := make(map[string]*reservationRecord, len(reservationIDs))
+		for _, reservationID := range reservationIDs {
+			record, ok := state.reservations[id][reservationID.String()]
+			if !ok {
+				return wrapStoreError(errorSubjectArchive, errorCodeDelete, ledger.ErrUnknownReservation)
+			}
+			removedReservations[reservationID.String()] = record
+		}
+
+		previousEntryIDs := state.accountEntries[id]
+		retainedEntryIDs := make([]string, 0, len(previousEntryIDs))
+		for _, entryID := range previousEntryIDs {
+			if _, removed := removedEntries[entryID]; !removed {
+				retainedEntryIDs = append(retainedEntryIDs, entryID)
+			}
+		}
+		state.accountEntries[id] = retainedEntryIDs
+		if state.archivedKeys[id] == nil {
+			state.archivedKeys[id] = map[string]string{}
+		}
+		for entryID, record := range removedEntries {
+			delete(state.entries, entryID)
+			delete(state.idempotency[id], record.entry.IdempotencyKey().String())
+			state.archivedKeys[id][record.entry.IdempotencyKey().String()] = entryID
+			state.archivedEntries[entryID] = id
+			if record.link != nil {
+				delete(state.chainSequences[id], record.link.Sequence)
+			}
+		}
+		previousReservationIDs := state.reservationIDs[id]
+		retainedReservationIDs := make([]string, 0, len(previousReservationIDs))
+		for _, reservationID := range previousReservationIDs {
+			if _, removed := removedReservations[reservationID]; !removed {
+				retainedReservationIDs = append(retainedReservationIDs, reservationID)
+			}
+		}
+		state.reservationIDs[id] = retainedReservationIDs
+		for reservationID := range removedReservations {
+			delete(state.reservations[id], reservationID)
+		}
+		previousArchive, hadArchive := state.archives[id]
+		state.archives[id] = archive
+		state.record(func() {
+			state.accountEntries[id] = previousEntryIDs
+			for entryID, record := range removedEntries {
+				state.entries[entryID] = record
+				state.idempotency[id][record.entry.IdempotencyKey().String()] = entryID
+				delete(state.archivedKeys[id], record.entry.IdempotencyKey().String())
+				delete(state.archivedEntries, entryID)
+				if record.link != nil {
+					state.chainSequences[id][record.link.Sequence] = entryID
+				}
+			}
+			state.reservationIDs[id] = previousReservationIDs
+			for reservationID, record := range removedReservations {
+				state.reservations[id][reservationID] = record
+			}
+			if hadArchive {
+				state.archives[id] = previousArchive
+			} else {
+				delete(state.archives, id)
+			}
+		})
+		return nil
+	})
+}
+
+// run executes fn against the store's state, taking the store lock unless a transaction already holds it.
+func (store *Store) run(ctx context.Context, fn func(state *state) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if !store.inTransaction {
+		store.lock.Lock()
+		defer store.lock.Unlock()
+	}
+	return fn(store.state)
+}
+
+// record remembers how to undo a write when a transaction is open.
+func (state *state) record(undo func()) {
+	if state.undo != nil {
+		state.undo = append(state.undo, undo)
+	}
+}
+
+// rollback undoes every write recorded after savepoint, newest first.
+func (state *state) rollback(savepoint int) {
+	for index := len(state.undo) - 1; index >= savepoint; index-- {
+		state.undo[index]()
+	}
+	state.undo = state.undo[:savepoint]
+}
+
+func (state *state) accountEntryRecords(accountID string) []*entryRecord {
+	entryIDs := state.accountEntries[accountID]
+	records := make([]*entryRecord, 0, len(entryIDs))
+	for _, entryID := range entryIDs {
+		records = append(records, state.entries[entryID])
+	}
+	return records
+}
+
+func (state *state) sumTotal(accountID string, atUnixUTC int64) int64 {
+	var total int64
+	for _, record := range state.accountEntryRecords(accountID) {
+		total += record.entry.BalanceCentsAt(atUnixUTC)
+	}
+	return total
+}
+
+// entryBeforeCursor reports whether entry sorts after the (before, beforeEntryID) cursor in newest-first order.
+func entryBeforeCursor(entry ledger.Entry, before int64, beforeEntryID *ledger.EntryID) bool {
+	if beforeEntryID == nil || entry.CreatedUnixUTC() != before {
+		return entry.CreatedUnixUTC() < before
+	}
+	return entry.EntryID().String() < beforeEntryID.String()
+}
+
+func matchesEntryFilter(entry ledger.Entry, filter ledger.ListEntriesFilter) bool {
+	if len(filter.Types) > 0 && !containsType(filter.Types, entry.Type()) {
+		return false
+	}
+	if filter.ReservationID != nil {
+		reservationID, ok := entry.ReservationID()
+		if !ok || reservationID != *filter.ReservationID {
+			return false
+		}
+	}
+	if filter.EffectiveAfterUnixUTC != 0 && entry.EffectiveAtUnixUTC() <= filter.EffectiveAfterUnixUTC {
+		return false
+	}
+	if filter.IdempotencyKeyPrefix != nil && !strings.HasPrefix(entry.IdempotencyKey().String(), filter.IdempotencyKeyPrefix.String()) {
+		return false
+	}
+	actor := entry.Actor()
+	for _, field := range [][2]string{
+		{filter.Actor.APIKeyID(), actor.APIKeyID()},
+		{filter.Actor.Service(), actor.Service()},
+		{filter.Actor.RequestID(), actor.RequestID()},
+		{filter.Actor.ClientAddress(), actor.ClientAddress()},
+	} {
+		if field[0] != "" && field[0] != field[1] {
+			return false
+		}
+	}
+	return true
+}
+
+func isHoldType(entryType ledger.EntryType) bool {
+	return entryType == ledger.EntryHold || entryType == ledger.EntryReverseHold
+}
+
+func containsType(types []ledger.EntryType, entryType ledger.EntryType) bool {
+	for _, candidate := range types {
+		if candidate == entryType {
+			return true
+		}
+	}
+	return false
+}
+
+func containsStatus(statuses []ledger.ReservationStatus, status ledger.ReservationStatus) bool {
+	for _, candidate := range statuses {
+		if candidate == status {
+			return true
+		}
+	}
+	return false
+}
+
+// beforeOrNow mirrors the SQL stores: a zero bound lists everything created up to now.
+func beforeOrNow(beforeUnixUTC int64) int64 {
+	if beforeUnixUTC == 0 {
+		return time.Now().UTC().Add(time.Second).Unix()
+	}
+	return beforeUnixUTC
+}
+
+// applyLimit keeps the first limit items; a negative limit keeps every item, as in SQL.
+func applyLimit[T any](items []T, limit int) []T {
+	if limit >= 0 && len(items) > limit {
+		return items[:limit]
+	}
+	return items
+}
+
+func wrapStoreError(subject string, code string, err error) error {
+	return ledger.WrapError(errorOperationStore, subject, code, err)
+}
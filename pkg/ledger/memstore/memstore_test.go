@@ -0,0 +1,987 @@
+package memstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/MarkoPoloResearchLab/ledger/pkg/ledger"
+	"github.com/MarkoPoloResearchLab/ledger/pkg/ledger/storetest"
+)
+
+var errRollback = errors.New("rollback")
+
+type accountFixture struct {
+	tenantID ledger.TenantID
+	userID   ledger.UserID
+	ledgerID ledger.LedgerID
+	metadata ledger.MetadataJSON
+}
+
+func newAccountFixture(test *testing.T, rawUserID string) accountFixture {
+	test.Helper()
+	tenantID, err := ledger.NewTenantID("default")
+	if err != nil {
+		test.Fatalf("tenant id: %v", err)
+	}
+	userID, err := ledger.NewUserID(rawUserID)
+	if err != nil {
+		test.Fatalf("user id: %v", err)
+	}
+	ledgerID, err := ledger.NewLedgerID("default")
+	if err != nil {
+		test.Fatalf("ledger id: %v", err)
+	}
+	metadata, err := ledger.NewMetadataJSON("{}")
+	if err != nil {
+		test.Fatalf("metadata: %v", err)
+	}
+	return accountFixture{tenantID: tenantID, userID: userID, ledgerID: ledgerID, metadata: metadata}
+}
+
+func mustAmount(test *testing.T, cents int64) ledger.PositiveAmountCents {
+	test.Helper()
+	amount, err := ledger.NewPositiveAmountCents(cents)
+	if err != nil {
+		test.Fatalf("amount: %v", err)
+	}
+	return amount
+}
+
+func mustKey(test *testing.T, raw string) ledger.IdempotencyKey {
+	test.Helper()
+	key, err := ledger.NewIdempotencyKey(raw)
+	if err != nil {
+		test.Fatalf("idempotency key: %v", err)
+	}
+	return key
+}
+
+func mustEntryInput(test *testing.T, accountID ledger.AccountID, rawKey string, cents int64) ledger.EntryInput {
+	test.Helper()
+	amount, err := ledger.NewEntryAmountCents(cents)
+	if err != nil {
+		test.Fatalf("entry amount: %v", err)
+	}
+	metadata, err := ledger.NewMetadataJSON("{}")
+	if err != nil {
+		test.Fatalf("metadata: %v", err)
+	}
+	input, err := ledger.NewEntryInput(accountID, ledger.EntryGrant, amount, nil, nil, mustKey(test, rawKey), 0, metadata, 100)
+	if err != nil {
+		test.Fatalf("entry input: %v", err)
+	}
+	return input
+}
+
+func TestServiceFlowOnMemoryStore(test *testing.T) {
+	test.Parallel()
+	ctx := context.Background()
+	fixture := newAccountFixture(test, "user-1")
+	service, err := ledger.NewService(New(), func() int64 { return 100 })
+	if err != nil {
+		test.Fatalf("new service: %v", err)
+	}
+	reservationID, err := ledger.NewReservationID("order-1")
+	if err != nil {
+		test.Fatalf("reservation id: %v", err)
+	}
+
+	if err := service.Grant(ctx, fixture.tenantID, fixture.userID, fixture.ledgerID, mustAmount(test, 1000), mustKey(test, "grant-1"), 0, fixture.metadata); err != nil {
+		test.Fatalf("grant: %v", err)
+	}
+	if err := service.Grant(ctx, fixture.tenantID, fixture.userID, fixture.ledgerID, mustAmount(test, 1000), mustKey(test, "grant-1"), 0, fixture.metadata); !errors.Is(err, ledger.ErrDuplicateIdempotencyKey) {
+		test.Fatalf("expected duplicate idempotency key, got %v", err)
+	}
+	if err := service.Reserve(ctx, fixture.tenantID, fixture.userID, fixture.ledgerID, mustAmount(test, 300), reservationID, mustKey(test, "reserve-1"), 0, fixture.metadata); err != nil {
+		test.Fatalf("reserve: %v", err)
+	}
+	if err := service.Reserve(ctx, fixture.tenantID, fixture.userID, fixture.ledgerID, mustAmount(test, 300), reservationID, mustKey(test, "reserve-2"), 0, fixture.metadata); !errors.Is(err, ledger.ErrReservationExists) {
+		test.Fatalf("expected reservation conflict, got %v", err)
+	}
+	if err := service.Capture(ctx, fixture.tenantID, fixture.userID, fixture.ledgerID, reservationID, mustKey(test, "capture-1"), mustAmount(test, 300), fixture.metadata); err != nil {
+		test.Fatalf("capture: %v", err)
+	}
+	if err := service.Release(ctx, fixture.tenantID, fixture.userID, fixture.ledgerID, reservationID, mustKey(test, "release-1"), fixture.metadata); !errors.Is(err, ledger.ErrReservationClosed) {
+		test.Fatalf("expected closed reservation, got %v", err)
+	}
+	if err := service.Spend(ctx, fixture.tenantID, fixture.userID, fixture.ledgerID, mustAmount(test, 100), mustKey(test, "spend-1"), fixture.metadata); err != nil {
+		test.Fatalf("spend: %v", err)
+	}
+
+	balance, err := service.Balance(ctx, fixture.tenantID, fixture.userID, fixture.ledgerID)
+	if err != nil {
+		test.Fatalf("balance: %v", err)
+	}
+	if balance.TotalCents != 600 || balance.AvailableCents != 600 {
+		test.Fatalf("unexpected balance %+v", balance)
+	}
+	entries, err := service.ListEntries(ctx, fixture.tenantID, fixture.userID, fixture.ledgerID, 0, 10, ledger.ListEntriesFilter{})
+	if err != nil {
+		test.Fatalf("list entries: %v", err)
+	}
+	if len(entries) != 5 {
+		test.Fatalf("expected 5 entries, got %d", len(entries))
+	}
+	chain, err := service.VerifyChain(ctx, fixture.tenantID, fixture.userID, fixture.ledgerID)
+	if err != nil {
+		test.Fatalf("verify chain: %v", err)
+	}
+	if !chain.Valid() || chain.HeadSequence != 5 {
+		test.Fatalf("unexpected chain verification %+v", chain)
+	}
+	verification, err := service.VerifyAccount(ctx, fixture.tenantID, fixture.userID, fixture.ledgerID)
+	if err != nil {
+		test.Fatalf("verify account: %v", err)
+	}
+	if !verification.Valid() {
+		test.Fatalf("unexpected violations %+v", verification.Violations)
+	}
+}
+
+func TestVerifyAccountPagesEntriesSharingOneSecond(test *testing.T) {
+	test.Parallel()
+	ctx := context.Background()
+	fixture := newAccountFixture(test, "user-1")
+	service, err := ledger.NewService(New(), func() int64 { return 100 })
+	if err != nil {
+		test.Fatalf("new service: %v", err)
+	}
+	const grantCount = 1203
+	for index := range grantCount {
+		if err := service.Grant(ctx, fixture.tenantID, fixture.userID, fixture.ledgerID, mustAmount(test, 1), mustKey(test, fmt.Sprintf("grant-%d", index)), 0, fixture.metadata); err != nil {
+			test.Fatalf("grant %d: %v", index, err)
+		}
+	}
+	verification, err := service.VerifyAccount(ctx, fixture.tenantID, fixture.userID, fixture.ledgerID)
+	if err != nil {
+		test.Fatalf("verify account: %v", err)
+	}
+	if verification.CheckedEntries != grantCount || !verification.Valid() {
+		test.Fatalf("expected %d consistent entries, got %d with violations %+v", grantCount, verification.CheckedEntries, verification.Violations)
+	}
+}
+
+func TestWithTxRollsBackNestedTransactionsIndependently(test *testing.T) {
+	test.Parallel()
+	ctx := context.Background()
+	store := New()
+	fixture := newAccountFixture(test, "user-1")
+	accountID, err := store.GetOrCreateAccountID(ctx, fixture.tenantID, fixture.userID, fixture.ledgerID)
+	if err != nil {
+		test.Fatalf("account: %v", err)
+	}
+
+	err = store.WithTx(ctx, func(ctx context.Context, txStore ledger.Store) error {
+		if _, err := txStore.InsertEntry(ctx, mustEntryInput(test, accountID, "outer", 10)); err != nil {
+			return err
+		}
+		nestedErr := txStore.WithTx(ctx, func(ctx context.Context, nestedStore ledger.Store) error {
+			if _, err := nestedStore.InsertEntry(ctx, mustEntryInput(test, accountID, "inner", 20)); err != nil {
+				return err
+			}
+			return errRollback
+		})
+		if !errors.Is(nestedErr, errRollback) {
+			return fmt.Errorf("nested: %w", nestedErr)
+		}
+		return nil
+	})
+	if err != nil {
+		test.Fatalf("outer transaction: %v", err)
+	}
+	if _, err := store.GetEntryByIdempotencyKey(ctx, accountID, mustKey(test, "outer")); err != nil {
+		test.Fatalf("expected outer entry committed: %v", err)
+	}
+	if _, err := store.GetEntryByIdempotencyKey(ctx, accountID, mustKey(test, "inner")); !errors.Is(err, ledger.ErrUnknownEntry) {
+		test.Fatalf("expected inner entry rolled back, got %v", err)
+	}
+
+	err = store.WithTx(ctx, func(ctx context.Context, txStore ledger.Store) error {
+		otherFixture := newAccountFixture(test, "user-2")
+		if _, err := txStore.GetOrCreateAccountID(ctx, otherFixture.tenantID, otherFixture.userID, otherFixture.ledgerID); err != nil {
+			return err
+		}
+		if _, err := txStore.InsertEntry(ctx, mustEntryInput(test, accountID, "discarded", 30)); err != nil {
+			return err
+		}
+		return txStore.WithTx(ctx, func(ctx context.Context, nestedStore ledger.Store) error {
+			_, err := nestedStore.InsertEntry(ctx, mustEntryInput(test, accountID, "outer", 40))
+			return err
+		})
+	})
+	if !errors.Is(err, ledger.ErrDuplicateIdempotencyKey) {
+		test.Fatalf("expected duplicate idempotency key, got %v", err)
+	}
+	total, err := store.SumTotal(ctx, accountID, 200)
+	if err != nil {
+		test.Fatalf("sum total: %v", err)
+	}
+	if total != 10 {
+		test.Fatalf("expected only the committed entry, got total %d", total)
+	}
+	accounts, err := store.ListAccounts(ctx, fixture.tenantID, 0, 10, ledger.ListAccountsFilter{})
+	if err != nil {
+		test.Fatalf("list accounts: %v", err)
+	}
+	if len(accounts) != 1 {
+		test.Fatalf("expected the rolled back account to be gone, got %d accounts", len(accounts))
+	}
+}
+
+func TestWithTxRollsBackOnPanic(test *testing.T) {
+	test.Parallel()
+	ctx := context.Background()
+	store := New()
+	fixture := newAccountFixture(test, "user-1")
+	accountID, err := store.GetOrCreateAccountID(ctx, fixture.tenantID, fixture.userID, fixture.ledgerID)
+	if err != nil {
+		test.Fatalf("account: %v", err)
+	}
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				test.Fatalf("expected panic to propagate")
+			}
+		}()
+		_ = store.WithTx(ctx, func(ctx context.Context, txStore ledger.Store) error {
+			if _, err := txStore.InsertEntry(ctx, mustEntryInput(test, accountID, "panicking", 10)); err != nil {
+				return err
+			}
+			panic("boom")
+		})
+	}()
+
+	if _, err := store.InsertEntry(ctx, mustEntryInput(test, accountID, "panicking", 10)); err != nil {
+		test.Fatalf("expected the panicking write to be rolled back and the store unlocked: %v", err)
+	}
+}
+
+func TestGrantConsumptionOrdersTiedExpiriesAndRollsBack(test *testing.T) {
+	test.Parallel()
+	ctx := context.Background()
+	store := New()
+	fixture := newAccountFixture(test, "user-1")
+	accountID, err := store.GetOrCreateAccountID(ctx, fixture.tenantID, fixture.userID, fixture.ledgerID)
+	if err != nil {
+		test.Fatalf("account: %v", err)
+	}
+	insertExpiringGrant := func(rawKey string, createdUnixUTC int64) ledger.Entry {
+		test.Helper()
+		input, err := ledger.NewEntryInput(accountID, ledger.EntryGrant, mustAmount(test, 10).ToEntryAmountCents(), nil, nil, mustKey(test, rawKey), 500, fixture.metadata, createdUnixUTC)
+		if err != nil {
+			test.Fatalf("entry input: %v", err)
+		}
+		entry, err := store.InsertEntry(ctx, input)
+		if err != nil {
+			test.Fatalf("insert %s: %v", rawKey, err)
+		}
+		return entry
+	}
+	later := insertExpiringGrant("later", 200)
+	earlier := insertExpiringGrant("earlier", 100)
+
+	grants, err := store.ListConsumableGrants(ctx, accountID, 300)
+	if err != nil {
+		test.Fatalf("list consumable grants: %v", err)
+	}
+	if len(grants) != 2 || grants[0].EntryID() != earlier.EntryID() || grants[1].EntryID() != later.EntryID() {
+		test.Fatalf("expected grants expiring together ordered by creation, got %+v", grants)
+	}
+
+	err = store.WithTx(ctx, func(ctx context.Context, txStore ledger.Store) error {
+		if err := txStore.(ledger.GrantConsumptionStore).ConsumeGrant(ctx, accountID, earlier.EntryID(), 10); err != nil {
+			return err
+		}
+		return errRollback
+	})
+	if !errors.Is(err, errRollback) {
+		test.Fatalf("expected rollback, got %v", err)
+	}
+	grants, err = store.ListConsumableGrants(ctx, accountID, 300)
+	if err != nil || len(grants) != 2 || grants[0].ConsumedCents() != 0 {
+		test.Fatalf("expected the rolled back consumption to be undone, got %+v, %v", grants, err)
+	}
+
+	canceledCtx, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := store.ListConsumableGrants(canceledCtx, accountID, 300); !errors.Is(err, context.Canceled) {
+		test.Fatalf("expected context canceled, got %v", err)
+	}
+}
+
+func TestConcurrentSpendsNeverOverdraw(test *testing.T) {
+	test.Parallel()
+	ctx := context.Background()
+	fixture := newAccountFixture(test, "user-1")
+	service, err := ledger.NewService(New(), func() int64 { return 100 })
+	if err != nil {
+		test.Fatalf("new service: %v", err)
+	}
+	if err := service.Grant(ctx, fixture.tenantID, fixture.userID, fixture.ledgerID, mustAmount(test, 1000), mustKey(test, "grant"), 0, fixture.metadata); err != nil {
+		test.Fatalf("grant: %v", err)
+	}
+
+	const spenders = 50
+	spendAmount := mustAmount(test, 30)
+	var (
+		waitGroup sync.WaitGroup
+		mutex     sync.Mutex
+		succeeded int
+		failures  []error
+	)
+	for index := 0; index < spenders; index++ {
+		key := mustKey(test, fmt.Sprintf("spend-%d", index))
+		waitGroup.Add(1)
+		go func() {
+			defer waitGroup.Done()
+			err := service.Spend(ctx, fixture.tenantID, fixture.userID, fixture.ledgerID, spendAmount, key, fixture.metadata)
+			mutex.Lock()
+			defer mutex.Unlock()
+			if err == nil {
+				succeeded++
+			} else if !errors.Is(err, ledger.ErrInsufficientFunds) {
+				failures = append(failures, err)
+			}
+		}()
+	}
+	waitGroup.Wait()
+
+	if len(failures) != 0 {
+		test.Fatalf("unexpected spend errors: %v", failures)
+	}
+	if succeeded != 33 {
+		test.Fatalf("expected 33 spends to fit in 1000 cents, got %d", succeeded)
+	}
+	balance, err := service.Balance(ctx, fixture.tenantID, fixture.userID, fixture.ledgerID)
+	if err != nil {
+		test.Fatalf("balance: %v", err)
+	}
+	if balance.TotalCents != 10 {
+		test.Fatalf("expected 10 cents left, got %d", balance.TotalCents)
+	}
+}
+
+type recordingArchiveWriter struct {
+	staged    int
+	discarded int
+	archives  []ledger.AccountArchive
+}
+
+func (writer *recordingArchiveWriter) StageAccountArchive(ctx context.Context, archive ledger.AccountArchive) (ledger.StagedArchive, error) {
+	writer.staged++
+	return &recordingStagedArchive{writer: writer, archive: archive, location: fmt.Sprintf("archive-%d", writer.staged)}, nil
+}
+
+type recordingStagedArchive struct {
+	writer   *recordingArchiveWriter
+	archive  ledger.AccountArchive
+	location string
+}
+
+func (staged *recordingStagedArchive) Location() string {
+	return staged.location
+}
+
+func (staged *recordingStagedArchive) Publish() error {
+	staged.writer.archives = append(staged.writer.archives, staged.archive)
+	return nil
+}
+
+func (staged *recordingStagedArchive) Discard() error {
+	staged.writer.discarded++
+	return nil
+}
+
+// failingArchiveStore fails ArchiveEntries, after compaction has staged its archive.
+type failingArchiveStore struct {
+	*Store
+	err error
+}
+
+func (store failingArchiveStore) WithTx(ctx context.Context, fn func(ctx context.Context, txStore ledger.Store) error) error {
+	return store.Store.WithTx(ctx, func(ctx context.Context, txStore ledger.Store) error {
+		return fn(ctx, failingArchiveStore{Store: txStore.(*Store), err: store.err})
+	})
+}
+
+func (store failingArchiveStore) ArchiveEntries(ctx context.Context, archive ledger.EntryArchive, entryIDs []ledger.EntryID, reservationIDs []ledger.ReservationID) error {
+	return store.err
+}
+
+func TestCompactAccountCarriesBalanceForward(test *testing.T) {
+	test.Parallel()
+	ctx := context.Background()
+	fixture := newAccountFixture(test, "user-1")
+	nowUnixUTC := int64(100)
+	service, err := ledger.NewService(New(), func() int64 { return nowUnixUTC })
+	if err != nil {
+		test.Fatalf("new service: %v", err)
+	}
+	captured, err := ledger.NewReservationID("order-1")
+	if err != nil {
+		test.Fatalf("reservation id: %v", err)
+	}
+	open, err := ledger.NewReservationID("order-2")
+	if err != nil {
+		test.Fatalf("reservation id: %v", err)
+	}
+
+	if err := service.Grant(ctx, fixture.tenantID, fixture.userID, fixture.ledgerID, mustAmount(test, 1000), mustKey(test, "grant-1"), 0, fixture.metadata); err != nil {
+		test.Fatalf("grant: %v", err)
+	}
+	if err := service.Spend(ctx, fixture.tenantID, fixture.userID, fixture.ledgerID, mustAmount(test, 100), mustKey(test, "spend-1"), fixture.metadata); err != nil {
+		test.Fatalf("spend: %v", err)
+	}
+	if err := service.Reserve(ctx, fixture.tenantID, fixture.userID, fixture.ledgerID, mustAmount(test, 300), captured, mustKey(test, "reserve-1"), 0, fixture.metadata); err != nil {
+		test.Fatalf("reserve: %v", err)
+	}
+	nowUnixUTC = 110
+	if err := service.Capture(ctx, fixture.tenantID, fixture.userID, fixture.ledgerID, captured, mustKey(test, "capture-1"), mustAmount(test, 300), fixture.metadata); err != nil {
+		test.Fatalf("capture: %v", err)
+	}
+	nowUnixUTC = 120
+	if err := service.Reserve(ctx, fixture.tenantID, fixture.userID, fixture.ledgerID, mustAmount(test, 200), open, mustKey(test, "reserve-2"), 0, fixture.metadata); err != nil {
+		test.Fatalf("reserve: %v", err)
+	}
+	nowUnixUTC = 130
+	if err := service.Grant(ctx, fixture.tenantID, fixture.userID, fixture.ledgerID, mustAmount(test, 50), mustKey(test, "grant-2"), 0, fixture.metadata); err != nil {
+		test.Fatalf("grant: %v", err)
+	}
+	nowUnixUTC = 200
+	before, err := service.Balance(ctx, fixture.tenantID, fixture.userID, fixture.ledgerID)
+	if err != nil {
+		test.Fatalf("balance: %v", err)
+	}
+	entriesBefore, err := service.ListEntries(ctx, fixture.tenantID, fixture.userID, fixture.ledgerID, 0, 100, ledger.ListEntriesFilter{})
+	if err != nil {
+		test.Fatalf("list entries: %v", err)
+	}
+
+	writer := &recordingArchiveWriter{}
+	if _, err := service.CompactAccount(ctx, fixture.tenantID, fixture.userID, fixture.ledgerID, 201, writer); !errors.Is(err, ledger.ErrInvalidArchiveCutoff) {
+		test.Fatalf("expected invalid cutoff for a future cutoff, got %v", err)
+	}
+	if _, err := service.CompactAccount(ctx, fixture.tenantID, fixture.userID, fixture.ledgerID, 150, nil); !errors.Is(err, ledger.ErrInvalidServiceConfig) {
+		test.Fatalf("expected invalid config for a nil writer, got %v", err)
+	}
+	result, err := service.CompactAccount(ctx, fixture.tenantID, fixture.userID, fixture.ledgerID, 150, writer)
+	if err != nil {
+		test.Fatalf("compact: %v", err)
+	}
+	if len(writer.archives) != 1 {
+		test.Fatalf("expected one archive written, got %d", len(writer.archives))
+	}
+	written := writer.archives[0]
+	archivedCount := len(entriesBefore) - 2
+	if result.ArchivedEntries != archivedCount || len(written.Entries) != archivedCount {
+		test.Fatalf("expected %d archived entries, got result %+v and %d written", archivedCount, result, len(written.Entries))
+	}
+	if result.ArchivedReservations != 1 || len(written.Reservations) != 1 || written.Reservations[0].ReservationID() != captured {
+		test.Fatalf("expected the captured reservation to be archived, got %+v", written.Reservations)
+	}
+	if result.RetainedBeforeCutoff != 2 {
+		test.Fatalf("expected the open hold and later grant to be retained, got %d", result.RetainedBeforeCutoff)
+	}
+	if result.OpeningBalanceCents != 600 || written.OpeningBalanceCents != 600 || result.Location != "archive-1" {
+		test.Fatalf("unexpected compaction result %+v", result)
+	}
+
+	after, err := service.Balance(ctx, fixture.tenantID, fixture.userID, fixture.ledgerID)
+	if err != nil {
+		test.Fatalf("balance: %v", err)
+	}
+	if after != before {
+		test.Fatalf("expected balance %+v to survive compaction, got %+v", before, after)
+	}
+	entries, err := service.ListEntries(ctx, fixture.tenantID, fixture.userID, fixture.ledgerID, 0, 100, ledger.ListEntriesFilter{})
+	if err != nil {
+		test.Fatalf("list entries: %v", err)
+	}
+	if len(entries) != 3 {
+		test.Fatalf("expected the opening balance and two retained entries, got %d", len(entries))
+	}
+	var opening ledger.Entry
+	for _, entry := range entries {
+		if entry.Type() == ledger.EntryOpeningBalance {
+			opening = entry
+		}
+	}
+	if opening.AmountCents() != 600 || opening.CreatedUnixUTC() != 150 || opening.IdempotencyKey().String() != fmt.Sprintf("opening_balance:150:%d", archivedCount) {
+		test.Fatalf("unexpected opening balance entry %+v", opening)
+	}
+
+	archive, err := service.EntryArchive(ctx, fixture.tenantID, fixture.userID, fixture.ledgerID)
+	if err != nil {
+		test.Fatalf("entry archive: %v", err)
+	}
+	if archive.CutoffUnixUTC != 150 || archive.ThroughSequence != written.ThroughSequence || archive.ArchivedEntries != int64(archivedCount) || archive.Location != "archive-1" {
+		test.Fatalf("unexpected archive record %+v", archive)
+	}
+	chain, err := service.VerifyChain(ctx, fixture.tenantID, fixture.userID, fixture.ledgerID)
+	if err != nil {
+		test.Fatalf("verify chain: %v", err)
+	}
+	if !chain.Valid() || chain.ArchivedThroughSequence != int64(archivedCount) || chain.CheckedEntries != 3 {
+		test.Fatalf("unexpected chain verification %+v", chain)
+	}
+	verification, err := service.VerifyAccount(ctx, fixture.tenantID, fixture.userID, fixture.ledgerID)
+	if err != nil {
+		test.Fatalf("verify account: %v", err)
+	}
+	if !verification.Valid() {
+		test.Fatalf("unexpected violations %+v", verification.Violations)
+	}
+
+	if err := service.Release(ctx, fixture.tenantID, fixture.userID, fixture.ledgerID, open, mustKey(test, "release-2"), fixture.metadata); err != nil {
+		test.Fatalf("release: %v", err)
+	}
+	nowUnixUTC = 300
+	result, err = service.CompactAccount(ctx, fixture.tenantID, fixture.userID, fixture.ledgerID, 250, writer)
+	if err != nil {
+		test.Fatalf("compact again: %v", err)
+	}
+	if result.ArchivedEntries != 4 || result.RetainedBeforeCutoff != 0 || result.OpeningBalanceCents != 650 {
+		test.Fatalf("unexpected second compaction %+v", result)
+	}
+	chain, err = service.VerifyChain(ctx, fixture.tenantID, fixture.userID, fixture.ledgerID)
+	if err != nil {
+		test.Fatalf("verify chain: %v", err)
+	}
+	if !chain.Valid() || chain.CheckedEntries != 1 {
+		test.Fatalf("unexpected chain verification after second compaction %+v", chain)
+	}
+	balance, err := service.Balance(ctx, fixture.tenantID, fixture.userID, fixture.ledgerID)
+	if err != nil {
+		test.Fatalf("balance: %v", err)
+	}
+	if balance.TotalCents != 650 || balance.AvailableCents != 650 {
+		test.Fatalf("unexpected balance after second compaction %+v", balance)
+	}
+}
+
+func TestCompactAccountKeepsArchivedIdempotencyKeysTaken(test *testing.T) {
+	test.Parallel()
+	ctx := context.Background()
+	fixture := newAccountFixture(test, "user-1")
+	nowUnixUTC := int64(100)
+	service, err := ledger.NewService(New(), func() int64 { return nowUnixUTC })
+	if err != nil {
+		test.Fatalf("new service: %v", err)
+	}
+	if err := service.Grant(ctx, fixture.tenantID, fixture.userID, fixture.ledgerID, mustAmount(test, 1000), mustKey(test, "grant-1"), 0, fixture.metadata); err != nil {
+		test.Fatalf("grant: %v", err)
+	}
+	if err := service.Spend(ctx, fixture.tenantID, fixture.userID, fixture.ledgerID, mustAmount(test, 100), mustKey(test, "spend-1"), fixture.metadata); err != nil {
+		test.Fatalf("spend: %v", err)
+	}
+	nowUnixUTC = 200
+	result, err := service.CompactAccount(ctx, fixture.tenantID, fixture.userID, fixture.ledgerID, 150, &recordingArchiveWriter{})
+	if err != nil {
+		test.Fatalf("compact: %v", err)
+	}
+	if result.ArchivedEntries != 2 {
+		test.Fatalf("expected both entries archived, got %+v", result)
+	}
+
+	if err := service.Grant(ctx, fixture.tenantID, fixture.userID, fixture.ledgerID, mustAmount(test, 1000), mustKey(test, "grant-1"), 0, fixture.metadata); !errors.Is(err, ledger.ErrDuplicateIdempotencyKey) {
+		test.Fatalf("expected a replayed archived grant to be a duplicate, got %v", err)
+	}
+	if err := service.Spend(ctx, fixture.tenantID, fixture.userID, fixture.ledgerID, mustAmount(test, 100), mustKey(test, "spend-1"), fixture.metadata); !errors.Is(err, ledger.ErrDuplicateIdempotencyKey) {
+		test.Fatalf("expected a replayed archived spend to be a duplicate, got %v", err)
+	}
+	if err := service.RefundByOriginalIdempotencyKey(ctx, fixture.tenantID, fixture.userID, fixture.ledgerID, mustKey(test, "spend-1"), mustAmount(test, 50), mustKey(test, "refund-1"), fixture.metadata); !errors.Is(err, ledger.ErrArchivedEntry) {
+		test.Fatalf("expected refunding an archived debit to fail with ErrArchivedEntry, got %v", err)
+	}
+	balance, err := service.Balance(ctx, fixture.tenantID, fixture.userID, fixture.ledgerID)
+	if err != nil {
+		test.Fatalf("balance: %v", err)
+	}
+	if balance.TotalCents != 900 {
+		test.Fatalf("expected replays to leave the balance at 900, got %d", balance.TotalCents)
+	}
+}
+
+// Store implements every optional capability, so RunConformance skips none of its subtests.
+var _ interface {
+	ledger.Store
+	ledger.AccountStore
+	ledger.VelocityStore
+	ledger.JournalStore
+	ledger.GrantConsumptionStore
+	ledger.LiabilityStore
+	ledger.ChangeFeedStore
+	ledger.OutboxStore
+	ledger.ArchiveStore
+} = (*Store)(nil)
+
+func TestConformance(test *testing.T) {
+	test.Parallel()
+	storetest.RunConformance(test, func(test *testing.T) ledger.Store {
+		return New()
+	})
+}
+
+func TestCompactAccountPublishesArchiveOnlyAfterCommit(test *testing.T) {
+	test.Parallel()
+	ctx := context.Background()
+	fixture := newAccountFixture(test, "user-1")
+	nowUnixUTC := int64(100)
+	store := New()
+	archiveErr := errors.New("archive entries failed")
+	failingService, err := ledger.NewService(failingArchiveStore{Store: store, err: archiveErr}, func() int64 { return nowUnixUTC })
+	if err != nil {
+		test.Fatalf("new service: %v", err)
+	}
+	service, err := ledger.NewService(store, func() int64 { return nowUnixUTC })
+	if err != nil {
+		test.Fatalf("new service: %v", err)
+	}
+	if err := service.Grant(ctx, fixture.tenantID, fixture.userID, fixture.ledgerID, mustAmount(test, 1000), mustKey(test, "grant-1"), 0, fixture.metadata); err != nil {
+		test.Fatalf("grant: %v", err)
+	}
+	nowUnixUTC = 200
+
+	writer := &recordingArchiveWriter{}
+	if _, err := failingService.CompactAccount(ctx, fixture.tenantID, fixture.userID, fixture.ledgerID, 150, writer); !errors.Is(err, archiveErr) {
+		test.Fatalf("expected the archive failure, got %v", err)
+	}
+	if writer.staged != 1 || writer.discarded != 1 || len(writer.archives) != 0 {
+		test.Fatalf("expected the staged archive to be discarded unpublished, got %+v", writer)
+	}
+	entries, err := service.ListEntries(ctx, fixture.tenantID, fixture.userID, fixture.ledgerID, 0, 10, ledger.ListEntriesFilter{})
+	if err != nil || len(entries) != 1 || entries[0].Type() != ledger.EntryGrant {
+		test.Fatalf("expected the rolled back compaction to keep the grant, got %v, %v", entries, err)
+	}
+
+	result, err := service.CompactAccount(ctx, fixture.tenantID, fixture.userID, fixture.ledgerID, 150, writer)
+	if err != nil {
+		test.Fatalf("compact: %v", err)
+	}
+	if writer.discarded != 1 || len(writer.archives) != 1 || result.Location != "archive-2" || len(writer.archives[0].Entries) != 1 {
+		test.Fatalf("expected the committed compaction to publish its archive, got result %+v and writer %+v", result, writer)
+	}
+}
+
+func TestStoreRejectsCancelledContexts(test *testing.T) {
+	test.Parallel()
+	store := New()
+	fixture := newAccountFixture(test, "user-1")
+	accountID, err := store.GetOrCreateAccountID(context.Background(), fixture.tenantID, fixture.userID, fixture.ledgerID)
+	if err != nil {
+		test.Fatalf("account: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	calls := map[string]func() error{
+		"with tx": func() error {
+			return store.WithTx(ctx, func(context.Context, ledger.Store) error { return nil })
+		},
+		"list accounts": func() error {
+			_, err := store.ListAccounts(ctx, fixture.tenantID, 0, 10, ledger.ListAccountsFilter{})
+			return err
+		},
+		"list reservations": func() error {
+			_, err := store.ListReservations(ctx, accountID, 0, 10, ledger.ListReservationsFilter{})
+			return err
+		},
+		"list entries": func() error {
+			_, err := store.ListEntries(ctx, accountID, 0, 10, ledger.ListEntriesFilter{})
+			return err
+		},
+		"sum journal lines": func() error {
+			_, err := store.SumJournalLines(ctx, fixture.tenantID, 100)
+			return err
+		},
+		"sum entries by expiry": func() error {
+			_, err := store.SumEntriesByExpiry(ctx, fixture.tenantID, 100)
+			return err
+		},
+		"list entry changes": func() error {
+			_, err := store.ListEntryChanges(ctx, fixture.tenantID, 0, 10, ledger.EntryChangeFilter{})
+			return err
+		},
+		"list chained entries": func() error {
+			_, err := store.ListChainedEntries(ctx, accountID, 0, 10)
+			return err
+		},
+	}
+	for name, call := range calls {
+		if err := call(); !errors.Is(err, context.Canceled) {
+			test.Fatalf("%s: expected context canceled, got %v", name, err)
+		}
+	}
+}
+
+func TestStoreRejectsInvalidValues(test *testing.T) {
+	test.Parallel()
+	ctx := context.Background()
+	store := New()
+	fixture := newAccountFixture(test, "user-1")
+	accountID, err := store.GetOrCreateAccountID(ctx, fixture.tenantID, fixture.userID, fixture.ledgerID)
+	if err != nil {
+		test.Fatalf("account: %v", err)
+	}
+	reservationID, err := ledger.NewReservationID("reservation-1")
+	if err != nil {
+		test.Fatalf("reservation id: %v", err)
+	}
+	reservation, err := ledger.NewReservation(accountID, reservationID, mustAmount(test, 10), ledger.ReservationStatusActive, 0)
+	if err != nil {
+		test.Fatalf("reservation: %v", err)
+	}
+	if err := store.CreateReservation(ctx, reservation); err != nil {
+		test.Fatalf("create reservation: %v", err)
+	}
+
+	if _, err := store.GetOrCreateAccountID(ctx, ledger.TenantID{}, fixture.userID, fixture.ledgerID); !errors.Is(err, ledger.ErrInvalidTenantID) {
+		test.Fatalf("expected invalid tenant id, got %v", err)
+	}
+	if _, err := store.InsertEntry(ctx, ledger.EntryInput{}); !errors.Is(err, ledger.ErrInvalidAccountID) {
+		test.Fatalf("expected invalid entry account id, got %v", err)
+	}
+	if err := store.CreateReservation(ctx, ledger.Reservation{}); !errors.Is(err, ledger.ErrInvalidAccountID) {
+		test.Fatalf("expected invalid account id, got %v", err)
+	}
+	if err := store.UpdateReservationStatus(ctx, accountID, reservationID, ledger.ReservationStatusActive, ledger.ReservationStatus("unknown")); !errors.Is(err, ledger.ErrInvalidReservationStatus) {
+		test.Fatalf("expected invalid reservation status, got %v", err)
+	}
+}
+
+func TestStoreStampsEntriesWithoutCreationTime(test *testing.T) {
+	test.Parallel()
+	ctx := context.Background()
+	store := New()
+	fixture := newAccountFixture(test, "user-1")
+	accountID, err := store.GetOrCreateAccountID(ctx, fixture.tenantID, fixture.userID, fixture.ledgerID)
+	if err != nil {
+		test.Fatalf("account: %v", err)
+	}
+	amount, err := ledger.NewEntryAmountCents(10)
+	if err != nil {
+		test.Fatalf("entry amount: %v", err)
+	}
+	input, err := ledger.NewEntryInput(accountID, ledger.EntryGrant, amount, nil, nil, mustKey(test, "unstamped"), 0, fixture.metadata, 0)
+	if err != nil {
+		test.Fatalf("entry input: %v", err)
+	}
+	entry, err := store.InsertEntry(ctx, input)
+	if err != nil {
+		test.Fatalf("insert entry: %v", err)
+	}
+	if entry.CreatedUnixUTC() == 0 {
+		test.Fatalf("expected the entry stamped with the current time")
+	}
+
+	accounts, err := store.ListAccounts(ctx, fixture.tenantID, 0, 10, ledger.ListAccountsFilter{CreatedAfterUnixUTC: entry.CreatedUnixUTC() + 3600})
+	if err != nil {
+		test.Fatalf("list accounts: %v", err)
+	}
+	if len(accounts) != 0 {
+		test.Fatalf("expected accounts created before the filter skipped, got %d", len(accounts))
+	}
+}
+
+func TestEntryChangesSkipEntriesOfUnknownAccounts(test *testing.T) {
+	test.Parallel()
+	ctx := context.Background()
+	store := New()
+	fixture := newAccountFixture(test, "user-1")
+	unknownAccountID, err := ledger.NewAccountID("unknown-account")
+	if err != nil {
+		test.Fatalf("account id: %v", err)
+	}
+	entry, err := store.InsertEntry(ctx, mustEntryInput(test, unknownAccountID, "orphan", 10))
+	if err != nil {
+		test.Fatalf("insert entry: %v", err)
+	}
+	if err := store.AppendEntryChange(ctx, fixture.tenantID, entry.EntryID()); err != nil {
+		test.Fatalf("append entry change: %v", err)
+	}
+	if err := store.AppendEntryChange(ctx, fixture.tenantID, entry.EntryID()); !errors.Is(err, errDuplicateEntryChange) {
+		test.Fatalf("expected duplicate entry change, got %v", err)
+	}
+	changes, err := store.ListEntryChanges(ctx, fixture.tenantID, 0, 10, ledger.EntryChangeFilter{})
+	if err != nil {
+		test.Fatalf("list entry changes: %v", err)
+	}
+	if len(changes) != 0 {
+		test.Fatalf("expected the orphaned entry skipped, got %d changes", len(changes))
+	}
+}
+
+func TestChainJournalOutboxAndStatusWritesRollBack(test *testing.T) {
+	test.Parallel()
+	ctx := context.Background()
+	store := New()
+	fixture := newAccountFixture(test, "user-1")
+	accountID, err := store.GetOrCreateAccountID(ctx, fixture.tenantID, fixture.userID, fixture.ledgerID)
+	if err != nil {
+		test.Fatalf("account: %v", err)
+	}
+	first, err := store.InsertEntry(ctx, mustEntryInput(test, accountID, "first", 10))
+	if err != nil {
+		test.Fatalf("insert first: %v", err)
+	}
+	second, err := store.InsertEntry(ctx, mustEntryInput(test, accountID, "second", 10))
+	if err != nil {
+		test.Fatalf("insert second: %v", err)
+	}
+	reservationID, err := ledger.NewReservationID("reservation-1")
+	if err != nil {
+		test.Fatalf("reservation id: %v", err)
+	}
+	reservation, err := ledger.NewReservation(accountID, reservationID, mustAmount(test, 10), ledger.ReservationStatusActive, 0)
+	if err != nil {
+		test.Fatalf("reservation: %v", err)
+	}
+	if err := store.CreateReservation(ctx, reservation); err != nil {
+		test.Fatalf("create reservation: %v", err)
+	}
+	if err := store.AppendChainLink(ctx, accountID, ledger.ChainLink{EntryID: first.EntryID(), Sequence: 1, Hash: "first"}); !errors.Is(err, errMissingChainHead) {
+		test.Fatalf("expected missing chain head, got %v", err)
+	}
+
+	err = store.WithTx(ctx, func(ctx context.Context, txStore ledger.Store) error {
+		chainStore := txStore.(ledger.ChainStore)
+		if _, err := chainStore.LockChainHead(ctx, accountID); err != nil {
+			return err
+		}
+		if err := chainStore.AppendChainLink(ctx, accountID, ledger.ChainLink{EntryID: first.EntryID(), Sequence: 1, Hash: "first"}); err != nil {
+			return err
+		}
+		if err := chainStore.AppendChainLink(ctx, accountID, ledger.ChainLink{EntryID: second.EntryID(), Sequence: 1, Hash: "second"}); !errors.Is(err, errDuplicateChainLink) {
+			return fmt.Errorf("expected duplicate chain link, got %w", err)
+		}
+		if err := chainStore.AppendChainLink(ctx, accountID, ledger.ChainLink{EntryID: first.EntryID(), Sequence: 2, Hash: "relinked"}); err != nil {
+			return err
+		}
+		if err := txStore.(ledger.JournalStore).InsertJournalLines(ctx, []ledger.JournalLine{{TenantID: fixture.tenantID, LedgerID: fixture.ledgerID, EntryID: first.EntryID(), AmountCents: 10, PostedUnixUTC: 100}}); err != nil {
+			return err
+		}
+		if err := txStore.(ledger.OutboxStore).InsertOutboxEvent(ctx, ledger.OutboxEvent{TenantID: fixture.tenantID, EventType: "entry.created", PayloadJSON: "{}", CreatedUnixUTC: 100}); err != nil {
+			return err
+		}
+		if err := txStore.UpdateReservationStatus(ctx, accountID, reservationID, ledger.ReservationStatusActive, ledger.ReservationStatusCaptured); err != nil {
+			return err
+		}
+		return errRollback
+	})
+	if !errors.Is(err, errRollback) {
+		test.Fatalf("expected rollback, got %v", err)
+	}
+	stored, err := store.GetReservation(ctx, accountID, reservationID)
+	if err != nil {
+		test.Fatalf("get reservation: %v", err)
+	}
+	if stored.Status() != ledger.ReservationStatusActive {
+		test.Fatalf("expected the status update rolled back, got %s", stored.Status())
+	}
+	head, err := store.GetChainHead(ctx, accountID)
+	if err != nil {
+		test.Fatalf("chain head: %v", err)
+	}
+	if head != (ledger.ChainHead{}) {
+		test.Fatalf("expected the chain head rolled back, got %+v", head)
+	}
+	chained, err := store.ListChainedEntries(ctx, accountID, 0, 10)
+	if err != nil {
+		test.Fatalf("list chained entries: %v", err)
+	}
+	if len(chained) != 0 {
+		test.Fatalf("expected the chain links rolled back, got %d", len(chained))
+	}
+	balances, err := store.SumJournalLines(ctx, fixture.tenantID, 100)
+	if err != nil {
+		test.Fatalf("sum journal lines: %v", err)
+	}
+	if len(balances) != 0 {
+		test.Fatalf("expected the journal lines rolled back, got %d", len(balances))
+	}
+	if len(store.state.outboxEvents) != 0 {
+		test.Fatalf("expected the outbox event rolled back, got %d", len(store.state.outboxEvents))
+	}
+}
+
+func TestArchiveEntriesRollsBackWithItsTransaction(test *testing.T) {
+	test.Parallel()
+	ctx := context.Background()
+	store := New()
+	fixture := newAccountFixture(test, "user-1")
+	accountID, err := store.GetOrCreateAccountID(ctx, fixture.tenantID, fixture.userID, fixture.ledgerID)
+	if err != nil {
+		test.Fatalf("account: %v", err)
+	}
+	first, err := store.InsertEntry(ctx, mustEntryInput(test, accountID, "first", 10))
+	if err != nil {
+		test.Fatalf("insert first: %v", err)
+	}
+	second, err := store.InsertEntry(ctx, mustEntryInput(test, accountID, "second", 10))
+	if err != nil {
+		test.Fatalf("insert second: %v", err)
+	}
+	if _, err := store.LockChainHead(ctx, accountID); err != nil {
+		test.Fatalf("lock chain head: %v", err)
+	}
+	if err := store.AppendChainLink(ctx, accountID, ledger.ChainLink{EntryID: first.EntryID(), Sequence: 1, Hash: "first"}); err != nil {
+		test.Fatalf("append chain link: %v", err)
+	}
+	reservationID, err := ledger.NewReservationID("reservation-1")
+	if err != nil {
+		test.Fatalf("reservation id: %v", err)
+	}
+	reservation, err := ledger.NewReservation(accountID, reservationID, mustAmount(test, 10), ledger.ReservationStatusCaptured, 0)
+	if err != nil {
+		test.Fatalf("reservation: %v", err)
+	}
+	if err := store.CreateReservation(ctx, reservation); err != nil {
+		test.Fatalf("create reservation: %v", err)
+	}
+	firstArchive := ledger.EntryArchive{AccountID: accountID, ThroughSequence: 1, ThroughHash: "first", ArchivedEntries: 1, Location: "first"}
+	rolledBack := func(archive ledger.EntryArchive, entryIDs []ledger.EntryID, reservationIDs []ledger.ReservationID) {
+		test.Helper()
+		err := store.WithTx(ctx, func(ctx context.Context, txStore ledger.Store) error {
+			if err := txStore.(ledger.ArchiveStore).ArchiveEntries(ctx, archive, entryIDs, reservationIDs); err != nil {
+				return err
+			}
+			return errRollback
+		})
+		if !errors.Is(err, errRollback) {
+			test.Fatalf("expected rollback, got %v", err)
+		}
+	}
+
+	rolledBack(firstArchive, []ledger.EntryID{first.EntryID()}, []ledger.ReservationID{reservationID})
+	if archive, err := store.GetEntryArchive(ctx, accountID); err != nil || !archive.IsZero() {
+		test.Fatalf("expected no archive record after the rollback, got %+v (%v)", archive, err)
+	}
+	chained, err := store.ListChainedEntries(ctx, accountID, 0, 10)
+	if err != nil || len(chained) != 1 {
+		test.Fatalf("expected the chained entry restored, got %d (%v)", len(chained), err)
+	}
+	if _, err := store.GetEntryByIdempotencyKey(ctx, accountID, mustKey(test, "first")); err != nil {
+		test.Fatalf("expected the idempotency key restored: %v", err)
+	}
+	if _, err := store.GetReservation(ctx, accountID, reservationID); err != nil {
+		test.Fatalf("expected the reservation restored: %v", err)
+	}
+
+	if err := store.ArchiveEntries(ctx, firstArchive, []ledger.EntryID{first.EntryID()}, nil); err != nil {
+		test.Fatalf("archive first: %v", err)
+	}
+	rolledBack(ledger.EntryArchive{AccountID: accountID, ArchivedEntries: 2, Location: "second"}, []ledger.EntryID{second.EntryID()}, nil)
+	archive, err := store.GetEntryArchive(ctx, accountID)
+	if err != nil {
+		test.Fatalf("entry archive: %v", err)
+	}
+	if archive != firstArchive {
+		test.Fatalf("expected the committed archive record restored, got %+v", archive)
+	}
+	if _, err := store.GetEntry(ctx, accountID, second.EntryID()); err != nil {
+		test.Fatalf("expected the second entry restored: %v", err)
+	}
+}
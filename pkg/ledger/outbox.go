@@ -0,0 +1,104 @@
+package ledger
+
+import "encoding/json"
+
+const outboxEntryEventPrefix = "entry."
+
+// OutboxEvent is a notification recorded in the same transaction as the change it describes,
+// so it is delivered if and only if that change commits.
+type OutboxEvent struct {
+	TenantID       TenantID
+	EventType      string
+	PayloadJSON    string
+	CreatedUnixUTC int64
+}
+
+// WithOutbox records an outbox event for every entry written for the listed tenants.
+func WithOutbox(tenantIDs ...TenantID) ServiceOption {
+	return func(service *Service) {
+		if len(tenantIDs) == 0 {
+			return
+		}
+		if service.outboxTenants == nil {
+			service.outboxTenants = make(map[TenantID]struct{}, len(tenantIDs))
+		}
+		for _, tenantID := range tenantIDs {
+			service.outboxTenants[tenantID] = struct{}{}
+		}
+	}
+}
+
+type entryEventPayload struct {
+	TenantID           string          `json:"tenant_id"`
+	UserID             string          `json:"user_id"`
+	LedgerID           string          `json:"ledger_id"`
+	EntryID            string          `json:"entry_id"`
+	AccountID          string          `json:"account_id"`
+	Type               string          `json:"type"`
+	AmountCents        int64           `json:"amount_cents"`
+	ReservationID      string          `json:"reservation_id,omitempty"`
+	RefundOfEntryID    string          `json:"refund_of_entry_id,omitempty"`
+	IdempotencyKey     string          `json:"idempotency_key"`
+	ExpiresAtUnixUTC   int64           `json:"expires_at_unix_utc,omitempty"`
+	EffectiveAtUnixUTC int64           `json:"effective_at_unix_utc,omitempty"`
+	Metadata           json.RawMessage `json:"metadata"`
+	CreatedUnixUTC     int64           `json:"created_unix_utc"`
+	Actor              *actorPayload   `json:"actor,omitempty"`
+}
+
+type actorPayload struct {
+	APIKeyID      string `json:"api_key_id,omitempty"`
+	Service       string `json:"service,omitempty"`
+	RequestID     string `json:"request_id,omitempty"`
+	ClientAddress string `json:"client_address,omitempty"`
+}
+
+func newEntryEventPayload(tenantID TenantID, userID UserID, ledgerID LedgerID, entry Entry) entryEventPayload {
+	payload := entryEventPayload{
+		TenantID:           tenantID.String(),
+		UserID:             userID.String(),
+		LedgerID:           ledgerID.String(),
+		EntryID:            entry.EntryID().String(),
+		AccountID:          entry.AccountID().String(),
+		Type:               entry.Type().String(),
+		AmountCents:        entry.AmountCents().Int64(),
+		IdempotencyKey:     entry.IdempotencyKey().String(),
+		ExpiresAtUnixUTC:   entry.ExpiresAtUnixUTC(),
+		EffectiveAtUnixUTC: entry.EffectiveAtUnixUTC(),
+		Metadata:           json.RawMessage(entry.MetadataJSON().String()),
+		CreatedUnixUTC:     entry.CreatedUnixUTC(),
+	}
+	if reservationID, ok := entry.ReservationID(); ok {
+		payload.ReservationID = reservationID.String()
+	}
+	if refundOfEntryID, ok := entry.RefundOfEntryID(); ok {
+		payload.RefundOfEntryID = refundOfEntryID.String()
+	}
+	if actor := entry.Actor(); !actor.IsZero() {
+		payload.Actor = &actorPayload{
+			APIKeyID:      actor.APIKeyID(),
+			Service:       actor.Service(),
+			RequestID:     actor.RequestID(),
+			ClientAddress: actor.ClientAddress(),
+		}
+	}
+	return payload
+}
+
+// entryOutboxEvent returns the "entry.<type>" outbox event for an entry, or nil for tenants without an outbox.
+func (service *Service) entryOutboxEvent(tenantID TenantID, userID UserID, ledgerID LedgerID, entry Entry) (*OutboxEvent, error) {
+	if _, ok := service.outboxTenants[tenantID]; !ok {
+		return nil, nil
+	}
+	payload := newEntryEventPayload(tenantID, userID, ledgerID, entry)
+	payloadJSON, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	return &OutboxEvent{
+		TenantID:       tenantID,
+		EventType:      outboxEntryEventPrefix + entry.Type().String(),
+		PayloadJSON:    string(payloadJSON),
+		CreatedUnixUTC: service.nowFn(),
+	}, nil
+}
@@ -0,0 +1,124 @@
+package ledger
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestOutboxRecordsEntryEventsForConfiguredTenants(test *testing.T) {
+	test.Parallel()
+	store := newStubStore(test, 0)
+	tenantID := mustTenantID(test, defaultTenantIDValue)
+	userID := mustUserID(test, "user-1")
+	ledgerID := mustLedgerID(test, defaultLedgerIDValue)
+	service, err := NewService(store, func() int64 { return 100 }, WithOutbox(tenantID))
+	if err != nil {
+		test.Fatalf("new service: %v", err)
+	}
+	ctx := context.Background()
+
+	if err := service.Grant(ctx, tenantID, userID, ledgerID, mustPositiveAmount(test, 500), mustIdempotencyKey(test, "grant-1"), 0, mustMetadata(test, `{"source":"signup"}`)); err != nil {
+		test.Fatalf("grant: %v", err)
+	}
+	if err := service.Spend(ctx, tenantID, userID, ledgerID, mustPositiveAmount(test, 900), mustIdempotencyKey(test, "spend-1"), mustMetadata(test, "{}")); !errors.Is(err, ErrInsufficientFunds) {
+		test.Fatalf("expected insufficient funds, got %v", err)
+	}
+	if len(store.outboxEvents) != 1 {
+		test.Fatalf("expected one committed event, got %+v", store.outboxEvents)
+	}
+	event := store.outboxEvents[0]
+	if event.TenantID != tenantID || event.EventType != "entry.grant" || event.CreatedUnixUTC != 100 {
+		test.Fatalf("unexpected event: %+v", event)
+	}
+	var payload map[string]any
+	if err := json.Unmarshal([]byte(event.PayloadJSON), &payload); err != nil {
+		test.Fatalf("decode payload: %v", err)
+	}
+	if payload["user_id"] != "user-1" || payload["ledger_id"] != defaultLedgerIDValue || payload["amount_cents"] != float64(500) || payload["idempotency_key"] != "grant-1" {
+		test.Fatalf("unexpected payload: %s", event.PayloadJSON)
+	}
+	if metadata, ok := payload["metadata"].(map[string]any); !ok || metadata["source"] != "signup" {
+		test.Fatalf("expected metadata to be embedded as json, got %s", event.PayloadJSON)
+	}
+
+	otherStore := newStubStore(test, 0)
+	otherService := mustNewService(test, otherStore)
+	if err := otherService.Grant(ctx, tenantID, userID, ledgerID, mustPositiveAmount(test, 500), mustIdempotencyKey(test, "grant-1"), 0, mustMetadata(test, "{}")); err != nil {
+		test.Fatalf("grant: %v", err)
+	}
+	if len(otherStore.outboxEvents) != 0 {
+		test.Fatalf("expected no events without an outbox, got %+v", otherStore.outboxEvents)
+	}
+}
+
+func TestWithOutboxWithoutTenantsRecordsNoEvents(test *testing.T) {
+	test.Parallel()
+	service, err := NewService(newStubStore(test, 0), func() int64 { return 100 }, WithOutbox())
+	if err != nil {
+		test.Fatalf("new service: %v", err)
+	}
+	if service.outboxTenants != nil {
+		test.Fatalf("expected no outbox tenants, got %+v", service.outboxTenants)
+	}
+}
+
+func TestEntryOutboxEventCarriesReservationAndActor(test *testing.T) {
+	test.Parallel()
+	tenantID := mustTenantID(test, defaultTenantIDValue)
+	service, err := NewService(newStubStore(test, 0), func() int64 { return 100 }, WithOutbox(tenantID))
+	if err != nil {
+		test.Fatalf("new service: %v", err)
+	}
+	reservationID := mustReservationID(test, "order-1")
+	actor, err := NewActor("key-1", "checkout", "", "")
+	if err != nil {
+		test.Fatalf("actor: %v", err)
+	}
+	entry := Entry{
+		entryID:        mustEntryID(test, "entry-1"),
+		accountID:      mustAccountID(test, "account-1"),
+		entryType:      EntryHold,
+		amountCents:    -200,
+		reservationID:  &reservationID,
+		idempotencyKey: mustIdempotencyKey(test, "hold-1"),
+		metadata:       mustMetadata(test, "{}"),
+		actor:          actor,
+	}
+	event, err := service.entryOutboxEvent(tenantID, mustUserID(test, "user-1"), mustLedgerID(test, defaultLedgerIDValue), entry)
+	if err != nil {
+		test.Fatalf("outbox event: %v", err)
+	}
+	var payload map[string]any
+	if err := json.Unmarshal([]byte(event.PayloadJSON), &payload); err != nil {
+		test.Fatalf("decode payload: %v", err)
+	}
+	actorPayload, ok := payload["actor"].(map[string]any)
+	if payload["reservation_id"] != "order-1" || !ok || actorPayload["api_key_id"] != "key-1" || actorPayload["service"] != "checkout" {
+		test.Fatalf("expected the reservation and actor in the payload, got %s", event.PayloadJSON)
+	}
+
+	refundOfEntryID := mustEntryID(test, "entry-0")
+	refund := entry
+	refund.entryType = EntryRefund
+	refund.amountCents = 50
+	refund.reservationID = nil
+	refund.refundOfEntryID = &refundOfEntryID
+	refundEvent, err := service.entryOutboxEvent(tenantID, mustUserID(test, "user-1"), mustLedgerID(test, defaultLedgerIDValue), refund)
+	if err != nil {
+		test.Fatalf("refund outbox event: %v", err)
+	}
+	var refundPayload map[string]any
+	if err := json.Unmarshal([]byte(refundEvent.PayloadJSON), &refundPayload); err != nil {
+		test.Fatalf("decode refund payload: %v", err)
+	}
+	if refundPayload["refund_of_entry_id"] != "entry-0" {
+		test.Fatalf("expected the refunded entry in the payload, got %s", refundEvent.PayloadJSON)
+	}
+
+	entry.metadata = MetadataJSON{}
+	if _, err := service.entryOutboxEvent(tenantID, mustUserID(test, "user-1"), mustLedgerID(test, defaultLedgerIDValue), entry); err == nil {
+		test.Fatalf("expected an entry without metadata to fail encoding")
+	}
+}
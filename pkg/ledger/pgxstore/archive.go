@@ -0,0 +1,83 @@
+package pgxstore
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/MarkoPoloResearchLab/ledger/pkg/ledger"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	errorSubjectArchive = "archive"
+	errorCodeDelete     = "delete"
+	errorCodeTombstone  = "tombstone"
+)
+
+const (
+	sqlGetEntryArchive = "SELECT cutoff_at, through_sequence, through_hash, archived_entries, location, archived_at" +
+		" FROM entry_archives WHERE account_id = $1"
+	sqlInsertArchivedEntryKeys = "INSERT INTO archived_entry_keys (account_id, idempotency_key, entry_id)" +
+		" SELECT account_id, idempotency_key, entry_id FROM ledger_entries WHERE account_id = $1 AND entry_id = ANY($2::text[]::uuid[])"
+	sqlDeleteArchivedEntries      = "DELETE FROM ledger_entries WHERE account_id = $1 AND entry_id = ANY($2::text[]::uuid[])"
+	sqlDeleteArchivedReservations = "DELETE FROM reservations WHERE account_id = $1 AND reservation_id = ANY($2::text[])"
+	sqlUpsertEntryArchive         = "INSERT INTO entry_archives (account_id, cutoff_at, through_sequence, through_hash, archived_entries, location, archived_at)" +
+		" VALUES ($1, $2, $3, $4, $5, $6, $7) ON CONFLICT (account_id) DO UPDATE SET cutoff_at = excluded.cutoff_at," +
+		" through_sequence = excluded.through_sequence, through_hash = excluded.through_hash," +
+		" archived_entries = excluded.archived_entries, location = excluded.location, archived_at = excluded.archived_at"
+)
+
+// GetEntryArchive reads the account's archive record. Accounts never compacted have the zero record.
+func (store *Store) GetEntryArchive(ctx context.Context, accountID ledger.AccountID) (ledger.EntryArchive, error) {
+	archive := ledger.EntryArchive{AccountID: accountID}
+	var cutoffAt, archivedAt time.Time
+	err := store.db.QueryRow(ctx, sqlGetEntryArchive, accountID.String()).
+		Scan(&cutoffAt, &archive.ThroughSequence, &archive.ThroughHash, &archive.ArchivedEntries, &archive.Location, &archivedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ledger.EntryArchive{}, nil
+	}
+	if err != nil {
+		return ledger.EntryArchive{}, wrapStoreError(errorSubjectArchive, errorCodeGet, err)
+	}
+	archive.CutoffUnixUTC = cutoffAt.UTC().Unix()
+	archive.ArchivedUnixUTC = archivedAt.UTC().Unix()
+	return archive, nil
+}
+
+// ArchiveEntries deletes the account's listed entries and reservations and replaces its archive record. The
+// idempotency keys and IDs of the deleted entries move to archived_entry_keys. Journal lines and change-feed positions of deleted entries stay behind; the feed skips them.
+func (store *Store) ArchiveEntries(ctx context.Context, archive ledger.EntryArchive, entryIDs []ledger.EntryID, reservationIDs []ledger.ReservationID) error {
+	accountID := archive.AccountID.String()
+	entryValues := make([]string, 0, len(entryIDs))
+	for _, entryID := range entryIDs {
+		entryValues = append(entryValues, entryID.String())
+	}
+	if _, err := store.db.Exec(ctx, sqlInsertArchivedEntryKeys, accountID, entryValues); err != nil {
+		return wrapStoreError(errorSubjectArchive, errorCodeTombstone, err)
+	}
+	tag, err := store.db.Exec(ctx, sqlDeleteArchivedEntries, accountID, entryValues)
+	if err != nil {
+		return wrapStoreError(errorSubjectArchive, errorCodeDelete, err)
+	}
+	if tag.RowsAffected() != int64(len(entryValues)) {
+		return wrapStoreError(errorSubjectArchive, errorCodeDelete, ledger.ErrUnknownEntry)
+	}
+	reservationValues := make([]string, 0, len(reservationIDs))
+	for _, reservationID := range reservationIDs {
+		reservationValues = append(reservationValues, reservationID.String())
+	}
+	tag, err = store.db.Exec(ctx, sqlDeleteArchivedReservations, accountID, reservationValues)
+	if err != nil {
+		return wrapStoreError(errorSubjectArchive, errorCodeDelete, err)
+	}
+	if tag.RowsAffected() != int64(len(reservationValues)) {
+		return wrapStoreError(errorSubjectArchive, errorCodeDelete, ledger.ErrUnknownReservation)
+	}
+	_, err = store.db.Exec(ctx, sqlUpsertEntryArchive, accountID, unixTime(archive.CutoffUnixUTC), archive.ThroughSequence,
+		archive.ThroughHash, archive.ArchivedEntries, archive.Location, unixTime(archive.ArchivedUnixUTC))
+	if err != nil {
+		return wrapStoreError(errorSubjectArchive, errorCodeUpdate, err)
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package pgxstore
+
+import (
+	"context"
+	"errors"
+
+	"github.com/MarkoPoloResearchLab/ledger/pkg/ledger"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	errorSubjectChain = "chain"
+	errorCodeLock     = "lock"
+	errorCodeUpdate   = "update"
+)
+
+const (
+	// sqlLockChainHead creates the head on first use; the no-op update locks it until the transaction commits.
+	sqlLockChainHead = "INSERT INTO account_chain_heads (account_id, last_sequence, last_hash) VALUES ($1, 0, '')" +
+		" ON CONFLICT (account_id) DO UPDATE SET last_sequence = account_chain_heads.last_sequence RETURNING last_sequence, last_hash"
+	// sqlAppendChainLink links the entry and moves the head in one statement, and moves the head only when
+	// the entry belongs to the account.
+	sqlAppendChainLink = "WITH linked AS (UPDATE ledger_entries SET chain_sequence = $3, previous_hash = $4, entry_hash = $5" +
+		" WHERE account_id = $1 AND entry_id = $2 RETURNING entry_id)," +
+		" moved AS (UPDATE account_chain_heads SET last_sequence = $3, last_hash = $5 WHERE account_id = $1 AND EXISTS (SELECT 1 FROM linked))" +
+		" SELECT count(*) FROM linked"
+	sqlGetChainHead       = "SELECT last_sequence, last_hash FROM account_chain_heads WHERE account_id = $1"
+	sqlListChainedEntries = "SELECT " + entryColumns + ", e.chain_sequence, e.previous_hash, e.entry_hash FROM ledger_entries e" +
+		" WHERE e.account_id = $1 AND e.chain_sequence > $2 ORDER BY e.chain_sequence ASC LIMIT $3"
+)
+
+// LockChainHead returns the account's chain head, creating an empty one on first use, and locks it until the
+// surrounding transaction commits, so writers to one account extend the chain in turn.
+func (store *Store) LockChainHead(ctx context.Context, accountID ledger.AccountID) (ledger.ChainHead, error) {
+	var head ledger.ChainHead
+	if err := store.db.QueryRow(ctx, sqlLockChainHead, accountID.String()).Scan(&head.Sequence, &head.Hash); err != nil {
+		return ledger.ChainHead{}, wrapStoreError(errorSubjectChain, errorCodeLock, err)
+	}
+	return head, nil
+}
+
+// AppendChainLink stores the link on its entry and moves the account's chain head to it.
+func (store *Store) AppendChainLink(ctx context.Context, accountID ledger.AccountID, link ledger.ChainLink) error {
+	var linked int64
+	err := store.db.QueryRow(ctx, sqlAppendChainLink, accountID.String(), link.EntryID.String(), link.Sequence, link.PreviousHash, link.Hash).Scan(&linked)
+	if err != nil {
+		return wrapStoreError(errorSubjectChain, errorCodeUpdate, err)
+	}
+	if linked != 1 {
+		return wrapStoreError(errorSubjectChain, errorCodeUpdate, ledger.ErrUnknownEntry)
+	}
+	return nil
+}
+
+// GetChainHead reads the account's chain head without locking it. Accounts without chained entries have the zero head.
+func (store *Store) GetChainHead(ctx context.Context, accountID ledger.AccountID) (ledger.ChainHead, error) {
+	var head ledger.ChainHead
+	err := store.db.QueryRow(ctx, sqlGetChainHead, accountID.String()).Scan(&head.Sequence, &head.Hash)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ledger.ChainHead{}, nil
+	}
+	if err != nil {
+		return ledger.ChainHead{}, wrapStoreError(errorSubjectChain, errorCodeGet, err)
+	}
+	return head, nil
+}
+
+// ListChainedEntries returns the account's chained entries after afterSequence in chain order.
+func (store *Store) ListChainedEntries(ctx context.Context, accountID ledger.AccountID, afterSequence int64, limit int) ([]ledger.ChainedEntry, error) {
+	rows, err := store.db.Query(ctx, sqlListChainedEntries, accountID.String(), afterSequence, limitValue(limit))
+	if err != nil {
+		return nil, wrapStoreError(errorSubjectChain, errorCodeList, err)
+	}
+	chained, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (ledger.ChainedEntry, error) {
+		var record entryRow
+		var link ledger.ChainLink
+		if err := row.Scan(append(record.scanTargets(), &link.Sequence, &link.PreviousHash, &link.Hash)...); err != nil {
+			return ledger.ChainedEntry{}, err
+		}
+		entry, err := mapEntry(record)
+		if err != nil {
+			return ledger.ChainedEntry{}, err
+		}
+		link.EntryID = entry.EntryID()
+		return ledger.ChainedEntry{Entry: entry, Link: link}, nil
+	})
+	if err != nil {
+		return nil, wrapStoreError(errorSubjectChain, errorCodeInvalid, err)
+	}
+	return chained, nil
+}
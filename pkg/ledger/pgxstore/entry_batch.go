@@ -0,0 +1,111 @@
+package pgxstore
+
+import (
+	"context"
+	"time"
+
+	"github.com/MarkoPoloResearchLab/ledger/pkg/ledger"
+	"github.com/jackc/pgx/v5"
+)
+
+// InsertEntryBatch writes an entry with its chain link, change-feed row, grant consumptions, outbox event, and
+// journal lines in two round trips, whatever their number, plus the reads prepare makes. One batch locks the
+// chain head and checks the archived keys; compaction holds the same lock, so no key is archived after the
+// check. The second batch carries every write, the change-feed row last so the tenant's feed head is locked for
+// as little of the batch as possible. Run it inside WithTx; a failed write aborts the transaction.
+func (store *Store) InsertEntryBatch(ctx context.Context, entryInput ledger.EntryInput, prepare func(entry ledger.Entry, head ledger.ChainHead) (ledger.EntryWrite, error)) (ledger.Entry, error) {
+	row := newEntryRow(entryInput)
+	head, err := store.lockEntryHead(ctx, row)
+	if err != nil {
+		return ledger.Entry{}, err
+	}
+	entry, err := mapEntry(row)
+	if err != nil {
+		return ledger.Entry{}, wrapStoreError(errorSubjectEntry, errorCodeInvalid, err)
+	}
+	write, err := prepare(entry, head)
+	if err != nil {
+		return ledger.Entry{}, err
+	}
+
+	batch := &pgx.Batch{}
+	batch.Queue(sqlInsertEntry, row.insertArgs()...)
+	batch.Queue(sqlAppendChainLink, row.AccountID, write.Link.EntryID.String(), write.Link.Sequence, write.Link.PreviousHash, write.Link.Hash)
+	for _, consumption := range write.GrantConsumptions {
+		batch.Queue(sqlConsumeGrant, row.AccountID, consumption.GrantEntryID.String(), consumption.AmountCents.Int64())
+	}
+	if write.OutboxEvent != nil {
+		batch.Queue(sqlInsertOutboxEvent, outboxEventArgs(*write.OutboxEvent)...)
+	}
+	queueJournalLines(batch, write.JournalLines)
+	batch.Queue(sqlAppendEntryChange, write.TenantID.String(), row.EntryID, time.Now().UTC())
+
+	results := store.db.SendBatch(ctx, batch)
+	err = readEntryWrites(results, write)
+	if closeErr := results.Close(); err == nil && closeErr != nil {
+		err = wrapStoreError(errorSubjectEntry, errorCodeInsert, closeErr)
+	}
+	if err != nil {
+		return ledger.Entry{}, err
+	}
+	return entry, nil
+}
+
+// lockEntryHead locks the account's chain head and rejects the entry's idempotency key when compaction
+// archived it. The two statements share a round trip; the check runs after the lock is held.
+func (store *Store) lockEntryHead(ctx context.Context, row entryRow) (ledger.ChainHead, error) {
+	batch := &pgx.Batch{}
+	batch.Queue(sqlLockChainHead, row.AccountID)
+	batch.Queue(sqlArchivedEntryKey, row.AccountID, row.IdempotencyKey)
+	results := store.db.SendBatch(ctx, batch)
+	defer func() { _ = results.Close() }()
+	var head ledger.ChainHead
+	if err := results.QueryRow().Scan(&head.Sequence, &head.Hash); err != nil {
+		return ledger.ChainHead{}, wrapStoreError(errorSubjectChain, errorCodeLock, err)
+	}
+	var archived bool
+	if err := results.QueryRow().Scan(&archived); err != nil {
+		return ledger.ChainHead{}, wrapStoreError(errorSubjectEntry, errorCodeInsert, err)
+	}
+	if archived {
+		return ledger.ChainHead{}, wrapStoreError(errorSubjectEntry, errorCodeDuplicate, ledger.ErrDuplicateIdempotencyKey)
+	}
+	return head, nil
+}
+
+// readEntryWrites reads the results of InsertEntryBatch's write batch in queue order and returns the first failure.
+func readEntryWrites(results pgx.BatchResults, write ledger.EntryWrite) error {
+	if err := insertedEntryError(results.Exec()); err != nil {
+		return err
+	}
+	var linked int64
+	if err := results.QueryRow().Scan(&linked); err != nil {
+		return wrapStoreError(errorSubjectChain, errorCodeUpdate, err)
+	}
+	if linked != 1 {
+		return wrapStoreError(errorSubjectChain, errorCodeUpdate, ledger.ErrUnknownEntry)
+	}
+	for range write.GrantConsumptions {
+		tag, err := results.Exec()
+		if err != nil {
+			return wrapStoreError(errorSubjectEntry, errorCodeConsumeGrant, err)
+		}
+		if tag.RowsAffected() == 0 {
+			return wrapStoreError(errorSubjectEntry, errorCodeConsumeGrant, ledger.ErrUnknownEntry)
+		}
+	}
+	if write.OutboxEvent != nil {
+		if _, err := results.Exec(); err != nil {
+			return wrapStoreError(errorSubjectOutbox, errorCodeInsert, err)
+		}
+	}
+	for range write.JournalLines {
+		if _, err := results.Exec(); err != nil {
+			return wrapStoreError(errorSubjectJournal, errorCodeInsert, err)
+		}
+	}
+	if _, err := results.Exec(); err != nil {
+		return wrapStoreError(errorSubjectEntryFeed, errorCodeSequence, err)
+	}
+	return nil
+}
@@ -0,0 +1,936 @@
+// Package pgxstore implements ledger.Store on PostgreSQL through pgx, without an ORM. It reads and writes the
+// schema that gormstore migrations create; run gormstore.MigrateUp or `ledgerd migrate up` first.
+package pgxstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MarkoPoloResearchLab/ledger/pkg/ledger"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	defaultMetadataJSON         = "{}"
+	outboxStatusPending         = "pending"
+	pgUniqueViolationCode       = "23505"
+	constraintEntryIdempotency  = "uniq_entry_idem"
+	constraintReservationKey    = "reservations_pkey"
+	errorOperationStore         = "store"
+	errorSubjectAccount         = "account"
+	errorSubjectBalance         = "balance"
+	errorSubjectEntry           = "entry"
+	errorSubjectEntryFeed       = "entry_feed"
+	errorSubjectJournal         = "journal"
+	errorSubjectOutbox          = "outbox"
+	errorSubjectReservation     = "reservation"
+	errorSubjectTransaction     = "transaction"
+	errorCodeBegin              = "begin"
+	errorCodeCommit             = "commit"
+	errorCodeConsumeGrant       = "consume_grant"
+	errorCodeCreate             = "create"
+	errorCodeDuplicate          = "duplicate"
+	errorCodeGet                = "get"
+	errorCodeInsert             = "insert"
+	errorCodeInvalid            = "invalid"
+	errorCodeList               = "list"
+	errorCodeLookup             = "lookup"
+	errorCodeSequence           = "sequence"
+	errorCodeSumActiveHolds     = "sum_active_holds"
+	errorCodeSumBalance         = "sum_balance"
+	errorCodeSumDebitVelocity   = "sum_debit_velocity"
+	errorCodeSumEntriesByExpiry = "sum_entries_by_expiry"
+	errorCodeSumJournalLines    = "sum_journal_lines"
+	errorCodeSumRefunds         = "sum_refunds"
+	errorCodeSumTotal           = "sum_total"
+	errorCodeUpdateStatus       = "update_status"
+)
+
+const entryColumns = "e.entry_id::text, e.account_id::text, e.type, e.amount_cents, e.reservation_id, e.refund_of_entry_id::text," +
+	" e.idempotency_key, e.expires_at, e.effective_at, e.metadata::text, e.created_at," +
+	" e.actor_api_key_id, e.actor_service, e.actor_request_id, e.actor_client_address, e.consumed_cents"
+
+// sqlEntryBalance is what one entry row contributes to the balance total: its amount, or only the consumed
+// part once it has expired. It expects the evaluation time as $2.
+const sqlEntryBalance = "CASE WHEN expires_at IS NOT NULL AND expires_at <= $2 THEN consumed_cents ELSE amount_cents END"
+
+const (
+	sqlGetOrCreateAccount = "INSERT INTO accounts (account_id, tenant_id, user_id, ledger_id, created_at) VALUES ($1, $2, $3, $4, $5)" +
+		" ON CONFLICT (tenant_id, user_id, ledger_id) DO UPDATE SET tenant_id = excluded.tenant_id RETURNING account_id::text"
+	sqlGetAccount  = "SELECT account_id::text FROM accounts WHERE tenant_id = $1 AND user_id = $2 AND ledger_id = $3"
+	sqlInsertEntry = "INSERT INTO ledger_entries (entry_id, account_id, type, amount_cents, reservation_id, refund_of_entry_id," +
+		" idempotency_key, expires_at, effective_at, metadata, created_at," +
+		" actor_api_key_id, actor_service, actor_request_id, actor_client_address)" +
+		" SELECT $1::uuid, $2::uuid, $3::text, $4::bigint, $5::text, $6::uuid, $7::text, $8::timestamptz, $9::timestamptz, $10::jsonb," +
+		" $11::timestamptz, $12::text, $13::text, $14::text, $15::text" +
+		" WHERE NOT EXISTS (SELECT 1 FROM archived_entry_keys WHERE account_id = $2::uuid AND idempotency_key = $7::text)"
+	sqlGetEntry              = "SELECT " + entryColumns + " FROM ledger_entries e WHERE e.account_id = $1 AND e.entry_id = $2 FOR UPDATE"
+	sqlGetEntryByKey         = "SELECT " + entryColumns + " FROM ledger_entries e WHERE e.account_id = $1 AND e.idempotency_key = $2"
+	sqlArchivedEntryID       = "SELECT EXISTS (SELECT 1 FROM archived_entry_keys WHERE account_id = $1 AND entry_id = $2)"
+	sqlArchivedEntryKey      = "SELECT EXISTS (SELECT 1 FROM archived_entry_keys WHERE account_id = $1 AND idempotency_key = $2)"
+	sqlSumRefunds            = "SELECT coalesce(sum(amount_cents), 0)::bigint FROM ledger_entries WHERE account_id = $1 AND type = $2 AND refund_of_entry_id = $3"
+	sqlTotalExpression       = "SELECT coalesce(sum(" + sqlEntryBalance + "), 0)::bigint FROM ledger_entries WHERE account_id = $1 AND type NOT IN ('hold', 'reverse_hold') AND (effective_at IS NULL OR effective_at <= $2)"
+	sqlActiveHoldsExpression = "SELECT coalesce(sum(amount_cents), 0)::bigint FROM reservations WHERE account_id = $1 AND status = 'active' AND (expires_at IS NULL OR expires_at > $2)"
+	sqlSumBalance            = "SELECT (" + sqlTotalExpression + "), (" + sqlActiveHoldsExpression + ")"
+	sqlSumDebitVelocity      = "SELECT coalesce(sum(amount_cents), 0)::bigint, coalesce(sum(CASE WHEN type = $2 OR reservation_id IS NULL THEN 1 ELSE 0 END), 0)::bigint" +
+		" FROM ledger_entries e WHERE account_id = $1 AND type = ANY($3) AND created_at >= $4" +
+		" AND (type <> $5 OR EXISTS (SELECT 1 FROM ledger_entries holds WHERE holds.account_id = e.account_id" +
+		" AND holds.reservation_id = e.reservation_id AND holds.type = $2 AND holds.created_at >= $4))"
+	sqlInsertJournalLine = "INSERT INTO journal_lines (line_id, tenant_id, ledger_id, entry_id, account, user_account_id, amount_cents, posted_at, created_at)" +
+		" VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)"
+	sqlSumJournalLines = "SELECT ledger_id, account, coalesce(sum(amount_cents), 0)::bigint FROM journal_lines" +
+		" WHERE tenant_id = $1 AND posted_at <= $2 GROUP BY ledger_id, account ORDER BY ledger_id, account"
+	sqlSumEntriesByExpiry = "SELECT a.ledger_id, e.expires_at, coalesce(sum(e.amount_cents - e.consumed_cents), 0)::bigint," +
+		" coalesce(sum(e.consumed_cents), 0)::bigint FROM ledger_entries e" +
+		" JOIN accounts a ON a.account_id = e.account_id" +
+		" WHERE a.tenant_id = $1 AND e.type NOT IN ('hold', 'reverse_hold') AND (e.effective_at IS NULL OR e.effective_at <= $2)" +
+		" AND (e.effective_at IS NULL OR e.expires_at IS NULL OR e.effective_at < e.expires_at)" +
+		" GROUP BY a.ledger_id, e.expires_at"
+	sqlInsertOutboxEvent = "INSERT INTO outbox_events (event_id, tenant_id, event_type, payload, status, attempts, next_attempt_at, last_error, created_at)" +
+		" VALUES ($1, $2, $3, $4::jsonb, $5, 0, $6, '', $6)"
+	// sqlAppendEntryChange bumps the tenant's feed head and records the change in one statement. The upsert
+	// locks the head row until the surrounding transaction commits, so sequences follow commit order.
+	sqlAppendEntryChange = "WITH head AS (INSERT INTO entry_feed_heads (tenant_id, last_sequence) VALUES ($1, 1)" +
+		" ON CONFLICT (tenant_id) DO UPDATE SET last_sequence = entry_feed_heads.last_sequence + 1 RETURNING last_sequence)" +
+		" INSERT INTO entry_changes (tenant_id, sequence, entry_id, created_at) SELECT $1::text, last_sequence, $2::uuid, $3::timestamptz FROM head"
+	sqlListConsumableGrants = "SELECT " + entryColumns + " FROM ledger_entries e WHERE e.account_id = $1 AND e.type = 'grant'" +
+		" AND e.expires_at > $2 AND (e.effective_at IS NULL OR e.effective_at <= $2) AND e.consumed_cents < e.amount_cents" +
+		" ORDER BY e.expires_at, e.created_at, e.entry_id"
+	sqlConsumeGrant      = "UPDATE ledger_entries SET consumed_cents = consumed_cents + $3 WHERE account_id = $1 AND entry_id = $2 AND type = 'grant'"
+	sqlCreateReservation = "INSERT INTO reservations (account_id, reservation_id, amount_cents, status, expires_at, created_at, updated_at)" +
+		" VALUES ($1, $2, $3, $4, $5, $6, $6)"
+	sqlGetReservation = "SELECT amount_cents, status, expires_at, created_at, updated_at FROM reservations" +
+		" WHERE account_id = $1 AND reservation_id = $2 FOR UPDATE"
+	sqlUpdateReservationStatus = "UPDATE reservations SET status = $4, updated_at = $5 WHERE account_id = $1 AND reservation_id = $2 AND status = $3"
+)
+
+// querier is the part of pgxpool.Pool and pgx.Tx the store uses; Begin on a pgx.Tx opens a savepoint.
+type querier interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+	SendBatch(ctx context.Context, batch *pgx.Batch) pgx.BatchResults
+}
+
+// Store implements ledger.Store, ledger.BalanceStore, and ledger.ArchiveStore using pgx.
+type Store struct {
+	db querier
+}
+
+// New returns a Store backed by pool. Statements run in pgx's default cached-statement mode, so each
+// connection prepares a statement once and reuses it.
+func New(pool *pgxpool.Pool) *Store {
+	return &Store{db: pool}
+}
+
+// Open connects a pool to databaseURL, a postgres:// URL or key/value DSN, and returns a Store on it with
+// the pool, which the caller closes. It fails when the database cannot be reached.
+func Open(ctx context.Context, databaseURL string) (*Store, *pgxpool.Pool, error) {
+	config, err := pgxpool.ParseConfig(databaseURL)
+	if err != nil {
+		return nil, nil, fmt.Errorf("parse database url: %w", err)
+	}
+	config.ConnConfig.DefaultQueryExecMode = pgx.QueryExecModeCacheStatement
+	pool, err := pgxpool.NewWithConfig(ctx, config)
+	if err != nil {
+		return nil, nil, fmt.Errorf("open pool: %w", err)
+	}
+	if err := pool.Ping(ctx); err != nil {
+		pool.Close()
+		return nil, nil, fmt.Errorf("ping database: %w", err)
+	}
+	return New(pool), pool, nil
+}
+
+// WithTx executes fn within a transaction, or within a savepoint when the store is already transactional.
+// Returning an error or panicking from fn rolls back; rolling back after a commit is a no-op.
+func (store *Store) WithTx(ctx context.Context, fn func(ctx context.Context, txStore ledger.Store) error) error {
+	transaction, err := store.db.Begin(ctx)
+	if err != nil {
+		return wrapStoreError(errorSubjectTransaction, errorCodeBegin, err)
+	}
+	defer func() { _ = transaction.Rollback(ctx) }()
+	if err := fn(ctx, &Store{db: transaction}); err != nil {
+		return err
+	}
+	if err := transaction.Commit(ctx); err != nil {
+		return wrapStoreError(errorSubjectTransaction, errorCodeCommit, err)
+	}
+	return nil
+}
+
+func (store *Store) GetOrCreateAccountID(ctx context.Context, tenantID ledger.TenantID, userID ledger.UserID, ledgerID ledger.LedgerID) (ledger.AccountID, error) {
+	var rawAccountID string
+	err := store.db.QueryRow(ctx, sqlGetOrCreateAccount, uuid.NewString(), tenantID.String(), userID.String(), ledgerID.String(), time.Now().UTC()).Scan(&rawAccountID)
+	if err != nil {
+		return ledger.AccountID{}, wrapStoreError(errorSubjectAccount, errorCodeLookup, err)
+	}
+	accountID, err := ledger.NewAccountID(rawAccountID)
+	if err != nil {
+		return ledger.AccountID{}, wrapStoreError(errorSubjectAccount, errorCodeInvalid, err)
+	}
+	return accountID, nil
+}
+
+func (store *Store) GetAccountID(ctx context.Context, tenantID ledger.TenantID, userID ledger.UserID, ledgerID ledger.LedgerID) (ledger.AccountID, error) {
+	var rawAccountID string
+	err := store.db.QueryRow(ctx, sqlGetAccount, tenantID.String(), userID.String(), ledgerID.String()).Scan(&rawAccountID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ledger.AccountID{}, wrapStoreError(errorSubjectAccount, errorCodeGet, ledger.ErrUnknownAccount)
+	}
+	if err != nil {
+		return ledger.AccountID{}, wrapStoreError(errorSubjectAccount, errorCodeGet, err)
+	}
+	accountID, err := ledger.NewAccountID(rawAccountID)
+	if err != nil {
+		return ledger.AccountID{}, wrapStoreError(errorSubjectAccount, errorCodeInvalid, err)
+	}
+	return accountID, nil
+}
+
+func (store *Store) ListAccounts(ctx context.Context, tenantID ledger.TenantID, beforeCreatedUnixUTC int64, limit int, filter ledger.ListAccountsFilter) ([]ledger.Account, error) {
+	var args queryArgs
+	query := "SELECT account_id::text, tenant_id, user_id, ledger_id, created_at FROM accounts" +
+		" WHERE tenant_id = " + args.add(tenantID.String()) + " AND created_at < " + args.add(beforeOrNow(beforeCreatedUnixUTC))
+	if filter.LedgerID != nil {
+		query += " AND ledger_id = " + args.add(filter.LedgerID.String())
+	}
+	if filter.UserIDPrefix != "" {
+		query += " AND user_id LIKE " + args.add(likePrefixPattern(filter.UserIDPrefix)) + ` ESCAPE '\'`
+	}
+	if filter.CreatedAfterUnixUTC != 0 {
+		query += " AND created_at >= " + args.add(unixTime(filter.CreatedAfterUnixUTC))
+	}
+	if filter.NonZeroBalance {
+		at := args.add(unixTime(filter.BalanceAtUnixUTC))
+		query += " AND (SELECT coalesce(sum(CASE WHEN e.expires_at IS NOT NULL AND e.expires_at <= " + at +
+			" THEN e.consumed_cents ELSE e.amount_cents END), 0) FROM ledger_entries e WHERE e.account_id = accounts.account_id" +
+			" AND e.type NOT IN ('hold', 'reverse_hold')" +
+			" AND (e.effective_at IS NULL OR e.effective_at <= " + at + ")) <> 0"
+	}
+	query += " ORDER BY created_at DESC LIMIT " + args.add(limitValue(limit))
+
+	rows, err := store.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, wrapStoreError(errorSubjectAccount, errorCodeList, err)
+	}
+	accounts, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (ledger.Account, error) {
+		var rawAccountID, rawTenantID, rawUserID, rawLedgerID string
+		var createdAt time.Time
+		if err := row.Scan(&rawAccountID, &rawTenantID, &rawUserID, &rawLedgerID, &createdAt); err != nil {
+			return ledger.Account{}, err
+		}
+		return mapAccount(rawAccountID, rawTenantID, rawUserID, rawLedgerID, createdAt)
+	})
+	if err != nil {
+		return nil, wrapStoreError(errorSubjectAccount, errorCodeInvalid, err)
+	}
+	return accounts, nil
+}
+
+func (store *Store) InsertEntry(ctx context.Context, entryInput ledger.EntryInput) (ledger.Entry, error) {
+	row := newEntryRow(entryInput)
+	tag, err := store.db.Exec(ctx, sqlInsertEntry, row.insertArgs()...)
+	if err := insertedEntryError(tag, err); err != nil {
+		return ledger.Entry{}, err
+	}
+	entry, err := mapEntry(row)
+	if err != nil {
+		return ledger.Entry{}, wrapStoreError(errorSubjectEntry, errorCodeInvalid, err)
+	}
+	return entry, nil
+}
+
+func (store *Store) GetEntry(ctx context.Context, accountID ledger.AccountID, entryID ledger.EntryID) (ledger.Entry, error) {
+	return store.getEntry(ctx, sqlGetEntry, sqlArchivedEntryID, accountID.String(), entryID.String())
+}
+
+func (store *Store) GetEntryByIdempotencyKey(ctx context.Context, accountID ledger.AccountID, idempotencyKey ledger.IdempotencyKey) (ledger.Entry, error) {
+	return store.getEntry(ctx, sqlGetEntryByKey, sqlArchivedEntryKey, accountID.String(), idempotencyKey.String())
+}
+
+// getEntry reads one entry with query; when there is none, archivedQuery, which takes the same arguments,
+// tells an entry compaction archived from an unknown one.
+func (store *Store) getEntry(ctx context.Context, query string, archivedQuery string, args ...any) (ledger.Entry, error) {
+	var row entryRow
+	err := store.db.QueryRow(ctx, query, args...).Scan(row.scanTargets()...)
+	if errors.Is(err, pgx.ErrNoRows) {
+		var archived bool
+		if err := store.db.QueryRow(ctx, archivedQuery, args...).Scan(&archived); err != nil {
+			return ledger.Entry{}, wrapStoreError(errorSubjectEntry, errorCodeGet, err)
+		}
+		if archived {
+			return ledger.Entry{}, wrapStoreError(errorSubjectEntry, errorCodeGet, ledger.ErrArchivedEntry)
+		}
+		return ledger.Entry{}, wrapStoreError(errorSubjectEntry, errorCodeGet, ledger.ErrUnknownEntry)
+	}
+	if err != nil {
+		return ledger.Entry{}, wrapStoreError(errorSubjectEntry, errorCodeGet, err)
+	}
+	entry, err := mapEntry(row)
+	if err != nil {
+		return ledger.Entry{}, wrapStoreError(errorSubjectEntry, errorCodeInvalid, err)
+	}
+	return entry, nil
+}
+
+func (store *Store) SumRefunds(ctx context.Context, accountID ledger.AccountID, originalEntryID ledger.EntryID) (ledger.AmountCents, error) {
+	var total int64
+	err := store.db.QueryRow(ctx, sqlSumRefunds, accountID.String(), ledger.EntryRefund.String(), originalEntryID.String()).Scan(&total)
+	if err != nil {
+		return 0, wrapStoreError(errorSubjectBalance, errorCodeSumRefunds, err)
+	}
+	refunded, err := ledger.NewAmountCents(total)
+	if err != nil {
+		return 0, wrapStoreError(errorSubjectBalance, errorCodeInvalid, err)
+	}
+	return refunded, nil
+}
+
+func (store *Store) SumTotal(ctx context.Context, accountID ledger.AccountID, atUnixUTC int64) (ledger.SignedAmountCents, error) {
+	var total int64
+	if err := store.db.QueryRow(ctx, sqlTotalExpression, accountID.String(), unixTime(atUnixUTC)).Scan(&total); err != nil {
+		return 0, wrapStoreError(errorSubjectBalance, errorCodeSumTotal, err)
+	}
+	return ledger.SignedAmountCents(total), nil
+}
+
+func (store *Store) SumActiveHolds(ctx context.Context, accountID ledger.AccountID, atUnixUTC int64) (ledger.AmountCents, error) {
+	var total int64
+	if err := store.db.QueryRow(ctx, sqlActiveHoldsExpression, accountID.String(), unixTime(atUnixUTC)).Scan(&total); err != nil {
+		return 0, wrapStoreError(errorSubjectBalance, errorCodeSumActiveHolds, err)
+	}
+	activeHolds, err := ledger.NewAmountCents(total)
+	if err != nil {
+		return 0, wrapStoreError(errorSubjectBalance, errorCodeInvalid, err)
+	}
+	return activeHolds, nil
+}
+
+// SumBalance returns SumTotal and SumActiveHolds from one query.
+func (store *Store) SumBalance(ctx context.Context, accountID ledger.AccountID, atUnixUTC int64) (ledger.SignedAmountCents, ledger.AmountCents, error) {
+	var total, holds int64
+	if err := store.db.QueryRow(ctx, sqlSumBalance, accountID.String(), unixTime(atUnixUTC)).Scan(&total, &holds); err != nil {
+		return 0, 0, wrapStoreError(errorSubjectBalance, errorCodeSumBalance, err)
+	}
+	activeHolds, err := ledger.NewAmountCents(holds)
+	if err != nil {
+		return 0, 0, wrapStoreError(errorSubjectBalance, errorCodeInvalid, err)
+	}
+	return ledger.SignedAmountCents(total), activeHolds, nil
+}
+
+func (store *Store) SumDebitVelocity(ctx context.Context, accountID ledger.AccountID, sinceUnixUTC int64) (ledger.DebitVelocity, error) {
+	debitTypes := []string{ledger.EntrySpend.String(), ledger.EntryHold.String(), ledger.EntryReverseHold.String()}
+	var total, operations int64
+	err := store.db.QueryRow(ctx, sqlSumDebitVelocity, accountID.String(), ledger.EntryHold.String(), debitTypes, unixTime(sinceUnixUTC), ledger.EntryReverseHold.String()).Scan(&total, &operations)
+	if err != nil {
+		return ledger.DebitVelocity{}, wrapStoreError(errorSubjectBalance, errorCodeSumDebitVelocity, err)
+	}
+	return ledger.DebitVelocity{AmountCents: ledger.AmountCents(max(-total, 0)), Operations: operations}, nil
+}
+
+// InsertJournalLines sends the lines as one pipelined batch.
+func (store *Store) InsertJournalLines(ctx context.Context, lines []ledger.JournalLine) error {
+	if len(lines) == 0 {
+		return nil
+	}
+	batch := &pgx.Batch{}
+	queueJournalLines(batch, lines)
+	if err := store.db.SendBatch(ctx, batch).Close(); err != nil {
+		return wrapStoreError(errorSubjectJournal, errorCodeInsert, err)
+	}
+	return nil
+}
+
+func queueJournalLines(batch *pgx.Batch, lines []ledger.JournalLine) {
+	createdAt := time.Now().UTC()
+	for _, line := range lines {
+		var userAccountID *string
+		if line.Account == ledger.JournalAccountUser {
+			value := line.UserAccountID.String()
+			userAccountID = &value
+		}
+		batch.Queue(sqlInsertJournalLine,
+			uuid.NewString(),
+			line.TenantID.String(),
+			line.LedgerID.String(),
+			line.EntryID.String(),
+			line.Account.String(),
+			userAccountID,
+			line.AmountCents.Int64(),
+			unixTime(line.PostedUnixUTC),
+			createdAt,
+		)
+	}
+}
+
+func (store *Store) SumJournalLines(ctx context.Context, tenantID ledger.TenantID, atUnixUTC int64) ([]ledger.TrialBalanceLine, error) {
+	rows, err := store.db.Query(ctx, sqlSumJournalLines, tenantID.String(), unixTime(atUnixUTC))
+	if err != nil {
+		return nil, wrapStoreError(errorSubjectJournal, errorCodeSumJournalLines, err)
+	}
+	balances, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (ledger.TrialBalanceLine, error) {
+		var rawLedgerID, rawAccount string
+		var total int64
+		if err := row.Scan(&rawLedgerID, &rawAccount, &total); err != nil {
+			return ledger.TrialBalanceLine{}, err
+		}
+		ledgerID, err := ledger.NewLedgerID(rawLedgerID)
+		if err != nil {
+			return ledger.TrialBalanceLine{}, err
+		}
+		account, err := ledger.ParseJournalAccount(rawAccount)
+		if err != nil {
+			return ledger.TrialBalanceLine{}, err
+		}
+		return ledger.TrialBalanceLine{LedgerID: ledgerID, Account: account, BalanceCents: ledger.SignedAmountCents(total)}, nil
+	})
+	if err != nil {
+		return nil, wrapStoreError(errorSubjectJournal, errorCodeInvalid, err)
+	}
+	return balances, nil
+}
+
+func (store *Store) SumEntriesByExpiry(ctx context.Context, tenantID ledger.TenantID, atUnixUTC int64) ([]ledger.ExpiryTotal, error) {
+	rows, err := store.db.Query(ctx, sqlSumEntriesByExpiry, tenantID.String(), unixTime(atUnixUTC))
+	if err != nil {
+		return nil, wrapStoreError(errorSubjectBalance, errorCodeSumEntriesByExpiry, err)
+	}
+	totals, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (ledger.ExpiryTotal, error) {
+		var rawLedgerID string
+		var expiresAt *time.Time
+		var total, consumed int64
+		if err := row.Scan(&rawLedgerID, &expiresAt, &total, &consumed); err != nil {
+			return ledger.ExpiryTotal{}, err
+		}
+		ledgerID, err := ledger.NewLedgerID(rawLedgerID)
+		if err != nil {
+			return ledger.ExpiryTotal{}, err
+		}
+		return ledger.ExpiryTotal{LedgerID: ledgerID, ExpiresAtUnixUTC: timeOrZero(expiresAt), AmountCents: ledger.SignedAmountCents(total), ConsumedCents: ledger.AmountCents(consumed)}, nil
+	})
+	if err != nil {
+		return nil, wrapStoreError(errorSubjectBalance, errorCodeInvalid, err)
+	}
+	return totals, nil
+}
+
+// ListConsumableGrants returns the account's effective grants that expire after atUnixUTC and are not fully
+// consumed, earliest expiry first.
+func (store *Store) ListConsumableGrants(ctx context.Context, accountID ledger.AccountID, atUnixUTC int64) ([]ledger.Entry, error) {
+	rows, err := store.db.Query(ctx, sqlListConsumableGrants, accountID.String(), unixTime(atUnixUTC))
+	if err != nil {
+		return nil, wrapStoreError(errorSubjectEntry, errorCodeList, err)
+	}
+	grants, err := collectEntries(rows)
+	if err != nil {
+		return nil, wrapStoreError(errorSubjectEntry, errorCodeInvalid, err)
+	}
+	return grants, nil
+}
+
+// ConsumeGrant adds amountCents to the consumed part of one of the account's grants.
+func (store *Store) ConsumeGrant(ctx context.Context, accountID ledger.AccountID, entryID ledger.EntryID, amountCents ledger.AmountCents) error {
+	tag, err := store.db.Exec(ctx, sqlConsumeGrant, accountID.String(), entryID.String(), amountCents.Int64())
+	if err != nil {
+		return wrapStoreError(errorSubjectEntry, errorCodeConsumeGrant, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return wrapStoreError(errorSubjectEntry, errorCodeConsumeGrant, ledger.ErrUnknownEntry)
+	}
+	return nil
+}
+
+// InsertOutboxEvent queues an event as pending delivery; it shares the caller's transaction with the entry it describes.
+func (store *Store) InsertOutboxEvent(ctx context.Context, event ledger.OutboxEvent) error {
+	if _, err := store.db.Exec(ctx, sqlInsertOutboxEvent, outboxEventArgs(event)...); err != nil {
+		return wrapStoreError(errorSubjectOutbox, errorCodeInsert, err)
+	}
+	return nil
+}
+
+// outboxEventArgs lists a new pending event's values in sqlInsertOutboxEvent order.
+func outboxEventArgs(event ledger.OutboxEvent) []any {
+	payload := event.PayloadJSON
+	if payload == "" {
+		payload = defaultMetadataJSON
+	}
+	return []any{uuid.NewString(), event.TenantID.String(), event.EventType, payload, outboxStatusPending, unixTime(event.CreatedUnixUTC)}
+}
+
+func (store *Store) AppendEntryChange(ctx context.Context, tenantID ledger.TenantID, entryID ledger.EntryID) error {
+	if _, err := store.db.Exec(ctx, sqlAppendEntryChange, tenantID.String(), entryID.String(), time.Now().UTC()); err != nil {
+		return wrapStoreError(errorSubjectEntryFeed, errorCodeSequence, err)
+	}
+	return nil
+}
+
+func (store *Store) ListEntryChanges(ctx context.Context, tenantID ledger.TenantID, afterCursor int64, limit int, filter ledger.EntryChangeFilter) ([]ledger.EntryChange, error) {
+	var args queryArgs
+	query := "SELECT c.sequence, a.user_id, a.ledger_id, " + entryColumns + " FROM entry_changes c" +
+		" JOIN ledger_entries e ON e.entry_id = c.entry_id" +
+		" JOIN accounts a ON a.account_id = e.account_id" +
+		" WHERE c.tenant_id = " + args.add(tenantID.String()) + " AND c.sequence > " + args.add(afterCursor)
+	if filter.LedgerID != nil {
+		query += " AND a.ledger_id = " + args.add(filter.LedgerID.String())
+	}
+	if filter.UserID != nil {
+		query += " AND a.user_id = " + args.add(filter.UserID.String())
+	}
+	if len(filter.Types) > 0 {
+		query += " AND e.type = ANY(" + args.add(entryTypeValues(filter.Types)) + ")"
+	}
+	query += " ORDER BY c.sequence ASC LIMIT " + args.add(limitValue(limit))
+
+	rows, err := store.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, wrapStoreError(errorSubjectEntryFeed, errorCodeList, err)
+	}
+	changes, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (ledger.EntryChange, error) {
+		var sequence int64
+		var rawUserID, rawLedgerID string
+		var entry entryRow
+		if err := row.Scan(append([]any{&sequence, &rawUserID, &rawLedgerID}, entry.scanTargets()...)...); err != nil {
+			return ledger.EntryChange{}, err
+		}
+		userID, err := ledger.NewUserID(rawUserID)
+		if err != nil {
+			return ledger.EntryChange{}, err
+		}
+		ledgerID, err := ledger.NewLedgerID(rawLedgerID)
+		if err != nil {
+			return ledger.EntryChange{}, err
+		}
+		mapped, err := mapEntry(entry)
+		if err != nil {
+			return ledger.EntryChange{}, err
+		}
+		return ledger.EntryChange{Cursor: sequence, UserID: userID, LedgerID: ledgerID, Entry: mapped}, nil
+	})
+	if err != nil {
+		return nil, wrapStoreError(errorSubjectEntryFeed, errorCodeInvalid, err)
+	}
+	return changes, nil
+}
+
+func (store *Store) CreateReservation(ctx context.Context, reservation ledger.Reservation) error {
+	_, err := store.db.Exec(ctx, sqlCreateReservation,
+		reservation.AccountID().String(),
+		reservation.ReservationID().String(),
+		reservation.AmountCents().Int64(),
+		reservation.Status().String(),
+		optionalTime(reservation.ExpiresAtUnixUTC()),
+		time.Now().UTC(),
+	)
+	if isUniqueViolation(err, constraintReservationKey) {
+		return wrapStoreError(errorSubjectReservation, errorCodeDuplicate, ledger.ErrReservationExists)
+	}
+	if err != nil {
+		return wrapStoreError(errorSubjectReservation, errorCodeCreate, err)
+	}
+	return nil
+}
+
+func (store *Store) GetReservation(ctx context.Context, accountID ledger.AccountID, reservationID ledger.ReservationID) (ledger.Reservation, error) {
+	var row reservationRow
+	err := store.db.QueryRow(ctx, sqlGetReservation, accountID.String(), reservationID.String()).
+		Scan(&row.AmountCents, &row.Status, &row.ExpiresAt, &row.CreatedAt, &row.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ledger.Reservation{}, wrapStoreError(errorSubjectReservation, errorCodeGet, ledger.ErrUnknownReservation)
+	}
+	if err != nil {
+		return ledger.Reservation{}, wrapStoreError(errorSubjectReservation, errorCodeGet, err)
+	}
+	row.ReservationID = reservationID.String()
+	reservation, err := mapReservation(accountID, row)
+	if err != nil {
+		return ledger.Reservation{}, wrapStoreError(errorSubjectReservation, errorCodeInvalid, err)
+	}
+	return reservation, nil
+}
+
+func (store *Store) UpdateReservationStatus(ctx context.Context, accountID ledger.AccountID, reservationID ledger.ReservationID, from, to ledger.ReservationStatus) error {
+	tag, err := store.db.Exec(ctx, sqlUpdateReservationStatus, accountID.String(), reservationID.String(), from.String(), to.String(), time.Now().UTC())
+	if err != nil {
+		return wrapStoreError(errorSubjectReservation, errorCodeUpdateStatus, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return wrapStoreError(errorSubjectReservation, errorCodeUpdateStatus, ledger.ErrReservationClosed)
+	}
+	return nil
+}
+
+func (store *Store) ListReservations(ctx context.Context, accountID ledger.AccountID, beforeCreatedUnixUTC int64, limit int, filter ledger.ListReservationsFilter) ([]ledger.Reservation, error) {
+	var args queryArgs
+	query := "SELECT reservation_id, amount_cents, status, expires_at, created_at, updated_at FROM reservations" +
+		" WHERE account_id = " + args.add(accountID.String()) + " AND created_at < " + args.add(beforeOrNow(beforeCreatedUnixUTC))
+	if len(filter.Statuses) > 0 {
+		statuses := make([]string, 0, len(filter.Statuses))
+		for _, status := range filter.Statuses {
+			statuses = append(statuses, status.String())
+		}
+		query += " AND status = ANY(" + args.add(statuses) + ")"
+	}
+	query += " ORDER BY created_at DESC LIMIT " + args.add(limitValue(limit))
+
+	rows, err := store.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, wrapStoreError(errorSubjectReservation, errorCodeList, err)
+	}
+	reservations, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (ledger.Reservation, error) {
+		var record reservationRow
+		if err := row.Scan(&record.ReservationID, &record.AmountCents, &record.Status, &record.ExpiresAt, &record.CreatedAt, &record.UpdatedAt); err != nil {
+			return ledger.Reservation{}, err
+		}
+		return mapReservation(accountID, record)
+	})
+	if err != nil {
+		return nil, wrapStoreError(errorSubjectReservation, errorCodeInvalid, err)
+	}
+	return reservations, nil
+}
+
+func (store *Store) ListEntries(ctx context.Context, accountID ledger.AccountID, beforeUnixUTC int64, limit int, filter ledger.ListEntriesFilter) ([]ledger.Entry, error) {
+	var args queryArgs
+	query := "SELECT " + entryColumns + " FROM ledger_entries e" + " WHERE e.account_id = " + args.add(accountID.String())
+	if filter.BeforeEntryID != nil {
+		query += " AND e.created_at < " + args.add(unixTime(beforeUnixUTC+1)) +
+			" AND (e.created_at < " + args.add(unixTime(beforeUnixUTC)) + " OR e.entry_id < " + args.add(filter.BeforeEntryID.String()) + "::uuid)"
+	} else {
+		query += " AND e.created_at < " + args.add(beforeOrNow(beforeUnixUTC))
+	}
+	if len(filter.Types) > 0 {
+		query += " AND e.type = ANY(" + args.add(entryTypeValues(filter.Types)) + ")"
+	}
+	if filter.ReservationID != nil {
+		query += " AND e.reservation_id = " + args.add(filter.ReservationID.String())
+	}
+	if filter.EffectiveAfterUnixUTC != 0 {
+		query += " AND e.effective_at > " + args.add(unixTime(filter.EffectiveAfterUnixUTC))
+	}
+	if filter.IdempotencyKeyPrefix != nil {
+		query += " AND e.idempotency_key LIKE " + args.add(likePrefixPattern(filter.IdempotencyKeyPrefix.String())) + ` ESCAPE '\'`
+	}
+	for _, actorFilter := range []struct {
+		column string
+		value  string
+	}{
+		{column: "actor_api_key_id", value: filter.Actor.APIKeyID()},
+		{column: "actor_service", value: filter.Actor.Service()},
+		{column: "actor_request_id", value: filter.Actor.RequestID()},
+		{column: "actor_client_address", value: filter.Actor.ClientAddress()},
+	} {
+		if actorFilter.value != "" {
+			query += " AND e." + actorFilter.column + " = " + args.add(actorFilter.value)
+		}
+	}
+	query += " ORDER BY e.created_at DESC, e.entry_id DESC LIMIT " + args.add(limitValue(limit))
+
+	rows, err := store.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, wrapStoreError(errorSubjectEntry, errorCodeList, err)
+	}
+	entries, err := collectEntries(rows)
+	if err != nil {
+		return nil, wrapStoreError(errorSubjectEntry, errorCodeInvalid, err)
+	}
+	return entries, nil
+}
+
+func wrapStoreError(subject string, code string, err error) error {
+	return ledger.WrapError(errorOperationStore, subject, code, err)
+}
+
+// queryArgs collects positional arguments while a query with optional filters is assembled.
+type queryArgs []any
+
+func (args *queryArgs) add(value any) string {
+	*args = append(*args, value)
+	return "$" + strconv.Itoa(len(*args))
+}
+
+type entryRow struct {
+	EntryID            string
+	AccountID          string
+	Type               string
+	AmountCents        int64
+	ReservationID      *string
+	RefundOfEntryID    *string
+	IdempotencyKey     string
+	ExpiresAt          *time.Time
+	EffectiveAt        *time.Time
+	Metadata           string
+	CreatedAt          time.Time
+	ActorAPIKeyID      string
+	ActorService       string
+	ActorRequestID     string
+	ActorClientAddress string
+	ConsumedCents      int64
+}
+
+// scanTargets lists the row's fields in entryColumns order.
+func (row *entryRow) scanTargets() []any {
+	return []any{
+		&row.EntryID, &row.AccountID, &row.Type, &row.AmountCents, &row.ReservationID, &row.RefundOfEntryID,
+		&row.IdempotencyKey, &row.ExpiresAt, &row.EffectiveAt, &row.Metadata, &row.CreatedAt,
+		&row.ActorAPIKeyID, &row.ActorService, &row.ActorRequestID, &row.ActorClientAddress, &row.ConsumedCents,
+	}
+}
+
+// newEntryRow assigns the entry an ID and, unless the input carries one, the current second as its creation time.
+func newEntryRow(entryInput ledger.EntryInput) entryRow {
+	row := entryRow{
+		EntryID:            uuid.NewString(),
+		AccountID:          entryInput.AccountID().String(),
+		Type:               entryInput.Type().String(),
+		AmountCents:        entryInput.AmountCents().Int64(),
+		IdempotencyKey:     entryInput.IdempotencyKey().String(),
+		ExpiresAt:          optionalTime(entryInput.ExpiresAtUnixUTC()),
+		EffectiveAt:        optionalTime(entryInput.EffectiveAtUnixUTC()),
+		Metadata:           entryInput.MetadataJSON().String(),
+		CreatedAt:          time.Now().UTC().Truncate(time.Second),
+		ActorAPIKeyID:      entryInput.Actor().APIKeyID(),
+		ActorService:       entryInput.Actor().Service(),
+		ActorRequestID:     entryInput.Actor().RequestID(),
+		ActorClientAddress: entryInput.Actor().ClientAddress(),
+	}
+	if value, ok := entryInput.ReservationID(); ok {
+		raw := value.String()
+		row.ReservationID = &raw
+	}
+	if value, ok := entryInput.RefundOfEntryID(); ok {
+		raw := value.String()
+		row.RefundOfEntryID = &raw
+	}
+	if entryInput.CreatedUnixUTC() != 0 {
+		row.CreatedAt = unixTime(entryInput.CreatedUnixUTC())
+	}
+	if row.Metadata == "" {
+		row.Metadata = defaultMetadataJSON
+	}
+	return row
+}
+
+// insertArgs lists the row's fields in sqlInsertEntry order.
+func (row *entryRow) insertArgs() []any {
+	return []any{
+		row.EntryID, row.AccountID, row.Type, row.AmountCents, row.ReservationID, row.RefundOfEntryID,
+		row.IdempotencyKey, row.ExpiresAt, row.EffectiveAt, row.Metadata, row.CreatedAt,
+		row.ActorAPIKeyID, row.ActorService, row.ActorRequestID, row.ActorClientAddress,
+	}
+}
+
+// insertedEntryError maps the outcome of sqlInsertEntry to the store's errors.
+func insertedEntryError(tag pgconn.CommandTag, err error) error {
+	if isIdempotencyConflict(err) {
+		return wrapStoreError(errorSubjectEntry, errorCodeDuplicate, ledger.ErrDuplicateIdempotencyKey)
+	}
+	if err != nil {
+		return wrapStoreError(errorSubjectEntry, errorCodeInsert, err)
+	}
+	if tag.RowsAffected() == 0 {
+		// The insert skips keys of entries compaction archived.
+		return wrapStoreError(errorSubjectEntry, errorCodeDuplicate, ledger.ErrDuplicateIdempotencyKey)
+	}
+	return nil
+}
+
+type reservationRow struct {
+	ReservationID string
+	AmountCents   int64
+	Status        string
+	ExpiresAt     *time.Time
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+func collectEntries(rows pgx.Rows) ([]ledger.Entry, error) {
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (ledger.Entry, error) {
+		var record entryRow
+		if err := row.Scan(record.scanTargets()...); err != nil {
+			return ledger.Entry{}, err
+		}
+		return mapEntry(record)
+	})
+}
+
+func mapAccount(rawAccountID string, rawTenantID string, rawUserID string, rawLedgerID string, createdAt time.Time) (ledger.Account, error) {
+	accountID, err := ledger.NewAccountID(rawAccountID)
+	if err != nil {
+		return ledger.Account{}, err
+	}
+	tenantID, err := ledger.NewTenantID(rawTenantID)
+	if err != nil {
+		return ledger.Account{}, err
+	}
+	userID, err := ledger.NewUserID(rawUserID)
+	if err != nil {
+		return ledger.Account{}, err
+	}
+	ledgerID, err := ledger.NewLedgerID(rawLedgerID)
+	if err != nil {
+		return ledger.Account{}, err
+	}
+	return ledger.NewAccount(accountID, tenantID, userID, ledgerID, createdAt.UTC().Unix())
+}
+
+func mapEntry(row entryRow) (ledger.Entry, error) {
+	entryID, err := ledger.NewEntryID(row.EntryID)
+	if err != nil {
+		return ledger.Entry{}, err
+	}
+	accountID, err := ledger.NewAccountID(row.AccountID)
+	if err != nil {
+		return ledger.Entry{}, err
+	}
+	entryType, err := ledger.ParseEntryType(row.Type)
+	if err != nil {
+		return ledger.Entry{}, err
+	}
+	amountCents, err := ledger.NewEntryAmountCents(row.AmountCents)
+	if err != nil {
+		return ledger.Entry{}, err
+	}
+	var reservationID *ledger.ReservationID
+	if row.ReservationID != nil {
+		parsedReservationID, err := ledger.NewReservationID(*row.ReservationID)
+		if err != nil {
+			return ledger.Entry{}, err
+		}
+		reservationID = &parsedReservationID
+	}
+	var refundOfEntryID *ledger.EntryID
+	if row.RefundOfEntryID != nil {
+		parsedRefundOfEntryID, err := ledger.NewEntryID(*row.RefundOfEntryID)
+		if err != nil {
+			return ledger.Entry{}, err
+		}
+		refundOfEntryID = &parsedRefundOfEntryID
+	}
+	idempotencyKey, err := ledger.NewIdempotencyKey(row.IdempotencyKey)
+	if err != nil {
+		return ledger.Entry{}, err
+	}
+	metadata, err := ledger.NewMetadataJSON(row.Metadata)
+	if err != nil {
+		return ledger.Entry{}, err
+	}
+	entry, err := ledger.NewEntry(
+		entryID,
+		accountID,
+		entryType,
+		amountCents,
+		reservationID,
+		refundOfEntryID,
+		idempotencyKey,
+		timeOrZero(row.ExpiresAt),
+		metadata,
+		row.CreatedAt.Unix(),
+	)
+	if err != nil {
+		return ledger.Entry{}, err
+	}
+	actor, err := ledger.NewActor(row.ActorAPIKeyID, row.ActorService, row.ActorRequestID, row.ActorClientAddress)
+	if err != nil {
+		return ledger.Entry{}, err
+	}
+	return entry.WithEffectiveAtUnixUTC(timeOrZero(row.EffectiveAt)).WithActor(actor).WithConsumedCents(ledger.AmountCents(row.ConsumedCents)), nil
+}
+
+func mapReservation(accountID ledger.AccountID, row reservationRow) (ledger.Reservation, error) {
+	reservationID, err := ledger.NewReservationID(row.ReservationID)
+	if err != nil {
+		return ledger.Reservation{}, err
+	}
+	amountCents, err := ledger.NewPositiveAmountCents(row.AmountCents)
+	if err != nil {
+		return ledger.Reservation{}, err
+	}
+	status, err := ledger.ParseReservationStatus(row.Status)
+	if err != nil {
+		return ledger.Reservation{}, err
+	}
+	return ledger.NewReservationWithTimestamps(
+		accountID,
+		reservationID,
+		amountCents,
+		status,
+		timeOrZero(row.ExpiresAt),
+		row.CreatedAt.UTC().Unix(),
+		row.UpdatedAt.UTC().Unix(),
+	)
+}
+
+func entryTypeValues(types []ledger.EntryType) []string {
+	values := make([]string, 0, len(types))
+	for _, entryType := range types {
+		values = append(values, entryType.String())
+	}
+	return values
+}
+
+func unixTime(unixUTC int64) time.Time {
+	return time.Unix(unixUTC, 0).UTC()
+}
+
+// optionalTime maps the domain's zero timestamp to NULL.
+func optionalTime(unixUTC int64) *time.Time {
+	if unixUTC == 0 {
+		return nil
+	}
+	value := unixTime(unixUTC)
+	return &value
+}
+
+func timeOrZero(value *time.Time) int64 {
+	if value == nil {
+		return 0
+	}
+	return value.Unix()
+}
+
+// beforeOrNow treats a zero cursor as "everything created so far".
+func beforeOrNow(beforeUnixUTC int64) time.Time {
+	if beforeUnixUTC == 0 {
+		return time.Now().UTC().Add(time.Second)
+	}
+	return unixTime(beforeUnixUTC)
+}
+
+// likeEscaper escapes the LIKE wildcards and the escape character itself, for patterns used with ESCAPE '\'.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// likePrefixPattern matches values that start with prefix taken literally.
+func likePrefixPattern(prefix string) string {
+	return likeEscaper.Replace(prefix) + "%"
+}
+
+// limitValue maps a negative limit to LIMIT NULL, which Postgres reads as no limit.
+func limitValue(limit int) any {
+	if limit < 0 {
+		return nil
+	}
+	return limit
+}
+
+// isUniqueViolation reports whether err is a unique violation of the named constraint. Violations of any
+// other constraint are integrity errors, not conflicts the caller can treat as a replay.
+func isUniqueViolation(err error, constraintName string) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == pgUniqueViolationCode && pgErr.ConstraintName == constraintName
+}
+
+// isIdempotencyConflict reports whether an entry insert hit its account's idempotency key, either in the
+// ledger_entries index or, on month-partitioned tables, in the ledger_entry_idempotency claim table.
+func isIdempotencyConflict(err error) bool {
+	return isUniqueViolation(err, constraintEntryIdempotency)
+}
@@ -0,0 +1,191 @@
+package ledger
+
+import (
+	"context"
+	"fmt"
+)
+
+const (
+	errorPolicyNegative        = "limits must be zero or greater"
+	errorPolicyDefaultTTL      = "default reservation ttl exceeds max reservation ttl"
+	errorPolicyDuplicateLedger = "duplicate ledger policy"
+	errorPolicyUnknownLedger   = "ledger is not configured for tenant"
+)
+
+// LedgerPolicySettings configures a LedgerPolicy. Zero TTLs and amounts are not enforced.
+type LedgerPolicySettings struct {
+	// DefaultReservationTTLSeconds is applied to reservations created without an expiry.
+	DefaultReservationTTLSeconds int64
+	// MaxReservationTTLSeconds bounds how far in the future a reservation may expire.
+	MaxReservationTTLSeconds int64
+	// MaxGrantAmountCents bounds a single grant.
+	MaxGrantAmountCents int64
+	// MaxSpendAmountCents bounds a single spend or reservation.
+	MaxSpendAmountCents int64
+	// DenyExpiringGrants rejects grants with an expiry.
+	DenyExpiringGrants bool
+}
+
+// LedgerPolicy is the validated policy for one ledger of a tenant.
+type LedgerPolicy struct {
+	ledgerID LedgerID
+	settings LedgerPolicySettings
+}
+
+// NewLedgerPolicy validates the settings for ledgerID.
+func NewLedgerPolicy(ledgerID LedgerID, settings LedgerPolicySettings) (LedgerPolicy, error) {
+	if settings.DefaultReservationTTLSeconds < 0 || settings.MaxReservationTTLSeconds < 0 ||
+		settings.MaxGrantAmountCents < 0 || settings.MaxSpendAmountCents < 0 {
+		return LedgerPolicy{}, fmt.Errorf("%w: %s", ErrInvalidPolicy, errorPolicyNegative)
+	}
+	if settings.MaxReservationTTLSeconds > 0 && settings.DefaultReservationTTLSeconds > settings.MaxReservationTTLSeconds {
+		return LedgerPolicy{}, fmt.Errorf("%w: %s", ErrInvalidPolicy, errorPolicyDefaultTTL)
+	}
+	return LedgerPolicy{ledgerID: ledgerID, settings: settings}, nil
+}
+
+// LedgerID returns the ledger the policy applies to.
+func (policy LedgerPolicy) LedgerID() LedgerID {
+	return policy.ledgerID
+}
+
+// Settings returns the policy settings.
+func (policy LedgerPolicy) Settings() LedgerPolicySettings {
+	return policy.settings
+}
+
+// TenantPolicy groups the ledger policies of one tenant.
+// When RejectUnknownLedgers is set, only ledgers with a policy are accepted.
+type TenantPolicy struct {
+	tenantID             TenantID
+	rejectUnknownLedgers bool
+	ledgers              map[LedgerID]LedgerPolicy
+}
+
+// NewTenantPolicy validates that every ledger has at most one policy.
+func NewTenantPolicy(tenantID TenantID, rejectUnknownLedgers bool, ledgerPolicies ...LedgerPolicy) (TenantPolicy, error) {
+	ledgers := make(map[LedgerID]LedgerPolicy, len(ledgerPolicies))
+	for _, ledgerPolicy := range ledgerPolicies {
+		if _, exists := ledgers[ledgerPolicy.ledgerID]; exists {
+			return TenantPolicy{}, fmt.Errorf("%w: %s %q", ErrInvalidPolicy, errorPolicyDuplicateLedger, ledgerPolicy.ledgerID.String())
+		}
+		ledgers[ledgerPolicy.ledgerID] = ledgerPolicy
+	}
+	return TenantPolicy{tenantID: tenantID, rejectUnknownLedgers: rejectUnknownLedgers, ledgers: ledgers}, nil
+}
+
+// TenantID returns the tenant the policy applies to.
+func (policy TenantPolicy) TenantID() TenantID {
+	return policy.tenantID
+}
+
+// RejectUnknownLedgers reports whether ledgers without a policy are rejected.
+func (policy TenantPolicy) RejectUnknownLedgers() bool {
+	return policy.rejectUnknownLedgers
+}
+
+// LedgerPolicy returns the policy configured for ledgerID.
+func (policy TenantPolicy) LedgerPolicy(ledgerID LedgerID) (LedgerPolicy, bool) {
+	ledgerPolicy, ok := policy.ledgers[ledgerID]
+	return ledgerPolicy, ok
+}
+
+// WithTenantPolicies enforces tenant ledger policies. A later policy for the same tenant replaces an earlier one.
+func WithTenantPolicies(policies ...TenantPolicy) ServiceOption {
+	return func(service *Service) {
+		if service.tenantPolicies == nil {
+			service.tenantPolicies = make(map[TenantID]TenantPolicy, len(policies))
+		}
+		for _, policy := range policies {
+			service.tenantPolicies[policy.tenantID] = policy
+		}
+	}
+}
+
+// resolveAccountID rejects ledgers the tenant policy does not allow before resolving the account.
+func (service *Service) resolveAccountID(ctx context.Context, store Store, tenantID TenantID, userID UserID, ledgerID LedgerID) (AccountID, error) {
+	if err := service.checkLedgerAllowed(tenantID, ledgerID); err != nil {
+		return AccountID{}, err
+	}
+	accountID, err := store.GetOrCreateAccountID(ctx, tenantID, userID, ledgerID)
+	if err != nil {
+		return AccountID{}, err
+	}
+	if err := service.watchAccount(ctx, store, tenantID, userID, ledgerID, accountID); err != nil {
+		return AccountID{}, err
+	}
+	return accountID, nil
+}
+
+func (service *Service) checkLedgerAllowed(tenantID TenantID, ledgerID LedgerID) error {
+	tenantPolicy, ok := service.tenantPolicies[tenantID]
+	if !ok || !tenantPolicy.rejectUnknownLedgers {
+		return nil
+	}
+	if _, ok := tenantPolicy.ledgers[ledgerID]; !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownLedger, errorPolicyUnknownLedger)
+	}
+	return nil
+}
+
+func (service *Service) ledgerPolicySettings(tenantID TenantID, ledgerID LedgerID) (LedgerPolicySettings, bool) {
+	tenantPolicy, ok := service.tenantPolicies[tenantID]
+	if !ok {
+		return LedgerPolicySettings{}, false
+	}
+	ledgerPolicy, ok := tenantPolicy.ledgers[ledgerID]
+	if !ok {
+		return LedgerPolicySettings{}, false
+	}
+	return ledgerPolicy.settings, true
+}
+
+// checkGrantPolicy rejects grants above the ledger maximum and expiring grants where they are not allowed.
+func (service *Service) checkGrantPolicy(tenantID TenantID, ledgerID LedgerID, amount int64, expiresAtUnixUTC int64) error {
+	settings, ok := service.ledgerPolicySettings(tenantID, ledgerID)
+	if !ok {
+		return nil
+	}
+	if settings.MaxGrantAmountCents > 0 && amount > settings.MaxGrantAmountCents {
+		return fmt.Errorf("%w: grant above %d cents", ErrAmountLimitExceeded, settings.MaxGrantAmountCents)
+	}
+	if expiresAtUnixUTC != 0 && settings.DenyExpiringGrants {
+		return ErrExpiringGrantNotAllowed
+	}
+	return nil
+}
+
+// checkSpendPolicy rejects a single spend or reservation above the ledger maximum.
+func (service *Service) checkSpendPolicy(tenantID TenantID, ledgerID LedgerID, amount int64) error {
+	settings, ok := service.ledgerPolicySettings(tenantID, ledgerID)
+	if !ok {
+		return nil
+	}
+	if settings.MaxSpendAmountCents > 0 && amount > settings.MaxSpendAmountCents {
+		return fmt.Errorf("%w: spend above %d cents", ErrAmountLimitExceeded, settings.MaxSpendAmountCents)
+	}
+	return nil
+}
+
+// reservationExpiry applies the ledger default reservation TTL and rejects expiries beyond the maximum.
+// Without a default, a reservation on a ledger with a maximum TTL expires at the maximum.
+func (service *Service) reservationExpiry(tenantID TenantID, ledgerID LedgerID, expiresAtUnixUTC int64, nowUnixUTC int64) (int64, error) {
+	settings, ok := service.ledgerPolicySettings(tenantID, ledgerID)
+	if !ok {
+		return expiresAtUnixUTC, nil
+	}
+	if expiresAtUnixUTC == 0 {
+		switch {
+		case settings.DefaultReservationTTLSeconds > 0:
+			return nowUnixUTC + settings.DefaultReservationTTLSeconds, nil
+		case settings.MaxReservationTTLSeconds > 0:
+			return nowUnixUTC + settings.MaxReservationTTLSeconds, nil
+		default:
+			return 0, nil
+		}
+	}
+	if settings.MaxReservationTTLSeconds > 0 && expiresAtUnixUTC-nowUnixUTC > settings.MaxReservationTTLSeconds {
+		return 0, fmt.Errorf("%w: reservation ttl above %ds", ErrReservationTTLExceeded, settings.MaxReservationTTLSeconds)
+	}
+	return expiresAtUnixUTC, nil
+}
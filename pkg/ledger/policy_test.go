@@ -0,0 +1,222 @@
+package ledger
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestNewLedgerPolicyValidation(test *testing.T) {
+	test.Parallel()
+	ledgerID := mustLedgerID(test, defaultLedgerIDValue)
+	testCases := []struct {
+		name     string
+		settings LedgerPolicySettings
+		wantErr  bool
+	}{
+		{name: "empty", settings: LedgerPolicySettings{}},
+		{name: "default within max", settings: LedgerPolicySettings{DefaultReservationTTLSeconds: 60, MaxReservationTTLSeconds: 3600}},
+		{name: "default above max", settings: LedgerPolicySettings{DefaultReservationTTLSeconds: 7200, MaxReservationTTLSeconds: 3600}, wantErr: true},
+		{name: "negative ttl", settings: LedgerPolicySettings{MaxReservationTTLSeconds: -1}, wantErr: true},
+		{name: "negative amount", settings: LedgerPolicySettings{MaxSpendAmountCents: -1}, wantErr: true},
+	}
+	for _, testCase := range testCases {
+		testCase := testCase
+		test.Run(testCase.name, func(test *testing.T) {
+			test.Parallel()
+			policy, err := NewLedgerPolicy(ledgerID, testCase.settings)
+			if testCase.wantErr {
+				if !errors.Is(err, ErrInvalidPolicy) {
+					test.Fatalf("expected invalid policy, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				test.Fatalf("new ledger policy: %v", err)
+			}
+			if policy.LedgerID() != ledgerID || policy.Settings() != testCase.settings {
+				test.Fatalf("unexpected policy accessors: %+v", policy)
+			}
+		})
+	}
+}
+
+func TestNewTenantPolicyRejectsDuplicateLedgers(test *testing.T) {
+	test.Parallel()
+	ledgerPolicy := mustLedgerPolicy(test, defaultLedgerIDValue, LedgerPolicySettings{})
+	if _, err := NewTenantPolicy(mustTenantID(test, defaultTenantIDValue), false, ledgerPolicy, ledgerPolicy); !errors.Is(err, ErrInvalidPolicy) {
+		test.Fatalf("expected invalid policy, got %v", err)
+	}
+	policy, err := NewTenantPolicy(mustTenantID(test, defaultTenantIDValue), true, ledgerPolicy)
+	if err != nil {
+		test.Fatalf("new tenant policy: %v", err)
+	}
+	if !policy.RejectUnknownLedgers() || policy.TenantID().String() != defaultTenantIDValue {
+		test.Fatalf("unexpected tenant policy: %+v", policy)
+	}
+	if _, ok := policy.LedgerPolicy(mustLedgerID(test, defaultLedgerIDValue)); !ok {
+		test.Fatalf("expected ledger policy lookup to succeed")
+	}
+}
+
+func TestPolicyRejectsUnknownLedgers(test *testing.T) {
+	test.Parallel()
+	store := newStubStore(test, mustSignedAmount(test, 1000))
+	service := mustNewPolicyService(test, store, true, mustLedgerPolicy(test, defaultLedgerIDValue, LedgerPolicySettings{}))
+	ctx := context.Background()
+	tenantID := mustTenantID(test, defaultTenantIDValue)
+	userID := mustUserID(test, "user-1")
+	unknownLedgerID := mustLedgerID(test, "unknown")
+
+	if _, err := service.Balance(ctx, tenantID, userID, unknownLedgerID); !errors.Is(err, ErrUnknownLedger) {
+		test.Fatalf("expected unknown ledger on balance, got %v", err)
+	}
+	if err := service.Grant(ctx, tenantID, userID, unknownLedgerID, mustPositiveAmount(test, 10), mustIdempotencyKey(test, "grant-unknown"), 0, mustMetadata(test, "{}")); !errors.Is(err, ErrUnknownLedger) {
+		test.Fatalf("expected unknown ledger on grant, got %v", err)
+	}
+	if _, err := service.SpendAcrossLedgers(ctx, tenantID, userID, []LedgerID{mustLedgerID(test, defaultLedgerIDValue), unknownLedgerID}, mustPositiveAmount(test, 10), mustIdempotencyKey(test, "spend-multi"), mustMetadata(test, "{}")); !errors.Is(err, ErrUnknownLedger) {
+		test.Fatalf("expected unknown ledger on multi-ledger spend, got %v", err)
+	}
+	if _, err := service.Balance(ctx, tenantID, userID, mustLedgerID(test, defaultLedgerIDValue)); err != nil {
+		test.Fatalf("expected configured ledger to be accepted, got %v", err)
+	}
+	if _, err := service.Balance(ctx, mustTenantID(test, "other-tenant"), userID, unknownLedgerID); err != nil {
+		test.Fatalf("expected tenants without policy to be unrestricted, got %v", err)
+	}
+}
+
+func TestPolicyEnforcesAmountLimitsAndExpiringGrants(test *testing.T) {
+	test.Parallel()
+	store := newStubStore(test, mustSignedAmount(test, 10000))
+	service := mustNewPolicyService(test, store, false, mustLedgerPolicy(test, defaultLedgerIDValue, LedgerPolicySettings{
+		MaxGrantAmountCents: 500,
+		MaxSpendAmountCents: 300,
+		DenyExpiringGrants:  true,
+	}))
+	ctx := context.Background()
+	tenantID := mustTenantID(test, defaultTenantIDValue)
+	userID := mustUserID(test, "user-1")
+	ledgerID := mustLedgerID(test, defaultLedgerIDValue)
+
+	if err := service.Grant(ctx, tenantID, userID, ledgerID, mustPositiveAmount(test, 501), mustIdempotencyKey(test, "grant-big"), 0, mustMetadata(test, "{}")); !errors.Is(err, ErrAmountLimitExceeded) {
+		test.Fatalf("expected amount limit on grant, got %v", err)
+	}
+	if err := service.Grant(ctx, tenantID, userID, ledgerID, mustPositiveAmount(test, 100), mustIdempotencyKey(test, "grant-expiring"), 500, mustMetadata(test, "{}")); !errors.Is(err, ErrExpiringGrantNotAllowed) {
+		test.Fatalf("expected expiring grant rejection, got %v", err)
+	}
+	if err := service.Spend(ctx, tenantID, userID, ledgerID, mustPositiveAmount(test, 301), mustIdempotencyKey(test, "spend-big"), mustMetadata(test, "{}")); !errors.Is(err, ErrAmountLimitExceeded) {
+		test.Fatalf("expected amount limit on spend, got %v", err)
+	}
+	if err := service.Reserve(ctx, tenantID, userID, ledgerID, mustPositiveAmount(test, 301), mustReservationID(test, "order-big"), mustIdempotencyKey(test, "reserve-big"), 0, mustMetadata(test, "{}")); !errors.Is(err, ErrAmountLimitExceeded) {
+		test.Fatalf("expected amount limit on reserve, got %v", err)
+	}
+	if err := service.Spend(ctx, tenantID, userID, ledgerID, mustPositiveAmount(test, 300), mustIdempotencyKey(test, "spend-ok"), mustMetadata(test, "{}")); err != nil {
+		test.Fatalf("spend within limit: %v", err)
+	}
+	unbounded, err := service.ReserveEntry(ctx, tenantID, userID, ledgerID, mustPositiveAmount(test, 100), mustReservationID(test, "order-ok"), mustIdempotencyKey(test, "reserve-ok"), 0, mustMetadata(test, "{}"))
+	if err != nil {
+		test.Fatalf("reserve within limit: %v", err)
+	}
+	if unbounded.ExpiresAtUnixUTC() != 0 {
+		test.Fatalf("expected a policy without reservation ttls to leave the reservation open, got %d", unbounded.ExpiresAtUnixUTC())
+	}
+
+	operations := []BatchOperation{
+		{OperationID: "grant-over", Grant: &BatchGrantOperation{Amount: mustPositiveAmount(test, 600), IdempotencyKey: mustIdempotencyKey(test, "batch-grant"), Metadata: mustMetadata(test, "{}")}},
+		{OperationID: "spend-over", Spend: &BatchSpendOperation{Amount: mustPositiveAmount(test, 400), IdempotencyKey: mustIdempotencyKey(test, "batch-spend"), Metadata: mustMetadata(test, "{}")}},
+		{OperationID: "spend-ok", Spend: &BatchSpendOperation{Amount: mustPositiveAmount(test, 200), IdempotencyKey: mustIdempotencyKey(test, "batch-spend-ok"), Metadata: mustMetadata(test, "{}")}},
+		{OperationID: "reserve-over", Reserve: &BatchReserveOperation{Amount: mustPositiveAmount(test, 400), ReservationID: mustReservationID(test, "batch-order"), IdempotencyKey: mustIdempotencyKey(test, "batch-reserve"), Metadata: mustMetadata(test, "{}")}},
+	}
+	results, err := service.Batch(ctx, tenantID, userID, ledgerID, operations, false)
+	if err != nil {
+		test.Fatalf("batch: %v", err)
+	}
+	if !errors.Is(results[0].Error, ErrAmountLimitExceeded) || !errors.Is(results[1].Error, ErrAmountLimitExceeded) || results[2].Error != nil || !errors.Is(results[3].Error, ErrAmountLimitExceeded) {
+		test.Fatalf("unexpected batch results: %+v", results)
+	}
+}
+
+func TestPolicyWithOnlyAmountLimitsAllowsExpiringGrants(test *testing.T) {
+	test.Parallel()
+	store := newStubStore(test, mustSignedAmount(test, 0))
+	service := mustNewPolicyService(test, store, false, mustLedgerPolicy(test, defaultLedgerIDValue, LedgerPolicySettings{MaxGrantAmountCents: 500}))
+	ctx := context.Background()
+
+	if err := service.Grant(ctx, mustTenantID(test, defaultTenantIDValue), mustUserID(test, "user-1"), mustLedgerID(test, defaultLedgerIDValue), mustPositiveAmount(test, 100), mustIdempotencyKey(test, "grant-expiring"), 500, mustMetadata(test, "{}")); err != nil {
+		test.Fatalf("expected an expiring grant under a policy that only caps amounts, got %v", err)
+	}
+}
+
+func TestPolicyAppliesReservationTTL(test *testing.T) {
+	test.Parallel()
+	store := newStubStore(test, mustSignedAmount(test, 10000))
+	service := mustNewPolicyService(test, store, false,
+		mustLedgerPolicy(test, defaultLedgerIDValue, LedgerPolicySettings{DefaultReservationTTLSeconds: 60, MaxReservationTTLSeconds: 600}),
+		mustLedgerPolicy(test, "capped", LedgerPolicySettings{MaxReservationTTLSeconds: 300}),
+	)
+	ctx := context.Background()
+	tenantID := mustTenantID(test, defaultTenantIDValue)
+	userID := mustUserID(test, "user-1")
+	ledgerID := mustLedgerID(test, defaultLedgerIDValue)
+
+	defaulted, err := service.ReserveEntry(ctx, tenantID, userID, ledgerID, mustPositiveAmount(test, 10), mustReservationID(test, "order-1"), mustIdempotencyKey(test, "reserve-1"), 0, mustMetadata(test, "{}"))
+	if err != nil {
+		test.Fatalf("reserve with default ttl: %v", err)
+	}
+	if defaulted.ExpiresAtUnixUTC() != 160 {
+		test.Fatalf("expected default expiry 160, got %d", defaulted.ExpiresAtUnixUTC())
+	}
+	explicit, err := service.ReserveEntry(ctx, tenantID, userID, ledgerID, mustPositiveAmount(test, 10), mustReservationID(test, "order-2"), mustIdempotencyKey(test, "reserve-2"), 700, mustMetadata(test, "{}"))
+	if err != nil {
+		test.Fatalf("reserve with explicit ttl: %v", err)
+	}
+	if explicit.ExpiresAtUnixUTC() != 700 {
+		test.Fatalf("expected explicit expiry 700, got %d", explicit.ExpiresAtUnixUTC())
+	}
+	if _, err := service.ReserveEntry(ctx, tenantID, userID, ledgerID, mustPositiveAmount(test, 10), mustReservationID(test, "order-3"), mustIdempotencyKey(test, "reserve-3"), 701, mustMetadata(test, "{}")); !errors.Is(err, ErrReservationTTLExceeded) {
+		test.Fatalf("expected reservation ttl exceeded, got %v", err)
+	}
+	capped, err := service.ReserveEntry(ctx, tenantID, userID, mustLedgerID(test, "capped"), mustPositiveAmount(test, 10), mustReservationID(test, "order-4"), mustIdempotencyKey(test, "reserve-4"), 0, mustMetadata(test, "{}"))
+	if err != nil {
+		test.Fatalf("reserve on capped ledger: %v", err)
+	}
+	if capped.ExpiresAtUnixUTC() != 400 {
+		test.Fatalf("expected max expiry 400, got %d", capped.ExpiresAtUnixUTC())
+	}
+
+	results, err := service.Batch(ctx, tenantID, userID, ledgerID, []BatchOperation{
+		{OperationID: "reserve-default", Reserve: &BatchReserveOperation{Amount: mustPositiveAmount(test, 10), ReservationID: mustReservationID(test, "batch-order-1"), IdempotencyKey: mustIdempotencyKey(test, "batch-reserve-1"), Metadata: mustMetadata(test, "{}")}},
+		{OperationID: "reserve-too-long", Reserve: &BatchReserveOperation{Amount: mustPositiveAmount(test, 10), ReservationID: mustReservationID(test, "batch-order-2"), IdempotencyKey: mustIdempotencyKey(test, "batch-reserve-2"), ExpiresAtUnixUTC: 1000, Metadata: mustMetadata(test, "{}")}},
+	}, false)
+	if err != nil {
+		test.Fatalf("batch: %v", err)
+	}
+	if results[0].Entry == nil || results[0].Entry.ExpiresAtUnixUTC() != 160 {
+		test.Fatalf("expected batch reservation to use default ttl, got %+v", results[0])
+	}
+	if !errors.Is(results[1].Error, ErrReservationTTLExceeded) {
+		test.Fatalf("expected batch reservation ttl exceeded, got %v", results[1].Error)
+	}
+}
+
+func mustNewPolicyService(test *testing.T, store Store, rejectUnknownLedgers bool, ledgerPolicies ...LedgerPolicy) *Service {
+	test.Helper()
+	policy, err := NewTenantPolicy(mustTenantID(test, defaultTenantIDValue), rejectUnknownLedgers, ledgerPolicies...)
+	if err != nil {
+		test.Fatalf("tenant policy: %v", err)
+	}
+	service, err := NewService(store, func() int64 { return 100 }, WithTenantPolicies(policy))
+	if err != nil {
+		test.Fatalf("new service: %v", err)
+	}
+	return service
+}
+
+func mustLedgerPolicy(test *testing.T, rawLedgerID string, settings LedgerPolicySettings) LedgerPolicy {
+	test.Helper()
+	policy, err := NewLedgerPolicy(mustLedgerID(test, rawLedgerID), settings)
+	if err != nil {
+		test.Fatalf("ledger policy: %v", err)
+	}
+	return policy
+}
@@ -10,10 +10,17 @@ type DeriveKeyFunc func(baseKey IdempotencyKey, suffix string) (IdempotencyKey,
 
 // Service contains the domain logic over a Store.
 type Service struct {
-	store       Store
-	nowFn       func() int64
-	logger      OperationLogger
-	deriveKeyFn DeriveKeyFunc
+	store             Store
+	nowFn             func() int64
+	logger            OperationLogger
+	deriveKeyFn       DeriveKeyFunc
+	velocityRules     []VelocityRule
+	balanceLimits     []BalanceLimit
+	balanceThresholds []BalanceThreshold
+	tenantPolicies    map[TenantID]TenantPolicy
+	strictAccounts    bool
+	outboxTenants     map[TenantID]struct{}
+	eventSinks        []EventSink
 }
 
 // NewService wires a Service.
@@ -33,18 +40,17 @@ func NewService(store Store, now func() int64, options ...ServiceOption) (*Servi
 	return service, nil
 }
 
-// Balance returns total and available (total minus active holds).
+// Balance returns total and available (total minus active holds). Unknown accounts report a zero balance.
 func (service *Service) Balance(ctx context.Context, tenantID TenantID, userID UserID, ledgerID LedgerID) (Balance, error) {
-	accountID, err := service.store.GetOrCreateAccountID(ctx, tenantID, userID, ledgerID)
-	if err != nil {
-		return Balance{}, err
+	accountID, err := service.lookupAccountID(ctx, service.store, tenantID, userID, ledgerID)
+	if service.isLenientUnknownAccount(err) {
+		return Balance{}, nil
 	}
-	nowUnixUTC := service.nowFn()
-	total, err := service.store.SumTotal(ctx, accountID, nowUnixUTC)
 	if err != nil {
 		return Balance{}, err
 	}
-	holds, err := service.store.SumActiveHolds(ctx, accountID, nowUnixUTC)
+	nowUnixUTC := service.nowFn()
+	total, holds, err := sumBalance(ctx, service.store, accountID, nowUnixUTC)
 	if err != nil {
 		return Balance{}, err
 	}
@@ -63,43 +69,7 @@ func (service *Service) Grant(ctx context.Context, tenantID TenantID, userID Use
 
 // GrantEntry appends a positive grant (optionally expiring) and returns the persisted entry.
 func (service *Service) GrantEntry(ctx context.Context, tenantID TenantID, userID UserID, ledgerID LedgerID, amount PositiveAmountCents, idempotencyKey IdempotencyKey, expiresAtUnixUTC int64, metadata MetadataJSON) (Entry, error) {
-	var persistedEntry Entry
-	operationError := service.store.WithTx(ctx, func(ctx context.Context, transactionStore Store) error {
-		accountID, err := transactionStore.GetOrCreateAccountID(ctx, tenantID, userID, ledgerID)
-		if err != nil {
-			return err
-		}
-		entryInput, err := NewEntryInput(
-			accountID,
-			EntryGrant,
-			amount.ToEntryAmountCents(),
-			nil,
-			nil,
-			idempotencyKey,
-			expiresAtUnixUTC,
-			metadata,
-			service.nowFn(),
-		)
-		if err != nil {
-			return err
-		}
-		persistedEntry, err = transactionStore.InsertEntry(ctx, entryInput)
-		return err
-	})
-	service.logOperation(ctx, OperationLog{
-		Operation:      operationGrant,
-		TenantID:       tenantID,
-		UserID:         userID,
-		LedgerID:       ledgerID,
-		Amount:         amount.ToAmountCents(),
-		IdempotencyKey: idempotencyKey,
-		Metadata:       metadata,
-		Error:          operationError,
-	})
-	if operationError != nil {
-		return Entry{}, operationError
-	}
-	return persistedEntry, nil
+	return service.ScheduleGrantEntry(ctx, tenantID, userID, ledgerID, amount, idempotencyKey, 0, expiresAtUnixUTC, metadata)
 }
 
 // Reserve appends a negative hold if sufficient available balance.
@@ -111,17 +81,20 @@ func (service *Service) Reserve(ctx context.Context, tenantID TenantID, userID U
 // ReserveEntry appends a negative hold if sufficient available balance and returns the persisted hold entry.
 func (service *Service) ReserveEntry(ctx context.Context, tenantID TenantID, userID UserID, ledgerID LedgerID, amount PositiveAmountCents, reservationID ReservationID, idempotencyKey IdempotencyKey, expiresAtUnixUTC int64, metadata MetadataJSON) (Entry, error) {
 	var persistedEntry Entry
-	operationError := service.store.WithTx(ctx, func(ctx context.Context, transactionStore Store) error {
-		accountID, err := transactionStore.GetOrCreateAccountID(ctx, tenantID, userID, ledgerID)
+	operationError := service.withTx(ctx, func(ctx context.Context, transactionStore Store) error {
+		accountID, err := service.resolveAccountID(ctx, transactionStore, tenantID, userID, ledgerID)
 		if err != nil {
 			return err
 		}
+		if err := service.checkSpendPolicy(tenantID, ledgerID, amount.Int64()); err != nil {
+			return err
+		}
 		nowUnixUTC := service.nowFn()
-		total, err := transactionStore.SumTotal(ctx, accountID, nowUnixUTC)
+		expiresAtUnixUTC, err := service.reservationExpiry(tenantID, ledgerID, expiresAtUnixUTC, nowUnixUTC)
 		if err != nil {
 			return err
 		}
-		holds, err := transactionStore.SumActiveHolds(ctx, accountID, nowUnixUTC)
+		total, holds, err := sumBalance(ctx, transactionStore, accountID, nowUnixUTC)
 		if err != nil {
 			return err
 		}
@@ -130,11 +103,14 @@ func (service *Service) ReserveEntry(ctx context.Context, tenantID TenantID, use
 		if available.Int64() < amountCents.Int64() {
 			return ErrInsufficientFunds
 		}
+		if err := service.checkVelocity(ctx, transactionStore, tenantID, ledgerID, accountID, amountCents.Int64(), nowUnixUTC); err != nil {
+			return err
+		}
 		reservation, err := NewReservation(accountID, reservationID, amount, ReservationStatusActive, expiresAtUnixUTC)
 		if err != nil {
 			return err
 		}
-		if err := transactionStore.CreateReservation(ctx, reservation); err != nil {
+		if err := service.createReservation(ctx, transactionStore, tenantID, userID, ledgerID, reservation); err != nil {
 			return err
 		}
 		entryInput, err := NewEntryInput(
@@ -151,7 +127,7 @@ func (service *Service) ReserveEntry(ctx context.Context, tenantID TenantID, use
 		if err != nil {
 			return err
 		}
-		persistedEntry, err = transactionStore.InsertEntry(ctx, entryInput)
+		persistedEntry, err = service.insertEntry(ctx, transactionStore, tenantID, userID, ledgerID, entryInput)
 		return err
 	})
 	reservationRef := reservationID
@@ -181,8 +157,8 @@ func (service *Service) Capture(ctx context.Context, tenantID TenantID, userID U
 // CaptureDebitEntry finalizes a reservation and returns the persisted debit entry.
 func (service *Service) CaptureDebitEntry(ctx context.Context, tenantID TenantID, userID UserID, ledgerID LedgerID, reservationID ReservationID, idempotencyKey IdempotencyKey, amount PositiveAmountCents, metadata MetadataJSON) (Entry, error) {
 	var persistedEntry Entry
-	operationError := service.store.WithTx(ctx, func(ctx context.Context, transactionStore Store) error {
-		accountID, err := transactionStore.GetOrCreateAccountID(ctx, tenantID, userID, ledgerID)
+	operationError := service.withTx(ctx, func(ctx context.Context, transactionStore Store) error {
+		accountID, err := service.resolveAccountID(ctx, transactionStore, tenantID, userID, ledgerID)
 		if err != nil {
 			return err
 		}
@@ -200,7 +176,7 @@ func (service *Service) CaptureDebitEntry(ctx context.Context, tenantID TenantID
 		if reservation.AmountCents() != amount {
 			return fmt.Errorf("%w: capture amount mismatch", ErrInvalidAmountCents)
 		}
-		if err := transactionStore.UpdateReservationStatus(ctx, accountID, reservationID, ReservationStatusActive, ReservationStatusCaptured); err != nil {
+		if err := service.closeReservation(ctx, transactionStore, tenantID, userID, ledgerID, reservation, ReservationStatusCaptured); err != nil {
 			return err
 		}
 		reverseKey, err := service.deriveKeyFn(idempotencyKey, idempotencySuffixReverse)
@@ -221,7 +197,7 @@ func (service *Service) CaptureDebitEntry(ctx context.Context, tenantID TenantID
 		if err != nil {
 			return err
 		}
-		if _, err := transactionStore.InsertEntry(ctx, reverseEntry); err != nil {
+		if _, err := service.insertEntry(ctx, transactionStore, tenantID, userID, ledgerID, reverseEntry); err != nil {
 			return err
 		}
 		spendKey, err := service.deriveKeyFn(idempotencyKey, idempotencySuffixSpend)
@@ -242,7 +218,7 @@ func (service *Service) CaptureDebitEntry(ctx context.Context, tenantID TenantID
 		if err != nil {
 			return err
 		}
-		persistedEntry, err = transactionStore.InsertEntry(ctx, spendEntry)
+		persistedEntry, err = service.insertEntry(ctx, transactionStore, tenantID, userID, ledgerID, spendEntry)
 		return err
 	})
 	reservationRef := reservationID
@@ -273,8 +249,8 @@ func (service *Service) Release(ctx context.Context, tenantID TenantID, userID U
 func (service *Service) ReleaseEntry(ctx context.Context, tenantID TenantID, userID UserID, ledgerID LedgerID, reservationID ReservationID, idempotencyKey IdempotencyKey, metadata MetadataJSON) (Entry, error) {
 	var reservationAmount AmountCents
 	var persistedEntry Entry
-	operationError := service.store.WithTx(ctx, func(ctx context.Context, transactionStore Store) error {
-		accountID, err := transactionStore.GetOrCreateAccountID(ctx, tenantID, userID, ledgerID)
+	operationError := service.withTx(ctx, func(ctx context.Context, transactionStore Store) error {
+		accountID, err := service.resolveAccountID(ctx, transactionStore, tenantID, userID, ledgerID)
 		if err != nil {
 			return err
 		}
@@ -286,7 +262,7 @@ func (service *Service) ReleaseEntry(ctx context.Context, tenantID TenantID, use
 			return ErrReservationClosed
 		}
 		reservationAmount = reservation.AmountCents().ToAmountCents()
-		if err := transactionStore.UpdateReservationStatus(ctx, accountID, reservationID, ReservationStatusActive, ReservationStatusReleased); err != nil {
+		if err := service.closeReservation(ctx, transactionStore, tenantID, userID, ledgerID, reservation, ReservationStatusReleased); err != nil {
 			return err
 		}
 		entryInput, err := NewEntryInput(
@@ -303,7 +279,7 @@ func (service *Service) ReleaseEntry(ctx context.Context, tenantID TenantID, use
 		if err != nil {
 			return err
 		}
-		persistedEntry, err = transactionStore.InsertEntry(ctx, entryInput)
+		persistedEntry, err = service.insertEntry(ctx, transactionStore, tenantID, userID, ledgerID, entryInput)
 		return err
 	})
 	reservationRef := reservationID
@@ -343,6 +319,22 @@ func deriveIdempotencyKey(baseKey IdempotencyKey, suffix string) (IdempotencyKey
 	return NewIdempotencyKey(combined)
 }
 
+// sumBalance returns an account's total and active holds, in one call when the store is a BalanceStore.
+func sumBalance(ctx context.Context, store Store, accountID AccountID, atUnixUTC int64) (SignedAmountCents, AmountCents, error) {
+	if balanceStore, ok := store.(BalanceStore); ok {
+		return balanceStore.SumBalance(ctx, accountID, atUnixUTC)
+	}
+	total, err := store.SumTotal(ctx, accountID, atUnixUTC)
+	if err != nil {
+		return 0, 0, err
+	}
+	holds, err := store.SumActiveHolds(ctx, accountID, atUnixUTC)
+	if err != nil {
+		return 0, 0, err
+	}
+	return total, holds, nil
+}
+
 func calculateAvailable(total SignedAmountCents, holds AmountCents) SignedAmountCents {
 	availableRaw := total.Int64() - holds.Int64()
 	available, _ := NewSignedAmountCents(availableRaw)
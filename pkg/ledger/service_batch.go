@@ -2,16 +2,19 @@ package ledger
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
 	"fmt"
 )
 
 // BatchGrantOperation describes a grant mutation within a batch request.
 type BatchGrantOperation struct {
-	Amount           PositiveAmountCents
-	IdempotencyKey   IdempotencyKey
-	ExpiresAtUnixUTC int64
-	Metadata         MetadataJSON
+	Amount             PositiveAmountCents
+	IdempotencyKey     IdempotencyKey
+	EffectiveAtUnixUTC int64
+	ExpiresAtUnixUTC   int64
+	Metadata           MetadataJSON
 }
 
 // BatchReserveOperation describes a reserve mutation within a batch request.
@@ -84,8 +87,8 @@ func (service *Service) Batch(ctx context.Context, tenantID TenantID, userID Use
 
 	results := make([]BatchOperationResult, len(operations))
 	batchRolledBack := false
-	operationError := service.store.WithTx(ctx, func(ctx context.Context, transactionStore Store) error {
-		accountID, err := transactionStore.GetOrCreateAccountID(ctx, tenantID, userID, ledgerID)
+	operationError := service.withTx(ctx, func(ctx context.Context, transactionStore Store) error {
+		accountID, err := service.resolveAccountID(ctx, transactionStore, tenantID, userID, ledgerID)
 		if err != nil {
 			return err
 		}
@@ -94,7 +97,7 @@ func (service *Service) Batch(ctx context.Context, tenantID TenantID, userID Use
 		for index, operation := range operations {
 			operation := operation
 			result := BatchOperationResult{OperationID: operation.OperationID}
-			entry, err := service.applyBatchOperation(ctx, transactionStore, accountID, operation)
+			entry, err := service.applyBatchOperation(ctx, transactionStore, tenantID, userID, ledgerID, accountID, operation)
 			if err != nil {
 				if errors.Is(err, ErrDuplicateIdempotencyKey) {
 					result.Duplicate = true
@@ -115,28 +118,133 @@ func (service *Service) Batch(ctx context.Context, tenantID TenantID, userID Use
 		}
 		return nil
 	})
+	batchID := newBatchID()
+	summary := BatchSummary{Atomic: atomic, Operations: len(operations)}
 	if operationError != nil && !errors.Is(operationError, errBatchAtomicRollback) {
+		service.logOperation(ctx, OperationLog{
+			Operation: operationBatch,
+			TenantID:  tenantID,
+			UserID:    userID,
+			LedgerID:  ledgerID,
+			BatchID:   batchID,
+			Batch:     &summary,
+			Error:     operationError,
+		})
 		return nil, operationError
 	}
 
-	if batchRolledBack {
-		for index := range results {
-			result := results[index]
-			if result.Error == nil && !result.Duplicate && result.Entry != nil {
-				result.RolledBack = true
-				result.Entry = nil
-				results[index] = result
-			}
+	for index := range results {
+		result := results[index]
+		if batchRolledBack && result.Error == nil && !result.Duplicate && result.Entry != nil {
+			result.RolledBack = true
+		}
+		service.logOperation(ctx, batchOperationLog(batchID, tenantID, userID, ledgerID, operations[index], result))
+		switch {
+		case result.RolledBack:
+			result.Entry = nil
+			summary.RolledBack++
+		case result.Duplicate:
+			summary.Duplicates++
+		case result.Error != nil:
+			summary.Failed++
+		default:
+			summary.Succeeded++
 		}
+		results[index] = result
 	}
+	summaryStatus := operationStatusOK
+	if batchRolledBack {
+		summaryStatus = operationStatusRolledBack
+	} else if summary.Failed > 0 {
+		summaryStatus = operationStatusError
+	}
+	service.logOperation(ctx, OperationLog{
+		Operation: operationBatch,
+		TenantID:  tenantID,
+		UserID:    userID,
+		LedgerID:  ledgerID,
+		BatchID:   batchID,
+		Batch:     &summary,
+		Status:    summaryStatus,
+	})
 
 	return results, nil
 }
 
-func (service *Service) applyBatchOperation(ctx context.Context, transactionStore Store, accountID AccountID, operation BatchOperation) (Entry, error) {
+// batchOperationLog describes one batch operation the way the matching single-call operation is logged.
+func batchOperationLog(batchID string, tenantID TenantID, userID UserID, ledgerID LedgerID, operation BatchOperation, result BatchOperationResult) OperationLog {
+	logEntry := OperationLog{
+		TenantID:    tenantID,
+		UserID:      userID,
+		LedgerID:    ledgerID,
+		BatchID:     batchID,
+		OperationID: operation.OperationID,
+		Error:       result.Error,
+	}
+	switch {
+	case result.RolledBack:
+		logEntry.Status = operationStatusRolledBack
+	case result.Duplicate:
+		logEntry.Status = operationStatusDuplicate
+	}
+	switch {
+	case operation.Grant != nil:
+		logEntry.Operation = operationGrant
+		logEntry.Amount = operation.Grant.Amount.ToAmountCents()
+		logEntry.IdempotencyKey = operation.Grant.IdempotencyKey
+		logEntry.Metadata = operation.Grant.Metadata
+	case operation.Spend != nil:
+		logEntry.Operation = operationSpend
+		logEntry.Amount = operation.Spend.Amount.ToAmountCents()
+		logEntry.IdempotencyKey = operation.Spend.IdempotencyKey
+		logEntry.Metadata = operation.Spend.Metadata
+	case operation.Reserve != nil:
+		reservationID := operation.Reserve.ReservationID
+		logEntry.Operation = operationReserve
+		logEntry.ReservationID = &reservationID
+		logEntry.Amount = operation.Reserve.Amount.ToAmountCents()
+		logEntry.IdempotencyKey = operation.Reserve.IdempotencyKey
+		logEntry.Metadata = operation.Reserve.Metadata
+	case operation.Capture != nil:
+		reservationID := operation.Capture.ReservationID
+		logEntry.Operation = operationCapture
+		logEntry.ReservationID = &reservationID
+		logEntry.Amount = operation.Capture.Amount.ToAmountCents()
+		logEntry.IdempotencyKey = operation.Capture.IdempotencyKey
+		logEntry.Metadata = operation.Capture.Metadata
+	case operation.Release != nil:
+		reservationID := operation.Release.ReservationID
+		logEntry.Operation = operationRelease
+		logEntry.ReservationID = &reservationID
+		if result.Entry != nil {
+			if amount, err := NewAmountCents(result.Entry.AmountCents().Int64()); err == nil {
+				logEntry.Amount = amount
+			}
+		}
+		logEntry.IdempotencyKey = operation.Release.IdempotencyKey
+		logEntry.Metadata = operation.Release.Metadata
+	case operation.Refund != nil:
+		logEntry.Operation = operationRefund
+		logEntry.Amount = operation.Refund.Amount.ToAmountCents()
+		logEntry.IdempotencyKey = operation.Refund.IdempotencyKey
+		logEntry.Metadata = operation.Refund.Metadata
+	}
+	return logEntry
+}
+
+// newBatchID returns a random identifier that correlates the log records of one Batch call.
+func newBatchID() string {
+	var raw [16]byte
+	_, _ = rand.Read(raw[:])
+	return hex.EncodeToString(raw[:])
+}
+
+func (service *Service) applyBatchOperation(ctx context.Context, transactionStore Store, tenantID TenantID, userID UserID, ledgerID LedgerID, accountID AccountID, operation BatchOperation) (Entry, error) {
 	var persistedEntry Entry
+	events := transactionEvents(ctx)
+	eventsMark := events.mark()
 	err := transactionStore.WithTx(ctx, func(ctx context.Context, txStore Store) error {
-		entry, err := service.applyBatchOperationWithinTx(ctx, txStore, accountID, operation)
+		entry, err := service.applyBatchOperationWithinTx(ctx, txStore, tenantID, userID, ledgerID, accountID, operation)
 		if err != nil {
 			return err
 		}
@@ -144,34 +252,45 @@ func (service *Service) applyBatchOperation(ctx context.Context, transactionStor
 		return nil
 	})
 	if err != nil {
+		events.discardFrom(eventsMark)
 		return Entry{}, err
 	}
 	return persistedEntry, nil
 }
 
-func (service *Service) applyBatchOperationWithinTx(ctx context.Context, txStore Store, accountID AccountID, operation BatchOperation) (Entry, error) {
+func (service *Service) applyBatchOperationWithinTx(ctx context.Context, txStore Store, tenantID TenantID, userID UserID, ledgerID LedgerID, accountID AccountID, operation BatchOperation) (Entry, error) {
 	if operation.Grant != nil {
-		return service.applyBatchGrant(ctx, txStore, accountID, *operation.Grant)
+		return service.applyBatchGrant(ctx, txStore, tenantID, userID, ledgerID, accountID, *operation.Grant)
 	}
 	if operation.Spend != nil {
-		return service.applyBatchSpend(ctx, txStore, accountID, *operation.Spend)
+		return service.applyBatchSpend(ctx, txStore, tenantID, userID, ledgerID, accountID, *operation.Spend)
 	}
 	if operation.Reserve != nil {
-		return service.applyBatchReserve(ctx, txStore, accountID, *operation.Reserve)
+		return service.applyBatchReserve(ctx, txStore, tenantID, userID, ledgerID, accountID, *operation.Reserve)
 	}
 	if operation.Capture != nil {
-		return service.applyBatchCapture(ctx, txStore, accountID, *operation.Capture)
+		return service.applyBatchCapture(ctx, txStore, tenantID, userID, ledgerID, accountID, *operation.Capture)
 	}
 	if operation.Release != nil {
-		return service.applyBatchRelease(ctx, txStore, accountID, *operation.Release)
+		return service.applyBatchRelease(ctx, txStore, tenantID, userID, ledgerID, accountID, *operation.Release)
 	}
 	if operation.Refund != nil {
-		return service.applyBatchRefund(ctx, txStore, accountID, *operation.Refund)
+		return service.applyBatchRefund(ctx, txStore, tenantID, userID, ledgerID, accountID, *operation.Refund)
 	}
 	return Entry{}, errors.New("unknown_batch_operation")
 }
 
-func (service *Service) applyBatchGrant(ctx context.Context, txStore Store, accountID AccountID, operation BatchGrantOperation) (Entry, error) {
+func (service *Service) applyBatchGrant(ctx context.Context, txStore Store, tenantID TenantID, userID UserID, ledgerID LedgerID, accountID AccountID, operation BatchGrantOperation) (Entry, error) {
+	if err := validateGrantSchedule(operation.EffectiveAtUnixUTC, operation.ExpiresAtUnixUTC); err != nil {
+		return Entry{}, err
+	}
+	if err := service.checkGrantPolicy(tenantID, ledgerID, operation.Amount.Int64(), operation.ExpiresAtUnixUTC); err != nil {
+		return Entry{}, err
+	}
+	nowUnixUTC := service.nowFn()
+	if err := service.checkBalanceLimit(ctx, txStore, tenantID, ledgerID, accountID, operation.Amount.Int64(), max(nowUnixUTC, operation.EffectiveAtUnixUTC), operation.ExpiresAtUnixUTC); err != nil {
+		return Entry{}, err
+	}
 	entryInput, err := NewEntryInput(
 		accountID,
 		EntryGrant,
@@ -181,21 +300,20 @@ func (service *Service) applyBatchGrant(ctx context.Context, txStore Store, acco
 		operation.IdempotencyKey,
 		operation.ExpiresAtUnixUTC,
 		operation.Metadata,
-		service.nowFn(),
+		nowUnixUTC,
 	)
 	if err != nil {
 		return Entry{}, err
 	}
-	return txStore.InsertEntry(ctx, entryInput)
+	return service.insertEntry(ctx, txStore, tenantID, userID, ledgerID, entryInput.WithEffectiveAtUnixUTC(operation.EffectiveAtUnixUTC))
 }
 
-func (service *Service) applyBatchSpend(ctx context.Context, txStore Store, accountID AccountID, operation BatchSpendOperation) (Entry, error) {
-	nowUnixUTC := service.nowFn()
-	total, err := txStore.SumTotal(ctx, accountID, nowUnixUTC)
-	if err != nil {
+func (service *Service) applyBatchSpend(ctx context.Context, txStore Store, tenantID TenantID, userID UserID, ledgerID LedgerID, accountID AccountID, operation BatchSpendOperation) (Entry, error) {
+	if err := service.checkSpendPolicy(tenantID, ledgerID, operation.Amount.Int64()); err != nil {
 		return Entry{}, err
 	}
-	holds, err := txStore.SumActiveHolds(ctx, accountID, nowUnixUTC)
+	nowUnixUTC := service.nowFn()
+	total, holds, err := sumBalance(ctx, txStore, accountID, nowUnixUTC)
 	if err != nil {
 		return Entry{}, err
 	}
@@ -204,6 +322,9 @@ func (service *Service) applyBatchSpend(ctx context.Context, txStore Store, acco
 	if available.Int64() < amountCents.Int64() {
 		return Entry{}, ErrInsufficientFunds
 	}
+	if err := service.checkVelocity(ctx, txStore, tenantID, ledgerID, accountID, amountCents.Int64(), nowUnixUTC); err != nil {
+		return Entry{}, err
+	}
 	entryInput, err := NewEntryInput(
 		accountID,
 		EntrySpend,
@@ -218,16 +339,19 @@ func (service *Service) applyBatchSpend(ctx context.Context, txStore Store, acco
 	if err != nil {
 		return Entry{}, err
 	}
-	return txStore.InsertEntry(ctx, entryInput)
+	return service.insertEntry(ctx, txStore, tenantID, userID, ledgerID, entryInput)
 }
 
-func (service *Service) applyBatchReserve(ctx context.Context, txStore Store, accountID AccountID, operation BatchReserveOperation) (Entry, error) {
+func (service *Service) applyBatchReserve(ctx context.Context, txStore Store, tenantID TenantID, userID UserID, ledgerID LedgerID, accountID AccountID, operation BatchReserveOperation) (Entry, error) {
+	if err := service.checkSpendPolicy(tenantID, ledgerID, operation.Amount.Int64()); err != nil {
+		return Entry{}, err
+	}
 	nowUnixUTC := service.nowFn()
-	total, err := txStore.SumTotal(ctx, accountID, nowUnixUTC)
+	expiresAtUnixUTC, err := service.reservationExpiry(tenantID, ledgerID, operation.ExpiresAtUnixUTC, nowUnixUTC)
 	if err != nil {
 		return Entry{}, err
 	}
-	holds, err := txStore.SumActiveHolds(ctx, accountID, nowUnixUTC)
+	total, holds, err := sumBalance(ctx, txStore, accountID, nowUnixUTC)
 	if err != nil {
 		return Entry{}, err
 	}
@@ -236,11 +360,14 @@ func (service *Service) applyBatchReserve(ctx context.Context, txStore Store, ac
 	if available.Int64() < amountCents.Int64() {
 		return Entry{}, ErrInsufficientFunds
 	}
-	reservation, err := NewReservation(accountID, operation.ReservationID, operation.Amount, ReservationStatusActive, operation.ExpiresAtUnixUTC)
+	if err := service.checkVelocity(ctx, txStore, tenantID, ledgerID, accountID, amountCents.Int64(), nowUnixUTC); err != nil {
+		return Entry{}, err
+	}
+	reservation, err := NewReservation(accountID, operation.ReservationID, operation.Amount, ReservationStatusActive, expiresAtUnixUTC)
 	if err != nil {
 		return Entry{}, err
 	}
-	if err := txStore.CreateReservation(ctx, reservation); err != nil {
+	if err := service.createReservation(ctx, txStore, tenantID, userID, ledgerID, reservation); err != nil {
 		return Entry{}, err
 	}
 	entryInput, err := NewEntryInput(
@@ -250,17 +377,17 @@ func (service *Service) applyBatchReserve(ctx context.Context, txStore Store, ac
 		&operation.ReservationID,
 		nil,
 		operation.IdempotencyKey,
-		operation.ExpiresAtUnixUTC,
+		expiresAtUnixUTC,
 		operation.Metadata,
 		nowUnixUTC,
 	)
 	if err != nil {
 		return Entry{}, err
 	}
-	return txStore.InsertEntry(ctx, entryInput)
+	return service.insertEntry(ctx, txStore, tenantID, userID, ledgerID, entryInput)
 }
 
-func (service *Service) applyBatchCapture(ctx context.Context, txStore Store, accountID AccountID, operation BatchCaptureOperation) (Entry, error) {
+func (service *Service) applyBatchCapture(ctx context.Context, txStore Store, tenantID TenantID, userID UserID, ledgerID LedgerID, accountID AccountID, operation BatchCaptureOperation) (Entry, error) {
 	nowUnixUTC := service.nowFn()
 	reservation, err := txStore.GetReservation(ctx, accountID, operation.ReservationID)
 	if err != nil {
@@ -275,7 +402,7 @@ func (service *Service) applyBatchCapture(ctx context.Context, txStore Store, ac
 	if reservation.AmountCents() != operation.Amount {
 		return Entry{}, fmt.Errorf("%w: capture amount mismatch", ErrInvalidAmountCents)
 	}
-	if err := txStore.UpdateReservationStatus(ctx, accountID, operation.ReservationID, ReservationStatusActive, ReservationStatusCaptured); err != nil {
+	if err := service.closeReservation(ctx, txStore, tenantID, userID, ledgerID, reservation, ReservationStatusCaptured); err != nil {
 		return Entry{}, err
 	}
 	reverseKey, err := service.deriveKeyFn(operation.IdempotencyKey, idempotencySuffixReverse)
@@ -296,7 +423,7 @@ func (service *Service) applyBatchCapture(ctx context.Context, txStore Store, ac
 	if err != nil {
 		return Entry{}, err
 	}
-	if _, err := txStore.InsertEntry(ctx, reverseEntry); err != nil {
+	if _, err := service.insertEntry(ctx, txStore, tenantID, userID, ledgerID, reverseEntry); err != nil {
 		return Entry{}, err
 	}
 	spendKey, err := service.deriveKeyFn(operation.IdempotencyKey, idempotencySuffixSpend)
@@ -317,10 +444,10 @@ func (service *Service) applyBatchCapture(ctx context.Context, txStore Store, ac
 	if err != nil {
 		return Entry{}, err
 	}
-	return txStore.InsertEntry(ctx, spendEntry)
+	return service.insertEntry(ctx, txStore, tenantID, userID, ledgerID, spendEntry)
 }
 
-func (service *Service) applyBatchRelease(ctx context.Context, txStore Store, accountID AccountID, operation BatchReleaseOperation) (Entry, error) {
+func (service *Service) applyBatchRelease(ctx context.Context, txStore Store, tenantID TenantID, userID UserID, ledgerID LedgerID, accountID AccountID, operation BatchReleaseOperation) (Entry, error) {
 	reservation, err := txStore.GetReservation(ctx, accountID, operation.ReservationID)
 	if err != nil {
 		return Entry{}, err
@@ -328,7 +455,7 @@ func (service *Service) applyBatchRelease(ctx context.Context, txStore Store, ac
 	if reservation.Status() != ReservationStatusActive {
 		return Entry{}, ErrReservationClosed
 	}
-	if err := txStore.UpdateReservationStatus(ctx, accountID, operation.ReservationID, ReservationStatusActive, ReservationStatusReleased); err != nil {
+	if err := service.closeReservation(ctx, txStore, tenantID, userID, ledgerID, reservation, ReservationStatusReleased); err != nil {
 		return Entry{}, err
 	}
 	entryInput, err := NewEntryInput(
@@ -345,10 +472,10 @@ func (service *Service) applyBatchRelease(ctx context.Context, txStore Store, ac
 	if err != nil {
 		return Entry{}, err
 	}
-	return txStore.InsertEntry(ctx, entryInput)
+	return service.insertEntry(ctx, txStore, tenantID, userID, ledgerID, entryInput)
 }
 
-func (service *Service) applyBatchRefund(ctx context.Context, txStore Store, accountID AccountID, operation BatchRefundOperation) (Entry, error) {
+func (service *Service) applyBatchRefund(ctx context.Context, txStore Store, tenantID TenantID, userID UserID, ledgerID LedgerID, accountID AccountID, operation BatchRefundOperation) (Entry, error) {
 	existingEntry, err := txStore.GetEntryByIdempotencyKey(ctx, accountID, operation.IdempotencyKey)
 	if err == nil {
 		if existingEntry.Type() != EntryRefund {
@@ -356,6 +483,9 @@ func (service *Service) applyBatchRefund(ctx context.Context, txStore Store, acc
 		}
 		return existingEntry, ErrDuplicateIdempotencyKey
 	}
+	if errors.Is(err, ErrArchivedEntry) {
+		return Entry{}, fmt.Errorf("%w: existing entry is archived", ErrIdempotencyKeyConflict)
+	}
 	if !errors.Is(err, ErrUnknownEntry) {
 		return Entry{}, err
 	}
@@ -393,6 +523,10 @@ func (service *Service) applyBatchRefund(ctx context.Context, txStore Store, acc
 		return Entry{}, ErrRefundExceedsDebit
 	}
 
+	nowUnixUTC := service.nowFn()
+	if err := service.checkBalanceLimit(ctx, txStore, tenantID, ledgerID, accountID, operation.Amount.Int64(), nowUnixUTC, 0); err != nil {
+		return Entry{}, err
+	}
 	refundOfEntryID := originalEntry.EntryID()
 	entryInput, err := NewEntryInput(
 		accountID,
@@ -403,13 +537,13 @@ func (service *Service) applyBatchRefund(ctx context.Context, txStore Store, acc
 		operation.IdempotencyKey,
 		0,
 		operation.Metadata,
-		service.nowFn(),
+		nowUnixUTC,
 	)
 	if err != nil {
 		return Entry{}, err
 	}
 
-	persistedEntry, err := txStore.InsertEntry(ctx, entryInput)
+	persistedEntry, err := service.insertEntry(ctx, txStore, tenantID, userID, ledgerID, entryInput)
 	if !errors.Is(err, ErrDuplicateIdempotencyKey) {
 		return persistedEntry, err
 	}
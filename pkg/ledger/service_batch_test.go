@@ -401,7 +401,7 @@ func TestApplyBatchRefundReturnsDuplicateWhenInsertEntryDetectsExistingRefund(te
 	}
 
 	operationOriginalEntryID := originalEntryID
-	entry, err := service.applyBatchRefund(context.Background(), store, accountID, BatchRefundOperation{
+	entry, err := service.applyBatchRefund(context.Background(), store, mustTenantID(test, defaultTenantIDValue), mustUserID(test, "user-1"), mustLedgerID(test, defaultLedgerIDValue), accountID, BatchRefundOperation{
 		OriginalEntryID: &operationOriginalEntryID,
 		Amount:          mustPositiveAmount(test, 50),
 		IdempotencyKey:  refundKey,
@@ -448,7 +448,7 @@ func TestApplyBatchRefundReturnsConflictWhenInsertEntryDetectsExistingNonRefundE
 	}
 
 	operationOriginalEntryID := originalEntryID
-	_, err := service.applyBatchRefund(context.Background(), store, accountID, BatchRefundOperation{
+	_, err := service.applyBatchRefund(context.Background(), store, mustTenantID(test, defaultTenantIDValue), mustUserID(test, "user-1"), mustLedgerID(test, defaultLedgerIDValue), accountID, BatchRefundOperation{
 		OriginalEntryID: &operationOriginalEntryID,
 		Amount:          mustPositiveAmount(test, 50),
 		IdempotencyKey:  refundKey,
@@ -482,7 +482,7 @@ func TestApplyBatchRefundReturnsErrorWhenInsertEntryDuplicateCannotBeResolved(te
 	}
 
 	operationOriginalEntryID := originalEntryID
-	_, err := service.applyBatchRefund(context.Background(), store, accountID, BatchRefundOperation{
+	_, err := service.applyBatchRefund(context.Background(), store, mustTenantID(test, defaultTenantIDValue), mustUserID(test, "user-1"), mustLedgerID(test, defaultLedgerIDValue), accountID, BatchRefundOperation{
 		OriginalEntryID: &operationOriginalEntryID,
 		Amount:          mustPositiveAmount(test, 50),
 		IdempotencyKey:  mustIdempotencyKey(test, "refund-1"),
@@ -1270,6 +1270,14 @@ func (store *duplicateInsertRefundStore) GetOrCreateAccountID(ctx context.Contex
 	panic("GetOrCreateAccountID not used")
 }
 
+func (store *duplicateInsertRefundStore) GetAccountID(ctx context.Context, tenantID TenantID, userID UserID, ledgerID LedgerID) (AccountID, error) {
+	panic("GetAccountID not used")
+}
+
+func (store *duplicateInsertRefundStore) ListAccounts(ctx context.Context, tenantID TenantID, beforeCreatedUnixUTC int64, limit int, filter ListAccountsFilter) ([]Account, error) {
+	panic("ListAccounts not used")
+}
+
 func (store *duplicateInsertRefundStore) InsertEntry(ctx context.Context, entry EntryInput) (Entry, error) {
 	return Entry{}, ErrDuplicateIdempotencyKey
 }
@@ -1304,6 +1312,58 @@ func (store *duplicateInsertRefundStore) SumActiveHolds(ctx context.Context, acc
 	panic("SumActiveHolds not used")
 }
 
+func (store *duplicateInsertRefundStore) SumDebitVelocity(ctx context.Context, accountID AccountID, sinceUnixUTC int64) (DebitVelocity, error) {
+	panic("SumDebitVelocity not used")
+}
+
+func (store *duplicateInsertRefundStore) InsertJournalLines(ctx context.Context, lines []JournalLine) error {
+	return nil
+}
+
+func (store *duplicateInsertRefundStore) SumJournalLines(ctx context.Context, tenantID TenantID, atUnixUTC int64) ([]TrialBalanceLine, error) {
+	panic("SumJournalLines not used")
+}
+
+func (store *duplicateInsertRefundStore) SumEntriesByExpiry(ctx context.Context, tenantID TenantID, atUnixUTC int64) ([]ExpiryTotal, error) {
+	panic("SumEntriesByExpiry not used")
+}
+
+func (store *duplicateInsertRefundStore) ListConsumableGrants(ctx context.Context, accountID AccountID, atUnixUTC int64) ([]Entry, error) {
+	return nil, nil
+}
+
+func (store *duplicateInsertRefundStore) ConsumeGrant(ctx context.Context, accountID AccountID, entryID EntryID, amountCents AmountCents) error {
+	panic("ConsumeGrant not used")
+}
+
+func (store *duplicateInsertRefundStore) AppendEntryChange(ctx context.Context, tenantID TenantID, entryID EntryID) error {
+	return nil
+}
+
+func (store *duplicateInsertRefundStore) ListEntryChanges(ctx context.Context, tenantID TenantID, afterCursor int64, limit int, filter EntryChangeFilter) ([]EntryChange, error) {
+	panic("ListEntryChanges not used")
+}
+
+func (store *duplicateInsertRefundStore) InsertOutboxEvent(ctx context.Context, event OutboxEvent) error {
+	return nil
+}
+
+func (store *duplicateInsertRefundStore) LockChainHead(ctx context.Context, accountID AccountID) (ChainHead, error) {
+	return ChainHead{}, nil
+}
+
+func (store *duplicateInsertRefundStore) AppendChainLink(ctx context.Context, accountID AccountID, link ChainLink) error {
+	return nil
+}
+
+func (store *duplicateInsertRefundStore) GetChainHead(ctx context.Context, accountID AccountID) (ChainHead, error) {
+	panic("GetChainHead not used")
+}
+
+func (store *duplicateInsertRefundStore) ListChainedEntries(ctx context.Context, accountID AccountID, afterSequence int64, limit int) ([]ChainedEntry, error) {
+	panic("ListChainedEntries not used")
+}
+
 func (store *duplicateInsertRefundStore) CreateReservation(ctx context.Context, reservation Reservation) error {
 	panic("CreateReservation not used")
 }
@@ -944,3 +944,38 @@ func (store *sumRefundsFailingStore) WithTx(ctx context.Context, fn func(ctx con
 func (store *sumRefundsFailingStore) SumRefunds(ctx context.Context, accountID AccountID, originalEntryID EntryID) (AmountCents, error) {
 	return 0, store.err
 }
+
+// sumBalanceStore wraps stubStore as a BalanceStore whose answer differs from SumTotal and SumActiveHolds,
+// so tests can tell which path the service took.
+type sumBalanceStore struct {
+	*stubStore
+	calls int
+	err   error
+}
+
+func (store *sumBalanceStore) SumBalance(ctx context.Context, accountID AccountID, atUnixUTC int64) (SignedAmountCents, AmountCents, error) {
+	store.calls++
+	if store.err != nil {
+		return 0, 0, store.err
+	}
+	return 700, 200, nil
+}
+
+func TestBalanceUsesSumBalanceWhenStoreProvidesIt(test *testing.T) {
+	test.Parallel()
+	store := &sumBalanceStore{stubStore: newStubStore(test, mustSignedAmount(test, 100))}
+	service := mustNewService(test, store)
+
+	balance, err := service.Balance(context.Background(), mustTenantID(test, defaultTenantIDValue), mustUserID(test, "user-1"), mustLedgerID(test, defaultLedgerIDValue))
+	if err != nil {
+		test.Fatalf("balance: %v", err)
+	}
+	if store.calls != 1 || balance.TotalCents != 700 || balance.AvailableCents != 500 {
+		test.Fatalf("expected one SumBalance call to produce 700/500, got %d calls and %+v", store.calls, balance)
+	}
+
+	store.err = errors.New("sum balance failed")
+	if _, err := service.Balance(context.Background(), mustTenantID(test, defaultTenantIDValue), mustUserID(test, "user-1"), mustLedgerID(test, defaultLedgerIDValue)); !errors.Is(err, store.err) {
+		test.Fatalf("expected the SumBalance error, got %v", err)
+	}
+}
@@ -11,17 +11,16 @@ func (service *Service) Spend(requestContext context.Context, tenantID TenantID,
 // SpendEntry debits the user's available balance immediately (no hold) and returns the persisted spend entry.
 func (service *Service) SpendEntry(requestContext context.Context, tenantID TenantID, userID UserID, ledgerID LedgerID, amount PositiveAmountCents, idempotencyKey IdempotencyKey, metadata MetadataJSON) (Entry, error) {
 	var persistedEntry Entry
-	operationError := service.store.WithTx(requestContext, func(ctx context.Context, transactionStore Store) error {
-		accountID, err := transactionStore.GetOrCreateAccountID(ctx, tenantID, userID, ledgerID)
+	operationError := service.withTx(requestContext, func(ctx context.Context, transactionStore Store) error {
+		accountID, err := service.resolveAccountID(ctx, transactionStore, tenantID, userID, ledgerID)
 		if err != nil {
 			return err
 		}
-		nowUnixUTC := service.nowFn()
-		total, err := transactionStore.SumTotal(ctx, accountID, nowUnixUTC)
-		if err != nil {
+		if err := service.checkSpendPolicy(tenantID, ledgerID, amount.Int64()); err != nil {
 			return err
 		}
-		holds, err := transactionStore.SumActiveHolds(ctx, accountID, nowUnixUTC)
+		nowUnixUTC := service.nowFn()
+		total, holds, err := sumBalance(ctx, transactionStore, accountID, nowUnixUTC)
 		if err != nil {
 			return err
 		}
@@ -30,6 +29,9 @@ func (service *Service) SpendEntry(requestContext context.Context, tenantID Tena
 		if available.Int64() < amountCents.Int64() {
 			return ErrInsufficientFunds
 		}
+		if err := service.checkVelocity(ctx, transactionStore, tenantID, ledgerID, accountID, amountCents.Int64(), nowUnixUTC); err != nil {
+			return err
+		}
 		entryInput, err := NewEntryInput(
 			accountID,
 			EntrySpend,
@@ -44,7 +46,7 @@ func (service *Service) SpendEntry(requestContext context.Context, tenantID Tena
 		if err != nil {
 			return err
 		}
-		persistedEntry, err = transactionStore.InsertEntry(ctx, entryInput)
+		persistedEntry, err = service.insertEntry(ctx, transactionStore, tenantID, userID, ledgerID, entryInput)
 		return err
 	})
 	service.logOperation(requestContext, OperationLog{
@@ -65,9 +67,17 @@ func (service *Service) SpendEntry(requestContext context.Context, tenantID Tena
 
 // ListEntries lists ledger entries for a user before a cutoff time.
 func (service *Service) ListEntries(requestContext context.Context, tenantID TenantID, userID UserID, ledgerID LedgerID, beforeUnixUTC int64, limit int, filter ListEntriesFilter) ([]Entry, error) {
-	accountID, err := service.store.GetOrCreateAccountID(requestContext, tenantID, userID, ledgerID)
+	accountID, err := service.lookupAccountID(requestContext, service.store, tenantID, userID, ledgerID)
+	if service.isLenientUnknownAccount(err) {
+		return nil, nil
+	}
 	if err != nil {
 		return nil, err
 	}
 	return service.store.ListEntries(requestContext, accountID, beforeUnixUTC, limit, filter)
 }
+
+// IsEntryPending reports whether an entry has not taken effect yet according to the service clock.
+func (service *Service) IsEntryPending(entry Entry) bool {
+	return entry.IsPendingAt(service.nowFn())
+}
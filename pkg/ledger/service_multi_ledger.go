@@ -0,0 +1,289 @@
+package ledger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// LedgerDebit is the portion of a multi-ledger spend debited from a single ledger.
+type LedgerDebit struct {
+	LedgerID LedgerID
+	Entry    Entry
+}
+
+// LedgerRefund is the portion of a multi-ledger refund credited back to a single ledger.
+type LedgerRefund struct {
+	LedgerID LedgerID
+	Entry    Entry
+}
+
+type ledgerAccount struct {
+	ledgerID  LedgerID
+	accountID AccountID
+}
+
+// SpendAcrossLedgers debits amount from the supplied ledgers in priority order within one transaction.
+// Each ledger contributes up to its available balance; the spend fails with ErrInsufficientFunds when the
+// ledgers cannot cover the amount together. The whole amount must be within the spend maximum of every
+// listed ledger. The same idempotency key is recorded on every debit entry.
+func (service *Service) SpendAcrossLedgers(ctx context.Context, tenantID TenantID, userID UserID, ledgerIDs []LedgerID, amount PositiveAmountCents, idempotencyKey IdempotencyKey, metadata MetadataJSON) ([]LedgerDebit, error) {
+	var debits []LedgerDebit
+	operationError := validateLedgerPriority(ledgerIDs)
+	if operationError == nil {
+		operationError = service.withTx(ctx, func(ctx context.Context, transactionStore Store) error {
+			accounts, err := service.resolveLedgerAccounts(ctx, transactionStore, tenantID, userID, ledgerIDs)
+			if err != nil {
+				return err
+			}
+			for _, account := range accounts {
+				if err := service.checkSpendPolicy(tenantID, account.ledgerID, amount.Int64()); err != nil {
+					return err
+				}
+			}
+			for _, account := range accounts {
+				_, err := transactionStore.GetEntryByIdempotencyKey(ctx, account.accountID, idempotencyKey)
+				if err == nil || errors.Is(err, ErrArchivedEntry) {
+					return ErrDuplicateIdempotencyKey
+				}
+				if !errors.Is(err, ErrUnknownEntry) {
+					return err
+				}
+			}
+			nowUnixUTC := service.nowFn()
+			remaining := amount.Int64()
+			for _, account := range accounts {
+				if remaining == 0 {
+					break
+				}
+				total, holds, err := sumBalance(ctx, transactionStore, account.accountID, nowUnixUTC)
+				if err != nil {
+					return err
+				}
+				available := calculateAvailable(total, holds).Int64()
+				if available <= 0 {
+					continue
+				}
+				portion := min(available, remaining)
+				if err := service.checkVelocity(ctx, transactionStore, tenantID, account.ledgerID, account.accountID, portion, nowUnixUTC); err != nil {
+					return err
+				}
+				entryInput, err := NewEntryInput(
+					account.accountID,
+					EntrySpend,
+					EntryAmountCents(-portion),
+					nil,
+					nil,
+					idempotencyKey,
+					0,
+					metadata,
+					nowUnixUTC,
+				)
+				if err != nil {
+					return err
+				}
+				persistedEntry, err := service.insertEntry(ctx, transactionStore, tenantID, userID, account.ledgerID, entryInput)
+				if err != nil {
+					return err
+				}
+				debits = append(debits, LedgerDebit{LedgerID: account.ledgerID, Entry: persistedEntry})
+				remaining -= portion
+			}
+			if remaining > 0 {
+				return ErrInsufficientFunds
+			}
+			return nil
+		})
+	}
+	if operationError != nil {
+		service.logOperation(ctx, OperationLog{
+			Operation:      operationSpendAcrossLedgers,
+			TenantID:       tenantID,
+			UserID:         userID,
+			LedgerID:       firstLedgerID(ledgerIDs),
+			Amount:         amount.ToAmountCents(),
+			IdempotencyKey: idempotencyKey,
+			Metadata:       metadata,
+			Error:          operationError,
+		})
+		return nil, operationError
+	}
+	for _, debit := range debits {
+		service.logOperation(ctx, OperationLog{
+			Operation:      operationSpendAcrossLedgers,
+			TenantID:       tenantID,
+			UserID:         userID,
+			LedgerID:       debit.LedgerID,
+			Amount:         AmountCents(-debit.Entry.AmountCents().Int64()),
+			IdempotencyKey: idempotencyKey,
+			Metadata:       metadata,
+		})
+	}
+	return debits, nil
+}
+
+// RefundAcrossLedgers refunds a multi-ledger spend identified by its idempotency key.
+// The refund is routed back to the ledgers that were debited, in reverse priority order (the last ledger
+// drained is refunded first), and never exceeds the remaining refundable amount of each debit.
+// Retrying with the same idempotency key returns the previously persisted refund entries.
+func (service *Service) RefundAcrossLedgers(ctx context.Context, tenantID TenantID, userID UserID, ledgerIDs []LedgerID, originalIdempotencyKey IdempotencyKey, amount PositiveAmountCents, idempotencyKey IdempotencyKey, metadata MetadataJSON) ([]LedgerRefund, error) {
+	var refunds []LedgerRefund
+	operationError := validateLedgerPriority(ledgerIDs)
+	if operationError == nil {
+		operationError = service.withTx(ctx, func(ctx context.Context, transactionStore Store) error {
+			accounts, err := service.resolveLedgerAccounts(ctx, transactionStore, tenantID, userID, ledgerIDs)
+			if err != nil {
+				return err
+			}
+			for _, account := range accounts {
+				existingEntry, err := transactionStore.GetEntryByIdempotencyKey(ctx, account.accountID, idempotencyKey)
+				if err == nil {
+					if existingEntry.Type() != EntryRefund {
+						return ErrDuplicateIdempotencyKey
+					}
+					refunds = append(refunds, LedgerRefund{LedgerID: account.ledgerID, Entry: existingEntry})
+					continue
+				}
+				if errors.Is(err, ErrArchivedEntry) {
+					return ErrDuplicateIdempotencyKey
+				}
+				if !errors.Is(err, ErrUnknownEntry) {
+					return err
+				}
+			}
+			if len(refunds) > 0 {
+				return nil
+			}
+
+			type ledgerDebitEntry struct {
+				account ledgerAccount
+				entry   Entry
+			}
+			originals := make([]ledgerDebitEntry, 0, len(accounts))
+			for _, account := range accounts {
+				originalEntry, err := transactionStore.GetEntryByIdempotencyKey(ctx, account.accountID, originalIdempotencyKey)
+				if errors.Is(err, ErrUnknownEntry) {
+					continue
+				}
+				if err != nil {
+					return err
+				}
+				if originalEntry.Type() != EntrySpend || originalEntry.AmountCents().Int64() >= 0 {
+					return ErrInvalidRefundOriginal
+				}
+				originals = append(originals, ledgerDebitEntry{account: account, entry: originalEntry})
+			}
+			if len(originals) == 0 {
+				return ErrUnknownEntry
+			}
+
+			nowUnixUTC := service.nowFn()
+			remaining := amount.Int64()
+			for index := len(originals) - 1; index >= 0 && remaining > 0; index-- {
+				original := originals[index]
+				refunded, err := transactionStore.SumRefunds(ctx, original.account.accountID, original.entry.EntryID())
+				if err != nil {
+					return err
+				}
+				refundable := -original.entry.AmountCents().Int64() - refunded.Int64()
+				if refundable <= 0 {
+					continue
+				}
+				portion := min(refundable, remaining)
+				if err := service.checkBalanceLimit(ctx, transactionStore, tenantID, original.account.ledgerID, original.account.accountID, portion, nowUnixUTC, 0); err != nil {
+					return err
+				}
+				var reservationRef *ReservationID
+				if reservationID, hasReservation := original.entry.ReservationID(); hasReservation {
+					reservationRef = &reservationID
+				}
+				refundOfEntryID := original.entry.EntryID()
+				entryInput, err := NewEntryInput(
+					original.account.accountID,
+					EntryRefund,
+					EntryAmountCents(portion),
+					reservationRef,
+					&refundOfEntryID,
+					idempotencyKey,
+					0,
+					metadata,
+					nowUnixUTC,
+				)
+				if err != nil {
+					return err
+				}
+				persistedEntry, err := service.insertEntry(ctx, transactionStore, tenantID, userID, original.account.ledgerID, entryInput)
+				if err != nil {
+					return err
+				}
+				refunds = append(refunds, LedgerRefund{LedgerID: original.account.ledgerID, Entry: persistedEntry})
+				remaining -= portion
+			}
+			if remaining > 0 {
+				return ErrRefundExceedsDebit
+			}
+			return nil
+		})
+	}
+	if operationError != nil {
+		service.logOperation(ctx, OperationLog{
+			Operation:      operationRefundAcrossLedgers,
+			TenantID:       tenantID,
+			UserID:         userID,
+			LedgerID:       firstLedgerID(ledgerIDs),
+			Amount:         amount.ToAmountCents(),
+			IdempotencyKey: idempotencyKey,
+			Metadata:       metadata,
+			Error:          operationError,
+		})
+		return nil, operationError
+	}
+	for _, refund := range refunds {
+		service.logOperation(ctx, OperationLog{
+			Operation:      operationRefundAcrossLedgers,
+			TenantID:       tenantID,
+			UserID:         userID,
+			LedgerID:       refund.LedgerID,
+			Amount:         AmountCents(refund.Entry.AmountCents().Int64()),
+			IdempotencyKey: idempotencyKey,
+			Metadata:       metadata,
+		})
+	}
+	return refunds, nil
+}
+
+func validateLedgerPriority(ledgerIDs []LedgerID) error {
+	if len(ledgerIDs) == 0 {
+		return fmt.Errorf("%w: %s", ErrInvalidLedgerID, errorEmptyValue)
+	}
+	seen := make(map[LedgerID]struct{}, len(ledgerIDs))
+	for _, ledgerID := range ledgerIDs {
+		if err := validateIdentifierValue(ledgerID.value, ErrInvalidLedgerID); err != nil {
+			return err
+		}
+		if _, duplicate := seen[ledgerID]; duplicate {
+			return fmt.Errorf("%w: %s", ErrInvalidLedgerID, errorDuplicateValue)
+		}
+		seen[ledgerID] = struct{}{}
+	}
+	return nil
+}
+
+func (service *Service) resolveLedgerAccounts(ctx context.Context, transactionStore Store, tenantID TenantID, userID UserID, ledgerIDs []LedgerID) ([]ledgerAccount, error) {
+	accounts := make([]ledgerAccount, 0, len(ledgerIDs))
+	for _, ledgerID := range ledgerIDs {
+		accountID, err := service.resolveAccountID(ctx, transactionStore, tenantID, userID, ledgerID)
+		if err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, ledgerAccount{ledgerID: ledgerID, accountID: accountID})
+	}
+	return accounts, nil
+}
+
+func firstLedgerID(ledgerIDs []LedgerID) LedgerID {
+	if len(ledgerIDs) == 0 {
+		return LedgerID{}
+	}
+	return ledgerIDs[0]
+}
@@ -0,0 +1,542 @@
+package ledger
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+const (
+	promoLedgerIDValue     = "promo"
+	purchasedLedgerIDValue = "purchased"
+)
+
+func TestSpendAcrossLedgersDrainsLedgersInPriorityOrder(test *testing.T) {
+	test.Parallel()
+	store := newMultiLedgerStore(test, map[string]int64{promoLedgerIDValue: 30, purchasedLedgerIDValue: 100})
+	logger := &recorderLogger{}
+	service, err := NewService(store, func() int64 { return 100 }, WithOperationLogger(logger))
+	if err != nil {
+		test.Fatalf("new service: %v", err)
+	}
+	tenantID := mustTenantID(test, defaultTenantIDValue)
+	userID := mustUserID(test, "user-1")
+	ledgerIDs := []LedgerID{mustLedgerID(test, promoLedgerIDValue), mustLedgerID(test, purchasedLedgerIDValue)}
+
+	debits, err := service.SpendAcrossLedgers(context.Background(), tenantID, userID, ledgerIDs, mustPositiveAmount(test, 50), mustIdempotencyKey(test, "spend-1"), mustMetadata(test, "{}"))
+	if err != nil {
+		test.Fatalf("spend across ledgers: %v", err)
+	}
+	if len(debits) != 2 {
+		test.Fatalf("expected 2 debits, got %d", len(debits))
+	}
+	if debits[0].LedgerID != ledgerIDs[0] || debits[0].Entry.AmountCents().Int64() != -30 || debits[0].Entry.Type() != EntrySpend {
+		test.Fatalf("unexpected first debit: ledger=%s amount=%d", debits[0].LedgerID.String(), debits[0].Entry.AmountCents().Int64())
+	}
+	if debits[1].LedgerID != ledgerIDs[1] || debits[1].Entry.AmountCents().Int64() != -20 {
+		test.Fatalf("unexpected second debit: ledger=%s amount=%d", debits[1].LedgerID.String(), debits[1].Entry.AmountCents().Int64())
+	}
+	if store.ledger(test, promoLedgerIDValue).total != 0 || store.ledger(test, purchasedLedgerIDValue).total != 80 {
+		test.Fatalf("unexpected totals: promo=%d purchased=%d", store.ledger(test, promoLedgerIDValue).total, store.ledger(test, purchasedLedgerIDValue).total)
+	}
+	if len(logger.entries) != 2 || logger.entries[0].Operation != operationSpendAcrossLedgers || logger.entries[0].Amount != 30 || logger.entries[1].Amount != 20 {
+		test.Fatalf("unexpected log entries: %+v", logger.entries)
+	}
+}
+
+func TestSpendAcrossLedgersStopsOnceAmountIsCovered(test *testing.T) {
+	test.Parallel()
+	store := newMultiLedgerStore(test, map[string]int64{promoLedgerIDValue: 0, purchasedLedgerIDValue: 100})
+	service := mustNewService(test, store)
+	ledgerIDs := []LedgerID{mustLedgerID(test, promoLedgerIDValue), mustLedgerID(test, purchasedLedgerIDValue), mustLedgerID(test, "bonus")}
+
+	debits, err := service.SpendAcrossLedgers(context.Background(), mustTenantID(test, defaultTenantIDValue), mustUserID(test, "user-1"), ledgerIDs, mustPositiveAmount(test, 40), mustIdempotencyKey(test, "spend-1"), mustMetadata(test, "{}"))
+	if err != nil {
+		test.Fatalf("spend across ledgers: %v", err)
+	}
+	if len(debits) != 1 || debits[0].LedgerID != ledgerIDs[1] || debits[0].Entry.AmountCents().Int64() != -40 {
+		test.Fatalf("expected a single purchased debit of 40, got %+v", debits)
+	}
+}
+
+func TestSpendAcrossLedgersChecksWholeAmountAgainstSpendMaximum(test *testing.T) {
+	test.Parallel()
+	store := newMultiLedgerStore(test, map[string]int64{promoLedgerIDValue: 300, purchasedLedgerIDValue: 300})
+	service := mustNewPolicyService(test, store, false,
+		mustLedgerPolicy(test, promoLedgerIDValue, LedgerPolicySettings{MaxSpendAmountCents: 300}),
+		mustLedgerPolicy(test, purchasedLedgerIDValue, LedgerPolicySettings{MaxSpendAmountCents: 300}),
+	)
+	ledgerIDs := []LedgerID{mustLedgerID(test, promoLedgerIDValue), mustLedgerID(test, purchasedLedgerIDValue)}
+
+	debits, err := service.SpendAcrossLedgers(context.Background(), mustTenantID(test, defaultTenantIDValue), mustUserID(test, "user-1"), ledgerIDs, mustPositiveAmount(test, 600), mustIdempotencyKey(test, "spend-split"), mustMetadata(test, "{}"))
+	if !errors.Is(err, ErrAmountLimitExceeded) || debits != nil {
+		test.Fatalf("expected a split spend above the maximum to fail with ErrAmountLimitExceeded, got %+v, %v", debits, err)
+	}
+	if store.ledger(test, promoLedgerIDValue).total != 300 || store.ledger(test, purchasedLedgerIDValue).total != 300 {
+		test.Fatalf("expected no debits, got promo=%d purchased=%d", store.ledger(test, promoLedgerIDValue).total, store.ledger(test, purchasedLedgerIDValue).total)
+	}
+	if _, err := service.SpendAcrossLedgers(context.Background(), mustTenantID(test, defaultTenantIDValue), mustUserID(test, "user-1"), ledgerIDs, mustPositiveAmount(test, 300), mustIdempotencyKey(test, "spend-max"), mustMetadata(test, "{}")); err != nil {
+		test.Fatalf("spend at the maximum: %v", err)
+	}
+}
+
+func TestSpendAcrossLedgersRollsBackWhenLedgersCannotCoverAmount(test *testing.T) {
+	test.Parallel()
+	store := newMultiLedgerStore(test, map[string]int64{promoLedgerIDValue: 30, purchasedLedgerIDValue: 10})
+	logger := &recorderLogger{}
+	service, err := NewService(store, func() int64 { return 100 }, WithOperationLogger(logger))
+	if err != nil {
+		test.Fatalf("new service: %v", err)
+	}
+	ledgerIDs := []LedgerID{mustLedgerID(test, promoLedgerIDValue), mustLedgerID(test, purchasedLedgerIDValue)}
+
+	debits, err := service.SpendAcrossLedgers(context.Background(), mustTenantID(test, defaultTenantIDValue), mustUserID(test, "user-1"), ledgerIDs, mustPositiveAmount(test, 50), mustIdempotencyKey(test, "spend-1"), mustMetadata(test, "{}"))
+	if !errors.Is(err, ErrInsufficientFunds) {
+		test.Fatalf("expected insufficient funds, got %v", err)
+	}
+	if debits != nil {
+		test.Fatalf("expected no debits, got %+v", debits)
+	}
+	if len(store.ledger(test, promoLedgerIDValue).entries) != 0 || store.ledger(test, promoLedgerIDValue).total != 30 {
+		test.Fatalf("expected promo ledger to be rolled back")
+	}
+	if len(logger.entries) != 1 || logger.entries[0].Status != operationStatusError || logger.entries[0].LedgerID != ledgerIDs[0] {
+		test.Fatalf("unexpected log entries: %+v", logger.entries)
+	}
+}
+
+func TestSpendAcrossLedgersRejectsReusedIdempotencyKey(test *testing.T) {
+	test.Parallel()
+	store := newMultiLedgerStore(test, map[string]int64{promoLedgerIDValue: 50, purchasedLedgerIDValue: 100})
+	service := mustNewService(test, store)
+	tenantID := mustTenantID(test, defaultTenantIDValue)
+	userID := mustUserID(test, "user-1")
+	ledgerIDs := []LedgerID{mustLedgerID(test, promoLedgerIDValue), mustLedgerID(test, purchasedLedgerIDValue)}
+
+	if _, err := service.SpendAcrossLedgers(context.Background(), tenantID, userID, ledgerIDs, mustPositiveAmount(test, 50), mustIdempotencyKey(test, "spend-1"), mustMetadata(test, "{}")); err != nil {
+		test.Fatalf("first spend: %v", err)
+	}
+	_, err := service.SpendAcrossLedgers(context.Background(), tenantID, userID, ledgerIDs, mustPositiveAmount(test, 50), mustIdempotencyKey(test, "spend-1"), mustMetadata(test, "{}"))
+	if !errors.Is(err, ErrDuplicateIdempotencyKey) {
+		test.Fatalf("expected duplicate idempotency key, got %v", err)
+	}
+	if store.ledger(test, purchasedLedgerIDValue).total != 100 {
+		test.Fatalf("expected retry not to drain the next ledger, got %d", store.ledger(test, purchasedLedgerIDValue).total)
+	}
+}
+
+func TestSpendAcrossLedgersValidatesLedgerPriority(test *testing.T) {
+	test.Parallel()
+	store := newMultiLedgerStore(test, map[string]int64{promoLedgerIDValue: 50})
+	service := mustNewService(test, store)
+	promoLedgerID := mustLedgerID(test, promoLedgerIDValue)
+	testCases := []struct {
+		name      string
+		ledgerIDs []LedgerID
+	}{
+		{name: "empty", ledgerIDs: nil},
+		{name: "duplicate", ledgerIDs: []LedgerID{promoLedgerID, promoLedgerID}},
+		{name: "zero value", ledgerIDs: []LedgerID{{}}},
+	}
+	for _, testCase := range testCases {
+		_, err := service.SpendAcrossLedgers(context.Background(), mustTenantID(test, defaultTenantIDValue), mustUserID(test, "user-1"), testCase.ledgerIDs, mustPositiveAmount(test, 10), mustIdempotencyKey(test, "spend-1"), mustMetadata(test, "{}"))
+		if !errors.Is(err, ErrInvalidLedgerID) {
+			test.Fatalf("%s: expected invalid ledger id, got %v", testCase.name, err)
+		}
+		_, err = service.RefundAcrossLedgers(context.Background(), mustTenantID(test, defaultTenantIDValue), mustUserID(test, "user-1"), testCase.ledgerIDs, mustIdempotencyKey(test, "spend-1"), mustPositiveAmount(test, 10), mustIdempotencyKey(test, "refund-1"), mustMetadata(test, "{}"))
+		if !errors.Is(err, ErrInvalidLedgerID) {
+			test.Fatalf("%s: expected invalid ledger id for refund, got %v", testCase.name, err)
+		}
+	}
+}
+
+func TestSpendAcrossLedgersReturnsStoreErrors(test *testing.T) {
+	test.Parallel()
+	storeError := errors.New("store failure")
+	ledgerIDs := []LedgerID{mustLedgerID(test, promoLedgerIDValue)}
+	testCases := []struct {
+		name      string
+		configure func(store *stubStore)
+	}{
+		{name: "sum total", configure: func(store *stubStore) { store.sumTotalError = storeError }},
+		{name: "sum active holds", configure: func(store *stubStore) { store.sumActiveHoldsError = storeError }},
+		{name: "insert entry", configure: func(store *stubStore) { store.insertEntryError = storeError }},
+	}
+	for _, testCase := range testCases {
+		store := newMultiLedgerStore(test, map[string]int64{promoLedgerIDValue: 50})
+		testCase.configure(store.ledger(test, promoLedgerIDValue))
+		service := mustNewService(test, store)
+		_, err := service.SpendAcrossLedgers(context.Background(), mustTenantID(test, defaultTenantIDValue), mustUserID(test, "user-1"), ledgerIDs, mustPositiveAmount(test, 10), mustIdempotencyKey(test, "spend-1"), mustMetadata(test, "{}"))
+		if !errors.Is(err, storeError) {
+			test.Fatalf("%s: expected store error, got %v", testCase.name, err)
+		}
+	}
+
+	accountStore := newMultiLedgerStore(test, map[string]int64{promoLedgerIDValue: 50})
+	accountStore.getAccountError = storeError
+	service := mustNewService(test, accountStore)
+	if _, err := service.SpendAcrossLedgers(context.Background(), mustTenantID(test, defaultTenantIDValue), mustUserID(test, "user-1"), ledgerIDs, mustPositiveAmount(test, 10), mustIdempotencyKey(test, "spend-1"), mustMetadata(test, "{}")); !errors.Is(err, storeError) {
+		test.Fatalf("expected account lookup error, got %v", err)
+	}
+	if _, err := service.RefundAcrossLedgers(context.Background(), mustTenantID(test, defaultTenantIDValue), mustUserID(test, "user-1"), ledgerIDs, mustIdempotencyKey(test, "spend-1"), mustPositiveAmount(test, 10), mustIdempotencyKey(test, "refund-1"), mustMetadata(test, "{}")); !errors.Is(err, storeError) {
+		test.Fatalf("expected account lookup error for refund, got %v", err)
+	}
+}
+
+func TestRefundAcrossLedgersRoutesRefundsInReversePriorityOrder(test *testing.T) {
+	test.Parallel()
+	store := newMultiLedgerStore(test, map[string]int64{promoLedgerIDValue: 30, purchasedLedgerIDValue: 100})
+	logger := &recorderLogger{}
+	service, err := NewService(store, func() int64 { return 100 }, WithOperationLogger(logger))
+	if err != nil {
+		test.Fatalf("new service: %v", err)
+	}
+	tenantID := mustTenantID(test, defaultTenantIDValue)
+	userID := mustUserID(test, "user-1")
+	ledgerIDs := []LedgerID{mustLedgerID(test, promoLedgerIDValue), mustLedgerID(test, purchasedLedgerIDValue)}
+	spendKey := mustIdempotencyKey(test, "spend-1")
+
+	debits, err := service.SpendAcrossLedgers(context.Background(), tenantID, userID, ledgerIDs, mustPositiveAmount(test, 50), spendKey, mustMetadata(test, "{}"))
+	if err != nil {
+		test.Fatalf("spend across ledgers: %v", err)
+	}
+
+	refunds, err := service.RefundAcrossLedgers(context.Background(), tenantID, userID, ledgerIDs, spendKey, mustPositiveAmount(test, 25), mustIdempotencyKey(test, "refund-1"), mustMetadata(test, "{}"))
+	if err != nil {
+		test.Fatalf("refund across ledgers: %v", err)
+	}
+	if len(refunds) != 2 {
+		test.Fatalf("expected 2 refunds, got %d", len(refunds))
+	}
+	if refunds[0].LedgerID != ledgerIDs[1] || refunds[0].Entry.AmountCents().Int64() != 20 {
+		test.Fatalf("expected purchased ledger to be refunded first, got ledger=%s amount=%d", refunds[0].LedgerID.String(), refunds[0].Entry.AmountCents().Int64())
+	}
+	refundOf, ok := refunds[0].Entry.RefundOfEntryID()
+	if !ok || refundOf != debits[1].Entry.EntryID() {
+		test.Fatalf("expected refund to reference purchased debit")
+	}
+	if refunds[1].LedgerID != ledgerIDs[0] || refunds[1].Entry.AmountCents().Int64() != 5 || refunds[1].Entry.Type() != EntryRefund {
+		test.Fatalf("expected promo ledger remainder, got ledger=%s amount=%d", refunds[1].LedgerID.String(), refunds[1].Entry.AmountCents().Int64())
+	}
+	if store.ledger(test, promoLedgerIDValue).total != 5 || store.ledger(test, purchasedLedgerIDValue).total != 100 {
+		test.Fatalf("unexpected totals: promo=%d purchased=%d", store.ledger(test, promoLedgerIDValue).total, store.ledger(test, purchasedLedgerIDValue).total)
+	}
+
+	replayed, err := service.RefundAcrossLedgers(context.Background(), tenantID, userID, ledgerIDs, spendKey, mustPositiveAmount(test, 25), mustIdempotencyKey(test, "refund-1"), mustMetadata(test, "{}"))
+	if err != nil {
+		test.Fatalf("replay refund: %v", err)
+	}
+	if len(replayed) != 2 || store.ledger(test, promoLedgerIDValue).total != 5 {
+		test.Fatalf("expected replay to return existing refunds without new credits, got %+v", replayed)
+	}
+
+	_, err = service.RefundAcrossLedgers(context.Background(), tenantID, userID, ledgerIDs, spendKey, mustPositiveAmount(test, 26), mustIdempotencyKey(test, "refund-2"), mustMetadata(test, "{}"))
+	if !errors.Is(err, ErrRefundExceedsDebit) {
+		test.Fatalf("expected refund exceeds debit, got %v", err)
+	}
+	if len(logger.entries) == 0 || logger.entries[len(logger.entries)-1].Operation != operationRefundAcrossLedgers || logger.entries[len(logger.entries)-1].Error == nil {
+		test.Fatalf("expected failed refund to be logged, got %+v", logger.entries)
+	}
+}
+
+func TestRefundAcrossLedgersRejectsInvalidOriginals(test *testing.T) {
+	test.Parallel()
+	store := newMultiLedgerStore(test, map[string]int64{promoLedgerIDValue: 0, purchasedLedgerIDValue: 0})
+	service := mustNewService(test, store)
+	tenantID := mustTenantID(test, defaultTenantIDValue)
+	userID := mustUserID(test, "user-1")
+	ledgerIDs := []LedgerID{mustLedgerID(test, promoLedgerIDValue), mustLedgerID(test, purchasedLedgerIDValue)}
+
+	_, err := service.RefundAcrossLedgers(context.Background(), tenantID, userID, ledgerIDs, mustIdempotencyKey(test, "missing"), mustPositiveAmount(test, 10), mustIdempotencyKey(test, "refund-1"), mustMetadata(test, "{}"))
+	if !errors.Is(err, ErrUnknownEntry) {
+		test.Fatalf("expected unknown entry, got %v", err)
+	}
+
+	if err := service.Grant(context.Background(), tenantID, userID, ledgerIDs[1], mustPositiveAmount(test, 10), mustIdempotencyKey(test, "grant-1"), 0, mustMetadata(test, "{}")); err != nil {
+		test.Fatalf("grant: %v", err)
+	}
+	_, err = service.RefundAcrossLedgers(context.Background(), tenantID, userID, ledgerIDs, mustIdempotencyKey(test, "grant-1"), mustPositiveAmount(test, 10), mustIdempotencyKey(test, "refund-1"), mustMetadata(test, "{}"))
+	if !errors.Is(err, ErrInvalidRefundOriginal) {
+		test.Fatalf("expected invalid refund original, got %v", err)
+	}
+
+	_, err = service.RefundAcrossLedgers(context.Background(), tenantID, userID, ledgerIDs, mustIdempotencyKey(test, "missing"), mustPositiveAmount(test, 10), mustIdempotencyKey(test, "grant-1"), mustMetadata(test, "{}"))
+	if !errors.Is(err, ErrDuplicateIdempotencyKey) {
+		test.Fatalf("expected duplicate idempotency key, got %v", err)
+	}
+}
+
+func TestRefundAcrossLedgersSkipsFullyRefundedDebits(test *testing.T) {
+	test.Parallel()
+	store := newMultiLedgerStore(test, map[string]int64{promoLedgerIDValue: 30, purchasedLedgerIDValue: 100})
+	service := mustNewService(test, store)
+	tenantID := mustTenantID(test, defaultTenantIDValue)
+	userID := mustUserID(test, "user-1")
+	ledgerIDs := []LedgerID{mustLedgerID(test, promoLedgerIDValue), mustLedgerID(test, purchasedLedgerIDValue)}
+	spendKey := mustIdempotencyKey(test, "spend-1")
+
+	debits, err := service.SpendAcrossLedgers(context.Background(), tenantID, userID, ledgerIDs, mustPositiveAmount(test, 50), spendKey, mustMetadata(test, "{}"))
+	if err != nil {
+		test.Fatalf("spend across ledgers: %v", err)
+	}
+	if _, err := service.RefundByEntryIDEntry(context.Background(), tenantID, userID, ledgerIDs[1], debits[1].Entry.EntryID(), mustPositiveAmount(test, 20), mustIdempotencyKey(test, "refund-direct"), mustMetadata(test, "{}")); err != nil {
+		test.Fatalf("direct refund: %v", err)
+	}
+	refunds, err := service.RefundAcrossLedgers(context.Background(), tenantID, userID, ledgerIDs, spendKey, mustPositiveAmount(test, 10), mustIdempotencyKey(test, "refund-1"), mustMetadata(test, "{}"))
+	if err != nil {
+		test.Fatalf("refund across ledgers: %v", err)
+	}
+	if len(refunds) != 1 || refunds[0].LedgerID != ledgerIDs[0] {
+		test.Fatalf("expected only the promo ledger to be refunded, got %+v", refunds)
+	}
+}
+
+func TestRefundAcrossLedgersReturnsStoreErrors(test *testing.T) {
+	test.Parallel()
+	storeError := errors.New("store failure")
+	tenantID := mustTenantID(test, defaultTenantIDValue)
+	userID := mustUserID(test, "user-1")
+	ledgerIDs := []LedgerID{mustLedgerID(test, promoLedgerIDValue)}
+	spendKey := mustIdempotencyKey(test, "spend-1")
+
+	insertFailure := newMultiLedgerStore(test, map[string]int64{promoLedgerIDValue: 50})
+	service := mustNewService(test, insertFailure)
+	if _, err := service.SpendAcrossLedgers(context.Background(), tenantID, userID, ledgerIDs, mustPositiveAmount(test, 10), spendKey, mustMetadata(test, "{}")); err != nil {
+		test.Fatalf("spend across ledgers: %v", err)
+	}
+	promoStore := insertFailure.ledger(test, promoLedgerIDValue)
+	promoStore.insertEntryError = storeError
+	if _, err := service.RefundAcrossLedgers(context.Background(), tenantID, userID, ledgerIDs, spendKey, mustPositiveAmount(test, 5), mustIdempotencyKey(test, "refund-1"), mustMetadata(test, "{}")); !errors.Is(err, storeError) {
+		test.Fatalf("expected insert error, got %v", err)
+	}
+
+	failing := newFailingStore(test, storeError)
+	failingService := mustNewService(test, failing)
+	if _, err := failingService.RefundAcrossLedgers(context.Background(), tenantID, userID, ledgerIDs, spendKey, mustPositiveAmount(test, 5), mustIdempotencyKey(test, "refund-1"), mustMetadata(test, "{}")); !errors.Is(err, storeError) {
+		test.Fatalf("expected lookup error, got %v", err)
+	}
+	if _, err := failingService.SpendAcrossLedgers(context.Background(), tenantID, userID, ledgerIDs, mustPositiveAmount(test, 5), spendKey, mustMetadata(test, "{}")); !errors.Is(err, storeError) {
+		test.Fatalf("expected lookup error for spend, got %v", err)
+	}
+}
+
+func TestRefundAcrossLedgersReturnsOriginalLookupAndLimitErrors(test *testing.T) {
+	test.Parallel()
+	storeError := errors.New("store failure")
+	tenantID := mustTenantID(test, defaultTenantIDValue)
+	userID := mustUserID(test, "user-1")
+	ledgerIDs := []LedgerID{mustLedgerID(test, promoLedgerIDValue)}
+	spendKey := mustIdempotencyKey(test, "spend-1")
+	refundKey := mustIdempotencyKey(test, "refund-1")
+	testCases := []struct {
+		name      string
+		options   []ServiceOption
+		configure func(store *multiLedgerStore)
+		metadata  MetadataJSON
+		expected  error
+	}{
+		{
+			name: "archived refund key",
+			configure: func(store *multiLedgerStore) {
+				store.getEntryByKeyErrors = map[IdempotencyKey]error{refundKey: ErrArchivedEntry}
+			},
+			metadata: mustMetadata(test, "{}"),
+			expected: ErrDuplicateIdempotencyKey,
+		},
+		{
+			name: "original lookup",
+			configure: func(store *multiLedgerStore) {
+				store.getEntryByKeyErrors = map[IdempotencyKey]error{spendKey: storeError}
+			},
+			metadata: mustMetadata(test, "{}"),
+			expected: storeError,
+		},
+		{name: "refunded sum", configure: func(store *multiLedgerStore) { store.sumRefundsError = storeError }, metadata: mustMetadata(test, "{}"), expected: storeError},
+		{
+			name:      "balance limit",
+			options:   []ServiceOption{WithBalanceLimits(mustBalanceLimit(test, promoLedgerIDValue, 1000))},
+			configure: func(store *multiLedgerStore) { store.ledger(test, promoLedgerIDValue).sumTotalError = storeError },
+			metadata:  mustMetadata(test, "{}"),
+			expected:  storeError,
+		},
+		{name: "entry input", configure: func(store *multiLedgerStore) {}, expected: ErrInvalidMetadataJSON},
+	}
+	for _, testCase := range testCases {
+		test.Run(testCase.name, func(test *testing.T) {
+			test.Parallel()
+			store := newMultiLedgerStore(test, map[string]int64{promoLedgerIDValue: 50})
+			service, err := NewService(store, func() int64 { return 100 }, testCase.options...)
+			if err != nil {
+				test.Fatalf("new service: %v", err)
+			}
+			if _, err := service.SpendAcrossLedgers(context.Background(), tenantID, userID, ledgerIDs, mustPositiveAmount(test, 10), spendKey, mustMetadata(test, "{}")); err != nil {
+				test.Fatalf("spend across ledgers: %v", err)
+			}
+			testCase.configure(store)
+			_, err = service.RefundAcrossLedgers(context.Background(), tenantID, userID, ledgerIDs, spendKey, mustPositiveAmount(test, 5), refundKey, testCase.metadata)
+			if !errors.Is(err, testCase.expected) {
+				test.Fatalf("expected %v, got %v", testCase.expected, err)
+			}
+		})
+	}
+}
+
+func TestRefundAcrossLedgersKeepsCapturedReservation(test *testing.T) {
+	test.Parallel()
+	store := newMultiLedgerStore(test, map[string]int64{promoLedgerIDValue: 50})
+	service := mustNewService(test, store)
+	tenantID := mustTenantID(test, defaultTenantIDValue)
+	userID := mustUserID(test, "user-1")
+	ledgerIDs := []LedgerID{mustLedgerID(test, promoLedgerIDValue)}
+	reservationID := mustReservationID(test, "order-1")
+	if err := service.Reserve(context.Background(), tenantID, userID, ledgerIDs[0], mustPositiveAmount(test, 20), reservationID, mustIdempotencyKey(test, "reserve-1"), 0, mustMetadata(test, "{}")); err != nil {
+		test.Fatalf("reserve: %v", err)
+	}
+	if err := service.Capture(context.Background(), tenantID, userID, ledgerIDs[0], reservationID, mustIdempotencyKey(test, "capture-1"), mustPositiveAmount(test, 20), mustMetadata(test, "{}")); err != nil {
+		test.Fatalf("capture: %v", err)
+	}
+	captureSpendKey, err := service.deriveKeyFn(mustIdempotencyKey(test, "capture-1"), idempotencySuffixSpend)
+	if err != nil {
+		test.Fatalf("derive capture spend key: %v", err)
+	}
+	refunds, err := service.RefundAcrossLedgers(context.Background(), tenantID, userID, ledgerIDs, captureSpendKey, mustPositiveAmount(test, 5), mustIdempotencyKey(test, "refund-1"), mustMetadata(test, "{}"))
+	if err != nil {
+		test.Fatalf("refund across ledgers: %v", err)
+	}
+	if len(refunds) != 1 {
+		test.Fatalf("expected 1 refund, got %d", len(refunds))
+	}
+	if refundReservationID, ok := refunds[0].Entry.ReservationID(); !ok || refundReservationID != reservationID {
+		test.Fatalf("expected the refund to keep reservation %s, got %s (%t)", reservationID.String(), refundReservationID.String(), ok)
+	}
+}
+
+func TestSpendAcrossLedgersRejectsInvalidEntryInput(test *testing.T) {
+	test.Parallel()
+	store := newMultiLedgerStore(test, map[string]int64{promoLedgerIDValue: 50})
+	service := mustNewService(test, store)
+	ledgerIDs := []LedgerID{mustLedgerID(test, promoLedgerIDValue)}
+	_, err := service.SpendAcrossLedgers(context.Background(), mustTenantID(test, defaultTenantIDValue), mustUserID(test, "user-1"), ledgerIDs, mustPositiveAmount(test, 10), mustIdempotencyKey(test, "spend-1"), MetadataJSON{})
+	if !errors.Is(err, ErrInvalidMetadataJSON) {
+		test.Fatalf("expected invalid metadata, got %v", err)
+	}
+	if store.ledger(test, promoLedgerIDValue).total != 50 {
+		test.Fatalf("expected the failed spend to leave the total at 50, got %d", store.ledger(test, promoLedgerIDValue).total)
+	}
+}
+
+// multiLedgerStore routes each ledger of a user to its own stubStore account.
+type multiLedgerStore struct {
+	*stubStore
+	ledgers             map[LedgerID]*stubStore
+	getAccountError     error
+	getEntryByKeyErrors map[IdempotencyKey]error
+	sumRefundsError     error
+}
+
+func newMultiLedgerStore(test *testing.T, totals map[string]int64) *multiLedgerStore {
+	test.Helper()
+	store := &multiLedgerStore{stubStore: newStubStore(test, 0), ledgers: make(map[LedgerID]*stubStore, len(totals))}
+	for rawLedgerID, total := range totals {
+		ledgerStore := newStubStore(test, mustSignedAmount(test, total))
+		ledgerStore.accountID = mustAccountID(test, "acct-"+rawLedgerID)
+		store.ledgers[mustLedgerID(test, rawLedgerID)] = ledgerStore
+	}
+	return store
+}
+
+func (store *multiLedgerStore) ledger(test *testing.T, rawLedgerID string) *stubStore {
+	test.Helper()
+	ledgerStore, ok := store.ledgers[mustLedgerID(test, rawLedgerID)]
+	if !ok {
+		test.Fatalf("ledger %s not configured", rawLedgerID)
+	}
+	return ledgerStore
+}
+
+func (store *multiLedgerStore) accountStore(accountID AccountID) *stubStore {
+	for _, ledgerStore := range store.ledgers {
+		if ledgerStore.accountID == accountID {
+			return ledgerStore
+		}
+	}
+	return store.stubStore
+}
+
+func (store *multiLedgerStore) WithTx(ctx context.Context, fn func(ctx context.Context, txStore Store) error) error {
+	snapshots := make(map[LedgerID]*stubStore, len(store.ledgers))
+	for ledgerID, ledgerStore := range store.ledgers {
+		snapshots[ledgerID] = ledgerStore.clone()
+	}
+	journalLines := append([]JournalLine(nil), store.journalLines...)
+	changedEntryIDs := append([]EntryID(nil), store.changedEntryIDs...)
+	outboxEvents := append([]OutboxEvent(nil), store.outboxEvents...)
+	if err := fn(ctx, store); err != nil {
+		for ledgerID, snapshot := range snapshots {
+			store.ledgers[ledgerID].applyTransaction(snapshot)
+		}
+		store.journalLines = journalLines
+		store.changedEntryIDs = changedEntryIDs
+		store.outboxEvents = outboxEvents
+		return err
+	}
+	return nil
+}
+
+func (store *multiLedgerStore) GetOrCreateAccountID(ctx context.Context, tenantID TenantID, userID UserID, ledgerID LedgerID) (AccountID, error) {
+	if store.getAccountError != nil {
+		return AccountID{}, store.getAccountError
+	}
+	ledgerStore, ok := store.ledgers[ledgerID]
+	if !ok {
+		ledgerStore = store.stubStore.clone()
+		ledgerStore.accountID = AccountID{value: "acct-" + ledgerID.String()}
+		store.ledgers[ledgerID] = ledgerStore
+	}
+	return ledgerStore.accountID, nil
+}
+
+func (store *multiLedgerStore) GetAccountID(ctx context.Context, tenantID TenantID, userID UserID, ledgerID LedgerID) (AccountID, error) {
+	if store.getAccountError != nil {
+		return AccountID{}, store.getAccountError
+	}
+	ledgerStore, ok := store.ledgers[ledgerID]
+	if !ok {
+		return AccountID{}, ErrUnknownAccount
+	}
+	return ledgerStore.accountID, nil
+}
+
+func (store *multiLedgerStore) InsertEntry(ctx context.Context, entryInput EntryInput) (Entry, error) {
+	return store.accountStore(entryInput.AccountID()).InsertEntry(ctx, entryInput)
+}
+
+func (store *multiLedgerStore) GetEntry(ctx context.Context, accountID AccountID, entryID EntryID) (Entry, error) {
+	return store.accountStore(accountID).GetEntry(ctx, accountID, entryID)
+}
+
+func (store *multiLedgerStore) GetEntryByIdempotencyKey(ctx context.Context, accountID AccountID, idempotencyKey IdempotencyKey) (Entry, error) {
+	if err := store.getEntryByKeyErrors[idempotencyKey]; err != nil {
+		return Entry{}, err
+	}
+	return store.accountStore(accountID).GetEntryByIdempotencyKey(ctx, accountID, idempotencyKey)
+}
+
+func (store *multiLedgerStore) SumRefunds(ctx context.Context, accountID AccountID, originalEntryID EntryID) (AmountCents, error) {
+	if store.sumRefundsError != nil {
+		return 0, store.sumRefundsError
+	}
+	return store.accountStore(accountID).SumRefunds(ctx, accountID, originalEntryID)
+}
+
+func (store *multiLedgerStore) SumTotal(ctx context.Context, accountID AccountID, atUnixUTC int64) (SignedAmountCents, error) {
+	return store.accountStore(accountID).SumTotal(ctx, accountID, atUnixUTC)
+}
+
+func (store *multiLedgerStore) SumActiveHolds(ctx context.Context, accountID AccountID, atUnixUTC int64) (AmountCents, error) {
+	return store.accountStore(accountID).SumActiveHolds(ctx, accountID, atUnixUTC)
+}
+
+func (store *multiLedgerStore) SumDebitVelocity(ctx context.Context, accountID AccountID, sinceUnixUTC int64) (DebitVelocity, error) {
+	return store.accountStore(accountID).SumDebitVelocity(ctx, accountID, sinceUnixUTC)
+}
@@ -15,8 +15,8 @@ func (service *Service) RefundByEntryID(ctx context.Context, tenantID TenantID,
 func (service *Service) RefundByEntryIDEntry(ctx context.Context, tenantID TenantID, userID UserID, ledgerID LedgerID, originalEntryID EntryID, amount PositiveAmountCents, idempotencyKey IdempotencyKey, metadata MetadataJSON) (Entry, error) {
 	var reservationRef *ReservationID
 	var persistedEntry Entry
-	operationError := service.store.WithTx(ctx, func(ctx context.Context, transactionStore Store) error {
-		accountID, err := transactionStore.GetOrCreateAccountID(ctx, tenantID, userID, ledgerID)
+	operationError := service.withTx(ctx, func(ctx context.Context, transactionStore Store) error {
+		accountID, err := service.resolveAccountID(ctx, transactionStore, tenantID, userID, ledgerID)
 		if err != nil {
 			return err
 		}
@@ -29,6 +29,9 @@ func (service *Service) RefundByEntryIDEntry(ctx context.Context, tenantID Tenan
 			persistedEntry = existingEntry
 			return nil
 		}
+		if errors.Is(err, ErrArchivedEntry) {
+			return ErrDuplicateIdempotencyKey
+		}
 		if !errors.Is(err, ErrUnknownEntry) {
 			return err
 		}
@@ -57,6 +60,10 @@ func (service *Service) RefundByEntryIDEntry(ctx context.Context, tenantID Tenan
 			return ErrRefundExceedsDebit
 		}
 
+		nowUnixUTC := service.nowFn()
+		if err := service.checkBalanceLimit(ctx, transactionStore, tenantID, ledgerID, accountID, amount.Int64(), nowUnixUTC, 0); err != nil {
+			return err
+		}
 		refundOfEntryID := originalEntry.EntryID()
 		entryInput, err := NewEntryInput(
 			accountID,
@@ -67,12 +74,12 @@ func (service *Service) RefundByEntryIDEntry(ctx context.Context, tenantID Tenan
 			idempotencyKey,
 			0,
 			metadata,
-			service.nowFn(),
+			nowUnixUTC,
 		)
 		if err != nil {
 			return err
 		}
-		persistedEntry, err = transactionStore.InsertEntry(ctx, entryInput)
+		persistedEntry, err = service.insertEntry(ctx, transactionStore, tenantID, userID, ledgerID, entryInput)
 		if errors.Is(err, ErrDuplicateIdempotencyKey) {
 			existingEntry, lookupErr := transactionStore.GetEntryByIdempotencyKey(ctx, accountID, idempotencyKey)
 			if lookupErr != nil {
@@ -114,8 +121,8 @@ func (service *Service) RefundByOriginalIdempotencyKey(ctx context.Context, tena
 // RefundByOriginalIdempotencyKeyEntry appends a refund credit for an original debit entry referenced by its idempotency key and returns the persisted refund entry.
 func (service *Service) RefundByOriginalIdempotencyKeyEntry(ctx context.Context, tenantID TenantID, userID UserID, ledgerID LedgerID, originalIdempotencyKey IdempotencyKey, amount PositiveAmountCents, idempotencyKey IdempotencyKey, metadata MetadataJSON) (Entry, error) {
 	var originalEntryID EntryID
-	operationError := service.store.WithTx(ctx, func(ctx context.Context, transactionStore Store) error {
-		accountID, err := transactionStore.GetOrCreateAccountID(ctx, tenantID, userID, ledgerID)
+	operationError := service.withTx(ctx, func(ctx context.Context, transactionStore Store) error {
+		accountID, err := service.resolveAccountID(ctx, transactionStore, tenantID, userID, ledgerID)
 		if err != nil {
 			return err
 		}
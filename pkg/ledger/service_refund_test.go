@@ -470,6 +470,14 @@ func (store *insertDuplicateRefundStore) GetOrCreateAccountID(ctx context.Contex
 	return store.accountID, nil
 }
 
+func (store *insertDuplicateRefundStore) GetAccountID(ctx context.Context, tenantID TenantID, userID UserID, ledgerID LedgerID) (AccountID, error) {
+	return store.accountID, nil
+}
+
+func (store *insertDuplicateRefundStore) ListAccounts(ctx context.Context, tenantID TenantID, beforeCreatedUnixUTC int64, limit int, filter ListAccountsFilter) ([]Account, error) {
+	return nil, nil
+}
+
 func (store *insertDuplicateRefundStore) InsertEntry(ctx context.Context, entry EntryInput) (Entry, error) {
 	return Entry{}, ErrDuplicateIdempotencyKey
 }
@@ -507,6 +515,58 @@ func (store *insertDuplicateRefundStore) SumActiveHolds(ctx context.Context, acc
 	return AmountCents(0), nil
 }
 
+func (store *insertDuplicateRefundStore) SumDebitVelocity(ctx context.Context, accountID AccountID, sinceUnixUTC int64) (DebitVelocity, error) {
+	return DebitVelocity{}, nil
+}
+
+func (store *insertDuplicateRefundStore) InsertJournalLines(ctx context.Context, lines []JournalLine) error {
+	return nil
+}
+
+func (store *insertDuplicateRefundStore) SumJournalLines(ctx context.Context, tenantID TenantID, atUnixUTC int64) ([]TrialBalanceLine, error) {
+	return nil, nil
+}
+
+func (store *insertDuplicateRefundStore) SumEntriesByExpiry(ctx context.Context, tenantID TenantID, atUnixUTC int64) ([]ExpiryTotal, error) {
+	return nil, nil
+}
+
+func (store *insertDuplicateRefundStore) ListConsumableGrants(ctx context.Context, accountID AccountID, atUnixUTC int64) ([]Entry, error) {
+	return nil, nil
+}
+
+func (store *insertDuplicateRefundStore) ConsumeGrant(ctx context.Context, accountID AccountID, entryID EntryID, amountCents AmountCents) error {
+	return nil
+}
+
+func (store *insertDuplicateRefundStore) AppendEntryChange(ctx context.Context, tenantID TenantID, entryID EntryID) error {
+	return nil
+}
+
+func (store *insertDuplicateRefundStore) ListEntryChanges(ctx context.Context, tenantID TenantID, afterCursor int64, limit int, filter EntryChangeFilter) ([]EntryChange, error) {
+	return nil, nil
+}
+
+func (store *insertDuplicateRefundStore) InsertOutboxEvent(ctx context.Context, event OutboxEvent) error {
+	return nil
+}
+
+func (store *insertDuplicateRefundStore) LockChainHead(ctx context.Context, accountID AccountID) (ChainHead, error) {
+	return ChainHead{}, nil
+}
+
+func (store *insertDuplicateRefundStore) AppendChainLink(ctx context.Context, accountID AccountID, link ChainLink) error {
+	return nil
+}
+
+func (store *insertDuplicateRefundStore) GetChainHead(ctx context.Context, accountID AccountID) (ChainHead, error) {
+	return ChainHead{}, nil
+}
+
+func (store *insertDuplicateRefundStore) ListChainedEntries(ctx context.Context, accountID AccountID, afterSequence int64, limit int) ([]ChainedEntry, error) {
+	return nil, nil
+}
+
 func (store *insertDuplicateRefundStore) CreateReservation(ctx context.Context, reservation Reservation) error {
 	return nil
 }
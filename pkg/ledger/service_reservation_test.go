@@ -3,6 +3,7 @@ package ledger
 import (
 	"context"
 	"errors"
+	"slices"
 	"testing"
 )
 
@@ -381,12 +382,25 @@ type stubStore struct {
 	total                  SignedAmountCents
 	reservations           map[ReservationID]Reservation
 	entries                []EntryInput
+	consumedGrants         map[EntryID]int64
+	journalLines           []JournalLine
+	changedEntryIDs        []EntryID
+	outboxEvents           []OutboxEvent
+	entryChanges           []EntryChange
+	chainLinks             []ChainLink
+	entryChangeFilter      EntryChangeFilter
+	expiryTotals           []ExpiryTotal
+	expiryTotalsError      error
 	listEntries            []Entry
 	listErr                error
 	idempotency            map[IdempotencyKey]struct{}
 	getAccountError        error
+	accountMissing         bool
+	accounts               []Account
+	listAccountsFilter     ListAccountsFilter
 	sumTotalError          error
 	sumActiveHoldsError    error
+	sumDebitVelocityError  error
 	createReservationError error
 	getReservationError    error
 	updateReservationError error
@@ -426,6 +440,14 @@ func (store *stubStore) clone() *stubStore {
 	}
 
 	clone.entries = append([]EntryInput(nil), store.entries...)
+	clone.consumedGrants = make(map[EntryID]int64, len(store.consumedGrants))
+	for entryID, consumed := range store.consumedGrants {
+		clone.consumedGrants[entryID] = consumed
+	}
+	clone.journalLines = append([]JournalLine(nil), store.journalLines...)
+	clone.changedEntryIDs = append([]EntryID(nil), store.changedEntryIDs...)
+	clone.outboxEvents = append([]OutboxEvent(nil), store.outboxEvents...)
+	clone.chainLinks = append([]ChainLink(nil), store.chainLinks...)
 	clone.listEntries = append([]Entry(nil), store.listEntries...)
 
 	clone.idempotency = make(map[IdempotencyKey]struct{}, len(store.idempotency))
@@ -440,6 +462,11 @@ func (store *stubStore) applyTransaction(transactionStore *stubStore) {
 	store.total = transactionStore.total
 	store.reservations = transactionStore.reservations
 	store.entries = transactionStore.entries
+	store.consumedGrants = transactionStore.consumedGrants
+	store.journalLines = transactionStore.journalLines
+	store.changedEntryIDs = transactionStore.changedEntryIDs
+	store.outboxEvents = transactionStore.outboxEvents
+	store.chainLinks = transactionStore.chainLinks
 	store.listEntries = transactionStore.listEntries
 	store.listErr = transactionStore.listErr
 	store.idempotency = transactionStore.idempotency
@@ -453,6 +480,24 @@ func (store *stubStore) GetOrCreateAccountID(ctx context.Context, tenantID Tenan
 	return store.accountID, nil
 }
 
+func (store *stubStore) GetAccountID(ctx context.Context, tenantID TenantID, userID UserID, ledgerID LedgerID) (AccountID, error) {
+	if store.getAccountError != nil {
+		return AccountID{}, store.getAccountError
+	}
+	if store.accountMissing {
+		return AccountID{}, ErrUnknownAccount
+	}
+	return store.accountID, nil
+}
+
+func (store *stubStore) ListAccounts(ctx context.Context, tenantID TenantID, beforeCreatedUnixUTC int64, limit int, filter ListAccountsFilter) ([]Account, error) {
+	if store.getAccountError != nil {
+		return nil, store.getAccountError
+	}
+	store.listAccountsFilter = filter
+	return store.accounts, nil
+}
+
 func (store *stubStore) InsertEntry(ctx context.Context, entryInput EntryInput) (Entry, error) {
 	store.insertEntryCallCount++
 	if store.insertEntryError != nil {
@@ -466,7 +511,7 @@ func (store *stubStore) InsertEntry(ctx context.Context, entryInput EntryInput)
 	store.idempotency[entryInput.IdempotencyKey()] = struct{}{}
 	store.entries = append(store.entries, entryInput)
 	switch entryInput.Type() {
-	case EntryGrant, EntrySpend, EntryRefund:
+	case EntryGrant, EntrySpend, EntryRefund, EntryGrantCancel:
 		store.total = applyEntryDelta(store.total, entryInput.AmountCents())
 	}
 	entryID, err := NewEntryID(entryInput.IdempotencyKey().String())
@@ -496,7 +541,33 @@ func (store *stubStore) InsertEntry(ctx context.Context, entryInput EntryInput)
 	if err != nil {
 		return Entry{}, err
 	}
-	return entry, nil
+	return entry.WithEffectiveAtUnixUTC(entryInput.EffectiveAtUnixUTC()).WithActor(entryInput.Actor()), nil
+}
+
+func (store *stubStore) ListConsumableGrants(ctx context.Context, accountID AccountID, atUnixUTC int64) ([]Entry, error) {
+	var grants []Entry
+	for _, entryInput := range store.entries {
+		entry, err := store.materializeEntry(entryInput)
+		if err != nil {
+			return nil, err
+		}
+		if entry.Type() != EntryGrant || entry.ExpiresAtUnixUTC() <= atUnixUTC || entry.IsPendingAt(atUnixUTC) || entry.ConsumedCents().Int64() >= entry.AmountCents().Int64() {
+			continue
+		}
+		grants = append(grants, entry)
+	}
+	slices.SortStableFunc(grants, func(left, right Entry) int {
+		return int(left.ExpiresAtUnixUTC() - right.ExpiresAtUnixUTC())
+	})
+	return grants, nil
+}
+
+func (store *stubStore) ConsumeGrant(ctx context.Context, accountID AccountID, entryID EntryID, amountCents AmountCents) error {
+	if store.consumedGrants == nil {
+		store.consumedGrants = make(map[EntryID]int64)
+	}
+	store.consumedGrants[entryID] += amountCents.Int64()
+	return nil
 }
 
 func (store *stubStore) GetEntry(ctx context.Context, accountID AccountID, entryID EntryID) (Entry, error) {
@@ -568,14 +639,22 @@ func (store *stubStore) materializeEntry(entryInput EntryInput) (Entry, error) {
 	if err != nil {
 		return Entry{}, err
 	}
-	return entry, nil
+	return entry.WithEffectiveAtUnixUTC(entryInput.EffectiveAtUnixUTC()).WithActor(entryInput.Actor()).WithConsumedCents(AmountCents(store.consumedGrants[entryID])), nil
 }
 
-func (store *stubStore) SumTotal(ctx context.Context, accountID AccountID, _ int64) (SignedAmountCents, error) {
+// SumTotal leaves out entries that take effect after atUnixUTC or have expired by then.
+func (store *stubStore) SumTotal(ctx context.Context, accountID AccountID, atUnixUTC int64) (SignedAmountCents, error) {
 	if store.sumTotalError != nil {
 		return SignedAmountCents(0), store.sumTotalError
 	}
-	return store.total, nil
+	total := store.total
+	for _, entryInput := range store.entries {
+		expired := entryInput.ExpiresAtUnixUTC() != 0 && entryInput.ExpiresAtUnixUTC() <= atUnixUTC
+		if entryInput.EffectiveAtUnixUTC() > atUnixUTC || expired {
+			total -= SignedAmountCents(entryInput.AmountCents().Int64())
+		}
+	}
+	return total, nil
 }
 
 func (store *stubStore) SumActiveHolds(ctx context.Context, accountID AccountID, _ int64) (AmountCents, error) {
@@ -591,6 +670,131 @@ func (store *stubStore) SumActiveHolds(ctx context.Context, accountID AccountID,
 	return NewAmountCents(sum)
 }
 
+func (store *stubStore) SumDebitVelocity(ctx context.Context, accountID AccountID, sinceUnixUTC int64) (DebitVelocity, error) {
+	if store.sumDebitVelocityError != nil {
+		return DebitVelocity{}, store.sumDebitVelocityError
+	}
+	var velocity DebitVelocity
+	var net int64
+	windowHolds := make(map[ReservationID]struct{})
+	for _, entryInput := range store.entries {
+		if reservationID, ok := entryInput.ReservationID(); ok && entryInput.Type() == EntryHold && entryInput.CreatedUnixUTC() >= sinceUnixUTC {
+			windowHolds[reservationID] = struct{}{}
+		}
+	}
+	for _, entryInput := range store.entries {
+		if entryInput.CreatedUnixUTC() < sinceUnixUTC {
+			continue
+		}
+		reservationID, hasReservation := entryInput.ReservationID()
+		switch entryInput.Type() {
+		case EntryHold:
+			velocity.Operations++
+		case EntrySpend:
+			if !hasReservation {
+				velocity.Operations++
+			}
+		case EntryReverseHold:
+			if _, ok := windowHolds[reservationID]; !ok {
+				continue
+			}
+		default:
+			continue
+		}
+		net += entryInput.AmountCents().Int64()
+	}
+	velocity.AmountCents = AmountCents(max(-net, 0))
+	return velocity, nil
+}
+
+func (store *stubStore) InsertJournalLines(ctx context.Context, lines []JournalLine) error {
+	store.journalLines = append(store.journalLines, lines...)
+	return nil
+}
+
+func (store *stubStore) SumJournalLines(ctx context.Context, tenantID TenantID, atUnixUTC int64) ([]TrialBalanceLine, error) {
+	var balances []TrialBalanceLine
+	for _, line := range store.journalLines {
+		if line.TenantID != tenantID || line.PostedUnixUTC > atUnixUTC {
+			continue
+		}
+		index := slices.IndexFunc(balances, func(balance TrialBalanceLine) bool {
+			return balance.LedgerID == line.LedgerID && balance.Account == line.Account
+		})
+		if index < 0 {
+			balances = append(balances, TrialBalanceLine{LedgerID: line.LedgerID, Account: line.Account})
+			index = len(balances) - 1
+		}
+		balances[index].BalanceCents += line.AmountCents
+	}
+	return balances, nil
+}
+
+func (store *stubStore) SumEntriesByExpiry(ctx context.Context, tenantID TenantID, atUnixUTC int64) ([]ExpiryTotal, error) {
+	if store.expiryTotalsError != nil {
+		return nil, store.expiryTotalsError
+	}
+	return store.expiryTotals, nil
+}
+
+func (store *stubStore) AppendEntryChange(ctx context.Context, tenantID TenantID, entryID EntryID) error {
+	store.changedEntryIDs = append(store.changedEntryIDs, entryID)
+	return nil
+}
+
+func (store *stubStore) ListEntryChanges(ctx context.Context, tenantID TenantID, afterCursor int64, limit int, filter EntryChangeFilter) ([]EntryChange, error) {
+	store.entryChangeFilter = filter
+	changes := make([]EntryChange, 0, len(store.entryChanges))
+	for _, change := range store.entryChanges {
+		if change.Cursor > afterCursor && len(changes) < limit {
+			changes = append(changes, change)
+		}
+	}
+	return changes, nil
+}
+
+func (store *stubStore) InsertOutboxEvent(ctx context.Context, event OutboxEvent) error {
+	store.outboxEvents = append(store.outboxEvents, event)
+	return nil
+}
+
+func (store *stubStore) LockChainHead(ctx context.Context, accountID AccountID) (ChainHead, error) {
+	return store.GetChainHead(ctx, accountID)
+}
+
+func (store *stubStore) AppendChainLink(ctx context.Context, accountID AccountID, link ChainLink) error {
+	store.chainLinks = append(store.chainLinks, link)
+	return nil
+}
+
+func (store *stubStore) GetChainHead(ctx context.Context, accountID AccountID) (ChainHead, error) {
+	if len(store.chainLinks) == 0 {
+		return ChainHead{}, nil
+	}
+	last := store.chainLinks[len(store.chainLinks)-1]
+	return ChainHead{Sequence: last.Sequence, Hash: last.Hash}, nil
+}
+
+func (store *stubStore) ListChainedEntries(ctx context.Context, accountID AccountID, afterSequence int64, limit int) ([]ChainedEntry, error) {
+	chained := make([]ChainedEntry, 0, len(store.chainLinks))
+	for _, link := range store.chainLinks {
+		if link.Sequence <= afterSequence || len(chained) == limit {
+			continue
+		}
+		for _, entryInput := range store.entries {
+			entry, err := store.materializeEntry(entryInput)
+			if err != nil {
+				return nil, err
+			}
+			if entry.EntryID() == link.EntryID {
+				chained = append(chained, ChainedEntry{Entry: entry, Link: link})
+				break
+			}
+		}
+	}
+	return chained, nil
+}
+
 func (store *stubStore) CreateReservation(ctx context.Context, reservation Reservation) error {
 	if store.createReservationError != nil {
 		return store.createReservationError
@@ -869,6 +1073,14 @@ func (store *failingStore) GetOrCreateAccountID(ctx context.Context, tenantID Te
 	return store.accountID, nil
 }
 
+func (store *failingStore) GetAccountID(ctx context.Context, tenantID TenantID, userID UserID, ledgerID LedgerID) (AccountID, error) {
+	return store.accountID, nil
+}
+
+func (store *failingStore) ListAccounts(ctx context.Context, tenantID TenantID, beforeCreatedUnixUTC int64, limit int, filter ListAccountsFilter) ([]Account, error) {
+	return nil, store.err
+}
+
 func (store *failingStore) InsertEntry(ctx context.Context, entry EntryInput) (Entry, error) {
 	return Entry{}, store.err
 }
@@ -893,6 +1105,58 @@ func (store *failingStore) SumActiveHolds(ctx context.Context, accountID Account
 	return store.activeHolds, nil
 }
 
+func (store *failingStore) SumDebitVelocity(ctx context.Context, accountID AccountID, sinceUnixUTC int64) (DebitVelocity, error) {
+	return DebitVelocity{}, nil
+}
+
+func (store *failingStore) InsertJournalLines(ctx context.Context, lines []JournalLine) error {
+	return nil
+}
+
+func (store *failingStore) SumJournalLines(ctx context.Context, tenantID TenantID, atUnixUTC int64) ([]TrialBalanceLine, error) {
+	return nil, store.err
+}
+
+func (store *failingStore) SumEntriesByExpiry(ctx context.Context, tenantID TenantID, atUnixUTC int64) ([]ExpiryTotal, error) {
+	return nil, store.err
+}
+
+func (store *failingStore) ListConsumableGrants(ctx context.Context, accountID AccountID, atUnixUTC int64) ([]Entry, error) {
+	return nil, store.err
+}
+
+func (store *failingStore) ConsumeGrant(ctx context.Context, accountID AccountID, entryID EntryID, amountCents AmountCents) error {
+	return store.err
+}
+
+func (store *failingStore) AppendEntryChange(ctx context.Context, tenantID TenantID, entryID EntryID) error {
+	return nil
+}
+
+func (store *failingStore) ListEntryChanges(ctx context.Context, tenantID TenantID, afterCursor int64, limit int, filter EntryChangeFilter) ([]EntryChange, error) {
+	return nil, store.err
+}
+
+func (store *failingStore) InsertOutboxEvent(ctx context.Context, event OutboxEvent) error {
+	return nil
+}
+
+func (store *failingStore) LockChainHead(ctx context.Context, accountID AccountID) (ChainHead, error) {
+	return ChainHead{}, nil
+}
+
+func (store *failingStore) AppendChainLink(ctx context.Context, accountID AccountID, link ChainLink) error {
+	return nil
+}
+
+func (store *failingStore) GetChainHead(ctx context.Context, accountID AccountID) (ChainHead, error) {
+	return ChainHead{}, store.err
+}
+
+func (store *failingStore) ListChainedEntries(ctx context.Context, accountID AccountID, afterSequence int64, limit int) ([]ChainedEntry, error) {
+	return nil, store.err
+}
+
 func (store *failingStore) CreateReservation(ctx context.Context, reservation Reservation) error {
 	return nil
 }
@@ -17,7 +17,10 @@ type ReservationState struct {
 
 // GetReservationState returns the computed state for a reservation.
 func (service *Service) GetReservationState(ctx context.Context, tenantID TenantID, userID UserID, ledgerID LedgerID, reservationID ReservationID) (ReservationState, error) {
-	accountID, err := service.store.GetOrCreateAccountID(ctx, tenantID, userID, ledgerID)
+	accountID, err := service.lookupAccountID(ctx, service.store, tenantID, userID, ledgerID)
+	if service.isLenientUnknownAccount(err) {
+		return ReservationState{}, ErrUnknownReservation
+	}
 	if err != nil {
 		return ReservationState{}, err
 	}
@@ -31,7 +34,10 @@ func (service *Service) GetReservationState(ctx context.Context, tenantID Tenant
 
 // ListReservationStates returns the computed states for reservations matching the supplied filters.
 func (service *Service) ListReservationStates(ctx context.Context, tenantID TenantID, userID UserID, ledgerID LedgerID, beforeCreatedUnixUTC int64, limit int, filter ListReservationsFilter) ([]ReservationState, error) {
-	accountID, err := service.store.GetOrCreateAccountID(ctx, tenantID, userID, ledgerID)
+	accountID, err := service.lookupAccountID(ctx, service.store, tenantID, userID, ledgerID)
+	if service.isLenientUnknownAccount(err) {
+		return nil, nil
+	}
 	if err != nil {
 		return nil, err
 	}
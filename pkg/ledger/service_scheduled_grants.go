@@ -0,0 +1,180 @@
+package ledger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ScheduleGrant appends a positive grant that only counts toward balances from effectiveAtUnixUTC.
+func (service *Service) ScheduleGrant(ctx context.Context, tenantID TenantID, userID UserID, ledgerID LedgerID, amount PositiveAmountCents, idempotencyKey IdempotencyKey, effectiveAtUnixUTC int64, expiresAtUnixUTC int64, metadata MetadataJSON) error {
+	_, err := service.ScheduleGrantEntry(ctx, tenantID, userID, ledgerID, amount, idempotencyKey, effectiveAtUnixUTC, expiresAtUnixUTC, metadata)
+	return err
+}
+
+// ScheduleGrantEntry appends a positive grant that only counts toward balances from effectiveAtUnixUTC and returns the persisted entry.
+// An effectiveAtUnixUTC of 0 makes the grant usable immediately.
+func (service *Service) ScheduleGrantEntry(ctx context.Context, tenantID TenantID, userID UserID, ledgerID LedgerID, amount PositiveAmountCents, idempotencyKey IdempotencyKey, effectiveAtUnixUTC int64, expiresAtUnixUTC int64, metadata MetadataJSON) (Entry, error) {
+	var persistedEntry Entry
+	operationError := validateGrantSchedule(effectiveAtUnixUTC, expiresAtUnixUTC)
+	if operationError == nil {
+		operationError = service.checkGrantPolicy(tenantID, ledgerID, amount.Int64(), expiresAtUnixUTC)
+	}
+	if operationError == nil {
+		operationError = service.withTx(ctx, func(ctx context.Context, transactionStore Store) error {
+			accountID, err := service.resolveAccountID(ctx, transactionStore, tenantID, userID, ledgerID)
+			if err != nil {
+				return err
+			}
+			nowUnixUTC := service.nowFn()
+			if err := service.checkBalanceLimit(ctx, transactionStore, tenantID, ledgerID, accountID, amount.Int64(), max(nowUnixUTC, effectiveAtUnixUTC), expiresAtUnixUTC); err != nil {
+				return err
+			}
+			entryInput, err := NewEntryInput(
+				accountID,
+				EntryGrant,
+				amount.ToEntryAmountCents(),
+				nil,
+				nil,
+				idempotencyKey,
+				expiresAtUnixUTC,
+				metadata,
+				nowUnixUTC,
+			)
+			if err != nil {
+				return err
+			}
+			persistedEntry, err = service.insertEntry(ctx, transactionStore, tenantID, userID, ledgerID, entryInput.WithEffectiveAtUnixUTC(effectiveAtUnixUTC))
+			return err
+		})
+	}
+	service.logOperation(ctx, OperationLog{
+		Operation:      operationGrant,
+		TenantID:       tenantID,
+		UserID:         userID,
+		LedgerID:       ledgerID,
+		Amount:         amount.ToAmountCents(),
+		IdempotencyKey: idempotencyKey,
+		Metadata:       metadata,
+		Error:          operationError,
+	})
+	if operationError != nil {
+		return Entry{}, operationError
+	}
+	return persistedEntry, nil
+}
+
+// CancelGrant cancels a grant that has not taken effect yet.
+func (service *Service) CancelGrant(ctx context.Context, tenantID TenantID, userID UserID, ledgerID LedgerID, grantEntryID EntryID, metadata MetadataJSON) error {
+	_, err := service.CancelGrantEntry(ctx, tenantID, userID, ledgerID, grantEntryID, metadata)
+	return err
+}
+
+// CancelGrantEntry cancels a grant that has not taken effect yet and returns the persisted grant_cancel entry.
+// The cancellation mirrors the grant's amount, effective time, and expiration so the two net to zero at all times.
+// Repeating a cancellation returns the existing grant_cancel entry.
+func (service *Service) CancelGrantEntry(ctx context.Context, tenantID TenantID, userID UserID, ledgerID LedgerID, grantEntryID EntryID, metadata MetadataJSON) (Entry, error) {
+	var grantAmount AmountCents
+	var cancelKey IdempotencyKey
+	var persistedEntry Entry
+	operationError := service.withTx(ctx, func(ctx context.Context, transactionStore Store) error {
+		accountID, err := service.resolveAccountID(ctx, transactionStore, tenantID, userID, ledgerID)
+		if err != nil {
+			return err
+		}
+		grantEntry, err := transactionStore.GetEntry(ctx, accountID, grantEntryID)
+		if err != nil {
+			return err
+		}
+		if grantEntry.Type() != EntryGrant {
+			return fmt.Errorf("%w: entry is %s", ErrGrantNotCancellable, grantEntry.Type())
+		}
+		grantAmount = AmountCents(grantEntry.AmountCents().Int64())
+		cancelKey, err = service.deriveKeyFn(grantEntry.IdempotencyKey(), idempotencySuffixCancel)
+		if err != nil {
+			return err
+		}
+		existingEntry, err := transactionStore.GetEntryByIdempotencyKey(ctx, accountID, cancelKey)
+		if err == nil {
+			if existingEntry.Type() != EntryGrantCancel {
+				return fmt.Errorf("%w: existing entry is %s", ErrIdempotencyKeyConflict, existingEntry.Type())
+			}
+			persistedEntry = existingEntry
+			return nil
+		}
+		if !errors.Is(err, ErrUnknownEntry) {
+			return err
+		}
+		nowUnixUTC := service.nowFn()
+		if !grantEntry.IsPendingAt(nowUnixUTC) {
+			return fmt.Errorf("%w: grant already in effect", ErrGrantNotCancellable)
+		}
+		entryInput, err := NewEntryInput(
+			accountID,
+			EntryGrantCancel,
+			grantEntry.AmountCents().Negated(),
+			nil,
+			nil,
+			cancelKey,
+			grantEntry.ExpiresAtUnixUTC(),
+			metadata,
+			nowUnixUTC,
+		)
+		if err != nil {
+			return err
+		}
+		persistedEntry, err = service.insertEntry(ctx, transactionStore, tenantID, userID, ledgerID, entryInput.WithEffectiveAtUnixUTC(grantEntry.EffectiveAtUnixUTC()))
+		return err
+	})
+	service.logOperation(ctx, OperationLog{
+		Operation:      operationCancelGrant,
+		TenantID:       tenantID,
+		UserID:         userID,
+		LedgerID:       ledgerID,
+		Amount:         grantAmount,
+		IdempotencyKey: cancelKey,
+		Metadata:       metadata,
+		Error:          operationError,
+	})
+	if operationError != nil {
+		return Entry{}, operationError
+	}
+	return persistedEntry, nil
+}
+
+// CancelGrantByIdempotencyKey cancels a pending grant referenced by its idempotency key.
+func (service *Service) CancelGrantByIdempotencyKey(ctx context.Context, tenantID TenantID, userID UserID, ledgerID LedgerID, grantIdempotencyKey IdempotencyKey, metadata MetadataJSON) error {
+	_, err := service.CancelGrantByIdempotencyKeyEntry(ctx, tenantID, userID, ledgerID, grantIdempotencyKey, metadata)
+	return err
+}
+
+// CancelGrantByIdempotencyKeyEntry cancels a pending grant referenced by its idempotency key and returns the persisted grant_cancel entry.
+func (service *Service) CancelGrantByIdempotencyKeyEntry(ctx context.Context, tenantID TenantID, userID UserID, ledgerID LedgerID, grantIdempotencyKey IdempotencyKey, metadata MetadataJSON) (Entry, error) {
+	var grantEntryID EntryID
+	operationError := service.withTx(ctx, func(ctx context.Context, transactionStore Store) error {
+		accountID, err := service.resolveAccountID(ctx, transactionStore, tenantID, userID, ledgerID)
+		if err != nil {
+			return err
+		}
+		entry, err := transactionStore.GetEntryByIdempotencyKey(ctx, accountID, grantIdempotencyKey)
+		if err != nil {
+			return err
+		}
+		grantEntryID = entry.EntryID()
+		return nil
+	})
+	if operationError != nil {
+		return Entry{}, operationError
+	}
+	return service.CancelGrantEntry(ctx, tenantID, userID, ledgerID, grantEntryID, metadata)
+}
+
+func validateGrantSchedule(effectiveAtUnixUTC int64, expiresAtUnixUTC int64) error {
+	if effectiveAtUnixUTC < 0 {
+		return fmt.Errorf("%w: %s", ErrInvalidEffectiveAt, errorAmountZeroOrGreater)
+	}
+	if effectiveAtUnixUTC != 0 && expiresAtUnixUTC != 0 && effectiveAtUnixUTC >= expiresAtUnixUTC {
+		return fmt.Errorf("%w: %s", ErrInvalidEffectiveAt, errorBeforeExpiration)
+	}
+	return nil
+}
@@ -0,0 +1,277 @@
+package ledger
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestScheduleGrantEntryPersistsEffectiveAt(test *testing.T) {
+	test.Parallel()
+	store := newStubStore(test, 0)
+	logger := &recorderLogger{}
+	service, err := NewService(store, func() int64 { return 100 }, WithOperationLogger(logger))
+	if err != nil {
+		test.Fatalf("new service: %v", err)
+	}
+	entry, err := service.ScheduleGrantEntry(context.Background(), mustTenantID(test, defaultTenantIDValue), mustUserID(test, "user-1"), mustLedgerID(test, defaultLedgerIDValue), mustPositiveAmount(test, 500), mustIdempotencyKey(test, "allowance-2026-11"), 200, 300, mustMetadata(test, "{}"))
+	if err != nil {
+		test.Fatalf("schedule grant: %v", err)
+	}
+	if entry.Type() != EntryGrant || entry.EffectiveAtUnixUTC() != 200 || entry.ExpiresAtUnixUTC() != 300 {
+		test.Fatalf("unexpected scheduled grant: type=%s effective=%d expires=%d", entry.Type(), entry.EffectiveAtUnixUTC(), entry.ExpiresAtUnixUTC())
+	}
+	if !service.IsEntryPending(entry) {
+		test.Fatalf("expected scheduled grant to be pending at service time")
+	}
+	if len(logger.entries) != 1 || logger.entries[0].Operation != operationGrant || logger.entries[0].Status != operationStatusOK {
+		test.Fatalf("unexpected log entries: %+v", logger.entries)
+	}
+
+	immediate, err := service.GrantEntry(context.Background(), mustTenantID(test, defaultTenantIDValue), mustUserID(test, "user-1"), mustLedgerID(test, defaultLedgerIDValue), mustPositiveAmount(test, 10), mustIdempotencyKey(test, "grant-now"), 0, mustMetadata(test, "{}"))
+	if err != nil {
+		test.Fatalf("grant: %v", err)
+	}
+	if immediate.EffectiveAtUnixUTC() != 0 || service.IsEntryPending(immediate) {
+		test.Fatalf("expected immediate grant to take effect at once")
+	}
+}
+
+func TestScheduleGrantEntryValidatesEffectiveAt(test *testing.T) {
+	test.Parallel()
+	testCases := []struct {
+		name               string
+		effectiveAtUnixUTC int64
+		expiresAtUnixUTC   int64
+	}{
+		{name: "negative", effectiveAtUnixUTC: -1},
+		{name: "equal to expiration", effectiveAtUnixUTC: 300, expiresAtUnixUTC: 300},
+		{name: "after expiration", effectiveAtUnixUTC: 400, expiresAtUnixUTC: 300},
+	}
+	for _, testCase := range testCases {
+		store := newStubStore(test, 0)
+		logger := &recorderLogger{}
+		service, err := NewService(store, func() int64 { return 100 }, WithOperationLogger(logger))
+		if err != nil {
+			test.Fatalf("new service: %v", err)
+		}
+		_, err = service.ScheduleGrantEntry(context.Background(), mustTenantID(test, defaultTenantIDValue), mustUserID(test, "user-1"), mustLedgerID(test, defaultLedgerIDValue), mustPositiveAmount(test, 500), mustIdempotencyKey(test, "grant-1"), testCase.effectiveAtUnixUTC, testCase.expiresAtUnixUTC, mustMetadata(test, "{}"))
+		if !errors.Is(err, ErrInvalidEffectiveAt) {
+			test.Fatalf("%s: expected invalid effective at, got %v", testCase.name, err)
+		}
+		if len(store.entries) != 0 {
+			test.Fatalf("%s: expected no entries", testCase.name)
+		}
+		if len(logger.entries) != 1 || logger.entries[0].Status != operationStatusError {
+			test.Fatalf("%s: expected error log entry, got %+v", testCase.name, logger.entries)
+		}
+	}
+}
+
+func TestCancelGrantEntryOffsetsPendingGrant(test *testing.T) {
+	test.Parallel()
+	store := newStubStore(test, 0)
+	logger := &recorderLogger{}
+	service, err := NewService(store, func() int64 { return 100 }, WithOperationLogger(logger))
+	if err != nil {
+		test.Fatalf("new service: %v", err)
+	}
+	tenantID := mustTenantID(test, defaultTenantIDValue)
+	userID := mustUserID(test, "user-1")
+	ledgerID := mustLedgerID(test, defaultLedgerIDValue)
+	grant, err := service.ScheduleGrantEntry(context.Background(), tenantID, userID, ledgerID, mustPositiveAmount(test, 500), mustIdempotencyKey(test, "allowance"), 200, 300, mustMetadata(test, "{}"))
+	if err != nil {
+		test.Fatalf("schedule grant: %v", err)
+	}
+
+	cancelEntry, err := service.CancelGrantEntry(context.Background(), tenantID, userID, ledgerID, grant.EntryID(), mustMetadata(test, `{"reason":"plan downgraded"}`))
+	if err != nil {
+		test.Fatalf("cancel grant: %v", err)
+	}
+	if cancelEntry.Type() != EntryGrantCancel || cancelEntry.AmountCents().Int64() != -500 {
+		test.Fatalf("unexpected cancel entry: type=%s amount=%d", cancelEntry.Type(), cancelEntry.AmountCents().Int64())
+	}
+	if cancelEntry.EffectiveAtUnixUTC() != 200 || cancelEntry.ExpiresAtUnixUTC() != 300 {
+		test.Fatalf("expected cancel entry to mirror grant schedule, got effective=%d expires=%d", cancelEntry.EffectiveAtUnixUTC(), cancelEntry.ExpiresAtUnixUTC())
+	}
+	if cancelEntry.IdempotencyKey().String() != "allowance:cancel" {
+		test.Fatalf("unexpected cancel idempotency key %q", cancelEntry.IdempotencyKey().String())
+	}
+	if store.total != 0 {
+		test.Fatalf("expected grant and cancellation to net to zero, got %d", store.total)
+	}
+	lastLog := logger.entries[len(logger.entries)-1]
+	if lastLog.Operation != operationCancelGrant || lastLog.Amount != 500 || lastLog.IdempotencyKey != cancelEntry.IdempotencyKey() {
+		test.Fatalf("unexpected cancel log entry: %+v", lastLog)
+	}
+
+	replayed, err := service.CancelGrantByIdempotencyKeyEntry(context.Background(), tenantID, userID, ledgerID, mustIdempotencyKey(test, "allowance"), mustMetadata(test, "{}"))
+	if err != nil {
+		test.Fatalf("replay cancel: %v", err)
+	}
+	if replayed.EntryID() != cancelEntry.EntryID() || len(store.entries) != 2 {
+		test.Fatalf("expected replay to return the existing cancellation")
+	}
+}
+
+func TestCancelGrantEntryRejectsGrantsInEffect(test *testing.T) {
+	test.Parallel()
+	store := newStubStore(test, 0)
+	service := mustNewService(test, store)
+	tenantID := mustTenantID(test, defaultTenantIDValue)
+	userID := mustUserID(test, "user-1")
+	ledgerID := mustLedgerID(test, defaultLedgerIDValue)
+
+	immediate, err := service.GrantEntry(context.Background(), tenantID, userID, ledgerID, mustPositiveAmount(test, 50), mustIdempotencyKey(test, "grant-now"), 0, mustMetadata(test, "{}"))
+	if err != nil {
+		test.Fatalf("grant: %v", err)
+	}
+	if err := service.CancelGrant(context.Background(), tenantID, userID, ledgerID, immediate.EntryID(), mustMetadata(test, "{}")); !errors.Is(err, ErrGrantNotCancellable) {
+		test.Fatalf("expected grant not cancellable, got %v", err)
+	}
+
+	elapsed, err := service.ScheduleGrantEntry(context.Background(), tenantID, userID, ledgerID, mustPositiveAmount(test, 50), mustIdempotencyKey(test, "grant-elapsed"), 100, 0, mustMetadata(test, "{}"))
+	if err != nil {
+		test.Fatalf("schedule grant: %v", err)
+	}
+	if err := service.CancelGrant(context.Background(), tenantID, userID, ledgerID, elapsed.EntryID(), mustMetadata(test, "{}")); !errors.Is(err, ErrGrantNotCancellable) {
+		test.Fatalf("expected grant not cancellable once effective, got %v", err)
+	}
+
+	spend, err := service.SpendEntry(context.Background(), tenantID, userID, ledgerID, mustPositiveAmount(test, 10), mustIdempotencyKey(test, "spend-1"), mustMetadata(test, "{}"))
+	if err != nil {
+		test.Fatalf("spend: %v", err)
+	}
+	if err := service.CancelGrant(context.Background(), tenantID, userID, ledgerID, spend.EntryID(), mustMetadata(test, "{}")); !errors.Is(err, ErrGrantNotCancellable) {
+		test.Fatalf("expected grant not cancellable for spend, got %v", err)
+	}
+	if err := service.CancelGrantByIdempotencyKey(context.Background(), tenantID, userID, ledgerID, mustIdempotencyKey(test, "missing"), mustMetadata(test, "{}")); !errors.Is(err, ErrUnknownEntry) {
+		test.Fatalf("expected unknown entry, got %v", err)
+	}
+}
+
+func TestCancelGrantEntryRejectsConflictingCancelKey(test *testing.T) {
+	test.Parallel()
+	store := newStubStore(test, 0)
+	service := mustNewService(test, store)
+	tenantID := mustTenantID(test, defaultTenantIDValue)
+	userID := mustUserID(test, "user-1")
+	ledgerID := mustLedgerID(test, defaultLedgerIDValue)
+
+	grant, err := service.ScheduleGrantEntry(context.Background(), tenantID, userID, ledgerID, mustPositiveAmount(test, 50), mustIdempotencyKey(test, "allowance"), 200, 0, mustMetadata(test, "{}"))
+	if err != nil {
+		test.Fatalf("schedule grant: %v", err)
+	}
+	if err := service.Grant(context.Background(), tenantID, userID, ledgerID, mustPositiveAmount(test, 5), mustIdempotencyKey(test, "allowance:cancel"), 0, mustMetadata(test, "{}")); err != nil {
+		test.Fatalf("grant: %v", err)
+	}
+	if err := service.CancelGrant(context.Background(), tenantID, userID, ledgerID, grant.EntryID(), mustMetadata(test, "{}")); !errors.Is(err, ErrIdempotencyKeyConflict) {
+		test.Fatalf("expected idempotency key conflict, got %v", err)
+	}
+}
+
+func TestCancelGrantEntryReturnsStoreErrors(test *testing.T) {
+	test.Parallel()
+	storeError := errors.New("store failure")
+	tenantID := mustTenantID(test, defaultTenantIDValue)
+	userID := mustUserID(test, "user-1")
+	ledgerID := mustLedgerID(test, defaultLedgerIDValue)
+
+	failingService := mustNewService(test, newFailingStore(test, storeError))
+	if err := failingService.CancelGrant(context.Background(), tenantID, userID, ledgerID, mustEntryID(test, "grant-1"), mustMetadata(test, "{}")); !errors.Is(err, storeError) {
+		test.Fatalf("expected account lookup error, got %v", err)
+	}
+	if err := failingService.CancelGrantByIdempotencyKey(context.Background(), tenantID, userID, ledgerID, mustIdempotencyKey(test, "grant-1"), mustMetadata(test, "{}")); !errors.Is(err, storeError) {
+		test.Fatalf("expected account lookup error by key, got %v", err)
+	}
+
+	store := newStubStore(test, 0)
+	service := mustNewService(test, store)
+	grant, err := service.ScheduleGrantEntry(context.Background(), tenantID, userID, ledgerID, mustPositiveAmount(test, 50), mustIdempotencyKey(test, "allowance"), 200, 0, mustMetadata(test, "{}"))
+	if err != nil {
+		test.Fatalf("schedule grant: %v", err)
+	}
+	store.insertEntryError = storeError
+	if err := service.CancelGrant(context.Background(), tenantID, userID, ledgerID, grant.EntryID(), mustMetadata(test, "{}")); !errors.Is(err, storeError) {
+		test.Fatalf("expected insert error, got %v", err)
+	}
+	store.insertEntryError = nil
+
+	deriveFailure := errors.New("derive failure")
+	service.deriveKeyFn = func(IdempotencyKey, string) (IdempotencyKey, error) { return IdempotencyKey{}, deriveFailure }
+	if err := service.CancelGrant(context.Background(), tenantID, userID, ledgerID, grant.EntryID(), mustMetadata(test, "{}")); !errors.Is(err, deriveFailure) {
+		test.Fatalf("expected derive error, got %v", err)
+	}
+}
+
+func TestBatchGrantSupportsEffectiveAt(test *testing.T) {
+	test.Parallel()
+	store := newStubStore(test, 0)
+	service := mustNewService(test, store)
+	results, err := service.Batch(context.Background(), mustTenantID(test, defaultTenantIDValue), mustUserID(test, "user-1"), mustLedgerID(test, defaultLedgerIDValue), []BatchOperation{
+		{OperationID: "scheduled", Grant: &BatchGrantOperation{Amount: mustPositiveAmount(test, 10), IdempotencyKey: mustIdempotencyKey(test, "grant-1"), EffectiveAtUnixUTC: 500, Metadata: mustMetadata(test, "{}")}},
+		{OperationID: "invalid", Grant: &BatchGrantOperation{Amount: mustPositiveAmount(test, 10), IdempotencyKey: mustIdempotencyKey(test, "grant-2"), EffectiveAtUnixUTC: 500, ExpiresAtUnixUTC: 400, Metadata: mustMetadata(test, "{}")}},
+	}, false)
+	if err != nil {
+		test.Fatalf("batch: %v", err)
+	}
+	if results[0].Entry == nil || results[0].Entry.EffectiveAtUnixUTC() != 500 {
+		test.Fatalf("expected scheduled batch grant, got %+v", results[0])
+	}
+	if !errors.Is(results[1].Error, ErrInvalidEffectiveAt) {
+		test.Fatalf("expected invalid effective at, got %v", results[1].Error)
+	}
+}
+
+// cancelKeyLookupStore fails idempotency-key lookups with err, after the grant itself was read by entry id.
+type cancelKeyLookupStore struct {
+	*stubStore
+	err error
+}
+
+func (store cancelKeyLookupStore) WithTx(ctx context.Context, fn func(ctx context.Context, txStore Store) error) error {
+	return store.stubStore.WithTx(ctx, func(ctx context.Context, txStore Store) error {
+		return fn(ctx, cancelKeyLookupStore{stubStore: txStore.(*stubStore), err: store.err})
+	})
+}
+
+func (store cancelKeyLookupStore) GetEntryByIdempotencyKey(ctx context.Context, accountID AccountID, idempotencyKey IdempotencyKey) (Entry, error) {
+	return Entry{}, store.err
+}
+
+func TestCancelGrantEntryReturnsLookupAndInputErrors(test *testing.T) {
+	test.Parallel()
+	storeError := errors.New("store failure")
+	tenantID := mustTenantID(test, defaultTenantIDValue)
+	userID := mustUserID(test, "user-1")
+	ledgerID := mustLedgerID(test, defaultLedgerIDValue)
+
+	store := newStubStore(test, 0)
+	service := mustNewService(test, store)
+	if err := service.ScheduleGrant(context.Background(), tenantID, userID, ledgerID, mustPositiveAmount(test, 50), mustIdempotencyKey(test, "allowance"), 200, 0, mustMetadata(test, "{}")); err != nil {
+		test.Fatalf("schedule grant: %v", err)
+	}
+	grant, err := store.GetEntryByIdempotencyKey(context.Background(), store.accountID, mustIdempotencyKey(test, "allowance"))
+	if err != nil {
+		test.Fatalf("read scheduled grant: %v", err)
+	}
+	if grant.EffectiveAtUnixUTC() != 200 {
+		test.Fatalf("expected the grant to take effect at 200, got %d", grant.EffectiveAtUnixUTC())
+	}
+	if err := service.CancelGrant(context.Background(), tenantID, userID, ledgerID, grant.EntryID(), MetadataJSON{}); !errors.Is(err, ErrInvalidMetadataJSON) {
+		test.Fatalf("expected invalid metadata, got %v", err)
+	}
+
+	lookupService := mustNewService(test, cancelKeyLookupStore{stubStore: store, err: storeError})
+	if err := lookupService.CancelGrant(context.Background(), tenantID, userID, ledgerID, grant.EntryID(), mustMetadata(test, "{}")); !errors.Is(err, storeError) {
+		test.Fatalf("expected cancel key lookup error, got %v", err)
+	}
+
+	store.getAccountError = storeError
+	if err := service.CancelGrant(context.Background(), tenantID, userID, ledgerID, grant.EntryID(), mustMetadata(test, "{}")); !errors.Is(err, storeError) {
+		test.Fatalf("expected account lookup error, got %v", err)
+	}
+	if err := service.CancelGrantByIdempotencyKey(context.Background(), tenantID, userID, ledgerID, mustIdempotencyKey(test, "allowance"), mustMetadata(test, "{}")); !errors.Is(err, storeError) {
+		test.Fatalf("expected account lookup error by key, got %v", err)
+	}
+}
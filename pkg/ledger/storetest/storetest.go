@@ -0,0 +1,1439 @@
+// Package storetest checks that a ledger.Store implementation honours the contract Service relies on.
+package storetest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/MarkoPoloResearchLab/ledger/pkg/ledger"
+)
+
+const (
+	baseUnixUTC   = int64(1_700_000_000)
+	hourSeconds   = int64(3600)
+	entryMetadata = `{"source":"conformance","tags":["a","b"]}`
+)
+
+var errRollback = errors.New("storetest: rollback")
+
+// StoreFactory returns a new, empty store. It is called once per subtest, from that subtest's goroutine,
+// and should register any cleanup with test.Cleanup.
+type StoreFactory func(test *testing.T) ledger.Store
+
+// RunConformance runs the ledger.Store contract against stores built by factory. Every subtest runs in
+// parallel with a store of its own.
+func RunConformance(test *testing.T, factory StoreFactory) {
+	test.Helper()
+	cases := []struct {
+		name string
+		run  func(test *testing.T, store ledger.Store)
+	}{
+		{name: "accounts", run: testAccounts},
+		{name: "account_lookup", run: testAccountLookup},
+		{name: "list_accounts", run: testListAccounts},
+		{name: "prefix_filters_match_literally", run: testPrefixFiltersMatchLiterally},
+		{name: "entry_round_trip", run: testEntryRoundTrip},
+		{name: "idempotency", run: testIdempotency},
+		{name: "balances", run: testBalances},
+		{name: "debit_velocity", run: testDebitVelocity},
+		{name: "list_entries", run: testListEntries},
+		{name: "list_entries_keyset_cursor", run: testListEntriesKeysetCursor},
+		{name: "reservations", run: testReservations},
+		{name: "transactions", run: testTransactions},
+		{name: "nested_transactions", run: testNestedTransactions},
+		{name: "journal", run: testJournal},
+		{name: "entries_by_expiry", run: testEntriesByExpiry},
+		{name: "grant_consumption", run: testGrantConsumption},
+		{name: "entry_feed", run: testEntryFeed},
+		{name: "outbox", run: testOutbox},
+		{name: "hash_chain", run: testHashChain},
+		{name: "entry_batch", run: testEntryBatch},
+		{name: "archive", run: testArchive},
+	}
+	for _, testCase := range cases {
+		test.Run(testCase.name, func(test *testing.T) {
+			test.Parallel()
+			testCase.run(test, factory(test))
+		})
+	}
+}
+
+// capability returns store as T and skips the subtest when the store does not implement it.
+func capability[T any](test *testing.T, store ledger.Store) T {
+	test.Helper()
+	capable, ok := store.(T)
+	if !ok {
+		test.Skipf("store does not implement %v", reflect.TypeFor[T]())
+	}
+	return capable
+}
+
+// txCapability returns a transaction store as T. A store's transaction stores share its capabilities.
+func txCapability[T any](txStore ledger.Store) (T, error) {
+	capable, ok := txStore.(T)
+	if !ok {
+		return capable, fmt.Errorf("transaction store does not implement %v", reflect.TypeFor[T]())
+	}
+	return capable, nil
+}
+
+func testAccounts(test *testing.T, store ledger.Store) {
+	accountID := mustAccount(test, store, "tenant-a", "user-1", "default")
+	again := mustAccount(test, store, "tenant-a", "user-1", "default")
+	if again != accountID {
+		test.Fatalf("GetOrCreateAccountID returned %s then %s for one account", accountID, again)
+	}
+	for _, other := range []ledger.AccountID{
+		mustAccount(test, store, "tenant-b", "user-1", "default"),
+		mustAccount(test, store, "tenant-a", "user-2", "default"),
+		mustAccount(test, store, "tenant-a", "user-1", "bonus"),
+	} {
+		if other == accountID {
+			test.Fatalf("distinct tenant, user, and ledger must get distinct accounts")
+		}
+	}
+}
+
+func testAccountLookup(test *testing.T, store ledger.Store) {
+	ctx := context.Background()
+	accountStore := capability[ledger.AccountStore](test, store)
+	accountID := mustAccount(test, store, "tenant-a", "user-1", "default")
+	found, err := accountStore.GetAccountID(ctx, mustTenantID(test, "tenant-a"), mustUserID(test, "user-1"), mustLedgerID(test, "default"))
+	if err != nil || found != accountID {
+		test.Fatalf("GetAccountID = %s, %v; want %s", found, err, accountID)
+	}
+	_, err = accountStore.GetAccountID(ctx, mustTenantID(test, "tenant-a"), mustUserID(test, "missing"), mustLedgerID(test, "default"))
+	if !errors.Is(err, ledger.ErrUnknownAccount) {
+		test.Fatalf("GetAccountID for a missing account = %v; want ErrUnknownAccount", err)
+	}
+}
+
+func testListAccounts(test *testing.T, store ledger.Store) {
+	ctx := context.Background()
+	accountStore := capability[ledger.AccountStore](test, store)
+	funded := mustAccount(test, store, "tenant-a", "alice", "default")
+	empty := mustAccount(test, store, "tenant-a", "alex", "default")
+	bonus := mustAccount(test, store, "tenant-a", "bob", "bonus")
+	mustAccount(test, store, "tenant-b", "alice", "default")
+	mustInsertEntry(test, store, entrySpec{accountID: funded, entryType: ledger.EntryGrant, amountCents: 100, key: "grant"})
+	mustInsertEntry(test, store, entrySpec{accountID: empty, entryType: ledger.EntryGrant, amountCents: 100, key: "grant", expiresAt: baseUnixUTC})
+
+	tenantID := mustTenantID(test, "tenant-a")
+	defaultLedger := mustLedgerID(test, "default")
+	testCases := []struct {
+		name     string
+		limit    int
+		filter   ledger.ListAccountsFilter
+		expected []ledger.AccountID
+	}{
+		{name: "tenant", limit: 10, expected: []ledger.AccountID{funded, empty, bonus}},
+		{name: "ledger", limit: 10, filter: ledger.ListAccountsFilter{LedgerID: &defaultLedger}, expected: []ledger.AccountID{funded, empty}},
+		{name: "user_prefix", limit: 10, filter: ledger.ListAccountsFilter{UserIDPrefix: "al"}, expected: []ledger.AccountID{funded, empty}},
+		{name: "non_zero_balance", limit: 10, filter: ledger.ListAccountsFilter{NonZeroBalance: true, BalanceAtUnixUTC: baseUnixUTC + hourSeconds}, expected: []ledger.AccountID{funded}},
+		{name: "limit", limit: 2, expected: nil},
+	}
+	for _, testCase := range testCases {
+		accounts, err := accountStore.ListAccounts(ctx, tenantID, 0, testCase.limit, testCase.filter)
+		if err != nil {
+			test.Fatalf("%s: ListAccounts: %v", testCase.name, err)
+		}
+		if testCase.expected == nil {
+			if len(accounts) != testCase.limit {
+				test.Fatalf("%s: got %d accounts, want %d", testCase.name, len(accounts), testCase.limit)
+			}
+			continue
+		}
+		assertSameAccounts(test, testCase.name, accounts, testCase.expected)
+	}
+	accounts, err := accountStore.ListAccounts(ctx, tenantID, baseUnixUTC, 10, ledger.ListAccountsFilter{})
+	if err != nil {
+		test.Fatalf("ListAccounts before a past time: %v", err)
+	}
+	if len(accounts) != 0 {
+		test.Fatalf("accounts created now must not be listed before %d, got %d", baseUnixUTC, len(accounts))
+	}
+}
+
+func testPrefixFiltersMatchLiterally(test *testing.T, store ledger.Store) {
+	ctx := context.Background()
+	underscore := mustAccount(test, store, "tenant-a", "a_1", "default")
+	mustAccount(test, store, "tenant-a", "ab1", "default")
+	percent := mustAccount(test, store, "tenant-a", "a%x", "default")
+	mustAccount(test, store, "tenant-a", "abx", "default")
+	backslash := mustAccount(test, store, "tenant-a", `a\1`, "default")
+	tenantID := mustTenantID(test, "tenant-a")
+	accountStore, listsAccounts := store.(ledger.AccountStore)
+	for _, testCase := range []struct {
+		prefix   string
+		expected []ledger.AccountID
+	}{
+		{prefix: "a_", expected: []ledger.AccountID{underscore}},
+		{prefix: "a%", expected: []ledger.AccountID{percent}},
+		{prefix: `a\`, expected: []ledger.AccountID{backslash}},
+	} {
+		if !listsAccounts {
+			break
+		}
+		accounts, err := accountStore.ListAccounts(ctx, tenantID, 0, 10, ledger.ListAccountsFilter{UserIDPrefix: testCase.prefix})
+		if err != nil {
+			test.Fatalf("ListAccounts with prefix %q: %v", testCase.prefix, err)
+		}
+		assertSameAccounts(test, "user prefix "+testCase.prefix, accounts, testCase.expected)
+	}
+
+	literal := mustInsertEntry(test, store, entrySpec{accountID: underscore, entryType: ledger.EntryGrant, amountCents: 100, key: "grant_1"})
+	mustInsertEntry(test, store, entrySpec{accountID: underscore, entryType: ledger.EntryGrant, amountCents: 100, key: "grantx1"})
+	prefix := mustIdempotencyKey(test, "grant_")
+	entries, err := store.ListEntries(ctx, underscore, 0, 10, ledger.ListEntriesFilter{IdempotencyKeyPrefix: &prefix})
+	if err != nil {
+		test.Fatalf("ListEntries with an idempotency key prefix: %v", err)
+	}
+	if len(entries) != 1 || entries[0].EntryID() != literal.EntryID() {
+		test.Fatalf("idempotency key prefix %q must match only %s, got %d entries", prefix.String(), literal.IdempotencyKey().String(), len(entries))
+	}
+}
+
+func testEntryRoundTrip(test *testing.T, store ledger.Store) {
+	ctx := context.Background()
+	accountID := mustAccount(test, store, "tenant-a", "user-1", "default")
+	actor, err := ledger.NewActor("key-1", "billing", "request-1", "10.0.0.1")
+	if err != nil {
+		test.Fatalf("actor: %v", err)
+	}
+	grant := mustInsertEntry(test, store, entrySpec{
+		accountID:   accountID,
+		entryType:   ledger.EntryGrant,
+		amountCents: 500,
+		key:         "grant",
+		expiresAt:   baseUnixUTC + 10*hourSeconds,
+		effectiveAt: baseUnixUTC + hourSeconds,
+		createdAt:   baseUnixUTC,
+		metadata:    entryMetadata,
+		actor:       actor,
+	})
+	if grant.EntryID().String() == "" {
+		test.Fatalf("InsertEntry must assign an entry id")
+	}
+	reservationID := mustReservationID(test, "order-1")
+	grantID := grant.EntryID()
+	refund := mustInsertEntry(test, store, entrySpec{accountID: accountID, entryType: ledger.EntryRefund, amountCents: 20, key: "refund", reservationID: &reservationID, refundOf: &grantID, createdAt: baseUnixUTC + 1})
+
+	for _, expected := range []ledger.Entry{grant, refund} {
+		byID, err := store.GetEntry(ctx, accountID, expected.EntryID())
+		if err != nil {
+			test.Fatalf("GetEntry: %v", err)
+		}
+		assertSameEntry(test, "GetEntry", byID, expected)
+		byKey, err := store.GetEntryByIdempotencyKey(ctx, accountID, expected.IdempotencyKey())
+		if err != nil {
+			test.Fatalf("GetEntryByIdempotencyKey: %v", err)
+		}
+		assertSameEntry(test, "GetEntryByIdempotencyKey", byKey, expected)
+	}
+	if grant.Actor() != actor || grant.EffectiveAtUnixUTC() != baseUnixUTC+hourSeconds || grant.ExpiresAtUnixUTC() != baseUnixUTC+10*hourSeconds || grant.CreatedUnixUTC() != baseUnixUTC {
+		test.Fatalf("InsertEntry lost fields: %+v", grant)
+	}
+	if storedReservation, ok := refund.ReservationID(); !ok || storedReservation != reservationID {
+		test.Fatalf("InsertEntry lost the reservation id")
+	}
+	if refundOf, ok := refund.RefundOfEntryID(); !ok || refundOf != grantID {
+		test.Fatalf("InsertEntry lost the refunded entry id")
+	}
+
+	otherAccount := mustAccount(test, store, "tenant-a", "user-2", "default")
+	if _, err := store.GetEntry(ctx, otherAccount, grant.EntryID()); !errors.Is(err, ledger.ErrUnknownEntry) {
+		test.Fatalf("GetEntry through another account = %v; want ErrUnknownEntry", err)
+	}
+	if _, err := store.GetEntryByIdempotencyKey(ctx, accountID, mustIdempotencyKey(test, "missing")); !errors.Is(err, ledger.ErrUnknownEntry) {
+		test.Fatalf("GetEntryByIdempotencyKey for a missing key = %v; want ErrUnknownEntry", err)
+	}
+}
+
+func testIdempotency(test *testing.T, store ledger.Store) {
+	ctx := context.Background()
+	accountID := mustAccount(test, store, "tenant-a", "user-1", "default")
+	otherAccount := mustAccount(test, store, "tenant-a", "user-2", "default")
+	first := mustInsertEntry(test, store, entrySpec{accountID: accountID, entryType: ledger.EntryGrant, amountCents: 100, key: "shared"})
+
+	_, err := store.InsertEntry(ctx, mustEntryInput(test, entrySpec{accountID: accountID, entryType: ledger.EntrySpend, amountCents: -5, key: "shared"}))
+	if !errors.Is(err, ledger.ErrDuplicateIdempotencyKey) {
+		test.Fatalf("duplicate key on one account = %v; want ErrDuplicateIdempotencyKey", err)
+	}
+	mustInsertEntry(test, store, entrySpec{accountID: otherAccount, entryType: ledger.EntryGrant, amountCents: 100, key: "shared"})
+
+	stored, err := store.GetEntryByIdempotencyKey(ctx, accountID, mustIdempotencyKey(test, "shared"))
+	if err != nil {
+		test.Fatalf("GetEntryByIdempotencyKey: %v", err)
+	}
+	assertSameEntry(test, "after duplicate", stored, first)
+}
+
+func testBalances(test *testing.T, store ledger.Store) {
+	ctx := context.Background()
+	accountID := mustAccount(test, store, "tenant-a", "user-1", "default")
+	reservationID := mustReservationID(test, "order-1")
+	grant := mustInsertEntry(test, store, entrySpec{accountID: accountID, entryType: ledger.EntryGrant, amountCents: 1000, key: "grant"})
+	mustInsertEntry(test, store, entrySpec{accountID: accountID, entryType: ledger.EntryGrant, amountCents: 300, key: "expiring", expiresAt: baseUnixUTC + hourSeconds})
+	mustInsertEntry(test, store, entrySpec{accountID: accountID, entryType: ledger.EntryGrant, amountCents: 200, key: "scheduled", effectiveAt: baseUnixUTC + hourSeconds})
+	mustInsertEntry(test, store, entrySpec{accountID: accountID, entryType: ledger.EntryHold, amountCents: -50, key: "hold", reservationID: &reservationID})
+	spend := mustInsertEntry(test, store, entrySpec{accountID: accountID, entryType: ledger.EntrySpend, amountCents: -400, key: "spend"})
+	spendID := spend.EntryID()
+	grantID := grant.EntryID()
+	mustInsertEntry(test, store, entrySpec{accountID: accountID, entryType: ledger.EntryRefund, amountCents: 100, key: "refund-1", refundOf: &spendID})
+	mustInsertEntry(test, store, entrySpec{accountID: accountID, entryType: ledger.EntryRefund, amountCents: 50, key: "refund-2", refundOf: &spendID})
+
+	testCases := []struct {
+		atUnixUTC int64
+		expected  ledger.SignedAmountCents
+	}{
+		{atUnixUTC: baseUnixUTC, expected: 1000 + 300 - 400 + 150},
+		{atUnixUTC: baseUnixUTC + hourSeconds, expected: 1000 + 200 - 400 + 150},
+	}
+	for _, testCase := range testCases {
+		total, err := store.SumTotal(ctx, accountID, testCase.atUnixUTC)
+		if err != nil {
+			test.Fatalf("SumTotal: %v", err)
+		}
+		if total != testCase.expected {
+			test.Fatalf("SumTotal at %d = %d; want %d", testCase.atUnixUTC, total, testCase.expected)
+		}
+	}
+
+	refunded, err := store.SumRefunds(ctx, accountID, spendID)
+	if err != nil || refunded != 150 {
+		test.Fatalf("SumRefunds = %d, %v; want 150", refunded, err)
+	}
+	refunded, err = store.SumRefunds(ctx, accountID, grantID)
+	if err != nil || refunded != 0 {
+		test.Fatalf("SumRefunds without refunds = %d, %v; want 0", refunded, err)
+	}
+
+	mustCreateReservation(test, store, accountID, "active", 70, ledger.ReservationStatusActive, 0)
+	mustCreateReservation(test, store, accountID, "expiring", 30, ledger.ReservationStatusActive, baseUnixUTC+hourSeconds)
+	mustCreateReservation(test, store, accountID, "captured", 500, ledger.ReservationStatusCaptured, 0)
+	for atUnixUTC, expected := range map[int64]ledger.AmountCents{baseUnixUTC: 100, baseUnixUTC + hourSeconds: 70} {
+		holds, err := store.SumActiveHolds(ctx, accountID, atUnixUTC)
+		if err != nil {
+			test.Fatalf("SumActiveHolds: %v", err)
+		}
+		if holds != expected {
+			test.Fatalf("SumActiveHolds at %d = %d; want %d", atUnixUTC, holds, expected)
+		}
+		balanceStore, ok := store.(ledger.BalanceStore)
+		if !ok {
+			continue
+		}
+		total, err := store.SumTotal(ctx, accountID, atUnixUTC)
+		if err != nil {
+			test.Fatalf("SumTotal: %v", err)
+		}
+		balanceTotal, balanceHolds, err := balanceStore.SumBalance(ctx, accountID, atUnixUTC)
+		if err != nil {
+			test.Fatalf("SumBalance: %v", err)
+		}
+		if balanceTotal != total || balanceHolds != holds {
+			test.Fatalf("SumBalance at %d = %d, %d; want SumTotal %d and SumActiveHolds %d", atUnixUTC, balanceTotal, balanceHolds, total, holds)
+		}
+	}
+
+	otherAccount := mustAccount(test, store, "tenant-a", "user-2", "default")
+	total, err := store.SumTotal(ctx, otherAccount, baseUnixUTC)
+	if err != nil || total != 0 {
+		test.Fatalf("SumTotal for an empty account = %d, %v; want 0", total, err)
+	}
+}
+
+func testDebitVelocity(test *testing.T, store ledger.Store) {
+	ctx := context.Background()
+	velocityStore := capability[ledger.VelocityStore](test, store)
+	accountID := mustAccount(test, store, "tenant-a", "user-1", "default")
+	reservationID := mustReservationID(test, "order-1")
+	mustInsertEntry(test, store, entrySpec{accountID: accountID, entryType: ledger.EntryGrant, amountCents: 1000, key: "grant", createdAt: baseUnixUTC})
+	mustInsertEntry(test, store, entrySpec{accountID: accountID, entryType: ledger.EntrySpend, amountCents: -100, key: "old-spend", createdAt: baseUnixUTC - hourSeconds})
+	mustInsertEntry(test, store, entrySpec{accountID: accountID, entryType: ledger.EntrySpend, amountCents: -40, key: "spend", createdAt: baseUnixUTC})
+	mustInsertEntry(test, store, entrySpec{accountID: accountID, entryType: ledger.EntryHold, amountCents: -60, key: "hold", reservationID: &reservationID, createdAt: baseUnixUTC})
+	mustInsertEntry(test, store, entrySpec{accountID: accountID, entryType: ledger.EntryReverseHold, amountCents: 60, key: "reverse", reservationID: &reservationID, createdAt: baseUnixUTC + 1})
+	mustInsertEntry(test, store, entrySpec{accountID: accountID, entryType: ledger.EntrySpend, amountCents: -60, key: "capture", reservationID: &reservationID, createdAt: baseUnixUTC + 1})
+	olderReservationID := mustReservationID(test, "order-0")
+	mustInsertEntry(test, store, entrySpec{accountID: accountID, entryType: ledger.EntryHold, amountCents: -70, key: "old-hold", reservationID: &olderReservationID, createdAt: baseUnixUTC - hourSeconds})
+	mustInsertEntry(test, store, entrySpec{accountID: accountID, entryType: ledger.EntryReverseHold, amountCents: 70, key: "old-release", reservationID: &olderReservationID, createdAt: baseUnixUTC + 2})
+
+	velocity, err := velocityStore.SumDebitVelocity(ctx, accountID, baseUnixUTC)
+	if err != nil {
+		test.Fatalf("SumDebitVelocity: %v", err)
+	}
+	if velocity.AmountCents != 100 || velocity.Operations != 2 {
+		test.Fatalf("SumDebitVelocity = %+v; want 100 cents over 2 operations, ignoring the release of a hold placed before the window", velocity)
+	}
+	velocity, err = velocityStore.SumDebitVelocity(ctx, accountID, baseUnixUTC+hourSeconds)
+	if err != nil {
+		test.Fatalf("SumDebitVelocity: %v", err)
+	}
+	if velocity.AmountCents != 0 || velocity.Operations != 0 {
+		test.Fatalf("SumDebitVelocity after every debit = %+v; want zero", velocity)
+	}
+}
+
+func testListEntries(test *testing.T, store ledger.Store) {
+	ctx := context.Background()
+	accountID := mustAccount(test, store, "tenant-a", "user-1", "default")
+	reservationID := mustReservationID(test, "order-1")
+	actor, err := ledger.NewActor("key-1", "billing", "", "")
+	if err != nil {
+		test.Fatalf("actor: %v", err)
+	}
+	grant := mustInsertEntry(test, store, entrySpec{accountID: accountID, entryType: ledger.EntryGrant, amountCents: 1000, key: "grant:1", createdAt: baseUnixUTC, actor: actor})
+	hold := mustInsertEntry(test, store, entrySpec{accountID: accountID, entryType: ledger.EntryHold, amountCents: -100, key: "hold:1", reservationID: &reservationID, createdAt: baseUnixUTC + 10})
+	spend := mustInsertEntry(test, store, entrySpec{accountID: accountID, entryType: ledger.EntrySpend, amountCents: -100, key: "spend:1", reservationID: &reservationID, createdAt: baseUnixUTC + 20})
+	second := mustInsertEntry(test, store, entrySpec{accountID: accountID, entryType: ledger.EntryGrant, amountCents: 10, key: "grant:2", createdAt: baseUnixUTC + 30, effectiveAt: baseUnixUTC + hourSeconds})
+	otherAccount := mustAccount(test, store, "tenant-a", "user-2", "default")
+	mustInsertEntry(test, store, entrySpec{accountID: otherAccount, entryType: ledger.EntryGrant, amountCents: 5, key: "grant:1", createdAt: baseUnixUTC})
+
+	grantPrefix := mustIdempotencyKey(test, "grant:")
+	serviceActor, err := ledger.NewActor("", "billing", "", "")
+	if err != nil {
+		test.Fatalf("actor: %v", err)
+	}
+	otherActor, err := ledger.NewActor("key-2", "", "", "")
+	if err != nil {
+		test.Fatalf("actor: %v", err)
+	}
+	testCases := []struct {
+		name     string
+		before   int64
+		limit    int
+		filter   ledger.ListEntriesFilter
+		expected []ledger.Entry
+	}{
+		{name: "newest_first", limit: 10, expected: []ledger.Entry{second, spend, hold, grant}},
+		{name: "limit", limit: 2, expected: []ledger.Entry{second, spend}},
+		{name: "before", before: baseUnixUTC + 20, limit: 10, expected: []ledger.Entry{hold, grant}},
+		{name: "types", limit: 10, filter: ledger.ListEntriesFilter{Types: []ledger.EntryType{ledger.EntryHold, ledger.EntrySpend}}, expected: []ledger.Entry{spend, hold}},
+		{name: "reservation", limit: 10, filter: ledger.ListEntriesFilter{ReservationID: &reservationID}, expected: []ledger.Entry{spend, hold}},
+		{name: "idempotency_key_prefix", limit: 10, filter: ledger.ListEntriesFilter{IdempotencyKeyPrefix: &grantPrefix}, expected: []ledger.Entry{second, grant}},
+		{name: "actor", limit: 10, filter: ledger.ListEntriesFilter{Actor: serviceActor}, expected: []ledger.Entry{grant}},
+		{name: "actor_mismatch", limit: 10, filter: ledger.ListEntriesFilter{Actor: otherActor}, expected: []ledger.Entry{}},
+		{name: "effective_after", limit: 10, filter: ledger.ListEntriesFilter{EffectiveAfterUnixUTC: baseUnixUTC + 30}, expected: []ledger.Entry{second}},
+	}
+	for _, testCase := range testCases {
+		entries, err := store.ListEntries(ctx, accountID, testCase.before, testCase.limit, testCase.filter)
+		if err != nil {
+			test.Fatalf("%s: ListEntries: %v", testCase.name, err)
+		}
+		assertEntryIDs(test, testCase.name, entries, testCase.expected)
+	}
+}
+
+func testListEntriesKeysetCursor(test *testing.T, store ledger.Store) {
+	ctx := context.Background()
+	accountID := mustAccount(test, store, "tenant-a", "user-1", "default")
+	older := mustInsertEntry(test, store, entrySpec{accountID: accountID, entryType: ledger.EntryGrant, amountCents: 1, key: "older", createdAt: baseUnixUTC})
+	sameSecond := make([]ledger.Entry, 0, 5)
+	for index := range 5 {
+		sameSecond = append(sameSecond, mustInsertEntry(test, store, entrySpec{accountID: accountID, entryType: ledger.EntryGrant, amountCents: 1, key: fmt.Sprintf("busy-%d", index), createdAt: baseUnixUTC + 1}))
+	}
+	newer := mustInsertEntry(test, store, entrySpec{accountID: accountID, entryType: ledger.EntryGrant, amountCents: 1, key: "newer", createdAt: baseUnixUTC + 2})
+	sort.Slice(sameSecond, func(left, right int) bool {
+		return sameSecond[left].EntryID().String() > sameSecond[right].EntryID().String()
+	})
+	expected := append(append([]ledger.Entry{newer}, sameSecond...), older)
+
+	var collected []ledger.Entry
+	var filter ledger.ListEntriesFilter
+	var beforeUnixUTC int64
+	for page := 0; ; page++ {
+		if page > len(expected) {
+			test.Fatalf("keyset paging did not terminate, collected %d entries", len(collected))
+		}
+		entries, err := store.ListEntries(ctx, accountID, beforeUnixUTC, 2, filter)
+		if err != nil {
+			test.Fatalf("ListEntries page %d: %v", page, err)
+		}
+		collected = append(collected, entries...)
+		if len(entries) < 2 {
+			break
+		}
+		last := entries[len(entries)-1]
+		beforeUnixUTC = last.CreatedUnixUTC()
+		lastEntryID := last.EntryID()
+		filter.BeforeEntryID = &lastEntryID
+	}
+	assertEntryIDs(test, "keyset pages", collected, expected)
+}
+
+func testReservations(test *testing.T, store ledger.Store) {
+	ctx := context.Background()
+	accountID := mustAccount(test, store, "tenant-a", "user-1", "default")
+	otherAccount := mustAccount(test, store, "tenant-a", "user-2", "default")
+	mustCreateReservation(test, store, accountID, "order-1", 250, ledger.ReservationStatusActive, baseUnixUTC+hourSeconds)
+	mustCreateReservation(test, store, accountID, "order-2", 100, ledger.ReservationStatusActive, 0)
+	mustCreateReservation(test, store, otherAccount, "order-1", 10, ledger.ReservationStatusActive, 0)
+
+	duplicate, err := ledger.NewReservation(accountID, mustReservationID(test, "order-1"), mustPositiveAmount(test, 5), ledger.ReservationStatusActive, 0)
+	if err != nil {
+		test.Fatalf("reservation: %v", err)
+	}
+	if err := store.CreateReservation(ctx, duplicate); !errors.Is(err, ledger.ErrReservationExists) {
+		test.Fatalf("duplicate CreateReservation = %v; want ErrReservationExists", err)
+	}
+
+	reservation, err := store.GetReservation(ctx, accountID, mustReservationID(test, "order-1"))
+	if err != nil {
+		test.Fatalf("GetReservation: %v", err)
+	}
+	if reservation.AmountCents() != 250 || reservation.Status() != ledger.ReservationStatusActive || reservation.ExpiresAtUnixUTC() != baseUnixUTC+hourSeconds || reservation.AccountID() != accountID {
+		test.Fatalf("GetReservation lost fields: %+v", reservation)
+	}
+	if _, err := store.GetReservation(ctx, accountID, mustReservationID(test, "missing")); !errors.Is(err, ledger.ErrUnknownReservation) {
+		test.Fatalf("GetReservation for a missing reservation = %v; want ErrUnknownReservation", err)
+	}
+
+	orderID := mustReservationID(test, "order-1")
+	if err := store.UpdateReservationStatus(ctx, accountID, orderID, ledger.ReservationStatusActive, ledger.ReservationStatusCaptured); err != nil {
+		test.Fatalf("UpdateReservationStatus: %v", err)
+	}
+	if err := store.UpdateReservationStatus(ctx, accountID, orderID, ledger.ReservationStatusActive, ledger.ReservationStatusReleased); !errors.Is(err, ledger.ErrReservationClosed) {
+		test.Fatalf("UpdateReservationStatus from a stale status = %v; want ErrReservationClosed", err)
+	}
+	if err := store.UpdateReservationStatus(ctx, accountID, mustReservationID(test, "missing"), ledger.ReservationStatusActive, ledger.ReservationStatusReleased); !errors.Is(err, ledger.ErrReservationClosed) {
+		test.Fatalf("UpdateReservationStatus for a missing reservation = %v; want ErrReservationClosed", err)
+	}
+	reservation, err = store.GetReservation(ctx, accountID, orderID)
+	if err != nil || reservation.Status() != ledger.ReservationStatusCaptured {
+		test.Fatalf("status after update = %v, %v; want captured", reservation.Status(), err)
+	}
+	otherReservation, err := store.GetReservation(ctx, otherAccount, orderID)
+	if err != nil || otherReservation.Status() != ledger.ReservationStatusActive {
+		test.Fatalf("updating one account's reservation changed another's: %v, %v", otherReservation.Status(), err)
+	}
+
+	reservations, err := store.ListReservations(ctx, accountID, 0, 10, ledger.ListReservationsFilter{})
+	if err != nil {
+		test.Fatalf("ListReservations: %v", err)
+	}
+	assertReservationIDs(test, "all", reservations, "order-1", "order-2")
+	reservations, err = store.ListReservations(ctx, accountID, 0, 10, ledger.ListReservationsFilter{Statuses: []ledger.ReservationStatus{ledger.ReservationStatusActive}})
+	if err != nil {
+		test.Fatalf("ListReservations: %v", err)
+	}
+	assertReservationIDs(test, "active", reservations, "order-2")
+	reservations, err = store.ListReservations(ctx, accountID, baseUnixUTC, 10, ledger.ListReservationsFilter{})
+	if err != nil {
+		test.Fatalf("ListReservations: %v", err)
+	}
+	assertReservationIDs(test, "before", reservations)
+}
+
+func testTransactions(test *testing.T, store ledger.Store) {
+	ctx := context.Background()
+	accountID := mustAccount(test, store, "tenant-a", "user-1", "default")
+
+	err := store.WithTx(ctx, func(ctx context.Context, txStore ledger.Store) error {
+		if _, err := txStore.InsertEntry(ctx, mustEntryInput(test, entrySpec{accountID: accountID, entryType: ledger.EntryGrant, amountCents: 100, key: "committed"})); err != nil {
+			return err
+		}
+		total, err := txStore.SumTotal(ctx, accountID, baseUnixUTC)
+		if err != nil {
+			return err
+		}
+		if total != 100 {
+			test.Errorf("transaction must read its own writes, got total %d", total)
+		}
+		return nil
+	})
+	if err != nil {
+		test.Fatalf("WithTx: %v", err)
+	}
+
+	err = store.WithTx(ctx, func(ctx context.Context, txStore ledger.Store) error {
+		if _, err := txStore.GetOrCreateAccountID(ctx, mustTenantID(test, "tenant-a"), mustUserID(test, "rolled-back"), mustLedgerID(test, "default")); err != nil {
+			return err
+		}
+		if _, err := txStore.InsertEntry(ctx, mustEntryInput(test, entrySpec{accountID: accountID, entryType: ledger.EntryGrant, amountCents: 50, key: "rolled-back"})); err != nil {
+			return err
+		}
+		reservation, err := ledger.NewReservation(accountID, mustReservationID(test, "rolled-back"), mustPositiveAmount(test, 5), ledger.ReservationStatusActive, 0)
+		if err != nil {
+			return err
+		}
+		if err := txStore.CreateReservation(ctx, reservation); err != nil {
+			return err
+		}
+		return errRollback
+	})
+	if !errors.Is(err, errRollback) {
+		test.Fatalf("WithTx must return the callback error, got %v", err)
+	}
+	assertTotal(test, store, accountID, 100)
+	if _, err := store.GetEntryByIdempotencyKey(ctx, accountID, mustIdempotencyKey(test, "rolled-back")); !errors.Is(err, ledger.ErrUnknownEntry) {
+		test.Fatalf("rolled back entry is visible: %v", err)
+	}
+	if _, err := store.GetReservation(ctx, accountID, mustReservationID(test, "rolled-back")); !errors.Is(err, ledger.ErrUnknownReservation) {
+		test.Fatalf("rolled back reservation is visible: %v", err)
+	}
+	if accountStore, ok := store.(ledger.AccountStore); ok {
+		if _, err := accountStore.GetAccountID(ctx, mustTenantID(test, "tenant-a"), mustUserID(test, "rolled-back"), mustLedgerID(test, "default")); !errors.Is(err, ledger.ErrUnknownAccount) {
+			test.Fatalf("rolled back account is visible: %v", err)
+		}
+	}
+}
+
+func testNestedTransactions(test *testing.T, store ledger.Store) {
+	ctx := context.Background()
+	accountID := mustAccount(test, store, "tenant-a", "user-1", "default")
+
+	err := store.WithTx(ctx, func(ctx context.Context, txStore ledger.Store) error {
+		if _, err := txStore.InsertEntry(ctx, mustEntryInput(test, entrySpec{accountID: accountID, entryType: ledger.EntryGrant, amountCents: 100, key: "outer"})); err != nil {
+			return err
+		}
+		nestedErr := txStore.WithTx(ctx, func(ctx context.Context, nestedStore ledger.Store) error {
+			if _, err := nestedStore.InsertEntry(ctx, mustEntryInput(test, entrySpec{accountID: accountID, entryType: ledger.EntryGrant, amountCents: 10, key: "inner"})); err != nil {
+				return err
+			}
+			_, err := nestedStore.InsertEntry(ctx, mustEntryInput(test, entrySpec{accountID: accountID, entryType: ledger.EntryGrant, amountCents: 1, key: "outer"}))
+			return err
+		})
+		if !errors.Is(nestedErr, ledger.ErrDuplicateIdempotencyKey) {
+			test.Errorf("nested WithTx = %v; want ErrDuplicateIdempotencyKey", nestedErr)
+		}
+		nestedErr = txStore.WithTx(ctx, func(ctx context.Context, nestedStore ledger.Store) error {
+			_, err := nestedStore.InsertEntry(ctx, mustEntryInput(test, entrySpec{accountID: accountID, entryType: ledger.EntryGrant, amountCents: 1000, key: "kept"}))
+			return err
+		})
+		if nestedErr != nil {
+			return nestedErr
+		}
+		_, err := txStore.InsertEntry(ctx, mustEntryInput(test, entrySpec{accountID: accountID, entryType: ledger.EntryGrant, amountCents: 5, key: "after"}))
+		return err
+	})
+	if err != nil {
+		test.Fatalf("outer WithTx: %v", err)
+	}
+	assertTotal(test, store, accountID, 1105)
+	if _, err := store.GetEntryByIdempotencyKey(ctx, accountID, mustIdempotencyKey(test, "inner")); !errors.Is(err, ledger.ErrUnknownEntry) {
+		test.Fatalf("a failed nested transaction must roll back its writes, got %v", err)
+	}
+
+	err = store.WithTx(ctx, func(ctx context.Context, txStore ledger.Store) error {
+		if err := txStore.WithTx(ctx, func(ctx context.Context, nestedStore ledger.Store) error {
+			_, err := nestedStore.InsertEntry(ctx, mustEntryInput(test, entrySpec{accountID: accountID, entryType: ledger.EntryGrant, amountCents: 7, key: "nested-commit"}))
+			return err
+		}); err != nil {
+			return err
+		}
+		return errRollback
+	})
+	if !errors.Is(err, errRollback) {
+		test.Fatalf("outer WithTx = %v; want the callback error", err)
+	}
+	assertTotal(test, store, accountID, 1105)
+}
+
+func testJournal(test *testing.T, store ledger.Store) {
+	ctx := context.Background()
+	journalStore := capability[ledger.JournalStore](test, store)
+	accountID := mustAccount(test, store, "tenant-a", "user-1", "default")
+	entry := mustInsertEntry(test, store, entrySpec{accountID: accountID, entryType: ledger.EntryGrant, amountCents: 100, key: "grant"})
+	tenantID := mustTenantID(test, "tenant-a")
+	defaultLedger := mustLedgerID(test, "default")
+	bonusLedger := mustLedgerID(test, "bonus")
+	line := func(tenant ledger.TenantID, ledgerID ledger.LedgerID, account ledger.JournalAccount, amountCents ledger.SignedAmountCents, postedUnixUTC int64) ledger.JournalLine {
+		return ledger.JournalLine{TenantID: tenant, LedgerID: ledgerID, EntryID: entry.EntryID(), Account: account, UserAccountID: accountID, AmountCents: amountCents, PostedUnixUTC: postedUnixUTC}
+	}
+	if err := journalStore.InsertJournalLines(ctx, nil); err != nil {
+		test.Fatalf("InsertJournalLines without lines: %v", err)
+	}
+	err := journalStore.InsertJournalLines(ctx, []ledger.JournalLine{
+		line(tenantID, defaultLedger, ledger.JournalAccountUser, 100, baseUnixUTC),
+		line(tenantID, defaultLedger, ledger.JournalAccountIssuance, -100, baseUnixUTC),
+		line(tenantID, defaultLedger, ledger.JournalAccountUser, -30, baseUnixUTC+hourSeconds),
+		line(tenantID, defaultLedger, ledger.JournalAccountRevenue, 30, baseUnixUTC+hourSeconds),
+		line(tenantID, bonusLedger, ledger.JournalAccountUser, 5, baseUnixUTC),
+		line(tenantID, bonusLedger, ledger.JournalAccountIssuance, -5, baseUnixUTC),
+		line(mustTenantID(test, "tenant-b"), defaultLedger, ledger.JournalAccountUser, 999, baseUnixUTC),
+	})
+	if err != nil {
+		test.Fatalf("InsertJournalLines: %v", err)
+	}
+
+	balances, err := journalStore.SumJournalLines(ctx, tenantID, baseUnixUTC)
+	if err != nil {
+		test.Fatalf("SumJournalLines: %v", err)
+	}
+	expected := []ledger.TrialBalanceLine{
+		{LedgerID: bonusLedger, Account: ledger.JournalAccountIssuance, BalanceCents: -5},
+		{LedgerID: bonusLedger, Account: ledger.JournalAccountUser, BalanceCents: 5},
+		{LedgerID: defaultLedger, Account: ledger.JournalAccountIssuance, BalanceCents: -100},
+		{LedgerID: defaultLedger, Account: ledger.JournalAccountUser, BalanceCents: 100},
+	}
+	if !reflect.DeepEqual(balances, expected) {
+		test.Fatalf("SumJournalLines = %+v; want %+v ordered by ledger and account", balances, expected)
+	}
+	balances, err = journalStore.SumJournalLines(ctx, tenantID, baseUnixUTC+hourSeconds)
+	if err != nil {
+		test.Fatalf("SumJournalLines: %v", err)
+	}
+	if len(balances) != 5 || balances[4].Account != ledger.JournalAccountUser || balances[4].BalanceCents != 70 {
+		test.Fatalf("SumJournalLines after the second posting = %+v", balances)
+	}
+}
+
+func testEntriesByExpiry(test *testing.T, store ledger.Store) {
+	ctx := context.Background()
+	liabilityStore := capability[ledger.LiabilityStore](test, store)
+	firstExpiry := baseUnixUTC + hourSeconds
+	secondExpiry := baseUnixUTC + 2*hourSeconds
+	alice := mustAccount(test, store, "tenant-a", "alice", "default")
+	bob := mustAccount(test, store, "tenant-a", "bob", "default")
+	bonus := mustAccount(test, store, "tenant-a", "alice", "bonus")
+	outsider := mustAccount(test, store, "tenant-b", "alice", "default")
+	reservationID := mustReservationID(test, "order-1")
+	mustInsertEntry(test, store, entrySpec{accountID: alice, entryType: ledger.EntryGrant, amountCents: 100, key: "perpetual"})
+	mustInsertEntry(test, store, entrySpec{accountID: alice, entryType: ledger.EntrySpend, amountCents: -40, key: "spend"})
+	mustInsertEntry(test, store, entrySpec{accountID: alice, entryType: ledger.EntryGrant, amountCents: 30, key: "expiring", expiresAt: firstExpiry})
+	mustInsertEntry(test, store, entrySpec{accountID: alice, entryType: ledger.EntryHold, amountCents: -10, key: "hold", reservationID: &reservationID})
+	mustInsertEntry(test, store, entrySpec{accountID: bob, entryType: ledger.EntryGrant, amountCents: 20, key: "expiring", expiresAt: firstExpiry})
+	mustInsertEntry(test, store, entrySpec{accountID: bob, entryType: ledger.EntryGrant, amountCents: 5, key: "later", expiresAt: secondExpiry})
+	mustInsertEntry(test, store, entrySpec{accountID: bob, entryType: ledger.EntryGrant, amountCents: 7, key: "scheduled", effectiveAt: baseUnixUTC + 3*hourSeconds})
+	mustInsertEntry(test, store, entrySpec{accountID: bob, entryType: ledger.EntryGrant, amountCents: 9, key: "never-effective", effectiveAt: firstExpiry, expiresAt: firstExpiry})
+	mustInsertEntry(test, store, entrySpec{accountID: bonus, entryType: ledger.EntryGrant, amountCents: 3, key: "bonus"})
+	mustInsertEntry(test, store, entrySpec{accountID: outsider, entryType: ledger.EntryGrant, amountCents: 999, key: "outsider"})
+
+	totals, err := liabilityStore.SumEntriesByExpiry(ctx, mustTenantID(test, "tenant-a"), baseUnixUTC)
+	if err != nil {
+		test.Fatalf("SumEntriesByExpiry: %v", err)
+	}
+	got := map[string]map[int64]ledger.SignedAmountCents{}
+	for _, total := range totals {
+		if got[total.LedgerID.String()] == nil {
+			got[total.LedgerID.String()] = map[int64]ledger.SignedAmountCents{}
+		}
+		got[total.LedgerID.String()][total.ExpiresAtUnixUTC] += total.AmountCents
+	}
+	expected := map[string]map[int64]ledger.SignedAmountCents{
+		"default": {0: 60, firstExpiry: 50, secondExpiry: 5},
+		"bonus":   {0: 3},
+	}
+	if !reflect.DeepEqual(got, expected) || len(totals) != 4 {
+		test.Fatalf("SumEntriesByExpiry = %+v; want one row per ledger and expiry: %v", totals, expected)
+	}
+}
+
+func testGrantConsumption(test *testing.T, store ledger.Store) {
+	ctx := context.Background()
+	consumptionStore := capability[ledger.GrantConsumptionStore](test, store)
+	liabilityStore := capability[ledger.LiabilityStore](test, store)
+	firstExpiry := baseUnixUTC + hourSeconds
+	secondExpiry := baseUnixUTC + 2*hourSeconds
+	accountID := mustAccount(test, store, "tenant-a", "alice", "default")
+	otherAccount := mustAccount(test, store, "tenant-a", "bob", "default")
+	mustInsertEntry(test, store, entrySpec{accountID: accountID, entryType: ledger.EntryGrant, amountCents: 100, key: "perpetual"})
+	late := mustInsertEntry(test, store, entrySpec{accountID: accountID, entryType: ledger.EntryGrant, amountCents: 30, key: "late", expiresAt: secondExpiry})
+	early := mustInsertEntry(test, store, entrySpec{accountID: accountID, entryType: ledger.EntryGrant, amountCents: 20, key: "early", expiresAt: firstExpiry})
+	mustInsertEntry(test, store, entrySpec{accountID: accountID, entryType: ledger.EntryGrant, amountCents: 9, key: "scheduled", effectiveAt: baseUnixUTC + 10, expiresAt: secondExpiry})
+	spend := mustInsertEntry(test, store, entrySpec{accountID: accountID, entryType: ledger.EntrySpend, amountCents: -35, key: "spend"})
+
+	grants, err := consumptionStore.ListConsumableGrants(ctx, accountID, baseUnixUTC)
+	if err != nil {
+		test.Fatalf("ListConsumableGrants: %v", err)
+	}
+	assertEntryIDs(test, "consumable", grants, []ledger.Entry{early, late})
+
+	if err := consumptionStore.ConsumeGrant(ctx, accountID, early.EntryID(), 20); err != nil {
+		test.Fatalf("ConsumeGrant early: %v", err)
+	}
+	if err := consumptionStore.ConsumeGrant(ctx, accountID, late.EntryID(), 15); err != nil {
+		test.Fatalf("ConsumeGrant late: %v", err)
+	}
+	for name, entryID := range map[string]ledger.EntryID{"other_account": early.EntryID(), "spend": spend.EntryID()} {
+		target := accountID
+		if name == "other_account" {
+			target = otherAccount
+		}
+		if err := consumptionStore.ConsumeGrant(ctx, target, entryID, 1); !errors.Is(err, ledger.ErrUnknownEntry) {
+			test.Fatalf("ConsumeGrant %s: expected ErrUnknownEntry, got %v", name, err)
+		}
+	}
+
+	grants, err = consumptionStore.ListConsumableGrants(ctx, accountID, baseUnixUTC)
+	if err != nil {
+		test.Fatalf("ListConsumableGrants after consumption: %v", err)
+	}
+	assertEntryIDs(test, "consumable after consumption", grants, []ledger.Entry{late})
+	if grants[0].ConsumedCents() != 15 {
+		test.Fatalf("late grant consumed %d; want 15", grants[0].ConsumedCents())
+	}
+
+	for _, testCase := range []struct {
+		name      string
+		atUnixUTC int64
+		expected  ledger.SignedAmountCents
+	}{
+		{name: "before_expiry", atUnixUTC: baseUnixUTC + 10, expected: 124},
+		{name: "early_expired", atUnixUTC: firstExpiry, expected: 124},
+		{name: "all_expired", atUnixUTC: secondExpiry, expected: 100},
+	} {
+		total, err := store.SumTotal(ctx, accountID, testCase.atUnixUTC)
+		if err != nil {
+			test.Fatalf("%s: SumTotal: %v", testCase.name, err)
+		}
+		if total != testCase.expected {
+			test.Fatalf("%s: SumTotal = %d; want %d", testCase.name, total, testCase.expected)
+		}
+	}
+
+	totals, err := liabilityStore.SumEntriesByExpiry(ctx, mustTenantID(test, "tenant-a"), baseUnixUTC)
+	if err != nil {
+		test.Fatalf("SumEntriesByExpiry: %v", err)
+	}
+	got := map[int64]ledger.ExpiryTotal{}
+	for _, total := range totals {
+		got[total.ExpiresAtUnixUTC] = total
+	}
+	if got[firstExpiry].AmountCents != 0 || got[firstExpiry].ConsumedCents != 20 ||
+		got[secondExpiry].AmountCents != 15 || got[secondExpiry].ConsumedCents != 15 ||
+		got[0].AmountCents != 65 || got[0].ConsumedCents != 0 {
+		test.Fatalf("SumEntriesByExpiry = %+v; want consumed cents split out of each expiry bucket", totals)
+	}
+}
+
+func testEntryFeed(test *testing.T, store ledger.Store) {
+	ctx := context.Background()
+	feedStore := capability[ledger.ChangeFeedStore](test, store)
+	tenantID := mustTenantID(test, "tenant-a")
+	alice := mustAccount(test, store, "tenant-a", "alice", "default")
+	bonus := mustAccount(test, store, "tenant-a", "alice", "bonus")
+	bob := mustAccount(test, store, "tenant-a", "bob", "default")
+	outsider := mustAccount(test, store, "tenant-b", "alice", "default")
+	reservationID := mustReservationID(test, "order-1")
+	entries := []ledger.Entry{
+		mustInsertEntry(test, store, entrySpec{accountID: alice, entryType: ledger.EntryGrant, amountCents: 100, key: "grant"}),
+		mustInsertEntry(test, store, entrySpec{accountID: bonus, entryType: ledger.EntryGrant, amountCents: 10, key: "grant"}),
+		mustInsertEntry(test, store, entrySpec{accountID: bob, entryType: ledger.EntryGrant, amountCents: 20, key: "grant"}),
+		mustInsertEntry(test, store, entrySpec{accountID: alice, entryType: ledger.EntryHold, amountCents: -5, key: "hold", reservationID: &reservationID}),
+	}
+	for _, entry := range entries {
+		if err := feedStore.AppendEntryChange(ctx, tenantID, entry.EntryID()); err != nil {
+			test.Fatalf("AppendEntryChange: %v", err)
+		}
+	}
+	outsiderEntry := mustInsertEntry(test, store, entrySpec{accountID: outsider, entryType: ledger.EntryGrant, amountCents: 1, key: "grant"})
+	if err := feedStore.AppendEntryChange(ctx, mustTenantID(test, "tenant-b"), outsiderEntry.EntryID()); err != nil {
+		test.Fatalf("AppendEntryChange: %v", err)
+	}
+
+	changes, err := feedStore.ListEntryChanges(ctx, tenantID, 0, 10, ledger.EntryChangeFilter{})
+	if err != nil {
+		test.Fatalf("ListEntryChanges: %v", err)
+	}
+	if len(changes) != len(entries) {
+		test.Fatalf("ListEntryChanges returned %d changes; want %d", len(changes), len(entries))
+	}
+	for index, change := range changes {
+		if change.Cursor != int64(index+1) || change.Entry.EntryID() != entries[index].EntryID() {
+			test.Fatalf("change %d = cursor %d entry %s; want cursor %d entry %s", index, change.Cursor, change.Entry.EntryID(), index+1, entries[index].EntryID())
+		}
+	}
+	if changes[1].UserID.String() != "alice" || changes[1].LedgerID.String() != "bonus" {
+		test.Fatalf("change must carry the account's user and ledger, got %s/%s", changes[1].UserID, changes[1].LedgerID)
+	}
+
+	userID := mustUserID(test, "alice")
+	ledgerID := mustLedgerID(test, "default")
+	testCases := []struct {
+		name     string
+		after    int64
+		limit    int
+		filter   ledger.EntryChangeFilter
+		expected []int64
+	}{
+		{name: "after_cursor", after: 2, limit: 10, expected: []int64{3, 4}},
+		{name: "limit", limit: 2, expected: []int64{1, 2}},
+		{name: "ledger", limit: 10, filter: ledger.EntryChangeFilter{LedgerID: &ledgerID}, expected: []int64{1, 3, 4}},
+		{name: "user", limit: 10, filter: ledger.EntryChangeFilter{UserID: &userID}, expected: []int64{1, 2, 4}},
+		{name: "types", limit: 10, filter: ledger.EntryChangeFilter{Types: []ledger.EntryType{ledger.EntryHold}}, expected: []int64{4}},
+	}
+	for _, testCase := range testCases {
+		filtered, err := feedStore.ListEntryChanges(ctx, tenantID, testCase.after, testCase.limit, testCase.filter)
+		if err != nil {
+			test.Fatalf("%s: ListEntryChanges: %v", testCase.name, err)
+		}
+		cursors := make([]int64, 0, len(filtered))
+		for _, change := range filtered {
+			cursors = append(cursors, change.Cursor)
+		}
+		if !reflect.DeepEqual(cursors, testCase.expected) {
+			test.Fatalf("%s: cursors = %v; want %v", testCase.name, cursors, testCase.expected)
+		}
+	}
+
+	err = store.WithTx(ctx, func(ctx context.Context, txStore ledger.Store) error {
+		entry, err := txStore.InsertEntry(ctx, mustEntryInput(test, entrySpec{accountID: bob, entryType: ledger.EntrySpend, amountCents: -1, key: "rolled-back"}))
+		if err != nil {
+			return err
+		}
+		txFeedStore, err := txCapability[ledger.ChangeFeedStore](txStore)
+		if err != nil {
+			return err
+		}
+		if err := txFeedStore.AppendEntryChange(ctx, tenantID, entry.EntryID()); err != nil {
+			return err
+		}
+		return errRollback
+	})
+	if !errors.Is(err, errRollback) {
+		test.Fatalf("WithTx = %v; want the callback error", err)
+	}
+	next := mustInsertEntry(test, store, entrySpec{accountID: bob, entryType: ledger.EntrySpend, amountCents: -1, key: "next"})
+	if err := feedStore.AppendEntryChange(ctx, tenantID, next.EntryID()); err != nil {
+		test.Fatalf("AppendEntryChange: %v", err)
+	}
+	changes, err = feedStore.ListEntryChanges(ctx, tenantID, 4, 10, ledger.EntryChangeFilter{})
+	if err != nil {
+		test.Fatalf("ListEntryChanges: %v", err)
+	}
+	if len(changes) != 1 || changes[0].Cursor != 5 || changes[0].Entry.EntryID() != next.EntryID() {
+		test.Fatalf("a rolled back change must not consume a cursor, got %+v", changes)
+	}
+}
+
+func testOutbox(test *testing.T, store ledger.Store) {
+	ctx := context.Background()
+	outboxStore := capability[ledger.OutboxStore](test, store)
+	event := ledger.OutboxEvent{TenantID: mustTenantID(test, "tenant-a"), EventType: "entry.created", PayloadJSON: `{"amount_cents":100}`, CreatedUnixUTC: baseUnixUTC}
+	if err := outboxStore.InsertOutboxEvent(ctx, event); err != nil {
+		test.Fatalf("InsertOutboxEvent: %v", err)
+	}
+	err := store.WithTx(ctx, func(ctx context.Context, txStore ledger.Store) error {
+		txOutboxStore, err := txCapability[ledger.OutboxStore](txStore)
+		if err != nil {
+			return err
+		}
+		return txOutboxStore.InsertOutboxEvent(ctx, event)
+	})
+	if err != nil {
+		test.Fatalf("InsertOutboxEvent in a transaction: %v", err)
+	}
+}
+
+func testHashChain(test *testing.T, store ledger.Store) {
+	ctx := context.Background()
+	chainStore := capability[ledger.ChainStore](test, store)
+	accountID := mustAccount(test, store, "tenant-a", "user-1", "default")
+	otherAccount := mustAccount(test, store, "tenant-a", "user-2", "default")
+	head, err := chainStore.GetChainHead(ctx, accountID)
+	if err != nil || head != (ledger.ChainHead{}) {
+		test.Fatalf("GetChainHead before any link = %+v, %v; want the zero head", head, err)
+	}
+
+	var links []ledger.ChainLink
+	for index, key := range []string{"first", "second", "third"} {
+		entry := mustInsertEntry(test, store, entrySpec{accountID: accountID, entryType: ledger.EntryGrant, amountCents: 10, key: key})
+		err := store.WithTx(ctx, func(ctx context.Context, txStore ledger.Store) error {
+			txChainStore, err := txCapability[ledger.ChainStore](txStore)
+			if err != nil {
+				return err
+			}
+			head, err := txChainStore.LockChainHead(ctx, accountID)
+			if err != nil {
+				return err
+			}
+			if head.Sequence != int64(index) {
+				test.Errorf("LockChainHead sequence = %d; want %d", head.Sequence, index)
+			}
+			link := ledger.ChainLink{EntryID: entry.EntryID(), Sequence: head.Sequence + 1, PreviousHash: head.Hash, Hash: "hash-" + key}
+			links = append(links, link)
+			return txChainStore.AppendChainLink(ctx, accountID, link)
+		})
+		if err != nil {
+			test.Fatalf("append link %d: %v", index+1, err)
+		}
+	}
+	mustInsertEntry(test, store, entrySpec{accountID: accountID, entryType: ledger.EntryGrant, amountCents: 10, key: "unchained"})
+
+	head, err = chainStore.GetChainHead(ctx, accountID)
+	if err != nil || head != (ledger.ChainHead{Sequence: 3, Hash: "hash-third"}) {
+		test.Fatalf("GetChainHead = %+v, %v; want sequence 3", head, err)
+	}
+	chained, err := chainStore.ListChainedEntries(ctx, accountID, 0, 10)
+	if err != nil {
+		test.Fatalf("ListChainedEntries: %v", err)
+	}
+	if len(chained) != len(links) {
+		test.Fatalf("ListChainedEntries returned %d entries; want %d", len(chained), len(links))
+	}
+	for index, entry := range chained {
+		if entry.Link != links[index] || entry.Entry.EntryID() != links[index].EntryID {
+			test.Fatalf("chained entry %d = %+v; want link %+v", index, entry.Link, links[index])
+		}
+	}
+	chained, err = chainStore.ListChainedEntries(ctx, accountID, 1, 1)
+	if err != nil || len(chained) != 1 || chained[0].Link.Sequence != 2 {
+		test.Fatalf("ListChainedEntries after 1 limit 1 = %+v, %v; want sequence 2", chained, err)
+	}
+
+	unknownEntry := mustInsertEntry(test, store, entrySpec{accountID: otherAccount, entryType: ledger.EntryGrant, amountCents: 10, key: "other"})
+	err = store.WithTx(ctx, func(ctx context.Context, txStore ledger.Store) error {
+		txChainStore, err := txCapability[ledger.ChainStore](txStore)
+		if err != nil {
+			return err
+		}
+		if _, err := txChainStore.LockChainHead(ctx, accountID); err != nil {
+			return err
+		}
+		return txChainStore.AppendChainLink(ctx, accountID, ledger.ChainLink{EntryID: unknownEntry.EntryID(), Sequence: 4, PreviousHash: "hash-third", Hash: "hash-other"})
+	})
+	if !errors.Is(err, ledger.ErrUnknownEntry) {
+		test.Fatalf("AppendChainLink for another account's entry = %v; want ErrUnknownEntry", err)
+	}
+	head, err = chainStore.GetChainHead(ctx, otherAccount)
+	if err != nil || head != (ledger.ChainHead{}) {
+		test.Fatalf("GetChainHead of an unchained account = %+v, %v; want the zero head", head, err)
+	}
+}
+
+func testEntryBatch(test *testing.T, store ledger.Store) {
+	capability[ledger.EntryBatchStore](test, store)
+	chainStore := capability[ledger.ChainStore](test, store)
+	feedStore := capability[ledger.ChangeFeedStore](test, store)
+	consumptionStore := capability[ledger.GrantConsumptionStore](test, store)
+	journalStore := capability[ledger.JournalStore](test, store)
+	ctx := context.Background()
+	tenantID := mustTenantID(test, "tenant-a")
+	ledgerID := mustLedgerID(test, "default")
+	accountID := mustAccount(test, store, "tenant-a", "user-1", "default")
+	grant := mustInsertEntry(test, store, entrySpec{accountID: accountID, entryType: ledger.EntryGrant, amountCents: 100, key: "grant", expiresAt: baseUnixUTC + hourSeconds})
+	insertBatch := func(spec entrySpec, prepare func(entry ledger.Entry, head ledger.ChainHead) (ledger.EntryWrite, error)) (ledger.Entry, error) {
+		var entry ledger.Entry
+		err := store.WithTx(ctx, func(ctx context.Context, txStore ledger.Store) error {
+			batchStore, err := txCapability[ledger.EntryBatchStore](txStore)
+			if err != nil {
+				return err
+			}
+			entry, err = batchStore.InsertEntryBatch(ctx, mustEntryInput(test, spec), prepare)
+			return err
+		})
+		return entry, err
+	}
+
+	spendSpec := entrySpec{accountID: accountID, entryType: ledger.EntrySpend, amountCents: -30, key: "spend", createdAt: baseUnixUTC, metadata: entryMetadata}
+	var prepared ledger.Entry
+	spend, err := insertBatch(spendSpec, func(entry ledger.Entry, head ledger.ChainHead) (ledger.EntryWrite, error) {
+		prepared = entry
+		if head != (ledger.ChainHead{}) {
+			test.Errorf("prepare got head %+v; want the zero head", head)
+		}
+		return ledger.EntryWrite{
+			TenantID:          tenantID,
+			Link:              ledger.ChainLink{EntryID: entry.EntryID(), Sequence: 1, Hash: "hash-spend"},
+			GrantConsumptions: []ledger.GrantConsumption{{GrantEntryID: grant.EntryID(), AmountCents: 30}},
+			OutboxEvent:       &ledger.OutboxEvent{TenantID: tenantID, EventType: "entry.spend", PayloadJSON: `{"amount_cents":-30}`, CreatedUnixUTC: baseUnixUTC},
+			JournalLines: []ledger.JournalLine{
+				{TenantID: tenantID, LedgerID: ledgerID, EntryID: entry.EntryID(), Account: ledger.JournalAccountUser, UserAccountID: accountID, AmountCents: -30, PostedUnixUTC: baseUnixUTC},
+				{TenantID: tenantID, LedgerID: ledgerID, EntryID: entry.EntryID(), Account: ledger.JournalAccountRevenue, AmountCents: 30, PostedUnixUTC: baseUnixUTC},
+			},
+		}, nil
+	})
+	if err != nil {
+		test.Fatalf("InsertEntryBatch: %v", err)
+	}
+	if spend.EntryID() != prepared.EntryID() || spend.CreatedUnixUTC() != baseUnixUTC || spend.AmountCents() != -30 {
+		test.Fatalf("InsertEntryBatch returned %s created at %d for %d; want the prepared entry %s", spend.EntryID(), spend.CreatedUnixUTC(), spend.AmountCents(), prepared.EntryID())
+	}
+	stored, err := store.GetEntry(ctx, accountID, spend.EntryID())
+	if err != nil || stored.IdempotencyKey() != spend.IdempotencyKey() {
+		test.Fatalf("GetEntry after InsertEntryBatch = %s, %v", stored.IdempotencyKey(), err)
+	}
+	head, err := chainStore.GetChainHead(ctx, accountID)
+	if err != nil || head != (ledger.ChainHead{Sequence: 1, Hash: "hash-spend"}) {
+		test.Fatalf("GetChainHead after InsertEntryBatch = %+v, %v; want the spend's link", head, err)
+	}
+	chained, err := chainStore.ListChainedEntries(ctx, accountID, 0, 10)
+	if err != nil || len(chained) != 1 || chained[0].Entry.EntryID() != spend.EntryID() {
+		test.Fatalf("ListChainedEntries after InsertEntryBatch = %d entries, %v; want the spend", len(chained), err)
+	}
+	changes, err := feedStore.ListEntryChanges(ctx, tenantID, 0, 10, ledger.EntryChangeFilter{})
+	if err != nil || len(changes) != 1 || changes[0].Entry.EntryID() != spend.EntryID() {
+		test.Fatalf("ListEntryChanges after InsertEntryBatch = %d changes, %v; want the spend", len(changes), err)
+	}
+	grants, err := consumptionStore.ListConsumableGrants(ctx, accountID, baseUnixUTC)
+	if err != nil || len(grants) != 1 || grants[0].ConsumedCents() != 30 {
+		test.Fatalf("ListConsumableGrants after InsertEntryBatch = %d grants, %v; want the grant with 30 consumed", len(grants), err)
+	}
+	lines, err := journalStore.SumJournalLines(ctx, tenantID, baseUnixUTC)
+	if err != nil {
+		test.Fatalf("SumJournalLines: %v", err)
+	}
+	expectedLines := []ledger.TrialBalanceLine{
+		{LedgerID: ledgerID, Account: ledger.JournalAccountRevenue, BalanceCents: 30},
+		{LedgerID: ledgerID, Account: ledger.JournalAccountUser, BalanceCents: -30},
+	}
+	if !reflect.DeepEqual(lines, expectedLines) {
+		test.Fatalf("SumJournalLines after InsertEntryBatch = %+v; want %+v", lines, expectedLines)
+	}
+
+	_, err = insertBatch(entrySpec{accountID: accountID, entryType: ledger.EntryGrant, amountCents: 10, key: "abandoned"}, func(ledger.Entry, ledger.ChainHead) (ledger.EntryWrite, error) {
+		return ledger.EntryWrite{}, errRollback
+	})
+	if !errors.Is(err, errRollback) {
+		test.Fatalf("InsertEntryBatch with a failing prepare = %v; want its error", err)
+	}
+	if _, err := store.GetEntryByIdempotencyKey(ctx, accountID, mustIdempotencyKey(test, "abandoned")); !errors.Is(err, ledger.ErrUnknownEntry) {
+		test.Fatalf("GetEntryByIdempotencyKey after a failing prepare = %v; want ErrUnknownEntry", err)
+	}
+
+	_, err = insertBatch(spendSpec, func(entry ledger.Entry, head ledger.ChainHead) (ledger.EntryWrite, error) {
+		return ledger.EntryWrite{TenantID: tenantID, Link: ledger.ChainLink{EntryID: entry.EntryID(), Sequence: head.Sequence + 1, PreviousHash: head.Hash, Hash: "hash-replay"}}, nil
+	})
+	if !errors.Is(err, ledger.ErrDuplicateIdempotencyKey) {
+		test.Fatalf("InsertEntryBatch with a taken key = %v; want ErrDuplicateIdempotencyKey", err)
+	}
+	if head, err := chainStore.GetChainHead(ctx, accountID); err != nil || head.Sequence != 1 {
+		test.Fatalf("GetChainHead after a duplicate = %+v, %v; want the spend's link", head, err)
+	}
+}
+
+func testArchive(test *testing.T, store ledger.Store) {
+	archiveStore := capability[ledger.ArchiveStore](test, store)
+	feedStore := capability[ledger.ChangeFeedStore](test, store)
+	ctx := context.Background()
+	tenantID := mustTenantID(test, "tenant-a")
+	accountID := mustAccount(test, store, "tenant-a", "user-1", "default")
+	otherAccount := mustAccount(test, store, "tenant-a", "user-2", "default")
+	archive, err := archiveStore.GetEntryArchive(ctx, accountID)
+	if err != nil || archive != (ledger.EntryArchive{}) {
+		test.Fatalf("GetEntryArchive before compaction = %+v, %v; want the zero archive", archive, err)
+	}
+
+	reservationID := mustReservationID(test, "order-1")
+	mustCreateReservation(test, store, accountID, "order-1", 40, ledger.ReservationStatusCaptured, 0)
+	mustCreateReservation(test, store, accountID, "order-2", 10, ledger.ReservationStatusActive, 0)
+	archived := []ledger.Entry{
+		mustInsertEntry(test, store, entrySpec{accountID: accountID, entryType: ledger.EntryGrant, amountCents: 100, key: "grant"}),
+		mustInsertEntry(test, store, entrySpec{accountID: accountID, entryType: ledger.EntryHold, amountCents: -40, key: "hold", reservationID: &reservationID}),
+	}
+	kept := mustInsertEntry(test, store, entrySpec{accountID: accountID, entryType: ledger.EntryGrant, amountCents: 5, key: "kept", createdAt: baseUnixUTC})
+	otherEntry := mustInsertEntry(test, store, entrySpec{accountID: otherAccount, entryType: ledger.EntryGrant, amountCents: 1, key: "grant"})
+	for index, entry := range append(archived, kept) {
+		if err := feedStore.AppendEntryChange(ctx, tenantID, entry.EntryID()); err != nil {
+			test.Fatalf("AppendEntryChange: %v", err)
+		}
+		err := store.WithTx(ctx, func(ctx context.Context, txStore ledger.Store) error {
+			txArchiveStore, err := txCapability[ledger.ArchiveStore](txStore)
+			if err != nil {
+				return err
+			}
+			if _, err := txArchiveStore.LockChainHead(ctx, accountID); err != nil {
+				return err
+			}
+			return txArchiveStore.AppendChainLink(ctx, accountID, ledger.ChainLink{EntryID: entry.EntryID(), Sequence: int64(index + 1), Hash: "hash-" + entry.IdempotencyKey().String()})
+		})
+		if err != nil {
+			test.Fatalf("append link %d: %v", index+1, err)
+		}
+	}
+
+	record := ledger.EntryArchive{
+		AccountID:       accountID,
+		CutoffUnixUTC:   baseUnixUTC,
+		ThroughSequence: 2,
+		ThroughHash:     "hash-hold",
+		ArchivedEntries: 2,
+		Location:        "archive-1.jsonl.gz",
+		ArchivedUnixUTC: baseUnixUTC + hourSeconds,
+	}
+	archiveEntries := func(entryIDs []ledger.EntryID, reservationIDs []ledger.ReservationID) error {
+		return store.WithTx(ctx, func(ctx context.Context, txStore ledger.Store) error {
+			txArchiveStore, err := txCapability[ledger.ArchiveStore](txStore)
+			if err != nil {
+				return err
+			}
+			return txArchiveStore.ArchiveEntries(ctx, record, entryIDs, reservationIDs)
+		})
+	}
+	if err := archiveEntries([]ledger.EntryID{archived[0].EntryID(), otherEntry.EntryID()}, nil); !errors.Is(err, ledger.ErrUnknownEntry) {
+		test.Fatalf("ArchiveEntries with another account's entry = %v; want ErrUnknownEntry", err)
+	}
+	if err := archiveEntries(nil, []ledger.ReservationID{mustReservationID(test, "missing")}); !errors.Is(err, ledger.ErrUnknownReservation) {
+		test.Fatalf("ArchiveEntries with a missing reservation = %v; want ErrUnknownReservation", err)
+	}
+	if _, err := store.GetEntry(ctx, accountID, archived[0].EntryID()); err != nil {
+		test.Fatalf("a failed ArchiveEntries must not delete entries: %v", err)
+	}
+	if archive, err := archiveStore.GetEntryArchive(ctx, accountID); err != nil || archive != (ledger.EntryArchive{}) {
+		test.Fatalf("a failed ArchiveEntries must not record an archive, got %+v, %v", archive, err)
+	}
+
+	if err := archiveEntries([]ledger.EntryID{archived[0].EntryID(), archived[1].EntryID()}, []ledger.ReservationID{reservationID}); err != nil {
+		test.Fatalf("ArchiveEntries: %v", err)
+	}
+	for _, entry := range archived {
+		if _, err := store.GetEntry(ctx, accountID, entry.EntryID()); !errors.Is(err, ledger.ErrArchivedEntry) {
+			test.Fatalf("GetEntry for an archived entry = %v; want ErrArchivedEntry", err)
+		}
+		if _, err := store.GetEntryByIdempotencyKey(ctx, accountID, entry.IdempotencyKey()); !errors.Is(err, ledger.ErrArchivedEntry) {
+			test.Fatalf("GetEntryByIdempotencyKey for an archived entry = %v; want ErrArchivedEntry", err)
+		}
+	}
+	if _, err := store.InsertEntry(ctx, mustEntryInput(test, entrySpec{accountID: accountID, entryType: ledger.EntryGrant, amountCents: 100, key: "grant"})); !errors.Is(err, ledger.ErrDuplicateIdempotencyKey) {
+		test.Fatalf("InsertEntry with an archived idempotency key = %v; want ErrDuplicateIdempotencyKey", err)
+	}
+	if _, err := store.GetEntry(ctx, otherAccount, archived[0].EntryID()); !errors.Is(err, ledger.ErrUnknownEntry) {
+		test.Fatalf("GetEntry for another account's archived entry = %v; want ErrUnknownEntry", err)
+	}
+	if _, err := store.GetEntryByIdempotencyKey(ctx, otherAccount, mustIdempotencyKey(test, "grant")); err != nil {
+		test.Fatalf("archiving must leave other accounts' keys alone: %v", err)
+	}
+	if _, err := store.GetReservation(ctx, accountID, reservationID); !errors.Is(err, ledger.ErrUnknownReservation) {
+		test.Fatalf("GetReservation for an archived reservation = %v; want ErrUnknownReservation", err)
+	}
+	reservations, err := store.ListReservations(ctx, accountID, 0, 10, ledger.ListReservationsFilter{})
+	if err != nil {
+		test.Fatalf("ListReservations: %v", err)
+	}
+	assertReservationIDs(test, "after archiving", reservations, "order-2")
+	entries, err := store.ListEntries(ctx, accountID, 0, 10, ledger.ListEntriesFilter{})
+	if err != nil {
+		test.Fatalf("ListEntries: %v", err)
+	}
+	assertEntryIDs(test, "after archiving", entries, []ledger.Entry{kept})
+	if _, err := store.GetEntry(ctx, otherAccount, otherEntry.EntryID()); err != nil {
+		test.Fatalf("archiving must not touch other accounts: %v", err)
+	}
+	chained, err := archiveStore.ListChainedEntries(ctx, accountID, 0, 10)
+	if err != nil || len(chained) != 1 || chained[0].Link.Sequence != 3 {
+		test.Fatalf("ListChainedEntries after archiving = %+v, %v; want only sequence 3", chained, err)
+	}
+	changes, err := feedStore.ListEntryChanges(ctx, tenantID, 0, 10, ledger.EntryChangeFilter{})
+	if err != nil || len(changes) != 1 || changes[0].Cursor != 3 {
+		test.Fatalf("ListEntryChanges after archiving = %+v, %v; want only cursor 3", changes, err)
+	}
+	assertTotal(test, store, accountID, 5)
+	archive, err = archiveStore.GetEntryArchive(ctx, accountID)
+	if err != nil || archive != record {
+		test.Fatalf("GetEntryArchive = %+v, %v; want %+v", archive, err, record)
+	}
+
+	record.CutoffUnixUTC = baseUnixUTC + hourSeconds
+	record.ArchivedEntries = 3
+	record.Location = "archive-2.jsonl.gz"
+	if err := archiveEntries(nil, nil); err != nil {
+		test.Fatalf("ArchiveEntries replacing the record: %v", err)
+	}
+	archive, err = archiveStore.GetEntryArchive(ctx, accountID)
+	if err != nil || archive != record {
+		test.Fatalf("GetEntryArchive after replacing = %+v, %v; want %+v", archive, err, record)
+	}
+}
+
+type entrySpec struct {
+	accountID     ledger.AccountID
+	entryType     ledger.EntryType
+	amountCents   int64
+	key           string
+	reservationID *ledger.ReservationID
+	refundOf      *ledger.EntryID
+	expiresAt     int64
+	effectiveAt   int64
+	createdAt     int64
+	metadata      string
+	actor         ledger.Actor
+}
+
+func mustEntryInput(test *testing.T, spec entrySpec) ledger.EntryInput {
+	test.Helper()
+	amount, err := ledger.NewEntryAmountCents(spec.amountCents)
+	if err != nil {
+		test.Fatalf("entry amount: %v", err)
+	}
+	metadata, err := ledger.NewMetadataJSON(spec.metadata)
+	if err != nil {
+		test.Fatalf("metadata: %v", err)
+	}
+	createdAt := spec.createdAt
+	if createdAt == 0 {
+		createdAt = baseUnixUTC - hourSeconds
+	}
+	input, err := ledger.NewEntryInput(spec.accountID, spec.entryType, amount, spec.reservationID, spec.refundOf, mustIdempotencyKey(test, spec.key), spec.expiresAt, metadata, createdAt)
+	if err != nil {
+		test.Fatalf("entry input: %v", err)
+	}
+	return input.WithEffectiveAtUnixUTC(spec.effectiveAt).WithActor(spec.actor)
+}
+
+func mustInsertEntry(test *testing.T, store ledger.Store, spec entrySpec) ledger.Entry {
+	test.Helper()
+	entry, err := store.InsertEntry(context.Background(), mustEntryInput(test, spec))
+	if err != nil {
+		test.Fatalf("InsertEntry %s: %v", spec.key, err)
+	}
+	return entry
+}
+
+func mustCreateReservation(test *testing.T, store ledger.Store, accountID ledger.AccountID, rawReservationID string, amountCents int64, status ledger.ReservationStatus, expiresAtUnixUTC int64) {
+	test.Helper()
+	reservation, err := ledger.NewReservation(accountID, mustReservationID(test, rawReservationID), mustPositiveAmount(test, amountCents), status, expiresAtUnixUTC)
+	if err != nil {
+		test.Fatalf("reservation: %v", err)
+	}
+	if err := store.CreateReservation(context.Background(), reservation); err != nil {
+		test.Fatalf("CreateReservation %s: %v", rawReservationID, err)
+	}
+}
+
+func mustAccount(test *testing.T, store ledger.Store, rawTenantID string, rawUserID string, rawLedgerID string) ledger.AccountID {
+	test.Helper()
+	accountID, err := store.GetOrCreateAccountID(context.Background(), mustTenantID(test, rawTenantID), mustUserID(test, rawUserID), mustLedgerID(test, rawLedgerID))
+	if err != nil {
+		test.Fatalf("GetOrCreateAccountID: %v", err)
+	}
+	return accountID
+}
+
+func mustTenantID(test *testing.T, raw string) ledger.TenantID {
+	test.Helper()
+	value, err := ledger.NewTenantID(raw)
+	if err != nil {
+		test.Fatalf("tenant id: %v", err)
+	}
+	return value
+}
+
+func mustUserID(test *testing.T, raw string) ledger.UserID {
+	test.Helper()
+	value, err := ledger.NewUserID(raw)
+	if err != nil {
+		test.Fatalf("user id: %v", err)
+	}
+	return value
+}
+
+func mustLedgerID(test *testing.T, raw string) ledger.LedgerID {
+	test.Helper()
+	value, err := ledger.NewLedgerID(raw)
+	if err != nil {
+		test.Fatalf("ledger id: %v", err)
+	}
+	return value
+}
+
+func mustReservationID(test *testing.T, raw string) ledger.ReservationID {
+	test.Helper()
+	value, err := ledger.NewReservationID(raw)
+	if err != nil {
+		test.Fatalf("reservation id: %v", err)
+	}
+	return value
+}
+
+func mustIdempotencyKey(test *testing.T, raw string) ledger.IdempotencyKey {
+	test.Helper()
+	value, err := ledger.NewIdempotencyKey(raw)
+	if err != nil {
+		test.Fatalf("idempotency key: %v", err)
+	}
+	return value
+}
+
+func mustPositiveAmount(test *testing.T, cents int64) ledger.PositiveAmountCents {
+	test.Helper()
+	value, err := ledger.NewPositiveAmountCents(cents)
+	if err != nil {
+		test.Fatalf("amount: %v", err)
+	}
+	return value
+}
+
+func assertTotal(test *testing.T, store ledger.Store, accountID ledger.AccountID, expected ledger.SignedAmountCents) {
+	test.Helper()
+	total, err := store.SumTotal(context.Background(), accountID, baseUnixUTC)
+	if err != nil {
+		test.Fatalf("SumTotal: %v", err)
+	}
+	if total != expected {
+		test.Fatalf("SumTotal = %d; want %d", total, expected)
+	}
+}
+
+// assertSameEntry compares entries field by field; metadata is compared as JSON because databases may
+// reformat it.
+func assertSameEntry(test *testing.T, name string, got ledger.Entry, expected ledger.Entry) {
+	test.Helper()
+	gotReservation, gotHasReservation := got.ReservationID()
+	expectedReservation, expectedHasReservation := expected.ReservationID()
+	gotRefundOf, gotHasRefundOf := got.RefundOfEntryID()
+	expectedRefundOf, expectedHasRefundOf := expected.RefundOfEntryID()
+	same := got.EntryID() == expected.EntryID() &&
+		got.AccountID() == expected.AccountID() &&
+		got.Type() == expected.Type() &&
+		got.AmountCents() == expected.AmountCents() &&
+		gotHasReservation == expectedHasReservation && gotReservation == expectedReservation &&
+		gotHasRefundOf == expectedHasRefundOf && gotRefundOf == expectedRefundOf &&
+		got.IdempotencyKey() == expected.IdempotencyKey() &&
+		got.ExpiresAtUnixUTC() == expected.ExpiresAtUnixUTC() &&
+		got.EffectiveAtUnixUTC() == expected.EffectiveAtUnixUTC() &&
+		got.CreatedUnixUTC() == expected.CreatedUnixUTC() &&
+		got.Actor() == expected.Actor()
+	if !same {
+		test.Fatalf("%s: entry = %+v; want %+v", name, got, expected)
+	}
+	var gotMetadata, expectedMetadata any
+	if err := json.Unmarshal([]byte(got.MetadataJSON().String()), &gotMetadata); err != nil {
+		test.Fatalf("%s: metadata: %v", name, err)
+	}
+	if err := json.Unmarshal([]byte(expected.MetadataJSON().String()), &expectedMetadata); err != nil {
+		test.Fatalf("%s: metadata: %v", name, err)
+	}
+	if !reflect.DeepEqual(gotMetadata, expectedMetadata) {
+		test.Fatalf("%s: metadata = %s; want %s", name, got.MetadataJSON(), expected.MetadataJSON())
+	}
+}
+
+func assertEntryIDs(test *testing.T, name string, got []ledger.Entry, expected []ledger.Entry) {
+	test.Helper()
+	if len(got) != len(expected) {
+		test.Fatalf("%s: got %d entries; want %d", name, len(got), len(expected))
+	}
+	for index := range got {
+		if got[index].EntryID() != expected[index].EntryID() {
+			test.Fatalf("%s: entry %d is %s (%s); want %s (%s)", name, index, got[index].EntryID(), got[index].IdempotencyKey(), expected[index].EntryID(), expected[index].IdempotencyKey())
+		}
+	}
+}
+
+// assertSameAccounts compares account sets; accounts created in the same second have no defined order.
+func assertSameAccounts(test *testing.T, name string, got []ledger.Account, expected []ledger.AccountID) {
+	test.Helper()
+	gotIDs := map[ledger.AccountID]bool{}
+	for _, account := range got {
+		gotIDs[account.AccountID()] = true
+	}
+	expectedIDs := map[ledger.AccountID]bool{}
+	for _, accountID := range expected {
+		expectedIDs[accountID] = true
+	}
+	if len(got) != len(expected) || !reflect.DeepEqual(gotIDs, expectedIDs) {
+		test.Fatalf("%s: got accounts %v; want %v", name, gotIDs, expectedIDs)
+	}
+}
+
+// assertReservationIDs compares reservation sets; reservations created in the same second have no defined order.
+func assertReservationIDs(test *testing.T, name string, got []ledger.Reservation, expected ...string) {
+	test.Helper()
+	gotIDs := map[string]bool{}
+	for _, reservation := range got {
+		gotIDs[reservation.ReservationID().String()] = true
+	}
+	expectedIDs := map[string]bool{}
+	for _, reservationID := range expected {
+		expectedIDs[reservationID] = true
+	}
+	if len(got) != len(expected) || !reflect.DeepEqual(gotIDs, expectedIDs) {
+		test.Fatalf("%s: got reservations %v; want %v", name, gotIDs, expectedIDs)
+	}
+}
@@ -0,0 +1,274 @@
+package ledger
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+const (
+	errorBalanceThresholdNegative = "threshold must be zero or greater"
+
+	operationBalanceThresholdCrossed = "balance_threshold_crossed"
+)
+
+// BalanceThreshold watches the available balance of every account in one tenant ledger.
+// An account is below the threshold while its available balance is at or under ThresholdCents,
+// so a threshold of zero fires when an account runs out of credits.
+type BalanceThreshold struct {
+	tenantID       TenantID
+	ledgerID       LedgerID
+	thresholdCents int64
+}
+
+// NewBalanceThreshold validates an available-balance threshold for a tenant ledger.
+func NewBalanceThreshold(tenantID TenantID, ledgerID LedgerID, thresholdCents int64) (BalanceThreshold, error) {
+	if thresholdCents < 0 {
+		return BalanceThreshold{}, fmt.Errorf("%w: %s", ErrInvalidBalanceThreshold, errorBalanceThresholdNegative)
+	}
+	return BalanceThreshold{
+		tenantID:       tenantID,
+		ledgerID:       ledgerID,
+		thresholdCents: thresholdCents,
+	}, nil
+}
+
+// TenantID returns the tenant the threshold applies to.
+func (threshold BalanceThreshold) TenantID() TenantID {
+	return threshold.tenantID
+}
+
+// LedgerID returns the ledger the threshold applies to.
+func (threshold BalanceThreshold) LedgerID() LedgerID {
+	return threshold.ledgerID
+}
+
+// ThresholdCents returns the available balance at or under which an account is below the threshold.
+func (threshold BalanceThreshold) ThresholdCents() int64 {
+	return threshold.thresholdCents
+}
+
+// WithBalanceThresholds emits a balance_threshold_crossed event whenever a committed mutation moves an
+// account's available balance across a threshold.
+func WithBalanceThresholds(thresholds ...BalanceThreshold) ServiceOption {
+	return func(service *Service) {
+		service.balanceThresholds = append(service.balanceThresholds, thresholds...)
+	}
+}
+
+// ThresholdDirection tells which way an available balance crossed a threshold.
+type ThresholdDirection string
+
+const (
+	// ThresholdCrossedDown means the available balance fell to or below the threshold.
+	ThresholdCrossedDown ThresholdDirection = "down"
+	// ThresholdCrossedUp means the available balance rose above the threshold.
+	ThresholdCrossedUp ThresholdDirection = "up"
+)
+
+// String returns the direction as a primitive value.
+func (direction ThresholdDirection) String() string {
+	return string(direction)
+}
+
+// BalanceThresholdCrossing describes one account crossing one threshold in a committed transaction.
+type BalanceThresholdCrossing struct {
+	TenantID               TenantID
+	UserID                 UserID
+	LedgerID               LedgerID
+	AccountID              AccountID
+	ThresholdCents         int64
+	Direction              ThresholdDirection
+	PreviousAvailableCents int64
+	AvailableCents         int64
+	CrossedUnixUTC         int64
+}
+
+type thresholdWatchKey struct{}
+
+type watchedAccount struct {
+	tenantID               TenantID
+	userID                 UserID
+	ledgerID               LedgerID
+	accountID              AccountID
+	previousAvailableCents int64
+}
+
+// thresholdWatch remembers the available balance of each account a transaction resolves, as it was
+// before any write, so thresholds are compared once per transaction rather than once per entry.
+type thresholdWatch struct {
+	accounts []watchedAccount
+	seen     map[AccountID]struct{}
+}
+
+// withTx runs fn in a store transaction and evaluates balance thresholds for the accounts it resolved.
+// Crossings are recorded in the outbox inside the transaction and logged once it has committed, and the
+// domain events the transaction buffered are dispatched to the event sinks only then.
+func (service *Service) withTx(ctx context.Context, fn func(ctx context.Context, transactionStore Store) error) error {
+	var crossings []BalanceThresholdCrossing
+	var events []DomainEvent
+	err := service.store.WithTx(ctx, func(ctx context.Context, transactionStore Store) error {
+		buffer := &eventBuffer{}
+		var err error
+		crossings, err = service.runWatched(context.WithValue(ctx, eventBufferKey{}, buffer), transactionStore, fn)
+		events = buffer.events
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	for index := range crossings {
+		crossing := crossings[index]
+		service.logOperation(ctx, OperationLog{
+			Operation:         operationBalanceThresholdCrossed,
+			TenantID:          crossing.TenantID,
+			UserID:            crossing.UserID,
+			LedgerID:          crossing.LedgerID,
+			ThresholdCrossing: &crossing,
+		})
+	}
+	service.dispatchEvents(ctx, events)
+	return nil
+}
+
+// runWatched runs fn and, when balance thresholds are configured, evaluates them for the accounts fn resolved.
+func (service *Service) runWatched(ctx context.Context, transactionStore Store, fn func(ctx context.Context, transactionStore Store) error) ([]BalanceThresholdCrossing, error) {
+	if len(service.balanceThresholds) == 0 {
+		return nil, fn(ctx, transactionStore)
+	}
+	watch := &thresholdWatch{seen: make(map[AccountID]struct{})}
+	if err := fn(context.WithValue(ctx, thresholdWatchKey{}, watch), transactionStore); err != nil {
+		return nil, err
+	}
+	return service.evaluateThresholds(ctx, transactionStore, watch)
+}
+
+// watchAccount snapshots an account's available balance when a transaction first resolves it.
+func (service *Service) watchAccount(ctx context.Context, store Store, tenantID TenantID, userID UserID, ledgerID LedgerID, accountID AccountID) error {
+	watch, ok := ctx.Value(thresholdWatchKey{}).(*thresholdWatch)
+	if !ok || !service.hasBalanceThreshold(tenantID, ledgerID) {
+		return nil
+	}
+	if _, seen := watch.seen[accountID]; seen {
+		return nil
+	}
+	available, err := availableBalance(ctx, store, accountID, service.nowFn())
+	if err != nil {
+		return err
+	}
+	watch.seen[accountID] = struct{}{}
+	watch.accounts = append(watch.accounts, watchedAccount{
+		tenantID:               tenantID,
+		userID:                 userID,
+		ledgerID:               ledgerID,
+		accountID:              accountID,
+		previousAvailableCents: available,
+	})
+	return nil
+}
+
+func (service *Service) evaluateThresholds(ctx context.Context, store Store, watch *thresholdWatch) ([]BalanceThresholdCrossing, error) {
+	var crossings []BalanceThresholdCrossing
+	nowUnixUTC := service.nowFn()
+	for _, account := range watch.accounts {
+		available, err := availableBalance(ctx, store, account.accountID, nowUnixUTC)
+		if err != nil {
+			return nil, err
+		}
+		for _, threshold := range service.balanceThresholds {
+			if threshold.tenantID != account.tenantID || threshold.ledgerID != account.ledgerID {
+				continue
+			}
+			wasBelow := account.previousAvailableCents <= threshold.thresholdCents
+			isBelow := available <= threshold.thresholdCents
+			if wasBelow == isBelow {
+				continue
+			}
+			direction := ThresholdCrossedUp
+			if isBelow {
+				direction = ThresholdCrossedDown
+			}
+			crossing := BalanceThresholdCrossing{
+				TenantID:               account.tenantID,
+				UserID:                 account.userID,
+				LedgerID:               account.ledgerID,
+				AccountID:              account.accountID,
+				ThresholdCents:         threshold.thresholdCents,
+				Direction:              direction,
+				PreviousAvailableCents: account.previousAvailableCents,
+				AvailableCents:         available,
+				CrossedUnixUTC:         nowUnixUTC,
+			}
+			if err := service.recordThresholdEvent(ctx, store, crossing); err != nil {
+				return nil, err
+			}
+			service.publishThresholdCrossing(ctx, crossing)
+			crossings = append(crossings, crossing)
+		}
+	}
+	return crossings, nil
+}
+
+func (service *Service) hasBalanceThreshold(tenantID TenantID, ledgerID LedgerID) bool {
+	for _, threshold := range service.balanceThresholds {
+		if threshold.tenantID == tenantID && threshold.ledgerID == ledgerID {
+			return true
+		}
+	}
+	return false
+}
+
+func availableBalance(ctx context.Context, store Store, accountID AccountID, atUnixUTC int64) (int64, error) {
+	total, holds, err := sumBalance(ctx, store, accountID, atUnixUTC)
+	if err != nil {
+		return 0, err
+	}
+	return calculateAvailable(total, holds).Int64(), nil
+}
+
+type thresholdEventPayload struct {
+	TenantID               string `json:"tenant_id"`
+	UserID                 string `json:"user_id"`
+	LedgerID               string `json:"ledger_id"`
+	AccountID              string `json:"account_id"`
+	ThresholdCents         int64  `json:"threshold_cents"`
+	Direction              string `json:"direction"`
+	PreviousAvailableCents int64  `json:"previous_available_cents"`
+	AvailableCents         int64  `json:"available_cents"`
+	CrossedUnixUTC         int64  `json:"crossed_unix_utc"`
+}
+
+func newThresholdEventPayload(crossing BalanceThresholdCrossing) thresholdEventPayload {
+	return thresholdEventPayload{
+		TenantID:               crossing.TenantID.String(),
+		UserID:                 crossing.UserID.String(),
+		LedgerID:               crossing.LedgerID.String(),
+		AccountID:              crossing.AccountID.String(),
+		ThresholdCents:         crossing.ThresholdCents,
+		Direction:              crossing.Direction.String(),
+		PreviousAvailableCents: crossing.PreviousAvailableCents,
+		AvailableCents:         crossing.AvailableCents,
+		CrossedUnixUTC:         crossing.CrossedUnixUTC,
+	}
+}
+
+// recordThresholdEvent adds a balance_threshold_crossed outbox event for tenants with an outbox.
+func (service *Service) recordThresholdEvent(ctx context.Context, store Store, crossing BalanceThresholdCrossing) error {
+	if _, outboxTenant := service.outboxTenants[crossing.TenantID]; !outboxTenant {
+		return nil
+	}
+	outboxStore, ok := store.(OutboxStore)
+	if !ok {
+		return ErrOutboxUnsupported
+	}
+	payloadJSON, err := json.Marshal(newThresholdEventPayload(crossing))
+	if err != nil {
+		return err
+	}
+	return outboxStore.InsertOutboxEvent(ctx, OutboxEvent{
+		TenantID:       crossing.TenantID,
+		EventType:      operationBalanceThresholdCrossed,
+		PayloadJSON:    string(payloadJSON),
+		CreatedUnixUTC: crossing.CrossedUnixUTC,
+	})
+}
@@ -0,0 +1,200 @@
+package ledger
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestNewBalanceThresholdRejectsNegativeThreshold(test *testing.T) {
+	test.Parallel()
+	if _, err := NewBalanceThreshold(mustTenantID(test, defaultTenantIDValue), mustLedgerID(test, defaultLedgerIDValue), -1); !errors.Is(err, ErrInvalidBalanceThreshold) {
+		test.Fatalf("expected invalid balance threshold, got %v", err)
+	}
+	threshold := mustBalanceThreshold(test, mustTenantID(test, defaultTenantIDValue), mustLedgerID(test, "promo"), 250)
+	if threshold.TenantID().String() != defaultTenantIDValue || threshold.LedgerID().String() != "promo" || threshold.ThresholdCents() != 250 {
+		test.Fatalf("unexpected balance threshold %+v", threshold)
+	}
+}
+
+func TestBalanceThresholdsEmitOnlyWhenCrossed(test *testing.T) {
+	test.Parallel()
+	store := newStubStore(test, 0)
+	logger := &recorderLogger{}
+	tenantID := mustTenantID(test, defaultTenantIDValue)
+	userID := mustUserID(test, "user-1")
+	ledgerID := mustLedgerID(test, defaultLedgerIDValue)
+	lowBalance := mustBalanceThreshold(test, tenantID, ledgerID, 500)
+	empty := mustBalanceThreshold(test, tenantID, ledgerID, 0)
+	service, err := NewService(store, func() int64 { return 100 }, WithOperationLogger(logger), WithBalanceThresholds(lowBalance, empty), WithOutbox(tenantID))
+	if err != nil {
+		test.Fatalf("new service: %v", err)
+	}
+	ctx := context.Background()
+	metadata := mustMetadata(test, "{}")
+	spend := func(rawKey string, amountCents int64) {
+		test.Helper()
+		if err := service.Spend(ctx, tenantID, userID, ledgerID, mustPositiveAmount(test, amountCents), mustIdempotencyKey(test, rawKey), metadata); err != nil {
+			test.Fatalf("spend %s: %v", rawKey, err)
+		}
+	}
+
+	if err := service.Grant(ctx, tenantID, userID, ledgerID, mustPositiveAmount(test, 1000), mustIdempotencyKey(test, "grant-1"), 0, metadata); err != nil {
+		test.Fatalf("grant: %v", err)
+	}
+	assertCrossings(test, logger, []BalanceThresholdCrossing{
+		{ThresholdCents: 500, Direction: ThresholdCrossedUp, PreviousAvailableCents: 0, AvailableCents: 1000},
+		{ThresholdCents: 0, Direction: ThresholdCrossedUp, PreviousAvailableCents: 0, AvailableCents: 1000},
+	})
+	spend("spend-1", 300)
+	assertCrossings(test, logger, nil)
+	spend("spend-2", 300)
+	assertCrossings(test, logger, []BalanceThresholdCrossing{{ThresholdCents: 500, Direction: ThresholdCrossedDown, PreviousAvailableCents: 700, AvailableCents: 400}})
+	spend("spend-3", 100)
+	assertCrossings(test, logger, nil)
+	if err := service.Spend(ctx, tenantID, userID, ledgerID, mustPositiveAmount(test, 900), mustIdempotencyKey(test, "spend-too-much"), metadata); !errors.Is(err, ErrInsufficientFunds) {
+		test.Fatalf("expected insufficient funds, got %v", err)
+	}
+	assertCrossings(test, logger, nil)
+
+	reservationID := mustReservationID(test, "order-1")
+	if err := service.Reserve(ctx, tenantID, userID, ledgerID, mustPositiveAmount(test, 300), reservationID, mustIdempotencyKey(test, "reserve-1"), 0, metadata); err != nil {
+		test.Fatalf("reserve: %v", err)
+	}
+	assertCrossings(test, logger, []BalanceThresholdCrossing{{ThresholdCents: 0, Direction: ThresholdCrossedDown, PreviousAvailableCents: 300, AvailableCents: 0}})
+	if err := service.Capture(ctx, tenantID, userID, ledgerID, reservationID, mustIdempotencyKey(test, "capture-1"), mustPositiveAmount(test, 300), metadata); err != nil {
+		test.Fatalf("capture: %v", err)
+	}
+	assertCrossings(test, logger, nil)
+
+	thresholdEvents := 0
+	for _, event := range store.outboxEvents {
+		if event.EventType != operationBalanceThresholdCrossed {
+			continue
+		}
+		thresholdEvents++
+		var payload map[string]any
+		if err := json.Unmarshal([]byte(event.PayloadJSON), &payload); err != nil {
+			test.Fatalf("decode payload: %v", err)
+		}
+		if payload["user_id"] != "user-1" || payload["ledger_id"] != defaultLedgerIDValue || payload["direction"] == nil {
+			test.Fatalf("unexpected threshold payload: %s", event.PayloadJSON)
+		}
+	}
+	if thresholdEvents != 4 {
+		test.Fatalf("expected four threshold events in the outbox, got %d", thresholdEvents)
+	}
+}
+
+func TestBalanceThresholdsIgnoreOtherLedgers(test *testing.T) {
+	test.Parallel()
+	store := newStubStore(test, 0)
+	logger := &recorderLogger{}
+	tenantID := mustTenantID(test, defaultTenantIDValue)
+	promo := mustBalanceThreshold(test, tenantID, mustLedgerID(test, "promo"), 0)
+	highBalance := mustBalanceThreshold(test, tenantID, mustLedgerID(test, defaultLedgerIDValue), 1000)
+	service, err := NewService(store, func() int64 { return 100 }, WithOperationLogger(logger), WithBalanceThresholds(promo, highBalance))
+	if err != nil {
+		test.Fatalf("new service: %v", err)
+	}
+	if err := service.Grant(context.Background(), tenantID, mustUserID(test, "user-1"), mustLedgerID(test, defaultLedgerIDValue), mustPositiveAmount(test, 100), mustIdempotencyKey(test, "grant-1"), 0, mustMetadata(test, "{}")); err != nil {
+		test.Fatalf("grant: %v", err)
+	}
+	assertCrossings(test, logger, nil)
+}
+
+func TestBalanceThresholdsReturnStoreFailures(test *testing.T) {
+	test.Parallel()
+	tenantID := mustTenantID(test, defaultTenantIDValue)
+	userID := mustUserID(test, "user-1")
+	ledgerID := mustLedgerID(test, defaultLedgerIDValue)
+	threshold := mustBalanceThreshold(test, tenantID, ledgerID, 500)
+	sumTotalError := errors.New("sum total failed")
+
+	test.Run("snapshot", func(test *testing.T) {
+		test.Parallel()
+		store := newStubStore(test, 0)
+		store.sumTotalError = sumTotalError
+		service, err := NewService(store, func() int64 { return 100 }, WithBalanceThresholds(threshold))
+		if err != nil {
+			test.Fatalf("new service: %v", err)
+		}
+		if err := service.Grant(context.Background(), tenantID, userID, ledgerID, mustPositiveAmount(test, 100), mustIdempotencyKey(test, "grant-1"), 0, mustMetadata(test, "{}")); !errors.Is(err, sumTotalError) {
+			test.Fatalf("expected %v, got %v", sumTotalError, err)
+		}
+	})
+
+	test.Run("evaluation", func(test *testing.T) {
+		test.Parallel()
+		store := newStubStore(test, 0)
+		service, err := NewService(store, func() int64 { return 100 }, WithBalanceThresholds(threshold))
+		if err != nil {
+			test.Fatalf("new service: %v", err)
+		}
+		watch := &thresholdWatch{seen: make(map[AccountID]struct{})}
+		ctx := context.WithValue(context.Background(), thresholdWatchKey{}, watch)
+		for range 2 {
+			if err := service.watchAccount(ctx, store, tenantID, userID, ledgerID, store.accountID); err != nil {
+				test.Fatalf("watch account: %v", err)
+			}
+		}
+		if len(watch.accounts) != 1 {
+			test.Fatalf("expected the account to be snapshotted once, got %d", len(watch.accounts))
+		}
+		store.sumTotalError = sumTotalError
+		if _, err := service.evaluateThresholds(ctx, store, watch); !errors.Is(err, sumTotalError) {
+			test.Fatalf("expected %v, got %v", sumTotalError, err)
+		}
+	})
+
+	test.Run("outbox", func(test *testing.T) {
+		test.Parallel()
+		store := newStubStore(test, 1000)
+		service, err := NewService(store, func() int64 { return 100 }, WithBalanceThresholds(threshold), WithOutbox(tenantID))
+		if err != nil {
+			test.Fatalf("new service: %v", err)
+		}
+		watch := &thresholdWatch{accounts: []watchedAccount{{tenantID: tenantID, userID: userID, ledgerID: ledgerID, accountID: store.accountID}}}
+		if _, err := service.evaluateThresholds(context.Background(), baseStore{Store: store}, watch); !errors.Is(err, ErrOutboxUnsupported) {
+			test.Fatalf("expected ErrOutboxUnsupported, got %v", err)
+		}
+	})
+}
+
+func mustBalanceThreshold(test *testing.T, tenantID TenantID, ledgerID LedgerID, thresholdCents int64) BalanceThreshold {
+	test.Helper()
+	threshold, err := NewBalanceThreshold(tenantID, ledgerID, thresholdCents)
+	if err != nil {
+		test.Fatalf("balance threshold: %v", err)
+	}
+	return threshold
+}
+
+// assertCrossings checks the threshold crossings logged since the previous call and clears the logger.
+func assertCrossings(test *testing.T, logger *recorderLogger, expected []BalanceThresholdCrossing) {
+	test.Helper()
+	var crossings []BalanceThresholdCrossing
+	for _, entry := range logger.entries {
+		if entry.Operation != operationBalanceThresholdCrossed {
+			continue
+		}
+		if entry.ThresholdCrossing == nil || entry.Status != operationStatusOK {
+			test.Fatalf("unexpected threshold log entry: %+v", entry)
+		}
+		crossings = append(crossings, *entry.ThresholdCrossing)
+	}
+	logger.entries = nil
+	if len(crossings) != len(expected) {
+		test.Fatalf("expected %d crossings, got %+v", len(expected), crossings)
+	}
+	for index, crossing := range crossings {
+		want := expected[index]
+		if crossing.ThresholdCents != want.ThresholdCents || crossing.Direction != want.Direction || crossing.PreviousAvailableCents != want.PreviousAvailableCents || crossing.AvailableCents != want.AvailableCents {
+			test.Fatalf("crossing %d: expected %+v, got %+v", index, want, crossing)
+		}
+		if crossing.UserID.String() != "user-1" || crossing.CrossedUnixUTC != 100 {
+			test.Fatalf("crossing %d: unexpected attribution %+v", index, crossing)
+		}
+	}
+}
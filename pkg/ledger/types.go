@@ -15,6 +15,8 @@ const (
 	errorAmountGreaterThanZero = "must be greater than zero"
 	errorAmountNonZero         = "must be non-zero"
 	errorUnknownValue          = "unknown value"
+	errorDuplicateValue        = "duplicate value"
+	errorBeforeExpiration      = "must be before expiration"
 )
 
 // AmountCents is a non-negative currency value in cents.
@@ -82,11 +84,13 @@ const (
 type EntryType string
 
 const (
-	EntryGrant       EntryType = "grant"
-	EntryHold        EntryType = "hold"
-	EntryReverseHold EntryType = "reverse_hold"
-	EntrySpend       EntryType = "spend"
-	EntryRefund      EntryType = "refund"
+	EntryGrant          EntryType = "grant"
+	EntryHold           EntryType = "hold"
+	EntryReverseHold    EntryType = "reverse_hold"
+	EntrySpend          EntryType = "spend"
+	EntryRefund         EntryType = "refund"
+	EntryGrantCancel    EntryType = "grant_cancel"
+	EntryOpeningBalance EntryType = "opening_balance"
 )
 
 // Reservation represents a stored reservation record.
@@ -100,31 +104,45 @@ type Reservation struct {
 	updatedUnixUTC   int64
 }
 
+// Account represents a stored account: one user's balance in one tenant ledger.
+type Account struct {
+	accountID      AccountID
+	tenantID       TenantID
+	userID         UserID
+	ledgerID       LedgerID
+	createdUnixUTC int64
+}
+
 // EntryInput represents a new ledger entry to persist.
 type EntryInput struct {
-	accountID        AccountID
-	entryType        EntryType
-	amountCents      EntryAmountCents
-	reservationID    *ReservationID
-	refundOfEntryID  *EntryID
-	idempotencyKey   IdempotencyKey
-	expiresAtUnixUTC int64
-	metadata         MetadataJSON
-	createdUnixUTC   int64
+	accountID          AccountID
+	entryType          EntryType
+	amountCents        EntryAmountCents
+	reservationID      *ReservationID
+	refundOfEntryID    *EntryID
+	idempotencyKey     IdempotencyKey
+	expiresAtUnixUTC   int64
+	metadata           MetadataJSON
+	createdUnixUTC     int64
+	effectiveAtUnixUTC int64
+	actor              Actor
 }
 
 // Entry represents a persisted ledger entry.
 type Entry struct {
-	entryID          EntryID
-	accountID        AccountID
-	entryType        EntryType
-	amountCents      EntryAmountCents
-	reservationID    *ReservationID
-	refundOfEntryID  *EntryID
-	idempotencyKey   IdempotencyKey
-	expiresAtUnixUTC int64
-	metadata         MetadataJSON
-	createdUnixUTC   int64
+	entryID            EntryID
+	accountID          AccountID
+	entryType          EntryType
+	amountCents        EntryAmountCents
+	reservationID      *ReservationID
+	refundOfEntryID    *EntryID
+	idempotencyKey     IdempotencyKey
+	expiresAtUnixUTC   int64
+	metadata           MetadataJSON
+	createdUnixUTC     int64
+	effectiveAtUnixUTC int64
+	actor              Actor
+	consumedCents      AmountCents
 }
 
 // Balance is the current total and available funds for an account.
@@ -138,6 +156,13 @@ type ListEntriesFilter struct {
 	Types                []EntryType
 	ReservationID        *ReservationID
 	IdempotencyKeyPrefix *IdempotencyKey
+	// Actor keeps entries whose actor matches every non-empty field of this value.
+	Actor Actor
+	// BeforeEntryID turns the created-before cursor into a keyset cursor: entries created in the cursor's
+	// second are kept when their ID sorts before this one, so pages of one busy second never overlap or skip.
+	BeforeEntryID *EntryID
+	// EffectiveAfterUnixUTC keeps entries that take effect after this time; zero is not applied.
+	EffectiveAfterUnixUTC int64
 }
 
 // ListReservationsFilter narrows ListReservations queries.
@@ -145,6 +170,16 @@ type ListReservationsFilter struct {
 	Statuses []ReservationStatus
 }
 
+// ListAccountsFilter narrows ListAccounts queries. Zero values are not applied.
+// NonZeroBalance keeps accounts whose total balance at BalanceAtUnixUTC is not zero.
+type ListAccountsFilter struct {
+	LedgerID            *LedgerID
+	UserIDPrefix        string
+	CreatedAfterUnixUTC int64
+	NonZeroBalance      bool
+	BalanceAtUnixUTC    int64
+}
+
 // NewUserID validates and normalizes a user id.
 func NewUserID(raw string) (UserID, error) {
 	normalized, err := normalizeIdentifier(raw, ErrInvalidUserID)
@@ -365,7 +400,7 @@ func (entryType EntryType) String() string {
 // IsValid reports whether the entry type is recognized.
 func (entryType EntryType) IsValid() bool {
 	switch entryType {
-	case EntryGrant, EntryHold, EntryReverseHold, EntrySpend, EntryRefund:
+	case EntryGrant, EntryHold, EntryReverseHold, EntrySpend, EntryRefund, EntryGrantCancel, EntryOpeningBalance:
 		return true
 	default:
 		return false
@@ -455,6 +490,54 @@ func (reservation Reservation) UpdatedUnixUTC() int64 {
 	return reservation.updatedUnixUTC
 }
 
+// NewAccount constructs an account record.
+func NewAccount(accountID AccountID, tenantID TenantID, userID UserID, ledgerID LedgerID, createdUnixUTC int64) (Account, error) {
+	if err := validateIdentifierValue(accountID.value, ErrInvalidAccountID); err != nil {
+		return Account{}, err
+	}
+	if err := validateIdentifierValue(tenantID.value, ErrInvalidTenantID); err != nil {
+		return Account{}, err
+	}
+	if err := validateIdentifierValue(userID.value, ErrInvalidUserID); err != nil {
+		return Account{}, err
+	}
+	if err := validateIdentifierValue(ledgerID.value, ErrInvalidLedgerID); err != nil {
+		return Account{}, err
+	}
+	return Account{
+		accountID:      accountID,
+		tenantID:       tenantID,
+		userID:         userID,
+		ledgerID:       ledgerID,
+		createdUnixUTC: createdUnixUTC,
+	}, nil
+}
+
+// AccountID returns the account identifier.
+func (account Account) AccountID() AccountID {
+	return account.accountID
+}
+
+// TenantID returns the owning tenant.
+func (account Account) TenantID() TenantID {
+	return account.tenantID
+}
+
+// UserID returns the owning user.
+func (account Account) UserID() UserID {
+	return account.userID
+}
+
+// LedgerID returns the ledger the account belongs to.
+func (account Account) LedgerID() LedgerID {
+	return account.ledgerID
+}
+
+// CreatedUnixUTC returns the creation timestamp.
+func (account Account) CreatedUnixUTC() int64 {
+	return account.createdUnixUTC
+}
+
 // NewEntryInput constructs a new ledger entry payload.
 func NewEntryInput(accountID AccountID, entryType EntryType, amountCents EntryAmountCents, reservationID *ReservationID, refundOfEntryID *EntryID, idempotencyKey IdempotencyKey, expiresAtUnixUTC int64, metadata MetadataJSON, createdUnixUTC int64) (EntryInput, error) {
 	if err := validateIdentifierValue(accountID.value, ErrInvalidAccountID); err != nil {
@@ -546,6 +629,28 @@ func (entry EntryInput) CreatedUnixUTC() int64 {
 	return entry.createdUnixUTC
 }
 
+// EffectiveAtUnixUTC returns the time the entry starts counting toward balances (0 means immediately).
+func (entry EntryInput) EffectiveAtUnixUTC() int64 {
+	return entry.effectiveAtUnixUTC
+}
+
+// WithEffectiveAtUnixUTC returns a copy of the entry that only counts toward balances from effectiveAtUnixUTC.
+func (entry EntryInput) WithEffectiveAtUnixUTC(effectiveAtUnixUTC int64) EntryInput {
+	entry.effectiveAtUnixUTC = effectiveAtUnixUTC
+	return entry
+}
+
+// Actor returns who is writing the entry.
+func (entry EntryInput) Actor() Actor {
+	return entry.actor
+}
+
+// WithActor returns a copy of the entry attributed to actor.
+func (entry EntryInput) WithActor(actor Actor) EntryInput {
+	entry.actor = actor
+	return entry
+}
+
 // NewEntry constructs a persisted ledger entry.
 func NewEntry(entryID EntryID, accountID AccountID, entryType EntryType, amountCents EntryAmountCents, reservationID *ReservationID, refundOfEntryID *EntryID, idempotencyKey IdempotencyKey, expiresAtUnixUTC int64, metadata MetadataJSON, createdUnixUTC int64) (Entry, error) {
 	if err := validateIdentifierValue(entryID.value, ErrInvalidEntryID); err != nil {
@@ -625,7 +730,60 @@ func (entry Entry) CreatedUnixUTC() int64 {
 	return entry.createdUnixUTC
 }
 
-// Store is the persistence contract used by Service.
+// EffectiveAtUnixUTC returns the time the entry starts counting toward balances (0 means immediately).
+func (entry Entry) EffectiveAtUnixUTC() int64 {
+	return entry.effectiveAtUnixUTC
+}
+
+// WithEffectiveAtUnixUTC returns a copy of the entry that only counts toward balances from effectiveAtUnixUTC.
+func (entry Entry) WithEffectiveAtUnixUTC(effectiveAtUnixUTC int64) Entry {
+	entry.effectiveAtUnixUTC = effectiveAtUnixUTC
+	return entry
+}
+
+// Actor returns who wrote the entry; the zero Actor when unknown.
+func (entry Entry) Actor() Actor {
+	return entry.actor
+}
+
+// WithActor returns a copy of the entry attributed to actor.
+func (entry Entry) WithActor(actor Actor) Entry {
+	entry.actor = actor
+	return entry
+}
+
+// IsPendingAt reports whether the entry has not yet taken effect at the supplied time.
+func (entry Entry) IsPendingAt(atUnixUTC int64) bool {
+	return entry.effectiveAtUnixUTC > atUnixUTC
+}
+
+// ConsumedCents returns how much of an expiring grant debits have drawn on; 0 for every other entry.
+func (entry Entry) ConsumedCents() AmountCents {
+	return entry.consumedCents
+}
+
+// WithConsumedCents returns a copy of the entry with consumedCents of it drawn on by debits.
+func (entry Entry) WithConsumedCents(consumedCents AmountCents) Entry {
+	entry.consumedCents = consumedCents
+	return entry
+}
+
+// BalanceCentsAt returns what the entry adds to its account's total at atUnixUTC: nothing for holds or while
+// pending, only the consumed part once it has expired, and its full amount otherwise.
+func (entry Entry) BalanceCentsAt(atUnixUTC int64) int64 {
+	if entry.entryType == EntryHold || entry.entryType == EntryReverseHold || entry.IsPendingAt(atUnixUTC) {
+		return 0
+	}
+	if entry.expiresAtUnixUTC != 0 && entry.expiresAtUnixUTC <= atUnixUTC {
+		return entry.consumedCents.Int64()
+	}
+	return entry.amountCents.Int64()
+}
+
+// Store is the persistence contract used by Service. Features that need more from a store use optional
+// capability interfaces (AccountStore, VelocityStore, JournalStore, GrantConsumptionStore, LiabilityStore,
+// ChangeFeedStore, OutboxStore, ChainStore, BalanceStore, ArchiveStore, EntryBatchStore), which Service
+// detects by type assertion on the store and on the transaction stores WithTx passes to fn.
 type Store interface {
 	WithTx(ctx context.Context, fn func(ctx context.Context, txStore Store) error) error
 	GetOrCreateAccountID(ctx context.Context, tenantID TenantID, userID UserID, ledgerID LedgerID) (AccountID, error)
@@ -642,6 +800,112 @@ type Store interface {
 	ListEntries(ctx context.Context, accountID AccountID, beforeUnixUTC int64, limit int, filter ListEntriesFilter) ([]Entry, error)
 }
 
+// AccountStore is an optional Store capability for account discovery. GetAccountID returns ErrUnknownAccount
+// for a user without an account in the ledger. Read paths use it so they never create accounts, and fall back
+// to GetOrCreateAccountID without it. ListAccounts, VerifyAccounts, and CompactAccounts require it.
+type AccountStore interface {
+	GetAccountID(ctx context.Context, tenantID TenantID, userID UserID, ledgerID LedgerID) (AccountID, error)
+	ListAccounts(ctx context.Context, tenantID TenantID, beforeCreatedUnixUTC int64, limit int, filter ListAccountsFilter) ([]Account, error)
+}
+
+// VelocityStore is an optional Store capability that sums an account's debits since a point in time.
+// Debits checked against a VelocityRule require it.
+type VelocityStore interface {
+	SumDebitVelocity(ctx context.Context, accountID AccountID, sinceUnixUTC int64) (DebitVelocity, error)
+}
+
+// JournalStore is an optional Store capability for the double-entry journal. Every entry posts its journal
+// lines when the store implements it; TrialBalance requires it. Breakage nets out consumed credit only when the
+// store also implements GrantConsumptionStore.
+type JournalStore interface {
+	InsertJournalLines(ctx context.Context, lines []JournalLine) error
+	SumJournalLines(ctx context.Context, tenantID TenantID, atUnixUTC int64) ([]TrialBalanceLine, error)
+}
+
+// GrantConsumptionStore is an optional Store capability that records which expiring grants spends draw on, so
+// the consumed part stays in the balance after the grant expires. Without it an expired grant no longer counts.
+type GrantConsumptionStore interface {
+	ListConsumableGrants(ctx context.Context, accountID AccountID, atUnixUTC int64) ([]Entry, error)
+	ConsumeGrant(ctx context.Context, accountID AccountID, entryID EntryID, amountCents AmountCents) error
+}
+
+// LiabilityStore is an optional Store capability that totals a tenant's entries by expiry. LiabilityReport
+// requires it.
+type LiabilityStore interface {
+	SumEntriesByExpiry(ctx context.Context, tenantID TenantID, atUnixUTC int64) ([]ExpiryTotal, error)
+}
+
+// ChangeFeedStore is an optional Store capability for the tenant change feed. Every entry is appended to the
+// feed when the store implements it; ListEntryChanges requires it. Cursors must follow commit order, so a reader
+// never skips an entry that commits after a later cursor was read. The SQL stores number entries from a
+// per-tenant head row that AppendEntryChange locks until commit, which serializes the entry writes of one
+// tenant from their feed append to their commit; writes of different tenants do not contend.
+type ChangeFeedStore interface {
+	AppendEntryChange(ctx context.Context, tenantID TenantID, entryID EntryID) error
+	ListEntryChanges(ctx context.Context, tenantID TenantID, afterCursor int64, limit int, filter EntryChangeFilter) ([]EntryChange, error)
+}
+
+// OutboxStore is an optional Store capability for the webhook outbox. Entries of tenants configured with
+// WithOutbox fail with ErrOutboxUnsupported when the store does not implement it.
+type OutboxStore interface {
+	InsertOutboxEvent(ctx context.Context, event OutboxEvent) error
+}
+
+// ChainStore is an optional Store capability for per-account hash chains. Every entry is linked into its
+// account's chain when the store implements it; VerifyChain requires it.
+type ChainStore interface {
+	LockChainHead(ctx context.Context, accountID AccountID) (ChainHead, error)
+	AppendChainLink(ctx context.Context, accountID AccountID, link ChainLink) error
+	GetChainHead(ctx context.Context, accountID AccountID) (ChainHead, error)
+	ListChainedEntries(ctx context.Context, accountID AccountID, afterSequence int64, limit int) ([]ChainedEntry, error)
+}
+
+// BalanceStore is an optional Store capability: it returns SumTotal and SumActiveHolds for one account and
+// time in a single call. Service uses it for every balance read when the store implements it.
+type BalanceStore interface {
+	SumBalance(ctx context.Context, accountID AccountID, atUnixUTC int64) (SignedAmountCents, AmountCents, error)
+}
+
+// ArchiveStore is an optional Store capability for compaction, which walks and extends the hash chain, so it
+// includes ChainStore. GetEntryArchive returns the account's archive record, or the zero EntryArchive when
+// nothing was archived. ArchiveEntries deletes the listed entries and reservations of the account and replaces
+// its archive record. It keeps the deleted entries' idempotency keys and IDs: InsertEntry rejects the keys with
+// ErrDuplicateIdempotencyKey, and GetEntry and GetEntryByIdempotencyKey return ErrArchivedEntry for them.
+// Service.CompactAccount requires it; EntryArchive and VerifyChain use it when the store implements it.
+type ArchiveStore interface {
+	ChainStore
+	GetEntryArchive(ctx context.Context, accountID AccountID) (EntryArchive, error)
+	ArchiveEntries(ctx context.Context, archive EntryArchive, entryIDs []EntryID, reservationIDs []ReservationID) error
+}
+
+// GrantConsumption is the part of an expiring grant that a spend draws on.
+type GrantConsumption struct {
+	GrantEntryID EntryID
+	AmountCents  AmountCents
+}
+
+// EntryWrite holds the rows Service writes together with an entry: its hash-chain link, its position in
+// TenantID's change feed, the grants it consumes, its outbox event (nil for tenants without an outbox), and its
+// journal lines.
+type EntryWrite struct {
+	TenantID          TenantID
+	Link              ChainLink
+	GrantConsumptions []GrantConsumption
+	OutboxEvent       *OutboxEvent
+	JournalLines      []JournalLine
+}
+
+// EntryBatchStore is an optional Store capability that writes an entry and its EntryWrite in one round trip.
+// InsertEntryBatch locks the account's chain head, rejects archived idempotency keys with
+// ErrDuplicateIdempotencyKey, and builds the entry the input describes. It then calls prepare with that entry,
+// which is not persisted yet, and the locked head, and writes the entry with the rows prepare returns. prepare
+// may read through the store but must not write. Service uses it for every entry when the store implements it
+// and otherwise writes the rows one call at a time. A store that implements it writes every part of EntryWrite,
+// so it should implement ChainStore, ChangeFeedStore, GrantConsumptionStore, OutboxStore, and JournalStore too.
+type EntryBatchStore interface {
+	InsertEntryBatch(ctx context.Context, entryInput EntryInput, prepare func(entry Entry, head ChainHead) (EntryWrite, error)) (Entry, error)
+}
+
 func normalizeIdentifier(raw string, invalidError error) (string, error) {
 	trimmed := strings.TrimSpace(raw)
 	if trimmed == "" {
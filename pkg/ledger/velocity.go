@@ -0,0 +1,109 @@
+package ledger
+
+import (
+	"context"
+	"fmt"
+)
+
+const (
+	errorVelocityWindow   = "window must be positive"
+	errorVelocityLimits   = "at least one limit is required"
+	errorVelocityNegative = "limits must be zero or greater"
+)
+
+// DebitVelocity summarizes the debits recorded on an account since a point in time.
+// AmountCents is the net of spend, hold, and reverse_hold entries, so released holds do not count
+// and a captured reservation counts once. A reverse_hold only offsets a hold placed within the window,
+// so releasing an older hold never lowers the measured spend. Operations counts holds and spends that are
+// not captures.
+type DebitVelocity struct {
+	AmountCents AmountCents
+	Operations  int64
+}
+
+// VelocityRule caps the debits of every account in one tenant ledger within a rolling window.
+// A zero limit is not enforced.
+type VelocityRule struct {
+	tenantID       TenantID
+	ledgerID       LedgerID
+	windowSeconds  int64
+	maxAmountCents int64
+	maxOperations  int64
+}
+
+// NewVelocityRule validates a rolling-window debit limit for a tenant ledger.
+func NewVelocityRule(tenantID TenantID, ledgerID LedgerID, windowSeconds int64, maxAmountCents int64, maxOperations int64) (VelocityRule, error) {
+	if windowSeconds <= 0 {
+		return VelocityRule{}, fmt.Errorf("%w: %s", ErrInvalidVelocityRule, errorVelocityWindow)
+	}
+	if maxAmountCents < 0 || maxOperations < 0 {
+		return VelocityRule{}, fmt.Errorf("%w: %s", ErrInvalidVelocityRule, errorVelocityNegative)
+	}
+	if maxAmountCents == 0 && maxOperations == 0 {
+		return VelocityRule{}, fmt.Errorf("%w: %s", ErrInvalidVelocityRule, errorVelocityLimits)
+	}
+	return VelocityRule{
+		tenantID:       tenantID,
+		ledgerID:       ledgerID,
+		windowSeconds:  windowSeconds,
+		maxAmountCents: maxAmountCents,
+		maxOperations:  maxOperations,
+	}, nil
+}
+
+// TenantID returns the tenant the rule applies to.
+func (rule VelocityRule) TenantID() TenantID {
+	return rule.tenantID
+}
+
+// LedgerID returns the ledger the rule applies to.
+func (rule VelocityRule) LedgerID() LedgerID {
+	return rule.ledgerID
+}
+
+// WindowSeconds returns the rolling window length.
+func (rule VelocityRule) WindowSeconds() int64 {
+	return rule.windowSeconds
+}
+
+// MaxAmountCents returns the maximum debited amount within the window (0 = unlimited).
+func (rule VelocityRule) MaxAmountCents() int64 {
+	return rule.maxAmountCents
+}
+
+// MaxOperations returns the maximum number of debits within the window (0 = unlimited).
+func (rule VelocityRule) MaxOperations() int64 {
+	return rule.maxOperations
+}
+
+// WithVelocityRules enforces rolling-window debit limits in Spend, Reserve, SpendAcrossLedgers, and Batch.
+func WithVelocityRules(rules ...VelocityRule) ServiceOption {
+	return func(service *Service) {
+		service.velocityRules = append(service.velocityRules, rules...)
+	}
+}
+
+// checkVelocity rejects a debit of amount when it would break any rule configured for the tenant ledger.
+// Rules need a store that implements VelocityStore.
+func (service *Service) checkVelocity(ctx context.Context, store Store, tenantID TenantID, ledgerID LedgerID, accountID AccountID, amount int64, nowUnixUTC int64) error {
+	for _, rule := range service.velocityRules {
+		if rule.tenantID != tenantID || rule.ledgerID != ledgerID {
+			continue
+		}
+		velocityStore, ok := store.(VelocityStore)
+		if !ok {
+			return ErrVelocityUnsupported
+		}
+		velocity, err := velocityStore.SumDebitVelocity(ctx, accountID, nowUnixUTC-rule.windowSeconds)
+		if err != nil {
+			return err
+		}
+		if rule.maxAmountCents > 0 && velocity.AmountCents.Int64()+amount > rule.maxAmountCents {
+			return fmt.Errorf("%w: more than %d cents within %ds", ErrVelocityLimitExceeded, rule.maxAmountCents, rule.windowSeconds)
+		}
+		if rule.maxOperations > 0 && velocity.Operations+1 > rule.maxOperations {
+			return fmt.Errorf("%w: more than %d debits within %ds", ErrVelocityLimitExceeded, rule.maxOperations, rule.windowSeconds)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,191 @@
+package ledger
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestNewVelocityRuleValidation(test *testing.T) {
+	test.Parallel()
+	tenantID := mustTenantID(test, defaultTenantIDValue)
+	ledgerID := mustLedgerID(test, defaultLedgerIDValue)
+	testCases := []struct {
+		name           string
+		windowSeconds  int64
+		maxAmountCents int64
+		maxOperations  int64
+		wantErr        bool
+	}{
+		{name: "amount limit", windowSeconds: 86400, maxAmountCents: 10000},
+		{name: "operation limit", windowSeconds: 60, maxOperations: 50},
+		{name: "zero window", windowSeconds: 0, maxAmountCents: 10000, wantErr: true},
+		{name: "no limits", windowSeconds: 60, wantErr: true},
+		{name: "negative amount", windowSeconds: 60, maxAmountCents: -1, maxOperations: 5, wantErr: true},
+		{name: "negative operations", windowSeconds: 60, maxAmountCents: 5, maxOperations: -1, wantErr: true},
+	}
+	for _, testCase := range testCases {
+		testCase := testCase
+		test.Run(testCase.name, func(test *testing.T) {
+			test.Parallel()
+			rule, err := NewVelocityRule(tenantID, ledgerID, testCase.windowSeconds, testCase.maxAmountCents, testCase.maxOperations)
+			if testCase.wantErr {
+				if !errors.Is(err, ErrInvalidVelocityRule) {
+					test.Fatalf("expected invalid velocity rule, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				test.Fatalf("new velocity rule: %v", err)
+			}
+			if rule.TenantID() != tenantID || rule.LedgerID() != ledgerID || rule.WindowSeconds() != testCase.windowSeconds ||
+				rule.MaxAmountCents() != testCase.maxAmountCents || rule.MaxOperations() != testCase.maxOperations {
+				test.Fatalf("unexpected rule accessors: %+v", rule)
+			}
+		})
+	}
+}
+
+func TestSpendEnforcesVelocityAmountLimit(test *testing.T) {
+	test.Parallel()
+	store := newStubStore(test, mustSignedAmount(test, 10000))
+	service := mustNewVelocityService(test, store, mustVelocityRule(test, defaultLedgerIDValue, 60, 1000, 0))
+	ctx := context.Background()
+	tenantID := mustTenantID(test, defaultTenantIDValue)
+	userID := mustUserID(test, "user-1")
+	ledgerID := mustLedgerID(test, defaultLedgerIDValue)
+
+	if _, err := service.SpendEntry(ctx, tenantID, userID, ledgerID, mustPositiveAmount(test, 600), mustIdempotencyKey(test, "spend-1"), mustMetadata(test, "{}")); err != nil {
+		test.Fatalf("first spend: %v", err)
+	}
+	if _, err := service.SpendEntry(ctx, tenantID, userID, ledgerID, mustPositiveAmount(test, 400), mustIdempotencyKey(test, "spend-2"), mustMetadata(test, "{}")); err != nil {
+		test.Fatalf("spend up to the limit: %v", err)
+	}
+	_, err := service.SpendEntry(ctx, tenantID, userID, ledgerID, mustPositiveAmount(test, 1), mustIdempotencyKey(test, "spend-3"), mustMetadata(test, "{}"))
+	if !errors.Is(err, ErrVelocityLimitExceeded) {
+		test.Fatalf("expected velocity limit exceeded, got %v", err)
+	}
+	if len(store.entries) != 2 {
+		test.Fatalf("expected rejected spend not to be recorded, got %d entries", len(store.entries))
+	}
+
+	otherLedgerID := mustLedgerID(test, "other")
+	if _, err := service.SpendEntry(ctx, tenantID, userID, otherLedgerID, mustPositiveAmount(test, 1), mustIdempotencyKey(test, "spend-other"), mustMetadata(test, "{}")); err != nil {
+		test.Fatalf("expected rule to be scoped to its ledger, got %v", err)
+	}
+}
+
+func TestReserveEnforcesVelocityOperationLimit(test *testing.T) {
+	test.Parallel()
+	store := newStubStore(test, mustSignedAmount(test, 10000))
+	service := mustNewVelocityService(test, store, mustVelocityRule(test, defaultLedgerIDValue, 60, 0, 2))
+	ctx := context.Background()
+	tenantID := mustTenantID(test, defaultTenantIDValue)
+	userID := mustUserID(test, "user-1")
+	ledgerID := mustLedgerID(test, defaultLedgerIDValue)
+
+	if _, err := service.ReserveEntry(ctx, tenantID, userID, ledgerID, mustPositiveAmount(test, 10), mustReservationID(test, "order-1"), mustIdempotencyKey(test, "reserve-1"), 0, mustMetadata(test, "{}")); err != nil {
+		test.Fatalf("reserve: %v", err)
+	}
+	if err := service.Capture(ctx, tenantID, userID, ledgerID, mustReservationID(test, "order-1"), mustIdempotencyKey(test, "capture-1"), mustPositiveAmount(test, 10), mustMetadata(test, "{}")); err != nil {
+		test.Fatalf("capture: %v", err)
+	}
+	if _, err := service.SpendEntry(ctx, tenantID, userID, ledgerID, mustPositiveAmount(test, 10), mustIdempotencyKey(test, "spend-1"), mustMetadata(test, "{}")); err != nil {
+		test.Fatalf("expected captured reservation to count once, got %v", err)
+	}
+	_, err := service.ReserveEntry(ctx, tenantID, userID, ledgerID, mustPositiveAmount(test, 10), mustReservationID(test, "order-2"), mustIdempotencyKey(test, "reserve-2"), 0, mustMetadata(test, "{}"))
+	if !errors.Is(err, ErrVelocityLimitExceeded) {
+		test.Fatalf("expected velocity limit exceeded, got %v", err)
+	}
+}
+
+func TestVelocityWindowExcludesOlderDebits(test *testing.T) {
+	test.Parallel()
+	store := newStubStore(test, mustSignedAmount(test, 10000))
+	accountID := store.accountID
+	oldAmount, err := NewEntryAmountCents(-900)
+	if err != nil {
+		test.Fatalf("entry amount: %v", err)
+	}
+	oldSpend, err := NewEntryInput(accountID, EntrySpend, oldAmount, nil, nil, mustIdempotencyKey(test, "old-spend"), 0, mustMetadata(test, "{}"), 30)
+	if err != nil {
+		test.Fatalf("entry input: %v", err)
+	}
+	store.entries = append(store.entries, oldSpend)
+	service := mustNewVelocityService(test, store, mustVelocityRule(test, defaultLedgerIDValue, 60, 1000, 0))
+
+	_, err = service.SpendEntry(context.Background(), mustTenantID(test, defaultTenantIDValue), mustUserID(test, "user-1"), mustLedgerID(test, defaultLedgerIDValue), mustPositiveAmount(test, 1000), mustIdempotencyKey(test, "spend-now"), mustMetadata(test, "{}"))
+	if err != nil {
+		test.Fatalf("expected debit outside the window to be ignored, got %v", err)
+	}
+}
+
+func TestVelocityStoreErrorsPropagate(test *testing.T) {
+	test.Parallel()
+	storeErr := errors.New("velocity query failed")
+	store := newStubStore(test, mustSignedAmount(test, 10000))
+	store.sumDebitVelocityError = storeErr
+	service := mustNewVelocityService(test, store, mustVelocityRule(test, defaultLedgerIDValue, 60, 1000, 0))
+
+	_, err := service.SpendEntry(context.Background(), mustTenantID(test, defaultTenantIDValue), mustUserID(test, "user-1"), mustLedgerID(test, defaultLedgerIDValue), mustPositiveAmount(test, 10), mustIdempotencyKey(test, "spend-1"), mustMetadata(test, "{}"))
+	if !errors.Is(err, storeErr) {
+		test.Fatalf("expected store error, got %v", err)
+	}
+}
+
+func TestBatchEnforcesVelocityLimitsPerOperation(test *testing.T) {
+	test.Parallel()
+	store := newStubStore(test, mustSignedAmount(test, 10000))
+	service := mustNewVelocityService(test, store, mustVelocityRule(test, defaultLedgerIDValue, 60, 500, 0))
+	operations := []BatchOperation{
+		{OperationID: "spend-ok", Spend: &BatchSpendOperation{Amount: mustPositiveAmount(test, 300), IdempotencyKey: mustIdempotencyKey(test, "batch-spend-1"), Metadata: mustMetadata(test, "{}")}},
+		{OperationID: "reserve-over", Reserve: &BatchReserveOperation{Amount: mustPositiveAmount(test, 300), ReservationID: mustReservationID(test, "batch-order"), IdempotencyKey: mustIdempotencyKey(test, "batch-reserve-1"), Metadata: mustMetadata(test, "{}")}},
+		{OperationID: "spend-over", Spend: &BatchSpendOperation{Amount: mustPositiveAmount(test, 201), IdempotencyKey: mustIdempotencyKey(test, "batch-spend-2"), Metadata: mustMetadata(test, "{}")}},
+		{OperationID: "spend-fits", Spend: &BatchSpendOperation{Amount: mustPositiveAmount(test, 200), IdempotencyKey: mustIdempotencyKey(test, "batch-spend-3"), Metadata: mustMetadata(test, "{}")}},
+	}
+	results, err := service.Batch(context.Background(), mustTenantID(test, defaultTenantIDValue), mustUserID(test, "user-1"), mustLedgerID(test, defaultLedgerIDValue), operations, false)
+	if err != nil {
+		test.Fatalf("batch: %v", err)
+	}
+	if results[0].Error != nil || results[3].Error != nil {
+		test.Fatalf("expected operations within the limit to succeed, got %v and %v", results[0].Error, results[3].Error)
+	}
+	if !errors.Is(results[1].Error, ErrVelocityLimitExceeded) || !errors.Is(results[2].Error, ErrVelocityLimitExceeded) {
+		test.Fatalf("expected velocity errors, got %v and %v", results[1].Error, results[2].Error)
+	}
+}
+
+func TestSpendAcrossLedgersEnforcesVelocityPerLedger(test *testing.T) {
+	test.Parallel()
+	store := newMultiLedgerStore(test, map[string]int64{"promo": 500, "purchased": 5000})
+	service := mustNewVelocityService(test, store, mustVelocityRule(test, "purchased", 60, 100, 0))
+	ledgerIDs := []LedgerID{mustLedgerID(test, "promo"), mustLedgerID(test, "purchased")}
+	tenantID := mustTenantID(test, defaultTenantIDValue)
+	userID := mustUserID(test, "user-1")
+
+	if _, err := service.SpendAcrossLedgers(context.Background(), tenantID, userID, ledgerIDs, mustPositiveAmount(test, 600), mustIdempotencyKey(test, "multi-1"), mustMetadata(test, "{}")); err != nil {
+		test.Fatalf("spend within limit: %v", err)
+	}
+	_, err := service.SpendAcrossLedgers(context.Background(), tenantID, userID, ledgerIDs, mustPositiveAmount(test, 1), mustIdempotencyKey(test, "multi-2"), mustMetadata(test, "{}"))
+	if !errors.Is(err, ErrVelocityLimitExceeded) {
+		test.Fatalf("expected velocity limit exceeded, got %v", err)
+	}
+}
+
+func mustNewVelocityService(test *testing.T, store Store, rules ...VelocityRule) *Service {
+	test.Helper()
+	service, err := NewService(store, func() int64 { return 100 }, WithVelocityRules(rules...))
+	if err != nil {
+		test.Fatalf("new service: %v", err)
+	}
+	return service
+}
+
+func mustVelocityRule(test *testing.T, rawLedgerID string, windowSeconds int64, maxAmountCents int64, maxOperations int64) VelocityRule {
+	test.Helper()
+	rule, err := NewVelocityRule(mustTenantID(test, defaultTenantIDValue), mustLedgerID(test, rawLedgerID), windowSeconds, maxAmountCents, maxOperations)
+	if err != nil {
+		test.Fatalf("velocity rule: %v", err)
+	}
+	return rule
+}
@@ -0,0 +1,367 @@
+package ledger
+
+import (
+	"context"
+	"fmt"
+)
+
+const verifyAccountPageSize = 500
+
+// InvariantCheck names a consistency rule checked by VerifyAccount.
+type InvariantCheck string
+
+const (
+	// InvariantReservationHold requires every reservation to have exactly one hold entry for its amount,
+	// and every hold entry to belong to a known reservation.
+	InvariantReservationHold InvariantCheck = "reservation_hold"
+	// InvariantReservationSettlement requires a captured reservation to have one reverse_hold and one spend,
+	// a released reservation one reverse_hold and no spend, and an active reservation neither.
+	InvariantReservationSettlement InvariantCheck = "reservation_settlement"
+	// InvariantRefundWithinDebit requires every refund to reference a debit of the account and the refunds
+	// of a debit not to exceed it.
+	InvariantRefundWithinDebit InvariantCheck = "refund_within_debit"
+	// InvariantBalanceTotal requires the store's total balance to equal the sum of the effective entries.
+	InvariantBalanceTotal InvariantCheck = "balance_total"
+	// InvariantBalanceHolds requires the store's active holds to equal the holds the entries leave open.
+	InvariantBalanceHolds InvariantCheck = "balance_holds"
+)
+
+// String returns the check as a primitive value.
+func (check InvariantCheck) String() string {
+	return string(check)
+}
+
+// InvariantViolation is one broken rule. EntryID and ReservationID are set when the violation concerns
+// a single entry or reservation; ExpectedCents and ActualCents when it concerns an amount.
+type InvariantViolation struct {
+	Check         InvariantCheck
+	EntryID       EntryID
+	ReservationID ReservationID
+	ExpectedCents int64
+	ActualCents   int64
+	Message       string
+}
+
+// AccountVerification reports the invariant checks of one account. Violations is empty when the account is consistent.
+type AccountVerification struct {
+	AccountID           AccountID
+	UserID              UserID
+	LedgerID            LedgerID
+	CheckedUnixUTC      int64
+	CheckedEntries      int
+	CheckedReservations int
+	Violations          []InvariantViolation
+}
+
+// Valid reports whether no invariant was violated.
+func (verification AccountVerification) Valid() bool {
+	return len(verification.Violations) == 0
+}
+
+// VerifyAccount checks an account's entries, reservations, and balance projections for consistency.
+// An unknown account is reported as consistent unless strict account lookup is enabled.
+func (service *Service) VerifyAccount(ctx context.Context, tenantID TenantID, userID UserID, ledgerID LedgerID) (AccountVerification, error) {
+	accountID, err := service.lookupAccountID(ctx, service.store, tenantID, userID, ledgerID)
+	if service.isLenientUnknownAccount(err) {
+		return AccountVerification{UserID: userID, LedgerID: ledgerID, CheckedUnixUTC: service.nowFn()}, nil
+	}
+	if err != nil {
+		return AccountVerification{}, err
+	}
+	return service.verifyAccount(ctx, accountID, userID, ledgerID)
+}
+
+// VerifyAccounts runs VerifyAccount for every account of a tenant that matches filter.
+func (service *Service) VerifyAccounts(ctx context.Context, tenantID TenantID, filter ListAccountsFilter) ([]AccountVerification, error) {
+	accounts, err := listByCreation(
+		func(beforeUnixUTC int64, limit int) ([]Account, error) {
+			return service.ListAccounts(ctx, tenantID, beforeUnixUTC, limit, filter)
+		},
+		func(account Account) string { return account.AccountID().String() },
+		Account.CreatedUnixUTC,
+	)
+	if err != nil {
+		return nil, err
+	}
+	verifications := make([]AccountVerification, 0, len(accounts))
+	for _, account := range accounts {
+		verification, err := service.verifyAccount(ctx, account.AccountID(), account.UserID(), account.LedgerID())
+		if err != nil {
+			return nil, err
+		}
+		verifications = append(verifications, verification)
+	}
+	return verifications, nil
+}
+
+func (service *Service) verifyAccount(ctx context.Context, accountID AccountID, userID UserID, ledgerID LedgerID) (AccountVerification, error) {
+	verification := AccountVerification{AccountID: accountID, UserID: userID, LedgerID: ledgerID, CheckedUnixUTC: service.nowFn()}
+	err := service.store.WithTx(ctx, func(ctx context.Context, transactionStore Store) error {
+		entries, err := listAccountEntries(ctx, transactionStore, accountID, ListEntriesFilter{})
+		if err != nil {
+			return err
+		}
+		reservations, err := listByCreation(
+			func(beforeUnixUTC int64, limit int) ([]Reservation, error) {
+				return transactionStore.ListReservations(ctx, accountID, beforeUnixUTC, limit, ListReservationsFilter{})
+			},
+			func(reservation Reservation) string { return reservation.ReservationID().String() },
+			Reservation.CreatedUnixUTC,
+		)
+		if err != nil {
+			return err
+		}
+		total, holds, err := sumBalance(ctx, transactionStore, accountID, verification.CheckedUnixUTC)
+		if err != nil {
+			return err
+		}
+		verification.CheckedEntries = len(entries)
+		verification.CheckedReservations = len(reservations)
+		verification.Violations = checkAccountInvariants(entries, reservations, total, holds, verification.CheckedUnixUTC)
+		return nil
+	})
+	if err != nil {
+		return AccountVerification{}, err
+	}
+	return verification, nil
+}
+
+type reservationEntries struct {
+	holds        []Entry
+	reverseHolds []Entry
+	spends       []Entry
+}
+
+func checkAccountInvariants(entries []Entry, reservations []Reservation, total SignedAmountCents, holds AmountCents, atUnixUTC int64) []InvariantViolation {
+	var violations []InvariantViolation
+	byReservation := make(map[ReservationID]*reservationEntries)
+	var reservationOrder []ReservationID
+	debits := make(map[EntryID]Entry)
+	refunded := make(map[EntryID]int64)
+	var refundOrder []EntryID
+	var expectedTotal int64
+	for _, entry := range entries {
+		if reservationID, ok := entry.ReservationID(); ok && (entry.Type() == EntryHold || entry.Type() == EntryReverseHold || entry.Type() == EntrySpend) {
+			grouped := byReservation[reservationID]
+			if grouped == nil {
+				grouped = &reservationEntries{}
+				byReservation[reservationID] = grouped
+				reservationOrder = append(reservationOrder, reservationID)
+			}
+			switch entry.Type() {
+			case EntryHold:
+				grouped.holds = append(grouped.holds, entry)
+			case EntryReverseHold:
+				grouped.reverseHolds = append(grouped.reverseHolds, entry)
+			default:
+				grouped.spends = append(grouped.spends, entry)
+			}
+		}
+		if entry.AmountCents().Int64() < 0 {
+			debits[entry.EntryID()] = entry
+		}
+		if originalEntryID, ok := entry.RefundOfEntryID(); ok {
+			if _, seen := refunded[originalEntryID]; !seen {
+				refundOrder = append(refundOrder, originalEntryID)
+			}
+			refunded[originalEntryID] += entry.AmountCents().Int64()
+		}
+		expectedTotal += entry.BalanceCentsAt(atUnixUTC)
+	}
+
+	var expectedHolds int64
+	known := make(map[ReservationID]struct{}, len(reservations))
+	for _, reservation := range reservations {
+		reservationID := reservation.ReservationID()
+		known[reservationID] = struct{}{}
+		grouped := byReservation[reservationID]
+		if grouped == nil {
+			grouped = &reservationEntries{}
+		}
+		amount := reservation.AmountCents().Int64()
+		if len(grouped.holds) != 1 {
+			violations = append(violations, InvariantViolation{
+				Check:         InvariantReservationHold,
+				ReservationID: reservationID,
+				ExpectedCents: -amount,
+				ActualCents:   sumEntryAmounts(grouped.holds),
+				Message:       fmt.Sprintf("reservation has %d hold entries, expected 1", len(grouped.holds)),
+			})
+		} else if hold := grouped.holds[0]; hold.AmountCents().Int64() != -amount {
+			violations = append(violations, InvariantViolation{
+				Check:         InvariantReservationHold,
+				EntryID:       hold.EntryID(),
+				ReservationID: reservationID,
+				ExpectedCents: -amount,
+				ActualCents:   hold.AmountCents().Int64(),
+				Message:       "hold amount does not match the reservation",
+			})
+		}
+		expectedReverseHolds, expectedSpends := 0, 0
+		switch reservation.Status() {
+		case ReservationStatusCaptured:
+			expectedReverseHolds, expectedSpends = 1, 1
+		case ReservationStatusReleased:
+			expectedReverseHolds = 1
+		}
+		if len(grouped.reverseHolds) != expectedReverseHolds || len(grouped.spends) != expectedSpends {
+			violations = append(violations, InvariantViolation{
+				Check:         InvariantReservationSettlement,
+				ReservationID: reservationID,
+				Message: fmt.Sprintf("%s reservation has %d reverse_hold and %d spend entries, expected %d and %d",
+					reservation.Status(), len(grouped.reverseHolds), len(grouped.spends), expectedReverseHolds, expectedSpends),
+			})
+		} else if sumEntryAmounts(grouped.reverseHolds) != amount*int64(expectedReverseHolds) || sumEntryAmounts(grouped.spends) != -amount*int64(expectedSpends) {
+			violations = append(violations, InvariantViolation{
+				Check:         InvariantReservationSettlement,
+				ReservationID: reservationID,
+				ExpectedCents: amount * int64(expectedReverseHolds-expectedSpends),
+				ActualCents:   sumEntryAmounts(grouped.reverseHolds) + sumEntryAmounts(grouped.spends),
+				Message:       "settlement amounts do not match the reservation",
+			})
+		}
+	}
+	for _, reservationID := range reservationOrder {
+		grouped := byReservation[reservationID]
+		_, isKnown := known[reservationID]
+		for _, hold := range grouped.holds {
+			if !isKnown {
+				violations = append(violations, InvariantViolation{
+					Check:         InvariantReservationHold,
+					EntryID:       hold.EntryID(),
+					ReservationID: reservationID,
+					Message:       "hold entry references an unknown reservation",
+				})
+				continue
+			}
+			if len(grouped.reverseHolds) == 0 && (hold.ExpiresAtUnixUTC() == 0 || hold.ExpiresAtUnixUTC() > atUnixUTC) {
+				expectedHolds -= hold.AmountCents().Int64()
+			}
+		}
+	}
+
+	for _, originalEntryID := range refundOrder {
+		refundedCents := refunded[originalEntryID]
+		original, ok := debits[originalEntryID]
+		if !ok {
+			violations = append(violations, InvariantViolation{
+				Check:       InvariantRefundWithinDebit,
+				EntryID:     originalEntryID,
+				ActualCents: refundedCents,
+				Message:     "refunds reference an entry that is not a debit of the account",
+			})
+			continue
+		}
+		if debitCents := -original.AmountCents().Int64(); refundedCents > debitCents {
+			violations = append(violations, InvariantViolation{
+				Check:         InvariantRefundWithinDebit,
+				EntryID:       originalEntryID,
+				ExpectedCents: debitCents,
+				ActualCents:   refundedCents,
+				Message:       "refunds exceed the debit",
+			})
+		}
+	}
+
+	if total.Int64() != expectedTotal {
+		violations = append(violations, InvariantViolation{
+			Check:         InvariantBalanceTotal,
+			ExpectedCents: expectedTotal,
+			ActualCents:   total.Int64(),
+			Message:       "total balance does not match the entry sum",
+		})
+	}
+	if holds.Int64() != expectedHolds {
+		violations = append(violations, InvariantViolation{
+			Check:         InvariantBalanceHolds,
+			ExpectedCents: expectedHolds,
+			ActualCents:   holds.Int64(),
+			Message:       "active holds do not match the open hold entries",
+		})
+	}
+	return violations
+}
+
+func sumEntryAmounts(entries []Entry) int64 {
+	var sum int64
+	for _, entry := range entries {
+		sum += entry.AmountCents().Int64()
+	}
+	return sum
+}
+
+// listAccountEntries collects every entry of an account that matches filter, newest first, paging by creation
+// second and entry ID so entries sharing a second are neither repeated nor skipped at a page boundary.
+func listAccountEntries(ctx context.Context, store Store, accountID AccountID, filter ListEntriesFilter) ([]Entry, error) {
+	var entries []Entry
+	var beforeUnixUTC int64
+	for {
+		page, err := store.ListEntries(ctx, accountID, beforeUnixUTC, verifyAccountPageSize, filter)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, page...)
+		if len(page) < verifyAccountPageSize {
+			return entries, nil
+		}
+		last := page[len(page)-1]
+		beforeUnixUTC = last.CreatedUnixUTC()
+		lastEntryID := last.EntryID()
+		filter.BeforeEntryID = &lastEntryID
+	}
+}
+
+// listByCreation collects every item of a listing paged by a created-before cursor, as forEachByCreation visits them.
+func listByCreation[T any](fetch func(beforeUnixUTC int64, limit int) ([]T, error), key func(T) string, createdUnixUTC func(T) int64) ([]T, error) {
+	var items []T
+	err := forEachByCreation(fetch, key, createdUnixUTC, func(item T) error {
+		items = append(items, item)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// forEachByCreation calls visit once for every item of a listing paged by a created-before cursor, a page at
+// a time. Items created in the same second as a page boundary are fetched again and skipped by key; only the
+// keys of that second are kept. A page holding a single second is re-read with a larger limit so the cursor
+// always advances.
+func forEachByCreation[T any](fetch func(beforeUnixUTC int64, limit int) ([]T, error), key func(T) string, createdUnixUTC func(T) int64, visit func(T) error) error {
+	seen := make(map[string]struct{})
+	var beforeUnixUTC, boundaryUnixUTC int64
+	limit := verifyAccountPageSize
+	for {
+		page, err := fetch(beforeUnixUTC, limit)
+		if err != nil {
+			return err
+		}
+		for _, item := range page {
+			if _, ok := seen[key(item)]; ok {
+				continue
+			}
+			if err := visit(item); err != nil {
+				return err
+			}
+		}
+		if len(page) < limit {
+			return nil
+		}
+		oldestUnixUTC := createdUnixUTC(page[len(page)-1])
+		if oldestUnixUTC != boundaryUnixUTC {
+			seen = make(map[string]struct{})
+			boundaryUnixUTC = oldestUnixUTC
+		}
+		for _, item := range page {
+			if createdUnixUTC(item) == oldestUnixUTC {
+				seen[key(item)] = struct{}{}
+			}
+		}
+		if createdUnixUTC(page[0]) == oldestUnixUTC {
+			limit *= 2
+			continue
+		}
+		beforeUnixUTC = oldestUnixUTC + 1
+	}
+}
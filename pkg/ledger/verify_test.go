@@ -0,0 +1,347 @@
+package ledger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"testing"
+)
+
+func TestVerifyAccountReportsInvariantViolations(test *testing.T) {
+	test.Parallel()
+	tenantID := mustTenantID(test, defaultTenantIDValue)
+	userID := mustUserID(test, "user-1")
+	ledgerID := mustLedgerID(test, defaultLedgerIDValue)
+	capturedID := mustReservationID(test, "order-captured")
+	releasedID := mustReservationID(test, "order-released")
+	newVerifiedStore := func() (*Service, *stubStore, Entry) {
+		store := newStubStore(test, 0)
+		service := mustNewService(test, store)
+		ctx := context.Background()
+		metadata := mustMetadata(test, "{}")
+		if err := service.Grant(ctx, tenantID, userID, ledgerID, mustPositiveAmount(test, 1000), mustIdempotencyKey(test, "grant-1"), 0, metadata); err != nil {
+			test.Fatalf("grant: %v", err)
+		}
+		for _, reservationID := range []ReservationID{capturedID, releasedID} {
+			if err := service.Reserve(ctx, tenantID, userID, ledgerID, mustPositiveAmount(test, 100), reservationID, mustIdempotencyKey(test, "reserve-"+reservationID.String()), 0, metadata); err != nil {
+				test.Fatalf("reserve %s: %v", reservationID, err)
+			}
+		}
+		if err := service.Capture(ctx, tenantID, userID, ledgerID, capturedID, mustIdempotencyKey(test, "capture-1"), mustPositiveAmount(test, 100), metadata); err != nil {
+			test.Fatalf("capture: %v", err)
+		}
+		if err := service.Release(ctx, tenantID, userID, ledgerID, releasedID, mustIdempotencyKey(test, "release-1"), metadata); err != nil {
+			test.Fatalf("release: %v", err)
+		}
+		if err := service.Reserve(ctx, tenantID, userID, ledgerID, mustPositiveAmount(test, 50), mustReservationID(test, "order-active"), mustIdempotencyKey(test, "reserve-active"), 0, metadata); err != nil {
+			test.Fatalf("reserve active: %v", err)
+		}
+		spendEntry, err := service.SpendEntry(ctx, tenantID, userID, ledgerID, mustPositiveAmount(test, 200), mustIdempotencyKey(test, "spend-1"), metadata)
+		if err != nil {
+			test.Fatalf("spend: %v", err)
+		}
+		if _, err := service.RefundByEntryIDEntry(ctx, tenantID, userID, ledgerID, spendEntry.EntryID(), mustPositiveAmount(test, 50), mustIdempotencyKey(test, "refund-1"), metadata); err != nil {
+			test.Fatalf("refund: %v", err)
+		}
+		return service, store, spendEntry
+	}
+	appendEntry := func(store *stubStore, entryType EntryType, amount EntryAmountCents, reservationID *ReservationID, refundOfEntryID *EntryID, key string) {
+		entryInput, err := NewEntryInput(store.accountID, entryType, amount, reservationID, refundOfEntryID, mustIdempotencyKey(test, key), 0, mustMetadata(test, "{}"), 100)
+		if err != nil {
+			test.Fatalf("entry input: %v", err)
+		}
+		store.entries = append(store.entries, entryInput)
+	}
+
+	testCases := []struct {
+		name           string
+		tamper         func(store *stubStore, spendEntry Entry)
+		expectedChecks []InvariantCheck
+	}{
+		{
+			name:   "consistent",
+			tamper: func(store *stubStore, spendEntry Entry) {},
+		},
+		{
+			name: "duplicate hold",
+			tamper: func(store *stubStore, spendEntry Entry) {
+				appendEntry(store, EntryHold, -100, &capturedID, nil, "extra-hold")
+			},
+			expectedChecks: []InvariantCheck{InvariantReservationHold},
+		},
+		{
+			name: "released reservation with spend",
+			tamper: func(store *stubStore, spendEntry Entry) {
+				appendEntry(store, EntrySpend, -100, &releasedID, nil, "stray-spend")
+				store.total = applyEntryDelta(store.total, -100)
+			},
+			expectedChecks: []InvariantCheck{InvariantReservationSettlement},
+		},
+		{
+			name: "refund exceeds debit",
+			tamper: func(store *stubStore, spendEntry Entry) {
+				originalEntryID := spendEntry.EntryID()
+				appendEntry(store, EntryRefund, 500, nil, &originalEntryID, "extra-refund")
+			},
+			expectedChecks: []InvariantCheck{InvariantRefundWithinDebit, InvariantBalanceTotal},
+		},
+		{
+			name: "reservation projection drift",
+			tamper: func(store *stubStore, spendEntry Entry) {
+				reservation, err := NewReservation(store.accountID, releasedID, mustPositiveAmount(test, 100), ReservationStatusActive, 0)
+				if err != nil {
+					test.Fatalf("reservation: %v", err)
+				}
+				store.reservations[releasedID] = reservation
+			},
+			expectedChecks: []InvariantCheck{InvariantReservationSettlement, InvariantBalanceHolds},
+		},
+		{
+			name: "reservation amount drift",
+			tamper: func(store *stubStore, spendEntry Entry) {
+				reservation, err := NewReservation(store.accountID, capturedID, mustPositiveAmount(test, 90), ReservationStatusCaptured, 0)
+				if err != nil {
+					test.Fatalf("reservation: %v", err)
+				}
+				store.reservations[capturedID] = reservation
+			},
+			expectedChecks: []InvariantCheck{InvariantReservationHold, InvariantReservationSettlement},
+		},
+		{
+			name: "reservation renamed",
+			tamper: func(store *stubStore, spendEntry Entry) {
+				renamedID := mustReservationID(test, "order-renamed")
+				reservation, err := NewReservation(store.accountID, renamedID, mustPositiveAmount(test, 100), ReservationStatusReleased, 0)
+				if err != nil {
+					test.Fatalf("reservation: %v", err)
+				}
+				delete(store.reservations, releasedID)
+				store.reservations[renamedID] = reservation
+			},
+			expectedChecks: []InvariantCheck{InvariantReservationHold, InvariantReservationSettlement, InvariantReservationHold},
+		},
+		{
+			name: "refund of unknown entry",
+			tamper: func(store *stubStore, spendEntry Entry) {
+				unknownEntryID := mustEntryID(test, "entry-unknown")
+				appendEntry(store, EntryRefund, 10, nil, &unknownEntryID, "orphan-refund")
+				store.total = applyEntryDelta(store.total, 10)
+			},
+			expectedChecks: []InvariantCheck{InvariantRefundWithinDebit},
+		},
+	}
+	for _, testCase := range testCases {
+		testCase := testCase
+		test.Run(testCase.name, func(test *testing.T) {
+			test.Parallel()
+			service, store, spendEntry := newVerifiedStore()
+			testCase.tamper(store, spendEntry)
+			verification, err := service.VerifyAccount(context.Background(), tenantID, userID, ledgerID)
+			if err != nil {
+				test.Fatalf("verify account: %v", err)
+			}
+			if verification.CheckedReservations != 3 || verification.CheckedEntries < 9 {
+				test.Fatalf("expected every entry and reservation to be checked, got %+v", verification)
+			}
+			if len(verification.Violations) != len(testCase.expectedChecks) {
+				test.Fatalf("expected violations %v, got %+v", testCase.expectedChecks, verification.Violations)
+			}
+			for index, check := range testCase.expectedChecks {
+				if verification.Violations[index].Check != check || verification.Violations[index].Check.String() != string(check) {
+					test.Fatalf("violation %d: expected %s, got %+v", index, check, verification.Violations[index])
+				}
+			}
+			if verification.Valid() != (len(testCase.expectedChecks) == 0) {
+				test.Fatalf("unexpected validity for %+v", verification.Violations)
+			}
+		})
+	}
+}
+
+func TestVerifyAccountsPagesThroughEveryAccount(test *testing.T) {
+	test.Parallel()
+	store := newStubStore(test, 0)
+	service := mustNewService(test, store)
+	for index, rawUserID := range []string{"user-1", "user-2"} {
+		account, err := NewAccount(mustAccountID(test, fmt.Sprintf("acct-%d", index+1)), mustTenantID(test, defaultTenantIDValue), mustUserID(test, rawUserID), mustLedgerID(test, defaultLedgerIDValue), 100)
+		if err != nil {
+			test.Fatalf("account: %v", err)
+		}
+		store.accounts = append(store.accounts, account)
+	}
+	verifications, err := service.VerifyAccounts(context.Background(), mustTenantID(test, defaultTenantIDValue), ListAccountsFilter{})
+	if err != nil {
+		test.Fatalf("verify accounts: %v", err)
+	}
+	if len(verifications) != 2 || verifications[1].UserID.String() != "user-2" || !verifications[0].Valid() {
+		test.Fatalf("unexpected verifications %+v", verifications)
+	}
+}
+
+// reservationListFailureStore fails ListReservations inside transactions and delegates everything else to the stub.
+type reservationListFailureStore struct {
+	*stubStore
+	err error
+}
+
+func (store reservationListFailureStore) WithTx(ctx context.Context, fn func(ctx context.Context, txStore Store) error) error {
+	return store.stubStore.WithTx(ctx, func(ctx context.Context, txStore Store) error {
+		return fn(ctx, reservationListFailureStore{stubStore: txStore.(*stubStore), err: store.err})
+	})
+}
+
+func (store reservationListFailureStore) ListReservations(context.Context, AccountID, int64, int, ListReservationsFilter) ([]Reservation, error) {
+	return nil, store.err
+}
+
+func TestVerifyAccountReturnsStoreFailures(test *testing.T) {
+	test.Parallel()
+	ctx := context.Background()
+	tenantID := mustTenantID(test, defaultTenantIDValue)
+	userID := mustUserID(test, "user-1")
+	ledgerID := mustLedgerID(test, defaultLedgerIDValue)
+	storeError := errors.New("store failed")
+
+	missing := newStubStore(test, 0)
+	missing.accountMissing = true
+	verification, err := mustNewService(test, missing).VerifyAccount(ctx, tenantID, userID, ledgerID)
+	if err != nil || !verification.Valid() || verification.UserID != userID || verification.CheckedUnixUTC != 100 {
+		test.Fatalf("expected a consistent verification for an unknown account, got %+v (%v)", verification, err)
+	}
+
+	testCases := []struct {
+		name      string
+		configure func(store *stubStore) Store
+	}{
+		{name: "account lookup", configure: func(store *stubStore) Store { store.getAccountError = storeError; return store }},
+		{name: "entries", configure: func(store *stubStore) Store { store.listErr = storeError; return store }},
+		{name: "reservations", configure: func(store *stubStore) Store { return reservationListFailureStore{stubStore: store, err: storeError} }},
+		{name: "balance", configure: func(store *stubStore) Store { store.sumTotalError = storeError; return store }},
+	}
+	for _, testCase := range testCases {
+		test.Run(testCase.name, func(test *testing.T) {
+			test.Parallel()
+			service := mustNewService(test, testCase.configure(newStubStore(test, 0)))
+			if _, err := service.VerifyAccount(ctx, tenantID, userID, ledgerID); !errors.Is(err, storeError) {
+				test.Fatalf("expected %v, got %v", storeError, err)
+			}
+		})
+	}
+
+	listing := newStubStore(test, 0)
+	listing.getAccountError = storeError
+	if _, err := mustNewService(test, listing).VerifyAccounts(ctx, tenantID, ListAccountsFilter{}); !errors.Is(err, storeError) {
+		test.Fatalf("expected %v from the account listing, got %v", storeError, err)
+	}
+	balance := newStubStore(test, 0)
+	account, err := NewAccount(balance.accountID, tenantID, userID, ledgerID, 100)
+	if err != nil {
+		test.Fatalf("account: %v", err)
+	}
+	balance.accounts = []Account{account}
+	balance.sumTotalError = storeError
+	if _, err := mustNewService(test, balance).VerifyAccounts(ctx, tenantID, ListAccountsFilter{}); !errors.Is(err, storeError) {
+		test.Fatalf("expected %v from the account verification, got %v", storeError, err)
+	}
+}
+
+// pagedEntriesStore serves entries a page at a time after the filter's BeforeEntryID cursor.
+type pagedEntriesStore struct {
+	*stubStore
+	pages   []Entry
+	fetches *int
+}
+
+func (store pagedEntriesStore) ListEntries(ctx context.Context, accountID AccountID, beforeUnixUTC int64, limit int, filter ListEntriesFilter) ([]Entry, error) {
+	*store.fetches++
+	start := 0
+	if filter.BeforeEntryID != nil {
+		for index, entry := range store.pages {
+			if entry.EntryID() == *filter.BeforeEntryID {
+				start = index + 1
+			}
+		}
+	}
+	return store.pages[start:min(start+limit, len(store.pages))], nil
+}
+
+func TestListAccountEntriesPagesByEntryCursor(test *testing.T) {
+	test.Parallel()
+	stub := newStubStore(test, 0)
+	for index := range verifyAccountPageSize + 1 {
+		entryInput, err := NewEntryInput(stub.accountID, EntryGrant, 1, nil, nil, mustIdempotencyKey(test, fmt.Sprintf("grant-%d", index)), 0, mustMetadata(test, "{}"), 100)
+		if err != nil {
+			test.Fatalf("entry input: %v", err)
+		}
+		stub.entries = append(stub.entries, entryInput)
+	}
+	pages, err := stub.ListEntries(context.Background(), stub.accountID, 0, 0, ListEntriesFilter{})
+	if err != nil {
+		test.Fatalf("list entries: %v", err)
+	}
+	fetches := 0
+	entries, err := listAccountEntries(context.Background(), pagedEntriesStore{stubStore: stub, pages: pages, fetches: &fetches}, stub.accountID, ListEntriesFilter{})
+	if err != nil {
+		test.Fatalf("list account entries: %v", err)
+	}
+	if len(entries) != verifyAccountPageSize+1 || fetches != 2 {
+		test.Fatalf("expected %d entries over two pages, got %d over %d", verifyAccountPageSize+1, len(entries), fetches)
+	}
+}
+
+func TestForEachByCreationVisitsEachItemAsPagesAreRead(test *testing.T) {
+	test.Parallel()
+	type item struct {
+		key     string
+		created int64
+	}
+	var items []item
+	for index := range 3*verifyAccountPageSize + 7 {
+		items = append(items, item{key: fmt.Sprintf("item-%d", index), created: 10_000 - int64(index/7)})
+	}
+	for index := range 2 * verifyAccountPageSize {
+		items = append(items, item{key: fmt.Sprintf("burst-%d", index), created: 1_000})
+	}
+	fetches := 0
+	fetch := func(beforeUnixUTC int64, limit int) ([]item, error) {
+		fetches++
+		var page []item
+		for _, candidate := range items {
+			if beforeUnixUTC == 0 || candidate.created < beforeUnixUTC {
+				page = append(page, candidate)
+			}
+		}
+		if fetches%2 == 0 {
+			// Items created in the same second come back in no particular order.
+			for left, right := 0, len(page)-1; left < right; left, right = left+1, right-1 {
+				page[left], page[right] = page[right], page[left]
+			}
+			sort.SliceStable(page, func(left, right int) bool { return page[left].created > page[right].created })
+		}
+		return page[:min(limit, len(page))], nil
+	}
+	visited := make(map[string]int)
+	fetchesAtFirstVisit := 0
+	err := forEachByCreation(fetch, func(value item) string { return value.key }, func(value item) int64 { return value.created }, func(value item) error {
+		if len(visited) == 0 {
+			fetchesAtFirstVisit = fetches
+		}
+		visited[value.key]++
+		return nil
+	})
+	if err != nil {
+		test.Fatalf("forEachByCreation: %v", err)
+	}
+	if fetchesAtFirstVisit != 1 {
+		test.Fatalf("expected the first page to be visited before the next is read, got %d fetches", fetchesAtFirstVisit)
+	}
+	if len(visited) != len(items) {
+		test.Fatalf("expected %d items visited, got %d", len(items), len(visited))
+	}
+	for key, count := range visited {
+		if count != 1 {
+			test.Fatalf("expected %s to be visited once, got %d", key, count)
+		}
+	}
+}